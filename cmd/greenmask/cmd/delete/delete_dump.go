@@ -26,6 +26,7 @@ import (
 	"github.com/spf13/cobra"
 	gostr "github.com/xhit/go-str2duration/v2"
 
+	cmdInternals "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
 	pgDomains "github.com/greenmaskio/greenmask/internal/domains"
 	"github.com/greenmaskio/greenmask/internal/storages"
 	"github.com/greenmaskio/greenmask/internal/storages/builder"
@@ -237,28 +238,21 @@ func getSortedBackupWithStatuses(ctx context.Context, st storages.Storager) (*St
 		return nil, err
 	}
 	for _, backup := range backups {
-		status, md, err := dumpstatus.GetDumpStatusAndMetadata(ctx, backup)
+		d, err := getBackupWithStatus(ctx, backup)
 		if err != nil {
 			log.Warn().
 				Str("DumpId", backup.Dirname()).
 				Err(err).
 				Msg("unable to get dump status for dump")
 		}
-		d := Dump{
-			DumpId: backup.Dirname(),
-			Status: status,
-		}
-		if status == dumpstatus.DoneStatusName {
-			d.Date = md.StartedAt
-			d.Database = md.Header.DbName
-		}
+		status := d.Status
 		switch status {
 		case dumpstatus.DoneStatusName:
-			valid = append(valid, &d)
+			valid = append(valid, d)
 		case dumpstatus.FailedStatusName:
-			failed = append(failed, &d)
+			failed = append(failed, d)
 		case dumpstatus.UnknownOrFailedStatusName:
-			unknownOrFailed = append(unknownOrFailed, &d)
+			unknownOrFailed = append(unknownOrFailed, d)
 		}
 	}
 
@@ -281,6 +275,41 @@ func getSortedBackupWithStatuses(ctx context.Context, st storages.Storager) (*St
 	}, nil
 }
 
+// getBackupWithStatus - returns the dump with its status. The cluster dump is deleted with all its databases, so
+// only the cluster dump status is taken into account
+func getBackupWithStatus(ctx context.Context, backup storages.Storager) (*Dump, error) {
+	d := &Dump{
+		DumpId: backup.Dirname(),
+	}
+	isCluster, err := cmdInternals.IsClusterDump(ctx, backup)
+	if err != nil {
+		return d, err
+	}
+	if isCluster {
+		status, md, err := dumpstatus.GetClusterDumpStatusAndMetadata(ctx, backup)
+		d.Status = status
+		if err != nil {
+			return d, err
+		}
+		if status == dumpstatus.DoneStatusName {
+			d.Date = md.StartedAt
+			d.Database = "cluster"
+		}
+		return d, nil
+	}
+
+	status, md, err := dumpstatus.GetDumpStatusAndMetadata(ctx, backup)
+	d.Status = status
+	if err != nil {
+		return d, err
+	}
+	if status == dumpstatus.DoneStatusName {
+		d.Date = md.StartedAt
+		d.Database = md.Header.DbName
+	}
+	return d, nil
+}
+
 func deleteDumpById(ctx context.Context, st storages.Storager, d *Dump, dryRun bool) error {
 	if d.DumpId == "" {
 		panic("empty dump id")
@@ -17,18 +17,15 @@ package dump
 import (
 	"context"
 	"fmt"
-	"strconv"
-	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	cmdInternals "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
-	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
 	pgDomains "github.com/greenmaskio/greenmask/internal/domains"
-	"github.com/greenmaskio/greenmask/internal/storages/builder"
 	"github.com/greenmaskio/greenmask/internal/utils/logger"
+	"github.com/greenmaskio/greenmask/pkg/greenmask"
 )
 
 var (
@@ -42,25 +39,30 @@ var (
 
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
-			st, err := builder.GetStorage(ctx, &Config.Storage, &Config.Log)
-			if err != nil {
-				log.Fatal().Err(err).Msg("fatal")
-			}
-			st = st.SubStorage(strconv.FormatInt(time.Now().UnixMilli(), 10), true)
 
-			if Config.Common.TempDirectory == "" {
-				log.Fatal().Msg("common.tmp_dir cannot be empty")
+			if err := cmdInternals.SetSubsetSeedsOverrides(&Config.Dump, subsetSeeds); err != nil {
+				log.Fatal().Err(err).Msg("")
 			}
 
-			dump := cmdInternals.NewDump(Config, st, utils.DefaultTransformerRegistry)
-
-			if err := dump.Run(ctx); err != nil {
-				log.Fatal().Err(err).Msg("cannot make a backup")
+			if cluster && Config.Dump.Cluster == nil {
+				Config.Dump.Cluster = &pgDomains.Cluster{}
+			}
+			if rolePasswords {
+				if Config.Dump.Cluster == nil {
+					log.Fatal().Msg("--role-passwords is applicable only to the cluster dump: use it with --cluster")
+				}
+				Config.Dump.Cluster.RolePasswords = true
 			}
 
+			if _, err := greenmask.Dump(ctx, Config); err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
 		},
 	}
-	Config = pgDomains.NewConfig()
+	Config        = pgDomains.NewConfig()
+	subsetSeeds   []string
+	cluster       bool
+	rolePasswords bool
 )
 
 // TODO: Check how does work mixed options - use-list + tables, etc.
@@ -149,6 +151,24 @@ func init() {
 		"use pgzip compression instead of gzip",
 	)
 
+	Cmd.Flags().StringArrayVar(
+		&subsetSeeds, "subset-seeds", nil,
+		"seed the table subset from the file or storage:// object with the key values in the form schema.table=source",
+	)
+
+	Cmd.Flags().BoolVar(
+		&cluster, "cluster", false,
+		"dump the globals and all the databases (or dump.cluster.databases) under one dump ID",
+	)
+	Cmd.Flags().BoolVar(
+		&rolePasswords, "role-passwords", false, "dump the roles password hashes in the cluster mode",
+	)
+
+	Cmd.Flags().StringP("engine", "", "postgres", "database engine [postgres|mysql]")
+	if err := viper.BindPFlag("dump.engine", Cmd.Flags().Lookup("engine")); err != nil {
+		log.Fatal().Err(err).Msg("fatal")
+	}
+
 	// Connection options:
 	Cmd.Flags().StringP("dbname", "d", "postgres", "database to dump")
 	Cmd.Flags().StringP("host", "h", "/var/run/postgres", "database server host or socket directory")
@@ -0,0 +1,104 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	cmdInternals "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/export"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	pgDomains "github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/storages/builder"
+	"github.com/greenmaskio/greenmask/internal/utils/logger"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "export",
+		Short: "transform tables data and store it in storage as CSV, JSONL or Parquet files",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := logger.SetLogLevel(Config.Log.Level, Config.Log.Format); err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
+
+			if Config.Common.TempDirectory == "" {
+				log.Fatal().Msg("common.tmp_dir cannot be empty")
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			st, err := builder.GetStorage(ctx, &Config.Storage, &Config.Log)
+			if err != nil {
+				log.Fatal().Err(err).Msg("fatal")
+			}
+			st = st.SubStorage(strconv.FormatInt(time.Now().UnixMilli(), 10), true)
+
+			e, err := cmdInternals.NewExport(Config, st, utils.DefaultTransformerRegistry)
+			if err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
+
+			if err := e.Run(ctx); err != nil {
+				log.Fatal().Err(err).Msg("cannot make an export")
+			}
+		},
+	}
+	Config = pgDomains.NewConfig()
+)
+
+func init() {
+	formatFlagName := "format"
+	Cmd.Flags().String(
+		formatFlagName, export.ParquetFormat, "Format of the exported files. Possible values [csv|jsonl|parquet]",
+	)
+	flag := Cmd.Flags().Lookup(formatFlagName)
+	if err := viper.BindPFlag("export.format", flag); err != nil {
+		log.Fatal().Err(err).Msg("fatal")
+	}
+
+	compressFlagName := "compress"
+	Cmd.Flags().Bool(
+		compressFlagName, false, "Compress csv and jsonl files with gzip. Parquet files are always compressed with snappy",
+	)
+	flag = Cmd.Flags().Lookup(compressFlagName)
+	if err := viper.BindPFlag("export.compress", flag); err != nil {
+		log.Fatal().Err(err).Msg("fatal")
+	}
+
+	pgzipFlagName := "pgzip"
+	Cmd.Flags().Bool(
+		pgzipFlagName, false, "Use pgzip compression instead of gzip",
+	)
+	flag = Cmd.Flags().Lookup(pgzipFlagName)
+	if err := viper.BindPFlag("export.pgzip", flag); err != nil {
+		log.Fatal().Err(err).Msg("fatal")
+	}
+
+	maxRowsPerFileFlagName := "max-rows-per-file"
+	Cmd.Flags().Uint64(
+		maxRowsPerFileFlagName, 0, "Start the next file of the table after this many rows. 0 means no rollover",
+	)
+	flag = Cmd.Flags().Lookup(maxRowsPerFileFlagName)
+	if err := viper.BindPFlag("export.max_rows_per_file", flag); err != nil {
+		log.Fatal().Err(err).Msg("fatal")
+	}
+}
@@ -18,7 +18,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"slices"
 	"time"
 
 	"github.com/olekukonko/tablewriter"
@@ -26,10 +25,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/greenmaskio/greenmask/internal/domains"
-	"github.com/greenmaskio/greenmask/internal/storages"
-	"github.com/greenmaskio/greenmask/internal/storages/builder"
-	"github.com/greenmaskio/greenmask/internal/utils/dumpstatus"
 	"github.com/greenmaskio/greenmask/internal/utils/logger"
+	"github.com/greenmaskio/greenmask/pkg/greenmask"
 )
 
 var (
@@ -65,53 +62,40 @@ func SizePretty(b int64) string {
 func listDumps() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	st, err := builder.GetStorage(ctx, &Config.Storage, &Config.Log)
-	if err != nil {
-		log.Fatal().Err(err).Msg("")
-	}
-
-	_, dirs, err := st.ListDir(ctx)
+	dumps, err := greenmask.ListDumps(ctx, Config)
 	if err != nil {
 		return err
 	}
 
-	var data [][]string
-
-	for _, backup := range dirs {
-		dumpId := backup.Dirname()
-		if err = renderListItem(ctx, backup, &data); err != nil {
-			log.Warn().
-				Err(err).
-				Str("DumpId", dumpId).
-				Msg("unable to render list dump item")
-		}
-	}
-
-	slices.SortFunc(data, func(a, b []string) int {
-		if a[0] > b[0] {
-			return -1
-		}
-		return 1
-	})
-
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader([]string{"id", "date", "database", "size", "compressed size", "duration", "transformed", "status"})
-	table.AppendBulk(data)
+	table.AppendBulk(renderList(dumps))
 	table.Render()
 	return nil
 }
 
-func renderListItem(ctx context.Context, st storages.Storager, data *[][]string) error {
-	dumpId := st.Dirname()
-
-	status, metadata, err := dumpstatus.GetDumpStatusAndMetadata(ctx, st)
-	if err != nil {
-		return fmt.Errorf("failed to get status and metadata: %w", err)
+// renderList - renders the dumps in the order they are listed. The databases of the cluster dump are listed as
+// dumpId/database right after the cluster dump
+func renderList(dumps []*greenmask.DumpInfo) [][]string {
+	var data [][]string
+	for _, d := range dumps {
+		if d.IsCluster() {
+			data = append(data, renderClusterListItem(d))
+			for _, db := range d.Databases {
+				data = append(data, renderListItem(db))
+			}
+			continue
+		}
+		data = append(data, renderListItem(d))
 	}
+	return data
+}
 
-	var creationDate, dbName, size, compressedSize, duration, transformed string
-	transformed = "false"
-	if status == dumpstatus.DoneStatusName {
+func renderListItem(d *greenmask.DumpInfo) []string {
+	var creationDate, dbName, size, compressedSize, duration string
+	transformed := "false"
+	if d.Status == greenmask.DoneStatus {
+		metadata := d.Metadata
 		creationDate = metadata.Header.CreationDate.Format(time.RFC3339)
 		dbName = metadata.Header.DbName
 		size = SizePretty(metadata.OriginalSize)
@@ -123,15 +107,50 @@ func renderListItem(ctx context.Context, st storages.Storager, data *[][]string)
 		}
 	}
 
-	*data = append(*data, []string{
-		dumpId,
+	return []string{
+		d.Id,
 		creationDate,
 		dbName,
 		size,
 		compressedSize,
 		duration,
 		transformed,
-		status,
-	})
-	return nil
+		d.Status,
+	}
+}
+
+// renderClusterListItem - renders the cluster dump with the total size of the dumped databases
+func renderClusterListItem(d *greenmask.DumpInfo) []string {
+	var originalSize, compressedSize int64
+	transformed := "false"
+	for _, db := range d.Databases {
+		if db.Metadata == nil {
+			continue
+		}
+		originalSize += db.Metadata.OriginalSize
+		compressedSize += db.Metadata.CompressedSize
+		if len(db.Metadata.Transformers) > 0 {
+			transformed = "true"
+		}
+	}
+
+	clusterMetadata := d.ClusterMetadata
+	var size, compressed, duration string
+	if d.Status == greenmask.DoneStatus {
+		size = SizePretty(originalSize)
+		compressed = SizePretty(compressedSize)
+		diff := clusterMetadata.CompletedAt.Sub(clusterMetadata.StartedAt)
+		duration = time.Time{}.Add(diff).Format("15:04:05")
+	}
+
+	return []string{
+		d.Id,
+		clusterMetadata.StartedAt.Format(time.RFC3339),
+		fmt.Sprintf("cluster (%d databases)", len(clusterMetadata.Databases)),
+		size,
+		compressed,
+		duration,
+		transformed,
+		d.Status,
+	}
 }
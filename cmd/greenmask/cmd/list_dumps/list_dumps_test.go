@@ -0,0 +1,81 @@
+package list_dumps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/storage"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/greenmask"
+)
+
+func Test_renderList(t *testing.T) {
+	startedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	newMetadata := func(dbName string, size int64, transformed bool) *greenmask.Metadata {
+		md := &greenmask.Metadata{
+			StartedAt:      startedAt,
+			CompletedAt:    startedAt.Add(time.Minute),
+			OriginalSize:   size,
+			CompressedSize: size / 2,
+			Header:         storage.Header{CreationDate: startedAt, DbName: dbName},
+		}
+		if transformed {
+			md.Transformers = []*domains.Table{{Schema: "public", Name: "users"}}
+		}
+		return md
+	}
+	dumps := []*greenmask.DumpInfo{
+		{Id: "300", Status: greenmask.InProgressStatus},
+		{
+			Id:     "200",
+			Status: greenmask.DoneStatus,
+			ClusterMetadata: &greenmask.ClusterMetadata{
+				StartedAt:   startedAt,
+				CompletedAt: startedAt.Add(2 * time.Minute),
+				Databases:   []*storage.ClusterDatabase{{Name: "billing"}, {Name: "app"}},
+			},
+			Databases: []*greenmask.DumpInfo{
+				{Id: "200/billing", Status: greenmask.DoneStatus, Metadata: newMetadata("billing", 1024, false)},
+				{Id: "200/app", Status: greenmask.DoneStatus, Metadata: newMetadata("app", 2048, true)},
+			},
+		},
+		{Id: "100", Status: greenmask.DoneStatus, Metadata: newMetadata("app", 10, false)},
+	}
+
+	res := renderList(dumps)
+	require.Len(t, res, 5)
+	assert.Equal(t, []string{"300", "", "", "", "", "", "false", greenmask.InProgressStatus}, res[0])
+	// The cluster dump sums the sizes of its databases and is transformed if any database is transformed
+	assert.Equal(t, []string{
+		"200", "2024-01-01T10:00:00Z", "cluster (2 databases)", "3.0 KiB", "1.5 KiB", "00:02:00", "true",
+		greenmask.DoneStatus,
+	}, res[1])
+	assert.Equal(t, []string{
+		"200/billing", "2024-01-01T10:00:00Z", "billing", "1.0 KiB", "512 B", "00:01:00", "false", greenmask.DoneStatus,
+	}, res[2])
+	assert.Equal(t, []string{
+		"200/app", "2024-01-01T10:00:00Z", "app", "2.0 KiB", "1.0 KiB", "00:01:00", "true", greenmask.DoneStatus,
+	}, res[3])
+	assert.Equal(t, "100", res[4][0])
+}
+
+func Test_renderClusterListItem_notDone(t *testing.T) {
+	d := &greenmask.DumpInfo{
+		Id:     "200",
+		Status: greenmask.InProgressStatus,
+		ClusterMetadata: &greenmask.ClusterMetadata{
+			Databases: []*storage.ClusterDatabase{{Name: "billing"}, {Name: "app"}},
+		},
+		Databases: []*greenmask.DumpInfo{
+			{Id: "200/billing", Status: greenmask.InProgressStatus},
+			{Id: "200/app", Status: greenmask.InProgressStatus},
+		},
+	}
+	res := renderClusterListItem(d)
+	// The sizes and duration are not shown until the whole cluster dump is done
+	assert.Equal(t, []string{"", "", "", "false", greenmask.InProgressStatus}, res[3:])
+	assert.Equal(t, "cluster (2 databases)", res[2])
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mask_archive
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	cmdInternals "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	pgDomains "github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/storages/builder"
+	"github.com/greenmaskio/greenmask/internal/utils/logger"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "mask-archive [flags] archivePath",
+		Args:  cobra.ExactArgs(1),
+		Short: "transform data of the existing pg_dump archive (directory or custom format) and store it in storage",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := logger.SetLogLevel(Config.Log.Level, Config.Log.Format); err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			st, err := builder.GetStorage(ctx, &Config.Storage, &Config.Log)
+			if err != nil {
+				log.Fatal().Err(err).Msg("fatal")
+			}
+			st = st.SubStorage(strconv.FormatInt(time.Now().UnixMilli(), 10), true)
+
+			if Config.Common.TempDirectory == "" {
+				log.Fatal().Msg("common.tmp_dir cannot be empty")
+			}
+
+			if cmd.Flags().Changed("jobs") {
+				Config.Dump.PgDumpOptions.Jobs, _ = cmd.Flags().GetInt("jobs")
+			}
+			if cmd.Flags().Changed("pgzip") {
+				Config.Dump.PgDumpOptions.Pgzip, _ = cmd.Flags().GetBool("pgzip")
+			}
+			if Config.Dump.PgDumpOptions.Jobs < 1 {
+				Config.Dump.PgDumpOptions.Jobs = 1
+			}
+
+			ma := cmdInternals.NewMaskArchive(Config, st, utils.DefaultTransformerRegistry, args[0])
+
+			if err := ma.Run(ctx); err != nil {
+				log.Fatal().Err(err).Msg("cannot mask the archive")
+			}
+		},
+	}
+	Config = pgDomains.NewConfig()
+)
+
+func init() {
+	// The flags are not bound with viper since the same keys are bound by dump command flags
+	Cmd.Flags().IntP("jobs", "j", 1, "use this many parallel jobs to mask the archive")
+	Cmd.Flags().BoolP(
+		"pgzip", "", false,
+		"use pgzip compression instead of gzip",
+	)
+
+}
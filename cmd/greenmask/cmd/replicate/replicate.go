@@ -0,0 +1,90 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replicate
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	cmdInternals "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/replication"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	pgDomains "github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/storages/builder"
+	"github.com/greenmaskio/greenmask/internal/utils/logger"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "replicate",
+		Short: "take the masked snapshot and keep the target database up to date using the logical replication",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := logger.SetLogLevel(Config.Log.Level, Config.Log.Format); err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+			st, err := builder.GetStorage(ctx, &Config.Storage, &Config.Log)
+			if err != nil {
+				log.Fatal().Err(err).Msg("fatal")
+			}
+
+			if Config.Common.TempDirectory == "" {
+				log.Fatal().Msg("common.tmp_dir cannot be empty")
+			}
+
+			replicate := cmdInternals.NewReplicate(Config, st, utils.DefaultTransformerRegistry)
+			if err := replicate.Run(ctx); err != nil && ctx.Err() == nil {
+				log.Fatal().Err(err).Msg("replication error")
+			}
+		},
+	}
+	Config = pgDomains.NewConfig()
+)
+
+func init() {
+	slotNameFlagName := "slot-name"
+	Cmd.Flags().String(
+		slotNameFlagName, cmdInternals.DefaultReplicationSlotName, "name of the logical replication slot",
+	)
+	flag := Cmd.Flags().Lookup(slotNameFlagName)
+	if err := viper.BindPFlag("replicate.slot_name", flag); err != nil {
+		log.Fatal().Err(err).Msg("fatal")
+	}
+
+	publicationFlagName := "publication"
+	Cmd.Flags().String(publicationFlagName, "", "name of the publication on the source database")
+	flag = Cmd.Flags().Lookup(publicationFlagName)
+	if err := viper.BindPFlag("replicate.publication", flag); err != nil {
+		log.Fatal().Err(err).Msg("fatal")
+	}
+
+	statusIntervalFlagName := "status-interval"
+	Cmd.Flags().Duration(
+		statusIntervalFlagName, replication.DefaultStatusInterval,
+		"interval of the applied position reports to the source database",
+	)
+	flag = Cmd.Flags().Lookup(statusIntervalFlagName)
+	if err := viper.BindPFlag("replicate.status_interval", flag); err != nil {
+		log.Fatal().Err(err).Msg("fatal")
+	}
+}
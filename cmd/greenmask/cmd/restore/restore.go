@@ -17,23 +17,14 @@ package restore
 import (
 	"context"
 	"fmt"
-	"path"
-	"slices"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
-	"github.com/greenmaskio/greenmask/internal/storages"
-
-	cmdInternals "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
 	pgDomains "github.com/greenmaskio/greenmask/internal/domains"
-	"github.com/greenmaskio/greenmask/internal/storages/builder"
 	"github.com/greenmaskio/greenmask/internal/utils/logger"
-)
-
-const (
-	latestDumpName = "latest"
+	"github.com/greenmaskio/greenmask/pkg/greenmask"
 )
 
 var (
@@ -49,27 +40,7 @@ var (
 
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
-			st, err := builder.GetStorage(ctx, &Config.Storage, &Config.Log)
-			if err != nil {
-				log.Fatal().Err(err).Msg("fatal")
-			}
-
-			dumpId, err := getDumpId(ctx, st, args[0])
-			if err != nil {
-				log.Fatal().Err(err).Msg("")
-			}
-
-			st = st.SubStorage(dumpId, true)
-
-			restore := cmdInternals.NewRestore(
-				Config.Common.PgBinPath, st, &Config.Restore, Config.Restore.Scripts,
-				Config.Common.TempDirectory,
-			)
-
-			log.Info().
-				Str("dumpId", dumpId).
-				Msgf("restoring dump")
-			if err := restore.Run(ctx); err != nil {
+			if err := greenmask.Restore(ctx, Config, args[0]); err != nil {
 				log.Fatal().Err(err).Msg("fatal")
 			}
 		},
@@ -77,50 +48,6 @@ var (
 	Config = pgDomains.NewConfig()
 )
 
-func getDumpId(ctx context.Context, st storages.Storager, dumpId string) (string, error) {
-	if dumpId == latestDumpName {
-		var backupNames []string
-
-		_, dirs, err := st.ListDir(ctx)
-		if err != nil {
-			log.Fatal().Err(err).Msg("cannot walk through directory")
-		}
-		for _, dir := range dirs {
-			exists, err := dir.Exists(ctx, cmdInternals.MetadataJsonFileName)
-			if err != nil {
-				log.Fatal().Err(err).Msg("cannot check file existence")
-			}
-			if exists {
-				backupNames = append(backupNames, dir.Dirname())
-			}
-		}
-
-		slices.SortFunc(
-			backupNames, func(a, b string) int {
-				if a > b {
-					return -1
-				}
-				return 1
-			},
-		)
-		dumpId = backupNames[0]
-	} else {
-		exists, err := st.Exists(ctx, path.Join(dumpId, cmdInternals.MetadataJsonFileName))
-		if err != nil {
-			log.Fatal().
-				Err(err).
-				Msg("cannot check file existence")
-		}
-		if !exists {
-			log.Fatal().
-				Err(err).
-				Str("DumpId", dumpId).
-				Msg("dump with provided id is not found")
-		}
-	}
-	return dumpId, nil
-}
-
 // TODO: Options currently are not implemented:
 //  	* exit-on-error
 // 		* single-transaction
@@ -188,8 +115,37 @@ func init() {
 		"overriding-system-value", "", false,
 		"use OVERRIDING SYSTEM VALUE clause for INSERTs",
 	)
+	Cmd.Flags().BoolP(
+		"schema-tolerant", "", false,
+		"restore only the columns that exist in both dump and target table and fill the missing columns by "+
+			"defaults (requires --data-only)",
+	)
+	Cmd.Flags().BoolP(
+		"dead-letter", "", false,
+		"fall back from COPY to INSERTs when the COPY batch fails and write the rejected rows to the dead-letter "+
+			"files instead of failing the table",
+	)
+	Cmd.Flags().StringP(
+		"dead-letter-dir", "", "",
+		"local directory of the dead-letter files (default - dead_letter directory of the dump in the storage)",
+	)
 	Cmd.Flags().BoolP("no-blobs", "B", false, "exclude large objects from restoration (large objects will be created as empty placeholders)")
 
+	// Cluster dump options:
+	Cmd.Flags().StringSliceP(
+		"cluster-database", "", []string{}, "restore only the specified database(s) from the cluster dump",
+	)
+	Cmd.Flags().BoolP("no-globals", "", false, "do not restore roles and tablespaces from the cluster dump")
+	for flagName, key := range map[string]string{
+		"cluster-database": "databases",
+		"no-globals":       "no_globals",
+	} {
+		flag := Cmd.Flags().Lookup(flagName)
+		if err := viper.BindPFlag(fmt.Sprintf("%s.%s", "restore.cluster", key), flag); err != nil {
+			log.Fatal().Err(err).Msg("fatal")
+		}
+	}
+
 	// Connection options:
 	Cmd.Flags().StringP("host", "h", "/var/run/postgres", "database server host or socket directory")
 	Cmd.Flags().IntP("port", "p", 5432, "database server port number")
@@ -205,7 +161,7 @@ func init() {
 		"strict-names", "use-set-session-authorization", "inserts", "on-conflict-do-nothing", "restore-in-order",
 		"pgzip", "batch-size", "overriding-system-value", "superuser", "use-session-replication-role-replica",
 
-		"host", "port", "username", "no-blobs",
+		"host", "port", "username", "no-blobs", "schema-tolerant", "dead-letter", "dead-letter-dir",
 	} {
 		flag := Cmd.Flags().Lookup(flagName)
 		if err := viper.BindPFlag(fmt.Sprintf("%s.%s", "restore.pg_restore_options", flagName), flag); err != nil {
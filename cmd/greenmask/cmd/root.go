@@ -27,12 +27,17 @@ import (
 
 	"github.com/greenmaskio/greenmask/cmd/greenmask/cmd/delete"
 	"github.com/greenmaskio/greenmask/cmd/greenmask/cmd/dump"
+	"github.com/greenmaskio/greenmask/cmd/greenmask/cmd/export"
 	"github.com/greenmaskio/greenmask/cmd/greenmask/cmd/list_dumps"
 	"github.com/greenmaskio/greenmask/cmd/greenmask/cmd/list_transformers"
+	"github.com/greenmaskio/greenmask/cmd/greenmask/cmd/mask_archive"
+	"github.com/greenmaskio/greenmask/cmd/greenmask/cmd/replicate"
 	"github.com/greenmaskio/greenmask/cmd/greenmask/cmd/restore"
 	"github.com/greenmaskio/greenmask/cmd/greenmask/cmd/show_dump"
 	"github.com/greenmaskio/greenmask/cmd/greenmask/cmd/show_transformer"
+	"github.com/greenmaskio/greenmask/cmd/greenmask/cmd/subset"
 	"github.com/greenmaskio/greenmask/cmd/greenmask/cmd/validate"
+	"github.com/greenmaskio/greenmask/cmd/greenmask/cmd/vault"
 	pgDomains "github.com/greenmaskio/greenmask/internal/domains"
 	configUtils "github.com/greenmaskio/greenmask/internal/utils/config"
 )
@@ -99,6 +104,11 @@ func init() {
 	RootCmd.AddCommand(list_transformers.Cmd)
 	RootCmd.AddCommand(validate.Cmd)
 	RootCmd.AddCommand(show_transformer.Cmd)
+	RootCmd.AddCommand(export.Cmd)
+	RootCmd.AddCommand(mask_archive.Cmd)
+	RootCmd.AddCommand(subset.Cmd)
+	RootCmd.AddCommand(replicate.Cmd)
+	RootCmd.AddCommand(vault.Cmd)
 
 	if err := viper.BindPFlag("log.format", RootCmd.PersistentFlags().Lookup("log-format")); err != nil {
 		log.Fatal().Err(err).Msg("")
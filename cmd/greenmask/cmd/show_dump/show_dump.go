@@ -35,6 +35,7 @@ const (
 var (
 	Config = pgDomains.NewConfig()
 	format string
+	report bool
 )
 
 var (
@@ -68,7 +69,11 @@ var (
 					if err != nil {
 						log.Fatal().Err(err).Msg("cannot check file existence")
 					}
-					if exists {
+					isCluster, err := cmdInternals.IsClusterDump(ctx, dir)
+					if err != nil {
+						log.Fatal().Err(err).Msg("cannot check file existence")
+					}
+					if exists || isCluster {
 						backupNames = append(backupNames, dir.Dirname())
 					}
 				}
@@ -86,11 +91,36 @@ var (
 				if err != nil {
 					log.Fatal().Err(err).Msg("cannot check file existence")
 				}
-				if !exists {
+				clusterExists, err := st.Exists(ctx, path.Join(dumpId, cmdInternals.ClusterMetadataJsonFileName))
+				if err != nil {
+					log.Fatal().Err(err).Msg("cannot check file existence")
+				}
+				if !exists && !clusterExists {
 					log.Fatal().Msgf("choose another dump %s is failed", dumpId)
 				}
 			}
 
+			isCluster, err := cmdInternals.IsClusterDump(ctx, st.SubStorage(dumpId, true))
+			if err != nil {
+				log.Fatal().Err(err).Msg("cannot check file existence")
+			}
+			if isCluster {
+				if report {
+					log.Fatal().Msg("the report is stored per database: use dumpId/database to show it")
+				}
+				if err := cmdInternals.ShowClusterDump(ctx, st, dumpId, format); err != nil {
+					log.Fatal().Err(err).Msg("")
+				}
+				return
+			}
+
+			if report {
+				if err := cmdInternals.ShowDumpReport(ctx, st, dumpId, format); err != nil {
+					log.Fatal().Err(err).Msg("")
+				}
+				return
+			}
+
 			if err := cmdInternals.ShowDump(ctx, st, dumpId, format); err != nil {
 				log.Fatal().Err(err).Msg("")
 			}
@@ -99,5 +129,6 @@ var (
 )
 
 func init() {
-	Cmd.Flags().StringVarP(&format, "format", "f", "text", "output format [text|yaml|json]")
+	Cmd.Flags().StringVarP(&format, "format", "f", "text", "output format [text|yaml|json], with --report [text|json|html]")
+	Cmd.Flags().BoolVarP(&report, "report", "", false, "show masking audit report instead of the dump metadata")
 }
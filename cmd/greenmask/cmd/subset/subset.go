@@ -0,0 +1,92 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subset
+
+import (
+	"context"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	cmdInternals "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/cmd/explain_utils"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	pgDomains "github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/utils/logger"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "subset",
+		Short: "database subset tools",
+	}
+	explainCmd = &cobra.Command{
+		Use:   "explain",
+		Short: "print the subset plan, generated queries and row counts without dumping",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := logger.SetLogLevel(Config.Log.Level, Config.Log.Format); err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if err := cmdInternals.SetSubsetSeedsOverrides(&Config.Dump, subsetSeeds); err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
+
+			se, err := cmdInternals.NewSubsetExplain(Config, utils.DefaultTransformerRegistry, os.Stdout)
+			if err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
+
+			if err := se.Run(ctx); err != nil {
+				log.Fatal().Err(err).Msg("cannot explain subset")
+			}
+		},
+	}
+	Config      = pgDomains.NewConfig()
+	subsetSeeds []string
+)
+
+func init() {
+	formatFlagName := "format"
+	explainCmd.Flags().String(
+		formatFlagName, explain_utils.TextFormat, "Format of output. Possible values [text|json|dot]",
+	)
+	flag := explainCmd.Flags().Lookup(formatFlagName)
+	if err := viper.BindPFlag("subset_explain.format", flag); err != nil {
+		log.Fatal().Err(err).Msg("fatal")
+	}
+
+	countsFlagName := "counts"
+	explainCmd.Flags().String(
+		countsFlagName, cmdInternals.SubsetExplainCountsEstimated,
+		"Rows counting of the subsetted tables. Possible values [none|estimated|exact]",
+	)
+	flag = explainCmd.Flags().Lookup(countsFlagName)
+	if err := viper.BindPFlag("subset_explain.counts", flag); err != nil {
+		log.Fatal().Err(err).Msg("fatal")
+	}
+
+	explainCmd.Flags().StringArrayVar(
+		&subsetSeeds, "subset-seeds", nil,
+		"seed the table subset from the file or storage:// object with the key values in the form schema.table=source",
+	)
+
+	Cmd.AddCommand(explainCmd)
+}
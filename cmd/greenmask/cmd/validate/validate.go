@@ -23,10 +23,9 @@ import (
 	"github.com/spf13/viper"
 
 	cmdInternals "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
-	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
 	"github.com/greenmaskio/greenmask/internal/domains"
-	"github.com/greenmaskio/greenmask/internal/storages/builder"
 	"github.com/greenmaskio/greenmask/internal/utils/logger"
+	"github.com/greenmaskio/greenmask/pkg/greenmask"
 )
 
 var (
@@ -43,42 +42,9 @@ func run(cmd *cobra.Command, args []string) {
 		log.Err(err).Msg("")
 	}
 
-	if Config.Common.TempDirectory == "" {
-		log.Fatal().Msg("common.tmp_dir cannot be empty")
-	}
-
-	if Config.Validate.RowsLimit <= 0 {
-		log.Fatal().
-			Msgf("--rows-limit must be greater than 0 got %d", Config.Validate.RowsLimit)
-	}
-
-	if Config.Validate.Format != cmdInternals.JsonFormat &&
-		Config.Validate.Format != cmdInternals.TextFormat {
-		log.Fatal().
-			Str("RequestedFormat", Config.Validate.Format).
-			Msg("unknown --format value")
-	}
-
-	if Config.Validate.TableFormat != cmdInternals.VerticalTableFormat &&
-		Config.Validate.TableFormat != cmdInternals.HorizontalTableFormat {
-		log.Fatal().
-			Str("RequestedTableFormat", Config.Validate.TableFormat).
-			Msg("unknown --table-format value")
-	}
-
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	st, err := builder.GetStorage(ctx, &Config.Storage, &Config.Log)
-	if err != nil {
-		log.Fatal().Err(err).Msg("fatal")
-	}
-
-	validate, err := cmdInternals.NewValidate(Config, utils.DefaultTransformerRegistry, st)
-	if err != nil {
-		log.Fatal().Err(err).Msg("")
-	}
-
-	exitCode, err := validate.Run(ctx)
+	exitCode, err := greenmask.Validate(ctx, Config)
 	if err != nil {
 		log.Fatal().Err(err).Msg("")
 	}
@@ -169,4 +135,22 @@ func init() {
 		log.Fatal().Err(err).Msg("fatal")
 	}
 
+	kAnonymityFlagName := "k-anonymity"
+	Cmd.Flags().Int(
+		kAnonymityFlagName, 0, "Report equivalence classes of validate.k_anonymity.tables quasi-identifiers smaller than k",
+	)
+	flag = Cmd.Flags().Lookup(kAnonymityFlagName)
+	if err := viper.BindPFlag("validate.k_anonymity.k", flag); err != nil {
+		log.Fatal().Err(err).Msg("fatal")
+	}
+
+	lDiversityFlagName := "l-diversity"
+	Cmd.Flags().Int(
+		lDiversityFlagName, 0, "Report equivalence classes with less than l distinct values of sensitive columns",
+	)
+	flag = Cmd.Flags().Lookup(lDiversityFlagName)
+	if err := viper.BindPFlag("validate.k_anonymity.l", flag); err != nil {
+		log.Fatal().Err(err).Msg("fatal")
+	}
+
 }
@@ -0,0 +1,115 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/utils/logger"
+	"github.com/greenmaskio/greenmask/internal/vault"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "vault",
+		Short: "work with the vault of the original to fake values mappings",
+	}
+	lookupCmd = &cobra.Command{
+		Use:   "lookup",
+		Args:  cobra.NoArgs,
+		Short: "find the fake value by the original one or the original values by the fake one",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := logger.SetLogLevel(Config.Log.Level, Config.Log.Format); err != nil {
+				log.Err(err).Msg("")
+			}
+
+			if err := lookup(cmd); err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
+		},
+	}
+	Config    = domains.NewConfig()
+	namespace string
+	original  string
+	fake      string
+)
+
+func lookup(cmd *cobra.Command) error {
+	originalSet := cmd.Flags().Changed("original")
+	fakeSet := cmd.Flags().Changed("fake")
+	if originalSet == fakeSet {
+		return errors.New("exactly one of --original and --fake must be set")
+	}
+	if Config.Dump.Vault == nil {
+		return errors.New("dump.vault is not configured")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v, err := vault.Open(ctx, Config.Dump.Vault, true)
+	if err != nil {
+		return fmt.Errorf("cannot open vault: %w", err)
+	}
+	defer func() {
+		if err := v.Close(); err != nil {
+			log.Warn().Err(err).Msg("error closing vault")
+		}
+	}()
+
+	if originalSet {
+		log.Info().Str("Namespace", namespace).Msg("vault lookup by original value")
+		res, ok, err := v.Lookup(ctx, namespace, []byte(original))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("original value is not found")
+		}
+		_, err = fmt.Fprintln(os.Stdout, string(res))
+		return err
+	}
+
+	log.Info().Str("Namespace", namespace).Msg("vault lookup by fake value")
+	res, err := v.Reverse(ctx, namespace, []byte(fake))
+	if err != nil {
+		return err
+	}
+	if len(res) == 0 {
+		return errors.New("fake value is not found")
+	}
+	for _, o := range res {
+		if _, err = fmt.Fprintln(os.Stdout, string(o)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	lookupCmd.Flags().StringVar(&namespace, "namespace", "", "vault namespace of the transformer")
+	lookupCmd.Flags().StringVar(&original, "original", "", "original value to find the fake value of")
+	lookupCmd.Flags().StringVar(&fake, "fake", "", "fake value to find the original values of")
+	if err := lookupCmd.MarkFlagRequired("namespace"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	Cmd.AddCommand(lookupCmd)
+}
@@ -0,0 +1,254 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+
+	"github.com/greenmaskio/greenmask/internal/db/mysql/client"
+	"github.com/greenmaskio/greenmask/internal/db/mysql/rowcodec"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const (
+	tablesQuery = `
+		SELECT TABLE_NAME, IFNULL(DATA_LENGTH, 0)
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE'
+		ORDER BY TABLE_NAME;
+	`
+
+	// columnsQuery - the generated columns are skipped because their values cannot be inserted
+	columnsQuery = `
+		SELECT TABLE_NAME, COLUMN_NAME, DATA_TYPE, COLUMN_TYPE, IS_NULLABLE, IFNULL(CHARACTER_MAXIMUM_LENGTH, -1)
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND EXTRA NOT LIKE '%GENERATED%'
+		ORDER BY TABLE_NAME, ORDINAL_POSITION;
+	`
+
+	primaryKeysQuery = `
+		SELECT TABLE_NAME, COLUMN_NAME
+		FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = DATABASE() AND CONSTRAINT_NAME = 'PRIMARY'
+		ORDER BY TABLE_NAME, ORDINAL_POSITION;
+	`
+
+	foreignKeysQuery = `
+		SELECT k.TABLE_NAME, k.CONSTRAINT_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_SCHEMA, k.REFERENCED_TABLE_NAME,
+		       k.REFERENCED_COLUMN_NAME, c.IS_NULLABLE
+		FROM information_schema.KEY_COLUMN_USAGE k
+		         JOIN information_schema.COLUMNS c ON c.TABLE_SCHEMA = k.TABLE_SCHEMA AND c.TABLE_NAME = k.TABLE_NAME AND
+		                                              c.COLUMN_NAME = k.COLUMN_NAME
+		WHERE k.TABLE_SCHEMA = DATABASE() AND k.REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY k.TABLE_NAME, k.CONSTRAINT_NAME, k.ORDINAL_POSITION;
+	`
+)
+
+// Schema - the introspected MySQL tables. The tables get the synthetic oids, so they might be used by the subset
+// graph and the runtime context in the same way as the PostgreSQL tables
+type Schema struct {
+	Tables []*entries.Table
+	// Kinds - the columns value kinds by the table oid
+	Kinds map[toolkit.Oid][]rowcodec.Kind
+	// References - the foreign keys by the oid of the table that owns them
+	References map[toolkit.Oid][]*toolkit.Reference
+}
+
+// Introspect - fetches the tables, columns, primary and foreign keys of the database
+func Introspect(ctx context.Context, c *client.Client, opts *client.Options) (*Schema, error) {
+	var rows [4][][]string
+	for idx, q := range []string{tablesQuery, columnsQuery, primaryKeysQuery, foreignKeysQuery} {
+		res, err := c.Query(ctx, q)
+		if err != nil {
+			return nil, fmt.Errorf("cannot introspect the database: %w", err)
+		}
+		rows[idx] = res
+	}
+	return buildSchema(opts, rows[0], rows[1], rows[2], rows[3])
+}
+
+func buildSchema(opts *client.Options, tableRows, columnRows, pkRows, fkRows [][]string) (*Schema, error) {
+	typeMap := pgtype.NewMap()
+	s := &Schema{
+		Kinds:      make(map[toolkit.Oid][]rowcodec.Kind),
+		References: make(map[toolkit.Oid][]*toolkit.Reference),
+	}
+	tablesByName := make(map[string]*entries.Table)
+	for _, row := range tableRows {
+		if len(row) != 2 {
+			return nil, fmt.Errorf("unexpected tables query row %v", row)
+		}
+		if !opts.IsTableIncluded(row[0]) {
+			continue
+		}
+		size, err := strconv.ParseInt(row[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse size of table %s: %w", row[0], err)
+		}
+		table := &entries.Table{
+			Table: &toolkit.Table{
+				Schema: opts.Database,
+				Name:   row[0],
+				Oid:    toolkit.Oid(len(s.Tables) + 1),
+				Kind:   "r",
+				Size:   size,
+			},
+			RelKind: 'r',
+		}
+		s.Tables = append(s.Tables, table)
+		tablesByName[table.Name] = table
+	}
+
+	for _, row := range columnRows {
+		if len(row) != 6 {
+			return nil, fmt.Errorf("unexpected columns query row %v", row)
+		}
+		table, ok := tablesByName[row[0]]
+		if !ok {
+			continue
+		}
+		typeName, kind := MapType(row[2], row[3])
+		idx := len(table.Columns)
+		column := &toolkit.Column{
+			Idx:               idx,
+			Name:              row[1],
+			TypeName:          typeName,
+			CanonicalTypeName: typeName,
+			Num:               toolkit.AttNum(idx + 1),
+			NotNull:           row[4] == "NO",
+			Length:            -1,
+			TypeLength:        -1,
+		}
+		if t, ok := typeMap.TypeForName(typeName); ok {
+			column.TypeOid = toolkit.Oid(t.OID)
+		}
+		if l, ok := typeLengths[typeName]; ok {
+			column.TypeLength = l
+		}
+		if typeName == "varchar" || typeName == "bpchar" {
+			if l, err := strconv.Atoi(row[5]); err == nil && l >= 0 {
+				// VARHDRSZ is added to the length as it is done in atttypmod
+				column.Length = l + 4
+			}
+		}
+		table.Columns = append(table.Columns, column)
+		s.Kinds[table.Oid] = append(s.Kinds[table.Oid], kind)
+	}
+
+	for _, row := range pkRows {
+		if len(row) != 2 {
+			return nil, fmt.Errorf("unexpected primary keys query row %v", row)
+		}
+		if table, ok := tablesByName[row[0]]; ok {
+			table.PrimaryKey = append(table.PrimaryKey, row[1])
+		}
+	}
+
+	if err := setReferences(s, opts.Database, tablesByName, fkRows); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// foreignKey - the foreign key columns with the referenced columns
+type foreignKey struct {
+	table             *entries.Table
+	referencedTable   string
+	columns           []string
+	referencedColumns []string
+	isNullable        bool
+}
+
+// setReferences - builds the references from the foreign keys. The subset graph joins the tables by the primary
+// key of the referenced table, so the foreign keys that refer to the other unique keys or the tables of the other
+// databases are skipped
+func setReferences(s *Schema, database string, tablesByName map[string]*entries.Table, fkRows [][]string) error {
+	var fks []*foreignKey
+	fksByName := make(map[string]*foreignKey)
+	for _, row := range fkRows {
+		if len(row) != 7 {
+			return fmt.Errorf("unexpected foreign keys query row %v", row)
+		}
+		table, ok := tablesByName[row[0]]
+		if !ok {
+			continue
+		}
+		if row[3] != database {
+			log.Debug().
+				Str("Table", row[0]).
+				Str("ForeignKey", row[1]).
+				Msg("foreign key refers to the table of the other database: it is skipped")
+			continue
+		}
+		key := row[0] + "." + row[1]
+		fk, ok := fksByName[key]
+		if !ok {
+			fk = &foreignKey{
+				table:           table,
+				referencedTable: row[4],
+			}
+			fksByName[key] = fk
+			fks = append(fks, fk)
+		}
+		fk.columns = append(fk.columns, row[2])
+		fk.referencedColumns = append(fk.referencedColumns, row[5])
+		fk.isNullable = fk.isNullable || row[6] == "YES"
+	}
+
+	for _, fk := range fks {
+		referencedTable, ok := tablesByName[fk.referencedTable]
+		if !ok {
+			continue
+		}
+		columns, ok := alignWithPrimaryKey(fk, referencedTable.PrimaryKey)
+		if !ok {
+			log.Debug().
+				Str("Table", fk.table.Name).
+				Str("ReferencedTable", fk.referencedTable).
+				Msg("foreign key does not refer to the primary key: it is not used for subset")
+			continue
+		}
+		s.References[fk.table.Oid] = append(s.References[fk.table.Oid], &toolkit.Reference{
+			Schema:         referencedTable.Schema,
+			Name:           referencedTable.Name,
+			ReferencedKeys: columns,
+			IsNullable:     fk.isNullable,
+		})
+	}
+	return nil
+}
+
+// alignWithPrimaryKey - returns the foreign key columns in the order of the referenced primary key columns
+func alignWithPrimaryKey(fk *foreignKey, pk []string) ([]string, bool) {
+	if len(pk) == 0 || len(pk) != len(fk.referencedColumns) {
+		return nil, false
+	}
+	res := make([]string, 0, len(pk))
+	for _, pkColumn := range pk {
+		idx := slices.Index(fk.referencedColumns, pkColumn)
+		if idx == -1 {
+			return nil, false
+		}
+		res = append(res, fk.columns[idx])
+	}
+	return res, true
+}
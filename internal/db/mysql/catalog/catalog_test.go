@@ -0,0 +1,54 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/mysql/client"
+	"github.com/greenmaskio/greenmask/internal/db/mysql/rowcodec"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestBuildSchema(t *testing.T) {
+	opts := &client.Options{Database: "shop", ExcludeTables: []string{"audit"}}
+	tableRows := [][]string{{"audit", "10"}, {"orders", "2048"}, {"users", "1024"}}
+	columnRows := [][]string{
+		{"audit", "id", "int", "int", "NO", "-1"},
+		{"orders", "id", "bigint", "bigint", "NO", "-1"},
+		{"orders", "user_id", "int", "int", "YES", "-1"},
+		{"orders", "paid", "tinyint", "tinyint(1)", "NO", "-1"},
+		{"users", "id", "int", "int", "NO", "-1"},
+		{"users", "email", "varchar", "varchar(255)", "NO", "255"},
+	}
+	pkRows := [][]string{{"orders", "id"}, {"users", "id"}}
+	fkRows := [][]string{
+		{"orders", "orders_users_fk", "user_id", "shop", "users", "id", "YES"},
+		{"orders", "orders_other_db_fk", "user_id", "crm", "users", "id", "YES"},
+	}
+
+	s, err := buildSchema(opts, tableRows, columnRows, pkRows, fkRows)
+	require.NoError(t, err)
+	require.Len(t, s.Tables, 2)
+
+	orders, users := s.Tables[0], s.Tables[1]
+	assert.Equal(t, "shop", orders.Schema)
+	assert.Equal(t, "orders", orders.Name)
+	assert.Equal(t, int64(2048), orders.Size)
+	assert.Equal(t, []string{"id"}, orders.PrimaryKey)
+	require.Len(t, orders.Columns, 3)
+	assert.Equal(t, "int8", orders.Columns[0].TypeName)
+	assert.True(t, orders.Columns[0].NotNull)
+	assert.False(t, orders.Columns[1].NotNull)
+	assert.Equal(t, []rowcodec.Kind{rowcodec.TextKind, rowcodec.TextKind, rowcodec.BoolKind}, s.Kinds[orders.Oid])
+	assert.Equal(t, 259, users.Columns[1].Length)
+
+	require.Len(t, s.References[orders.Oid], 1)
+	assert.Equal(t, &toolkit.Reference{
+		Schema:         "shop",
+		Name:           "users",
+		ReferencedKeys: []string{"user_id"},
+		IsNullable:     true,
+	}, s.References[orders.Oid][0])
+}
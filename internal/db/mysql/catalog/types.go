@@ -0,0 +1,93 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"strings"
+
+	"github.com/greenmaskio/greenmask/internal/db/mysql/rowcodec"
+)
+
+// typeLengths - pg_type.typlen of the fixed size PostgreSQL types the MySQL types are mapped to. The other types
+// are considered as varlena
+var typeLengths = map[string]int{
+	"bool":      1,
+	"int2":      2,
+	"int4":      4,
+	"int8":      8,
+	"float4":    4,
+	"float8":    8,
+	"date":      4,
+	"time":      8,
+	"timestamp": 8,
+}
+
+// MapType - returns the PostgreSQL type name which text representation is compatible with the MySQL type and the
+// value kind. The transformers work with the column as with the column of the PostgreSQL type. The unknown types are
+// mapped to text
+func MapType(dataType, columnType string) (string, rowcodec.Kind) {
+	dataType = strings.ToLower(dataType)
+	columnType = strings.ToLower(columnType)
+	unsigned := strings.Contains(columnType, "unsigned")
+	switch dataType {
+	case "tinyint":
+		if strings.HasPrefix(columnType, "tinyint(1)") {
+			return "bool", rowcodec.BoolKind
+		}
+		return "int2", rowcodec.TextKind
+	case "smallint":
+		if unsigned {
+			return "int4", rowcodec.TextKind
+		}
+		return "int2", rowcodec.TextKind
+	case "mediumint":
+		return "int4", rowcodec.TextKind
+	case "int", "integer":
+		if unsigned {
+			return "int8", rowcodec.TextKind
+		}
+		return "int4", rowcodec.TextKind
+	case "bigint":
+		if unsigned {
+			return "numeric", rowcodec.TextKind
+		}
+		return "int8", rowcodec.TextKind
+	case "decimal", "numeric":
+		return "numeric", rowcodec.TextKind
+	case "float":
+		return "float4", rowcodec.TextKind
+	case "double", "real":
+		return "float8", rowcodec.TextKind
+	case "year":
+		return "int2", rowcodec.TextKind
+	case "date":
+		return "date", rowcodec.TextKind
+	case "datetime", "timestamp":
+		return "timestamp", rowcodec.TextKind
+	case "time":
+		return "time", rowcodec.TextKind
+	case "char":
+		return "bpchar", rowcodec.TextKind
+	case "varchar":
+		return "varchar", rowcodec.TextKind
+	case "json":
+		return "json", rowcodec.TextKind
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bit",
+		"geometry", "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon",
+		"geometrycollection", "geomcollection":
+		return "bytea", rowcodec.BinaryKind
+	}
+	return "text", rowcodec.TextKind
+}
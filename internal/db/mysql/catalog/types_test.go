@@ -0,0 +1,36 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/greenmaskio/greenmask/internal/db/mysql/rowcodec"
+)
+
+func TestMapType(t *testing.T) {
+	tests := []struct {
+		dataType     string
+		columnType   string
+		expectedType string
+		expectedKind rowcodec.Kind
+	}{
+		{dataType: "tinyint", columnType: "tinyint(1)", expectedType: "bool", expectedKind: rowcodec.BoolKind},
+		{dataType: "tinyint", columnType: "tinyint(4)", expectedType: "int2", expectedKind: rowcodec.TextKind},
+		{dataType: "int", columnType: "int(11)", expectedType: "int4", expectedKind: rowcodec.TextKind},
+		{dataType: "int", columnType: "int unsigned", expectedType: "int8", expectedKind: rowcodec.TextKind},
+		{dataType: "bigint", columnType: "bigint unsigned", expectedType: "numeric", expectedKind: rowcodec.TextKind},
+		{dataType: "datetime", columnType: "datetime(6)", expectedType: "timestamp", expectedKind: rowcodec.TextKind},
+		{dataType: "varchar", columnType: "varchar(255)", expectedType: "varchar", expectedKind: rowcodec.TextKind},
+		{dataType: "enum", columnType: "enum('a','b')", expectedType: "text", expectedKind: rowcodec.TextKind},
+		{dataType: "longblob", columnType: "longblob", expectedType: "bytea", expectedKind: rowcodec.BinaryKind},
+		{dataType: "JSON", columnType: "json", expectedType: "json", expectedKind: rowcodec.TextKind},
+	}
+	for _, tt := range tests {
+		t.Run(tt.columnType, func(t *testing.T) {
+			typeName, kind := MapType(tt.dataType, tt.columnType)
+			assert.Equal(t, tt.expectedType, typeName)
+			assert.Equal(t, tt.expectedKind, kind)
+		})
+	}
+}
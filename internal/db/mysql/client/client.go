@@ -0,0 +1,221 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/greenmaskio/greenmask/internal/db/mysql/rowcodec"
+)
+
+const (
+	MysqlExecutable     = "mysql"
+	MysqldumpExecutable = "mysqldump"
+)
+
+const (
+	defaultCharacterSet = "utf8mb4"
+	passwordEnvName     = "MYSQL_PWD"
+	// maxStderrSize - the stderr size that is kept for the error message
+	maxStderrSize = 4096
+)
+
+// Client - runs the mysql and mysqldump utilities with the connection options
+type Client struct {
+	opts *Options
+}
+
+func NewClient(opts *Options) *Client {
+	return &Client{
+		opts: opts,
+	}
+}
+
+// Query - runs the query and returns the unescaped rows. The NULL values are returned as NULL string, so the
+// queries must use IFNULL or QUOTE for the nullable columns
+func (c *Client) Query(ctx context.Context, query string) ([][]string, error) {
+	stdout := &bytes.Buffer{}
+	if err := c.Exec(ctx, strings.NewReader(query), stdout); err != nil {
+		return nil, err
+	}
+	var res [][]string
+	for _, line := range bytes.Split(stdout.Bytes(), []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		fields := rowcodec.SplitBatchLine(line)
+		row := make([]string, 0, len(fields))
+		for _, f := range fields {
+			row = append(row, string(f))
+		}
+		res = append(res, row)
+	}
+	return res, nil
+}
+
+// Exec - runs the script from stdin in the batch mode and writes the result rows without the column names to
+// stdout
+func (c *Client) Exec(ctx context.Context, stdin io.Reader, stdout io.Writer) error {
+	cmd := c.command(ctx, MysqlExecutable, c.mysqlArgs()...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	return run(cmd)
+}
+
+// Stream - runs the script in the batch mode and returns the result reader. The rows are not buffered by the
+// utility and the output is flushed after each statement. The wait function must be called when the result is read
+func (c *Client) Stream(ctx context.Context, script string) (io.Reader, func() error, error) {
+	cmd := c.command(ctx, MysqlExecutable, append(c.mysqlArgs(), "--unbuffered")...)
+	cmd.Stdin = strings.NewReader(script)
+	stderr := newLimitedBuffer(maxStderrSize)
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot get stdout pipe: %w", err)
+	}
+	log.Debug().Str("Executable", cmd.Path).Msg("running")
+	if err = cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("cannot start %s: %w", cmd.Path, err)
+	}
+	wait := func() error {
+		if err := cmd.Wait(); err != nil {
+			return commandError(cmd, err, stderr)
+		}
+		return nil
+	}
+	return stdout, wait, nil
+}
+
+// Open - opens the session that executes the statements one by one until it is closed. It is used for the
+// statements whose effect lasts until the session end, for instance the locks
+func (c *Client) Open(ctx context.Context) (*Session, error) {
+	cmd := c.command(ctx, MysqlExecutable, append(c.mysqlArgs(), "--unbuffered")...)
+	stderr := newLimitedBuffer(maxStderrSize)
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get stdout pipe: %w", err)
+	}
+	log.Debug().Str("Executable", cmd.Path).Msg("running")
+	if err = cmd.Start(); err != nil {
+		return nil, fmt.Errorf("cannot start %s: %w", cmd.Path, err)
+	}
+	return &Session{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+		stderr: stderr,
+	}, nil
+}
+
+// Dump - runs mysqldump for the database with the provided arguments and writes the result to w. The tables
+// list and excluded tables are applied
+func (c *Client) Dump(ctx context.Context, w io.Writer, args ...string) error {
+	args = append(c.connArgs(), args...)
+	args = append(args, c.opts.MysqldumpArgs...)
+	for _, t := range c.opts.ExcludeTables {
+		args = append(args, fmt.Sprintf("--ignore-table=%s.%s", c.opts.Database, t))
+	}
+	args = append(args, c.opts.Database)
+	args = append(args, c.opts.Tables...)
+	cmd := c.command(ctx, MysqldumpExecutable, args...)
+	cmd.Stdout = w
+	return run(cmd)
+}
+
+func (c *Client) mysqlArgs() []string {
+	args := append(c.connArgs(), "--batch", "--skip-column-names", "--quick")
+	if c.opts.Database != "" {
+		args = append(args, "--database="+c.opts.Database)
+	}
+	return args
+}
+
+func (c *Client) connArgs() []string {
+	args := []string{"--default-character-set=" + defaultCharacterSet}
+	if c.opts.Host != "" {
+		args = append(args, "--host="+c.opts.Host)
+	}
+	if c.opts.Port != 0 {
+		args = append(args, "--port="+strconv.Itoa(c.opts.Port))
+	}
+	if c.opts.User != "" {
+		args = append(args, "--user="+c.opts.User)
+	}
+	return args
+}
+
+func (c *Client) command(ctx context.Context, executable string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, path.Join(c.opts.BinPath, executable), args...)
+	cmd.Env = os.Environ()
+	if c.opts.Password != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", passwordEnvName, c.opts.Password))
+	}
+	return cmd
+}
+
+func run(cmd *exec.Cmd) error {
+	stderr := newLimitedBuffer(maxStderrSize)
+	cmd.Stderr = stderr
+	log.Debug().Str("Executable", cmd.Path).Msg("running")
+	if err := cmd.Run(); err != nil {
+		return commandError(cmd, err, stderr)
+	}
+	return nil
+}
+
+func commandError(cmd *exec.Cmd, err error, stderr *limitedBuffer) error {
+	msg := strings.TrimSpace(stderr.String())
+	if msg == "" {
+		return fmt.Errorf("%s: %w", cmd.Path, err)
+	}
+	return fmt.Errorf("%s: %w: %s", cmd.Path, err, msg)
+}
+
+// limitedBuffer - keeps the first size bytes that were written
+type limitedBuffer struct {
+	bytes.Buffer
+	size int
+}
+
+func newLimitedBuffer(size int) *limitedBuffer {
+	return &limitedBuffer{size: size}
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if free := b.size - b.Len(); free > 0 {
+		if len(p) > free {
+			b.Buffer.Write(p[:free])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
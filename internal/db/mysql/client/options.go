@@ -0,0 +1,50 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import "slices"
+
+// Options - MySQL connection and dump settings. The password is passed to the mysql and mysqldump utilities via
+// the MYSQL_PWD environment variable, so it is not visible in the processes list
+type Options struct {
+	// BinPath - directory with the mysql and mysqldump utilities. If empty they are searched in PATH
+	BinPath  string `mapstructure:"bin_path" yaml:"bin_path" json:"bin_path,omitempty"`
+	Host     string `mapstructure:"host" yaml:"host" json:"host,omitempty"`
+	Port     int    `mapstructure:"port" yaml:"port" json:"port,omitempty"`
+	User     string `mapstructure:"user" yaml:"user" json:"user,omitempty"`
+	Password string `mapstructure:"password" yaml:"password" json:"password,omitempty"`
+	Database string `mapstructure:"database" yaml:"database" json:"database,omitempty"`
+	// Tables - tables to dump. If empty all the base tables of the database are dumped
+	Tables []string `mapstructure:"tables" yaml:"tables" json:"tables,omitempty"`
+	// ExcludeTables - tables that are not dumped
+	ExcludeTables []string `mapstructure:"exclude_tables" yaml:"exclude_tables" json:"exclude_tables,omitempty"`
+	// InsertBatchSize - number of rows in the single INSERT statement of the dumped data
+	InsertBatchSize int `mapstructure:"insert_batch_size" yaml:"insert_batch_size" json:"insert_batch_size,omitempty"`
+	// MysqldumpArgs - additional arguments of mysqldump, for instance --column-statistics=0 or
+	// --set-gtid-purged=OFF
+	MysqldumpArgs []string `mapstructure:"mysqldump_args" yaml:"mysqldump_args" json:"mysqldump_args,omitempty"`
+	// NoGlobalLock - do not hold FLUSH TABLES WITH READ LOCK while the schema is dumped and the data snapshot is
+	// established. It is required if the user does not have the RELOAD privilege, but the schema changes made
+	// during the dump might not match the dumped data
+	NoGlobalLock bool `mapstructure:"no_global_lock" yaml:"no_global_lock" json:"no_global_lock,omitempty"`
+}
+
+// IsTableIncluded - checks that the table is in the tables list (if it is set) and not excluded
+func (o *Options) IsTableIncluded(name string) bool {
+	if len(o.Tables) > 0 && !slices.Contains(o.Tables, name) {
+		return false
+	}
+	return !slices.Contains(o.ExcludeTables, name)
+}
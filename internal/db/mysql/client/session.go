@@ -0,0 +1,73 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// sessionDoneMarker - the row that is selected after each statement, so the statement completion is detected
+const sessionDoneMarker = "greenmask:done"
+
+// Session - the mysql utility session that is kept open between the statements
+type Session struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	stderr *limitedBuffer
+}
+
+// Exec - executes the statement and waits for its completion. The result rows are discarded
+func (s *Session) Exec(statement string) error {
+	if _, err := fmt.Fprintf(s.stdin, "%s;\nSELECT '%s';\n", statement, sessionDoneMarker); err != nil {
+		return s.closeWithError(fmt.Errorf("cannot write statement: %w", err))
+	}
+	for {
+		line, err := s.stdout.ReadBytes('\n')
+		if string(bytes.TrimRight(line, "\r\n")) == sessionDoneMarker {
+			return nil
+		}
+		if err != nil {
+			// The utility exits on the statement error, so the reason is in stderr
+			return s.closeWithError(fmt.Errorf("cannot read result: %w", err))
+		}
+	}
+}
+
+// Close - closes the session. The session locks and transaction are released by the server
+func (s *Session) Close() error {
+	if err := s.stdin.Close(); err != nil {
+		return fmt.Errorf("cannot close stdin: %w", err)
+	}
+	if _, err := io.Copy(io.Discard, s.stdout); err != nil {
+		return fmt.Errorf("cannot read result: %w", err)
+	}
+	if err := s.cmd.Wait(); err != nil {
+		return commandError(s.cmd, err, s.stderr)
+	}
+	return nil
+}
+
+func (s *Session) closeWithError(err error) error {
+	if closeErr := s.Close(); closeErr != nil {
+		return errors.Join(err, closeErr)
+	}
+	return err
+}
@@ -0,0 +1,540 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/greenmaskio/greenmask/internal/db/mysql/catalog"
+	"github.com/greenmaskio/greenmask/internal/db/mysql/client"
+	mysqlContext "github.com/greenmaskio/greenmask/internal/db/mysql/context"
+	"github.com/greenmaskio/greenmask/internal/db/mysql/rowcodec"
+	pgCmd "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
+	runtimeContext "github.com/greenmaskio/greenmask/internal/db/postgres/context"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/dumpers"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	storageDto "github.com/greenmaskio/greenmask/internal/db/postgres/storage"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/custom"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/storages"
+	"github.com/greenmaskio/greenmask/internal/utils/ioutils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const (
+	SchemaFileName   = "schema.sql"
+	PostDataFileName = "post_data.sql"
+)
+
+const (
+	dumpFormat       = "SQL"
+	schemaObjectType = "SCHEMA"
+	// postDataObjectType - the triggers are restored after the data, so they are not fired on the inserts
+	postDataObjectType = "TRIGGERS"
+	// tableMarkerPrefix - prefix of the row that is selected before the table data. The data rows are quoted or
+	// hex encoded, so they cannot be confused with the marker
+	tableMarkerPrefix = "greenmask:table:"
+	// snapshotMarker - the row that is selected when the data transaction snapshot is established
+	snapshotMarker = "greenmask:snapshot"
+)
+
+// Dump - dumps the MySQL database. The schema is dumped by mysqldump, the data of all the tables is selected in
+// the single transaction with consistent snapshot, transformed and stored as the extended INSERT statements. The
+// schema is dumped and the snapshot is established under the global read lock, so they match each other
+type Dump struct {
+	config     *domains.Config
+	opts       *client.Options
+	st         storages.Storager
+	registry   *utils.TransformerRegistry
+	client     *client.Client
+	schema     *catalog.Schema
+	context    *runtimeContext.RuntimeContext
+	tables     []*entries.Table
+	dumpedFrom string
+	entries    []*storageDto.Entry
+	progress   *pgCmd.ProgressReporter
+}
+
+func NewDump(cfg *domains.Config, st storages.Storager, registry *utils.TransformerRegistry) *Dump {
+	return &Dump{
+		config:   cfg,
+		opts:     &cfg.Dump.MysqlOptions,
+		st:       st,
+		registry: registry,
+		client:   client.NewClient(&cfg.Dump.MysqlOptions),
+	}
+}
+
+// SetProgressFunc - sets the callback that is called on each dump stage and dumped table
+func (d *Dump) SetProgressFunc(f pgCmd.ProgressFunc) {
+	d.progress = pgCmd.NewProgressReporter(f)
+}
+
+func (d *Dump) Run(ctx context.Context) error {
+	startedAt := time.Now()
+	if d.opts.Database == "" {
+		return errors.New("dump.mysql_options.database must be set")
+	}
+
+	if err := custom.BootstrapCustomTransformers(ctx, d.registry, d.config.CustomTransformers); err != nil {
+		return fmt.Errorf("error bootstraping custom transformers: %w", err)
+	}
+
+	if err := d.buildContextAndValidate(ctx); err != nil {
+		return fmt.Errorf("context error: %w", err)
+	}
+
+	done := make(chan struct{})
+	eg, gtx := errgroup.WithContext(ctx)
+	eg.Go(d.writeHeartBeatWorker(gtx, done))
+	eg.Go(func() error {
+		d.progress.Stage(pgCmd.SchemaProgressStage)
+		schema, postData, ds, err := d.startConsistentDump(gtx)
+		if err != nil {
+			return err
+		}
+		if err := d.writeSchema(gtx, schema, SchemaFileName, toc.SectionPreData, schemaObjectType); err != nil {
+			ds.abort()
+			return fmt.Errorf("schema dumping error: %w", err)
+		}
+		d.progress.Stage(pgCmd.DataProgressStage)
+		if err := d.dumpData(gtx, ds); err != nil {
+			return fmt.Errorf("data dumping error: %w", err)
+		}
+		if err := d.writeSchema(gtx, postData, PostDataFileName, toc.SectionPostData, postDataObjectType); err != nil {
+			return fmt.Errorf("post-data dumping error: %w", err)
+		}
+
+		completedAt := time.Now()
+		if err := d.writeReport(gtx, startedAt, completedAt); err != nil {
+			return fmt.Errorf("writeReport stage dumping error: %w", err)
+		}
+		if err := d.writeMetaData(gtx, startedAt, completedAt); err != nil {
+			return fmt.Errorf("writeMetaData stage dumping error: %w", err)
+		}
+		d.progress.Stage(pgCmd.CompletedProgressStage)
+		close(done)
+		return nil
+	})
+	return eg.Wait()
+}
+
+func (d *Dump) buildContextAndValidate(ctx context.Context) (err error) {
+	rows, err := d.client.Query(ctx, "SELECT VERSION();")
+	if err != nil {
+		return fmt.Errorf("cannot get server version: %w", err)
+	}
+	if len(rows) != 1 || len(rows[0]) != 1 {
+		return errors.New("unexpected server version query result")
+	}
+	d.dumpedFrom = rows[0][0]
+
+	d.schema, err = catalog.Introspect(ctx, d.client, d.opts)
+	if err != nil {
+		return err
+	}
+
+	d.context, err = mysqlContext.NewRuntimeContext(
+		ctx, d.schema.Tables, d.schema.References, &d.config.Dump, d.registry, d.config.Dump.VirtualReferences,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to build runtime context: %w", err)
+	}
+	for _, w := range d.context.Warnings {
+		if w.Severity == "error" {
+			log.Error().Any("ValidationWarning", w).Msg("")
+		}
+	}
+	if d.context.IsFatal() {
+		return fmt.Errorf("fatal validation error")
+	}
+
+	for _, obj := range d.context.DataSectionObjects {
+		t := obj.(*entries.Table)
+		if len(t.Columns) == 0 {
+			log.Warn().
+				Str("Table", t.Name).
+				Msg("table does not have the columns that can be inserted: the data is not dumped")
+			continue
+		}
+		d.tables = append(d.tables, t)
+	}
+	return nil
+}
+
+// startConsistentDump - dumps the schema and the triggers and starts the data transaction with consistent snapshot
+// while the global read lock is held, so the DDL and the writes cannot happen between them. The lock is released as
+// soon as the snapshot is established, so the data is selected without the lock
+func (d *Dump) startConsistentDump(ctx context.Context) (schema, postData *bytes.Buffer, ds *dataStream, err error) {
+	if !d.opts.NoGlobalLock {
+		lock, err := d.client.Open(ctx)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("cannot open lock session: %w", err)
+		}
+		defer func() {
+			if err := lock.Close(); err != nil {
+				log.Warn().Err(err).Msg("error closing lock session")
+			}
+		}()
+		if err = lock.Exec("FLUSH TABLES WITH READ LOCK"); err != nil {
+			return nil, nil, nil, fmt.Errorf("cannot acquire global read lock: %w", err)
+		}
+		log.Debug().Msg("global read lock acquired")
+	}
+
+	ds, err = d.startData(ctx)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("data dumping error: %w", err)
+	}
+	schema = &bytes.Buffer{}
+	err = d.client.Dump(ctx, schema,
+		"--no-data", "--skip-triggers", "--routines", "--events", "--single-transaction",
+	)
+	if err != nil {
+		ds.abort()
+		return nil, nil, nil, fmt.Errorf("schema dumping error: %w", err)
+	}
+	postData = &bytes.Buffer{}
+	err = d.client.Dump(ctx, postData,
+		"--no-data", "--no-create-info", "--triggers", "--skip-routines", "--skip-events", "--single-transaction",
+	)
+	if err != nil {
+		ds.abort()
+		return nil, nil, nil, fmt.Errorf("post-data dumping error: %w", err)
+	}
+	return schema, postData, ds, nil
+}
+
+// writeSchema - writes the schema objects dumped by mysqldump
+func (d *Dump) writeSchema(ctx context.Context, buf *bytes.Buffer, fileName string, section int32, objectType string) error {
+	size := int64(buf.Len())
+	if err := d.st.PutObject(ctx, fileName, buf); err != nil {
+		return fmt.Errorf("cannot write %s: %w", fileName, err)
+	}
+	d.entries = append(d.entries, &storageDto.Entry{
+		DumpId:         int32(len(d.entries) + 1),
+		ObjectType:     objectType,
+		Schema:         d.opts.Database,
+		Name:           d.opts.Database,
+		Section:        toc.SectionMap[section],
+		OriginalSize:   size,
+		CompressedSize: size,
+		FileName:       fileName,
+	})
+	return nil
+}
+
+// dataStream - the result of the data script that is running in the transaction with consistent snapshot
+type dataStream struct {
+	r      *bufio.Reader
+	wait   func() error
+	cancel context.CancelFunc
+}
+
+// abort - stops the data script if it was started
+func (ds *dataStream) abort() {
+	if ds == nil {
+		return
+	}
+	ds.cancel()
+	if err := ds.wait(); err != nil {
+		log.Debug().Err(err).Msg("data script is aborted")
+	}
+}
+
+// startData - starts the data script and waits until the transaction snapshot is established. It returns nil if
+// there are no tables to dump
+func (d *Dump) startData(ctx context.Context) (*dataStream, error) {
+	if len(d.tables) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r, wait, err := d.client.Stream(ctx, d.generateDataScript())
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	ds := &dataStream{
+		r:      bufio.NewReaderSize(r, 64*1024),
+		wait:   wait,
+		cancel: cancel,
+	}
+	line, err := ds.r.ReadBytes('\n')
+	if string(bytes.TrimSpace(line)) != snapshotMarker {
+		// The script is failed, so the reason is returned by wait
+		waitErr := wait()
+		cancel()
+		if waitErr != nil {
+			return nil, waitErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read data: %w", err)
+		}
+		return nil, fmt.Errorf("unexpected row before snapshot marker %q", line)
+	}
+	log.Debug().Msg("data transaction snapshot is established")
+	return ds, nil
+}
+
+// dumpData - selects the data of all the tables in one transaction, so the dumped data is consistent. The tables
+// data is separated by the marker rows
+func (d *Dump) dumpData(ctx context.Context, ds *dataStream) error {
+	if ds == nil {
+		return nil
+	}
+	defer ds.cancel()
+
+	eg, gtx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		var tw *tableWriter
+		closeWriter := func() error {
+			if tw == nil {
+				return nil
+			}
+			name := fmt.Sprintf("table %s.%s", tw.table.Schema, tw.table.Name)
+			err := tw.close(gtx)
+			tw = nil
+			if err != nil {
+				return err
+			}
+			d.progress.ObjectDone(pgCmd.DataProgressStage, name)
+			return nil
+		}
+		defer func() {
+			if err := closeWriter(); err != nil {
+				log.Warn().Err(err).Msg("error closing table writer")
+			}
+		}()
+
+		br := ds.r
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(line) > 0 {
+				if bytes.HasPrefix(line, []byte(tableMarkerPrefix)) {
+					if err := closeWriter(); err != nil {
+						return err
+					}
+					idx, convErr := strconv.Atoi(strings.TrimSpace(string(line[len(tableMarkerPrefix):])))
+					if convErr != nil || idx < 0 || idx >= len(d.tables) {
+						return fmt.Errorf("unexpected table marker %q", line)
+					}
+					tw, err = d.openTableWriter(gtx, eg, d.tables[idx])
+					if err != nil {
+						return err
+					}
+					continue
+				}
+				if tw == nil {
+					return fmt.Errorf("unexpected row before table marker")
+				}
+				if err := tw.writeLine(gtx, line); err != nil {
+					return err
+				}
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("cannot read data: %w", err)
+			}
+		}
+		if err := closeWriter(); err != nil {
+			return err
+		}
+		return ds.wait()
+	})
+	if err := eg.Wait(); err != nil {
+		ds.abort()
+		return err
+	}
+	log.Debug().Msg("all the data have been dumped")
+	return nil
+}
+
+// generateDataScript - generates the script that selects the tables data in the transaction with consistent
+// snapshot. ANSI_QUOTES mode is enabled because the subset queries use double-quoted identifiers
+func (d *Dump) generateDataScript() string {
+	sb := &strings.Builder{}
+	sb.WriteString("SET SESSION sql_mode = CONCAT_WS(',', NULLIF(@@sql_mode, ''), 'ANSI_QUOTES');\n")
+	sb.WriteString("SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ;\n")
+	sb.WriteString("START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY;\n")
+	fmt.Fprintf(sb, "SELECT '%s';\n", snapshotMarker)
+	for idx, t := range d.tables {
+		kinds := d.schema.Kinds[t.Oid]
+		exprs := make([]string, 0, len(t.Columns))
+		for colIdx, c := range t.Columns {
+			exprs = append(exprs, rowcodec.SelectExpr(c.Name, kinds[colIdx]))
+		}
+		from := fmt.Sprintf("%s.%s", rowcodec.QuoteAnsiIdent(t.Schema), rowcodec.QuoteAnsiIdent(t.Name))
+		if t.Query != "" {
+			from = fmt.Sprintf("(%s) AS %s", t.Query, rowcodec.QuoteAnsiIdent(t.Name))
+		}
+		fmt.Fprintf(sb, "SELECT '%s%d';\n", tableMarkerPrefix, idx)
+		fmt.Fprintf(sb, "SELECT %s FROM %s;\n", strings.Join(exprs, ", "), from)
+	}
+	sb.WriteString("COMMIT;\n")
+	return sb.String()
+}
+
+func (d *Dump) openTableWriter(ctx context.Context, eg *errgroup.Group, t *entries.Table) (*tableWriter, error) {
+	dumpId := int32(len(d.entries) + 1)
+	fileName := fmt.Sprintf("%d.sql.gz", dumpId)
+	w, r := ioutils.NewGzipPipe(false)
+	uploaded := make(chan error, 1)
+	go func() {
+		defer func() {
+			if err := r.Close(); err != nil {
+				log.Warn().Err(err).Msg("error closing table data reader")
+			}
+		}()
+		uploaded <- d.st.PutObject(ctx, fileName, r)
+	}()
+
+	columns := make([]string, 0, len(t.Columns))
+	for _, c := range t.Columns {
+		columns = append(columns, c.Name)
+	}
+	kinds := d.schema.Kinds[t.Oid]
+	tw := &tableWriter{
+		table:    t,
+		kinds:    kinds,
+		w:        w,
+		r:        r,
+		bw:       bufio.NewWriterSize(w, 64*1024),
+		uploaded: uploaded,
+		values:   make([]*toolkit.RawValue, len(kinds)),
+	}
+	tw.insertWriter = rowcodec.NewInsertWriter(tw.bw, t.Name, columns, kinds, d.opts.InsertBatchSize)
+	if len(t.TransformersContext) > 0 {
+		pipeline, err := dumpers.NewTransformationPipeline(ctx, eg, t, io.Discard)
+		if err == nil {
+			err = pipeline.Init(ctx)
+		}
+		if err != nil {
+			if closeErr := w.Close(); closeErr != nil {
+				log.Warn().Err(closeErr).Msg("error closing table data writer")
+			}
+			<-uploaded
+			return nil, fmt.Errorf("cannot initialize transformation pipeline for table %s: %w", t.Name, err)
+		}
+		tw.pipeline = pipeline
+	}
+	log.Debug().Str("Table", t.Name).Msg("dumping table data")
+
+	d.entries = append(d.entries, &storageDto.Entry{
+		DumpId:     dumpId,
+		ObjectType: toc.TableDataDesc,
+		Schema:     t.Schema,
+		Name:       t.Name,
+		Section:    toc.SectionMap[toc.SectionData],
+		FileName:   fileName,
+	})
+	tw.entry = d.entries[len(d.entries)-1]
+	return tw, nil
+}
+
+func (d *Dump) writeMetaData(ctx context.Context, startedAt, completedAt time.Time) error {
+	var originalSize, compressedSize int64
+	dumpIdsOrder := make([]int32, 0, len(d.entries))
+	for _, e := range d.entries {
+		originalSize += e.OriginalSize
+		compressedSize += e.CompressedSize
+		dumpIdsOrder = append(dumpIdsOrder, e.DumpId)
+	}
+	metadata := &storageDto.Metadata{
+		StartedAt:      startedAt,
+		CompletedAt:    completedAt,
+		OriginalSize:   originalSize,
+		CompressedSize: compressedSize,
+		Transformers:   d.config.Dump.Transformation,
+		DatabaseSchema: d.context.DatabaseSchema,
+		Header: storageDto.Header{
+			Engine:          domains.MysqlEngine,
+			CreationDate:    startedAt,
+			DbName:          d.opts.Database,
+			TocEntriesCount: len(d.entries),
+			Format:          dumpFormat,
+			DumpedFrom:      d.dumpedFrom,
+			DumpedBy:        client.MysqldumpExecutable,
+		},
+		Entries:      d.entries,
+		DumpIdsOrder: dumpIdsOrder,
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	if err := json.NewEncoder(buf).Encode(metadata); err != nil {
+		return fmt.Errorf("error encoding metadata.json: %w", err)
+	}
+	if err := d.st.PutObject(ctx, pgCmd.MetadataJsonFileName, buf); err != nil {
+		return fmt.Errorf("error writing metadata to the storage: %w", err)
+	}
+	return nil
+}
+
+// writeReport - writes masking audit report with the transformation statistics of the dumped tables
+func (d *Dump) writeReport(ctx context.Context, startedAt, completedAt time.Time) error {
+	report, err := storageDto.NewReport(startedAt, completedAt, d.tables)
+	if err != nil {
+		return fmt.Errorf("unable build report: %w", err)
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	if err = json.NewEncoder(buf).Encode(report); err != nil {
+		return fmt.Errorf("error encoding report.json: %w", err)
+	}
+	if err = d.st.PutObject(ctx, pgCmd.ReportJsonFileName, buf); err != nil {
+		return fmt.Errorf("error writing report to the storage: %w", err)
+	}
+	return nil
+}
+
+// writeHeartBeatWorker - writes heart beat file each HeartBeatWriteInterval and on the dump completion
+func (d *Dump) writeHeartBeatWorker(ctx context.Context, done chan struct{}) func() error {
+	return func() error {
+		if err := d.st.PutObject(ctx, pgCmd.HeartBeatFileName, bytes.NewBufferString(pgCmd.HeartBeatInProgressContent)); err != nil {
+			return fmt.Errorf("error writing heartbeat: %w", err)
+		}
+		t := time.NewTicker(pgCmd.HeartBeatWriteInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-done:
+				if err := d.st.PutObject(ctx, pgCmd.HeartBeatFileName, bytes.NewBufferString(pgCmd.HeartBeatDoneContent)); err != nil {
+					return fmt.Errorf("error writing heartbeat: %w", err)
+				}
+				return nil
+			case <-t.C:
+				if err := d.st.PutObject(ctx, pgCmd.HeartBeatFileName, bytes.NewBufferString(pgCmd.HeartBeatInProgressContent)); err != nil {
+					return fmt.Errorf("error writing heartbeat: %w", err)
+				}
+			}
+		}
+	}
+}
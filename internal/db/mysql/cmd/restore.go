@@ -0,0 +1,178 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/greenmaskio/greenmask/internal/db/mysql/client"
+	pgCmd "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
+	storageDto "github.com/greenmaskio/greenmask/internal/db/postgres/storage"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/storages"
+	"github.com/greenmaskio/greenmask/internal/utils/ioutils"
+)
+
+// dataScriptPrefix - the session settings of the data restoration. The zero values of AUTO_INCREMENT columns are
+// kept and the foreign keys are not checked because the tables are restored in the dump order
+const dataScriptPrefix = `SET NAMES utf8mb4;
+SET SESSION sql_mode = 'NO_AUTO_VALUE_ON_ZERO';
+SET SESSION FOREIGN_KEY_CHECKS = 0;
+SET SESSION UNIQUE_CHECKS = 0;
+START TRANSACTION;
+`
+
+const dataScriptSuffix = "COMMIT;\n"
+
+// Restore - restores the MySQL dump by executing the schema, the tables data and the triggers scripts with the
+// mysql utility. The target database must exist
+type Restore struct {
+	st       storages.Storager
+	opts     client.Options
+	client   *client.Client
+	progress *pgCmd.ProgressReporter
+}
+
+func NewRestore(st storages.Storager, cfg *domains.Restore) *Restore {
+	return &Restore{
+		st:   st,
+		opts: cfg.MysqlOptions,
+	}
+}
+
+// IsMysqlDump - checks that the dump in the storage is the MySQL dump
+func IsMysqlDump(ctx context.Context, st storages.Storager) (bool, error) {
+	exists, err := st.Exists(ctx, pgCmd.MetadataJsonFileName)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, nil
+	}
+	meta, err := readMetadata(ctx, st)
+	if err != nil {
+		return false, err
+	}
+	return meta.Header.Engine == domains.MysqlEngine, nil
+}
+
+// SetProgressFunc - sets the callback that is called on each restoration stage and restored table
+func (r *Restore) SetProgressFunc(f pgCmd.ProgressFunc) {
+	r.progress = pgCmd.NewProgressReporter(f)
+}
+
+func (r *Restore) Run(ctx context.Context) error {
+	meta, err := readMetadata(ctx, r.st)
+	if err != nil {
+		return err
+	}
+	if r.opts.Database == "" {
+		r.opts.Database = meta.Header.DbName
+	}
+	r.client = client.NewClient(&r.opts)
+
+	var stage pgCmd.ProgressStage
+	for _, e := range meta.Entries {
+		log.Debug().
+			Str("ObjectType", e.ObjectType).
+			Str("Name", e.Name).
+			Msg("restoring")
+		entryStage := pgCmd.PreDataProgressStage
+		switch e.Section {
+		case toc.SectionMap[toc.SectionData]:
+			entryStage = pgCmd.DataProgressStage
+		case toc.SectionMap[toc.SectionPostData]:
+			entryStage = pgCmd.PostDataProgressStage
+		}
+		if entryStage != stage {
+			stage = entryStage
+			r.progress.Stage(stage)
+		}
+
+		if stage == pgCmd.DataProgressStage {
+			err = r.restoreData(ctx, e)
+		} else {
+			err = r.restoreScript(ctx, e)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot restore %s %s: %w", strings.ToLower(e.ObjectType), e.Name, err)
+		}
+		if stage == pgCmd.DataProgressStage {
+			r.progress.ObjectDone(stage, fmt.Sprintf("table %s", e.Name))
+		}
+	}
+	r.progress.Stage(pgCmd.CompletedProgressStage)
+	return nil
+}
+
+// restoreScript - executes the mysqldump script
+func (r *Restore) restoreScript(ctx context.Context, e *storageDto.Entry) error {
+	f, err := r.st.GetObject(ctx, e.FileName)
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %w", e.FileName, err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Warn().Err(err).Msg("error closing script reader")
+		}
+	}()
+	return r.client.Exec(ctx, f, io.Discard)
+}
+
+// restoreData - executes the table data INSERT statements in one transaction
+func (r *Restore) restoreData(ctx context.Context, e *storageDto.Entry) error {
+	f, err := r.st.GetObject(ctx, e.FileName)
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %w", e.FileName, err)
+	}
+	gz, err := ioutils.NewGzipReader(f, false)
+	if err != nil {
+		if closeErr := f.Close(); closeErr != nil {
+			log.Warn().Err(closeErr).Msg("error closing data reader")
+		}
+		return fmt.Errorf("cannot create gzip reader: %w", err)
+	}
+	defer func() {
+		if err := gz.Close(); err != nil {
+			log.Warn().Err(err).Msg("error closing data reader")
+		}
+	}()
+	script := io.MultiReader(strings.NewReader(dataScriptPrefix), gz, strings.NewReader(dataScriptSuffix))
+	return r.client.Exec(ctx, script, io.Discard)
+}
+
+func readMetadata(ctx context.Context, st storages.Storager) (*storageDto.Metadata, error) {
+	f, err := st.GetObject(ctx, pgCmd.MetadataJsonFileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get metadata: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Warn().Err(err).Msg("error closing metadata reader")
+		}
+	}()
+	meta := &storageDto.Metadata{}
+	if err := json.NewDecoder(f).Decode(meta); err != nil {
+		return nil, fmt.Errorf("metadata parsing error: %w", err)
+	}
+	return meta, nil
+}
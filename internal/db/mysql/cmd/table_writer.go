@@ -0,0 +1,110 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/greenmaskio/greenmask/internal/db/mysql/rowcodec"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/dumpers"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	storageDto "github.com/greenmaskio/greenmask/internal/db/postgres/storage"
+	"github.com/greenmaskio/greenmask/internal/utils/ioutils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// tableWriter - decodes the selected rows of the table, transforms them and writes them as the INSERT statements
+// to the compressed storage object
+type tableWriter struct {
+	table        *entries.Table
+	entry        *storageDto.Entry
+	kinds        []rowcodec.Kind
+	w            ioutils.CountWriteCloser
+	r            ioutils.CountReadCloser
+	bw           *bufio.Writer
+	insertWriter *rowcodec.InsertWriter
+	// pipeline - transformation pipeline. It is nil if the table does not have transformers
+	pipeline *dumpers.TransformationPipeline
+	uploaded chan error
+	values   []*toolkit.RawValue
+	line     uint64
+}
+
+func (tw *tableWriter) writeLine(ctx context.Context, line []byte) error {
+	tw.line++
+	fields := rowcodec.SplitBatchLine(line)
+	if len(fields) != len(tw.kinds) {
+		return fmt.Errorf(
+			"table %s line %d: expected %d fields but received %d", tw.table.Name, tw.line, len(tw.kinds), len(fields),
+		)
+	}
+	for idx, f := range fields {
+		v, err := rowcodec.DecodeValue(f, tw.kinds[idx])
+		if err != nil {
+			return fmt.Errorf("table %s line %d column %s: %w", tw.table.Name, tw.line, tw.table.Columns[idx].Name, err)
+		}
+		tw.values[idx] = v
+	}
+	values := tw.values
+	if tw.pipeline != nil {
+		var err error
+		values, err = tw.pipeline.TransformValues(ctx, values)
+		if errors.Is(err, dumpers.ErrRowSkipped) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := tw.insertWriter.WriteRow(values); err != nil {
+		return fmt.Errorf("table %s line %d: %w", tw.table.Name, tw.line, err)
+	}
+	return nil
+}
+
+// close - completes the statement, waits for the object upload and sets the dumped sizes
+func (tw *tableWriter) close(ctx context.Context) error {
+	var lastErr error
+	if tw.pipeline != nil {
+		if err := tw.pipeline.Done(ctx); err != nil {
+			lastErr = err
+		}
+	}
+	if err := tw.insertWriter.Flush(); err != nil {
+		lastErr = fmt.Errorf("cannot write statement: %w", err)
+	}
+	if err := tw.bw.Flush(); err != nil {
+		lastErr = fmt.Errorf("cannot flush data: %w", err)
+	}
+	if err := tw.w.Close(); err != nil {
+		log.Warn().Err(err).Msg("error closing table data writer")
+	}
+	if err := <-tw.uploaded; err != nil {
+		lastErr = fmt.Errorf("cannot write object: %w", err)
+	}
+	if lastErr != nil {
+		return fmt.Errorf("error dumping table %s: %w", tw.table.Name, lastErr)
+	}
+	tw.entry.OriginalSize = tw.w.GetCount()
+	tw.entry.CompressedSize = tw.r.GetCount()
+	tw.table.OriginalSize = tw.entry.OriginalSize
+	tw.table.CompressedSize = tw.entry.CompressedSize
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+
+	runtimeContext "github.com/greenmaskio/greenmask/internal/db/postgres/context"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	transformersUtils "github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// NewRuntimeContext - creates runtime context for the tables introspected from the MySQL database. The features
+// that rely on the PostgreSQL catalog (subset_sample, subset_seeds, apply_for_references, the subset of the cyclic
+// references and the policy rules by comments and security labels) are not supported
+func NewRuntimeContext(
+	ctx context.Context, tables []*entries.Table, refs map[toolkit.Oid][]*toolkit.Reference, cfg *domains.Dump,
+	r *transformersUtils.TransformerRegistry, vr []*domains.VirtualReference,
+) (*runtimeContext.RuntimeContext, error) {
+	if warnings := validateConfig(cfg); warnings.IsFatal() {
+		return &runtimeContext.RuntimeContext{
+			Warnings: warnings,
+		}, nil
+	}
+	if cfg.Subset != nil && cfg.Subset.Materialize {
+		log.Warn().
+			Msg("subset key sets materialisation is not supported for MySQL: it is disabled")
+	}
+	return runtimeContext.NewIntrospectedRuntimeContext(ctx, tables, refs, cfg, r, vr)
+}
+
+// validateConfig - returns fatal warnings if the tables config uses the features that rely on the PostgreSQL
+// catalog
+func validateConfig(cfg *domains.Dump) toolkit.ValidationWarnings {
+	var warnings toolkit.ValidationWarnings
+	for _, t := range cfg.Transformation {
+		if t.SubsetSample != nil || t.SubsetSeeds != nil {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetMsg("subset_sample and subset_seeds are not supported for MySQL").
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("SchemaName", t.Schema).
+				AddMeta("TableName", t.Name),
+			)
+		}
+		if hasTransformerWithApplyForReferences(t) {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetMsg("apply_for_references is not supported for MySQL").
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("SchemaName", t.Schema).
+				AddMeta("TableName", t.Name),
+			)
+		}
+	}
+	return warnings
+}
+
+func hasTransformerWithApplyForReferences(t *domains.Table) bool {
+	for _, tr := range t.Transformers {
+		if tr.ApplyForReferences {
+			return true
+		}
+	}
+	return false
+}
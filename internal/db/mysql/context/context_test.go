@@ -0,0 +1,108 @@
+package context
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func newMysqlTestTables() ([]*entries.Table, map[toolkit.Oid][]*toolkit.Reference) {
+	users := &entries.Table{
+		Table: &toolkit.Table{
+			Schema:     "shop",
+			Name:       "users",
+			Oid:        1,
+			PrimaryKey: []string{"id"},
+			Columns:    []*toolkit.Column{{Name: "id", TypeName: "int4"}},
+		},
+		RelKind: 'r',
+	}
+	orders := &entries.Table{
+		Table: &toolkit.Table{
+			Schema:     "shop",
+			Name:       "orders",
+			Oid:        2,
+			PrimaryKey: []string{"id"},
+			Columns: []*toolkit.Column{
+				{Name: "id", TypeName: "int4"},
+				{Name: "user_id", TypeName: "int4", Idx: 1},
+			},
+		},
+		RelKind: 'r',
+	}
+	refs := map[toolkit.Oid][]*toolkit.Reference{
+		orders.Oid: {{Schema: "shop", Name: "users", ReferencedKeys: []string{"user_id"}}},
+	}
+	return []*entries.Table{users, orders}, refs
+}
+
+func TestNewRuntimeContext_Subset(t *testing.T) {
+	tables, refs := newMysqlTestTables()
+	cfg := &domains.Dump{
+		Transformation: []*domains.Table{
+			{Schema: "shop", Name: "users", SubsetConds: []string{`"shop"."users"."id" < 10`}},
+		},
+	}
+
+	rc, err := NewRuntimeContext(context.Background(), tables, refs, cfg, utils.NewTransformerRegistry(), nil)
+	require.NoError(t, err)
+	require.False(t, rc.IsFatal())
+	assert.Contains(t, tables[1].Query, `INNER JOIN "shop"."users" ON "shop"."orders"."user_id" = "shop"."users"."id"`)
+	assert.Contains(t, tables[1].Query, `"shop"."users"."id" < 10`)
+}
+
+func TestNewRuntimeContext_UnsupportedFeatures(t *testing.T) {
+	tables, refs := newMysqlTestTables()
+	cfg := &domains.Dump{
+		Transformation: []*domains.Table{
+			{Schema: "shop", Name: "users", SubsetSample: &domains.SubsetSample{}},
+			{
+				Schema:       "shop",
+				Name:         "orders",
+				Transformers: []*domains.TransformerConfig{{Name: "RandomInt", ApplyForReferences: true}},
+			},
+		},
+	}
+
+	rc, err := NewRuntimeContext(context.Background(), tables, refs, cfg, utils.NewTransformerRegistry(), nil)
+	require.NoError(t, err)
+	require.True(t, rc.IsFatal())
+	require.Len(t, rc.Warnings, 2)
+	assert.Equal(t, "subset_sample and subset_seeds are not supported for MySQL", rc.Warnings[0].Msg)
+	assert.Equal(t, "apply_for_references is not supported for MySQL", rc.Warnings[1].Msg)
+}
+
+func TestNewRuntimeContext_UnknownTable(t *testing.T) {
+	tables, refs := newMysqlTestTables()
+	cfg := &domains.Dump{
+		Transformation: []*domains.Table{{Schema: "shop", Name: "unknown"}},
+	}
+
+	rc, err := NewRuntimeContext(context.Background(), tables, refs, cfg, utils.NewTransformerRegistry(), nil)
+	require.NoError(t, err)
+	require.True(t, rc.IsFatal())
+	require.Len(t, rc.Warnings, 1)
+	assert.Equal(t, "table is not found in the database", rc.Warnings[0].Msg)
+}
+
+func TestNewRuntimeContext_CyclicSubset(t *testing.T) {
+	tables, refs := newMysqlTestTables()
+	refs[tables[0].Oid] = []*toolkit.Reference{{Schema: "shop", Name: "orders", ReferencedKeys: []string{"id"}}}
+	cfg := &domains.Dump{
+		Transformation: []*domains.Table{
+			{Schema: "shop", Name: "users", SubsetConds: []string{`"shop"."users"."id" < 10`}},
+		},
+	}
+
+	rc, err := NewRuntimeContext(context.Background(), tables, refs, cfg, utils.NewTransformerRegistry(), nil)
+	require.NoError(t, err)
+	require.True(t, rc.IsFatal())
+	assert.Contains(t, rc.Warnings[0].Msg, "cyclic references")
+}
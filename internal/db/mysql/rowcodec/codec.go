@@ -0,0 +1,220 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rowcodec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// Kind - the way the column value is selected from MySQL and converted to the PostgreSQL text representation
+// that is used by the transformers
+type Kind int
+
+const (
+	// TextKind - the MySQL text value is the same as the PostgreSQL text value
+	TextKind Kind = iota
+	// BoolKind - TINYINT(1) value: 0 and 1 in MySQL, f and t in PostgreSQL
+	BoolKind
+	// BinaryKind - binary value: hex digits in the select list, X'..' literal in MySQL and \x.. in PostgreSQL
+	BinaryKind
+)
+
+const (
+	// batchNullValue - the NULL value representation of the mysql utility batch mode
+	batchNullValue = "NULL"
+	// pgHexPrefix - the prefix of the PostgreSQL bytea hex format
+	pgHexPrefix = `\x`
+)
+
+var (
+	pgTrueValues  = []string{"t", "true", "y", "yes", "on", "1"}
+	pgFalseValues = []string{"f", "false", "n", "no", "off", "0"}
+)
+
+// SelectExpr - returns the select list expression of the column. The text values are selected with QUOTE, so the
+// NULL value can be distinguished from the 'NULL' string, the binary values are selected as hex digits, so they are
+// not affected by the client character set. The identifiers are quoted in ANSI_QUOTES mode
+func SelectExpr(column string, kind Kind) string {
+	ident := QuoteAnsiIdent(column)
+	if kind == BinaryKind {
+		return fmt.Sprintf("HEX(%s)", ident)
+	}
+	return fmt.Sprintf("QUOTE(%s)", ident)
+}
+
+// QuoteAnsiIdent - quotes the identifier with double quotes that are used in ANSI_QUOTES mode
+func QuoteAnsiIdent(ident string) string {
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+
+// QuoteIdent - quotes the identifier with backticks that are used regardless of sql_mode
+func QuoteIdent(ident string) string {
+	return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+}
+
+// SplitBatchLine - splits the line of the mysql utility batch mode output into the fields and unescapes them
+func SplitBatchLine(line []byte) [][]byte {
+	line = bytes.TrimSuffix(line, []byte{'\n'})
+	fields := bytes.Split(line, []byte{'\t'})
+	for idx, f := range fields {
+		fields[idx] = UnescapeBatchField(f)
+	}
+	return fields
+}
+
+// UnescapeBatchField - unescapes the field of the mysql utility batch mode output. The utility escapes the
+// backslash, tab, new line and zero byte
+func UnescapeBatchField(field []byte) []byte {
+	if bytes.IndexByte(field, '\\') == -1 {
+		return field
+	}
+	res := make([]byte, 0, len(field))
+	for i := 0; i < len(field); i++ {
+		c := field[i]
+		if c != '\\' || i+1 == len(field) {
+			res = append(res, c)
+			continue
+		}
+		i++
+		switch field[i] {
+		case 'n':
+			res = append(res, '\n')
+		case 't':
+			res = append(res, '\t')
+		case '0':
+			res = append(res, 0)
+		default:
+			res = append(res, field[i])
+		}
+	}
+	return res
+}
+
+// DecodeValue - decodes the selected field into the PostgreSQL text representation
+func DecodeValue(field []byte, kind Kind) (*toolkit.RawValue, error) {
+	if string(field) == batchNullValue {
+		return toolkit.NewRawValue(nil, true), nil
+	}
+	if kind == BinaryKind {
+		data := make([]byte, 0, len(pgHexPrefix)+len(field))
+		data = append(data, pgHexPrefix...)
+		data = append(data, bytes.ToLower(field)...)
+		return toolkit.NewRawValue(data, false), nil
+	}
+	data, err := unquote(field)
+	if err != nil {
+		return nil, err
+	}
+	if kind == BoolKind {
+		if string(data) == "0" {
+			return toolkit.NewRawValue([]byte("f"), false), nil
+		}
+		return toolkit.NewRawValue([]byte("t"), false), nil
+	}
+	return toolkit.NewRawValue(data, false), nil
+}
+
+// unquote - unquotes the string literal produced by QUOTE function
+func unquote(field []byte) ([]byte, error) {
+	if len(field) < 2 || field[0] != '\'' || field[len(field)-1] != '\'' {
+		return nil, fmt.Errorf("value %q is not quoted", field)
+	}
+	field = field[1 : len(field)-1]
+	res := make([]byte, 0, len(field))
+	for i := 0; i < len(field); i++ {
+		c := field[i]
+		if c != '\\' {
+			res = append(res, c)
+			continue
+		}
+		if i+1 == len(field) {
+			return nil, fmt.Errorf("unterminated escape sequence in value %q", field)
+		}
+		i++
+		switch field[i] {
+		case '0':
+			res = append(res, 0)
+		case 'Z':
+			res = append(res, 0x1a)
+		default:
+			res = append(res, field[i])
+		}
+	}
+	return res, nil
+}
+
+// EncodeValue - encodes the value in the PostgreSQL text representation into the MySQL literal and appends it to
+// buf
+func EncodeValue(buf []byte, v *toolkit.RawValue, kind Kind) ([]byte, error) {
+	if v.IsNull {
+		return append(buf, batchNullValue...), nil
+	}
+	switch kind {
+	case BoolKind:
+		s := strings.ToLower(strings.TrimSpace(string(v.Data)))
+		for _, t := range pgTrueValues {
+			if s == t {
+				return append(buf, '1'), nil
+			}
+		}
+		for _, f := range pgFalseValues {
+			if s == f {
+				return append(buf, '0'), nil
+			}
+		}
+		return nil, fmt.Errorf("invalid boolean value %q", v.Data)
+	case BinaryKind:
+		data := v.Data
+		if bytes.HasPrefix(data, []byte(pgHexPrefix)) {
+			data = data[len(pgHexPrefix):]
+			if _, err := hex.DecodeString(string(data)); err != nil {
+				return nil, fmt.Errorf("invalid bytea hex value: %w", err)
+			}
+		} else {
+			data = []byte(hex.EncodeToString(data))
+		}
+		buf = append(buf, "X'"...)
+		buf = append(buf, data...)
+		return append(buf, '\''), nil
+	}
+	return appendQuoted(buf, v.Data), nil
+}
+
+// appendQuoted - appends the string literal with the escaped special characters
+func appendQuoted(buf []byte, data []byte) []byte {
+	buf = append(buf, '\'')
+	for _, c := range data {
+		switch c {
+		case 0:
+			buf = append(buf, '\\', '0')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case 0x1a:
+			buf = append(buf, '\\', 'Z')
+		case '\\', '\'':
+			buf = append(buf, '\\', c)
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return append(buf, '\'')
+}
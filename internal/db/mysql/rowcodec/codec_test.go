@@ -0,0 +1,88 @@
+package rowcodec
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestSplitBatchLine(t *testing.T) {
+	fields := SplitBatchLine([]byte("'a\\tb'\tNULL\t'c\\\\\\n'\n"))
+	require.Len(t, fields, 3)
+	assert.Equal(t, "'a\tb'", string(fields[0]))
+	assert.Equal(t, "NULL", string(fields[1]))
+	assert.Equal(t, "'c\\\n'", string(fields[2]))
+}
+
+func TestDecodeValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    string
+		kind     Kind
+		expected *toolkit.RawValue
+	}{
+		{name: "null", field: "NULL", kind: TextKind, expected: toolkit.NewRawValue(nil, true)},
+		{name: "null string", field: "'NULL'", kind: TextKind, expected: toolkit.NewRawValue([]byte("NULL"), false)},
+		{name: "escapes", field: `'it\'s \\ \0 \Z'`, kind: TextKind, expected: toolkit.NewRawValue([]byte("it's \\ \x00 \x1a"), false)},
+		{name: "bool false", field: "'0'", kind: BoolKind, expected: toolkit.NewRawValue([]byte("f"), false)},
+		{name: "bool true", field: "'1'", kind: BoolKind, expected: toolkit.NewRawValue([]byte("t"), false)},
+		{name: "binary", field: "0AFF", kind: BinaryKind, expected: toolkit.NewRawValue([]byte(`\x0aff`), false)},
+		{name: "empty binary", field: "", kind: BinaryKind, expected: toolkit.NewRawValue([]byte(`\x`), false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := DecodeValue([]byte(tt.field), tt.kind)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected.IsNull, v.IsNull)
+			assert.Equal(t, string(tt.expected.Data), string(v.Data))
+		})
+	}
+
+	_, err := DecodeValue([]byte("abc"), TextKind)
+	require.Error(t, err)
+}
+
+func TestEncodeValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    *toolkit.RawValue
+		kind     Kind
+		expected string
+	}{
+		{name: "null", value: toolkit.NewRawValue(nil, true), kind: TextKind, expected: "NULL"},
+		{name: "text", value: toolkit.NewRawValue([]byte("it's\n\\"), false), kind: TextKind, expected: `'it\'s\n\\'`},
+		{name: "bool", value: toolkit.NewRawValue([]byte("t"), false), kind: BoolKind, expected: "1"},
+		{name: "bool false", value: toolkit.NewRawValue([]byte("false"), false), kind: BoolKind, expected: "0"},
+		{name: "bytea hex", value: toolkit.NewRawValue([]byte(`\x0aff`), false), kind: BinaryKind, expected: "X'0aff'"},
+		{name: "raw bytes", value: toolkit.NewRawValue([]byte{0x0a, 0xff}, false), kind: BinaryKind, expected: "X'0aff'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := EncodeValue(nil, tt.value, tt.kind)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(res))
+		})
+	}
+
+	_, err := EncodeValue(nil, toolkit.NewRawValue([]byte("maybe"), false), BoolKind)
+	require.Error(t, err)
+}
+
+func TestInsertWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	iw := NewInsertWriter(buf, "users", []string{"id", "name"}, []Kind{TextKind, TextKind}, 2)
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, iw.WriteRow([]*toolkit.RawValue{
+			toolkit.NewRawValue([]byte("1"), false),
+			toolkit.NewRawValue([]byte(name), false),
+		}))
+	}
+	require.NoError(t, iw.Flush())
+	expected := "INSERT INTO `users` (`id`, `name`) VALUES\n('1','a'),\n('1','b');\n" +
+		"INSERT INTO `users` (`id`, `name`) VALUES\n('1','c');\n"
+	assert.Equal(t, expected, buf.String())
+}
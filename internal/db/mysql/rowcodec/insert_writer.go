@@ -0,0 +1,94 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rowcodec
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const DefaultInsertBatchSize = 1000
+
+// InsertWriter - writes the rows as the extended INSERT statements with up to batchSize rows per statement
+type InsertWriter struct {
+	w         io.Writer
+	prefix    []byte
+	kinds     []Kind
+	batchSize int
+	rows      int
+	buf       []byte
+}
+
+func NewInsertWriter(w io.Writer, table string, columns []string, kinds []Kind, batchSize int) *InsertWriter {
+	if batchSize <= 0 {
+		batchSize = DefaultInsertBatchSize
+	}
+	quotedColumns := make([]string, 0, len(columns))
+	for _, c := range columns {
+		quotedColumns = append(quotedColumns, QuoteIdent(c))
+	}
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES\n", QuoteIdent(table), strings.Join(quotedColumns, ", "))
+	return &InsertWriter{
+		w:         w,
+		prefix:    []byte(prefix),
+		kinds:     kinds,
+		batchSize: batchSize,
+	}
+}
+
+// WriteRow - appends the row in the PostgreSQL text representation to the current statement. The statement is
+// written when the batch is full
+func (iw *InsertWriter) WriteRow(values []*toolkit.RawValue) (err error) {
+	if len(values) != len(iw.kinds) {
+		return fmt.Errorf("expected %d values but received %d", len(iw.kinds), len(values))
+	}
+	if iw.rows == 0 {
+		iw.buf = append(iw.buf[:0], iw.prefix...)
+	} else {
+		iw.buf = append(iw.buf, ",\n"...)
+	}
+	iw.buf = append(iw.buf, '(')
+	for idx, v := range values {
+		if idx > 0 {
+			iw.buf = append(iw.buf, ',')
+		}
+		iw.buf, err = EncodeValue(iw.buf, v, iw.kinds[idx])
+		if err != nil {
+			return fmt.Errorf("cannot encode value of column %d: %w", idx, err)
+		}
+	}
+	iw.buf = append(iw.buf, ')')
+	iw.rows++
+	if iw.rows == iw.batchSize {
+		return iw.Flush()
+	}
+	return nil
+}
+
+// Flush - terminates and writes the current statement if it has rows
+func (iw *InsertWriter) Flush() error {
+	if iw.rows == 0 {
+		return nil
+	}
+	iw.buf = append(iw.buf, ";\n"...)
+	iw.rows = 0
+	if _, err := iw.w.Write(iw.buf); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+)
+
+const readBufferSize = 64 * 1024
+
+// Archive - existing pg_dump archive in directory (-Fd) or custom (-Fc) format
+type Archive interface {
+	// Toc - returns TOC of the archive
+	Toc() *toc.Toc
+	// OpenData - returns decompressed COPY data of the entry
+	OpenData(entry *toc.Entry) (io.ReadCloser, error)
+}
+
+// Open - opens pg_dump archive. The directory is opened as directory format archive and the file is opened as
+// custom format archive
+func Open(archivePath string) (Archive, error) {
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open archive: %w", err)
+	}
+	if info.IsDir() {
+		return newDirectoryArchive(archivePath)
+	}
+	return newCustomArchive(archivePath)
+}
+
+// directoryArchive - archive in directory format. Each TABLE DATA entry is stored in the separate file that might
+// be gzip compressed
+type directoryArchive struct {
+	dir string
+	toc *toc.Toc
+}
+
+func newDirectoryArchive(dir string) (*directoryArchive, error) {
+	f, err := os.Open(path.Join(dir, "toc.dat"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot open toc file: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Warn().Err(err).Msg("error closing toc file")
+		}
+	}()
+	t, err := toc.NewReader(bufio.NewReader(f)).Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read toc file: %w", err)
+	}
+	return &directoryArchive{
+		dir: dir,
+		toc: t,
+	}, nil
+}
+
+func (da *directoryArchive) Toc() *toc.Toc {
+	return da.toc
+}
+
+func (da *directoryArchive) OpenData(entry *toc.Entry) (io.ReadCloser, error) {
+	if entry.FileName == nil || *entry.FileName == "" {
+		return nil, fmt.Errorf("entry %d does not have data file", entry.DumpId)
+	}
+	fileName := path.Join(da.dir, *entry.FileName)
+	// pg_dump adds the compression suffix to the file name stored in TOC
+	for _, suffix := range []string{".lz4", ".zst"} {
+		if _, err := os.Stat(fileName + suffix); err == nil {
+			return nil, fmt.Errorf("unsupported compression of the data file %s", *entry.FileName+suffix)
+		}
+	}
+	f, err := os.Open(fileName + ".gz")
+	if errors.Is(err, os.ErrNotExist) {
+		f, err = os.Open(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open data file: %w", err)
+		}
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot open data file: %w", err)
+	}
+	gz, err := gzip.NewReader(bufio.NewReaderSize(f, readBufferSize))
+	if err != nil {
+		closeFile(f)
+		return nil, fmt.Errorf("cannot create gzip reader: %w", err)
+	}
+	return &dataReadCloser{Reader: gz, closers: []io.Closer{gz, f}}, nil
+}
+
+// customArchive - archive in custom format. The data blocks of all the entries are stored after TOC in the same
+// file and might be zlib compressed
+type customArchive struct {
+	fileName string
+	toc      *toc.Toc
+}
+
+func newCustomArchive(fileName string) (*customArchive, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open archive file: %w", err)
+	}
+	defer closeFile(f)
+	t, err := toc.NewReader(bufio.NewReader(f)).Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read toc: %w", err)
+	}
+	if t.Header.Format != toc.ArchCustom {
+		return nil, fmt.Errorf("unsupported archive format \"%s\"", toc.BackupFormats[t.Header.Format])
+	}
+	switch t.Header.CompressionSpec.Algorithm {
+	case toc.PgCompressionNone, toc.PgCompressionGzip:
+	default:
+		return nil, fmt.Errorf("unsupported archive compression algorithm %d", t.Header.CompressionSpec.Algorithm)
+	}
+	return &customArchive{
+		fileName: fileName,
+		toc:      t,
+	}, nil
+}
+
+func (ca *customArchive) Toc() *toc.Toc {
+	return ca.toc
+}
+
+// OpenData - opens the archive file and finds the data block of the entry. If the archive was written into
+// non-seekable output, pg_dump does not store the blocks offsets, then the blocks are scanned from the beginning
+func (ca *customArchive) OpenData(entry *toc.Entry) (io.ReadCloser, error) {
+	if entry.DataState == toc.OffsetNoData {
+		return nil, fmt.Errorf("entry %d does not have data", entry.DumpId)
+	}
+	f, err := os.Open(ca.fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open archive file: %w", err)
+	}
+
+	var r *bufio.Reader
+	if entry.DataState == toc.OffsetPosSet {
+		if _, err = f.Seek(entry.DataPos, io.SeekStart); err != nil {
+			closeFile(f)
+			return nil, fmt.Errorf("cannot seek to data block: %w", err)
+		}
+		r = bufio.NewReaderSize(f, readBufferSize)
+	} else {
+		r = bufio.NewReaderSize(f, readBufferSize)
+		// Skip TOC to get to the first data block
+		if _, err = toc.NewReader(r).Read(); err != nil {
+			closeFile(f)
+			return nil, fmt.Errorf("cannot read toc: %w", err)
+		}
+	}
+
+	dr := toc.NewDataReader(r, ca.toc.Header)
+	for {
+		blockType, dumpId, err := dr.Next()
+		if err != nil {
+			closeFile(f)
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("data block of entry %d is not found", entry.DumpId)
+			}
+			return nil, fmt.Errorf("cannot read data block: %w", err)
+		}
+		if dumpId != entry.DumpId {
+			if entry.DataState == toc.OffsetPosSet {
+				closeFile(f)
+				return nil, fmt.Errorf("unexpected data block at offset %d: expected entry %d got %d",
+					entry.DataPos, entry.DumpId, dumpId)
+			}
+			continue
+		}
+		if blockType != toc.BlkData {
+			closeFile(f)
+			return nil, fmt.Errorf("entry %d is not a table data block", entry.DumpId)
+		}
+		break
+	}
+
+	if ca.toc.Header.CompressionSpec.Algorithm == toc.PgCompressionNone {
+		return &dataReadCloser{Reader: dr, closers: []io.Closer{f}}, nil
+	}
+	zr, err := zlib.NewReader(dr)
+	if err != nil {
+		closeFile(f)
+		return nil, fmt.Errorf("cannot create zlib reader: %w", err)
+	}
+	return &dataReadCloser{Reader: zr, closers: []io.Closer{zr, f}}, nil
+}
+
+type dataReadCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (d *dataReadCloser) Close() error {
+	var errs []error
+	for _, c := range d.closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func closeFile(f *os.File) {
+	if err := f.Close(); err != nil {
+		log.Warn().Err(err).Msg("error closing archive file")
+	}
+}
@@ -0,0 +1,450 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// columnDefinitionKeywords - keywords that may follow the column type in the CREATE TABLE statement generated by
+// pg_dump. The type names are always lower case in pg_dump output, so the upper case keywords cannot be confused
+// with them
+var columnDefinitionKeywords = []string{
+	"COLLATE", "COMPRESSION", "STORAGE", "DEFAULT", "NOT", "NULL", "GENERATED", "CONSTRAINT", "CHECK",
+	"REFERENCES", "UNIQUE", "PRIMARY",
+}
+
+// tableConstraintKeywords - keywords that start the table constraint instead of the column definition
+var tableConstraintKeywords = []string{
+	"CONSTRAINT", "CHECK", "UNIQUE", "PRIMARY", "FOREIGN", "EXCLUDE", "LIKE",
+}
+
+var (
+	createTableRegexp     = regexp.MustCompile(`^\s*CREATE\s+(?:(?:UNLOGGED|TEMPORARY|TEMP|GLOBAL|LOCAL)\s+)*TABLE\s+`)
+	createDomainRegexp    = regexp.MustCompile(`^\s*CREATE\s+DOMAIN\s+`)
+	copyRegexp            = regexp.MustCompile(`^\s*COPY\s+`)
+	alterTableOnlyRegexp  = regexp.MustCompile(`^\s*ALTER\s+TABLE\s+(?:ONLY\s+)?`)
+	attachPartitionRegexp = regexp.MustCompile(`^\s*ATTACH\s+PARTITION\s+`)
+	primaryKeyRegexp      = regexp.MustCompile(`^\s*ADD\s+CONSTRAINT\s+`)
+	inheritsRegexp        = regexp.MustCompile(`^\s*INHERITS\s*\(`)
+)
+
+type ddlColumn struct {
+	name        string
+	typeName    string
+	notNull     bool
+	isGenerated bool
+}
+
+type ddlTable struct {
+	schema      string
+	name        string
+	columns     []*ddlColumn
+	inherits    []*qualifiedName
+	partitioned bool
+}
+
+type qualifiedName struct {
+	schema string
+	name   string
+}
+
+func (qn *qualifiedName) String() string {
+	return fmt.Sprintf("%s.%s", qn.schema, qn.name)
+}
+
+// parseCreateTable - parses the CREATE TABLE statement generated by pg_dump and returns the table columns. Only the
+// first statement of the definition is parsed
+func parseCreateTable(defn string) (*ddlTable, error) {
+	loc := createTableRegexp.FindStringIndex(defn)
+	if loc == nil {
+		return nil, errors.New("definition does not start with CREATE TABLE statement")
+	}
+	qn, rest, err := parseQualifiedName(defn[loc[1]:])
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse table name: %w", err)
+	}
+	rest = strings.TrimLeft(rest, " \t\n")
+	if !strings.HasPrefix(rest, "(") {
+		return nil, errors.New("unsupported table definition: columns list is not found")
+	}
+	end := findClosingParenthesis(rest)
+	if end == -1 {
+		return nil, errors.New("columns list is not terminated")
+	}
+
+	t := &ddlTable{
+		schema: qn.schema,
+		name:   qn.name,
+	}
+	for _, item := range splitTopLevel(rest[1:end], ',') {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		words := scanTopLevelWords(item)
+		if slices.Contains(tableConstraintKeywords, words[0].text) {
+			continue
+		}
+		c, err := parseColumnDefinition(item)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse column definition \"%s\": %w", item, err)
+		}
+		t.columns = append(t.columns, c)
+	}
+
+	tail := rest[end+1:]
+	if loc = inheritsRegexp.FindStringIndex(tail); loc != nil {
+		tail = tail[loc[1]-1:]
+		inhEnd := findClosingParenthesis(tail)
+		if inhEnd == -1 {
+			return nil, errors.New("inherits list is not terminated")
+		}
+		for _, item := range splitTopLevel(tail[1:inhEnd], ',') {
+			parent, _, err := parseQualifiedName(strings.TrimSpace(item))
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse inherited table name: %w", err)
+			}
+			t.inherits = append(t.inherits, parent)
+		}
+		tail = tail[inhEnd+1:]
+	}
+	statementEnd := strings.Index(tail, ";")
+	if statementEnd != -1 {
+		tail = tail[:statementEnd]
+	}
+	t.partitioned = strings.Contains(tail, "PARTITION BY")
+	return t, nil
+}
+
+// parseColumnDefinition - parses column definition of CREATE TABLE statement
+func parseColumnDefinition(item string) (*ddlColumn, error) {
+	name, rest, err := parseIdentifier(item)
+	if err != nil {
+		return nil, err
+	}
+	c := &ddlColumn{
+		name: name,
+	}
+	words := scanTopLevelWords(rest)
+	typeEnd := len(rest)
+	for idx, w := range words {
+		if !slices.Contains(columnDefinitionKeywords, w.text) {
+			continue
+		}
+		if typeEnd == len(rest) {
+			typeEnd = w.start
+		}
+		switch w.text {
+		case "NOT":
+			if idx+1 < len(words) && words[idx+1].text == "NULL" {
+				c.notNull = true
+			}
+		case "GENERATED":
+			// Identity columns are generated by the separate statement, so there are only generated columns
+			// with expression: GENERATED ALWAYS AS (expr) [STORED|VIRTUAL]
+			if idx+3 < len(words) && words[idx+2].text == "AS" && strings.HasPrefix(words[idx+3].text, "(") {
+				c.isGenerated = true
+			}
+		}
+	}
+	c.typeName = strings.TrimSpace(rest[:typeEnd])
+	if c.typeName == "" {
+		return nil, errors.New("column type is empty")
+	}
+	return c, nil
+}
+
+// parseCopyStatement - parses COPY statement of TABLE DATA entry and returns table name and the list of columns
+func parseCopyStatement(stmt string) (*qualifiedName, []string, error) {
+	loc := copyRegexp.FindStringIndex(stmt)
+	if loc == nil {
+		return nil, nil, errors.New("statement does not start with COPY")
+	}
+	qn, rest, err := parseQualifiedName(stmt[loc[1]:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot parse table name: %w", err)
+	}
+	rest = strings.TrimLeft(rest, " \t\n")
+	if !strings.HasPrefix(rest, "(") {
+		// Columns list is not specified
+		return qn, nil, nil
+	}
+	end := findClosingParenthesis(rest)
+	if end == -1 {
+		return nil, nil, errors.New("columns list is not terminated")
+	}
+	var columns []string
+	for _, item := range splitTopLevel(rest[1:end], ',') {
+		name, _, err := parseIdentifier(strings.TrimSpace(item))
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot parse column name: %w", err)
+		}
+		columns = append(columns, name)
+	}
+	return qn, columns, nil
+}
+
+// parseCreateDomain - parses CREATE DOMAIN statement and returns domain name and its base type
+func parseCreateDomain(defn string) (*qualifiedName, string, error) {
+	loc := createDomainRegexp.FindStringIndex(defn)
+	if loc == nil {
+		return nil, "", errors.New("definition does not start with CREATE DOMAIN statement")
+	}
+	qn, rest, err := parseQualifiedName(defn[loc[1]:])
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot parse domain name: %w", err)
+	}
+	words := scanTopLevelWords(rest)
+	if len(words) < 2 || words[0].text != "AS" {
+		return nil, "", errors.New("domain base type is not found")
+	}
+	typeStart := words[1].start
+	typeEnd := len(rest)
+	for _, w := range words[1:] {
+		if slices.Contains(columnDefinitionKeywords, w.text) {
+			typeEnd = w.start
+			break
+		}
+	}
+	baseType := strings.TrimRight(strings.TrimSpace(rest[typeStart:typeEnd]), ";")
+	return qn, strings.TrimSpace(baseType), nil
+}
+
+// parseAttachPartition - parses ALTER TABLE ... ATTACH PARTITION statement of TABLE ATTACH entry and returns the
+// parent and the partition names
+func parseAttachPartition(defn string) (*qualifiedName, *qualifiedName, error) {
+	loc := alterTableOnlyRegexp.FindStringIndex(defn)
+	if loc == nil {
+		return nil, nil, errors.New("definition does not start with ALTER TABLE statement")
+	}
+	parent, rest, err := parseQualifiedName(defn[loc[1]:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot parse parent table name: %w", err)
+	}
+	loc = attachPartitionRegexp.FindStringIndex(rest)
+	if loc == nil {
+		return nil, nil, errors.New("ATTACH PARTITION clause is not found")
+	}
+	child, _, err := parseQualifiedName(rest[loc[1]:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot parse partition name: %w", err)
+	}
+	return parent, child, nil
+}
+
+// parsePrimaryKey - parses ALTER TABLE ... ADD CONSTRAINT ... PRIMARY KEY statement of CONSTRAINT entry. It returns
+// false if the constraint is not a primary key
+func parsePrimaryKey(defn string) (*qualifiedName, []string, bool) {
+	loc := alterTableOnlyRegexp.FindStringIndex(defn)
+	if loc == nil {
+		return nil, nil, false
+	}
+	qn, rest, err := parseQualifiedName(defn[loc[1]:])
+	if err != nil {
+		return nil, nil, false
+	}
+	loc = primaryKeyRegexp.FindStringIndex(rest)
+	if loc == nil {
+		return nil, nil, false
+	}
+	_, rest, err = parseIdentifier(rest[loc[1]:])
+	if err != nil {
+		return nil, nil, false
+	}
+	rest = strings.TrimLeft(rest, " \t\n")
+	if !strings.HasPrefix(rest, "PRIMARY KEY") {
+		return nil, nil, false
+	}
+	rest = strings.TrimLeft(strings.TrimPrefix(rest, "PRIMARY KEY"), " ")
+	end := findClosingParenthesis(rest)
+	if !strings.HasPrefix(rest, "(") || end == -1 {
+		return nil, nil, false
+	}
+	var columns []string
+	for _, item := range splitTopLevel(rest[1:end], ',') {
+		name, _, err := parseIdentifier(strings.TrimSpace(item))
+		if err != nil {
+			return nil, nil, false
+		}
+		columns = append(columns, name)
+	}
+	return qn, columns, true
+}
+
+// parseQualifiedName - parses schema qualified name. pg_dump always qualifies the objects names with schema
+func parseQualifiedName(s string) (*qualifiedName, string, error) {
+	schema, rest, err := parseIdentifier(s)
+	if err != nil {
+		return nil, "", err
+	}
+	if !strings.HasPrefix(rest, ".") {
+		return nil, "", fmt.Errorf("name \"%s\" is not schema qualified", schema)
+	}
+	name, rest, err := parseIdentifier(rest[1:])
+	if err != nil {
+		return nil, "", err
+	}
+	return &qualifiedName{schema: schema, name: name}, rest, nil
+}
+
+// parseIdentifier - parses quoted or unquoted identifier and returns its name and the rest of the string
+func parseIdentifier(s string) (string, string, error) {
+	s = strings.TrimLeft(s, " \t\n")
+	if s == "" {
+		return "", "", errors.New("identifier is empty")
+	}
+	if s[0] == '"' {
+		var sb strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '"' {
+				sb.WriteByte(s[i])
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '"' {
+				sb.WriteByte('"')
+				i++
+				continue
+			}
+			return sb.String(), s[i+1:], nil
+		}
+		return "", "", errors.New("quoted identifier is not terminated")
+	}
+	end := 0
+	for end < len(s) && isIdentifierChar(s[end]) {
+		end++
+	}
+	if end == 0 {
+		return "", "", fmt.Errorf("unexpected character '%c' in identifier", s[0])
+	}
+	return s[:end], s[end:], nil
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
+		c >= 0x80
+}
+
+// findClosingParenthesis - returns the index of parenthesis that closes the first character of the string
+func findClosingParenthesis(s string) int {
+	depth := 0
+	end := -1
+	scanTopLevel(s, func(idx int, c byte, level int) bool {
+		if level == -1 {
+			return true
+		}
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				end = idx
+				return false
+			}
+		}
+		return true
+	})
+	return end
+}
+
+// splitTopLevel - splits the string by separator that is not enclosed in parenthesis or quotes
+func splitTopLevel(s string, sep byte) []string {
+	var res []string
+	start := 0
+	scanTopLevel(s, func(idx int, c byte, level int) bool {
+		if c == sep && level == 0 {
+			res = append(res, s[start:idx])
+			start = idx + 1
+		}
+		return true
+	})
+	return append(res, s[start:])
+}
+
+type word struct {
+	text  string
+	start int
+}
+
+// scanTopLevelWords - splits the string by whitespaces that are not enclosed in parenthesis or quotes
+func scanTopLevelWords(s string) []word {
+	var res []word
+	start := -1
+	scanTopLevel(s, func(idx int, c byte, level int) bool {
+		isSpace := level == 0 && (c == ' ' || c == '\t' || c == '\n')
+		if isSpace && start != -1 {
+			res = append(res, word{text: s[start:idx], start: start})
+			start = -1
+		} else if !isSpace && start == -1 {
+			start = idx
+		}
+		return true
+	})
+	if start != -1 {
+		res = append(res, word{text: s[start:], start: start})
+	}
+	return res
+}
+
+// scanTopLevel - calls the function for each character outside quotes with the current parenthesis level. Quoted
+// characters are reported with level -1. The scan is stopped if the function returns false
+func scanTopLevel(s string, f func(idx int, c byte, level int) bool) {
+	level := 0
+	var quote byte
+	escapeString := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			if !f(i, c, -1) {
+				return
+			}
+			switch {
+			case escapeString && c == '\\':
+				i++
+			case c == quote && i+1 < len(s) && s[i+1] == quote:
+				i++
+			case c == quote:
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"':
+			quote = c
+			escapeString = c == '\'' && i > 0 && (s[i-1] == 'E' || s[i-1] == 'e')
+			if !f(i, c, level) {
+				return
+			}
+			continue
+		case ')':
+			level--
+			if !f(i, c, level) {
+				return
+			}
+			continue
+		}
+		if !f(i, c, level) {
+			return
+		}
+		if c == '(' {
+			level++
+		}
+	}
+}
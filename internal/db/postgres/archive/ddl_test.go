@@ -0,0 +1,89 @@
+package archive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCreateTable(t *testing.T) {
+	defn := `CREATE TABLE "my schema"."User ""Data""" (
+    id integer NOT NULL,
+    name character varying(255) DEFAULT 'a(b'::character varying,
+    "Full Name" text,
+    total numeric(10,2) GENERATED ALWAYS AS ((price * (2)::numeric)) STORED,
+    tags text[],
+    CONSTRAINT check_name CHECK ((length((name)::text) > 0))
+)
+INHERITS (public.base);
+`
+	dt, err := parseCreateTable(defn)
+	require.NoError(t, err)
+	assert.Equal(t, "my schema", dt.schema)
+	assert.Equal(t, `User "Data"`, dt.name)
+	assert.False(t, dt.partitioned)
+	require.Len(t, dt.inherits, 1)
+	assert.Equal(t, "public.base", dt.inherits[0].String())
+
+	expected := []*ddlColumn{
+		{name: "id", typeName: "integer", notNull: true},
+		{name: "name", typeName: "character varying(255)"},
+		{name: "Full Name", typeName: "text"},
+		{name: "total", typeName: "numeric(10,2)", isGenerated: true},
+		{name: "tags", typeName: "text[]"},
+	}
+	assert.Equal(t, expected, dt.columns)
+}
+
+func TestParseCreateTable_partitioned(t *testing.T) {
+	defn := `CREATE TABLE public.sales (
+    id bigint NOT NULL,
+    sale_date date NOT NULL
+)
+PARTITION BY RANGE (sale_date);
+`
+	dt, err := parseCreateTable(defn)
+	require.NoError(t, err)
+	assert.True(t, dt.partitioned)
+	assert.Len(t, dt.columns, 2)
+}
+
+func TestParseCopyStatement(t *testing.T) {
+	qn, columns, err := parseCopyStatement(`COPY "my schema"."User" (id, "Full Name", name) FROM stdin;` + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, "my schema.User", qn.String())
+	assert.Equal(t, []string{"id", "Full Name", "name"}, columns)
+}
+
+func TestParseCreateDomain(t *testing.T) {
+	qn, baseType, err := parseCreateDomain(
+		"CREATE DOMAIN public.email AS character varying(100)\n\tCONSTRAINT email_check CHECK ((VALUE ~~ '%@%'::text));\n",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "public.email", qn.String())
+	assert.Equal(t, "character varying(100)", baseType)
+}
+
+func TestParseAttachPartition(t *testing.T) {
+	parent, child, err := parseAttachPartition(
+		"ALTER TABLE ONLY public.sales ATTACH PARTITION public.sales_2024 FOR VALUES FROM ('2024-01-01') TO ('2025-01-01');\n",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "public.sales", parent.String())
+	assert.Equal(t, "public.sales_2024", child.String())
+}
+
+func TestParsePrimaryKey(t *testing.T) {
+	qn, columns, ok := parsePrimaryKey(
+		"ALTER TABLE ONLY public.orders\n    ADD CONSTRAINT orders_pkey PRIMARY KEY (id, \"Line\");\n",
+	)
+	require.True(t, ok)
+	assert.Equal(t, "public.orders", qn.String())
+	assert.Equal(t, []string{"id", "Line"}, columns)
+
+	_, _, ok = parsePrimaryKey(
+		"ALTER TABLE ONLY public.orders\n    ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES public.users(id);\n",
+	)
+	assert.False(t, ok)
+}
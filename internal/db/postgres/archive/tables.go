@@ -0,0 +1,258 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"fmt"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const (
+	tableDesc       = "TABLE"
+	tableAttachDesc = "TABLE ATTACH"
+	domainDesc      = "DOMAIN"
+	typeDesc        = "TYPE"
+	constraintDesc  = "CONSTRAINT"
+)
+
+// maxInheritanceDepth - protects from the cycles in the inheritance tree of the broken archive
+const maxInheritanceDepth = 100
+
+// GetTables - builds the tables of the archive using the pre-data DDL instead of the live catalog. The columns of
+// the table that has data are taken from the COPY statement of its TABLE DATA entry and their types are taken from
+// the CREATE TABLE statement including the inherited tables. The partitioned tables are returned as well, they do
+// not have data but might be used in the transformation config with apply_for_inherited
+func GetTables(t *toc.Toc) ([]*entries.Table, toolkit.ValidationWarnings, error) {
+	var warnings toolkit.ValidationWarnings
+	tr := newTypeResolver()
+	ddlTables := make(map[string]*ddlTable)
+	var ddlTablesOrder []string
+	tableOids := make(map[string]toolkit.Oid)
+	primaryKeys := make(map[string][]string)
+	partitions := make(map[string][]*qualifiedName)
+
+	for _, e := range t.Entries {
+		if e.Desc == nil || e.Defn == nil || *e.Defn == "" {
+			continue
+		}
+		switch *e.Desc {
+		case tableDesc:
+			dt, err := parseCreateTable(*e.Defn)
+			if err != nil {
+				warnings = append(warnings, newEntryWarning(e, "cannot parse table definition", err))
+				continue
+			}
+			key := (&qualifiedName{schema: dt.schema, name: dt.name}).String()
+			ddlTables[key] = dt
+			ddlTablesOrder = append(ddlTablesOrder, key)
+			tableOids[key] = toolkit.Oid(e.CatalogId.Oid)
+		case tableAttachDesc:
+			parent, child, err := parseAttachPartition(*e.Defn)
+			if err != nil {
+				warnings = append(warnings, newEntryWarning(e, "cannot parse partition attach definition", err))
+				continue
+			}
+			partitions[parent.String()] = append(partitions[parent.String()], child)
+		case domainDesc:
+			qn, baseType, err := parseCreateDomain(*e.Defn)
+			if err != nil {
+				warnings = append(warnings, newEntryWarning(e, "cannot parse domain definition", err))
+				continue
+			}
+			tr.domains[qn.String()] = baseType
+		case typeDesc:
+			if e.Namespace != nil && e.Tag != nil {
+				qn := &qualifiedName{schema: *e.Namespace, name: *e.Tag}
+				tr.customTypes[qn.String()] = toolkit.Oid(e.CatalogId.Oid)
+			}
+		case constraintDesc:
+			if qn, columns, ok := parsePrimaryKey(*e.Defn); ok {
+				primaryKeys[qn.String()] = columns
+			}
+		}
+	}
+
+	var tables []*entries.Table
+	tablesByName := make(map[string]*entries.Table)
+	for _, e := range t.Entries {
+		if e.Desc == nil || *e.Desc != toc.TableDataDesc {
+			continue
+		}
+		if e.Namespace == nil || e.Tag == nil || e.CopyStmt == nil {
+			return nil, nil, fmt.Errorf("table data entry %d does not have name or COPY statement", e.DumpId)
+		}
+		qn := &qualifiedName{schema: *e.Namespace, name: *e.Tag}
+		_, copyColumns, err := parseCopyStatement(*e.CopyStmt)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot parse COPY statement of table %s: %w", qn.String(), err)
+		}
+
+		table := &entries.Table{
+			Table: &toolkit.Table{
+				Schema:     qn.schema,
+				Name:       qn.name,
+				Oid:        toolkit.Oid(e.CatalogId.Oid),
+				Kind:       "r",
+				PrimaryKey: primaryKeys[qn.String()],
+			},
+			RelKind: 'r',
+			DumpId:  e.DumpId,
+		}
+		if e.Owner != nil {
+			table.Owner = *e.Owner
+		}
+		columns, columnsWarns := buildColumns(qn, copyColumns, ddlTables, tr)
+		table.EnrichWarningsWithTableName(columnsWarns)
+		warnings = append(warnings, columnsWarns...)
+		table.Columns = columns
+		tables = append(tables, table)
+		tablesByName[qn.String()] = table
+	}
+
+	// Partitioned tables do not have data, but they are required for the partitions transformation config
+	for _, key := range ddlTablesOrder {
+		dt := ddlTables[key]
+		if !dt.partitioned {
+			continue
+		}
+		table := &entries.Table{
+			Table: &toolkit.Table{
+				Schema:     dt.schema,
+				Name:       dt.name,
+				Oid:        tableOids[key],
+				Kind:       "p",
+				PrimaryKey: primaryKeys[key],
+			},
+			RelKind: 'p',
+		}
+		columns, columnsWarns := buildColumns(&qualifiedName{schema: dt.schema, name: dt.name}, nil, ddlTables, tr)
+		table.EnrichWarningsWithTableName(columnsWarns)
+		warnings = append(warnings, columnsWarns...)
+		table.Columns = columns
+		tables = append(tables, table)
+		tablesByName[key] = table
+	}
+
+	for parentKey, children := range partitions {
+		parent, ok := tablesByName[parentKey]
+		if !ok {
+			continue
+		}
+		for _, child := range children {
+			childTable, ok := tablesByName[child.String()]
+			if !ok {
+				continue
+			}
+			childTable.Parent = parent.Oid
+			parent.Children = append(parent.Children, childTable.Oid)
+		}
+	}
+
+	return tables, warnings, nil
+}
+
+// buildColumns - builds the table columns. If copyColumns is empty then all the columns that are not generated are
+// returned in the definition order
+func buildColumns(
+	qn *qualifiedName, copyColumns []string, ddlTables map[string]*ddlTable, tr *typeResolver,
+) ([]*toolkit.Column, toolkit.ValidationWarnings) {
+	var warnings toolkit.ValidationWarnings
+	ddlColumns := getDdlColumns(qn, ddlTables, 0)
+	if len(copyColumns) == 0 {
+		for _, c := range ddlColumns {
+			if !c.isGenerated {
+				copyColumns = append(copyColumns, c.name)
+			}
+		}
+	}
+
+	columns := make([]*toolkit.Column, 0, len(copyColumns))
+	for idx, name := range copyColumns {
+		column := &toolkit.Column{
+			Idx:               idx,
+			Name:              name,
+			Num:               toolkit.AttNum(idx + 1),
+			Length:            -1,
+			TypeLength:        -1,
+			TypeName:          "unknown",
+			CanonicalTypeName: "unknown",
+		}
+		columns = append(columns, column)
+
+		var dc *ddlColumn
+		for _, c := range ddlColumns {
+			if c.name == name {
+				dc = c
+				break
+			}
+		}
+		if dc == nil {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetMsg("column type is not found in the archive DDL: encode and decode operations are not supported").
+				SetSeverity(toolkit.WarningValidationSeverity).
+				AddMeta("ColumnName", name),
+			)
+			continue
+		}
+
+		column.TypeName = dc.typeName
+		column.NotNull = dc.notNull
+		ct, ok := tr.resolve(dc.typeName)
+		column.TypeOid = ct.oid
+		column.CanonicalTypeName = ct.canonicalName
+		column.Length = ct.typeMod
+		column.TypeLength = ct.length
+		if !ok {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetMsg("column has custom type that is not resolved from the archive DDL: encode and decode operations are not supported").
+				SetSeverity(toolkit.InfoValidationSeverity).
+				AddMeta("ColumnName", name).
+				AddMeta("TypeName", dc.typeName),
+			)
+		}
+	}
+	return columns, warnings
+}
+
+// getDdlColumns - returns the columns of the table including the columns inherited from the parent tables
+func getDdlColumns(qn *qualifiedName, ddlTables map[string]*ddlTable, depth int) []*ddlColumn {
+	dt, ok := ddlTables[qn.String()]
+	if !ok || depth > maxInheritanceDepth {
+		return nil
+	}
+	var res []*ddlColumn
+	for _, parent := range dt.inherits {
+		res = append(res, getDdlColumns(parent, ddlTables, depth+1)...)
+	}
+	return append(res, dt.columns...)
+}
+
+func newEntryWarning(e *toc.Entry, msg string, err error) *toolkit.ValidationWarning {
+	w := toolkit.NewValidationWarning().
+		SetMsg(msg).
+		SetSeverity(toolkit.WarningValidationSeverity).
+		AddMeta("DumpId", e.DumpId).
+		AddMeta("Error", err.Error())
+	if e.Namespace != nil {
+		w.AddMeta("SchemaName", *e.Namespace)
+	}
+	if e.Tag != nil {
+		w.AddMeta("ObjectName", *e.Tag)
+	}
+	return w
+}
@@ -0,0 +1,77 @@
+package archive
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func newTestEntry(dumpId int32, oid uint32, desc, namespace, tag, defn, copyStmt string) *toc.Entry {
+	e := &toc.Entry{
+		CatalogId: toc.CatalogId{Oid: toc.Oid(oid)},
+		DumpId:    dumpId,
+		Desc:      toc.NewObj(desc),
+		Namespace: toc.NewObj(namespace),
+		Tag:       toc.NewObj(tag),
+		Defn:      toc.NewObj(defn),
+	}
+	if copyStmt != "" {
+		e.CopyStmt = toc.NewObj(copyStmt)
+	}
+	return e
+}
+
+func TestGetTables(t *testing.T) {
+	archiveToc := &toc.Toc{
+		Header: &toc.Header{},
+		Entries: []*toc.Entry{
+			newTestEntry(1, 100, "DOMAIN", "public", "email",
+				"CREATE DOMAIN public.email AS text;\n", ""),
+			newTestEntry(2, 200, "TABLE", "public", "users",
+				"CREATE TABLE public.users (\n    id integer NOT NULL,\n    email public.email\n);\n", ""),
+			newTestEntry(3, 300, "TABLE", "public", "sales",
+				"CREATE TABLE public.sales (\n    id bigint NOT NULL,\n    day date\n)\nPARTITION BY RANGE (day);\n", ""),
+			newTestEntry(4, 301, "TABLE", "public", "sales_2024",
+				"CREATE TABLE public.sales_2024 (\n    id bigint NOT NULL,\n    day date\n);\n", ""),
+			newTestEntry(5, 301, "TABLE ATTACH", "public", "sales_2024",
+				"ALTER TABLE ONLY public.sales ATTACH PARTITION public.sales_2024 "+
+					"FOR VALUES FROM ('2024-01-01') TO ('2025-01-01');\n", ""),
+			newTestEntry(6, 400, "CONSTRAINT", "public", "users users_pkey",
+				"ALTER TABLE ONLY public.users\n    ADD CONSTRAINT users_pkey PRIMARY KEY (id);\n", ""),
+			newTestEntry(7, 200, "TABLE DATA", "public", "users",
+				"", "COPY public.users (id, email) FROM stdin;\n"),
+			newTestEntry(8, 301, "TABLE DATA", "public", "sales_2024",
+				"", "COPY public.sales_2024 (id, day) FROM stdin;\n"),
+		},
+	}
+
+	tables, warnings, err := GetTables(archiveToc)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	require.Len(t, tables, 3)
+
+	users := tables[0]
+	assert.Equal(t, "users", users.Name)
+	assert.Equal(t, int32(7), users.DumpId)
+	assert.Equal(t, []string{"id"}, users.PrimaryKey)
+	require.Len(t, users.Columns, 2)
+	assert.Equal(t, toolkit.Oid(pgtype.Int4OID), users.Columns[0].TypeOid)
+	assert.True(t, users.Columns[0].NotNull)
+	assert.Equal(t, "public.email", users.Columns[1].TypeName)
+	assert.Equal(t, toolkit.Oid(pgtype.TextOID), users.Columns[1].TypeOid)
+
+	partition := tables[1]
+	assert.Equal(t, "sales_2024", partition.Name)
+	assert.Equal(t, int32('r'), partition.RelKind)
+
+	parent := tables[2]
+	assert.Equal(t, "sales", parent.Name)
+	assert.Equal(t, int32('p'), parent.RelKind)
+	assert.Equal(t, []toolkit.Oid{301}, parent.Children)
+	assert.Equal(t, toolkit.Oid(300), partition.Parent)
+}
@@ -0,0 +1,188 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// sqlTypeNames - maps SQL standard type names produced by format_type to the internal PostgreSQL type names
+var sqlTypeNames = map[string]string{
+	"integer":                     "int4",
+	"smallint":                    "int2",
+	"bigint":                      "int8",
+	"real":                        "float4",
+	"double precision":            "float8",
+	"boolean":                     "bool",
+	"character varying":           "varchar",
+	"character":                   "bpchar",
+	"timestamp without time zone": "timestamp",
+	"timestamp with time zone":    "timestamptz",
+	"time without time zone":      "time",
+	"time with time zone":         "timetz",
+	"bit varying":                 "varbit",
+	`"char"`:                      "char",
+	"decimal":                     "numeric",
+}
+
+// typeLengths - pg_type.typlen of the fixed size built-in types. The other types are considered as varlena
+var typeLengths = map[string]int{
+	"bool":        1,
+	"char":        1,
+	"int2":        2,
+	"int4":        4,
+	"int8":        8,
+	"float4":      4,
+	"float8":      8,
+	"oid":         4,
+	"date":        4,
+	"time":        8,
+	"timetz":      12,
+	"timestamp":   8,
+	"timestamptz": 8,
+	"interval":    16,
+	"money":       8,
+	"uuid":        16,
+	"macaddr":     6,
+	"macaddr8":    8,
+	"point":       16,
+}
+
+var typeModifierRegexp = regexp.MustCompile(`\(([0-9, ]+)\)`)
+
+// columnType - the column type resolved from the type name used in the archive DDL
+type columnType struct {
+	oid           toolkit.Oid
+	canonicalName string
+	// typeMod - pg_attribute.atttypmod of the column
+	typeMod int
+	length  int
+}
+
+// typeResolver - resolves the type names used in the archive DDL into the built-in type oids. The domains are
+// resolved into their base types since the data of the domain is encoded the same way
+type typeResolver struct {
+	typeMap *pgtype.Map
+	// domains - map of qualified domain name to the base type name
+	domains map[string]string
+	// customTypes - map of qualified custom type name to its oid in the source database
+	customTypes map[string]toolkit.Oid
+}
+
+func newTypeResolver() *typeResolver {
+	return &typeResolver{
+		typeMap:     pgtype.NewMap(),
+		domains:     make(map[string]string),
+		customTypes: make(map[string]toolkit.Oid),
+	}
+}
+
+// resolve - returns the column type by its name. The second value is false if the type is not a built-in type or
+// a domain over built-in type
+func (tr *typeResolver) resolve(typeName string) (*columnType, bool) {
+	return tr.resolveWithDepth(typeName, 0)
+}
+
+func (tr *typeResolver) resolveWithDepth(typeName string, depth int) (*columnType, bool) {
+	name := strings.TrimSpace(typeName)
+	isArray := false
+	for strings.HasSuffix(name, "[]") {
+		isArray = true
+		name = strings.TrimSpace(strings.TrimSuffix(name, "[]"))
+	}
+
+	var modifiers []int
+	if m := typeModifierRegexp.FindStringSubmatch(name); m != nil {
+		for _, v := range strings.Split(m[1], ",") {
+			if i, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				modifiers = append(modifiers, i)
+			}
+		}
+		name = strings.Join(strings.Fields(typeModifierRegexp.ReplaceAllString(name, "")), " ")
+	}
+	if internalName, ok := sqlTypeNames[name]; ok {
+		name = internalName
+	}
+	name = strings.TrimPrefix(name, "pg_catalog.")
+
+	res := &columnType{
+		canonicalName: name,
+		typeMod:       getTypeMod(name, modifiers),
+		length:        -1,
+	}
+	if isArray {
+		res.canonicalName = "_" + name
+	} else if l, ok := typeLengths[name]; ok {
+		res.length = l
+	}
+
+	if t, ok := tr.typeMap.TypeForName(res.canonicalName); ok {
+		res.oid = toolkit.Oid(t.OID)
+		return res, true
+	}
+
+	// Custom types are always schema qualified in pg_dump output
+	key := name
+	if qn, rest, err := parseQualifiedName(name); err == nil && strings.TrimSpace(rest) == "" {
+		key = qn.String()
+	}
+
+	if baseType, ok := tr.domains[key]; ok && depth < 10 {
+		base, ok := tr.resolveWithDepth(baseType, depth+1)
+		if ok && isArray {
+			base.canonicalName = "_" + base.canonicalName
+			t, found := tr.typeMap.TypeForName(base.canonicalName)
+			if !found {
+				return res, false
+			}
+			base.oid = toolkit.Oid(t.OID)
+			base.length = -1
+		}
+		return base, ok
+	}
+
+	res.canonicalName = typeName
+	if oid, ok := tr.customTypes[key]; ok && !isArray {
+		res.oid = oid
+	}
+	return res, false
+}
+
+// getTypeMod - calculates pg_attribute.atttypmod value by the type modifiers
+func getTypeMod(name string, modifiers []int) int {
+	if len(modifiers) == 0 {
+		return -1
+	}
+	switch name {
+	case "varchar", "bpchar":
+		// VARHDRSZ is added to the length
+		return modifiers[0] + 4
+	case "numeric":
+		scale := 0
+		if len(modifiers) > 1 {
+			scale = modifiers[1]
+		}
+		return ((modifiers[0] << 16) | scale) + 4
+	case "timestamp", "timestamptz", "time", "timetz", "bit", "varbit":
+		return modifiers[0]
+	}
+	return -1
+}
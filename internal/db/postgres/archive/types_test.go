@@ -0,0 +1,68 @@
+package archive
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestTypeResolver_resolve(t *testing.T) {
+	tr := newTypeResolver()
+	tr.domains["public.email"] = "character varying(100)"
+	tr.customTypes["public.mood"] = 16500
+
+	tests := []struct {
+		name     string
+		typeName string
+		expected *columnType
+		ok       bool
+	}{
+		{
+			name:     "integer",
+			typeName: "integer",
+			expected: &columnType{oid: pgtype.Int4OID, canonicalName: "int4", typeMod: -1, length: 4},
+			ok:       true,
+		},
+		{
+			name:     "varchar with length",
+			typeName: "character varying(255)",
+			expected: &columnType{oid: pgtype.VarcharOID, canonicalName: "varchar", typeMod: 259, length: -1},
+			ok:       true,
+		},
+		{
+			name:     "timestamp with precision",
+			typeName: "timestamp(3) without time zone",
+			expected: &columnType{oid: pgtype.TimestampOID, canonicalName: "timestamp", typeMod: 3, length: 8},
+			ok:       true,
+		},
+		{
+			name:     "array",
+			typeName: "text[]",
+			expected: &columnType{oid: pgtype.TextArrayOID, canonicalName: "_text", typeMod: -1, length: -1},
+			ok:       true,
+		},
+		{
+			name:     "domain",
+			typeName: "public.email",
+			expected: &columnType{oid: pgtype.VarcharOID, canonicalName: "varchar", typeMod: 104, length: -1},
+			ok:       true,
+		},
+		{
+			name:     "custom type",
+			typeName: "public.mood",
+			expected: &columnType{oid: toolkit.Oid(16500), canonicalName: "public.mood", typeMod: -1, length: -1},
+			ok:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, ok := tr.resolve(tt.typeName)
+			require.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, res)
+		})
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+
+	storageDto "github.com/greenmaskio/greenmask/internal/db/postgres/storage"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/storages"
+	"github.com/greenmaskio/greenmask/internal/utils/cmd_runner"
+)
+
+const (
+	ClusterMetadataJsonFileName = "cluster.json"
+	GlobalsFileName             = "globals.sql"
+)
+
+const pgDumpAllExecutable = "pg_dumpall"
+
+// clusterDatabasesQuery - the query of the databases properties. The locale provider columns are substituted by
+// getClusterDatabasesQuery according to the server version
+const clusterDatabasesQuery = `
+	SELECT d.datname,
+	       pg_get_userbyid(d.datdba),
+	       pg_encoding_to_char(d.encoding),
+	       d.datcollate,
+	       d.datctype,
+	       %s,
+	       t.spcname
+	FROM pg_catalog.pg_database d
+		JOIN pg_catalog.pg_tablespace t ON t.oid = d.dattablespace
+	WHERE d.datallowconn
+	  AND NOT d.datistemplate
+	ORDER BY d.datname
+`
+
+// getClusterDatabasesQuery - returns the databases query for the server version. The locale provider is available
+// since PostgreSQL 15, the ICU locale column is renamed to datlocale in PostgreSQL 17
+func getClusterDatabasesQuery(version int) string {
+	var localeColumns string
+	switch {
+	case version >= 170000:
+		localeColumns = `CASE d.datlocprovider WHEN 'i' THEN 'icu' WHEN 'b' THEN 'builtin' ELSE 'libc' END,
+	       coalesce(d.datlocale, '')`
+	case version >= 150000:
+		localeColumns = `CASE d.datlocprovider WHEN 'i' THEN 'icu' ELSE 'libc' END,
+	       coalesce(d.daticulocale, '')`
+	default:
+		localeColumns = `'libc',
+	       ''`
+	}
+	return fmt.Sprintf(clusterDatabasesQuery, localeColumns)
+}
+
+// ClusterDump - dumps the globals using pg_dumpall and then each selected database into the sub storage with the
+// database name. Each database is dumped by Dump with its own transformation settings
+type ClusterDump struct {
+	cfg      *domains.Config
+	st       storages.Storager
+	registry *utils.TransformerRegistry
+	tmpDir   string
+	metadata *storageDto.ClusterMetadata
+	progress ProgressFunc
+}
+
+func NewClusterDump(cfg *domains.Config, st storages.Storager, registry *utils.TransformerRegistry) *ClusterDump {
+	return &ClusterDump{
+		cfg:      cfg,
+		st:       st,
+		registry: registry,
+		tmpDir:   path.Join(cfg.Common.TempDirectory, fmt.Sprintf("%d", time.Now().UnixNano())),
+	}
+}
+
+// SetProgressFunc - sets the progress callback of each database dump
+func (cd *ClusterDump) SetProgressFunc(f ProgressFunc) {
+	cd.progress = f
+}
+
+func (cd *ClusterDump) Run(ctx context.Context) error {
+	if err := os.Mkdir(cd.tmpDir, 0700); err != nil {
+		return fmt.Errorf("cannot create temp directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(cd.tmpDir); err != nil {
+			log.Debug().Err(err).Msg("error deleting temp dir")
+		}
+	}()
+
+	if len(cd.cfg.Dump.Transformation) > 0 || len(cd.cfg.Dump.VirtualReferences) > 0 {
+		log.Warn().
+			Msg("dump.transformation and dump.virtual_references are ignored in the cluster mode: " +
+				"use dump.cluster.databases instead")
+	}
+
+	cd.metadata = &storageDto.ClusterMetadata{
+		StartedAt:     time.Now(),
+		RolePasswords: cd.cfg.Dump.Cluster.RolePasswords,
+	}
+	if err := cd.gatherDatabases(ctx); err != nil {
+		return fmt.Errorf("cannot gather databases: %w", err)
+	}
+	if err := cd.writeMetadata(ctx); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	eg, gtx := errgroup.WithContext(ctx)
+	eg.Go(cd.writeHeartBeatWorker(gtx, done))
+	eg.Go(func() error {
+		if err := cd.dumpGlobals(gtx); err != nil {
+			return fmt.Errorf("cannot dump globals: %w", err)
+		}
+		for idx, db := range cd.metadata.Databases {
+			log.Info().Str("Database", db.Name).Msg("dumping database")
+			if err := cd.dumpDatabase(gtx, db.Name, idx == 0); err != nil {
+				return fmt.Errorf("cannot dump database \"%s\": %w", db.Name, err)
+			}
+		}
+		cd.metadata.CompletedAt = time.Now()
+		if err := cd.writeMetadata(gtx); err != nil {
+			return err
+		}
+		close(done)
+		return nil
+	})
+	return eg.Wait()
+}
+
+// gatherDatabases - collects the databases that must be dumped and their properties that are required to recreate
+// them during the restoration
+func (cd *ClusterDump) gatherDatabases(ctx context.Context) error {
+	dsn, err := cd.cfg.Dump.PgDumpOptions.GetPgDSN()
+	if err != nil {
+		return fmt.Errorf("cannot build connection string: %w", err)
+	}
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			log.Warn().Err(err).Msg("error closing connection")
+		}
+	}()
+
+	if err = conn.QueryRow(ctx, "SHOW server_version").Scan(&cd.metadata.DumpedFrom); err != nil {
+		return fmt.Errorf("cannot get server version: %w", err)
+	}
+
+	var version int
+	if err = conn.QueryRow(ctx, "SELECT current_setting('server_version_num')::INT").Scan(&version); err != nil {
+		return fmt.Errorf("cannot get server version number: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, getClusterDatabasesQuery(version))
+	if err != nil {
+		return fmt.Errorf("cannot get databases: %w", err)
+	}
+	defer rows.Close()
+	var databases []*storageDto.ClusterDatabase
+	for rows.Next() {
+		db := &storageDto.ClusterDatabase{}
+		if err = rows.Scan(
+			&db.Name, &db.Owner, &db.Encoding, &db.Collate, &db.Ctype, &db.LocaleProvider, &db.Locale, &db.Tablespace,
+		); err != nil {
+			return fmt.Errorf("cannot scan database: %w", err)
+		}
+		databases = append(databases, db)
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("cannot get databases: %w", err)
+	}
+
+	var res []*storageDto.ClusterDatabase
+	if len(cd.cfg.Dump.Cluster.Databases) > 0 {
+		for _, cdb := range cd.cfg.Dump.Cluster.Databases {
+			idx := slices.IndexFunc(databases, func(db *storageDto.ClusterDatabase) bool {
+				return db.Name == cdb.Name
+			})
+			if idx == -1 {
+				return fmt.Errorf("database \"%s\" is not found or does not allow connections", cdb.Name)
+			}
+			res = append(res, databases[idx])
+		}
+	} else {
+		res = databases
+	}
+
+	for _, db := range res {
+		if slices.Contains(cd.cfg.Dump.Cluster.ExcludeDatabases, db.Name) {
+			continue
+		}
+		cd.metadata.Databases = append(cd.metadata.Databases, db)
+	}
+	if len(cd.metadata.Databases) == 0 {
+		return fmt.Errorf("no databases to dump")
+	}
+	return nil
+}
+
+// dumpGlobals - dumps roles, memberships and tablespaces using pg_dumpall and stores the script in the storage
+func (cd *ClusterDump) dumpGlobals(ctx context.Context) error {
+	opts := &cd.cfg.Dump.PgDumpOptions
+	dsn, err := opts.GetPgDSN()
+	if err != nil {
+		return fmt.Errorf("cannot build connection string: %w", err)
+	}
+	fileName := path.Join(cd.tmpDir, GlobalsFileName)
+	args := []string{"--globals-only", "--file", fileName, "--dbname", dsn}
+	if !isConnectionString(opts.DbName) && opts.DbName != "" {
+		// pg_dumpall ignores the database name of the connection string
+		args = append(args, "--database", opts.DbName)
+	}
+	if !cd.cfg.Dump.Cluster.RolePasswords {
+		args = append(args, "--no-role-passwords")
+	}
+	if opts.NoTableSpaces {
+		args = append(args, "--no-tablespaces")
+	}
+	if opts.NoComments {
+		args = append(args, "--no-comments")
+	}
+	if opts.NoSecurityLabels {
+		args = append(args, "--no-security-labels")
+	}
+	executable := path.Join(cd.cfg.Common.PgBinPath, pgDumpAllExecutable)
+	log.Debug().Msgf("pg_dumpall: %s %s", executable, strings.Join(args, " "))
+	if err = cmd_runner.Run(ctx, &log.Logger, executable, args...); err != nil {
+		return err
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("cannot open globals file: %w", err)
+	}
+	defer f.Close()
+	if err = cd.st.PutObject(ctx, GlobalsFileName, f); err != nil {
+		return fmt.Errorf("cannot store globals file: %w", err)
+	}
+	return nil
+}
+
+// dumpDatabase - dumps the database into the sub storage with the database name. The custom transformers are
+// bootstrapped only once because the registry is shared between the databases
+func (cd *ClusterDump) dumpDatabase(ctx context.Context, name string, first bool) error {
+	dbName, err := replaceDbName(cd.cfg.Dump.PgDumpOptions.DbName, name)
+	if err != nil {
+		return err
+	}
+	cfg := *cd.cfg
+	cfg.Dump.PgDumpOptions.DbName = dbName
+	cfg.Dump.Transformation = nil
+	cfg.Dump.VirtualReferences = nil
+	cfg.Dump.Cluster = nil
+	idx := slices.IndexFunc(cd.cfg.Dump.Cluster.Databases, func(db *domains.ClusterDatabase) bool {
+		return db.Name == name
+	})
+	if idx != -1 {
+		cfg.Dump.Transformation = cd.cfg.Dump.Cluster.Databases[idx].Transformation
+		cfg.Dump.VirtualReferences = cd.cfg.Dump.Cluster.Databases[idx].VirtualReferences
+	}
+	if !first {
+		cfg.CustomTransformers = nil
+	}
+	d := NewDump(&cfg, cd.st.SubStorage(name, true), cd.registry)
+	d.SetProgressFunc(cd.progress)
+	return d.Run(ctx)
+}
+
+func (cd *ClusterDump) writeMetadata(ctx context.Context) error {
+	data, err := json.Marshal(cd.metadata)
+	if err != nil {
+		return fmt.Errorf("cannot marshal cluster metadata: %w", err)
+	}
+	if err = cd.st.PutObject(ctx, ClusterMetadataJsonFileName, bytes.NewBuffer(data)); err != nil {
+		return fmt.Errorf("cannot write cluster metadata: %w", err)
+	}
+	return nil
+}
+
+// writeHeartBeatWorker - writes the cluster dump heart beat file each HeartBeatWriteInterval and on the completion
+func (cd *ClusterDump) writeHeartBeatWorker(ctx context.Context, done chan struct{}) func() error {
+	return func() error {
+		if err := cd.st.PutObject(ctx, HeartBeatFileName, bytes.NewBufferString(HeartBeatInProgressContent)); err != nil {
+			return fmt.Errorf("error writing heartbeat: %w", err)
+		}
+		t := time.NewTicker(HeartBeatWriteInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-done:
+				if err := cd.st.PutObject(ctx, HeartBeatFileName, bytes.NewBufferString(HeartBeatDoneContent)); err != nil {
+					return fmt.Errorf("error writing heartbeat: %w", err)
+				}
+				return nil
+			case <-t.C:
+				if err := cd.st.PutObject(ctx, HeartBeatFileName, bytes.NewBufferString(HeartBeatInProgressContent)); err != nil {
+					return fmt.Errorf("error writing heartbeat: %w", err)
+				}
+			}
+		}
+	}
+}
+
+func isConnectionString(dbName string) bool {
+	return strings.HasPrefix(dbName, "postgresql://") ||
+		strings.HasPrefix(dbName, "postgres://") ||
+		strings.Contains(dbName, "=")
+}
+
+// replaceDbName - replaces the database name in the dbname option that might be a database name, URI or the
+// key-value connection string
+func replaceDbName(dbName, name string) (string, error) {
+	switch {
+	case strings.HasPrefix(dbName, "postgresql://") || strings.HasPrefix(dbName, "postgres://"):
+		u, err := url.Parse(dbName)
+		if err != nil {
+			return "", fmt.Errorf("cannot parse connection URI: %w", err)
+		}
+		u.Path = "/" + name
+		u.RawPath = "/" + url.PathEscape(name)
+		return u.String(), nil
+	case strings.Contains(dbName, "="):
+		// The last specified keyword value wins
+		escaped := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(name)
+		return fmt.Sprintf("%s dbname='%s'", dbName, escaped), nil
+	}
+	return name, nil
+}
@@ -0,0 +1,298 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	storageDto "github.com/greenmaskio/greenmask/internal/db/postgres/storage"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/storages"
+	"github.com/greenmaskio/greenmask/internal/utils/cmd_runner"
+)
+
+const psqlExecutable = "psql"
+
+const defaultTablespaceName = "pg_default"
+
+const (
+	localeProviderIcu     = "icu"
+	localeProviderBuiltin = "builtin"
+)
+
+// ClusterRestore - restores the globals and the databases of the cluster dump. The databases that do not exist are
+// created with the dumped properties and then restored by Restore
+type ClusterRestore struct {
+	binPath  string
+	st       storages.Storager
+	cfg      *domains.Restore
+	tmpDir   string
+	metadata *storageDto.ClusterMetadata
+	progress ProgressFunc
+}
+
+func NewClusterRestore(binPath string, st storages.Storager, cfg *domains.Restore, tmpDir string) *ClusterRestore {
+	return &ClusterRestore{
+		binPath: binPath,
+		st:      st,
+		cfg:     cfg,
+		tmpDir:  tmpDir,
+	}
+}
+
+// IsClusterDump - checks that the dump in the storage is the cluster dump
+func IsClusterDump(ctx context.Context, st storages.Storager) (bool, error) {
+	return st.Exists(ctx, ClusterMetadataJsonFileName)
+}
+
+// ReadClusterMetadata - reads the cluster dump metadata from the storage
+func ReadClusterMetadata(ctx context.Context, st storages.Storager) (*storageDto.ClusterMetadata, error) {
+	f, err := st.GetObject(ctx, ClusterMetadataJsonFileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open cluster metadata file: %w", err)
+	}
+	defer f.Close()
+	md := &storageDto.ClusterMetadata{}
+	if err = json.NewDecoder(f).Decode(md); err != nil {
+		return nil, fmt.Errorf("cannot decode cluster metadata: %w", err)
+	}
+	return md, nil
+}
+
+// SetProgressFunc - sets the progress callback of each database restoration
+func (cr *ClusterRestore) SetProgressFunc(f ProgressFunc) {
+	cr.progress = f
+}
+
+func (cr *ClusterRestore) Run(ctx context.Context) error {
+	md, err := ReadClusterMetadata(ctx, cr.st)
+	if err != nil {
+		return err
+	}
+	cr.metadata = md
+
+	databases, err := cr.getDatabases()
+	if err != nil {
+		return err
+	}
+
+	if !cr.cfg.Cluster.NoGlobals {
+		log.Info().Msg("restoring globals")
+		if err = cr.restoreGlobals(ctx); err != nil {
+			return fmt.Errorf("cannot restore globals: %w", err)
+		}
+	}
+
+	if err = cr.createDatabases(ctx, databases); err != nil {
+		return fmt.Errorf("cannot create databases: %w", err)
+	}
+
+	for _, db := range databases {
+		log.Info().Str("Database", db.Name).Msg("restoring database")
+		if err = cr.restoreDatabase(ctx, db.Name); err != nil {
+			return fmt.Errorf("cannot restore database \"%s\": %w", db.Name, err)
+		}
+	}
+	return nil
+}
+
+func (cr *ClusterRestore) getDatabases() ([]*storageDto.ClusterDatabase, error) {
+	if len(cr.cfg.Cluster.Databases) == 0 {
+		return cr.metadata.Databases, nil
+	}
+	var res []*storageDto.ClusterDatabase
+	for _, name := range cr.cfg.Cluster.Databases {
+		db := cr.metadata.GetDatabase(name)
+		if db == nil {
+			return nil, fmt.Errorf("database \"%s\" is not found in the cluster dump", name)
+		}
+		res = append(res, db)
+	}
+	return res, nil
+}
+
+// restoreGlobals - executes the globals script using psql. The errors do not stop the script execution, so the
+// existing roles and tablespaces are kept
+func (cr *ClusterRestore) restoreGlobals(ctx context.Context) error {
+	if err := os.MkdirAll(cr.tmpDir, 0700); err != nil {
+		return fmt.Errorf("cannot create temp directory: %w", err)
+	}
+	fileName := path.Join(cr.tmpDir, fmt.Sprintf("%d_%s", time.Now().UnixNano(), GlobalsFileName))
+	defer func() {
+		if err := os.Remove(fileName); err != nil {
+			log.Debug().Err(err).Msg("error deleting globals file")
+		}
+	}()
+	if err := cr.downloadGlobals(ctx, fileName); err != nil {
+		return err
+	}
+
+	dsn, err := cr.cfg.PgRestoreOptions.GetPgDSN()
+	if err != nil {
+		return fmt.Errorf("cannot build connection string: %w", err)
+	}
+	return cmd_runner.Run(
+		ctx, &log.Logger, path.Join(cr.binPath, psqlExecutable),
+		"--no-psqlrc", "--quiet", "--file", fileName, "--dbname", dsn,
+	)
+}
+
+func (cr *ClusterRestore) downloadGlobals(ctx context.Context, fileName string) error {
+	r, err := cr.st.GetObject(ctx, GlobalsFileName)
+	if err != nil {
+		return fmt.Errorf("cannot open globals file: %w", err)
+	}
+	defer r.Close()
+	f, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("cannot create globals file: %w", err)
+	}
+	defer f.Close()
+	if _, err = io.Copy(f, r); err != nil {
+		return fmt.Errorf("cannot download globals file: %w", err)
+	}
+	return nil
+}
+
+// createDatabases - creates the databases that do not exist in the target cluster
+func (cr *ClusterRestore) createDatabases(ctx context.Context, databases []*storageDto.ClusterDatabase) error {
+	dsn, err := cr.cfg.PgRestoreOptions.GetPgDSN()
+	if err != nil {
+		return fmt.Errorf("cannot build connection string: %w", err)
+	}
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			log.Warn().Err(err).Msg("error closing connection")
+		}
+	}()
+
+	var version int
+	if err = conn.QueryRow(ctx, "SELECT current_setting('server_version_num')::INT").Scan(&version); err != nil {
+		return fmt.Errorf("cannot get server version number: %w", err)
+	}
+
+	for _, db := range databases {
+		var exists, ownerExists, tablespaceExists bool
+		err = conn.QueryRow(
+			ctx,
+			`SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_database WHERE datname = $1),
+			        EXISTS (SELECT 1 FROM pg_catalog.pg_roles WHERE rolname = $2),
+			        EXISTS (SELECT 1 FROM pg_catalog.pg_tablespace WHERE spcname = $3)`,
+			db.Name, db.Owner, db.Tablespace,
+		).Scan(&exists, &ownerExists, &tablespaceExists)
+		if err != nil {
+			return fmt.Errorf("cannot check database \"%s\" existence: %w", db.Name, err)
+		}
+		if exists {
+			log.Info().Str("Database", db.Name).Msg("database already exists: skipping creation")
+			continue
+		}
+		if !ownerExists {
+			log.Warn().
+				Str("Database", db.Name).
+				Str("Owner", db.Owner).
+				Msg("database owner does not exist: the database is created with the current user as the owner")
+		}
+		if !tablespaceExists {
+			log.Warn().
+				Str("Database", db.Name).
+				Str("Tablespace", db.Tablespace).
+				Msg("database tablespace does not exist: the database is created in the default tablespace")
+		}
+		if !isLocaleProviderSupported(db.LocaleProvider, version) {
+			log.Warn().
+				Str("Database", db.Name).
+				Str("LocaleProvider", db.LocaleProvider).
+				Str("Locale", db.Locale).
+				Msg("database locale provider is not supported by the target server: the database is created with libc locale provider")
+		}
+		query := generateCreateDatabaseQuery(db, ownerExists, tablespaceExists, version)
+		log.Debug().Str("Query", query).Msg("creating database")
+		if _, err = conn.Exec(ctx, query); err != nil {
+			return fmt.Errorf("cannot create database \"%s\": %w", db.Name, err)
+		}
+	}
+	return nil
+}
+
+func (cr *ClusterRestore) restoreDatabase(ctx context.Context, name string) error {
+	dbName, err := replaceDbName(cr.cfg.PgRestoreOptions.DbName, name)
+	if err != nil {
+		return err
+	}
+	cfg := *cr.cfg
+	cfg.PgRestoreOptions.DbName = dbName
+	r := NewRestore(cr.binPath, cr.st.SubStorage(name, true), &cfg, cfg.Scripts, cr.tmpDir)
+	r.SetProgressFunc(cr.progress)
+	return r.Run(ctx)
+}
+
+// generateCreateDatabaseQuery - generates the database creation query. The icu and builtin locale providers are set
+// only if the target server version supports them, otherwise the database is created with libc locale provider
+func generateCreateDatabaseQuery(
+	db *storageDto.ClusterDatabase, withOwner, withTablespace bool, version int,
+) string {
+	query := fmt.Sprintf(
+		"CREATE DATABASE %s TEMPLATE template0 ENCODING %s LC_COLLATE %s LC_CTYPE %s",
+		pgx.Identifier{db.Name}.Sanitize(), quoteLiteral(db.Encoding), quoteLiteral(db.Collate),
+		quoteLiteral(db.Ctype),
+	)
+	if isLocaleProviderSupported(db.LocaleProvider, version) {
+		switch db.LocaleProvider {
+		case localeProviderIcu:
+			query += fmt.Sprintf(" LOCALE_PROVIDER icu ICU_LOCALE %s", quoteLiteral(db.Locale))
+		case localeProviderBuiltin:
+			query += fmt.Sprintf(" LOCALE_PROVIDER builtin BUILTIN_LOCALE %s", quoteLiteral(db.Locale))
+		}
+	}
+	if withOwner {
+		query += fmt.Sprintf(" OWNER %s", pgx.Identifier{db.Owner}.Sanitize())
+	}
+	if withTablespace && db.Tablespace != defaultTablespaceName {
+		query += fmt.Sprintf(" TABLESPACE %s", pgx.Identifier{db.Tablespace}.Sanitize())
+	}
+	return query
+}
+
+// isLocaleProviderSupported - checks that the target server version supports the locale provider. The icu locale
+// provider is supported since PostgreSQL 15 and the builtin locale provider since PostgreSQL 17
+func isLocaleProviderSupported(provider string, version int) bool {
+	switch provider {
+	case localeProviderIcu:
+		return version >= 150000
+	case localeProviderBuiltin:
+		return version >= 170000
+	default:
+		return true
+	}
+}
+
+func quoteLiteral(v string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(v, "'", "''"))
+}
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -33,7 +34,9 @@ import (
 	"github.com/greenmaskio/greenmask/internal/db/postgres/dumpers"
 	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
 	"github.com/greenmaskio/greenmask/internal/db/postgres/pgdump"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/schemarewrite"
 	storageDto "github.com/greenmaskio/greenmask/internal/db/postgres/storage"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/subset"
 	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
 	_ "github.com/greenmaskio/greenmask/internal/db/postgres/transformers"
 	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/custom"
@@ -45,6 +48,7 @@ import (
 
 const (
 	MetadataJsonFileName = "metadata.json"
+	ReportJsonFileName   = "report.json"
 	HeartBeatFileName    = "heartbeat"
 )
 
@@ -83,6 +87,11 @@ type Dump struct {
 	// validate shows that dump worker must be in validation mode
 	validate          bool
 	validateRowsLimit uint64
+	// schemaRewriter - rewrites the TOC entries definitions before the TOC is stored. It is nil if the schema
+	// rewrite is not configured
+	schemaRewriter *schemarewrite.Rewriter
+	// progress - reports the dump stages and the dumped data objects
+	progress *ProgressReporter
 }
 
 func NewDump(cfg *domains.Config, st storages.Storager, registry *utils.TransformerRegistry) *Dump {
@@ -99,6 +108,11 @@ func NewDump(cfg *domains.Config, st storages.Storager, registry *utils.Transfor
 	}
 }
 
+// SetProgressFunc - sets the callback that is called on each dump stage and dumped data object
+func (d *Dump) SetProgressFunc(f ProgressFunc) {
+	d.progress = NewProgressReporter(f)
+}
+
 func (d *Dump) prune() {
 	d.schemaToc = nil
 	d.context = nil
@@ -186,13 +200,26 @@ func (d *Dump) startMainTx(ctx context.Context, conn *pgx.Conn) (pgx.Tx, error)
 	return tx, nil
 }
 
-func (d *Dump) buildContextAndValidate(ctx context.Context, tx pgx.Tx) (err error) {
-	d.context, err = runtimeContext.NewRuntimeContext(
+// newRuntimeContext - loads the subset seeds and builds the runtime context. It is the shared step of all the
+// commands that build the runtime context of the dump config
+func (d *Dump) newRuntimeContext(ctx context.Context, tx pgx.Tx) (*runtimeContext.RuntimeContext, error) {
+	if err := d.loadSubsetSeeds(ctx); err != nil {
+		return nil, fmt.Errorf("cannot load subset seeds: %w", err)
+	}
+	rc, err := runtimeContext.NewRuntimeContext(
 		ctx, tx, &d.config.Dump, d.registry,
 		d.config.Dump.VirtualReferences, d.version,
 	)
 	if err != nil {
-		return fmt.Errorf("unable to build runtime context: %w", err)
+		return nil, fmt.Errorf("unable to build runtime context: %w", err)
+	}
+	return rc, nil
+}
+
+func (d *Dump) buildContextAndValidate(ctx context.Context, tx pgx.Tx) (err error) {
+	d.context, err = d.newRuntimeContext(ctx, tx)
+	if err != nil {
+		return err
 	}
 	// TODO: Implement warnings hook, such as logging and HTTP sender
 	for _, w := range d.context.Warnings {
@@ -432,6 +459,10 @@ func (d *Dump) mergeAndWriteToc(ctx context.Context) error {
 		return fmt.Errorf("unable to mergeAndWriteToc TOC files: %w", err)
 	}
 
+	if d.schemaRewriter != nil {
+		mergedEntries = d.schemaRewriter.Rewrite(mergedEntries)
+	}
+
 	log.Debug().Msg("writing built toc file into storage")
 	// Create TOC
 	mergedHeader := *d.schemaToc.Header
@@ -456,6 +487,19 @@ func (d *Dump) mergeAndWriteToc(ctx context.Context) error {
 	return nil
 }
 
+// setSchemaRewriter - builds the schema rewriter if the schema rewrite is configured
+func (d *Dump) setSchemaRewriter() error {
+	if d.config.Dump.SchemaRewrite == nil {
+		return nil
+	}
+	rewriter, err := schemarewrite.NewRewriter(d.config.Dump.SchemaRewrite)
+	if err != nil {
+		return fmt.Errorf("cannot build schema rewriter: %w", err)
+	}
+	d.schemaRewriter = rewriter
+	return nil
+}
+
 func (d *Dump) writeMetaData(ctx context.Context, startedAt, completedAt time.Time) error {
 	cycles := d.context.Graph.GetCycledTables()
 	metadata, err := storageDto.NewMetadata(
@@ -465,6 +509,7 @@ func (d *Dump) writeMetaData(ctx context.Context, startedAt, completedAt time.Ti
 	if err != nil {
 		return fmt.Errorf("unable build metadata: %w", err)
 	}
+	metadata.TransformationErrors = storageDto.NewTransformationErrors(d.dumpedTables())
 
 	buf := bytes.NewBuffer(make([]byte, 0, 1024))
 	if err = json.NewEncoder(buf).Encode(metadata); err != nil {
@@ -477,6 +522,35 @@ func (d *Dump) writeMetaData(ctx context.Context, startedAt, completedAt time.Ti
 	return nil
 }
 
+// dumpedTables - returns the tables of the data section
+func (d *Dump) dumpedTables() []*entries.Table {
+	var tables []*entries.Table
+	for _, obj := range d.context.DataSectionObjects {
+		if t, ok := obj.(*entries.Table); ok {
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
+
+// writeReport - writes masking audit report with the transformation statistics of the dumped tables
+func (d *Dump) writeReport(ctx context.Context, startedAt, completedAt time.Time) error {
+	report, err := storageDto.NewReport(startedAt, completedAt, d.dumpedTables())
+	if err != nil {
+		return fmt.Errorf("unable build report: %w", err)
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	if err = json.NewEncoder(buf).Encode(report); err != nil {
+		return fmt.Errorf("error encoding report.json: %w", err)
+	}
+
+	if err = d.st.PutObject(ctx, ReportJsonFileName, buf); err != nil {
+		return fmt.Errorf("error writing report to the storage: %w", err)
+	}
+	return nil
+}
+
 func (d *Dump) Run(ctx context.Context) (err error) {
 	defer d.prune()
 	startedAt := time.Now()
@@ -484,6 +558,9 @@ func (d *Dump) Run(ctx context.Context) (err error) {
 	if err := custom.BootstrapCustomTransformers(ctx, d.registry, d.config.CustomTransformers); err != nil {
 		return fmt.Errorf("error bootstraping custom transformers: %w", err)
 	}
+	if err := d.setSchemaRewriter(); err != nil {
+		return err
+	}
 
 	dsn, err := d.pgDumpOptions.GetPgDSN()
 	if err != nil {
@@ -514,14 +591,29 @@ func (d *Dump) Run(ctx context.Context) (err error) {
 		return fmt.Errorf("error gathering facts: %w", err)
 	}
 
+	ctx, v, err := openVault(ctx, &d.config.Dump, false)
+	if err != nil {
+		return err
+	}
+	if v != nil {
+		// The file vault is written on close, so the error must fail the dump
+		defer func() {
+			if closeErr := v.Close(); closeErr != nil {
+				err = errors.Join(err, fmt.Errorf("cannot close vault: %w", closeErr))
+			}
+		}()
+	}
+
 	if err := d.buildContextAndValidate(ctx, tx); err != nil {
 		return fmt.Errorf("context error: %w", err)
 	}
 
+	d.progress.Stage(SchemaProgressStage)
 	if err = d.schemaOnlyDump(ctx, tx); err != nil {
 		return fmt.Errorf("schema only stage dumping error: %w", err)
 	}
 
+	d.progress.Stage(DataProgressStage)
 	if err = d.dataDump(ctx); err != nil {
 		return fmt.Errorf("data stage dumping error: %w", err)
 	}
@@ -530,9 +622,15 @@ func (d *Dump) Run(ctx context.Context) (err error) {
 		return fmt.Errorf("mergeAndWriteToc stage dumping error: %w", err)
 	}
 
-	if err = d.writeMetaData(ctx, startedAt, time.Now()); err != nil {
+	completedAt := time.Now()
+	if err = d.writeReport(ctx, startedAt, completedAt); err != nil {
+		return fmt.Errorf("writeReport stage dumping error: %w", err)
+	}
+
+	if err = d.writeMetaData(ctx, startedAt, completedAt); err != nil {
 		return fmt.Errorf("writeMetaData stage dumping error: %w", err)
 	}
+	d.progress.Stage(CompletedProgressStage)
 
 	return nil
 }
@@ -605,6 +703,30 @@ func (d *Dump) getWorkerTransaction(ctx context.Context) (*pgx.Conn, pgx.Tx, err
 	return conn, tx, nil
 }
 
+// materializeKeySets - creates the subset key sets required by the table query that are not created in the worker
+// transaction yet. The temporary tables are visible only in the current session, so each worker creates only the key
+// sets of the tables it dumps
+func (d *Dump) materializeKeySets(
+	ctx context.Context, tx pgx.Tx, task dumpers.DumpTask, materialized map[string]struct{},
+) error {
+	tt, ok := task.(dumpers.TableTask)
+	if !ok || len(d.context.SubsetKeySets) == 0 || tt.Table().Query == "" {
+		return nil
+	}
+	for _, ks := range subset.RequiredKeySets(tt.Table().Query, d.context.SubsetKeySets) {
+		if _, ok := materialized[ks.Name]; ok {
+			continue
+		}
+		for _, q := range ks.Statements {
+			if _, err := tx.Exec(ctx, q); err != nil {
+				return fmt.Errorf("cannot materialise subset key set %s: %w", ks.Name, err)
+			}
+		}
+		materialized[ks.Name] = struct{}{}
+	}
+	return nil
+}
+
 func (d *Dump) dumpWorker(
 	ctx context.Context, tasks <-chan dumpers.DumpTask, id int,
 ) error {
@@ -614,6 +736,8 @@ func (d *Dump) dumpWorker(
 	if err != nil {
 		return fmt.Errorf("error preparing worker (id=%d) transaction: %w", id, err)
 	}
+	// materialized - the subset key sets created in the worker transaction
+	materialized := make(map[string]struct{})
 
 	defer func() {
 		if err := conn.Close(ctx); err != nil {
@@ -652,9 +776,13 @@ func (d *Dump) dumpWorker(
 			Str("ObjectName", task.DebugInfo()).
 			Msgf("dumping started")
 
+		if err = d.materializeKeySets(ctx, tx, task, materialized); err != nil {
+			return err
+		}
 		if err = task.Execute(ctx, tx, d.st); err != nil {
 			return err
 		}
+		d.progress.ObjectDone(DataProgressStage, task.DebugInfo())
 
 		log.Debug().
 			Int("WorkerId", id).
@@ -718,6 +846,9 @@ func (d *Dump) validateDumpExecuteTask(ctx context.Context, id int, task dumpers
 		}
 	}()
 
+	if err = d.materializeKeySets(ctx, tx, task, make(map[string]struct{})); err != nil {
+		return err
+	}
 	if err = task.Execute(ctx, tx, d.st); err != nil {
 		return err
 	}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	runtimeContext "github.com/greenmaskio/greenmask/internal/db/postgres/context"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/dumpers"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/export"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/subset"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+type txMock struct {
+	pgx.Tx
+	mock.Mock
+}
+
+func (m *txMock) Exec(ctx context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	args := m.Called(ctx, sql)
+	return pgconn.CommandTag{}, args.Error(0)
+}
+
+func TestDump_materializeKeySets(t *testing.T) {
+	ctx := context.Background()
+	keySets := []*subset.KeySet{
+		{Name: `pg_temp."__subset_keys_1"`, Statements: []string{"CREATE users keys", "ANALYZE users keys"}},
+		{Name: `pg_temp."__subset_keys_2"`, Statements: []string{"CREATE orders keys"}},
+		{Name: `pg_temp."__subset_keys_3"`, Statements: []string{"CREATE payments keys"}},
+	}
+	usersQuery := `SELECT * FROM items WHERE id IN (SELECT id FROM pg_temp."__subset_keys_1")`
+	ordersQuery := `SELECT * FROM items WHERE id IN (SELECT id FROM pg_temp."__subset_keys_2")`
+	newTable := func(query string) *entries.Table {
+		return &entries.Table{
+			Table: &toolkit.Table{Schema: "public", Name: "items"},
+			Query: query,
+		}
+	}
+	d := &Dump{
+		context: &runtimeContext.RuntimeContext{SubsetKeySets: keySets},
+	}
+
+	tests := []struct {
+		name     string
+		task     dumpers.DumpTask
+		expected []string
+	}{
+		{
+			name:     "table dumper",
+			task:     dumpers.NewTableDumper(newTable(usersQuery), false, 0, false),
+			expected: []string{"CREATE users keys", "ANALYZE users keys"},
+		},
+		{
+			name:     "table exporter",
+			task:     dumpers.NewTableExporter(newTable(ordersQuery), &dumpers.ExportOptions{}),
+			expected: []string{"CREATE orders keys"},
+		},
+		{
+			name: "table without subset query",
+			task: dumpers.NewTableExporter(newTable(""), &dumpers.ExportOptions{}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := new(txMock)
+			for _, q := range tt.expected {
+				tx.On("Exec", ctx, q).Return(nil).Once()
+			}
+			require.NoError(t, d.materializeKeySets(ctx, tx, tt.task, make(map[string]struct{})))
+			tx.AssertExpectations(t)
+			assert.Len(t, tx.Calls, len(tt.expected))
+		})
+	}
+
+	t.Run("already materialised", func(t *testing.T) {
+		tx := new(txMock)
+		task := dumpers.NewTableExporter(newTable(`SELECT * FROM pg_temp."__subset_keys_3"`), &dumpers.ExportOptions{})
+		materialized := map[string]struct{}{`pg_temp."__subset_keys_3"`: {}}
+		require.NoError(t, d.materializeKeySets(ctx, tx, task, materialized))
+		assert.Empty(t, tx.Calls)
+	})
+}
+
+func TestExport_buildContextAndValidate_subsetSeeds(t *testing.T) {
+	ctx := context.Background()
+	newExport := func(source string) (*Export, *domains.SubsetSeeds) {
+		seeds := &domains.SubsetSeeds{Source: source}
+		cfg := &domains.Config{}
+		cfg.Export.Format = export.CsvFormat
+		cfg.Dump.Transformation = []*domains.Table{{Schema: "public", Name: "users", SubsetSeeds: seeds}}
+		e, err := NewExport(cfg, nil, nil)
+		require.NoError(t, err)
+		return e, seeds
+	}
+
+	t.Run("seeds are loaded", func(t *testing.T) {
+		source := filepath.Join(t.TempDir(), "users.txt")
+		require.NoError(t, os.WriteFile(source, []byte("123\n456\n"), 0600))
+		e, seeds := newExport(source)
+		// The invalid salt stops the context building before the database is queried
+		t.Setenv("GREENMASK_GLOBAL_SALT", "not hex")
+
+		err := e.buildContextAndValidate(ctx, nil)
+		require.ErrorContains(t, err, "cannot set salt")
+		assert.Equal(t, [][]string{{"123"}, {"456"}}, seeds.Values)
+	})
+
+	t.Run("source error", func(t *testing.T) {
+		e, _ := newExport(filepath.Join(t.TempDir(), "unknown.txt"))
+		err := e.buildContextAndValidate(ctx, nil)
+		require.ErrorContains(t, err, "cannot load subset seeds")
+	})
+}
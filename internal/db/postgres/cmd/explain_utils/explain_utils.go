@@ -0,0 +1,168 @@
+package explain_utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/subset"
+)
+
+const (
+	TextFormat = "text"
+	JsonFormat = "json"
+	DotFormat  = "dot"
+)
+
+func ValidateFormat(format string) error {
+	switch format {
+	case TextFormat, JsonFormat, DotFormat:
+		return nil
+	}
+	return fmt.Errorf("unknown format \"%s\": possible values [text|json|dot]", format)
+}
+
+// Print - prints the subset plan in the requested format
+func Print(w io.Writer, p *subset.Plan, format string) error {
+	switch format {
+	case TextFormat:
+		return printText(w, p)
+	case JsonFormat:
+		return printJson(w, p)
+	case DotFormat:
+		return printDot(w, p)
+	}
+	return ValidateFormat(format)
+}
+
+func printJson(w io.Writer, p *subset.Plan) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(p); err != nil {
+		return fmt.Errorf("error encoding plan: %w", err)
+	}
+	return nil
+}
+
+func printText(w io.Writer, p *subset.Plan) error {
+	if _, err := fmt.Fprintln(w, "Tables:"); err != nil {
+		return err
+	}
+	prettyWriter := tablewriter.NewWriter(w)
+	prettyWriter.SetAutoWrapText(false)
+	prettyWriter.SetHeader([]string{"Table", "Mode", "Rows Before", "Rows After", "Cost After"})
+	for _, t := range p.Tables {
+		prettyWriter.Append([]string{
+			t.FullName(), t.Mode, formatRows(t.Before), formatRows(t.After), formatCost(t.After),
+		})
+	}
+	prettyWriter.Render()
+
+	if len(p.Components) > 0 {
+		if _, err := fmt.Fprintln(w, "\nCycles:"); err != nil {
+			return err
+		}
+		for _, c := range p.Components {
+			if _, err := fmt.Fprintf(w, "  component %d: %s\n", c.Id, strings.Join(c.Tables, ", ")); err != nil {
+				return err
+			}
+			for _, cycle := range c.Cycles {
+				if _, err := fmt.Fprintf(w, "    %s\n", strings.Join(cycle, " -> ")); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, t := range p.Tables {
+		if t.Mode == subset.PlanTableModeFull {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "\n%s (%s):\n", t.FullName(), t.Mode); err != nil {
+			return err
+		}
+		for _, c := range t.SubsetConds {
+			if _, err := fmt.Fprintf(w, "  condition: %s\n", c); err != nil {
+				return err
+			}
+		}
+		for _, r := range t.Path {
+			if _, err := fmt.Fprintf(w, "  path: %s\n", formatReference(r)); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "  query: %s\n", t.Query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printDot - prints the tables graph in Graphviz DOT format. The subsetted tables are filled, the nullable references
+// are dashed and the references of the cycles are red
+func printDot(w io.Writer, p *subset.Plan) error {
+	cycleEdges := make(map[string]struct{})
+	for _, c := range p.Components {
+		for _, cycle := range c.Cycles {
+			for idx := 0; idx < len(cycle)-1; idx++ {
+				cycleEdges[cycle[idx]+"->"+cycle[idx+1]] = struct{}{}
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("digraph subset {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box];\n")
+	for _, t := range p.Tables {
+		attrs := []string{fmt.Sprintf("label=%s", strconv.Quote(fmt.Sprintf("%s\n%s", t.FullName(), t.Mode)))}
+		if t.Mode != subset.PlanTableModeFull {
+			attrs = append(attrs, "style=filled", "fillcolor=lightblue")
+		}
+		sb.WriteString(fmt.Sprintf("  %s [%s];\n", strconv.Quote(t.FullName()), strings.Join(attrs, ", ")))
+	}
+	for _, r := range p.References {
+		attrs := []string{fmt.Sprintf("label=%s", strconv.Quote(strings.Join(r.Keys, ", ")))}
+		if r.IsNullable {
+			attrs = append(attrs, "style=dashed")
+		}
+		if _, ok := cycleEdges[r.From+"->"+r.To]; ok {
+			attrs = append(attrs, "color=red")
+		}
+		sb.WriteString(fmt.Sprintf(
+			"  %s -> %s [%s];\n", strconv.Quote(r.From), strconv.Quote(r.To), strings.Join(attrs, ", "),
+		))
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func formatReference(r *subset.PlanReference) string {
+	res := fmt.Sprintf("%s(%s) -> %s", r.From, strings.Join(r.Keys, ", "), r.To)
+	if r.IsNullable {
+		res += " [nullable]"
+	}
+	return res
+}
+
+func formatRows(e *subset.PlanEstimation) string {
+	if e == nil {
+		return ""
+	}
+	if e.Exact {
+		return strconv.FormatInt(e.Rows, 10)
+	}
+	return fmt.Sprintf("~%d", e.Rows)
+}
+
+func formatCost(e *subset.PlanEstimation) string {
+	if e == nil || e.Exact {
+		return ""
+	}
+	return strconv.FormatFloat(e.Cost, 'f', 2, 64)
+}
@@ -0,0 +1,90 @@
+package explain_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/subset"
+)
+
+func getPlan() *subset.Plan {
+	return &subset.Plan{
+		Tables: []*subset.PlanTable{
+			{
+				Schema:      "public",
+				Name:        "orders",
+				Mode:        subset.PlanTableModeSubsetConds,
+				SubsetConds: []string{"public.orders.id < 10"},
+				Query:       `SELECT "public"."orders".* FROM "public"."orders" WHERE public.orders.id < 10`,
+				Before:      &subset.PlanEstimation{Rows: 1000, Cost: 15.5},
+				After:       &subset.PlanEstimation{Rows: 10, Cost: 8.25},
+			},
+			{
+				Schema:    "public",
+				Name:      "employees",
+				Component: 1,
+				Mode:      subset.PlanTableModeFull,
+			},
+			{
+				Schema:    "public",
+				Name:      "departments",
+				Component: 1,
+				Mode:      subset.PlanTableModeFull,
+			},
+		},
+		References: []*subset.PlanReference{
+			{From: "public.employees", To: "public.departments", Keys: []string{"department_id"}},
+			{From: "public.departments", To: "public.employees", Keys: []string{"manager_id"}, IsNullable: true},
+		},
+		Components: []*subset.PlanComponent{
+			{
+				Id:     1,
+				Tables: []string{"public.employees", "public.departments"},
+				Cycles: [][]string{{"public.employees", "public.departments", "public.employees"}},
+			},
+		},
+	}
+}
+
+func TestPrint_Dot(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, Print(buf, getPlan(), DotFormat))
+	expected := `digraph subset {
+  rankdir=LR;
+  node [shape=box];
+  "public.orders" [label="public.orders\nsubset_conds", style=filled, fillcolor=lightblue];
+  "public.employees" [label="public.employees\nfull"];
+  "public.departments" [label="public.departments\nfull"];
+  "public.employees" -> "public.departments" [label="department_id", color=red];
+  "public.departments" -> "public.employees" [label="manager_id", style=dashed, color=red];
+}
+`
+	require.Equal(t, expected, buf.String())
+}
+
+func TestPrint_Json(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, Print(buf, getPlan(), JsonFormat))
+	res := &subset.Plan{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), res))
+	require.Equal(t, getPlan(), res)
+}
+
+func TestPrint_Text(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, Print(buf, getPlan(), TextFormat))
+	out := buf.String()
+	require.Contains(t, out, "~1000")
+	require.Contains(t, out, "8.25")
+	require.Contains(t, out, "public.employees -> public.departments -> public.employees")
+	require.Contains(t, out, "condition: public.orders.id < 10")
+	require.NotContains(t, out, "public.employees (full)")
+}
+
+func TestValidateFormat(t *testing.T) {
+	require.NoError(t, ValidateFormat(DotFormat))
+	require.Error(t, ValidateFormat("yaml"))
+}
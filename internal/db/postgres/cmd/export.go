@@ -0,0 +1,200 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/dumpers"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/export"
+	storageDto "github.com/greenmaskio/greenmask/internal/db/postgres/storage"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/custom"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/storages"
+)
+
+const ManifestJsonFileName = "manifest.json"
+
+// Export - exports transformed tables data into CSV, JSONL or Parquet files instead of the PostgreSQL archive.
+// It uses the same runtime context, subset queries and transformation pipeline as Dump
+type Export struct {
+	*Dump
+	opts      *dumpers.ExportOptions
+	exporters []*dumpers.TableExporter
+}
+
+func NewExport(cfg *domains.Config, st storages.Storager, registry *utils.TransformerRegistry) (*Export, error) {
+	if err := export.ValidateFormat(cfg.Export.Format); err != nil {
+		return nil, err
+	}
+	d := NewDump(cfg, st, registry)
+	d.dumpIdSequence = toc.NewDumpSequence(1)
+	return &Export{
+		Dump: d,
+		opts: &dumpers.ExportOptions{
+			Format:         cfg.Export.Format,
+			Compress:       cfg.Export.Compress,
+			UsePgzip:       cfg.Export.Pgzip,
+			MaxRowsPerFile: cfg.Export.MaxRowsPerFile,
+		},
+	}, nil
+}
+
+func (e *Export) Run(ctx context.Context) (err error) {
+	defer e.prune()
+	startedAt := time.Now()
+
+	if err := custom.BootstrapCustomTransformers(ctx, e.registry, e.config.CustomTransformers); err != nil {
+		return fmt.Errorf("error bootstraping custom transformers: %w", err)
+	}
+
+	dsn, err := e.pgDumpOptions.GetPgDSN()
+	if err != nil {
+		return fmt.Errorf("cannot build connection string: %w", err)
+	}
+
+	conn, err := e.connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			log.Warn().Err(err)
+		}
+	}()
+
+	tx, err := e.startMainTx(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("cannot prepare export transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil {
+			log.Warn().Err(err)
+		}
+	}()
+
+	if err = e.gatherPgFacts(ctx, tx); err != nil {
+		return fmt.Errorf("error gathering facts: %w", err)
+	}
+
+	if err := e.buildContextAndValidate(ctx, tx); err != nil {
+		return fmt.Errorf("context error: %w", err)
+	}
+
+	if err = e.dataExport(ctx); err != nil {
+		return fmt.Errorf("data stage export error: %w", err)
+	}
+
+	completedAt := time.Now()
+	if err = e.writeReport(ctx, startedAt, completedAt); err != nil {
+		return fmt.Errorf("writeReport stage export error: %w", err)
+	}
+
+	if err = e.writeManifest(ctx, startedAt, completedAt); err != nil {
+		return fmt.Errorf("writeManifest stage export error: %w", err)
+	}
+
+	return nil
+}
+
+func (e *Export) dataExport(ctx context.Context) error {
+	tasks := make(chan dumpers.DumpTask, e.pgDumpOptions.Jobs)
+
+	log.Debug().Msgf("planned %d workers", e.pgDumpOptions.Jobs)
+	done := make(chan struct{})
+	eg, gtx := errgroup.WithContext(ctx)
+	eg.Go(e.dumpWorkerPlanner(gtx, tasks, done))
+	eg.Go(e.exportTaskProducer(gtx, tasks))
+
+	if err := eg.Wait(); err != nil {
+		return fmt.Errorf("at least one worker exited with error: %w", err)
+	}
+	log.Debug().Msg("all the data have been exported")
+	return nil
+}
+
+// exportTaskProducer - produces TableExporter tasks for the tables. Sequences and large objects are not exported
+func (e *Export) exportTaskProducer(ctx context.Context, tasks chan<- dumpers.DumpTask) func() error {
+	return func() error {
+		defer close(tasks)
+		for _, dumpObj := range e.context.DataSectionObjects {
+			t, ok := dumpObj.(*entries.Table)
+			if !ok || t.RelKind == 'p' {
+				continue
+			}
+			t.SetDumpId(e.dumpIdSequence)
+			exporter := dumpers.NewTableExporter(t, e.opts)
+			e.exporters = append(e.exporters, exporter)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case tasks <- exporter:
+			}
+		}
+		return nil
+	}
+}
+
+func (e *Export) writeManifest(ctx context.Context, startedAt, completedAt time.Time) error {
+	compression := "none"
+	if e.opts.Format == export.ParquetFormat {
+		compression = "snappy"
+	} else if e.opts.Compress {
+		compression = "gzip"
+	}
+
+	manifest := &storageDto.Manifest{
+		StartedAt:    startedAt,
+		CompletedAt:  completedAt,
+		Format:       e.opts.Format,
+		Compression:  compression,
+		Transformers: e.config.Dump.Transformation,
+		Tables:       make([]*storageDto.ManifestTable, 0, len(e.exporters)),
+	}
+	for _, exporter := range e.exporters {
+		files := make([]*storageDto.ManifestFile, 0, len(exporter.Files()))
+		for _, f := range exporter.Files() {
+			files = append(files, &storageDto.ManifestFile{
+				Name:           f.Name,
+				Rows:           f.Rows,
+				OriginalSize:   f.OriginalSize,
+				CompressedSize: f.CompressedSize,
+			})
+		}
+		manifest.Tables = append(
+			manifest.Tables, storageDto.NewManifestTable(exporter.Table(), e.opts.Format, files),
+		)
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	if err := json.NewEncoder(buf).Encode(manifest); err != nil {
+		return fmt.Errorf("error encoding manifest.json: %w", err)
+	}
+
+	if err := e.st.PutObject(ctx, ManifestJsonFileName, buf); err != nil {
+		return fmt.Errorf("error writing manifest to the storage: %w", err)
+	}
+	return nil
+}
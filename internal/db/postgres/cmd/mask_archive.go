@@ -0,0 +1,248 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/archive"
+	runtimeContext "github.com/greenmaskio/greenmask/internal/db/postgres/context"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/dumpers"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	storageDto "github.com/greenmaskio/greenmask/internal/db/postgres/storage"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/custom"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/storages"
+)
+
+// MaskArchive - transforms the data of the existing pg_dump archive in directory or custom format and stores the
+// result as greenmask dump. The database connection is not required, the tables metadata is taken from the archive
+// pre-data DDL
+type MaskArchive struct {
+	*Dump
+	archivePath string
+	archive     archive.Archive
+}
+
+func NewMaskArchive(
+	cfg *domains.Config, st storages.Storager, registry *utils.TransformerRegistry, archivePath string,
+) *MaskArchive {
+	return &MaskArchive{
+		Dump:        NewDump(cfg, st, registry),
+		archivePath: archivePath,
+	}
+}
+
+func (ma *MaskArchive) Run(ctx context.Context) (err error) {
+	defer ma.prune()
+	startedAt := time.Now()
+
+	if err := custom.BootstrapCustomTransformers(ctx, ma.registry, ma.config.CustomTransformers); err != nil {
+		return fmt.Errorf("error bootstraping custom transformers: %w", err)
+	}
+
+	ma.archive, err = archive.Open(ma.archivePath)
+	if err != nil {
+		return fmt.Errorf("cannot open archive: %w", err)
+	}
+	ma.schemaToc = ma.archive.Toc()
+
+	if err = ma.setSchemaRewriter(); err != nil {
+		return err
+	}
+
+	if err = ma.buildArchiveContextAndValidate(ctx); err != nil {
+		return fmt.Errorf("context error: %w", err)
+	}
+
+	if err = ma.dataMask(ctx); err != nil {
+		return fmt.Errorf("data stage masking error: %w", err)
+	}
+
+	if err = ma.writeToc(ctx); err != nil {
+		return fmt.Errorf("writeToc stage masking error: %w", err)
+	}
+
+	completedAt := time.Now()
+	if err = ma.writeReport(ctx, startedAt, completedAt); err != nil {
+		return fmt.Errorf("writeReport stage masking error: %w", err)
+	}
+
+	if err = ma.writeMetaData(ctx, startedAt, completedAt); err != nil {
+		return fmt.Errorf("writeMetaData stage masking error: %w", err)
+	}
+
+	return nil
+}
+
+func (ma *MaskArchive) buildArchiveContextAndValidate(ctx context.Context) (err error) {
+	ma.context, err = runtimeContext.NewArchiveRuntimeContext(ctx, ma.schemaToc, &ma.config.Dump, ma.registry)
+	if err != nil {
+		return fmt.Errorf("unable to build runtime context: %w", err)
+	}
+	for _, w := range ma.context.Warnings {
+		if w.Severity == "error" {
+			log.Error().Any("ValidationWarning", w).Msg("")
+		}
+	}
+	if ma.context.IsFatal() {
+		return fmt.Errorf("fatal validation error")
+	}
+	return nil
+}
+
+// dataMask - reads the tables data from the archive, transforms it and writes to the storage. The tables keep the
+// dump ids of the archive, so the archive TOC might be reused
+func (ma *MaskArchive) dataMask(ctx context.Context) error {
+	log.Debug().Msgf("planned %d workers", ma.pgDumpOptions.Jobs)
+	done := make(chan struct{})
+	eg, gtx := errgroup.WithContext(ctx)
+	eg.Go(ma.writeHeartBeatWorker(gtx, done))
+	eg.Go(func() error {
+		defer close(done)
+		workerEg, wtx := errgroup.WithContext(gtx)
+		workerEg.SetLimit(ma.pgDumpOptions.Jobs)
+		for _, obj := range ma.context.DataSectionObjects {
+			t, ok := obj.(*entries.Table)
+			if !ok {
+				continue
+			}
+			entry, err := ma.findDataEntry(t.DumpId)
+			if err != nil {
+				return err
+			}
+			task := dumpers.NewArchiveTableDumper(t, func() (io.ReadCloser, error) {
+				return ma.archive.OpenData(entry)
+			}, ma.pgDumpOptions.Pgzip)
+			workerEg.Go(func() error {
+				log.Debug().Str("ObjectName", task.DebugInfo()).Msg("masking started")
+				if err := task.Execute(wtx, nil, ma.st); err != nil {
+					return err
+				}
+				log.Debug().Str("ObjectName", task.DebugInfo()).Msg("masking is done")
+				return nil
+			})
+		}
+		return workerEg.Wait()
+	})
+
+	if err := eg.Wait(); err != nil {
+		return fmt.Errorf("at least one worker exited with error: %w", err)
+	}
+
+	for _, obj := range ma.context.DataSectionObjects {
+		if t, ok := obj.(*entries.Table); ok {
+			ma.tableOidToDumpId[t.Oid] = t.DumpId
+			ma.dumpedObjectSizes[t.DumpId] = storageDto.ObjectSizeStat{
+				Original:   t.OriginalSize,
+				Compressed: t.CompressedSize,
+			}
+			ma.sortedTablesDumpIds = append(ma.sortedTablesDumpIds, t.DumpId)
+		}
+	}
+	log.Debug().Msg("all the data have been masked")
+	return nil
+}
+
+func (ma *MaskArchive) findDataEntry(dumpId int32) (*toc.Entry, error) {
+	for _, e := range ma.schemaToc.Entries {
+		if e.DumpId == dumpId {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("toc entry %d is not found", dumpId)
+}
+
+// writeToc - writes the archive TOC in directory format. The TABLE DATA entries refer to the masked data files and
+// the large objects data is skipped since it cannot be transformed
+func (ma *MaskArchive) writeToc(ctx context.Context) error {
+	header := ma.schemaToc.Header.Copy()
+	header.Format = toc.ArchTar
+	header.CompressionSpec.Algorithm = toc.PgCompressionGzip
+	if header.CompressionSpec.Level == 0 {
+		// Z_DEFAULT_COMPRESSION
+		header.CompressionSpec.Level = -1
+	}
+
+	resultEntries := make([]*toc.Entry, 0, len(ma.schemaToc.Entries))
+	for _, e := range ma.schemaToc.Entries {
+		entry := e.Copy()
+		entry.DataState = 0
+		entry.DataPos = 0
+		if entry.Desc != nil {
+			switch *entry.Desc {
+			case toc.BlobsDesc:
+				log.Warn().
+					Int32("DumpId", entry.DumpId).
+					Msg("large objects data is not supported by mask-archive and will be skipped")
+				continue
+			case toc.TableDataDesc:
+				entry.FileName = toc.NewObj(fmt.Sprintf("%d.dat.gz", entry.DumpId))
+			}
+		}
+		resultEntries = append(resultEntries, entry)
+	}
+	if ma.schemaRewriter != nil {
+		resultEntries = ma.schemaRewriter.Rewrite(resultEntries)
+	}
+	header.TocCount = int32(len(resultEntries))
+	ma.resultToc = &toc.Toc{
+		Header:  header,
+		Entries: resultEntries,
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	if err := toc.NewWriter(buf).Write(ma.resultToc); err != nil {
+		return fmt.Errorf("error writing built toc file to the storage: %w", err)
+	}
+	ma.tocFileSize = int64(buf.Len())
+	if err := ma.st.PutObject(ctx, "toc.dat", buf); err != nil {
+		return err
+	}
+	return nil
+}
+
+// writeMetaData - writes metadata without the tables dependencies graph since the foreign keys are not
+// introspected. The tables are restored in the archive order
+func (ma *MaskArchive) writeMetaData(ctx context.Context, startedAt, completedAt time.Time) error {
+	metadata, err := storageDto.NewMetadata(
+		ma.resultToc, ma.tocFileSize, startedAt, completedAt, ma.config.Dump.Transformation, ma.dumpedObjectSizes,
+		ma.context.DatabaseSchema, map[int32][]int32{}, ma.sortedTablesDumpIds, nil, ma.tableOidToDumpId,
+	)
+	if err != nil {
+		return fmt.Errorf("unable build metadata: %w", err)
+	}
+	metadata.TransformationErrors = storageDto.NewTransformationErrors(ma.dumpedTables())
+
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	if err = json.NewEncoder(buf).Encode(metadata); err != nil {
+		return fmt.Errorf("error encoding metadata.json: %w", err)
+	}
+
+	if err = ma.st.PutObject(ctx, MetadataJsonFileName, buf); err != nil {
+		return fmt.Errorf("error writing metadata to the storage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "sync/atomic"
+
+// ProgressStage - the stage of the dump or restore reported to ProgressFunc
+type ProgressStage string
+
+const (
+	SchemaProgressStage    ProgressStage = "schema"
+	PreDataProgressStage   ProgressStage = "pre-data"
+	DataProgressStage      ProgressStage = "data"
+	PostDataProgressStage  ProgressStage = "post-data"
+	CompletedProgressStage ProgressStage = "completed"
+)
+
+// Progress - the dump or restore progress event. The stage start events have the empty ObjectName, the data
+// stage reports the event per each dumped or restored object
+type Progress struct {
+	Stage ProgressStage
+	// ObjectName - the name of the processed data object
+	ObjectName string
+	// ObjectsDone - the number of the processed data objects
+	ObjectsDone int64
+}
+
+// ProgressFunc - the progress callback. It may be called concurrently from the dump and restore workers
+type ProgressFunc func(p Progress)
+
+// ProgressReporter - calls ProgressFunc and counts the processed data objects. The nil reporter and the reporter
+// without the callback do nothing
+type ProgressReporter struct {
+	f           ProgressFunc
+	objectsDone atomic.Int64
+}
+
+func NewProgressReporter(f ProgressFunc) *ProgressReporter {
+	return &ProgressReporter{
+		f: f,
+	}
+}
+
+// Stage - reports the stage start
+func (pr *ProgressReporter) Stage(stage ProgressStage) {
+	if pr == nil || pr.f == nil {
+		return
+	}
+	pr.f(Progress{
+		Stage:       stage,
+		ObjectsDone: pr.objectsDone.Load(),
+	})
+}
+
+// ObjectDone - reports the processed data object
+func (pr *ProgressReporter) ObjectDone(stage ProgressStage, name string) {
+	if pr == nil || pr.f == nil {
+		return
+	}
+	pr.f(Progress{
+		Stage:       stage,
+		ObjectName:  name,
+		ObjectsDone: pr.objectsDone.Add(1),
+	})
+}
+
+// Func - returns the progress callback
+func (pr *ProgressReporter) Func() ProgressFunc {
+	if pr == nil {
+		return nil
+	}
+	return pr.f
+}
@@ -0,0 +1,274 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/replication"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/custom"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/storages"
+)
+
+const DefaultReplicationSlotName = "greenmask"
+
+// Replicate - keeps the masked replica up to date. It takes the initial masked snapshot by dump and restore and
+// then applies the changes received from the logical replication slot using the same transformers
+type Replicate struct {
+	cfg      *domains.Config
+	st       storages.Storager
+	registry *utils.TransformerRegistry
+	// bootstrapped - the custom transformers are registered by the initial snapshot dump
+	bootstrapped bool
+}
+
+func NewReplicate(cfg *domains.Config, st storages.Storager, registry *utils.TransformerRegistry) *Replicate {
+	return &Replicate{
+		cfg:      cfg,
+		st:       st,
+		registry: registry,
+	}
+}
+
+func (r *Replicate) Run(ctx context.Context) error {
+	if r.cfg.Replicate.Publication == "" {
+		return errors.New("replicate.publication is required")
+	}
+	slotName := r.cfg.Replicate.SlotName
+	if slotName == "" {
+		slotName = DefaultReplicationSlotName
+	}
+
+	ctx, v, err := openVault(ctx, &r.cfg.Dump, false)
+	if err != nil {
+		return err
+	}
+	if v != nil {
+		defer func() {
+			if err := v.Close(); err != nil {
+				log.Warn().Err(err).Msg("error closing vault")
+			}
+		}()
+	}
+
+	targetDsn, err := r.cfg.Restore.PgRestoreOptions.GetPgDSN()
+	if err != nil {
+		return fmt.Errorf("cannot build target connection string: %w", err)
+	}
+	target, err := pgx.Connect(ctx, targetDsn)
+	if err != nil {
+		return fmt.Errorf("cannot connect to target database: %w", err)
+	}
+	defer func() {
+		if err := target.Close(ctx); err != nil {
+			log.Warn().Err(err).Msg("error closing target connection")
+		}
+	}()
+	checkpoint := replication.NewCheckpoint(r.cfg.Replicate.CheckpointTable, slotName)
+	if err = checkpoint.Init(ctx, target); err != nil {
+		return err
+	}
+	lsn, ok, err := checkpoint.Get(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	sourceDsn, err := r.cfg.Dump.PgDumpOptions.GetPgDSN()
+	if err != nil {
+		return fmt.Errorf("cannot build source connection string: %w", err)
+	}
+	replConn, err := replication.Connect(ctx, sourceDsn)
+	if err != nil {
+		return fmt.Errorf("cannot open replication connection: %w", err)
+	}
+	defer func() {
+		if err := replConn.Close(ctx); err != nil {
+			log.Warn().Err(err).Msg("error closing replication connection")
+		}
+	}()
+
+	if ok {
+		log.Info().
+			Str("Slot", slotName).
+			Str("Lsn", lsn.String()).
+			Msg("resuming replication from checkpoint")
+	} else {
+		lsn, err = r.initialSnapshot(ctx, replConn, target, checkpoint, slotName)
+		if err != nil {
+			return err
+		}
+	}
+
+	tables, err := r.getTables(ctx, sourceDsn)
+	if err != nil {
+		return err
+	}
+
+	if err = replication.StartReplication(ctx, replConn, slotName, lsn, r.cfg.Replicate.Publication); err != nil {
+		return fmt.Errorf("cannot start replication: %w", err)
+	}
+	log.Info().
+		Str("Slot", slotName).
+		Str("Lsn", lsn.String()).
+		Msg("replication started")
+
+	eg, gtx := errgroup.WithContext(ctx)
+	applier := replication.NewApplier(target, checkpoint, tables, eg, lsn)
+	eg.Go(func() error {
+		return replication.NewReplicator(replConn, applier, r.cfg.Replicate.StatusInterval).Run(gtx)
+	})
+	err = eg.Wait()
+	if closeErr := applier.Close(context.Background()); closeErr != nil {
+		log.Warn().Err(closeErr).Msg("error closing applier")
+	}
+	return err
+}
+
+// initialSnapshot - creates the slot and dumps the exported slot snapshot to the target database. The slot is
+// dropped if the snapshot cannot be restored, so the next run starts from scratch
+func (r *Replicate) initialSnapshot(
+	ctx context.Context, replConn *pgconn.PgConn, target *pgx.Conn, checkpoint *replication.Checkpoint,
+	slotName string,
+) (replication.LSN, error) {
+	slot, err := replication.CreateSlot(ctx, replConn, slotName)
+	if err != nil {
+		return 0, fmt.Errorf("cannot create replication slot: %w", err)
+	}
+	log.Info().
+		Str("Slot", slot.Name).
+		Str("Lsn", slot.ConsistentPoint.String()).
+		Str("Snapshot", slot.SnapshotName).
+		Msg("replication slot created: taking initial snapshot")
+
+	if err = r.dumpAndRestore(ctx, slot.SnapshotName); err != nil {
+		if dropErr := replication.DropSlot(ctx, replConn, slot.Name); dropErr != nil {
+			log.Warn().Err(dropErr).Msg("cannot drop replication slot")
+		}
+		return 0, fmt.Errorf("cannot take initial snapshot: %w", err)
+	}
+
+	tx, err := target.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("cannot start transaction: %w", err)
+	}
+	if err = checkpoint.Save(ctx, tx, slot.ConsistentPoint); err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("cannot commit checkpoint: %w", err)
+	}
+	return slot.ConsistentPoint, nil
+}
+
+func (r *Replicate) dumpAndRestore(ctx context.Context, snapshot string) error {
+	dumpId := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	st := r.st.SubStorage(dumpId, true)
+
+	r.cfg.Dump.PgDumpOptions.Snapshot = snapshot
+	defer func() {
+		r.cfg.Dump.PgDumpOptions.Snapshot = ""
+	}()
+	if err := NewDump(r.cfg, st, r.registry).Run(ctx); err != nil {
+		return fmt.Errorf("cannot make a backup: %w", err)
+	}
+	r.bootstrapped = true
+
+	log.Info().
+		Str("dumpId", dumpId).
+		Msg("restoring initial snapshot")
+	restore := NewRestore(
+		r.cfg.Common.PgBinPath, st, &r.cfg.Restore, r.cfg.Restore.Scripts, r.cfg.Common.TempDirectory,
+	)
+	if err := restore.Run(ctx); err != nil {
+		return fmt.Errorf("cannot restore: %w", err)
+	}
+	return nil
+}
+
+// getTables - builds the runtime context and returns the dumped tables with the initialized transformers. The
+// changes of other tables are skipped
+func (r *Replicate) getTables(ctx context.Context, dsn string) ([]*entries.Table, error) {
+	if !r.bootstrapped {
+		if err := custom.BootstrapCustomTransformers(ctx, r.registry, r.cfg.CustomTransformers); err != nil {
+			return nil, fmt.Errorf("error bootstraping custom transformers: %w", err)
+		}
+		r.bootstrapped = true
+	}
+
+	d := NewDump(r.cfg, nil, r.registry)
+	conn, err := d.connect(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			log.Warn().Err(err).Msg("error closing connection")
+		}
+	}()
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot start transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil {
+			log.Warn().Err(err).Msg("error rolling back transaction")
+		}
+	}()
+	if err = d.gatherPgFacts(ctx, tx); err != nil {
+		return nil, fmt.Errorf("error gathering facts: %w", err)
+	}
+
+	rc, err := d.newRuntimeContext(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range rc.Warnings {
+		if w.Severity == "error" {
+			log.Error().Any("ValidationWarning", w).Msg("")
+		}
+	}
+	if rc.IsFatal() {
+		return nil, fmt.Errorf("fatal validation error")
+	}
+
+	var tables []*entries.Table
+	for _, e := range rc.DataSectionObjects {
+		t, ok := e.(*entries.Table)
+		if !ok {
+			continue
+		}
+		if t.Query != "" {
+			log.Warn().
+				Str("Schema", t.Schema).
+				Str("Table", t.Name).
+				Msg("subset conditions and query are not applied to the replicated changes")
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
@@ -98,6 +98,13 @@ type Restore struct {
 	preDataClenUpToc  string
 	postDataClenUpToc string
 	restoredDumpIds   map[int32]bool
+	// columnsMappings - map of table data DumpId to the columns mapping. It is used in schema-tolerant mode only.
+	// If the table data entry does not have mapping, then the table is not found in the target database
+	columnsMappings map[int32]*restorers.ColumnsMapping
+	// progress - reports the restoration stages and the restored data objects
+	progress *ProgressReporter
+	// deadLetter - the writer of the rows rejected during the data restoration. It is set in dead-letter mode only
+	deadLetter *restorers.DeadLetter
 }
 
 func NewRestore(
@@ -118,6 +125,11 @@ func NewRestore(
 	}
 }
 
+// SetProgressFunc - sets the callback that is called on each restoration stage and restored data object
+func (r *Restore) SetProgressFunc(f ProgressFunc) {
+	r.progress = NewProgressReporter(f)
+}
+
 func (r *Restore) Run(ctx context.Context) error {
 
 	defer r.prune()
@@ -134,17 +146,21 @@ func (r *Restore) Run(ctx context.Context) error {
 		return fmt.Errorf("pre-flight stage restoration error: %w", err)
 	}
 
+	r.progress.Stage(PreDataProgressStage)
 	if err := r.preDataRestore(ctx); err != nil {
 		return fmt.Errorf("pre-data stage restoration error: %w", err)
 	}
 
+	r.progress.Stage(DataProgressStage)
 	if err := r.dataRestore(ctx); err != nil {
 		return fmt.Errorf("data stage restoration error: %w", err)
 	}
 
+	r.progress.Stage(PostDataProgressStage)
 	if err := r.postDataRestore(ctx); err != nil {
 		return fmt.Errorf("post-data stage restoration error: %w", err)
 	}
+	r.progress.Stage(CompletedProgressStage)
 
 	return nil
 }
@@ -231,6 +247,18 @@ func (r *Restore) prepare() error {
 			return fmt.Errorf("restore list parsing error: %w", err)
 		}
 	}
+	if r.restoreOpt.SchemaTolerant {
+		if !r.restoreOpt.DataOnly && r.restoreOpt.Section != dataSection {
+			return fmt.Errorf("--schema-tolerant can be used only with --data-only or --section=data")
+		}
+		if r.restoreOpt.Inserts || r.restoreOpt.OnConflictDoNothing {
+			return fmt.Errorf("--schema-tolerant cannot be used with --inserts or --on-conflict-do-nothing")
+		}
+		if r.restoreOpt.DeadLetter {
+			// The schema-tolerant restoration streams the projected COPY data, so the rows cannot be retried one by one
+			return fmt.Errorf("--schema-tolerant cannot be used with --dead-letter")
+		}
+	}
 	dsn, err := r.restoreOpt.GetPgDSN()
 	if err != nil {
 		return fmt.Errorf("cennot generate DSN: %w", err)
@@ -446,6 +474,17 @@ func (r *Restore) dataRestore(ctx context.Context) error {
 		return err
 	}
 
+	if r.restoreOpt.SchemaTolerant {
+		if err = r.compareTablesWithTarget(ctx, conn); err != nil {
+			return fmt.Errorf("schema tolerance check error: %w", err)
+		}
+	}
+
+	if r.restoreOpt.DeadLetter {
+		r.deadLetter = restorers.NewDeadLetter(r.st, r.restoreOpt.DeadLetterDir)
+		defer r.logDeadLetterSummary()
+	}
+
 	tasks := make(chan restorationTask, r.restoreOpt.Jobs)
 	eg, gtx := errgroup.WithContext(ctx)
 
@@ -471,6 +510,31 @@ func (r *Restore) dataRestore(ctx context.Context) error {
 	return nil
 }
 
+// logDeadLetterSummary - logs the tables with the rows rejected during the data restoration, so the masking config
+// that produced them can be fixed
+func (r *Restore) logDeadLetterSummary() {
+	summary := r.deadLetter.Summary()
+	if len(summary) == 0 {
+		log.Info().Msg("no rows were rejected during the data restoration")
+		return
+	}
+	var total int64
+	for _, s := range summary {
+		total += s.Rows
+		log.Warn().
+			Str("SchemaName", s.Schema).
+			Str("TableName", s.Name).
+			Int64("Rows", s.Rows).
+			Any("Errors", s.Errors).
+			Str("DeadLetterFile", s.Path).
+			Msg("rows were rejected during the data restoration")
+	}
+	log.Warn().
+		Int("Tables", len(summary)).
+		Int64("Rows", total).
+		Msg("dead-letter summary: rejected rows were written to the dead-letter files")
+}
+
 func (r *Restore) isNeedRestore(e *toc.Entry) bool {
 
 	if *e.Desc == toc.TableDataDesc || *e.Desc == toc.SequenceSetDesc {
@@ -633,14 +697,35 @@ func (r *Restore) taskPusher(ctx context.Context, tasks chan restorationTask) fu
 				var task restorationTask
 				switch *entry.Desc {
 				case toc.TableDataDesc:
-					if r.restoreOpt.Inserts || r.restoreOpt.OnConflictDoNothing {
+					if r.restoreOpt.SchemaTolerant {
+						mapping, ok := r.columnsMappings[entry.DumpId]
+						if !ok {
+							// The table is not found in the target database
+							continue
+						}
+						task = restorers.NewSchemaTolerantTableRestorer(
+							entry, r.st, r.restoreOpt.ToDataSectionSettings(), mapping,
+						)
+					} else if r.restoreOpt.Inserts || r.restoreOpt.OnConflictDoNothing {
 						t, err := r.getTableDefinitionFromMeta(entry.DumpId)
 						if err != nil {
 							return fmt.Errorf("cannot get table definition from meta: %w", err)
 						}
-						task = restorers.NewTableRestorerInsertFormat(
+						insertTask := restorers.NewTableRestorerInsertFormat(
 							entry, t, r.st, r.restoreOpt.ToDataSectionSettings(), r.cfg.ErrorExclusions,
 						)
+						if r.deadLetter != nil {
+							insertTask.SetDeadLetter(r.deadLetter)
+						}
+						task = insertTask
+					} else if r.deadLetter != nil {
+						t, err := r.getTableDefinitionFromMeta(entry.DumpId)
+						if err != nil {
+							return fmt.Errorf("cannot get table definition from meta: %w", err)
+						}
+						task = restorers.NewTableRestorerCopyFallback(
+							entry, t, r.st, r.restoreOpt.ToDataSectionSettings(), r.deadLetter,
+						)
 					} else {
 						task = restorers.NewTableRestorer(entry, r.st, r.restoreOpt.ToDataSectionSettings())
 					}
@@ -672,6 +757,72 @@ func (r *Restore) taskPusher(ctx context.Context, tasks chan restorationTask) fu
 	}
 }
 
+// compareTablesWithTarget - compares the dumped tables with the target database tables and builds the columns
+// mappings. All the column-level mismatches are logged before the data restoration is started. If there is at least
+// one error, then the restoration is aborted
+func (r *Restore) compareTablesWithTarget(ctx context.Context, conn *pgx.Conn) error {
+	r.columnsMappings = make(map[int32]*restorers.ColumnsMapping)
+	var warnings toolkit.ValidationWarnings
+	for _, entry := range getDataSectionTocEntries(r.tocObj.Entries) {
+		if *entry.Desc != toc.TableDataDesc || !r.isNeedRestore(entry) {
+			continue
+		}
+		metaTable, err := r.getTableDefinitionFromMeta(entry.DumpId)
+		if err != nil {
+			return fmt.Errorf("cannot get table definition from meta: %w", err)
+		}
+		// The metadata keeps the original table name, while the schema rewrite rules might have renamed the table
+		// in the TOC entries
+		t := *metaTable
+		t.Schema = *entry.Namespace
+		t.Name = *entry.Tag
+		targetColumns, err := restorers.GetTargetColumns(ctx, conn, t.Schema, t.Name)
+		if err != nil {
+			return fmt.Errorf("cannot get table %s.%s columns: %w", t.Schema, t.Name, err)
+		}
+		if len(targetColumns) == 0 {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetMsg("table is not found in the target database: table data will be skipped").
+				SetSeverity(toolkit.WarningValidationSeverity).
+				AddMeta("SchemaName", t.Schema).
+				AddMeta("TableName", t.Name),
+			)
+			continue
+		}
+		mapping, mappingWarns := restorers.BuildColumnsMapping(&t, targetColumns, r.getSchemaToleranceTable(&t))
+		warnings = append(warnings, mappingWarns...)
+		r.columnsMappings[entry.DumpId] = mapping
+	}
+
+	for _, w := range warnings {
+		switch w.Severity {
+		case toolkit.ErrorValidationSeverity:
+			log.Error().Any("ValidationWarning", w).Msg("")
+		case toolkit.WarningValidationSeverity:
+			log.Warn().Any("ValidationWarning", w).Msg("")
+		default:
+			log.Info().Any("ValidationWarning", w).Msg("")
+		}
+	}
+	if warnings.IsFatal() {
+		return fmt.Errorf("fatal schema mismatch")
+	}
+	return nil
+}
+
+func (r *Restore) getSchemaToleranceTable(t *toolkit.Table) *domains.SchemaToleranceTable {
+	if r.cfg.SchemaTolerance == nil {
+		return nil
+	}
+	idx := slices.IndexFunc(r.cfg.SchemaTolerance.Tables, func(st *domains.SchemaToleranceTable) bool {
+		return st.Schema == t.Schema && st.Name == t.Name
+	})
+	if idx == -1 {
+		return nil
+	}
+	return r.cfg.SchemaTolerance.Tables[idx]
+}
+
 func (r *Restore) getTableDefinitionFromMeta(dumpId int32) (*toolkit.Table, error) {
 	tableOid, ok := r.metadata.DumpIdsToTableOid[dumpId]
 	if !ok {
@@ -722,6 +873,7 @@ func (r *Restore) restoreWorker(ctx context.Context, tasks <-chan restorationTas
 			return fmt.Errorf("unable to perform restoration task (worker %d restoring %s): %w", id, task.DebugInfo(), err)
 		}
 		r.putDumpId(task)
+		r.progress.ObjectDone(DataProgressStage, task.DebugInfo())
 		log.Debug().
 			Int("workerId", id).
 			Str("objectName", task.DebugInfo()).
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/domains"
+)
+
+func TestRestore_prepare(t *testing.T) {
+	tests := []struct {
+		name        string
+		setup       func(cfg *domains.Restore)
+		expectedErr string
+	}{
+		{
+			name: "dead-letter",
+			setup: func(cfg *domains.Restore) {
+				cfg.PgRestoreOptions.DeadLetter = true
+			},
+		},
+		{
+			name: "schema-tolerant",
+			setup: func(cfg *domains.Restore) {
+				cfg.PgRestoreOptions.SchemaTolerant = true
+				cfg.PgRestoreOptions.DataOnly = true
+			},
+		},
+		{
+			name: "schema-tolerant without data only",
+			setup: func(cfg *domains.Restore) {
+				cfg.PgRestoreOptions.SchemaTolerant = true
+			},
+			expectedErr: "--schema-tolerant can be used only with --data-only or --section=data",
+		},
+		{
+			name: "schema-tolerant with dead-letter",
+			setup: func(cfg *domains.Restore) {
+				cfg.PgRestoreOptions.SchemaTolerant = true
+				cfg.PgRestoreOptions.Section = dataSection
+				cfg.PgRestoreOptions.DeadLetter = true
+			},
+			expectedErr: "--schema-tolerant cannot be used with --dead-letter",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &domains.Restore{}
+			cfg.PgRestoreOptions.DbName = "host=localhost dbname=postgres"
+			tt.setup(cfg)
+			r := NewRestore("", nil, cfg, nil, t.TempDir())
+			err := r.prepare()
+			if tt.expectedErr != "" {
+				require.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
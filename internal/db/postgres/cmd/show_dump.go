@@ -26,10 +26,14 @@ import (
 	"gopkg.in/yaml.v3"
 
 	storageDto "github.com/greenmaskio/greenmask/internal/db/postgres/storage"
+	"github.com/greenmaskio/greenmask/internal/domains"
 	"github.com/greenmaskio/greenmask/internal/storages"
 )
 
-const templateName = "metadataList"
+const (
+	templateName        = "metadataList"
+	clusterTemplateName = "clusterMetadataList"
+)
 
 const (
 	FormatText = "text"
@@ -57,6 +61,60 @@ var templateString = `;
 {{- end }}
 `
 
+var mysqlTemplateString = `;
+; MySQL dump created at {{ .Header.CreationDate.Format "2006-01-02 15:04:05 UTC" }}
+;     dbname: {{ .Header.DbName }}
+;     Entries: {{ .Header.TocEntriesCount }}
+;     Format: {{ .Header.Format }}
+;     Dumped from database version: {{ .Header.DumpedFrom }}
+;
+;
+; Selected Entries:
+;
+{{- range .Entries }}
+{{ .DumpId }}; {{ .ObjectType }} {{ .Schema }} {{ .Name }} {{ .FileName }}
+{{- end }}
+`
+
+var clusterTemplateString = `;
+; Cluster dump created at {{ .StartedAt.Format "2006-01-02 15:04:05 UTC" }}
+;     Dumped from database version: {{ .DumpedFrom }}
+;     Role passwords: {{ .RolePasswords }}
+;
+;
+; Databases (use dumpId/database to show the database dump):
+;
+{{- range .Databases }}
+{{ .Name }} {{ .Owner }} {{ .Encoding }} {{ .Collate }} {{ .Ctype }} {{ .Tablespace }}{{ if .Locale }} {{ .LocaleProvider }} {{ .Locale }}{{ end }}
+{{- end }}
+`
+
+// ShowClusterDump - shows the cluster dump metadata with the list of the dumped databases
+func ShowClusterDump(ctx context.Context, st storages.Storager, dumpId string, format string) error {
+	meta, err := ReadClusterMetadata(ctx, st.SubStorage(dumpId, true))
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatText:
+		t, err := template.New(clusterTemplateName).Parse(clusterTemplateString)
+		if err != nil {
+			return fmt.Errorf("cannot parser cluster report template: %w", err)
+		}
+		if err := t.Execute(os.Stdout, meta); err != nil {
+			return fmt.Errorf("template reder error: %s", err)
+		}
+	case FormatYaml:
+		return printYaml(meta)
+	case FormatJson:
+		return printJson(meta)
+	default:
+		return fmt.Errorf("unknown output format %s", format)
+	}
+	return nil
+}
+
 func ShowDump(ctx context.Context, st storages.Storager, dumpId string, format string) error {
 	meta := &storageDto.Metadata{}
 	r, err := st.GetObject(ctx, path.Join(dumpId, MetadataJsonFileName))
@@ -95,14 +153,14 @@ func ShowDump(ctx context.Context, st storages.Storager, dumpId string, format s
 	return nil
 }
 
-func printJson(meta *storageDto.Metadata) error {
+func printJson(meta any) error {
 	if err := json.NewEncoder(os.Stdout).Encode(meta); err != nil {
 		return fmt.Errorf("json render error: %w", err)
 	}
 	return nil
 }
 
-func printYaml(meta *storageDto.Metadata) error {
+func printYaml(meta any) error {
 	if err := yaml.NewEncoder(os.Stdout).Encode(meta); err != nil {
 		return fmt.Errorf("yaml render error: %w", err)
 	}
@@ -110,7 +168,11 @@ func printYaml(meta *storageDto.Metadata) error {
 }
 
 func printText(meta *storageDto.Metadata) error {
-	t, err := template.New(templateName).Parse(templateString)
+	tmpl := templateString
+	if meta.Header.Engine == domains.MysqlEngine {
+		tmpl = mysqlTemplateString
+	}
+	t, err := template.New(templateName).Parse(tmpl)
 	if err != nil {
 		return fmt.Errorf("cannot parser TOC report template: %w", err)
 	}
@@ -0,0 +1,133 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	htmlTemplate "html/template"
+	"io"
+	"os"
+	"path"
+	textTemplate "text/template"
+
+	storageDto "github.com/greenmaskio/greenmask/internal/db/postgres/storage"
+	"github.com/greenmaskio/greenmask/internal/storages"
+)
+
+const FormatHtml = "html"
+
+const reportTemplateName = "report"
+
+var reportTextTemplateString = `;
+; Masking report
+;     Started at: {{ .Data.StartedAt.Format "2006-01-02 15:04:05 MST" }}
+;     Completed at: {{ .Data.CompletedAt.Format "2006-01-02 15:04:05 MST" }}
+;     Digest ({{ .DigestAlgorithm }}): {{ .Digest }}
+;
+{{- range .Data.Tables }}
+;
+; {{ .DumpId }}; {{ .Schema }}.{{ .Name }}: rows seen {{ .RowsSeen }}, rows transformed {{ .RowsTransformed }}
+{{- range .Columns }}
+;     {{ .Name }}: rows transformed {{ .RowsTransformed }}, nulls kept {{ .NullsKept }}, unchanged {{ .Unchanged }}
+{{- end }}
+{{- end }}
+`
+
+var reportHtmlTemplateString = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Masking report</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #999; padding: 4px 8px; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Masking report</h1>
+<p>Started at: {{ .Data.StartedAt.Format "2006-01-02 15:04:05 MST" }}</p>
+<p>Completed at: {{ .Data.CompletedAt.Format "2006-01-02 15:04:05 MST" }}</p>
+<p>Digest ({{ .DigestAlgorithm }}): <code>{{ .Digest }}</code></p>
+{{- range .Data.Tables }}
+<h2>{{ .Schema }}.{{ .Name }}</h2>
+<p>Dump ID: {{ .DumpId }}, rows seen: {{ .RowsSeen }}, rows transformed: {{ .RowsTransformed }}</p>
+<table>
+<tr><th>Column</th><th>Rows transformed</th><th>Nulls kept</th><th>Unchanged</th></tr>
+{{- range .Columns }}
+<tr><td>{{ .Name }}</td><td>{{ .RowsTransformed }}</td><td>{{ .NullsKept }}</td><td>{{ .Unchanged }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`
+
+// ShowDumpReport - prints the masking audit report of the dump. It returns an error if the report digest
+// does not match
+func ShowDumpReport(ctx context.Context, st storages.Storager, dumpId string, format string) error {
+	exists, err := st.Exists(ctx, path.Join(dumpId, ReportJsonFileName))
+	if err != nil {
+		return fmt.Errorf("cannot check report existence: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("dump %s does not contain masking report", dumpId)
+	}
+
+	r, err := st.GetObject(ctx, path.Join(dumpId, ReportJsonFileName))
+	if err != nil {
+		return fmt.Errorf("cannot get report: %w", err)
+	}
+	defer r.Close()
+	report := &storageDto.Report{}
+	if err = json.NewDecoder(r).Decode(report); err != nil {
+		return fmt.Errorf("report parsing error: %w", err)
+	}
+	if err = report.Verify(); err != nil {
+		return fmt.Errorf("report verification error: %w", err)
+	}
+
+	return printReport(os.Stdout, report, format)
+}
+
+func printReport(w io.Writer, report *storageDto.Report, format string) error {
+	switch format {
+	case FormatText:
+		t, err := textTemplate.New(reportTemplateName).Parse(reportTextTemplateString)
+		if err != nil {
+			return fmt.Errorf("cannot parse report template: %w", err)
+		}
+		if err = t.Execute(w, report); err != nil {
+			return fmt.Errorf("template render error: %w", err)
+		}
+	case FormatJson:
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			return fmt.Errorf("json render error: %w", err)
+		}
+	case FormatHtml:
+		t, err := htmlTemplate.New(reportTemplateName).Parse(reportHtmlTemplateString)
+		if err != nil {
+			return fmt.Errorf("cannot parse report template: %w", err)
+		}
+		if err = t.Execute(w, report); err != nil {
+			return fmt.Errorf("template render error: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown report output format %s", format)
+	}
+	return nil
+}
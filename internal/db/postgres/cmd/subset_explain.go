@@ -0,0 +1,191 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/cmd/explain_utils"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/subset"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/custom"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/domains"
+)
+
+const (
+	SubsetExplainCountsNone      = "none"
+	SubsetExplainCountsEstimated = "estimated"
+	SubsetExplainCountsExact     = "exact"
+)
+
+// SubsetExplain - builds the runtime context and the subset queries in the read-only snapshot and prints the subset
+// plan without dumping
+type SubsetExplain struct {
+	*Dump
+	w io.Writer
+}
+
+func NewSubsetExplain(cfg *domains.Config, registry *utils.TransformerRegistry, w io.Writer) (*SubsetExplain, error) {
+	if err := explain_utils.ValidateFormat(cfg.SubsetExplain.Format); err != nil {
+		return nil, err
+	}
+	switch cfg.SubsetExplain.Counts {
+	case SubsetExplainCountsNone, SubsetExplainCountsEstimated, SubsetExplainCountsExact:
+	default:
+		return nil, fmt.Errorf(
+			"unknown counts value \"%s\": possible values [none|estimated|exact]", cfg.SubsetExplain.Counts,
+		)
+	}
+	return &SubsetExplain{
+		Dump: NewDump(cfg, nil, registry),
+		w:    w,
+	}, nil
+}
+
+func (se *SubsetExplain) Run(ctx context.Context) error {
+	defer se.prune()
+	if err := custom.BootstrapCustomTransformers(ctx, se.registry, se.config.CustomTransformers); err != nil {
+		return fmt.Errorf("error bootstraping custom transformers: %w", err)
+	}
+
+	dsn, err := se.pgDumpOptions.GetPgDSN()
+	if err != nil {
+		return fmt.Errorf("cannot build connection string: %w", err)
+	}
+
+	conn, err := se.connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			log.Warn().Err(err).Msg("error closing connection")
+		}
+	}()
+
+	tx, err := se.startMainTx(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("cannot prepare transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil {
+			log.Warn().Err(err).Msg("error rolling back transaction")
+		}
+	}()
+	if _, err = tx.Exec(ctx, "SET TRANSACTION READ ONLY"); err != nil {
+		return fmt.Errorf("cannot set read only transaction: %w", err)
+	}
+
+	if err = se.gatherPgFacts(ctx, tx); err != nil {
+		return fmt.Errorf("error gathering facts: %w", err)
+	}
+
+	ctx, v, err := openVault(ctx, &se.config.Dump, true)
+	if err != nil {
+		return err
+	}
+	if v != nil {
+		defer func() {
+			if err := v.Close(); err != nil {
+				log.Warn().Err(err).Msg("error closing vault")
+			}
+		}()
+	}
+
+	se.context, err = se.newRuntimeContext(ctx, tx)
+	if err != nil {
+		return err
+	}
+	for _, w := range se.context.Warnings {
+		if w.Severity == "error" {
+			log.Error().Any("ValidationWarning", w).Msg("")
+		}
+	}
+	if se.context.IsFatal() {
+		return fmt.Errorf("fatal validation error")
+	}
+
+	plan := se.context.Graph.Plan()
+	if err = se.setEstimations(ctx, tx, plan); err != nil {
+		return err
+	}
+
+	if err = explain_utils.Print(se.w, plan, se.config.SubsetExplain.Format); err != nil {
+		return fmt.Errorf("cannot print subset plan: %w", err)
+	}
+	return nil
+}
+
+// setEstimations - sets the rows count before and after subset for the tables that are not dumped in full
+func (se *SubsetExplain) setEstimations(ctx context.Context, tx pgx.Tx, plan *subset.Plan) error {
+	if se.config.SubsetExplain.Counts == SubsetExplainCountsNone {
+		return nil
+	}
+	for _, t := range plan.Tables {
+		if t.Mode == subset.PlanTableModeFull {
+			continue
+		}
+		fullQuery := fmt.Sprintf("SELECT * FROM %s", pgx.Identifier{t.Schema, t.Name}.Sanitize())
+		before, err := se.estimate(ctx, tx, fullQuery)
+		if err != nil {
+			return fmt.Errorf("cannot estimate table %s rows: %w", t.FullName(), err)
+		}
+		after, err := se.estimate(ctx, tx, t.Query)
+		if err != nil {
+			return fmt.Errorf("cannot estimate table %s subset rows: %w", t.FullName(), err)
+		}
+		t.Before, t.After = before, after
+	}
+	return nil
+}
+
+func (se *SubsetExplain) estimate(ctx context.Context, tx pgx.Tx, query string) (*subset.PlanEstimation, error) {
+	if se.config.SubsetExplain.Counts == SubsetExplainCountsExact {
+		res := &subset.PlanEstimation{Exact: true}
+		row := tx.QueryRow(ctx, fmt.Sprintf("SELECT count(*) FROM (%s) AS q", query))
+		if err := row.Scan(&res.Rows); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+
+	var data []byte
+	row := tx.QueryRow(ctx, fmt.Sprintf("EXPLAIN (FORMAT JSON) %s", query))
+	if err := row.Scan(&data); err != nil {
+		return nil, err
+	}
+	var plans []struct {
+		Plan struct {
+			TotalCost float64 `json:"Total Cost"`
+			PlanRows  float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(data, &plans); err != nil {
+		return nil, fmt.Errorf("cannot parse explain output: %w", err)
+	}
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("empty explain output")
+	}
+	return &subset.PlanEstimation{
+		Rows: int64(plans[0].Plan.PlanRows),
+		Cost: plans[0].Plan.TotalCost,
+	}, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/storages"
+	"github.com/greenmaskio/greenmask/internal/storages/builder"
+)
+
+const (
+	SubsetSeedsFormatCsv  = "csv"
+	SubsetSeedsFormatList = "list"
+)
+
+// subsetSeedsStoragePrefix - the prefix of the source that is read from the configured storage
+const subsetSeedsStoragePrefix = "storage://"
+
+// SetSubsetSeedsOverrides - sets the seeds sources received in the form schema.table=source to the tables config.
+// The table config is added if the table is not listed in the transformation section
+func SetSubsetSeedsOverrides(cfg *domains.Dump, overrides []string) error {
+	for _, o := range overrides {
+		tableName, source, ok := strings.Cut(o, "=")
+		schema, name, hasSchema := strings.Cut(tableName, ".")
+		if !ok || !hasSchema || schema == "" || name == "" || source == "" {
+			return fmt.Errorf("invalid subset seeds value \"%s\": expected schema.table=source", o)
+		}
+		var table *domains.Table
+		for _, t := range cfg.Transformation {
+			if t.Schema == schema && t.Name == name {
+				table = t
+				break
+			}
+		}
+		if table == nil {
+			table = &domains.Table{Schema: schema, Name: name}
+			cfg.Transformation = append(cfg.Transformation, table)
+		}
+		if table.SubsetSeeds == nil {
+			table.SubsetSeeds = &domains.SubsetSeeds{}
+		}
+		table.SubsetSeeds.Source = source
+	}
+	return nil
+}
+
+// loadSubsetSeeds - reads the seeds sources of the tables and sets the loaded values to the tables config. The
+// storage is initialized only if any source refers to it
+func (d *Dump) loadSubsetSeeds(ctx context.Context) error {
+	var st storages.Storager
+	for _, t := range d.config.Dump.Transformation {
+		s := t.SubsetSeeds
+		if s == nil {
+			continue
+		}
+		var r io.ReadCloser
+		var err error
+		if key, ok := strings.CutPrefix(s.Source, subsetSeedsStoragePrefix); ok {
+			if st == nil {
+				st, err = builder.GetStorage(ctx, &d.config.Storage, &d.config.Log)
+				if err != nil {
+					return fmt.Errorf("cannot initialise storage: %w", err)
+				}
+			}
+			r, err = st.GetObject(ctx, key)
+		} else {
+			r, err = os.Open(s.Source)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot open subset seeds source of table %s.%s: %w", t.Schema, t.Name, err)
+		}
+		err = readSubsetSeeds(r, s)
+		if closeErr := r.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read subset seeds source of table %s.%s: %w", t.Schema, t.Name, err)
+		}
+	}
+	return nil
+}
+
+// readSubsetSeeds - reads the values in the seeds format. The list format contains a single value per line, the
+// empty lines are skipped. The CSV format contains a value per column
+func readSubsetSeeds(r io.Reader, s *domains.SubsetSeeds) error {
+	format := strings.ToLower(s.Format)
+	if format == "" {
+		format = SubsetSeedsFormatList
+		if strings.EqualFold(filepath.Ext(s.Source), ".csv") {
+			format = SubsetSeedsFormatCsv
+		}
+	}
+
+	s.Values = nil
+	switch format {
+	case SubsetSeedsFormatList:
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			v := strings.TrimSpace(scanner.Text())
+			if v == "" {
+				continue
+			}
+			s.Values = append(s.Values, []string{v})
+		}
+		return scanner.Err()
+	case SubsetSeedsFormatCsv:
+		cr := csv.NewReader(r)
+		cr.TrimLeadingSpace = true
+		cr.FieldsPerRecord = -1
+		header := s.Header
+		for {
+			record, err := cr.Read()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			for i := range record {
+				record[i] = strings.TrimSpace(record[i])
+			}
+			if header {
+				header = false
+				if len(s.Columns) == 0 {
+					s.Columns = record
+				}
+				continue
+			}
+			s.Values = append(s.Values, record)
+		}
+	default:
+		return fmt.Errorf(
+			"unknown subset seeds format \"%s\": possible values [%s|%s]",
+			s.Format, SubsetSeedsFormatCsv, SubsetSeedsFormatList,
+		)
+	}
+}
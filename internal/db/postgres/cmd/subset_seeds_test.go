@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/domains"
+)
+
+func Test_readSubsetSeeds(t *testing.T) {
+	tests := []struct {
+		name            string
+		seeds           *domains.SubsetSeeds
+		data            string
+		expectedValues  [][]string
+		expectedColumns []string
+		expectedErr     string
+	}{
+		{
+			name:           "list by default",
+			seeds:          &domains.SubsetSeeds{Source: "ids.txt"},
+			data:           "1\n 2 \n\n\t\n3",
+			expectedValues: [][]string{{"1"}, {"2"}, {"3"}},
+		},
+		{
+			name:           "list with windows line endings",
+			seeds:          &domains.SubsetSeeds{Source: "ids.txt"},
+			data:           "1\r\n2\r\n\r\n",
+			expectedValues: [][]string{{"1"}, {"2"}},
+		},
+		{
+			name:           "csv by file extension",
+			seeds:          &domains.SubsetSeeds{Source: "ids.CSV"},
+			data:           "1,eu\n2, us \n",
+			expectedValues: [][]string{{"1", "eu"}, {"2", "us"}},
+		},
+		{
+			name:           "explicit format overrides file extension",
+			seeds:          &domains.SubsetSeeds{Source: "ids.csv", Format: "LIST"},
+			data:           "1,eu\n",
+			expectedValues: [][]string{{"1,eu"}},
+		},
+		{
+			name:           "csv empty lines are skipped",
+			seeds:          &domains.SubsetSeeds{Format: SubsetSeedsFormatCsv},
+			data:           "1,eu\n\n2,us\n\n",
+			expectedValues: [][]string{{"1", "eu"}, {"2", "us"}},
+		},
+		{
+			name:           "csv quoted values",
+			seeds:          &domains.SubsetSeeds{Format: SubsetSeedsFormatCsv},
+			data:           "\"1\",\"o'hara, jr\"\n",
+			expectedValues: [][]string{{"1", "o'hara, jr"}},
+		},
+		{
+			name:            "csv header sets columns",
+			seeds:           &domains.SubsetSeeds{Format: SubsetSeedsFormatCsv, Header: true},
+			data:            "id, region\n1,eu\n",
+			expectedValues:  [][]string{{"1", "eu"}},
+			expectedColumns: []string{"id", "region"},
+		},
+		{
+			name: "csv header does not override configured columns",
+			seeds: &domains.SubsetSeeds{
+				Format: SubsetSeedsFormatCsv, Header: true, Columns: []string{"tenant_id", "region_code"},
+			},
+			data:            "id,region\n1,eu\n",
+			expectedValues:  [][]string{{"1", "eu"}},
+			expectedColumns: []string{"tenant_id", "region_code"},
+		},
+		{
+			name:  "csv header only",
+			seeds: &domains.SubsetSeeds{Format: SubsetSeedsFormatCsv, Header: true},
+			data:  "id\n",
+			// The empty values are reported by the seeds validation
+			expectedColumns: []string{"id"},
+		},
+		{
+			name:        "invalid csv",
+			seeds:       &domains.SubsetSeeds{Format: SubsetSeedsFormatCsv},
+			data:        "\"1,eu\n",
+			expectedErr: "extraneous or missing \" in quoted-field",
+		},
+		{
+			name:        "unknown format",
+			seeds:       &domains.SubsetSeeds{Format: "json"},
+			expectedErr: "unknown subset seeds format \"json\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := readSubsetSeeds(strings.NewReader(tt.data), tt.seeds)
+			if tt.expectedErr != "" {
+				require.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedValues, tt.seeds.Values)
+			assert.Equal(t, tt.expectedColumns, tt.seeds.Columns)
+		})
+	}
+}
+
+func Test_readSubsetSeeds_resetsValues(t *testing.T) {
+	seeds := &domains.SubsetSeeds{Values: [][]string{{"old"}}}
+	require.NoError(t, readSubsetSeeds(strings.NewReader("new\n"), seeds))
+	assert.Equal(t, [][]string{{"new"}}, seeds.Values)
+}
+
+func TestSetSubsetSeedsOverrides(t *testing.T) {
+	cfg := &domains.Dump{
+		Transformation: []*domains.Table{
+			{Schema: "public", Name: "users", SubsetSeeds: &domains.SubsetSeeds{Source: "old.txt", Format: "list"}},
+		},
+	}
+	err := SetSubsetSeedsOverrides(cfg, []string{"public.users=new.txt", "billing.invoices=storage://seeds.csv"})
+	require.NoError(t, err)
+	require.Len(t, cfg.Transformation, 2)
+	assert.Equal(t, &domains.SubsetSeeds{Source: "new.txt", Format: "list"}, cfg.Transformation[0].SubsetSeeds)
+	assert.Equal(t, "billing", cfg.Transformation[1].Schema)
+	assert.Equal(t, "invoices", cfg.Transformation[1].Name)
+	assert.Equal(t, &domains.SubsetSeeds{Source: "storage://seeds.csv"}, cfg.Transformation[1].SubsetSeeds)
+
+	for _, v := range []string{"users=ids.txt", "public.users", ".users=ids.txt", "public.=ids.txt", "public.users="} {
+		assert.Error(t, SetSubsetSeedsOverrides(&domains.Dump{}, []string{v}), v)
+	}
+}
@@ -17,7 +17,6 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/greenmaskio/greenmask/internal/db/postgres/cmd/validate_utils"
-	runtimeContext "github.com/greenmaskio/greenmask/internal/db/postgres/context"
 	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
 	"github.com/greenmaskio/greenmask/internal/db/postgres/pgcopy"
 	storageDto "github.com/greenmaskio/greenmask/internal/db/postgres/storage"
@@ -121,13 +120,29 @@ func (v *Validate) Run(ctx context.Context) (int, error) {
 		return nonZeroExitCode, err
 	}
 	v.config.Dump.Transformation = tablesToValidate
+	// Limit the sensitive columns policy check to the validated tables as well. Otherwise, the columns
+	// of the filtered out tables would be reported as uncovered
+	if policy := v.config.Dump.Policy; len(v.config.Validate.Tables) > 0 &&
+		policy != nil && policy.SensitiveColumns != nil && len(policy.SensitiveColumns.Tables) == 0 {
+		policy.SensitiveColumns.Tables = v.config.Validate.Tables
+	}
 
-	v.context, err = runtimeContext.NewRuntimeContext(
-		ctx, tx, &v.config.Dump, v.registry,
-		v.config.Dump.VirtualReferences, v.version,
-	)
+	// The validation does not store the new mappings in the vault
+	ctx, vlt, err := openVault(ctx, &v.config.Dump, true)
 	if err != nil {
-		return nonZeroExitCode, fmt.Errorf("unable to build runtime context: %w", err)
+		return nonZeroExitCode, err
+	}
+	if vlt != nil {
+		defer func() {
+			if err := vlt.Close(); err != nil {
+				log.Warn().Err(err).Msg("error closing vault")
+			}
+		}()
+	}
+
+	v.context, err = v.newRuntimeContext(ctx, tx)
+	if err != nil {
+		return nonZeroExitCode, err
 	}
 
 	err = toolkit.PrintValidationWarnings(
@@ -144,7 +159,8 @@ func (v *Validate) Run(ctx context.Context) (int, error) {
 		return nonZeroExitCode, err
 	}
 
-	if !v.config.Validate.Data {
+	// The k-anonymity check is performed over the transformed sample, so it requires the data dump as well
+	if !v.config.Validate.Data && v.config.Validate.KAnonymity.K <= 0 {
 		return v.exitCode, nil
 	}
 
@@ -152,7 +168,13 @@ func (v *Validate) Run(ctx context.Context) (int, error) {
 		return nonZeroExitCode, err
 	}
 
-	if err = v.print(ctx); err != nil {
+	if v.config.Validate.Data {
+		if err = v.print(ctx); err != nil {
+			return nonZeroExitCode, err
+		}
+	}
+
+	if err = v.checkKAnonymity(ctx); err != nil {
 		return nonZeroExitCode, err
 	}
 
@@ -183,10 +205,87 @@ func (v *Validate) print(ctx context.Context) error {
 		if err = doc.Print(os.Stdout); err != nil {
 			return fmt.Errorf("unable to print validation document: %w", err)
 		}
+
+		if err = validate_utils.PrintTransformersStats(os.Stdout, t, v.config.Validate.Format); err != nil {
+			return fmt.Errorf("unable to print transformers stats: %w", err)
+		}
 	}
 	return nil
 }
 
+// checkKAnonymity - compute k-anonymity and l-diversity of the transformed sample and report the equivalence
+// classes that violate it. The exit code is non-zero if any violation is found
+func (v *Validate) checkKAnonymity(ctx context.Context) error {
+	cfg := v.config.Validate.KAnonymity
+	if cfg.K <= 0 {
+		return nil
+	}
+	if len(cfg.Tables) == 0 {
+		log.Warn().Msg("k-anonymity check is enabled but no tables with quasi-identifiers are defined")
+		return nil
+	}
+
+	for _, kt := range cfg.Tables {
+		idx := slices.IndexFunc(v.context.DataSectionObjectsToValidate, func(entry entries.Entry) bool {
+			t, ok := entry.(*entries.Table)
+			return ok && t.Schema == kt.Schema && t.Name == kt.Name
+		})
+		if idx == -1 {
+			log.Warn().
+				Str("SchemaName", kt.Schema).
+				Str("TableName", kt.Name).
+				Msg("skipping k-anonymity check: table is not found or does not have transformers")
+			continue
+		}
+		t := v.context.DataSectionObjectsToValidate[idx].(*entries.Table)
+
+		report, err := v.createKAnonymityReport(ctx, t, kt)
+		if err != nil {
+			return fmt.Errorf("unable to check k-anonymity of %s.%s: %w", kt.Schema, kt.Name, err)
+		}
+		if err = report.Print(os.Stdout, v.config.Validate.Format); err != nil {
+			return fmt.Errorf("unable to print k-anonymity report: %w", err)
+		}
+		if report.IsViolated() {
+			v.exitCode = nonZeroExitCode
+		}
+	}
+	return nil
+}
+
+func (v *Validate) createKAnonymityReport(
+	ctx context.Context, t *entries.Table, kt *domains.KAnonymityTable,
+) (*validate_utils.KAnonymityReport, error) {
+	cfg := v.config.Validate.KAnonymity
+	checker, err := validate_utils.NewKAnonymityChecker(t, kt.QuasiIdentifiers, kt.SensitiveColumns, cfg.K, cfg.L)
+	if err != nil {
+		return nil, err
+	}
+
+	closeReader, r, err := v.getReader(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	defer closeReader()
+
+	var line int
+	for {
+		_, transformed, err := v.readRecords(r, t)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if err = checker.Append(transformed); err != nil {
+			return nil, fmt.Errorf("unable to append line %d: %w", line, err)
+		}
+		line++
+	}
+
+	return checker.Report(), nil
+}
+
 func (v *Validate) getDocument(table *entries.Table) validate_utils.Documenter {
 	switch v.config.Validate.Format {
 	case JsonFormat:
@@ -0,0 +1,245 @@
+package validate_utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgcopy"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// EquivalenceClass - the group of rows that have the same values of the quasi-identifiers
+type EquivalenceClass struct {
+	Values map[string]string `json:"values"`
+	Size   int               `json:"size"`
+	// Diversity - the lowest number of the distinct values of the sensitive columns in the class
+	Diversity int `json:"diversity,omitempty"`
+	key       string
+	sensitive []map[string]struct{}
+}
+
+type KAnonymityReport struct {
+	Schema           string              `json:"schema"`
+	Name             string              `json:"name"`
+	QuasiIdentifiers []string            `json:"quasi_identifiers"`
+	SensitiveColumns []string            `json:"sensitive_columns,omitempty"`
+	K                int                 `json:"k"`
+	L                int                 `json:"l,omitempty"`
+	Rows             int                 `json:"rows"`
+	Classes          int                 `json:"classes"`
+	MinClassSize     int                 `json:"min_class_size"`
+	MinDiversity     int                 `json:"min_diversity,omitempty"`
+	Violations       []*EquivalenceClass `json:"violations"`
+}
+
+// KAnonymityChecker - groups the transformed rows into equivalence classes by the quasi-identifiers and finds the
+// classes that are smaller than k or have less than l distinct values of the sensitive columns
+type KAnonymityChecker struct {
+	table            *entries.Table
+	quasiIdentifiers []string
+	sensitiveColumns []string
+	qiIdxs           []int
+	sensitiveIdxs    []int
+	k                int
+	l                int
+	rows             int
+	classes          map[string]*EquivalenceClass
+}
+
+func NewKAnonymityChecker(
+	table *entries.Table, quasiIdentifiers, sensitiveColumns []string, k, l int,
+) (*KAnonymityChecker, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("k must be greater than 0")
+	}
+	if len(quasiIdentifiers) == 0 {
+		return nil, fmt.Errorf("at least one quasi-identifier column is required")
+	}
+	if l > 0 && len(sensitiveColumns) == 0 {
+		return nil, fmt.Errorf("l-diversity check requires at least one sensitive column")
+	}
+
+	qiIdxs, err := getColumnIdxsByNames(table, quasiIdentifiers)
+	if err != nil {
+		return nil, err
+	}
+	sensitiveIdxs, err := getColumnIdxsByNames(table, sensitiveColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KAnonymityChecker{
+		table:            table,
+		quasiIdentifiers: quasiIdentifiers,
+		sensitiveColumns: sensitiveColumns,
+		qiIdxs:           qiIdxs,
+		sensitiveIdxs:    sensitiveIdxs,
+		k:                k,
+		l:                l,
+		classes:          make(map[string]*EquivalenceClass),
+	}, nil
+}
+
+func (kac *KAnonymityChecker) Append(row *pgcopy.Row) error {
+	values := make([]*toolkit.RawValue, len(kac.qiIdxs))
+	keyParts := make([]string, len(kac.qiIdxs))
+	for i, idx := range kac.qiIdxs {
+		v, err := row.GetColumn(idx)
+		if err != nil {
+			return fmt.Errorf("unable to get column \"%s\" value: %w", kac.quasiIdentifiers[i], err)
+		}
+		values[i] = v
+		// NULL and the string "NULL" must fall into the different classes
+		if v.IsNull {
+			keyParts[i] = "N"
+		} else {
+			keyParts[i] = "V" + string(v.Data)
+		}
+	}
+	key := strings.Join(keyParts, "\x00")
+
+	class, ok := kac.classes[key]
+	if !ok {
+		class = &EquivalenceClass{
+			Values:    make(map[string]string, len(values)),
+			key:       key,
+			sensitive: make([]map[string]struct{}, len(kac.sensitiveIdxs)),
+		}
+		for i, v := range values {
+			class.Values[kac.quasiIdentifiers[i]] = getStringFromRawValue(v)
+		}
+		for i := range class.sensitive {
+			class.sensitive[i] = make(map[string]struct{})
+		}
+		kac.classes[key] = class
+	}
+
+	for i, idx := range kac.sensitiveIdxs {
+		v, err := row.GetColumn(idx)
+		if err != nil {
+			return fmt.Errorf("unable to get column \"%s\" value: %w", kac.sensitiveColumns[i], err)
+		}
+		class.sensitive[i][getStringFromRawValue(v)] = struct{}{}
+	}
+
+	class.Size++
+	kac.rows++
+	return nil
+}
+
+func (kac *KAnonymityChecker) Report() *KAnonymityReport {
+	report := &KAnonymityReport{
+		Schema:           kac.table.Schema,
+		Name:             kac.table.Name,
+		QuasiIdentifiers: kac.quasiIdentifiers,
+		SensitiveColumns: kac.sensitiveColumns,
+		K:                kac.k,
+		L:                kac.l,
+		Rows:             kac.rows,
+		Classes:          len(kac.classes),
+		Violations:       []*EquivalenceClass{},
+	}
+
+	for _, class := range kac.classes {
+		if len(class.sensitive) > 0 {
+			class.Diversity = len(class.sensitive[0])
+			for _, s := range class.sensitive[1:] {
+				class.Diversity = min(class.Diversity, len(s))
+			}
+		}
+		if report.MinClassSize == 0 || class.Size < report.MinClassSize {
+			report.MinClassSize = class.Size
+		}
+		if len(class.sensitive) > 0 && (report.MinDiversity == 0 || class.Diversity < report.MinDiversity) {
+			report.MinDiversity = class.Diversity
+		}
+		if class.Size < kac.k || (kac.l > 0 && class.Diversity < kac.l) {
+			report.Violations = append(report.Violations, class)
+		}
+	}
+
+	slices.SortFunc(report.Violations, func(a, b *EquivalenceClass) int {
+		if a.Size != b.Size {
+			return a.Size - b.Size
+		}
+		return strings.Compare(a.key, b.key)
+	})
+
+	return report
+}
+
+func (r *KAnonymityReport) IsViolated() bool {
+	return len(r.Violations) > 0
+}
+
+func (r *KAnonymityReport) Print(w io.Writer, format string) error {
+	switch format {
+	case jsonFormat:
+		return json.NewEncoder(w).Encode(r)
+	case textFormat:
+		return r.printText(w)
+	}
+	return fmt.Errorf("unknown format %s", format)
+}
+
+func (r *KAnonymityReport) printText(w io.Writer) error {
+	summary := fmt.Sprintf(
+		"k-anonymity of %s.%s: k=%d rows=%d classes=%d min_class_size=%d",
+		r.Schema, r.Name, r.K, r.Rows, r.Classes, r.MinClassSize,
+	)
+	if r.L > 0 {
+		summary += fmt.Sprintf(" l=%d min_diversity=%d", r.L, r.MinDiversity)
+	}
+	summary += fmt.Sprintf(" violations=%d\n", len(r.Violations))
+	if _, err := io.WriteString(w, summary); err != nil {
+		return err
+	}
+	if !r.IsViolated() {
+		return nil
+	}
+
+	header := slices.Clone(r.QuasiIdentifiers)
+	header = append(header, "%Size%")
+	if r.L > 0 {
+		header = append(header, "%Diversity%")
+	}
+
+	prettyWriter := tablewriter.NewWriter(w)
+	prettyWriter.SetAutoWrapText(true)
+	prettyWriter.SetAutoFormatHeaders(false)
+	prettyWriter.SetHeader(header)
+	for _, class := range r.Violations {
+		record := make([]string, 0, len(header))
+		for _, name := range r.QuasiIdentifiers {
+			record = append(record, class.Values[name])
+		}
+		record = append(record, strconv.Itoa(class.Size))
+		if r.L > 0 {
+			record = append(record, strconv.Itoa(class.Diversity))
+		}
+		prettyWriter.Append(record)
+	}
+	prettyWriter.Render()
+	return nil
+}
+
+func getColumnIdxsByNames(table *entries.Table, names []string) ([]int, error) {
+	idxs := make([]int, len(names))
+	for i, name := range names {
+		idx := slices.IndexFunc(table.Columns, func(column *toolkit.Column) bool {
+			return column.Name == name
+		})
+		if idx == -1 {
+			return nil, fmt.Errorf("column \"%s\" is not found in table %s.%s", name, table.Schema, table.Name)
+		}
+		idxs[i] = idx
+	}
+	return idxs, nil
+}
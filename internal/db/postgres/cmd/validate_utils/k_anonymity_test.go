@@ -0,0 +1,93 @@
+package validate_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgcopy"
+)
+
+func TestKAnonymityChecker_Report(t *testing.T) {
+	tab, _, transformedRecs := getTableAndRows()
+
+	checker, err := NewKAnonymityChecker(tab, []string{"groupname"}, []string{"name"}, 2, 2)
+	require.NoError(t, err)
+
+	for idx := range transformedRecs {
+		row := pgcopy.NewRow(4)
+		require.NoErrorf(t, row.Decode(transformedRecs[idx]), "error at %d line", idx)
+		require.NoErrorf(t, checker.Append(row), "error at %d line", idx)
+	}
+
+	report := checker.Report()
+	assert.Equal(t, 6, report.Rows)
+	assert.Equal(t, 3, report.Classes)
+	assert.Equal(t, 1, report.MinClassSize)
+	assert.Equal(t, 1, report.MinDiversity)
+	require.True(t, report.IsViolated())
+	require.Len(t, report.Violations, 1)
+	assert.Equal(t, map[string]string{"groupname": "Inventory Management"}, report.Violations[0].Values)
+	assert.Equal(t, 1, report.Violations[0].Size)
+	assert.Equal(t, 1, report.Violations[0].Diversity)
+}
+
+func TestKAnonymityChecker_Report_null_class(t *testing.T) {
+	tab, _, transformedRecs := getTableAndRows()
+
+	checker, err := NewKAnonymityChecker(tab, []string{"modifieddate"}, nil, 2, 0)
+	require.NoError(t, err)
+
+	for idx := range transformedRecs {
+		row := pgcopy.NewRow(4)
+		require.NoErrorf(t, row.Decode(transformedRecs[idx]), "error at %d line", idx)
+		require.NoErrorf(t, checker.Append(row), "error at %d line", idx)
+	}
+
+	report := checker.Report()
+	assert.Equal(t, 2, report.Classes)
+	require.Len(t, report.Violations, 1)
+	assert.Equal(t, map[string]string{"modifieddate": nullStringValue}, report.Violations[0].Values)
+	assert.Zero(t, report.MinDiversity)
+}
+
+func TestKAnonymityReport_Print(t *testing.T) {
+	tab, _, transformedRecs := getTableAndRows()
+
+	checker, err := NewKAnonymityChecker(tab, []string{"groupname"}, nil, 3, 0)
+	require.NoError(t, err)
+	for idx := range transformedRecs {
+		row := pgcopy.NewRow(4)
+		require.NoError(t, row.Decode(transformedRecs[idx]))
+		require.NoError(t, checker.Append(row))
+	}
+	report := checker.Report()
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, report.Print(buf, jsonFormat))
+	res := &KAnonymityReport{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), res))
+	assert.Equal(t, "department", res.Name)
+	assert.Len(t, res.Violations, 2)
+
+	buf.Reset()
+	require.NoError(t, report.Print(buf, textFormat))
+	assert.Contains(t, buf.String(), "violations=2")
+	assert.Contains(t, buf.String(), "Inventory Management")
+}
+
+func TestNewKAnonymityChecker_errors(t *testing.T) {
+	tab, _, _ := getTableAndRows()
+
+	_, err := NewKAnonymityChecker(tab, []string{"unknown"}, nil, 2, 0)
+	require.ErrorContains(t, err, "is not found")
+
+	_, err = NewKAnonymityChecker(tab, nil, nil, 2, 0)
+	require.Error(t, err)
+
+	_, err = NewKAnonymityChecker(tab, []string{"groupname"}, nil, 2, 2)
+	require.ErrorContains(t, err, "sensitive column")
+}
@@ -0,0 +1,78 @@
+package validate_utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+)
+
+type transformerCounter struct {
+	Columns []string `json:"columns"`
+	Counter string   `json:"counter"`
+	Value   uint64   `json:"value"`
+}
+
+type transformersStatsResponse struct {
+	Schema            string                `json:"schema"`
+	Name              string                `json:"name"`
+	TransformersStats []*transformerCounter `json:"transformers_stats"`
+}
+
+// PrintTransformersStats - prints the counters of the table transformers that implement utils.StatsReporter. Nothing
+// is printed if there are no such transformers
+func PrintTransformersStats(w io.Writer, t *entries.Table, format string) error {
+	var counters []*transformerCounter
+	for _, tc := range t.TransformersContext {
+		sr, ok := tc.Transformer.(utils.StatsReporter)
+		if !ok {
+			continue
+		}
+		affectedColumns := tc.Transformer.GetAffectedColumns()
+		columns := make([]string, 0, len(affectedColumns))
+		for _, idx := range slices.Sorted(maps.Keys(affectedColumns)) {
+			columns = append(columns, affectedColumns[idx])
+		}
+		stats := sr.GetStats()
+		for _, name := range slices.Sorted(maps.Keys(stats)) {
+			counters = append(counters, &transformerCounter{
+				Columns: columns,
+				Counter: name,
+				Value:   stats[name],
+			})
+		}
+	}
+	if len(counters) == 0 {
+		return nil
+	}
+
+	switch format {
+	case jsonFormat:
+		return json.NewEncoder(w).Encode(&transformersStatsResponse{
+			Schema:            t.Schema,
+			Name:              t.Name,
+			TransformersStats: counters,
+		})
+	case textFormat:
+		if _, err := fmt.Fprintf(w, "transformers stats of %s.%s\n", t.Schema, t.Name); err != nil {
+			return err
+		}
+		prettyWriter := tablewriter.NewWriter(w)
+		prettyWriter.SetAutoFormatHeaders(false)
+		prettyWriter.SetHeader([]string{"Column", "Counter", "Value"})
+		for _, c := range counters {
+			prettyWriter.Append([]string{strings.Join(c.Columns, ", "), c.Counter, strconv.FormatUint(c.Value, 10)})
+		}
+		prettyWriter.Render()
+		return nil
+	}
+	return fmt.Errorf("unknown format %s", format)
+}
@@ -0,0 +1,45 @@
+package validate_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+)
+
+type testStatsTransformer struct {
+	testTransformer
+}
+
+func (tst *testStatsTransformer) GetStats() map[string]uint64 {
+	return map[string]uint64{"phone": 2, "email": 3}
+}
+
+func TestPrintTransformersStats(t *testing.T) {
+	tab, _, _ := getTableAndRows()
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, PrintTransformersStats(buf, tab, textFormat))
+	assert.Empty(t, buf.String())
+
+	tab.TransformersContext = append(tab.TransformersContext, &utils.TransformerContext{
+		Transformer: &testStatsTransformer{},
+	})
+
+	require.NoError(t, PrintTransformersStats(buf, tab, jsonFormat))
+	res := &transformersStatsResponse{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), res))
+	assert.Equal(t, []*transformerCounter{
+		{Columns: []string{"name"}, Counter: "email", Value: 3},
+		{Columns: []string{"name"}, Counter: "phone", Value: 2},
+	}, res.TransformersStats)
+
+	buf.Reset()
+	require.NoError(t, PrintTransformersStats(buf, tab, textFormat))
+	assert.Contains(t, buf.String(), "transformers stats of humanresources.department")
+	assert.Contains(t, buf.String(), "email")
+}
@@ -11,6 +11,11 @@ var endOfFileSeq = []byte(`\.`)
 
 const nullStringValue = "NULL"
 
+const (
+	jsonFormat = "json"
+	textFormat = "text"
+)
+
 func getAffectedColumns(t *entries.Table) map[string]struct{} {
 	affectedColumns := make(map[string]struct{})
 	for _, tr := range t.TransformersContext {
@@ -0,0 +1,36 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/vault"
+)
+
+// openVault - opens the vault if dump.vault is set and returns the context with it. The transformers with the vault
+// namespace get the vault from the context. In the read only mode the new mappings are not stored
+func openVault(ctx context.Context, cfg *domains.Dump, readOnly bool) (context.Context, *vault.Vault, error) {
+	if cfg.Vault == nil {
+		return ctx, nil, nil
+	}
+	v, err := vault.Open(ctx, cfg.Vault, readOnly)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open vault: %w", err)
+	}
+	return vault.WithVault(ctx, v), v, nil
+}
@@ -0,0 +1,248 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/archive"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+	transformersUtils "github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// NewArchiveRuntimeContext - creates runtime context for the tables of the existing pg_dump archive. The tables
+// and their columns are built from the archive pre-data DDL instead of the live catalog, so the features that
+// require the database (subset, query, apply_for_references and the policy rules by comments and security labels)
+// are not supported
+func NewArchiveRuntimeContext(
+	ctx context.Context, t *toc.Toc, cfg *domains.Dump, r *transformersUtils.TransformerRegistry,
+) (*RuntimeContext, error) {
+	ctx, err := withSalt(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot set salt: %w", err)
+	}
+
+	tables, warnings, err := archive.GetTables(t)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get archive tables: %w", err)
+	}
+
+	buildWarns, err := validateAndBuildArchiveEntriesConfig(ctx, tables, cfg, r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot validate and build table config: %w", err)
+	}
+	warnings = append(warnings, buildWarns...)
+	if buildWarns.IsFatal() {
+		return &RuntimeContext{
+			Warnings: warnings,
+		}, nil
+	}
+
+	var dataSectionObjects, dataSectionObjectsToValidate []entries.Entry
+	schema := make(toolkit.DatabaseSchema, 0, len(tables))
+	for _, table := range tables {
+		schema = append(schema, table.Table)
+		if table.RelKind == 'p' {
+			continue
+		}
+		dataSectionObjects = append(dataSectionObjects, table)
+		if len(table.TransformersContext) > 0 {
+			dataSectionObjectsToValidate = append(dataSectionObjectsToValidate, table)
+		}
+	}
+
+	return &RuntimeContext{
+		DataSectionObjects:           dataSectionObjects,
+		DataSectionObjectsToValidate: dataSectionObjectsToValidate,
+		Warnings:                     warnings,
+		Registry:                     r,
+		DatabaseSchema:               schema,
+	}, nil
+}
+
+// validateAndBuildArchiveEntriesConfig - the same as validateAndBuildEntriesConfig, but it uses only the archive
+// tables metadata
+func validateAndBuildArchiveEntriesConfig(
+	ctx context.Context, tables []*entries.Table, cfg *domains.Dump, r *transformersUtils.TransformerRegistry,
+) (toolkit.ValidationWarnings, error) {
+	var warnings toolkit.ValidationWarnings
+	typeMap := pgtype.NewMap()
+
+	var mappings []*tableConfigMapping
+	for _, tcm := range findTablesWithTransformers(cfg.Transformation, tables) {
+		if w := validateArchiveTableConfig(tcm); len(w) > 0 {
+			warnings = append(warnings, w...)
+			continue
+		}
+		if tcm.entry.RelKind != 'p' {
+			mappings = append(mappings, tcm)
+			continue
+		}
+		if !tcm.config.ApplyForInherited {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetMsg("the table is partitioned use apply_for_inherited").
+				AddMeta("SchemaName", tcm.entry.Schema).
+				AddMeta("TableName", tcm.entry.Name).
+				SetSeverity(toolkit.ErrorValidationSeverity),
+			)
+			continue
+		}
+		for _, child := range getArchivePartitions(tcm.entry, tables) {
+			mappings = append(mappings, &tableConfigMapping{
+				entry:  child,
+				config: tcm.config,
+			})
+		}
+	}
+	for _, t := range cfg.Transformation {
+		found := slices.ContainsFunc(findTablesWithTransformers([]*domains.Table{t}, tables), func(*tableConfigMapping) bool {
+			return true
+		})
+		if !found {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetMsgf("table is not found in the archive").
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("Schema", t.Schema).
+				AddMeta("TableName", t.Name),
+			)
+		}
+	}
+	if warnings.IsFatal() {
+		return warnings, nil
+	}
+
+	for _, cfgMapping := range mappings {
+		driverWarnings, err := setGlobalDriverForTable(cfgMapping.entry, nil)
+		cfgMapping.entry.EnrichWarningsWithTableName(driverWarnings)
+		warnings = append(warnings, driverWarnings...)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot set global driver for table %s.%s: %w",
+				cfgMapping.entry.Schema, cfgMapping.entry.Name, err,
+			)
+		}
+		if driverWarnings.IsFatal() {
+			return warnings, nil
+		}
+
+		whenCondWarns := compileAndSetWhenCondForTable(cfgMapping.entry, cfgMapping.config)
+		cfgMapping.entry.EnrichWarningsWithTableName(whenCondWarns)
+		warnings = append(warnings, whenCondWarns...)
+		if whenCondWarns.IsFatal() {
+			return warnings, nil
+		}
+
+		setColumnTypeOverrides(cfgMapping.entry, cfgMapping.config, typeMap)
+
+		transformersInitWarns, err := initAndSetupTransformers(ctx, cfgMapping.entry, cfgMapping.config, r)
+		cfgMapping.entry.EnrichWarningsWithTableName(transformersInitWarns)
+		warnings = append(warnings, transformersInitWarns...)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot initialise and set transformers for table %s.%s: %w",
+				cfgMapping.entry.Schema, cfgMapping.entry.Name, err,
+			)
+		}
+
+		// Set on_error policies of the table and its transformers
+		onErrorWarns := setOnErrorPolicies(cfgMapping.entry, cfgMapping.config, cfg.AllowKeepOriginalOnError)
+		cfgMapping.entry.EnrichWarningsWithTableName(onErrorWarns)
+		warnings = append(warnings, onErrorWarns...)
+	}
+
+	policyWarns, err := validateArchiveSensitiveColumnsCoverage(ctx, tables, cfg.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("cannot validate sensitive columns policy: %w", err)
+	}
+	warnings = append(warnings, policyWarns...)
+
+	return warnings, nil
+}
+
+// validateArchiveTableConfig - returns fatal warnings if the table config uses the features that require the
+// database
+func validateArchiveTableConfig(tcm *tableConfigMapping) toolkit.ValidationWarnings {
+	var warnings toolkit.ValidationWarnings
+	if len(tcm.config.SubsetConds) > 0 || tcm.config.SubsetSample != nil || tcm.config.SubsetSeeds != nil ||
+		tcm.config.Query != "" {
+		warnings = append(warnings, toolkit.NewValidationWarning().
+			SetMsg("subset_conds, subset_sample, subset_seeds and query are not supported for the archive: the data cannot be filtered offline").
+			SetSeverity(toolkit.ErrorValidationSeverity).
+			AddMeta("SchemaName", tcm.entry.Schema).
+			AddMeta("TableName", tcm.entry.Name),
+		)
+	}
+	if tcm.hasTransformerWithApplyForReferences() {
+		warnings = append(warnings, toolkit.NewValidationWarning().
+			SetMsg("apply_for_references is not supported for the archive: the foreign keys are not introspected").
+			SetSeverity(toolkit.ErrorValidationSeverity).
+			AddMeta("SchemaName", tcm.entry.Schema).
+			AddMeta("TableName", tcm.entry.Name),
+		)
+	}
+	return warnings
+}
+
+// getArchivePartitions - returns all the partitions of the partitioned table including the partitions of the
+// sub-partitioned tables
+func getArchivePartitions(parent *entries.Table, tables []*entries.Table) []*entries.Table {
+	var res []*entries.Table
+	for _, oid := range parent.Children {
+		idx := slices.IndexFunc(tables, func(t *entries.Table) bool {
+			return t.Oid == oid
+		})
+		if idx == -1 {
+			continue
+		}
+		child := tables[idx]
+		child.RootPtSchema = parent.Schema
+		child.RootPtName = parent.Name
+		child.RootPtOid = parent.Oid
+		if child.RelKind == 'p' {
+			res = append(res, getArchivePartitions(child, tables)...)
+			continue
+		}
+		res = append(res, child)
+	}
+	return res
+}
+
+// validateArchiveSensitiveColumnsCoverage - checks the sensitive columns policy. The rules by comments and
+// security labels cannot be checked without the database
+func validateArchiveSensitiveColumnsCoverage(
+	ctx context.Context, tables []*entries.Table, policy *domains.Policy,
+) (toolkit.ValidationWarnings, error) {
+	if policy == nil || policy.SensitiveColumns == nil {
+		return nil, nil
+	}
+	rules, _ := compileSensitiveColumnRules(policy.SensitiveColumns.Rules)
+	if slices.ContainsFunc(rules, func(r *sensitiveColumnRule) bool {
+		return r.requiresMeta()
+	}) {
+		return toolkit.ValidationWarnings{
+			toolkit.NewValidationWarning().
+				SetMsg("sensitive columns rules by comment and security_label are not supported for the archive").
+				SetSeverity(toolkit.ErrorValidationSeverity),
+		}, nil
+	}
+	return validateSensitiveColumnsCoverage(ctx, nil, tables, policy)
+}
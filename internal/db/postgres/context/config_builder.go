@@ -72,9 +72,23 @@ func validateAndBuildEntriesConfig(
 		return nil, fmt.Errorf("cannot get Tables entries config: %w", err)
 	}
 	warnings = append(warnings, setConfigWarns...)
+	setGlobalSubsetParentMinimal(entries, cfg.Subset)
 	for _, cfgMapping := range entriesWithTransformers {
 		// set subset conditions
 		setSubsetConds(cfgMapping.entry, cfgMapping.config)
+		setSubsetParentMinimal(cfgMapping.entry, cfgMapping.config)
+		sampleWarns := setSubsetSample(cfgMapping.entry, cfgMapping.config)
+		cfgMapping.entry.EnrichWarningsWithTableName(sampleWarns)
+		warnings = append(warnings, sampleWarns...)
+		if sampleWarns.IsFatal() {
+			return warnings, nil
+		}
+		seedsWarns := setSubsetSeeds(cfgMapping.entry, cfgMapping.config, types)
+		cfgMapping.entry.EnrichWarningsWithTableName(seedsWarns)
+		warnings = append(warnings, seedsWarns...)
+		if seedsWarns.IsFatal() {
+			return warnings, nil
+		}
 		// set query
 		setQuery(cfgMapping.entry, cfgMapping.config)
 
@@ -87,14 +101,14 @@ func validateAndBuildEntriesConfig(
 				cfgMapping.entry.Schema, cfgMapping.entry.Name, err,
 			)
 		}
-		enrichWarningsWithTableName(driverWarnings, cfgMapping.entry)
+		cfgMapping.entry.EnrichWarningsWithTableName(driverWarnings)
 		if driverWarnings.IsFatal() {
 			return driverWarnings, nil
 		}
 
 		// Compile when condition and set to the table entry
 		whenCondWarns := compileAndSetWhenCondForTable(cfgMapping.entry, cfgMapping.config)
-		enrichWarningsWithTableName(driverWarnings, cfgMapping.entry)
+		cfgMapping.entry.EnrichWarningsWithTableName(driverWarnings)
 		warnings = append(warnings, whenCondWarns...)
 		if whenCondWarns.IsFatal() {
 			return whenCondWarns, nil
@@ -121,7 +135,7 @@ func validateAndBuildEntriesConfig(
 
 		// Set transformers for the table
 		transformersInitWarns, err := initAndSetupTransformers(ctx, cfgMapping.entry, cfgMapping.config, r)
-		enrichWarningsWithTableName(transformersInitWarns, cfgMapping.entry)
+		cfgMapping.entry.EnrichWarningsWithTableName(transformersInitWarns)
 		warnings = append(warnings, transformersInitWarns...)
 		if err != nil {
 			return nil, fmt.Errorf(
@@ -129,7 +143,20 @@ func validateAndBuildEntriesConfig(
 				cfgMapping.entry.Schema, cfgMapping.entry.Name, err,
 			)
 		}
+
+		// Set on_error policies of the table and its transformers
+		onErrorWarns := setOnErrorPolicies(cfgMapping.entry, cfgMapping.config, cfg.AllowKeepOriginalOnError)
+		onErrorWarns = append(onErrorWarns, validateOnErrorSkipReferences(cfgMapping.entry, graph)...)
+		cfgMapping.entry.EnrichWarningsWithTableName(onErrorWarns)
+		warnings = append(warnings, onErrorWarns...)
+	}
+
+	// Check that all the sensitive columns are covered by transformers
+	policyWarns, err := validateSensitiveColumnsCoverage(ctx, tx, entries, cfg.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("cannot validate sensitive columns policy: %w", err)
 	}
+	warnings = append(warnings, policyWarns...)
 
 	return warnings, nil
 }
@@ -211,6 +238,7 @@ func setConfigToEntries(
 				continue
 			}
 			refTables := getRefTables(tcm.entry, tcm.config, g, cfg)
+			resetParamsForReferences(refTables, r)
 			res = append(res, refTables...)
 		}
 		if tcm.entry.RelKind != 'p' {
@@ -368,6 +396,26 @@ func addTransformerToReferenceTable(
 	}
 }
 
+// resetParamsForReferences removes the parameters that are related to the root table only from the transformers
+// applied for the references
+func resetParamsForReferences(refTables []*tableConfigMapping, r *transformersUtils.TransformerRegistry) {
+	for _, tcm := range refTables {
+		for _, tr := range tcm.config.Transformers {
+			td, ok := r.Get(tr.Name)
+			if !ok {
+				continue
+			}
+			params, ok := td.Properties.GetMeta(transformers.ResetParamsForReferences)
+			if !ok {
+				continue
+			}
+			for _, name := range params.([]string) {
+				delete(tr.Params, name)
+			}
+		}
+	}
+}
+
 // getColumnTypeOverride retrieves column type overrides for foreign key columns, if specified
 func getColumnTypeOverride(rootTableCfg *domains.Table, columnName string) map[string]string {
 	colTypeOverride := make(map[string]string)
@@ -431,6 +479,21 @@ func setSubsetConds(t *entries.Table, cfg *domains.Table) {
 	t.SubsetConds = escapeSubsetConds(cfg.SubsetConds)
 }
 
+func setGlobalSubsetParentMinimal(tables []*entries.Table, cfg *domains.Subset) {
+	if cfg == nil {
+		return
+	}
+	for _, t := range tables {
+		t.SubsetParentMinimal = cfg.ParentMinimal
+	}
+}
+
+func setSubsetParentMinimal(t *entries.Table, cfg *domains.Table) {
+	if cfg.SubsetParentMinimal != nil {
+		t.SubsetParentMinimal = *cfg.SubsetParentMinimal
+	}
+}
+
 func setQuery(t *entries.Table, cfg *domains.Table) {
 	t.Query = cfg.Query
 }
@@ -501,13 +564,6 @@ func setColumnTypeOverrides(
 	}
 }
 
-func enrichWarningsWithTableName(warns toolkit.ValidationWarnings, t *entries.Table) {
-	for _, w := range warns {
-		w.AddMeta("SchemaName", t.Schema).
-			AddMeta("TableName", t.Name)
-	}
-}
-
 func enrichWarningsWithTransformerName(warns toolkit.ValidationWarnings, n string) {
 	for _, w := range warns {
 		w.AddMeta("TransformerName", n)
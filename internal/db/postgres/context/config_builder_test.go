@@ -223,6 +223,37 @@ func Test_isTransformerAllowedToApplyForReferences(t *testing.T) {
 	})
 }
 
+func Test_resetParamsForReferences(t *testing.T) {
+	refTables := []*tableConfigMapping{
+		{
+			config: &domains.Table{
+				Transformers: []*domains.TransformerConfig{
+					{
+						Name: transformers.DateShiftTransformerName,
+						Params: toolkit.StaticParameters{
+							"column":    toolkit.ParamsValue("patient_id"),
+							"columns":   toolkit.ParamsValue(`["admitted_at"]`),
+							"max_shift": toolkit.ParamsValue("30 days"),
+						},
+					},
+					{
+						Name: transformers.RandomIntTransformerName,
+						Params: toolkit.StaticParameters{
+							"column": toolkit.ParamsValue("patient_id"),
+						},
+					},
+				},
+			},
+		},
+	}
+	resetParamsForReferences(refTables, utils.DefaultTransformerRegistry)
+	assert.Equal(t, toolkit.StaticParameters{
+		"column":    toolkit.ParamsValue("patient_id"),
+		"max_shift": toolkit.ParamsValue("30 days"),
+	}, refTables[0].config.Transformers[0].Params)
+	assert.Len(t, refTables[0].config.Transformers[1].Params, 1)
+}
+
 func Test_runPostgresContainer(t *testing.T) {
 	ctx := context.Background()
 	// Start the PostgreSQL container
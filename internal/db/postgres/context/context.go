@@ -53,6 +53,9 @@ type RuntimeContext struct {
 	DatabaseSchema toolkit.DatabaseSchema
 	// Graph - graph of Tables with dependencies
 	Graph *subset.Graph
+	// SubsetKeySets - the materialised subset key sets in the topological order. The key sets required by the table
+	// query must be created in the worker transaction before the query execution
+	SubsetKeySets []*subset.KeySet
 }
 
 // NewRuntimeContext - creating new runtime context.
@@ -111,11 +114,27 @@ func NewRuntimeContext(
 		}, nil
 	}
 
+	// Set the content transformers of the large objects selected by the table columns or queries
+	if blobs != nil && len(cfg.LargeObjects) > 0 {
+		loWarns, err := setLargeObjectsTransformers(ctx, tx, blobs, cfg.LargeObjects)
+		if err != nil {
+			return nil, fmt.Errorf("cannot set large objects transformers: %w", err)
+		}
+		warnings = append(warnings, loWarns...)
+		if loWarns.IsFatal() {
+			return &RuntimeContext{
+				Warnings: warnings,
+			}, nil
+		}
+	}
+
 	// Set subset queries for Tables if they have subset conditions or sort Tables by size and transformation costs
+	var keySets []*subset.KeySet
 	if hasSubset(tables) {
 		// If table has subset the restoration must be in the topological order
 		// The Tables must be dumped one by one
-		if err = subset.SetSubsetQueries(graph); err != nil {
+		keySets, err = setSubsetQueries(ctx, tx, cfg, graph)
+		if err != nil {
 			return nil, fmt.Errorf("cannot set subset queries: %w", err)
 		}
 		debugQueries(tables)
@@ -157,6 +176,7 @@ func NewRuntimeContext(
 		DatabaseSchema:               schema,
 		Graph:                        graph,
 		DataSectionObjectsToValidate: dataSectionObjectsToValidate,
+		SubsetKeySets:                keySets,
 	}, nil
 }
 
@@ -188,6 +208,48 @@ func hasSubset(tables []*entries.Table) bool {
 	})
 }
 
+// setSubsetQueries - sets the subset queries. If materialisation is enabled and the temporary tables can be created
+// it returns the key sets to materialise, otherwise it falls back to the queries that join the whole path
+func setSubsetQueries(
+	ctx context.Context, tx pgx.Tx, cfg *domains.Dump, graph *subset.Graph,
+) ([]*subset.KeySet, error) {
+	if cfg.Subset == nil || !cfg.Subset.Materialize {
+		return nil, subset.SetSubsetQueries(graph)
+	}
+	allowed, err := isTempTablesAllowed(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot check temporary tables availability: %w", err)
+	}
+	if !allowed {
+		log.Warn().
+			Msg("temporary tables cannot be created: subset key sets materialisation is disabled")
+		return nil, subset.SetSubsetQueries(graph)
+	}
+	keySets, err := subset.SetMaterializedSubsetQueries(graph)
+	if err != nil {
+		return nil, err
+	}
+	for _, ks := range keySets {
+		log.Debug().Str("KeySet", ks.Name).Strs("Queries", ks.Statements).Msg("subset key set")
+	}
+	return keySets, nil
+}
+
+// isTempTablesAllowed - checks that the temporary tables might be created in the worker transactions. They cannot be
+// created on the standby, in the read only transaction and without TEMP privilege
+func isTempTablesAllowed(ctx context.Context, tx pgx.Tx) (bool, error) {
+	var allowed bool
+	row := tx.QueryRow(ctx, `
+		SELECT NOT pg_is_in_recovery()
+		       AND current_setting('transaction_read_only') = 'off'
+		       AND has_database_privilege(current_database(), 'TEMP')
+	`)
+	if err := row.Scan(&allowed); err != nil {
+		return false, err
+	}
+	return allowed, nil
+}
+
 func debugQueries(tables []*entries.Table) {
 	for _, t := range tables {
 		if t.Query == "" {
@@ -0,0 +1,202 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/subset"
+	transformersUtils "github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// NewIntrospectedRuntimeContext - creates runtime context for the tables introspected without the PostgreSQL catalog,
+// for instance from the database of another engine. The column types must be mapped to the PostgreSQL types, so the
+// transformers are used as is. The subset queries are built by the foreign keys fetched in advance. The subset of the
+// cyclic references and the policy rules by comments and security labels are not supported. The subset_sample,
+// subset_seeds, apply_for_references and subset.materialize settings are not applied, so the caller must reject them
+func NewIntrospectedRuntimeContext(
+	ctx context.Context, tables []*entries.Table, refs map[toolkit.Oid][]*toolkit.Reference, cfg *domains.Dump,
+	r *transformersUtils.TransformerRegistry, vr []*domains.VirtualReference,
+) (*RuntimeContext, error) {
+	var warnings toolkit.ValidationWarnings
+
+	ctx, err := withSalt(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot set salt: %w", err)
+	}
+
+	vrWarns := validateVirtualReferences(vr, tables)
+	warnings = append(warnings, vrWarns...)
+	if len(vrWarns) > 0 {
+		vr = nil
+	}
+
+	graph := subset.NewGraphFromReferences(slices.Clone(tables), refs, vr)
+
+	buildWarns, err := validateAndBuildIntrospectedEntriesConfig(ctx, tables, cfg, r, graph)
+	if err != nil {
+		return nil, fmt.Errorf("cannot validate and build table config: %w", err)
+	}
+	warnings = append(warnings, buildWarns...)
+	if buildWarns.IsFatal() {
+		return &RuntimeContext{
+			Warnings: warnings,
+		}, nil
+	}
+
+	if hasSubset(tables) {
+		if cycles := graph.GetCycledTables(); len(cycles) > 0 {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetMsg("subset of the database with cyclic references is not supported without the PostgreSQL catalog").
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("Cycles", cycles),
+			)
+			return &RuntimeContext{
+				Warnings: warnings,
+			}, nil
+		}
+		if err = subset.SetSubsetQueries(graph); err != nil {
+			return nil, fmt.Errorf("cannot set subset queries: %w", err)
+		}
+		debugQueries(tables)
+	} else {
+		scoreTablesEntriesAndSort(tables)
+	}
+
+	var dataSectionObjects, dataSectionObjectsToValidate []entries.Entry
+	schema := make(toolkit.DatabaseSchema, 0, len(tables))
+	for _, table := range tables {
+		schema = append(schema, table.Table)
+		dataSectionObjects = append(dataSectionObjects, table)
+		if len(table.TransformersContext) > 0 {
+			dataSectionObjectsToValidate = append(dataSectionObjectsToValidate, table)
+		}
+	}
+
+	return &RuntimeContext{
+		DataSectionObjects:           dataSectionObjects,
+		DataSectionObjectsToValidate: dataSectionObjectsToValidate,
+		Warnings:                     warnings,
+		Registry:                     r,
+		DatabaseSchema:               schema,
+		Graph:                        graph,
+	}, nil
+}
+
+// validateAndBuildIntrospectedEntriesConfig - the same as validateAndBuildEntriesConfig, but it uses only the
+// introspected tables metadata
+func validateAndBuildIntrospectedEntriesConfig(
+	ctx context.Context, tables []*entries.Table, cfg *domains.Dump, r *transformersUtils.TransformerRegistry,
+	graph *subset.Graph,
+) (toolkit.ValidationWarnings, error) {
+	var warnings toolkit.ValidationWarnings
+	typeMap := pgtype.NewMap()
+
+	mappings := findTablesWithTransformers(cfg.Transformation, tables)
+	for _, t := range cfg.Transformation {
+		if len(findTablesWithTransformers([]*domains.Table{t}, tables)) == 0 {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetMsgf("table is not found in the database").
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("Schema", t.Schema).
+				AddMeta("TableName", t.Name),
+			)
+		}
+	}
+	if warnings.IsFatal() {
+		return warnings, nil
+	}
+
+	setGlobalSubsetParentMinimal(tables, cfg.Subset)
+	for _, cfgMapping := range mappings {
+		setSubsetConds(cfgMapping.entry, cfgMapping.config)
+		setSubsetParentMinimal(cfgMapping.entry, cfgMapping.config)
+		setQuery(cfgMapping.entry, cfgMapping.config)
+
+		driverWarnings, err := setGlobalDriverForTable(cfgMapping.entry, nil)
+		cfgMapping.entry.EnrichWarningsWithTableName(driverWarnings)
+		warnings = append(warnings, driverWarnings...)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot set global driver for table %s.%s: %w",
+				cfgMapping.entry.Schema, cfgMapping.entry.Name, err,
+			)
+		}
+		if driverWarnings.IsFatal() {
+			return warnings, nil
+		}
+
+		whenCondWarns := compileAndSetWhenCondForTable(cfgMapping.entry, cfgMapping.config)
+		cfgMapping.entry.EnrichWarningsWithTableName(whenCondWarns)
+		warnings = append(warnings, whenCondWarns...)
+		if whenCondWarns.IsFatal() {
+			return warnings, nil
+		}
+
+		setColumnTypeOverrides(cfgMapping.entry, cfgMapping.config, typeMap)
+
+		transformersInitWarns, err := initAndSetupTransformers(ctx, cfgMapping.entry, cfgMapping.config, r)
+		cfgMapping.entry.EnrichWarningsWithTableName(transformersInitWarns)
+		warnings = append(warnings, transformersInitWarns...)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot initialise and set transformers for table %s.%s: %w",
+				cfgMapping.entry.Schema, cfgMapping.entry.Name, err,
+			)
+		}
+
+		// Set on_error policies of the table and its transformers
+		onErrorWarns := setOnErrorPolicies(cfgMapping.entry, cfgMapping.config, cfg.AllowKeepOriginalOnError)
+		onErrorWarns = append(onErrorWarns, validateOnErrorSkipReferences(cfgMapping.entry, graph)...)
+		cfgMapping.entry.EnrichWarningsWithTableName(onErrorWarns)
+		warnings = append(warnings, onErrorWarns...)
+	}
+
+	policyWarns, err := validateIntrospectedSensitiveColumnsCoverage(ctx, tables, cfg.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("cannot validate sensitive columns policy: %w", err)
+	}
+	warnings = append(warnings, policyWarns...)
+
+	return warnings, nil
+}
+
+// validateIntrospectedSensitiveColumnsCoverage - checks the sensitive columns policy. The rules by comments and
+// security labels are not supported
+func validateIntrospectedSensitiveColumnsCoverage(
+	ctx context.Context, tables []*entries.Table, policy *domains.Policy,
+) (toolkit.ValidationWarnings, error) {
+	if policy == nil || policy.SensitiveColumns == nil {
+		return nil, nil
+	}
+	rules, _ := compileSensitiveColumnRules(policy.SensitiveColumns.Rules)
+	if slices.ContainsFunc(rules, func(r *sensitiveColumnRule) bool {
+		return r.requiresMeta()
+	}) {
+		return toolkit.ValidationWarnings{
+			toolkit.NewValidationWarning().
+				SetMsg("sensitive columns rules by comment and security_label are not supported without the PostgreSQL catalog").
+				SetSeverity(toolkit.ErrorValidationSeverity),
+		}, nil
+	}
+	return validateSensitiveColumnsCoverage(ctx, nil, tables, policy)
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// largeObjectColumnQuery - returns true if the column stores the OIDs, i.e. it has oid type or domain over oid like lo
+const largeObjectColumnQuery = `
+	SELECT t.oid = 'oid'::regtype OR t.typbasetype = 'oid'::regtype
+	FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+	WHERE n.nspname = $1
+	  AND c.relname = $2
+	  AND a.attname = $3
+	  AND a.attnum > 0
+	  AND NOT a.attisdropped
+`
+
+// validateLargeObjectsConfig - checks that each rule has either table column or query and the mode is known
+func validateLargeObjectsConfig(rules []*domains.LargeObjects) toolkit.ValidationWarnings {
+	var warnings toolkit.ValidationWarnings
+	for idx, rule := range rules {
+		hasColumn := rule.Schema != "" || rule.Name != "" || rule.Column != ""
+		if hasColumn && rule.Query != "" {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("RuleIdx", idx).
+				SetMsg("large objects must be selected either by table column or by query"),
+			)
+		}
+		if rule.Query == "" && (rule.Schema == "" || rule.Name == "" || rule.Column == "") {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("RuleIdx", idx).
+				AddMeta("TableSchema", rule.Schema).
+				AddMeta("TableName", rule.Name).
+				AddMeta("ColumnName", rule.Column).
+				SetMsg("schema, name and column or query are required to select large objects"),
+			)
+		}
+		if rule.Mode != "" && !slices.Contains(transformers.BinaryContentModes, rule.Mode) {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("RuleIdx", idx).
+				AddMeta("Mode", rule.Mode).
+				AddMeta("AllowedValues", transformers.BinaryContentModes).
+				SetMsg("unknown large objects transformation mode"),
+			)
+		}
+	}
+	return warnings
+}
+
+// setLargeObjectsTransformers - selects the large objects by the rules and sets their content transformers
+func setLargeObjectsTransformers(
+	ctx context.Context, tx pgx.Tx, blobs *entries.Blobs, rules []*domains.LargeObjects,
+) (toolkit.ValidationWarnings, error) {
+	warnings := validateLargeObjectsConfig(rules)
+	if warnings.IsFatal() {
+		return warnings, nil
+	}
+
+	largeObjects := make(map[toc.Oid]*entries.LargeObject, len(blobs.LargeObjects))
+	for _, lo := range blobs.LargeObjects {
+		largeObjects[lo.Oid] = lo
+	}
+
+	for idx, rule := range rules {
+		query := rule.Query
+		if query == "" {
+			var isOidColumn bool
+			row := tx.QueryRow(ctx, largeObjectColumnQuery, rule.Schema, rule.Name, rule.Column)
+			if err := row.Scan(&isOidColumn); err != nil {
+				if !errors.Is(err, pgx.ErrNoRows) {
+					return nil, fmt.Errorf("error checking large objects column: %w", err)
+				}
+				warnings = append(warnings, toolkit.NewValidationWarning().
+					SetSeverity(toolkit.ErrorValidationSeverity).
+					AddMeta("RuleIdx", idx).
+					AddMeta("TableSchema", rule.Schema).
+					AddMeta("TableName", rule.Name).
+					AddMeta("ColumnName", rule.Column).
+					SetMsg("large objects column is not found"),
+				)
+				continue
+			}
+			if !isOidColumn {
+				warnings = append(warnings, toolkit.NewValidationWarning().
+					SetSeverity(toolkit.ErrorValidationSeverity).
+					AddMeta("RuleIdx", idx).
+					AddMeta("TableSchema", rule.Schema).
+					AddMeta("TableName", rule.Name).
+					AddMeta("ColumnName", rule.Column).
+					SetMsg("large objects column must have oid type or domain over oid"),
+				)
+				continue
+			}
+			column := pgx.Identifier{rule.Column}.Sanitize()
+			query = fmt.Sprintf(
+				"SELECT DISTINCT %s::oid FROM %s WHERE %s IS NOT NULL",
+				column, pgx.Identifier{rule.Schema, rule.Name}.Sanitize(), column,
+			)
+		}
+
+		mode := rule.Mode
+		if mode == "" {
+			mode = transformers.BinaryContentModePlaceholder
+		}
+		t, err := transformers.NewBinaryContent(mode, rule.KeepSize)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create large objects transformer: %w", err)
+		}
+
+		oids, err := getLargeObjectsOids(ctx, tx, query)
+		if err != nil {
+			return nil, fmt.Errorf("cannot select large objects of rule %d: %w", idx, err)
+		}
+		for _, oid := range oids {
+			// The references to the missing large objects are skipped
+			if lo, ok := largeObjects[oid]; ok && lo.Transformer == nil {
+				lo.Transformer = t
+			}
+		}
+	}
+	return warnings, nil
+}
+
+func getLargeObjectsOids(ctx context.Context, tx pgx.Tx, query string) ([]toc.Oid, error) {
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+	var res []toc.Oid
+	for rows.Next() {
+		var oid toc.Oid
+		if err = rows.Scan(&oid); err != nil {
+			return nil, fmt.Errorf("error scanning large object oid: %w", err)
+		}
+		res = append(res, oid)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading large objects oids: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/greenmaskio/greenmask/internal/domains"
+)
+
+func Test_validateLargeObjectsConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		rule          *domains.LargeObjects
+		expectedFatal bool
+	}{
+		{
+			name: "table column",
+			rule: &domains.LargeObjects{Schema: "public", Name: "documents", Column: "content", KeepSize: true},
+		},
+		{
+			name: "query",
+			rule: &domains.LargeObjects{
+				Query: "SELECT oid FROM pg_largeobject_metadata WHERE lomowner = 'app'::regrole",
+				Mode:  "strip_metadata",
+			},
+		},
+		{
+			name:          "both column and query",
+			rule:          &domains.LargeObjects{Schema: "public", Name: "documents", Column: "content", Query: "SELECT 1"},
+			expectedFatal: true,
+		},
+		{
+			name:          "incomplete column",
+			rule:          &domains.LargeObjects{Schema: "public", Name: "documents"},
+			expectedFatal: true,
+		},
+		{
+			name:          "unknown mode",
+			rule:          &domains.LargeObjects{Query: "SELECT 1", Mode: "null"},
+			expectedFatal: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warns := validateLargeObjectsConfig([]*domains.LargeObjects{tt.rule})
+			assert.Equal(t, tt.expectedFatal, warns.IsFatal())
+		})
+	}
+}
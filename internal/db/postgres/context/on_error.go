@@ -0,0 +1,195 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/subset"
+	transformersUtils "github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// setOnErrorPolicies - builds the on_error policies of the table and its transformers. The transformer policy
+// overrides the table one
+func setOnErrorPolicies(t *entries.Table, cfg *domains.Table, allowKeepOriginal bool) toolkit.ValidationWarnings {
+	var warnings toolkit.ValidationWarnings
+	tablePolicy, tableWarns := buildOnErrorPolicy(t, cfg.OnError, allowKeepOriginal)
+	warnings = append(warnings, tableWarns...)
+	if tableWarns.IsFatal() {
+		return warnings
+	}
+
+	for idx, tc := range t.TransformersContext {
+		if tc == nil || idx >= len(cfg.Transformers) {
+			continue
+		}
+		policy := tablePolicy
+		if trCfg := cfg.Transformers[idx]; trCfg.OnError != nil {
+			var trWarns toolkit.ValidationWarnings
+			policy, trWarns = buildOnErrorPolicy(t, trCfg.OnError, allowKeepOriginal)
+			enrichWarningsWithTransformerName(trWarns, trCfg.Name)
+			warnings = append(warnings, trWarns...)
+			if trWarns.IsFatal() {
+				continue
+			}
+		}
+		tc.OnError = policy
+
+		notNullWarns := validateOnErrorNotNullColumns(t, tc)
+		enrichWarningsWithTransformerName(notNullWarns, tc.Name)
+		warnings = append(warnings, notNullWarns...)
+	}
+	return warnings
+}
+
+// buildOnErrorPolicy - validates the on_error config and builds the policy. It returns nil policy if the config is
+// not set
+func buildOnErrorPolicy(
+	t *entries.Table, cfg *domains.OnError, allowKeepOriginal bool,
+) (*transformersUtils.OnErrorPolicy, toolkit.ValidationWarnings) {
+	if cfg == nil {
+		return nil, nil
+	}
+	var warnings toolkit.ValidationWarnings
+	policy := &transformersUtils.OnErrorPolicy{
+		Policy:   cfg.Policy,
+		Defaults: make(map[int]*toolkit.RawValue),
+	}
+	if policy.Policy == "" {
+		policy.Policy = transformersUtils.OnErrorFail
+	}
+
+	if !slices.Contains(transformersUtils.OnErrorPolicies, policy.Policy) {
+		warnings = append(warnings, toolkit.NewValidationWarning().
+			SetSeverity(toolkit.ErrorValidationSeverity).
+			AddMeta("Policy", policy.Policy).
+			AddMeta("AllowedPolicies", transformersUtils.OnErrorPolicies).
+			SetMsg("unknown on_error policy"),
+		)
+		return nil, warnings
+	}
+
+	if policy.Policy == transformersUtils.OnErrorKeepOriginal && !allowKeepOriginal {
+		warnings = append(warnings, toolkit.NewValidationWarning().
+			SetSeverity(toolkit.ErrorValidationSeverity).
+			AddMeta("Policy", policy.Policy).
+			AddMeta("Hint", "set dump.allow_keep_original_on_error to dump the original values of the failed rows").
+			SetMsg("keep_original on_error policy is not allowed"),
+		)
+		return nil, warnings
+	}
+
+	if len(cfg.Defaults) > 0 && policy.Policy != transformersUtils.OnErrorSetDefault {
+		warnings = append(warnings, toolkit.NewValidationWarning().
+			SetSeverity(toolkit.WarningValidationSeverity).
+			AddMeta("Policy", policy.Policy).
+			SetMsg("on_error defaults are used only by set_default policy"),
+		)
+	}
+
+	for name, value := range cfg.Defaults {
+		idx, _, ok := t.Driver.GetColumnByName(name)
+		if !ok {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ColumnName", name).
+				SetMsg("on_error default column is not found"),
+			)
+			continue
+		}
+		if _, err := t.Driver.DecodeValueByColumnIdx(idx, []byte(value)); err != nil {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ColumnName", name).
+				AddMeta("Value", value).
+				AddMeta("Error", err.Error()).
+				SetMsg("cannot decode on_error default value"),
+			)
+			continue
+		}
+		policy.Defaults[idx] = toolkit.NewRawValue([]byte(value), false)
+	}
+	if warnings.IsFatal() {
+		return nil, warnings
+	}
+	return policy, warnings
+}
+
+// validateOnErrorNotNullColumns - warns if the policy sets NULL into the NOT NULL column affected by the transformer.
+// The dump succeeds but the restoration of such rows fails
+func validateOnErrorNotNullColumns(
+	t *entries.Table, tc *transformersUtils.TransformerContext,
+) toolkit.ValidationWarnings {
+	if tc.OnError == nil ||
+		(tc.OnError.Policy != transformersUtils.OnErrorSetNull && tc.OnError.Policy != transformersUtils.OnErrorSetDefault) {
+		return nil
+	}
+	var warnings toolkit.ValidationWarnings
+	for _, idx := range tc.AffectedColumns(t.Columns) {
+		if _, ok := tc.OnError.Defaults[idx]; ok {
+			continue
+		}
+		if c := t.Columns[idx]; c.NotNull {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.WarningValidationSeverity).
+				AddMeta("ColumnName", c.Name).
+				AddMeta("Policy", tc.OnError.Policy).
+				SetMsg("on_error policy sets NULL into NOT NULL column: the restoration of the failed rows will fail"),
+			)
+		}
+	}
+	return warnings
+}
+
+// validateOnErrorSkipReferences - warns if the skip policy is set on the table that is referenced by the foreign keys
+// of the other tables. The skipped rows are not dumped, so the restoration of the rows referencing them fails
+func validateOnErrorSkipReferences(t *entries.Table, graph *subset.Graph) toolkit.ValidationWarnings {
+	hasSkipPolicy := slices.ContainsFunc(t.TransformersContext, func(tc *transformersUtils.TransformerContext) bool {
+		return tc != nil && tc.OnError != nil && tc.OnError.Policy == transformersUtils.OnErrorSkip
+	})
+	if graph == nil || !hasSkipPolicy {
+		return nil
+	}
+	idx := slices.IndexFunc(graph.Tables(), func(gt *entries.Table) bool {
+		return gt.Name == t.Name && gt.Schema == t.Schema
+	})
+	if idx == -1 {
+		return nil
+	}
+
+	var referencedBy []string
+	for _, e := range graph.ReversedGraph()[idx] {
+		ref := e.To().Table()
+		name := fmt.Sprintf("%s.%s", ref.Schema, ref.Name)
+		if !slices.Contains(referencedBy, name) {
+			referencedBy = append(referencedBy, name)
+		}
+	}
+	if len(referencedBy) == 0 {
+		return nil
+	}
+	return toolkit.ValidationWarnings{
+		toolkit.NewValidationWarning().
+			SetSeverity(toolkit.WarningValidationSeverity).
+			AddMeta("Policy", transformersUtils.OnErrorSkip).
+			AddMeta("ReferencedBy", referencedBy).
+			AddMeta("Hint", "use set_null or set_default policy to keep the referenced rows").
+			SetMsg("skip on_error policy breaks the foreign keys referencing the table on restoration"),
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/subset"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func newOnErrorTestTable(t *testing.T) *entries.Table {
+	table := &entries.Table{
+		Table: &toolkit.Table{
+			Schema: "public",
+			Name:   "users",
+			Columns: []*toolkit.Column{
+				{Name: "id", TypeName: "int4", TypeOid: pgtype.Int4OID, Num: 1, NotNull: true, Length: -1},
+				{Name: "email", TypeName: "text", TypeOid: pgtype.TextOID, Num: 2, Length: -1},
+				{Name: "age", TypeName: "int4", TypeOid: pgtype.Int4OID, Num: 3, NotNull: true, Length: -1},
+			},
+			Constraints: []toolkit.Constraint{},
+		},
+	}
+	driver, _, err := toolkit.NewDriver(table.Table, nil)
+	require.NoError(t, err)
+	table.Driver = driver
+	table.TransformersContext = []*utils.TransformerContext{
+		{Name: "RandomEmail", Transformer: &policyTestTransformer{affectedColumns: map[int]string{1: "email"}}},
+		{Name: "RandomInt", Transformer: &policyTestTransformer{affectedColumns: map[int]string{2: "age"}}},
+	}
+	return table
+}
+
+func Test_setOnErrorPolicies(t *testing.T) {
+	tests := []struct {
+		name              string
+		table             *domains.OnError
+		transformer       *domains.OnError
+		allowKeepOriginal bool
+		expected          []string
+		isFatal           bool
+		hasWarnings       bool
+	}{
+		{
+			name:     "not set",
+			expected: []string{"", ""},
+		},
+		{
+			name:     "table policy",
+			table:    &domains.OnError{Policy: utils.OnErrorSkip},
+			expected: []string{utils.OnErrorSkip, utils.OnErrorSkip},
+		},
+		{
+			name:        "transformer policy overrides table policy",
+			table:       &domains.OnError{Policy: utils.OnErrorSkip},
+			transformer: &domains.OnError{Policy: utils.OnErrorSetNull},
+			expected:    []string{utils.OnErrorSetNull, utils.OnErrorSkip},
+		},
+		{
+			name:        "empty policy is fail",
+			transformer: &domains.OnError{},
+			expected:    []string{utils.OnErrorFail, ""},
+		},
+		{
+			name:        "unknown policy",
+			transformer: &domains.OnError{Policy: "ignore"},
+			isFatal:     true,
+		},
+		{
+			name:        "keep original is not allowed",
+			transformer: &domains.OnError{Policy: utils.OnErrorKeepOriginal},
+			isFatal:     true,
+		},
+		{
+			name:              "keep original is allowed",
+			transformer:       &domains.OnError{Policy: utils.OnErrorKeepOriginal},
+			allowKeepOriginal: true,
+			expected:          []string{utils.OnErrorKeepOriginal, ""},
+		},
+		{
+			name:        "unknown default column",
+			transformer: &domains.OnError{Policy: utils.OnErrorSetDefault, Defaults: map[string]string{"phone": "1"}},
+			isFatal:     true,
+		},
+		{
+			name:    "invalid default value",
+			table:   &domains.OnError{Policy: utils.OnErrorSetDefault, Defaults: map[string]string{"age": "old"}},
+			isFatal: true,
+		},
+		{
+			name:     "default value",
+			table:    &domains.OnError{Policy: utils.OnErrorSetDefault, Defaults: map[string]string{"age": "18"}},
+			expected: []string{utils.OnErrorSetDefault, utils.OnErrorSetDefault},
+		},
+		{
+			name:        "set null into not null column",
+			table:       &domains.OnError{Policy: utils.OnErrorSetNull},
+			expected:    []string{utils.OnErrorSetNull, utils.OnErrorSetNull},
+			hasWarnings: true,
+		},
+		{
+			name:        "defaults without set_default policy",
+			transformer: &domains.OnError{Policy: utils.OnErrorSkip, Defaults: map[string]string{"email": "a@b.c"}},
+			expected:    []string{utils.OnErrorSkip, ""},
+			hasWarnings: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := newOnErrorTestTable(t)
+			cfg := &domains.Table{
+				OnError: tt.table,
+				Transformers: []*domains.TransformerConfig{
+					{Name: "RandomEmail", OnError: tt.transformer},
+					{Name: "RandomInt"},
+				},
+			}
+			warnings := setOnErrorPolicies(table, cfg, tt.allowKeepOriginal)
+			require.Equal(t, tt.isFatal, warnings.IsFatal())
+			if tt.isFatal {
+				return
+			}
+			assert.Equal(t, tt.hasWarnings, len(warnings) > 0)
+			for idx, policy := range tt.expected {
+				var actual string
+				if p := table.TransformersContext[idx].OnError; p != nil {
+					actual = p.Policy
+				}
+				assert.Equal(t, policy, actual)
+			}
+		})
+	}
+}
+
+func Test_setOnErrorPolicies_defaults(t *testing.T) {
+	table := newOnErrorTestTable(t)
+	cfg := &domains.Table{
+		OnError: &domains.OnError{Policy: utils.OnErrorSetDefault, Defaults: map[string]string{"age": "18"}},
+		Transformers: []*domains.TransformerConfig{
+			{Name: "RandomEmail"},
+			{Name: "RandomInt"},
+		},
+	}
+	warnings := setOnErrorPolicies(table, cfg, false)
+	require.Empty(t, warnings)
+	require.Equal(t, map[int]*toolkit.RawValue{
+		2: toolkit.NewRawValue([]byte("18"), false),
+	}, table.TransformersContext[1].OnError.Defaults)
+}
+
+func Test_validateOnErrorSkipReferences(t *testing.T) {
+	newTables := func(policy string) []*entries.Table {
+		users := newOnErrorTestTable(t)
+		users.Oid = 1
+		users.PrimaryKey = []string{"id"}
+		for _, tc := range users.TransformersContext {
+			tc.OnError = &utils.OnErrorPolicy{Policy: policy}
+		}
+		orders := &entries.Table{Table: &toolkit.Table{Oid: 2, Schema: "public", Name: "orders"}}
+		payments := &entries.Table{Table: &toolkit.Table{Oid: 3, Schema: "public", Name: "payments"}}
+		return []*entries.Table{users, orders, payments}
+	}
+	refs := map[toolkit.Oid][]*toolkit.Reference{
+		2: {
+			{Schema: "public", Name: "users", ReferencedKeys: []string{"user_id"}},
+			{Schema: "public", Name: "users", ReferencedKeys: []string{"manager_id"}},
+		},
+		3: {{Schema: "public", Name: "users", ReferencedKeys: []string{"user_id"}}},
+	}
+
+	t.Run("skip policy on referenced table", func(t *testing.T) {
+		tables := newTables(utils.OnErrorSkip)
+		graph := subset.NewGraphFromReferences(tables, refs, nil)
+		warnings := validateOnErrorSkipReferences(tables[0], graph)
+		require.Len(t, warnings, 1)
+		assert.Equal(t, toolkit.WarningValidationSeverity, warnings[0].Severity)
+		assert.Equal(t, []string{"public.orders", "public.payments"}, warnings[0].Meta["ReferencedBy"])
+	})
+
+	t.Run("set_null policy", func(t *testing.T) {
+		tables := newTables(utils.OnErrorSetNull)
+		graph := subset.NewGraphFromReferences(tables, refs, nil)
+		assert.Empty(t, validateOnErrorSkipReferences(tables[0], graph))
+	})
+
+	t.Run("not referenced table", func(t *testing.T) {
+		tables := newTables(utils.OnErrorSkip)
+		graph := subset.NewGraphFromReferences(tables, nil, nil)
+		assert.Empty(t, validateOnErrorSkipReferences(tables[0], graph))
+	})
+
+	t.Run("without graph", func(t *testing.T) {
+		tables := newTables(utils.OnErrorSkip)
+		assert.Empty(t, validateOnErrorSkipReferences(tables[0], nil))
+	})
+}
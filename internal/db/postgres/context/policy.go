@@ -0,0 +1,316 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const policyHint = "add a transformer for the column or declare an exemption with a reason in " +
+	"dump.policy.sensitive_columns.exemptions"
+
+// columnMeta - column comments and security labels collected from the table and its ancestors
+type columnMeta struct {
+	comments []string
+	labels   []string
+}
+
+// sensitiveColumnRule - compiled domains.SensitiveColumnRule
+type sensitiveColumnRule struct {
+	idx           int
+	schema        *regexp.Regexp
+	table         *regexp.Regexp
+	column        *regexp.Regexp
+	typ           *regexp.Regexp
+	comment       *regexp.Regexp
+	securityLabel *regexp.Regexp
+}
+
+func (r *sensitiveColumnRule) requiresMeta() bool {
+	return r.comment != nil || r.securityLabel != nil
+}
+
+// match - returns true if all the provided expressions match the column
+func (r *sensitiveColumnRule) match(t *entries.Table, c *toolkit.Column, meta *columnMeta) bool {
+	if r.schema != nil && !r.schema.MatchString(t.Schema) {
+		return false
+	}
+	if r.table != nil && !r.table.MatchString(t.Name) {
+		return false
+	}
+	if r.column != nil && !r.column.MatchString(c.Name) {
+		return false
+	}
+	if r.typ != nil && !r.typ.MatchString(c.TypeName) && !r.typ.MatchString(c.CanonicalTypeName) {
+		return false
+	}
+	if r.comment != nil && (meta == nil || !slices.ContainsFunc(meta.comments, r.comment.MatchString)) {
+		return false
+	}
+	if r.securityLabel != nil && (meta == nil || !slices.ContainsFunc(meta.labels, r.securityLabel.MatchString)) {
+		return false
+	}
+	return true
+}
+
+// validateSensitiveColumnsCoverage - checks that each column marked as sensitive by the policy is covered by at
+// least one transformer. Transformers inherited via apply_for_references or apply_for_inherited are taken into
+// account as well since they are already assigned to the tables at this stage
+func validateSensitiveColumnsCoverage(
+	ctx context.Context, tx pgx.Tx, tables []*entries.Table, policy *domains.Policy,
+) (toolkit.ValidationWarnings, error) {
+	if policy == nil || policy.SensitiveColumns == nil {
+		return nil, nil
+	}
+	p := policy.SensitiveColumns
+
+	rules, warnings := compileSensitiveColumnRules(p.Rules)
+	warnings = append(warnings, validateSensitiveColumnsExemptions(p.Exemptions)...)
+	if warnings.IsFatal() {
+		return warnings, nil
+	}
+	requiresMeta := slices.ContainsFunc(rules, func(r *sensitiveColumnRule) bool {
+		return r.requiresMeta()
+	})
+
+	usedExemptions := make(map[int]struct{})
+	for _, t := range tables {
+		if t.RelKind == 'p' || !isTableInPolicyScope(t, p.Tables) {
+			// Partitioned tables do not have data. Their partitions are checked instead
+			continue
+		}
+
+		var meta map[string]*columnMeta
+		if requiresMeta {
+			var err error
+			meta, err = getColumnsCommentsAndLabels(ctx, tx, t.Oid)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"cannot get columns comments and labels for table %s.%s: %w", t.Schema, t.Name, err,
+				)
+			}
+		}
+
+		covered := getColumnsCoveredByTransformers(t)
+		for _, c := range t.Columns {
+			matchedBy := findSensitiveColumnMatch(t, c, meta[c.Name], rules, p.Columns)
+			if matchedBy == "" {
+				continue
+			}
+			if _, ok := covered[c.Name]; ok {
+				continue
+			}
+
+			exemptionIdx := slices.IndexFunc(p.Exemptions, func(e *domains.SensitiveColumnExemption) bool {
+				return tableNameMatches(t, e.Schema, e.Name) && e.Column == c.Name
+			})
+			if exemptionIdx != -1 {
+				usedExemptions[exemptionIdx] = struct{}{}
+				warnings = append(warnings, toolkit.NewValidationWarning().
+					SetMsg("sensitive column is exempted from the coverage policy").
+					SetSeverity(toolkit.InfoValidationSeverity).
+					AddMeta("SchemaName", t.Schema).
+					AddMeta("TableName", t.Name).
+					AddMeta("ColumnName", c.Name).
+					AddMeta("MatchedBy", matchedBy).
+					AddMeta("Reason", p.Exemptions[exemptionIdx].Reason),
+				)
+				continue
+			}
+
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetMsg("sensitive column is not covered by any transformer").
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("SchemaName", t.Schema).
+				AddMeta("TableName", t.Name).
+				AddMeta("ColumnName", c.Name).
+				AddMeta("MatchedBy", matchedBy).
+				AddMeta("Hint", policyHint),
+			)
+		}
+	}
+
+	for idx, e := range p.Exemptions {
+		if _, ok := usedExemptions[idx]; ok {
+			continue
+		}
+		warnings = append(warnings, toolkit.NewValidationWarning().
+			SetMsg("policy exemption is not used: column is not sensitive, already transformed or not found").
+			SetSeverity(toolkit.WarningValidationSeverity).
+			AddMeta("ExemptionIdx", idx).
+			AddMeta("SchemaName", e.Schema).
+			AddMeta("TableName", e.Name).
+			AddMeta("ColumnName", e.Column),
+		)
+	}
+
+	return warnings, nil
+}
+
+func compileSensitiveColumnRules(rules []*domains.SensitiveColumnRule) (
+	[]*sensitiveColumnRule, toolkit.ValidationWarnings,
+) {
+	var res []*sensitiveColumnRule
+	var warnings toolkit.ValidationWarnings
+	for idx, r := range rules {
+		if r.Schema == "" && r.Table == "" && r.Column == "" && r.Type == "" &&
+			r.Comment == "" && r.SecurityLabel == "" {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetMsg("sensitive column rule must have at least one expression").
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("RuleIdx", idx),
+			)
+			continue
+		}
+
+		cr := &sensitiveColumnRule{idx: idx}
+		var ruleWarns toolkit.ValidationWarnings
+		for _, item := range []struct {
+			name string
+			expr string
+			dest **regexp.Regexp
+		}{
+			{"schema", r.Schema, &cr.schema},
+			{"table", r.Table, &cr.table},
+			{"column", r.Column, &cr.column},
+			{"type", r.Type, &cr.typ},
+			{"comment", r.Comment, &cr.comment},
+			{"security_label", r.SecurityLabel, &cr.securityLabel},
+		} {
+			if item.expr == "" {
+				continue
+			}
+			re, err := regexp.Compile(item.expr)
+			if err != nil {
+				ruleWarns = append(ruleWarns, toolkit.NewValidationWarning().
+					SetMsgf("cannot compile sensitive column rule expression: %s", err).
+					SetSeverity(toolkit.ErrorValidationSeverity).
+					AddMeta("RuleIdx", idx).
+					AddMeta("ParameterName", item.name).
+					AddMeta("ParameterValue", item.expr),
+				)
+				continue
+			}
+			*item.dest = re
+		}
+		warnings = append(warnings, ruleWarns...)
+		if !ruleWarns.IsFatal() {
+			res = append(res, cr)
+		}
+	}
+	return res, warnings
+}
+
+func validateSensitiveColumnsExemptions(exemptions []*domains.SensitiveColumnExemption) toolkit.ValidationWarnings {
+	var warnings toolkit.ValidationWarnings
+	for idx, e := range exemptions {
+		if e.Schema == "" || e.Name == "" || e.Column == "" {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetMsg("policy exemption must have schema, name and column").
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ExemptionIdx", idx),
+			)
+		}
+		if strings.TrimSpace(e.Reason) == "" {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetMsg("policy exemption must have a reason").
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ExemptionIdx", idx).
+				AddMeta("SchemaName", e.Schema).
+				AddMeta("TableName", e.Name).
+				AddMeta("ColumnName", e.Column),
+			)
+		}
+	}
+	return warnings
+}
+
+// findSensitiveColumnMatch - returns the description of the first policy item that marks the column as sensitive.
+// Returns empty string if the column is not sensitive
+func findSensitiveColumnMatch(
+	t *entries.Table, c *toolkit.Column, meta *columnMeta,
+	rules []*sensitiveColumnRule, columns []*domains.SensitiveColumn,
+) string {
+	for _, sc := range columns {
+		if tableNameMatches(t, sc.Schema, sc.Name) && sc.Column == c.Name {
+			return "columns"
+		}
+	}
+	for _, r := range rules {
+		if r.match(t, c, meta) {
+			return fmt.Sprintf("rules[%d]", r.idx)
+		}
+	}
+	return ""
+}
+
+func getColumnsCoveredByTransformers(t *entries.Table) map[string]struct{} {
+	res := make(map[string]struct{})
+	for _, tc := range t.TransformersContext {
+		for _, name := range tc.Transformer.GetAffectedColumns() {
+			res[name] = struct{}{}
+		}
+	}
+	return res
+}
+
+func isTableInPolicyScope(t *entries.Table, scope []string) bool {
+	if len(scope) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(scope, func(name string) bool {
+		schemaName, tableName, found := strings.Cut(name, ".")
+		if !found {
+			return tableNameMatches(t, t.Schema, schemaName)
+		}
+		return tableNameMatches(t, schemaName, tableName)
+	})
+}
+
+func tableNameMatches(t *entries.Table, schemaName, tableName string) bool {
+	return (tableName == t.Name || fmt.Sprintf(`"%s"`, tableName) == t.Name) &&
+		(schemaName == t.Schema || fmt.Sprintf(`"%s"`, schemaName) == t.Schema)
+}
+
+func getColumnsCommentsAndLabels(
+	ctx context.Context, tx pgx.Tx, tableOid toolkit.Oid,
+) (map[string]*columnMeta, error) {
+	rows, err := tx.Query(ctx, ColumnsCommentsAndLabelsQuery, tableOid)
+	if err != nil {
+		return nil, fmt.Errorf("error executing ColumnsCommentsAndLabelsQuery: %w", err)
+	}
+	defer rows.Close()
+
+	res := make(map[string]*columnMeta)
+	for rows.Next() {
+		var name string
+		meta := &columnMeta{}
+		if err = rows.Scan(&name, &meta.comments, &meta.labels); err != nil {
+			return nil, fmt.Errorf("error scanning ColumnsCommentsAndLabelsQuery: %w", err)
+		}
+		res[name] = meta
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,197 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+type policyTestTransformer struct {
+	affectedColumns map[int]string
+}
+
+func (tt *policyTestTransformer) Init(context.Context) error { return nil }
+
+func (tt *policyTestTransformer) Done(context.Context) error { return nil }
+
+func (tt *policyTestTransformer) Transform(_ context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	return r, nil
+}
+
+func (tt *policyTestTransformer) GetAffectedColumns() map[int]string {
+	return tt.affectedColumns
+}
+
+func newPolicyTestTable(transformedColumns ...string) *entries.Table {
+	affected := make(map[int]string)
+	for idx, name := range transformedColumns {
+		affected[idx] = name
+	}
+	return &entries.Table{
+		Table: &toolkit.Table{
+			Schema: "public",
+			Name:   "users",
+			Columns: []*toolkit.Column{
+				{Name: "id", TypeName: "int4", CanonicalTypeName: "integer"},
+				{Name: "email", TypeName: "text", CanonicalTypeName: "text"},
+				{Name: "phone_number", TypeName: "varchar", CanonicalTypeName: "character varying"},
+				{Name: "created_at", TypeName: "timestamptz", CanonicalTypeName: "timestamp with time zone"},
+			},
+		},
+		RelKind: 'r',
+		TransformersContext: []*utils.TransformerContext{
+			{Transformer: &policyTestTransformer{affectedColumns: affected}},
+		},
+	}
+}
+
+func getWarningsColumns(warnings toolkit.ValidationWarnings, severity string) []string {
+	var res []string
+	for _, w := range warnings {
+		if w.Severity == severity {
+			res = append(res, w.Meta["ColumnName"].(string))
+		}
+	}
+	return res
+}
+
+func Test_validateSensitiveColumnsCoverage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("nil policy", func(t *testing.T) {
+		warns, err := validateSensitiveColumnsCoverage(ctx, nil, []*entries.Table{newPolicyTestTable()}, nil)
+		require.NoError(t, err)
+		require.Empty(t, warns)
+	})
+
+	t.Run("uncovered columns", func(t *testing.T) {
+		policy := &domains.Policy{
+			SensitiveColumns: &domains.SensitiveColumnsPolicy{
+				Rules: []*domains.SensitiveColumnRule{
+					{Column: "^(email|phone.*)$"},
+				},
+				Columns: []*domains.SensitiveColumn{
+					{Schema: "public", Name: "users", Column: "created_at"},
+				},
+			},
+		}
+		warns, err := validateSensitiveColumnsCoverage(ctx, nil, []*entries.Table{newPolicyTestTable("email")}, policy)
+		require.NoError(t, err)
+		require.True(t, warns.IsFatal())
+		assert.ElementsMatch(t,
+			[]string{"phone_number", "created_at"},
+			getWarningsColumns(warns, toolkit.ErrorValidationSeverity),
+		)
+	})
+
+	t.Run("match by type", func(t *testing.T) {
+		policy := &domains.Policy{
+			SensitiveColumns: &domains.SensitiveColumnsPolicy{
+				Rules: []*domains.SensitiveColumnRule{
+					{Schema: "public", Type: "^character varying$"},
+				},
+			},
+		}
+		warns, err := validateSensitiveColumnsCoverage(ctx, nil, []*entries.Table{newPolicyTestTable()}, policy)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"phone_number"}, getWarningsColumns(warns, toolkit.ErrorValidationSeverity))
+	})
+
+	t.Run("exemptions", func(t *testing.T) {
+		policy := &domains.Policy{
+			SensitiveColumns: &domains.SensitiveColumnsPolicy{
+				Rules: []*domains.SensitiveColumnRule{
+					{Column: "^(email|phone_number)$"},
+				},
+				Exemptions: []*domains.SensitiveColumnExemption{
+					{Schema: "public", Name: "users", Column: "phone_number", Reason: "test numbers only"},
+					{Schema: "public", Name: "users", Column: "id", Reason: "not sensitive"},
+				},
+			},
+		}
+		warns, err := validateSensitiveColumnsCoverage(ctx, nil, []*entries.Table{newPolicyTestTable("email")}, policy)
+		require.NoError(t, err)
+		require.False(t, warns.IsFatal())
+		assert.Equal(t, []string{"phone_number"}, getWarningsColumns(warns, toolkit.InfoValidationSeverity))
+		assert.Equal(t, []string{"id"}, getWarningsColumns(warns, toolkit.WarningValidationSeverity))
+	})
+
+	t.Run("exemption without reason", func(t *testing.T) {
+		policy := &domains.Policy{
+			SensitiveColumns: &domains.SensitiveColumnsPolicy{
+				Exemptions: []*domains.SensitiveColumnExemption{
+					{Schema: "public", Name: "users", Column: "phone_number"},
+				},
+			},
+		}
+		warns, err := validateSensitiveColumnsCoverage(ctx, nil, []*entries.Table{newPolicyTestTable()}, policy)
+		require.NoError(t, err)
+		require.True(t, warns.IsFatal())
+	})
+
+	t.Run("invalid rule", func(t *testing.T) {
+		policy := &domains.Policy{
+			SensitiveColumns: &domains.SensitiveColumnsPolicy{
+				Rules: []*domains.SensitiveColumnRule{
+					{},
+					{Column: "("},
+				},
+			},
+		}
+		warns, err := validateSensitiveColumnsCoverage(ctx, nil, []*entries.Table{newPolicyTestTable()}, policy)
+		require.NoError(t, err)
+		require.Len(t, warns, 2)
+		require.True(t, warns.IsFatal())
+	})
+
+	t.Run("tables scope", func(t *testing.T) {
+		policy := &domains.Policy{
+			SensitiveColumns: &domains.SensitiveColumnsPolicy{
+				Rules: []*domains.SensitiveColumnRule{
+					{Column: "email"},
+				},
+				Tables: []string{"public.orders"},
+			},
+		}
+		warns, err := validateSensitiveColumnsCoverage(ctx, nil, []*entries.Table{newPolicyTestTable()}, policy)
+		require.NoError(t, err)
+		require.Empty(t, warns)
+	})
+}
+
+func Test_sensitiveColumnRule_match(t *testing.T) {
+	rules, warns := compileSensitiveColumnRules([]*domains.SensitiveColumnRule{
+		{Comment: "(?i)pii"},
+		{SecurityLabel: "^sensitive$"},
+	})
+	require.Empty(t, warns)
+	require.Len(t, rules, 2)
+
+	table := newPolicyTestTable()
+	column := table.Columns[1]
+	assert.False(t, rules[0].match(table, column, nil))
+	assert.True(t, rules[0].match(table, column, &columnMeta{comments: []string{"Contains PII"}}))
+	assert.False(t, rules[1].match(table, column, &columnMeta{labels: []string{"public"}}))
+	assert.True(t, rules[1].match(table, column, &columnMeta{labels: []string{"public", "sensitive"}}))
+}
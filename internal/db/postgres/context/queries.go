@@ -262,4 +262,32 @@ var (
 			JOIN pg_catalog.pg_attribute a ON a.attrelid = pcp.conrelid AND a.attnum = ANY (pcp.conkey) AND pcp.contype = 'p'
 		WHERE pcp.conrelid = $1;
 	`
+
+	// ColumnsCommentsAndLabelsQuery - SQL query for getting column comments and security labels of the table
+	// including the comments and labels defined on the ancestors (partitioned or inherited tables)
+	ColumnsCommentsAndLabelsQuery = `
+		WITH RECURSIVE ancestors AS (
+			SELECT $1::OID AS relid
+			UNION
+			SELECT i.inhparent
+			FROM pg_catalog.pg_inherits i
+				JOIN ancestors anc ON i.inhrelid = anc.relid
+		)
+		SELECT a.attname                                                                           AS name,
+			   coalesce(array_agg(DISTINCT d.description) FILTER ( WHERE d.description IS NOT NULL ),
+						ARRAY []::TEXT[])                                                          AS comments,
+			   coalesce(array_agg(DISTINCT sl.label) FILTER ( WHERE sl.label IS NOT NULL ),
+						ARRAY []::TEXT[])                                                          AS labels
+		FROM pg_catalog.pg_attribute a
+			JOIN ancestors anc ON a.attrelid = anc.relid
+			LEFT JOIN pg_catalog.pg_description d ON d.objoid = a.attrelid
+				AND d.classoid = 'pg_catalog.pg_class'::REGCLASS
+				AND d.objsubid = a.attnum
+			LEFT JOIN pg_catalog.pg_seclabel sl ON sl.objoid = a.attrelid
+				AND sl.classoid = 'pg_catalog.pg_class'::REGCLASS
+				AND sl.objsubid = a.attnum
+		WHERE a.attnum > 0
+		  AND NOT a.attisdropped
+		GROUP BY a.attname
+	`
 )
@@ -0,0 +1,173 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const (
+	subsetSampleMethodRandom      = "random"
+	subsetSampleMethodTableSample = "tablesample"
+	subsetSampleMethodHash        = "hash"
+)
+
+// subsetSampleBuckets - the number of the buckets the rows are distributed by the hash. It allows to set the
+// percent with precision up to 0.0001
+const subsetSampleBuckets = 1000000
+
+const (
+	subsetSampleGroupsAlias    = `"greenmask_sample_groups"`
+	subsetSampleRowsTotalAlias = `"greenmask_sample_rows"`
+)
+
+var subsetSampleHashMethodRegexp = regexp.MustCompile(`^hash\((.+)\)$`)
+
+// setSubsetSample - builds the subset condition by the sample config and adds it to the table subset conditions.
+// The condition is deterministic for every row, so the table is filtered in the same way in all the subset queries
+// and the same seed gives the same sample on every run
+func setSubsetSample(t *entries.Table, cfg *domains.Table) toolkit.ValidationWarnings {
+	if cfg.SubsetSample == nil {
+		return nil
+	}
+	cond, warnings := generateSubsetSampleCond(t, cfg.SubsetSample)
+	if warnings.IsFatal() {
+		return warnings
+	}
+	t.SubsetConds = append(t.SubsetConds, escapeSubsetConds([]string{cond})...)
+	return warnings
+}
+
+func generateSubsetSampleCond(t *entries.Table, s *domains.SubsetSample) (string, toolkit.ValidationWarnings) {
+	var warnings toolkit.ValidationWarnings
+	if (s.Percent == 0) == (s.Rows == 0) {
+		warnings = append(warnings, newSubsetSampleWarning("either percent or rows must be set in subset_sample"))
+	}
+	if s.Percent < 0 || s.Percent > 100 {
+		warnings = append(warnings, newSubsetSampleWarning("subset_sample percent must be in range (0, 100]").
+			AddMeta("Percent", s.Percent))
+	}
+	if s.Rows < 0 {
+		warnings = append(warnings, newSubsetSampleWarning("subset_sample rows must be positive").
+			AddMeta("Rows", s.Rows))
+	}
+
+	method, column := s.Method, ""
+	if method == "" {
+		method = subsetSampleMethodRandom
+	}
+	if m := subsetSampleHashMethodRegexp.FindStringSubmatch(method); m != nil {
+		method, column = subsetSampleMethodHash, strings.TrimSpace(m[1])
+		if !slices.ContainsFunc(t.Columns, func(c *toolkit.Column) bool {
+			return c.Name == column
+		}) {
+			warnings = append(warnings, newSubsetSampleWarning("subset_sample hash column is not found").
+				AddMeta("ColumnName", column))
+		}
+	}
+	switch method {
+	case subsetSampleMethodRandom, subsetSampleMethodTableSample, subsetSampleMethodHash:
+	default:
+		warnings = append(warnings, newSubsetSampleWarning("unknown subset_sample method").
+			AddMeta("Method", s.Method).
+			AddMeta("AllowedValues", []string{
+				subsetSampleMethodRandom, subsetSampleMethodTableSample, "hash(column)",
+			}))
+	}
+	if method == subsetSampleMethodTableSample && s.Rows > 0 {
+		warnings = append(warnings, newSubsetSampleWarning("subset_sample rows is not supported by tablesample method"))
+	}
+	if len(t.PrimaryKey) == 0 && method != subsetSampleMethodHash {
+		warnings = append(warnings, newSubsetSampleWarning(
+			"subset_sample requires primary key for this method: use hash(column) method with percent",
+		).AddMeta("Method", s.Method))
+	}
+	if warnings.IsFatal() {
+		return "", warnings
+	}
+
+	var pk []string
+	for _, k := range t.PrimaryKey {
+		pk = append(pk, fmt.Sprintf(`"%s"."%s"."%s"`, t.Schema, t.Name, k))
+	}
+	var key string
+	switch {
+	case method == subsetSampleMethodHash:
+		key = fmt.Sprintf(`"%s"."%s"."%s"`, t.Schema, t.Name, column)
+	case len(pk) == 1:
+		key = pk[0]
+	default:
+		key = fmt.Sprintf(`ROW(%s)`, strings.Join(pk, ", "))
+	}
+	hashExpr := fmt.Sprintf(`hashtextextended((%s)::TEXT, %d)`, key, s.Seed)
+
+	switch {
+	case method == subsetSampleMethodTableSample:
+		return fmt.Sprintf(
+			`(%s) IN (SELECT %s FROM "%s"."%s" TABLESAMPLE BERNOULLI (%s) REPEATABLE (%d))`,
+			strings.Join(pk, ", "), strings.Join(pk, ", "), t.Schema, t.Name, formatSubsetSamplePercent(s.Percent), s.Seed,
+		), warnings
+	case method == subsetSampleMethodHash && s.Rows > 0:
+		// The whole groups of the rows with the same column value are selected in the hash order while their total
+		// number of rows does not exceed the limit, so a group is never split at the limit boundary
+		return fmt.Sprintf(
+			`%s IN (SELECT "%s" FROM (SELECT %s AS "%s", sum(count(*)) OVER (ORDER BY %s, (%s)::TEXT) AS %s `+
+				`FROM "%s"."%s" WHERE %s IS NOT NULL GROUP BY %s) AS %s WHERE %s <= %d)`,
+			key, column, key, column, hashExpr, key, subsetSampleRowsTotalAlias,
+			t.Schema, t.Name, key, key, subsetSampleGroupsAlias, subsetSampleRowsTotalAlias, s.Rows,
+		), warnings
+	case s.Rows > 0:
+		// The primary key is used as tiebreaker for the rows with the same hash value
+		return fmt.Sprintf(
+			`(%s) IN (SELECT %s FROM "%s"."%s" ORDER BY %s, %s LIMIT %d)`,
+			strings.Join(pk, ", "), strings.Join(pk, ", "), t.Schema, t.Name, hashExpr, strings.Join(pk, ", "), s.Rows,
+		), warnings
+	default:
+		// The percent below the bucket precision is rounded up to one bucket, so the sample is never empty
+		buckets := int64(math.Round(s.Percent * subsetSampleBuckets / 100))
+		if buckets < 1 {
+			buckets = 1
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.WarningValidationSeverity).
+				AddMeta("Percent", s.Percent).
+				AddMeta("MinPercent", formatSubsetSamplePercent(100.0/subsetSampleBuckets)).
+				SetMsg("subset_sample percent is less than the minimal precision: rounded up to the minimal percent"),
+			)
+		}
+		return fmt.Sprintf(
+			`abs(mod(%s, %d)) < %d`,
+			hashExpr, subsetSampleBuckets, buckets,
+		), warnings
+	}
+}
+
+func formatSubsetSamplePercent(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+func newSubsetSampleWarning(msg string) *toolkit.ValidationWarning {
+	return toolkit.NewValidationWarning().
+		SetMsg(msg).
+		SetSeverity(toolkit.ErrorValidationSeverity)
+}
@@ -0,0 +1,154 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func Test_generateSubsetSampleCond(t *testing.T) {
+	table := &entries.Table{
+		Table: &toolkit.Table{
+			Schema:     "public",
+			Name:       "users",
+			PrimaryKey: []string{"id"},
+			Columns:    []*toolkit.Column{{Name: "id"}, {Name: "tenant_id"}},
+		},
+	}
+	noPkTable := &entries.Table{
+		Table: &toolkit.Table{
+			Schema:  "public",
+			Name:    "events",
+			Columns: []*toolkit.Column{{Name: "tenant_id"}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		table       *entries.Table
+		sample      *domains.SubsetSample
+		expected    string
+		isFatal     bool
+		hasWarnings bool
+	}{
+		{
+			name:     "random percent",
+			table:    table,
+			sample:   &domains.SubsetSample{Percent: 5, Seed: 42},
+			expected: `abs(mod(hashtextextended(("public"."users"."id")::TEXT, 42), 1000000)) < 50000`,
+		},
+		{
+			name:     "percent is rounded to the nearest bucket",
+			table:    table,
+			sample:   &domains.SubsetSample{Percent: 0.07},
+			expected: `abs(mod(hashtextextended(("public"."users"."id")::TEXT, 0), 1000000)) < 700`,
+		},
+		{
+			name:        "percent below precision is rounded up to one bucket",
+			table:       table,
+			sample:      &domains.SubsetSample{Percent: 0.00001},
+			expected:    `abs(mod(hashtextextended(("public"."users"."id")::TEXT, 0), 1000000)) < 1`,
+			hasWarnings: true,
+		},
+		{
+			name:   "random rows",
+			table:  table,
+			sample: &domains.SubsetSample{Rows: 10000, Method: "random"},
+			expected: `("public"."users"."id") IN (SELECT "public"."users"."id" FROM "public"."users" ` +
+				`ORDER BY hashtextextended(("public"."users"."id")::TEXT, 0), "public"."users"."id" LIMIT 10000)`,
+		},
+		{
+			name:   "tablesample",
+			table:  table,
+			sample: &domains.SubsetSample{Percent: 0.5, Method: "tablesample", Seed: 1},
+			expected: `("public"."users"."id") IN (SELECT "public"."users"."id" FROM "public"."users" ` +
+				`TABLESAMPLE BERNOULLI (0.5) REPEATABLE (1))`,
+		},
+		{
+			name:     "hash column without primary key",
+			table:    noPkTable,
+			sample:   &domains.SubsetSample{Percent: 10, Method: "hash(tenant_id)", Seed: 7},
+			expected: `abs(mod(hashtextextended(("public"."events"."tenant_id")::TEXT, 7), 1000000)) < 100000`,
+		},
+		{
+			name:   "hash column rows without primary key",
+			table:  noPkTable,
+			sample: &domains.SubsetSample{Rows: 1000, Method: "hash(tenant_id)", Seed: 7},
+			expected: `"public"."events"."tenant_id" IN (SELECT "tenant_id" FROM (SELECT "public"."events"."tenant_id" ` +
+				`AS "tenant_id", sum(count(*)) OVER (ORDER BY hashtextextended(("public"."events"."tenant_id")::TEXT, 7), ` +
+				`("public"."events"."tenant_id")::TEXT) AS "greenmask_sample_rows" FROM "public"."events" ` +
+				`WHERE "public"."events"."tenant_id" IS NOT NULL GROUP BY "public"."events"."tenant_id") ` +
+				`AS "greenmask_sample_groups" WHERE "greenmask_sample_rows" <= 1000)`,
+		},
+		{
+			name:    "both percent and rows",
+			table:   table,
+			sample:  &domains.SubsetSample{Percent: 5, Rows: 10},
+			isFatal: true,
+		},
+		{
+			name:    "percent out of range",
+			table:   table,
+			sample:  &domains.SubsetSample{Percent: 150},
+			isFatal: true,
+		},
+		{
+			name:    "unknown method",
+			table:   table,
+			sample:  &domains.SubsetSample{Percent: 5, Method: "system"},
+			isFatal: true,
+		},
+		{
+			name:    "unknown hash column",
+			table:   table,
+			sample:  &domains.SubsetSample{Percent: 5, Method: "hash(unknown)"},
+			isFatal: true,
+		},
+		{
+			name:    "tablesample rows",
+			table:   table,
+			sample:  &domains.SubsetSample{Rows: 5, Method: "tablesample"},
+			isFatal: true,
+		},
+		{
+			name:    "random without primary key",
+			table:   noPkTable,
+			sample:  &domains.SubsetSample{Percent: 5},
+			isFatal: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, warnings := generateSubsetSampleCond(tt.table, tt.sample)
+			require.Equal(t, tt.isFatal, warnings.IsFatal())
+			require.Equal(t, tt.expected, cond)
+			if !tt.isFatal {
+				require.Equal(t, tt.hasWarnings, len(warnings) > 0)
+			}
+		})
+	}
+}
+
+func Test_setSubsetSample(t *testing.T) {
+	table := &entries.Table{
+		Table: &toolkit.Table{
+			Schema:     "public",
+			Name:       "users",
+			PrimaryKey: []string{"id"},
+		},
+		SubsetConds: []string{`( "public"."users"."active" )`},
+	}
+	warnings := setSubsetSample(table, &domains.Table{
+		SubsetSample: &domains.SubsetSample{Percent: 100},
+	})
+	require.Empty(t, warnings)
+	require.Equal(t, []string{
+		`( "public"."users"."active" )`,
+		`( abs(mod(hashtextextended(("public"."users"."id")::TEXT, 0), 1000000)) < 1000000 )`,
+	}, table.SubsetConds)
+}
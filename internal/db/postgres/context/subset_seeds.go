@@ -0,0 +1,118 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// setSubsetSeeds - builds the subset condition by the loaded seed values and adds it to the table subset conditions.
+// The dependent tables are subsetted by the usual subset propagation
+func setSubsetSeeds(t *entries.Table, cfg *domains.Table, types []*toolkit.Type) toolkit.ValidationWarnings {
+	if cfg.SubsetSeeds == nil {
+		return nil
+	}
+	cond, warnings := generateSubsetSeedsCond(t, cfg.SubsetSeeds, types)
+	if warnings.IsFatal() {
+		return warnings
+	}
+	t.SubsetConds = append(t.SubsetConds, escapeSubsetConds([]string{cond})...)
+	return warnings
+}
+
+// generateSubsetSeedsCond - generates the condition that matches the table rows with the seed values list. The
+// values are cast to the column types, so the comparison might use the table indexes
+func generateSubsetSeedsCond(
+	t *entries.Table, s *domains.SubsetSeeds, types []*toolkit.Type,
+) (string, toolkit.ValidationWarnings) {
+	var warnings toolkit.ValidationWarnings
+	columnNames := s.Columns
+	if len(columnNames) == 0 {
+		columnNames = t.PrimaryKey
+	}
+	if len(columnNames) == 0 {
+		return "", append(warnings, newSubsetSeedsWarning(
+			"subset_seeds columns must be set for the table without primary key",
+		))
+	}
+
+	columns := make([]*toolkit.Column, 0, len(columnNames))
+	for _, name := range columnNames {
+		idx := slices.IndexFunc(t.Columns, func(c *toolkit.Column) bool {
+			return c.Name == name
+		})
+		if idx == -1 {
+			warnings = append(warnings, newSubsetSeedsWarning("subset_seeds column is not found").
+				AddMeta("ColumnName", name))
+			continue
+		}
+		columns = append(columns, t.Columns[idx])
+	}
+	if len(s.Values) == 0 {
+		warnings = append(warnings, newSubsetSeedsWarning("subset_seeds source contains no values").
+			AddMeta("Source", s.Source))
+	}
+	if warnings.IsFatal() {
+		return "", warnings
+	}
+
+	var rows []string
+	for idx, values := range s.Values {
+		if len(values) != len(columns) {
+			return "", append(warnings, newSubsetSeedsWarning("subset_seeds values count does not match columns count").
+				AddMeta("Source", s.Source).
+				AddMeta("RowNum", idx+1).
+				AddMeta("ColumnsCount", len(columns)).
+				AddMeta("ValuesCount", len(values)))
+		}
+		literals := make([]string, 0, len(values))
+		for i, v := range values {
+			literals = append(literals, fmt.Sprintf(
+				`'%s'::%s`, strings.ReplaceAll(v, "'", "''"), getSubsetSeedsCastType(columns[i], types),
+			))
+		}
+		rows = append(rows, fmt.Sprintf(`(%s)`, strings.Join(literals, ", ")))
+	}
+
+	keys := make([]string, 0, len(columns))
+	for _, c := range columns {
+		keys = append(keys, fmt.Sprintf(`"%s"."%s"."%s"`, t.Schema, t.Name, c.Name))
+	}
+	return fmt.Sprintf(`(%s) IN (VALUES %s)`, strings.Join(keys, ", "), strings.Join(rows, ", ")), warnings
+}
+
+// getSubsetSeedsCastType - returns the schema-qualified name of the custom column type, so the cast does not depend
+// on the search_path. The built-in types are always visible and returned as is
+func getSubsetSeedsCastType(c *toolkit.Column, types []*toolkit.Type) string {
+	idx := slices.IndexFunc(types, func(typ *toolkit.Type) bool {
+		return typ.Oid == c.TypeOid
+	})
+	if idx == -1 {
+		return c.TypeName
+	}
+	return fmt.Sprintf(`"%s"."%s"`, types[idx].Schema, types[idx].Name)
+}
+
+func newSubsetSeedsWarning(msg string) *toolkit.ValidationWarning {
+	return toolkit.NewValidationWarning().
+		SetMsg(msg).
+		SetSeverity(toolkit.ErrorValidationSeverity)
+}
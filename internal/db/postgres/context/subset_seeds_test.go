@@ -0,0 +1,81 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func Test_generateSubsetSeedsCond(t *testing.T) {
+	table := &entries.Table{
+		Table: &toolkit.Table{
+			Schema:     "public",
+			Name:       "customers",
+			PrimaryKey: []string{"id"},
+			Columns: []*toolkit.Column{
+				{Name: "id", TypeName: "integer", TypeOid: 23},
+				{Name: "tenant", TypeName: "text", TypeOid: 25},
+				{Name: "status", TypeName: "status_type", TypeOid: 16385},
+			},
+		},
+	}
+	types := []*toolkit.Type{
+		{Oid: 16385, Schema: "billing", Name: "status_type"},
+	}
+
+	tests := []struct {
+		name     string
+		seeds    *domains.SubsetSeeds
+		expected string
+		isFatal  bool
+	}{
+		{
+			name:     "primary key",
+			seeds:    &domains.SubsetSeeds{Values: [][]string{{"123"}, {"456"}}},
+			expected: `("public"."customers"."id") IN (VALUES ('123'::integer), ('456'::integer))`,
+		},
+		{
+			name: "columns",
+			seeds: &domains.SubsetSeeds{
+				Columns: []string{"tenant", "id"},
+				Values:  [][]string{{"o'hara", "1"}},
+			},
+			expected: `("public"."customers"."tenant", "public"."customers"."id") IN (VALUES ('o''hara'::text, '1'::integer))`,
+		},
+		{
+			name: "custom type is schema-qualified",
+			seeds: &domains.SubsetSeeds{
+				Columns: []string{"status"},
+				Values:  [][]string{{"active"}},
+			},
+			expected: `("public"."customers"."status") IN (VALUES ('active'::"billing"."status_type"))`,
+		},
+		{
+			name:    "empty values",
+			seeds:   &domains.SubsetSeeds{},
+			isFatal: true,
+		},
+		{
+			name:    "unknown column",
+			seeds:   &domains.SubsetSeeds{Columns: []string{"unknown"}, Values: [][]string{{"1"}}},
+			isFatal: true,
+		},
+		{
+			name:    "values count mismatch",
+			seeds:   &domains.SubsetSeeds{Values: [][]string{{"1", "2"}}},
+			isFatal: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, warnings := generateSubsetSeedsCond(table, tt.seeds, types)
+			require.Equal(t, tt.isFatal, warnings.IsFatal())
+			require.Equal(t, tt.expected, cond)
+		})
+	}
+}
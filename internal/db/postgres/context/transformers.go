@@ -18,8 +18,11 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/custom"
 	transformersUtils "github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
 	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/vault"
 	"github.com/greenmaskio/greenmask/pkg/toolkit"
 )
 
@@ -45,5 +48,40 @@ func initTransformer(
 	if err != nil {
 		return nil, nil, fmt.Errorf("unable to init transformer: %w", err)
 	}
+	if c.Vault != "" && !warnings.IsFatal() {
+		if w := setVaultTransformer(ctx, d, c, transformer); w != nil {
+			warnings = append(warnings, w)
+		}
+	}
 	return transformer, warnings, nil
 }
+
+// setVaultTransformer - wraps the transformer with the vault transformer if the vault namespace is set. The custom
+// and template record transformers cannot be wrapped because they are executed differently by the pipeline
+func setVaultTransformer(
+	ctx context.Context, d *toolkit.Driver, c *domains.TransformerConfig, tc *transformersUtils.TransformerContext,
+) *toolkit.ValidationWarning {
+	newWarning := func(msg string) *toolkit.ValidationWarning {
+		return toolkit.NewValidationWarning().
+			SetMsg(msg).
+			AddMeta("SchemaName", d.Table.Schema).
+			AddMeta("TableName", d.Table.Name).
+			AddMeta("TransformerName", c.Name).
+			AddMeta("Vault", c.Vault).
+			SetSeverity(toolkit.ErrorValidationSeverity)
+	}
+	v := vault.FromCtx(ctx)
+	if v == nil {
+		return newWarning("vault is not configured: set dump.vault section")
+	}
+	switch tc.Transformer.(type) {
+	case *custom.CmdTransformer, *transformers.TemplateRecordTransformer:
+		return newWarning("vault is not supported by the transformer")
+	}
+	vt, err := transformersUtils.NewVaultTransformer(tc.Transformer, v, c.Vault)
+	if err != nil {
+		return newWarning(err.Error())
+	}
+	tc.Transformer = vt
+	return nil
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/jackc/pgx/v5"
 
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
 	"github.com/greenmaskio/greenmask/internal/storages"
 )
 
@@ -26,3 +27,10 @@ type DumpTask interface {
 	Execute(ctx context.Context, tx pgx.Tx, st storages.Storager) error
 	DebugInfo() string
 }
+
+// TableTask - the task that dumps the table data. The worker prepares the session objects the table query depends on
+// before the task execution
+type TableTask interface {
+	DumpTask
+	Table() *entries.Table
+}
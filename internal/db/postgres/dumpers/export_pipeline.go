@@ -0,0 +1,113 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dumpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/export"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgcopy"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// ExportPipeline - transforms the table rows the same way as TransformationPipeline does and writes them using
+// export.RowWriter instead of COPY format
+type ExportPipeline struct {
+	table *entries.Table
+	// tp - transformation pipeline. It is nil if the table does not have transformers
+	tp     *TransformationPipeline
+	row    *pgcopy.Row
+	values []*toolkit.RawValue
+	w      export.RowWriter
+	line   uint64
+}
+
+func NewExportPipeline(
+	ctx context.Context, eg *errgroup.Group, table *entries.Table, w export.RowWriter,
+) (*ExportPipeline, error) {
+	var tp *TransformationPipeline
+	if len(table.TransformersContext) > 0 {
+		var err error
+		// The transformation pipeline writer is not used since the transformed lines are written by the export
+		// pipeline
+		tp, err = NewTransformationPipeline(ctx, eg, table, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &ExportPipeline{
+		table:  table,
+		tp:     tp,
+		row:    pgcopy.NewRow(len(table.Columns)),
+		values: make([]*toolkit.RawValue, len(table.Columns)),
+		w:      w,
+	}, nil
+}
+
+func (ep *ExportPipeline) Init(ctx context.Context) error {
+	if ep.tp != nil {
+		return ep.tp.Init(ctx)
+	}
+	return nil
+}
+
+func (ep *ExportPipeline) Dump(ctx context.Context, data []byte) error {
+	ep.line++
+	line := data[:len(data)-1]
+	if ep.tp != nil {
+		var err error
+		line, err = ep.tp.transformLine(ctx, data)
+		if errors.Is(err, ErrRowSkipped) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := ep.row.Decode(line); err != nil {
+		return NewDumpError(ep.table.Schema, ep.table.Name, ep.line, fmt.Errorf("error decoding copy line: %w", err))
+	}
+	for idx := range ep.values {
+		v, err := ep.row.GetColumn(idx)
+		if err != nil {
+			return NewDumpError(ep.table.Schema, ep.table.Name, ep.line, fmt.Errorf("error getting column value: %w", err))
+		}
+		ep.values[idx] = v
+	}
+	if err := ep.w.Write(ep.values); err != nil {
+		return NewDumpError(ep.table.Schema, ep.table.Name, ep.line, fmt.Errorf("error writing exported data: %w", err))
+	}
+	return nil
+}
+
+func (ep *ExportPipeline) Done(ctx context.Context) error {
+	if ep.tp != nil {
+		return ep.tp.Done(ctx)
+	}
+	return nil
+}
+
+func (ep *ExportPipeline) CompleteDump() error {
+	if err := ep.w.Close(); err != nil {
+		return NewDumpError(ep.table.Schema, ep.table.Name, ep.line, fmt.Errorf("error completing export: %w", err))
+	}
+	return nil
+}
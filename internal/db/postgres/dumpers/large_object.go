@@ -145,6 +145,13 @@ func largeObjectDumper(ctx context.Context, lo *entries.LargeObject, w ioutils.C
 		if err != nil {
 			return fmt.Errorf("error dumping large object %d: %w", lo.Oid, err)
 		}
+		// The transformer requires the whole content to detect its type, so it is collected in memory
+		var dst io.Writer = w
+		var content *bytes.Buffer
+		if lo.Transformer != nil {
+			content = bytes.NewBuffer(nil)
+			dst = content
+		}
 		var done bool
 		for !done {
 			size, err := loObj.Read(buf)
@@ -156,7 +163,15 @@ func largeObjectDumper(ctx context.Context, lo *entries.LargeObject, w ioutils.C
 					return fmt.Errorf("error reading large object %d: %w", lo.Oid, err)
 				}
 			}
-			if _, err = w.Write(buf); err != nil {
+			if _, err = dst.Write(buf); err != nil {
+				return fmt.Errorf("error writing large object %d into storage: %w", lo.Oid, err)
+			}
+		}
+		if content != nil {
+			log.Debug().
+				Uint32("oid", uint32(lo.Oid)).
+				Msg("transforming large object")
+			if _, err = w.Write(lo.Transformer.Transform(content.Bytes())); err != nil {
 				return fmt.Errorf("error writing large object %d into storage: %w", lo.Oid, err)
 			}
 		}
@@ -0,0 +1,222 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dumpers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgcopy"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/storages"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// ErrRowSkipped - the transformer error of the row is handled by the skip on_error policy. The row must not be
+// dumped or applied
+var ErrRowSkipped = errors.New("row is skipped by on_error policy")
+
+const (
+	redactedValue = "[redacted]"
+	// minRedactedValueLength - the shorter original values are not scrubbed from the error messages since they
+	// match almost any text
+	minRedactedValueLength = 3
+)
+
+// rowError - the transformer error handled by the on_error policy
+type rowError struct {
+	Transformer string `json:"transformer"`
+	Policy      string `json:"policy"`
+	Error       string `json:"error"`
+}
+
+// quarantineRecord - the line of the quarantine object. The values of the columns affected by the transformers
+// are redacted
+type quarantineRecord struct {
+	Line    uint64             `json:"line"`
+	Skipped bool               `json:"skipped"`
+	Errors  []*rowError        `json:"errors"`
+	Row     map[string]*string `json:"row"`
+}
+
+// quarantineWriter - streams the quarantine records into the storage object. The object is created on the first
+// record, so there is no object for the table without the failed rows
+type quarantineWriter struct {
+	ctx      context.Context
+	st       storages.Storager
+	name     string
+	w        *io.PipeWriter
+	uploaded chan error
+}
+
+func newQuarantineWriter(ctx context.Context, st storages.Storager, name string) *quarantineWriter {
+	return &quarantineWriter{
+		ctx:  ctx,
+		st:   st,
+		name: name,
+	}
+}
+
+func (qw *quarantineWriter) Write(p []byte) (int, error) {
+	if qw.w == nil {
+		r, w := io.Pipe()
+		qw.w = w
+		qw.uploaded = make(chan error, 1)
+		go func() {
+			err := qw.st.PutObject(qw.ctx, qw.name, r)
+			// Unblock the writer if the object is not read till the end
+			_ = r.CloseWithError(err)
+			qw.uploaded <- err
+		}()
+	}
+	return qw.w.Write(p)
+}
+
+// close - completes the object upload. The upload is aborted if dumpErr is not nil. It returns true if the object
+// has been written
+func (qw *quarantineWriter) close(dumpErr error) (bool, error) {
+	if qw.w == nil {
+		return false, nil
+	}
+	_ = qw.w.CloseWithError(dumpErr)
+	if err := <-qw.uploaded; err != nil {
+		return false, fmt.Errorf("cannot write quarantine object: %w", err)
+	}
+	return dumpErr == nil, nil
+}
+
+// redactedColumnIdxs - returns the sorted indexes of the columns affected by any transformer of the table
+func redactedColumnIdxs(table *entries.Table) []int {
+	var res []int
+	for _, tc := range table.TransformersContext {
+		for _, idx := range tc.AffectedColumns(table.Columns) {
+			if !slices.Contains(res, idx) {
+				res = append(res, idx)
+			}
+		}
+	}
+	slices.Sort(res)
+	return res
+}
+
+// handleTransformError - applies the on_error policy of the transformer to the current row. It returns DumpError
+// for fail policy and ErrRowSkipped for skip policy
+func (tp *TransformationPipeline) handleTransformError(tc *utils.TransformerContext, transformErr error) error {
+	if tc.OnError.IsFail() {
+		return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, transformErr)
+	}
+	tp.rowErrors = append(tp.rowErrors, &rowError{
+		Transformer: tc.Name,
+		Policy:      tc.OnError.Policy,
+		Error:       tp.redactErrorMessage(transformErr.Error()),
+	})
+	tp.stats.markError(tc.Name)
+
+	if tc.OnError.Policy == utils.OnErrorSkip {
+		return ErrRowSkipped
+	}
+	for _, idx := range tc.AffectedColumns(tp.table.Columns) {
+		var v *toolkit.RawValue
+		switch tc.OnError.Policy {
+		case utils.OnErrorSetNull, utils.OnErrorSetDefault:
+			v = tc.OnError.Defaults[idx]
+			if v == nil {
+				v = toolkit.NewRawValue(nil, true)
+			}
+		case utils.OnErrorKeepOriginal:
+			raw, err := tp.row.GetColumnRaw(idx)
+			if err != nil {
+				return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error getting original value: %w", err))
+			}
+			v = pgcopy.DecodeAttr(raw, nil)
+		default:
+			return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("unknown on_error policy \"%s\"", tc.OnError.Policy))
+		}
+		if err := tp.record.SetRawColumnValueByIdx(idx, v); err != nil {
+			return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error applying on_error policy: %w", err))
+		}
+	}
+	return nil
+}
+
+// completeRowErrors - counts the failed row and writes it into the quarantine if the row had handled errors
+func (tp *TransformationPipeline) completeRowErrors(skipped bool) error {
+	if len(tp.rowErrors) == 0 {
+		return nil
+	}
+	defer func() {
+		tp.rowErrors = tp.rowErrors[:0]
+	}()
+	tp.stats.markFailedRow(skipped)
+	if tp.quarantine == nil {
+		return nil
+	}
+
+	qr := &quarantineRecord{
+		Line:    tp.line,
+		Skipped: skipped,
+		Errors:  tp.rowErrors,
+		Row:     make(map[string]*string, len(tp.table.Columns)),
+	}
+	for idx, c := range tp.table.Columns {
+		if slices.Contains(tp.redactedColumns, idx) {
+			v := redactedValue
+			qr.Row[c.Name] = &v
+			continue
+		}
+		raw, err := tp.row.GetColumnRaw(idx)
+		if err != nil {
+			return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error getting original value: %w", err))
+		}
+		if v := pgcopy.DecodeAttr(raw, nil); !v.IsNull {
+			s := string(v.Data)
+			qr.Row[c.Name] = &s
+		} else {
+			qr.Row[c.Name] = nil
+		}
+	}
+	data, err := json.Marshal(qr)
+	if err != nil {
+		return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error encoding quarantine record: %w", err))
+	}
+	data = append(data, endOfLineSeq...)
+	if _, err = tp.quarantine.Write(data); err != nil {
+		return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error writing quarantine record: %w", err))
+	}
+	return nil
+}
+
+// redactErrorMessage - scrubs the original values of the columns affected by the transformers from the error message
+// since the transformers often include the invalid value into the error
+func (tp *TransformationPipeline) redactErrorMessage(msg string) string {
+	for _, idx := range tp.redactedColumns {
+		raw, err := tp.row.GetColumnRaw(idx)
+		if err != nil {
+			continue
+		}
+		v := pgcopy.DecodeAttr(raw, nil)
+		if v.IsNull || len(v.Data) < minRedactedValueLength {
+			continue
+		}
+		msg = strings.ReplaceAll(msg, string(v.Data), redactedValue)
+	}
+	return msg
+}
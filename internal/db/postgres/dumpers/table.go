@@ -15,7 +15,10 @@
 package dumpers
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 
@@ -25,16 +28,21 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgcopy"
 	"github.com/greenmaskio/greenmask/internal/storages"
 	"github.com/greenmaskio/greenmask/internal/utils/ioutils"
 )
 
 type TableDumper struct {
 	table             *entries.Table
-	recordNum         uint64
 	validate          bool
 	validateRowsLimit uint64
 	usePgzip          bool
+	// openArchiveData - opens the table COPY data of the existing pg_dump archive. If it is set then the data is
+	// read from the archive instead of the database
+	openArchiveData func() (io.ReadCloser, error)
+	// quarantine - the writer of the rows with the transformer errors handled by the on_error policies
+	quarantine *quarantineWriter
 }
 
 func NewTableDumper(table *entries.Table, validate bool, rowsLimit uint64, usePgzip bool) *TableDumper {
@@ -46,6 +54,16 @@ func NewTableDumper(table *entries.Table, validate bool, rowsLimit uint64, usePg
 	}
 }
 
+// NewArchiveTableDumper - creates TableDumper that reads the table data from the existing pg_dump archive using
+// openData function instead of the database. The transaction passed to Execute is not used and might be nil
+func NewArchiveTableDumper(table *entries.Table, openData func() (io.ReadCloser, error), usePgzip bool) *TableDumper {
+	return &TableDumper{
+		table:           table,
+		usePgzip:        usePgzip,
+		openArchiveData: openData,
+	}
+}
+
 // writer - writes the data to the storage
 func (td *TableDumper) writer(ctx context.Context, st storages.Storager, r io.ReadCloser) func() error {
 	return func() error {
@@ -74,10 +92,12 @@ func (td *TableDumper) dumper(ctx context.Context, eg *errgroup.Group, w io.Writ
 					return fmt.Errorf("cannot initialize validation pipeline: %w", err)
 				}
 			} else {
-				pipeline, err = NewTransformationPipeline(ctx, eg, td.table, w)
+				tp, err := NewTransformationPipeline(ctx, eg, td.table, w)
 				if err != nil {
 					return fmt.Errorf("cannot initialize transformation pipeline: %w", err)
 				}
+				tp.SetQuarantine(td.quarantine)
+				pipeline = tp
 			}
 
 		} else {
@@ -101,6 +121,9 @@ func (td *TableDumper) dumper(ctx context.Context, eg *errgroup.Group, w io.Writ
 func (td *TableDumper) Execute(ctx context.Context, tx pgx.Tx, st storages.Storager) error {
 
 	w, r := ioutils.NewGzipPipe(td.usePgzip)
+	name := fmt.Sprintf("quarantine/%d.jsonl", td.table.DumpId)
+	// The quarantine upload uses the parent context because the group context is cancelled when Wait returns
+	td.quarantine = newQuarantineWriter(ctx, st, name)
 
 	eg, gtx := errgroup.WithContext(ctx)
 
@@ -109,12 +132,28 @@ func (td *TableDumper) Execute(ctx context.Context, tx pgx.Tx, st storages.Stora
 	// Dumping and transformation goroutine
 	eg.Go(td.dumper(gtx, eg, w, tx))
 
-	if err := eg.Wait(); err != nil {
+	err := eg.Wait()
+	quarantined, quarantineErr := td.quarantine.close(err)
+	if err != nil {
 		return err
 	}
+	if quarantineErr != nil {
+		return quarantineErr
+	}
 
 	td.table.OriginalSize = w.GetCount()
 	td.table.CompressedSize = r.GetCount()
+
+	if quarantined {
+		td.table.QuarantineObject = name
+		log.Warn().
+			Str("SchemaName", td.table.Schema).
+			Str("TableName", td.table.Name).
+			Uint64("RowsFailed", td.table.Stats.RowsFailed).
+			Uint64("RowsSkipped", td.table.Stats.RowsSkipped).
+			Str("Object", name).
+			Msg("transformation errors were handled by on_error policy")
+	}
 	return nil
 }
 
@@ -125,11 +164,60 @@ func (td *TableDumper) process(ctx context.Context, tx pgx.Tx, w io.WriteCloser,
 		}
 	}()
 
+	if td.openArchiveData != nil {
+		r, err := td.openArchiveData()
+		if err != nil {
+			return fmt.Errorf("cannot open archive data: %w", err)
+		}
+		defer func() {
+			if err := r.Close(); err != nil {
+				log.Warn().Err(err).Msg("error closing archive data reader")
+			}
+		}()
+		return copyFromArchive(ctx, r, pipeline)
+	}
+
+	var rowsLimit uint64
+	if td.validate {
+		rowsLimit = td.validateRowsLimit
+	}
+	return copyOut(ctx, tx, td.table, pipeline, rowsLimit)
+}
+
+// copyFromArchive - reads the COPY data of pg_dump archive line by line and passes them to the pipeline. The data
+// is terminated by end-of-data marker
+func copyFromArchive(ctx context.Context, r io.Reader, pipeline Pipeliner) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		line, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("error reading archive data: %w", err)
+		}
+		if len(line) == 0 || bytes.Equal(bytes.TrimRight(line, "\n"), pgcopy.DefaultCopyTerminationSeq) {
+			return pipeline.CompleteDump()
+		}
+		if line[len(line)-1] != '\n' {
+			line = append(line, '\n')
+		}
+		if err = pipeline.Dump(ctx, line); err != nil {
+			return fmt.Errorf("dump error: %w", err)
+		}
+	}
+}
+
+// copyOut - runs COPY TO STDOUT query of the table and passes the received rows to the pipeline. If rowsLimit is
+// greater than zero then it exits after receiving rowsLimit rows without pipeline completion
+func copyOut(ctx context.Context, tx pgx.Tx, table *entries.Table, pipeline Pipeliner, rowsLimit uint64) error {
 	frontend := tx.Conn().PgConn().Frontend()
-	query, err := td.table.GetCopyFromStatement()
+	query, err := table.GetCopyFromStatement()
 	log.Debug().
 		Str("query", query).
-		Msgf("dumping table %s.%s using pgcopy query", td.table.Schema, td.table.Name)
+		Msgf("dumping table %s.%s using pgcopy query", table.Schema, table.Name)
 	if err != nil {
 		return fmt.Errorf("cannot get COPY FROM statement: %w", err)
 	}
@@ -141,6 +229,7 @@ func (td *TableDumper) process(ctx context.Context, tx pgx.Tx, w io.WriteCloser,
 		return fmt.Errorf("error flushing pg frontend: %w", err)
 	}
 
+	var recordNum uint64
 	for {
 		select {
 		case <-ctx.Done():
@@ -161,10 +250,10 @@ func (td *TableDumper) process(ctx context.Context, tx pgx.Tx, w io.WriteCloser,
 				return fmt.Errorf("dump error: %w", err)
 			}
 
-			if td.validate {
+			if rowsLimit > 0 {
 				// Logic for validation limiter - exit after recordNum rows
-				td.recordNum++
-				if td.recordNum == td.validateRowsLimit {
+				recordNum++
+				if recordNum == rowsLimit {
 					return nil
 				}
 			}
@@ -181,6 +270,11 @@ func (td *TableDumper) process(ctx context.Context, tx pgx.Tx, w io.WriteCloser,
 	}
 }
 
+// Table - returns the dumped table
+func (td *TableDumper) Table() *entries.Table {
+	return td.table
+}
+
 func (td *TableDumper) DebugInfo() string {
 	return fmt.Sprintf("table %s.%s", td.table.Schema, td.table.Name)
 }
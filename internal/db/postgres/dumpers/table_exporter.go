@@ -0,0 +1,219 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dumpers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/export"
+	"github.com/greenmaskio/greenmask/internal/storages"
+	"github.com/greenmaskio/greenmask/internal/utils/ioutils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+type ExportOptions struct {
+	Format   string
+	Compress bool
+	UsePgzip bool
+	// MaxRowsPerFile - the number of rows after which the next file is started. Zero means no rollover
+	MaxRowsPerFile uint64
+}
+
+// ExportedFile - the file written by TableExporter
+type ExportedFile struct {
+	Name string
+	Rows uint64
+	// OriginalSize - size of the data before compression
+	OriginalSize int64
+	// CompressedSize - size of the stored object
+	CompressedSize int64
+}
+
+// TableExporter - exports the table data into one or more files in the export format
+type TableExporter struct {
+	table *entries.Table
+	opts  *ExportOptions
+	files []*ExportedFile
+}
+
+func NewTableExporter(table *entries.Table, opts *ExportOptions) *TableExporter {
+	return &TableExporter{
+		table: table,
+		opts:  opts,
+	}
+}
+
+func (te *TableExporter) Execute(ctx context.Context, tx pgx.Tx, st storages.Storager) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	eg, gtx := errgroup.WithContext(ctx)
+
+	fw := newExportFilesWriter(gtx, st, te.table, te.opts)
+	eg.Go(func() error {
+		pipeline, err := NewExportPipeline(gtx, eg, te.table, fw)
+		if err != nil {
+			return fmt.Errorf("cannot initialize export pipeline: %w", err)
+		}
+		if err = fw.open(); err != nil {
+			return err
+		}
+		if err = pipeline.Init(gtx); err != nil {
+			fw.abort()
+			return fmt.Errorf("error initializing export pipeline: %w", err)
+		}
+		if err = copyOut(gtx, tx, te.table, pipeline, 0); err != nil {
+			fw.abort()
+			if doneErr := pipeline.Done(gtx); doneErr != nil {
+				log.Warn().Err(doneErr).Msg("error terminating export pipeline")
+			}
+			return fmt.Errorf("error processing table export %s.%s: %w", te.table.Schema, te.table.Name, err)
+		}
+		return pipeline.Done(gtx)
+	})
+
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	te.files = fw.files
+	return nil
+}
+
+// Files - returns the list of written files. It is available after successful Execute call
+func (te *TableExporter) Files() []*ExportedFile {
+	return te.files
+}
+
+func (te *TableExporter) Table() *entries.Table {
+	return te.table
+}
+
+func (te *TableExporter) DebugInfo() string {
+	return fmt.Sprintf("table %s.%s", te.table.Schema, te.table.Name)
+}
+
+// exportFilesWriter - export.RowWriter that writes the rows into the storage objects and starts the next object
+// when the MaxRowsPerFile is reached
+type exportFilesWriter struct {
+	ctx   context.Context
+	st    storages.Storager
+	table *entries.Table
+	opts  *ExportOptions
+
+	rw      export.RowWriter
+	w       ioutils.CountWriteCloser
+	r       ioutils.CountReadCloser
+	current *ExportedFile
+	putDone chan error
+	files   []*ExportedFile
+}
+
+func newExportFilesWriter(
+	ctx context.Context, st storages.Storager, table *entries.Table, opts *ExportOptions,
+) *exportFilesWriter {
+	return &exportFilesWriter{
+		ctx:   ctx,
+		st:    st,
+		table: table,
+		opts:  opts,
+	}
+}
+
+func (fw *exportFilesWriter) Write(values []*toolkit.RawValue) error {
+	if fw.opts.MaxRowsPerFile > 0 && fw.current.Rows == fw.opts.MaxRowsPerFile {
+		if err := fw.Close(); err != nil {
+			return err
+		}
+		if err := fw.open(); err != nil {
+			return err
+		}
+	}
+	if err := fw.rw.Write(values); err != nil {
+		return err
+	}
+	fw.current.Rows++
+	return nil
+}
+
+// Close - completes the current file and waits until it is stored
+func (fw *exportFilesWriter) Close() error {
+	if err := fw.rw.Close(); err != nil {
+		fw.abort()
+		return fmt.Errorf("error closing row writer: %w", err)
+	}
+	if err := fw.w.Close(); err != nil {
+		return fmt.Errorf("error closing file writer: %w", err)
+	}
+	if err := <-fw.putDone; err != nil {
+		return err
+	}
+	fw.current.OriginalSize = fw.w.GetCount()
+	fw.current.CompressedSize = fw.r.GetCount()
+	fw.files = append(fw.files, fw.current)
+	fw.rw = nil
+	return nil
+}
+
+// open - starts the next file
+func (fw *exportFilesWriter) open() error {
+	fw.current = &ExportedFile{
+		Name: fmt.Sprintf(
+			"%s.%s.%05d%s", fw.table.Schema, fw.table.Name, len(fw.files),
+			export.FileExtension(fw.opts.Format, fw.opts.Compress),
+		),
+	}
+	if fw.opts.Compress && fw.opts.Format != export.ParquetFormat {
+		fw.w, fw.r = ioutils.NewGzipPipe(fw.opts.UsePgzip)
+	} else {
+		fw.w, fw.r = ioutils.NewPipe()
+	}
+
+	putDone := make(chan error, 1)
+	fw.putDone = putDone
+	go func(name string, r ioutils.CountReadCloser) {
+		defer func() {
+			if err := r.Close(); err != nil {
+				log.Warn().Err(err).Msg("error closing export file reader")
+			}
+		}()
+		if err := fw.st.PutObject(fw.ctx, name, r); err != nil {
+			putDone <- fmt.Errorf("cannot write object %s: %w", name, err)
+			return
+		}
+		putDone <- nil
+	}(fw.current.Name, fw.r)
+
+	rw, err := export.NewRowWriter(fw.opts.Format, fw.table.Columns, fw.w)
+	if err != nil {
+		fw.abort()
+		return fmt.Errorf("cannot create row writer: %w", err)
+	}
+	fw.rw = rw
+	return nil
+}
+
+// abort - interrupts the current file writing
+func (fw *exportFilesWriter) abort() {
+	if fw.r != nil {
+		if err := fw.r.Close(); err != nil {
+			log.Warn().Err(err).Msg("error closing export file reader")
+		}
+	}
+}
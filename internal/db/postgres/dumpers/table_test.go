@@ -0,0 +1,111 @@
+package dumpers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/utils/testutils"
+)
+
+func TestCopyFromArchive(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{
+			name:     "with terminator",
+			data:     "1\tJohn\n\n2\tJane\n\\.\n\n\n",
+			expected: "1\tJohn\n\n2\tJane\n\\.\n\n",
+		},
+		{
+			name:     "without terminator",
+			data:     "1\tJohn\n2\tJane",
+			expected: "1\tJohn\n2\tJane\n\\.\n\n",
+		},
+		{
+			name:     "empty",
+			data:     "\\.\n",
+			expected: "\\.\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			pipeline := NewPlainDumpPipeline(getTable(""), buf)
+			err := copyFromArchive(context.Background(), strings.NewReader(tt.data), pipeline)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestQuarantineWriter(t *testing.T) {
+	ctx := context.Background()
+	newStorage := func(uploaded *bytes.Buffer, readErr *error) *testutils.StorageMock {
+		st := new(testutils.StorageMock)
+		st.On("PutObject", ctx, "quarantine/1.jsonl", mock.Anything).
+			Run(func(args mock.Arguments) {
+				_, *readErr = io.Copy(uploaded, args.Get(2).(io.Reader))
+			}).
+			Return(nil)
+		return st
+	}
+
+	t.Run("no records", func(t *testing.T) {
+		st := new(testutils.StorageMock)
+		qw := newQuarantineWriter(ctx, st, "quarantine/1.jsonl")
+		written, err := qw.close(nil)
+		require.NoError(t, err)
+		assert.False(t, written)
+		st.AssertNotCalled(t, "PutObject", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("records are streamed", func(t *testing.T) {
+		uploaded := new(bytes.Buffer)
+		var readErr error
+		st := newStorage(uploaded, &readErr)
+		qw := newQuarantineWriter(ctx, st, "quarantine/1.jsonl")
+		for _, rec := range []string{"{\"line\":1}\n", "{\"line\":2}\n"} {
+			_, err := qw.Write([]byte(rec))
+			require.NoError(t, err)
+		}
+		written, err := qw.close(nil)
+		require.NoError(t, err)
+		assert.True(t, written)
+		require.NoError(t, readErr)
+		assert.Equal(t, "{\"line\":1}\n{\"line\":2}\n", uploaded.String())
+	})
+
+	t.Run("upload is aborted on dump error", func(t *testing.T) {
+		uploaded := new(bytes.Buffer)
+		var readErr error
+		st := newStorage(uploaded, &readErr)
+		qw := newQuarantineWriter(ctx, st, "quarantine/1.jsonl")
+		_, err := qw.Write([]byte("{\"line\":1}\n"))
+		require.NoError(t, err)
+		dumpErr := errors.New("dump error")
+		written, err := qw.close(dumpErr)
+		require.NoError(t, err)
+		assert.False(t, written)
+		assert.ErrorIs(t, readErr, dumpErr)
+	})
+
+	t.Run("upload error", func(t *testing.T) {
+		st := new(testutils.StorageMock)
+		st.On("PutObject", ctx, "quarantine/1.jsonl", mock.Anything).Return(errors.New("access denied"))
+		qw := newQuarantineWriter(ctx, st, "quarantine/1.jsonl")
+		// The write fails or succeeds depending on whether the upload has already returned
+		_, _ = qw.Write([]byte("{\"line\":1}\n"))
+		_, err := qw.close(nil)
+		require.ErrorContains(t, err, "access denied")
+	})
+}
@@ -16,6 +16,7 @@ package dumpers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"slices"
@@ -32,6 +33,8 @@ import (
 
 var endOfLineSeq = []byte("\n")
 
+const defaultLineBufSize = 1024
+
 type transformationFunc func(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error)
 
 type TransformationPipeline struct {
@@ -43,12 +46,21 @@ type TransformationPipeline struct {
 	Transform             transformationFunc
 	isAsync               bool
 	record                *toolkit.Record
+	stats                 *transformationStats
+	// rowErrors - the transformer errors of the current row handled by the on_error policies
+	rowErrors []*rowError
+	// redactedColumns - the columns affected by the transformers that are redacted in the quarantine
+	redactedColumns []int
+	// quarantine - the writer of the rows with the handled transformer errors. It is optional
+	quarantine io.Writer
 }
 
 func NewTransformationPipeline(ctx context.Context, eg *errgroup.Group, table *entries.Table, w io.Writer) (*TransformationPipeline, error) {
 
 	var tws []*transformationWindow
 	var isAsync bool
+	stats := newTransformationStats(table)
+	table.Stats = stats.stats
 
 	// TODO: Fix this hint. Async execution cannot be performed with template record because it is unsafe.
 	//       For overcoming it - implement sequence transformer wrapper - that wraps internal (non CMD) transformers
@@ -60,10 +72,12 @@ func NewTransformationPipeline(ctx context.Context, eg *errgroup.Group, table *e
 	if !hasTemplateRecordTransformer && table.HasCustomTransformer() && len(table.TransformersContext) > 1 {
 		isAsync = true
 		tw := newTransformationWindow(ctx, eg)
+		tw.stats = stats
 		tws = append(tws, tw)
 		for _, t := range table.TransformersContext {
 			if !tw.tryAdd(table, t) {
 				tw = newTransformationWindow(ctx, eg)
+				tw.stats = stats
 				tws = append(tws, tw)
 				tw.tryAdd(table, t)
 			}
@@ -85,6 +99,8 @@ func NewTransformationPipeline(ctx context.Context, eg *errgroup.Group, table *e
 		transformationWindows: tws,
 		isAsync:               true,
 		record:                record,
+		stats:                 stats,
+		redactedColumns:       redactedColumnIdxs(table),
 	}
 
 	var tf transformationFunc = tp.TransformSync
@@ -96,6 +112,11 @@ func NewTransformationPipeline(ctx context.Context, eg *errgroup.Group, table *e
 	return tp, nil
 }
 
+// SetQuarantine - sets the writer of the rows with the transformer errors handled by the on_error policies
+func (tp *TransformationPipeline) SetQuarantine(w io.Writer) {
+	tp.quarantine = w
+}
+
 func (tp *TransformationPipeline) Init(ctx context.Context) error {
 	var lastInitErr error
 	var idx int
@@ -136,9 +157,12 @@ func (tp *TransformationPipeline) TransformSync(ctx context.Context, r *toolkit.
 		if !needTransform {
 			continue
 		}
+		tp.stats.markApplied(t)
 		_, err = t.Transformer.Transform(ctx, r)
 		if err != nil {
-			return nil, NewDumpError(tp.table.Schema, tp.table.Name, tp.line, err)
+			if err = tp.handleTransformError(t, err); err != nil {
+				return nil, err
+			}
 		}
 	}
 	return r, nil
@@ -151,47 +175,123 @@ func (tp *TransformationPipeline) TransformAsync(ctx context.Context, r *toolkit
 		if err != nil {
 			return nil, NewDumpError(tp.table.Schema, tp.table.Name, tp.line, err)
 		}
+		for _, ac := range w.window {
+			if ac.err == nil {
+				continue
+			}
+			err, ac.err = ac.err, nil
+			if err = tp.handleTransformError(ac.tc, err); err != nil {
+				return nil, err
+			}
+		}
 	}
 	return r, nil
 }
 
 func (tp *TransformationPipeline) Dump(ctx context.Context, data []byte) (err error) {
+	res, err := tp.transformLine(ctx, data)
+	if errors.Is(err, ErrRowSkipped) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tp.w.Write(res)
+	if err != nil {
+		return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error writing dumped data: %w", err))
+	}
+	_, err = tp.w.Write(endOfLineSeq)
+	if err != nil {
+		return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error writing dumped data: %w", err))
+	}
+	return nil
+}
+
+// transformLine - decodes the COPY line, applies the transformers and returns the encoded COPY line without the
+// end of line symbol. The result is valid until the next call. It returns ErrRowSkipped if the row must not be dumped
+func (tp *TransformationPipeline) transformLine(ctx context.Context, data []byte) ([]byte, error) {
 	tp.line++
-	if err = tp.row.Decode(data[:len(data)-1]); err != nil {
-		return fmt.Errorf("error decoding copy line: %w", err)
+	if err := tp.row.Decode(data[:len(data)-1]); err != nil {
+		return nil, fmt.Errorf("error decoding copy line: %w", err)
 	}
 	tp.record.SetRow(tp.row)
+	tp.stats.startRow()
 
 	needTransform, err := tp.table.When.Evaluate(tp.record)
 	if err != nil {
-		return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error evaluating when condition: %w", err))
+		return nil, NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error evaluating when condition: %w", err))
 	}
 
 	if needTransform {
 		_, err = tp.Transform(ctx, tp.record)
+		if errors.Is(err, ErrRowSkipped) {
+			// The encoding resets the values set by the transformers before the error
+			if _, err = tp.row.Encode(); err != nil {
+				return nil, NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error resetting skipped row: %w", err))
+			}
+			if err = tp.completeRowErrors(true); err != nil {
+				return nil, err
+			}
+			return nil, ErrRowSkipped
+		}
 		if err != nil {
-			return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, err)
+			return nil, NewDumpError(tp.table.Schema, tp.table.Name, tp.line, err)
+		}
+		if err = tp.stats.collect(tp.row); err != nil {
+			return nil, NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error collecting transformation stats: %w", err))
+		}
+		if err = tp.completeRowErrors(false); err != nil {
+			return nil, err
 		}
 	}
 
 	rowDriver, err := tp.record.Encode()
 	if err != nil {
-		return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error enocding Record to RowDriver: %w", err))
+		return nil, NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error enocding Record to RowDriver: %w", err))
 	}
 	res, err := rowDriver.Encode()
 	if err != nil {
-		return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error encoding RowDriver to []byte: %w", err))
+		return nil, NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error encoding RowDriver to []byte: %w", err))
 	}
+	return res, nil
+}
 
-	_, err = tp.w.Write(res)
-	if err != nil {
-		return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error writing dumped data: %w", err))
+// TransformValues - applies the transformers to the row values received not in the COPY format, for instance from
+// the logical replication stream. The values must be in the table columns order. It returns ErrRowSkipped if the row
+// must not be applied
+func (tp *TransformationPipeline) TransformValues(ctx context.Context, values []*toolkit.RawValue) (
+	[]*toolkit.RawValue, error,
+) {
+	line := make([]byte, 0, defaultLineBufSize)
+	var buf []byte
+	for idx, v := range values {
+		if idx > 0 {
+			line = append(line, pgcopy.DefaultCopyDelimiter)
+		}
+		buf = pgcopy.EncodeAttr(v, buf[:0])
+		line = append(line, buf...)
 	}
-	_, err = tp.w.Write(endOfLineSeq)
+	line = append(line, endOfLineSeq...)
+
+	res, err := tp.transformLine(ctx, line)
 	if err != nil {
-		return NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error writing dumped data: %w", err))
+		return nil, err
 	}
-	return nil
+	row := pgcopy.NewRow(len(values))
+	if err = row.Decode(res); err != nil {
+		return nil, NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error decoding transformed row: %w", err))
+	}
+	transformed := make([]*toolkit.RawValue, len(values))
+	for idx := range values {
+		v, err := row.GetColumn(idx)
+		if err != nil {
+			return nil, NewDumpError(tp.table.Schema, tp.table.Name, tp.line, fmt.Errorf("error getting transformed value: %w", err))
+		}
+		// The decoded value refers to the row buffer, so it is copied
+		transformed[idx] = toolkit.NewRawValue(slices.Clone(v.Data), v.IsNull)
+	}
+	return transformed, nil
 }
 
 func (tp *TransformationPipeline) CompleteDump() (err error) {
@@ -3,6 +3,8 @@ package dumpers
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -108,3 +110,180 @@ func TestTransformationPipeline_Dump_with_table_cond(t *testing.T) {
 	require.Equal(t, tt.callsCount, 0)
 	require.Equal(t, buf.String(), "1\t2023-08-27 00:00:00.00000\n\\.\n\n")
 }
+
+func TestTransformationPipeline_TransformValues(t *testing.T) {
+	termCtx, termCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer termCancel()
+	table := getTable("")
+	ctx := context.Background()
+	eg, gtx := errgroup.WithContext(ctx)
+	driver := getDriver(table.Table)
+	table.Driver = driver
+	when, warns := toolkit.NewWhenCond("", driver, nil)
+	require.Empty(t, warns)
+	tt := &testTransformer{}
+	table.TransformersContext = []*utils.TransformerContext{{Transformer: tt, When: when}}
+
+	pipeline, err := NewTransformationPipeline(gtx, eg, table, nil)
+	require.NoError(t, err)
+	require.NoError(t, pipeline.Init(termCtx))
+	res, err := pipeline.TransformValues(ctx, []*toolkit.RawValue{
+		toolkit.NewRawValue([]byte("1"), false),
+		toolkit.NewRawValue(nil, true),
+	})
+	require.NoError(t, err)
+	require.NoError(t, pipeline.Done(termCtx))
+	require.Equal(t, 1, tt.callsCount)
+	require.Equal(t, []*toolkit.RawValue{
+		toolkit.NewRawValue([]byte("2"), false),
+		toolkit.NewRawValue(nil, true),
+	}, res)
+}
+
+type failingTransformer struct{}
+
+func (ft *failingTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (ft *failingTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (ft *failingTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	raw, err := r.GetRawColumnValueByIdx(0)
+	if err != nil {
+		return nil, err
+	}
+	original := string(raw.Data)
+	// The column is changed before the error to check that the policy overrides it
+	if err = r.SetColumnValueByIdx(0, 7); err != nil {
+		return nil, err
+	}
+	if original == "123" {
+		return nil, fmt.Errorf("invalid value %s", original)
+	}
+	return r, nil
+}
+
+func (ft *failingTransformer) GetAffectedColumns() map[int]string {
+	return map[int]string{
+		0: "id",
+	}
+}
+
+func TestTransformationPipeline_Dump_on_error(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      *utils.OnErrorPolicy
+		expected    string
+		rowsSkipped uint64
+		isErr       bool
+	}{
+		{
+			name:   "fail",
+			policy: nil,
+			isErr:  true,
+		},
+		{
+			name:        "skip",
+			policy:      &utils.OnErrorPolicy{Policy: utils.OnErrorSkip},
+			expected:    "7\t2023-08-27 00:00:00\n\\.\n\n",
+			rowsSkipped: 1,
+		},
+		{
+			name:     "set null",
+			policy:   &utils.OnErrorPolicy{Policy: utils.OnErrorSetNull},
+			expected: "\\N\t2023-08-27 00:00:00\n7\t2023-08-27 00:00:00\n\\.\n\n",
+		},
+		{
+			name: "set default",
+			policy: &utils.OnErrorPolicy{
+				Policy:   utils.OnErrorSetDefault,
+				Defaults: map[int]*toolkit.RawValue{0: toolkit.NewRawValue([]byte("5"), false)},
+			},
+			expected: "5\t2023-08-27 00:00:00\n7\t2023-08-27 00:00:00\n\\.\n\n",
+		},
+		{
+			name:     "keep original",
+			policy:   &utils.OnErrorPolicy{Policy: utils.OnErrorKeepOriginal},
+			expected: "123\t2023-08-27 00:00:00\n7\t2023-08-27 00:00:00\n\\.\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			termCtx, termCancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer termCancel()
+			table := getTable("")
+			ctx := context.Background()
+			eg, gtx := errgroup.WithContext(ctx)
+			when, warns := toolkit.NewWhenCond("", table.Driver, nil)
+			require.Empty(t, warns)
+			table.TransformersContext = []*utils.TransformerContext{
+				{Name: "Failing", Transformer: &failingTransformer{}, When: when, OnError: tt.policy},
+			}
+
+			buf := bytes.NewBuffer(nil)
+			quarantine := bytes.NewBuffer(nil)
+			pipeline, err := NewTransformationPipeline(gtx, eg, table, buf)
+			require.NoError(t, err)
+			pipeline.SetQuarantine(quarantine)
+			require.NoError(t, pipeline.Init(termCtx))
+			err = pipeline.Dump(ctx, []byte("123\t2023-08-27 00:00:00\n"))
+			if tt.isErr {
+				require.Error(t, err)
+				require.Empty(t, quarantine.String())
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, pipeline.Dump(ctx, []byte("1\t2023-08-27 00:00:00\n")))
+			require.NoError(t, pipeline.Done(termCtx))
+			require.NoError(t, pipeline.CompleteDump())
+			require.Equal(t, tt.expected, buf.String())
+
+			require.Equal(t, uint64(1), table.Stats.RowsFailed)
+			require.Equal(t, tt.rowsSkipped, table.Stats.RowsSkipped)
+			require.Equal(t, map[string]uint64{"Failing": 1}, table.Stats.Errors)
+
+			qr := &quarantineRecord{}
+			require.NoError(t, json.Unmarshal(quarantine.Bytes(), qr))
+			createdAt := "2023-08-27 00:00:00"
+			redacted := redactedValue
+			require.Equal(t, &quarantineRecord{
+				Line:    1,
+				Skipped: tt.rowsSkipped > 0,
+				Errors: []*rowError{
+					{Transformer: "Failing", Policy: tt.policy.Policy, Error: "invalid value [redacted]"},
+				},
+				Row: map[string]*string{"id": &redacted, "created_at": &createdAt},
+			}, qr)
+		})
+	}
+}
+
+func TestTransformationPipeline_TransformValues_skip(t *testing.T) {
+	termCtx, termCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer termCancel()
+	table := getTable("")
+	ctx := context.Background()
+	eg, gtx := errgroup.WithContext(ctx)
+	when, warns := toolkit.NewWhenCond("", table.Driver, nil)
+	require.Empty(t, warns)
+	table.TransformersContext = []*utils.TransformerContext{{
+		Name:        "Failing",
+		Transformer: &failingTransformer{},
+		When:        when,
+		OnError:     &utils.OnErrorPolicy{Policy: utils.OnErrorSkip},
+	}}
+
+	pipeline, err := NewTransformationPipeline(gtx, eg, table, nil)
+	require.NoError(t, err)
+	require.NoError(t, pipeline.Init(termCtx))
+	_, err = pipeline.TransformValues(ctx, []*toolkit.RawValue{
+		toolkit.NewRawValue([]byte("123"), false),
+		toolkit.NewRawValue(nil, true),
+	})
+	require.ErrorIs(t, err, ErrRowSkipped)
+	require.NoError(t, pipeline.Done(termCtx))
+}
@@ -0,0 +1,170 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dumpers
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgcopy"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+)
+
+type columnStatsCollector struct {
+	idx   int
+	stats *entries.ColumnTransformationStats
+	// transformers - indexes of the transformers in the table TransformersContext that affect the column
+	transformers []int
+	buf          []byte
+}
+
+// transformationStats - collects the transformation statistics of the table. It is not thread safe and must
+// be used only in the goroutine that calls TransformationPipeline.Dump
+type transformationStats struct {
+	stats   *entries.TransformationStats
+	columns []*columnStatsCollector
+	// tcIdx - mapping of the transformer context to its index in the table TransformersContext
+	tcIdx map[*utils.TransformerContext]int
+	// applied - transformers applied to the current row
+	applied []bool
+}
+
+func newTransformationStats(table *entries.Table) *transformationStats {
+	stats := &entries.TransformationStats{}
+	tcIdx := make(map[*utils.TransformerContext]int, len(table.TransformersContext))
+	columnsMap := make(map[int]*columnStatsCollector)
+	var columns []*columnStatsCollector
+
+	for tIdx, tc := range table.TransformersContext {
+		tcIdx[tc] = tIdx
+		affectedColumns := tc.Transformer.GetAffectedColumns()
+		if len(affectedColumns) == 0 {
+			// The transformer does not declare affected columns, so it may change any of them
+			affectedColumns = make(map[int]string, len(table.Columns))
+			for idx, c := range table.Columns {
+				affectedColumns[idx] = c.Name
+			}
+		}
+		for idx := range table.Columns {
+			// Iterate over the table columns to keep the columns order stable
+			name, ok := affectedColumns[idx]
+			if !ok {
+				continue
+			}
+			csc, ok := columnsMap[idx]
+			if !ok {
+				csc = &columnStatsCollector{
+					idx:   idx,
+					stats: &entries.ColumnTransformationStats{Name: name},
+				}
+				columnsMap[idx] = csc
+				columns = append(columns, csc)
+			}
+			csc.transformers = append(csc.transformers, tIdx)
+		}
+	}
+
+	for _, csc := range columns {
+		stats.Columns = append(stats.Columns, csc.stats)
+	}
+
+	return &transformationStats{
+		stats:   stats,
+		columns: columns,
+		tcIdx:   tcIdx,
+		applied: make([]bool, len(table.TransformersContext)),
+	}
+}
+
+// startRow - resets the state of the previous row
+func (ts *transformationStats) startRow() {
+	ts.stats.RowsSeen++
+	clear(ts.applied)
+}
+
+// markApplied - marks the transformer as applied to the current row
+func (ts *transformationStats) markApplied(tc *utils.TransformerContext) {
+	if ts == nil {
+		return
+	}
+	if idx, ok := ts.tcIdx[tc]; ok {
+		ts.applied[idx] = true
+	}
+}
+
+// markError - counts the transformer error handled by the on_error policy
+func (ts *transformationStats) markError(name string) {
+	if ts.stats.Errors == nil {
+		ts.stats.Errors = make(map[string]uint64)
+	}
+	ts.stats.Errors[name]++
+}
+
+// markFailedRow - counts the row with the transformer errors handled by the on_error policies
+func (ts *transformationStats) markFailedRow(skipped bool) {
+	ts.stats.RowsFailed++
+	if skipped {
+		ts.stats.RowsSkipped++
+	}
+}
+
+// collect - compares the original column values with the transformed ones. It must be called after the
+// transformation and before the row encoding since the encoding resets the transformed values
+func (ts *transformationStats) collect(row *pgcopy.Row) error {
+	var rowTransformed bool
+	for _, c := range ts.columns {
+		if !ts.isColumnTransformed(c) {
+			continue
+		}
+		rowTransformed = true
+		c.stats.RowsTransformed++
+
+		raw, err := row.GetColumnRaw(c.idx)
+		if err != nil {
+			return fmt.Errorf("error getting original value of column \"%s\": %w", c.stats.Name, err)
+		}
+		original := pgcopy.DecodeAttr(raw, c.buf[:0])
+		if !original.IsNull {
+			// Keep the grown buffer for the next rows
+			c.buf = original.Data
+		}
+		current, err := row.GetColumn(c.idx)
+		if err != nil {
+			return fmt.Errorf("error getting transformed value of column \"%s\": %w", c.stats.Name, err)
+		}
+
+		switch {
+		case original.IsNull && current.IsNull:
+			c.stats.NullsKept++
+		case original.IsNull != current.IsNull:
+		case bytes.Equal(original.Data, current.Data):
+			c.stats.Unchanged++
+		}
+	}
+	if rowTransformed {
+		ts.stats.RowsTransformed++
+	}
+	return nil
+}
+
+func (ts *transformationStats) isColumnTransformed(c *columnStatsCollector) bool {
+	for _, tIdx := range c.transformers {
+		if ts.applied[tIdx] {
+			return true
+		}
+	}
+	return false
+}
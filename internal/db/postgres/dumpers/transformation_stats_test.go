@@ -0,0 +1,91 @@
+package dumpers
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestTransformationPipeline_Dump_stats(t *testing.T) {
+	termCtx, termCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer termCancel()
+	table := getTable("")
+	ctx := context.Background()
+	eg, gtx := errgroup.WithContext(ctx)
+	when, warns := toolkit.NewWhenCond("record.id != 3", table.Driver, make(map[string]any))
+	require.Empty(t, warns)
+	table.TransformersContext = []*utils.TransformerContext{
+		{
+			Transformer: &testStatsTransformer{},
+			When:        when,
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	pipeline, err := NewTransformationPipeline(gtx, eg, table, buf)
+	require.NoError(t, err)
+	require.NoError(t, pipeline.Init(termCtx))
+	for _, data := range []string{
+		"1\t2023-08-27 00:00:00.000000",
+		"2\t2023-08-27 00:00:00.000000",
+		"\\N\t2023-08-27 00:00:00.000000",
+		"3\t2023-08-28 00:00:00.000000",
+	} {
+		require.NoError(t, pipeline.Dump(ctx, []byte(data)))
+	}
+	require.NoError(t, pipeline.Done(termCtx))
+	require.NoError(t, pipeline.CompleteDump())
+
+	expected := &entries.TransformationStats{
+		RowsSeen:        4,
+		RowsTransformed: 3,
+		Columns: []*entries.ColumnTransformationStats{
+			{
+				Name:            "id",
+				RowsTransformed: 3,
+				NullsKept:       1,
+				Unchanged:       1,
+			},
+		},
+	}
+	require.Equal(t, expected, table.Stats)
+}
+
+// testStatsTransformer - sets id to 2 for not NULL values
+type testStatsTransformer struct{}
+
+func (tt *testStatsTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (tt *testStatsTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (tt *testStatsTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	v, err := r.GetRawColumnValueByName("id")
+	if err != nil {
+		return nil, err
+	}
+	if v.IsNull {
+		return r, nil
+	}
+	if err = r.SetColumnValueByName("id", 2); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (tt *testStatsTransformer) GetAffectedColumns() map[int]string {
+	return map[int]string{
+		0: "id",
+	}
+}
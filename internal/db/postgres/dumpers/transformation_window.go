@@ -29,6 +29,8 @@ import (
 type asyncContext struct {
 	tc *utils.TransformerContext
 	ch chan struct{}
+	// err - the transformer error of the current record that is handled by the on_error policy
+	err error
 }
 
 type transformationWindow struct {
@@ -39,6 +41,8 @@ type transformationWindow struct {
 	eg              *errgroup.Group
 	r               *toolkit.Record
 	ctx             context.Context
+	// stats - transformation stats of the pipeline. It is optional
+	stats *transformationStats
 }
 
 func newTransformationWindow(ctx context.Context, eg *errgroup.Group) *transformationWindow {
@@ -95,10 +99,11 @@ func (tw *transformationWindow) init() {
 					case <-ac.ch:
 					}
 					_, err := ac.tc.Transformer.Transform(tw.ctx, tw.r)
-					if err != nil {
+					if err != nil && ac.tc.OnError.IsFail() {
 						tw.wg.Done()
 						return err
 					}
+					ac.err = err
 					tw.wg.Done()
 				}
 			})
@@ -123,6 +128,7 @@ func (tw *transformationWindow) Transform(ctx context.Context, r *toolkit.Record
 		if !needTransform {
 			continue
 		}
+		tw.stats.markApplied(ac.tc)
 
 		tw.wg.Add(1)
 
@@ -20,6 +20,7 @@ import (
 	"strings"
 
 	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
 )
 
 type LargeObject struct {
@@ -32,6 +33,8 @@ type LargeObject struct {
 	DefaultACL    *ACL
 	Comment       string
 	Owner         string
+	// Transformer - transformer of the large object content. The content is dumped as is if it is nil
+	Transformer *transformers.BinaryContent
 }
 
 func (lo *LargeObject) SetDumpId(sequence *toc.DumpIdSequence) {
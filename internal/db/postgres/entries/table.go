@@ -49,6 +49,22 @@ type Table struct {
 	Scores      int64
 	SubsetConds []string
 	When        *toolkit.WhenCond
+	// Stats - transformation statistics collected during the dump. It is nil if the table was dumped
+	// without transformation
+	Stats *TransformationStats
+	// SubsetParentMinimal - restrict the table to the rows referenced by the subsetted tables
+	SubsetParentMinimal bool
+	// QuarantineObject - the storage object with the rows that failed during the transformation. It is empty if
+	// there are no such rows
+	QuarantineObject string
+}
+
+// EnrichWarningsWithTableName - adds the table schema and name to the warnings meta
+func (t *Table) EnrichWarningsWithTableName(warns toolkit.ValidationWarnings) {
+	for _, w := range warns {
+		w.AddMeta("SchemaName", t.Schema).
+			AddMeta("TableName", t.Name)
+	}
 }
 
 // HasCustomTransformer - check if table has custom transformer
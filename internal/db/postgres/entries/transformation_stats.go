@@ -0,0 +1,43 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entries
+
+// TransformationStats - statistics collected by the transformation pipeline during the table dump
+type TransformationStats struct {
+	// RowsSeen - total number of rows received from the database
+	RowsSeen uint64
+	// RowsTransformed - number of rows where at least one transformer was applied after the table and
+	// transformer when conditions evaluation
+	RowsTransformed uint64
+	// Columns - statistics of the columns affected by the transformers
+	Columns []*ColumnTransformationStats
+	// RowsFailed - number of rows where at least one transformer error was handled by the on_error policy
+	RowsFailed uint64
+	// RowsSkipped - number of failed rows that were not dumped due to the skip policy
+	RowsSkipped uint64
+	// Errors - number of the handled errors by the transformer name
+	Errors map[string]uint64
+}
+
+// ColumnTransformationStats - statistics of the single column affected by the transformers
+type ColumnTransformationStats struct {
+	Name string
+	// RowsTransformed - number of rows where at least one transformer affecting the column was applied
+	RowsTransformed uint64
+	// NullsKept - number of transformed rows where the value was NULL before and after the transformation
+	NullsKept uint64
+	// Unchanged - number of transformed rows where the not NULL value was left unchanged by the transformers
+	Unchanged uint64
+}
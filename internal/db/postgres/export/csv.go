@@ -0,0 +1,96 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// CsvWriter - writes rows in CSV format with the header. As in the PostgreSQL CSV format, NULL values are written as
+// unquoted empty fields and empty strings as quoted empty fields, so they can be distinguished
+type CsvWriter struct {
+	w *bufio.Writer
+}
+
+func NewCsvWriter(columns []*toolkit.Column, w io.Writer) (*CsvWriter, error) {
+	cw := &CsvWriter{
+		w: bufio.NewWriter(w),
+	}
+	for idx, c := range columns {
+		cw.writeField(idx, []byte(c.Name), false)
+	}
+	if err := cw.w.WriteByte('\n'); err != nil {
+		return nil, fmt.Errorf("error writing csv header: %w", err)
+	}
+	return cw, nil
+}
+
+func (cw *CsvWriter) Write(values []*toolkit.RawValue) error {
+	for idx, v := range values {
+		cw.writeField(idx, v.Data, v.IsNull)
+	}
+	// The write errors of bufio.Writer are sticky, so it is enough to check the last write
+	if err := cw.w.WriteByte('\n'); err != nil {
+		return fmt.Errorf("error writing csv record: %w", err)
+	}
+	return nil
+}
+
+func (cw *CsvWriter) Close() error {
+	return cw.w.Flush()
+}
+
+// writeField - writes the field with the preceding comma. The field is quoted if it is empty or contains the
+// special characters
+func (cw *CsvWriter) writeField(idx int, data []byte, isNull bool) {
+	if idx > 0 {
+		_ = cw.w.WriteByte(',')
+	}
+	if isNull {
+		return
+	}
+	if !csvFieldNeedsQuotes(data) {
+		_, _ = cw.w.Write(data)
+		return
+	}
+	_ = cw.w.WriteByte('"')
+	for _, b := range data {
+		if b == '"' {
+			_ = cw.w.WriteByte('"')
+		}
+		_ = cw.w.WriteByte(b)
+	}
+	_ = cw.w.WriteByte('"')
+}
+
+// csvFieldNeedsQuotes - reports whether the field must be quoted. The rules are the same as in encoding/csv except
+// the empty field that is quoted to distinguish it from NULL
+func csvFieldNeedsQuotes(data []byte) bool {
+	if len(data) == 0 || string(data) == `\.` {
+		return true
+	}
+	if bytes.ContainsAny(data, ",\"\r\n") {
+		return true
+	}
+	r, _ := utf8.DecodeRune(data)
+	return unicode.IsSpace(r)
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const (
+	CsvFormat     = "csv"
+	JsonlFormat   = "jsonl"
+	ParquetFormat = "parquet"
+)
+
+// RowWriter - writes table rows in the export format. The values are received in the PostgreSQL text
+// representation in the same order as the table columns
+type RowWriter interface {
+	Write(values []*toolkit.RawValue) error
+	// Close - flushes the buffered data. It does not close the underlying writer
+	Close() error
+}
+
+// NewRowWriter - creates RowWriter for the provided format
+func NewRowWriter(format string, columns []*toolkit.Column, w io.Writer) (RowWriter, error) {
+	switch format {
+	case CsvFormat:
+		return NewCsvWriter(columns, w)
+	case JsonlFormat:
+		return NewJsonlWriter(columns, w), nil
+	case ParquetFormat:
+		return NewParquetWriter(columns, w)
+	default:
+		return nil, fmt.Errorf("unknown export format \"%s\"", format)
+	}
+}
+
+// ValidateFormat - checks that the format is supported
+func ValidateFormat(format string) error {
+	switch format {
+	case CsvFormat, JsonlFormat, ParquetFormat:
+		return nil
+	default:
+		return fmt.Errorf("unknown export format \"%s\"", format)
+	}
+}
+
+// FileExtension - returns file extension for the format
+func FileExtension(format string, compress bool) string {
+	if compress && format != ParquetFormat {
+		// Parquet uses internal column chunks compression
+		return fmt.Sprintf(".%s.gz", format)
+	}
+	return fmt.Sprintf(".%s", format)
+}
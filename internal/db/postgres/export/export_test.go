@@ -0,0 +1,155 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func getTestColumns() []*toolkit.Column {
+	return []*toolkit.Column{
+		{Name: "id", TypeName: "int4", TypeOid: pgtype.Int4OID},
+		{Name: "name", TypeName: "text", TypeOid: pgtype.TextOID},
+		{Name: "active", TypeName: "bool", TypeOid: pgtype.BoolOID},
+		{Name: "score", TypeName: "float8", TypeOid: pgtype.Float8OID},
+		{Name: "created_at", TypeName: "timestamptz", TypeOid: pgtype.TimestamptzOID},
+		{Name: "data", TypeName: "jsonb", TypeOid: pgtype.JSONBOID},
+	}
+}
+
+func getTestRows() [][]*toolkit.RawValue {
+	return [][]*toolkit.RawValue{
+		{
+			toolkit.NewRawValue([]byte("1"), false),
+			toolkit.NewRawValue([]byte(`John "Doe", Jr`), false),
+			toolkit.NewRawValue([]byte("t"), false),
+			toolkit.NewRawValue([]byte("NaN"), false),
+			toolkit.NewRawValue([]byte("2023-08-27 12:30:00.5+03"), false),
+			toolkit.NewRawValue([]byte(`{"a": 1}`), false),
+		},
+		{
+			toolkit.NewRawValue([]byte("2"), false),
+			toolkit.NewRawValue(nil, true),
+			toolkit.NewRawValue([]byte("f"), false),
+			toolkit.NewRawValue([]byte("1.5"), false),
+			toolkit.NewRawValue(nil, true),
+			toolkit.NewRawValue(nil, true),
+		},
+		{
+			toolkit.NewRawValue([]byte("3"), false),
+			toolkit.NewRawValue([]byte(""), false),
+			toolkit.NewRawValue([]byte("t"), false),
+			toolkit.NewRawValue([]byte("0"), false),
+			toolkit.NewRawValue([]byte("2023-08-27 12:30:00+00"), false),
+			toolkit.NewRawValue([]byte(" {}"), false),
+		},
+	}
+}
+
+func writeTestRows(t *testing.T, format string) []byte {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewRowWriter(format, getTestColumns(), buf)
+	require.NoError(t, err)
+	for _, row := range getTestRows() {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestCsvWriter_Write(t *testing.T) {
+	expected := "id,name,active,score,created_at,data\n" +
+		"1,\"John \"\"Doe\"\", Jr\",t,NaN,2023-08-27 12:30:00.5+03,\"{\"\"a\"\": 1}\"\n" +
+		"2,,f,1.5,,\n" +
+		"3,\"\",t,0,2023-08-27 12:30:00+00,\" {}\"\n"
+	data := writeTestRows(t, CsvFormat)
+	assert.Equal(t, expected, string(data))
+
+	// The output is readable by encoding/csv
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, `John "Doe", Jr`, records[1][1])
+	assert.Equal(t, " {}", records[3][5])
+}
+
+func TestJsonlWriter_Write(t *testing.T) {
+	expected := `{"id":1,"name":"John \"Doe\", Jr","active":true,"score":"NaN",` +
+		`"created_at":"2023-08-27 12:30:00.5+03","data":{"a": 1}}` + "\n" +
+		`{"id":2,"name":null,"active":false,"score":1.5,"created_at":null,"data":null}` + "\n" +
+		`{"id":3,"name":"","active":true,"score":0,"created_at":"2023-08-27 12:30:00+00","data": {}}` + "\n"
+	assert.Equal(t, expected, string(writeTestRows(t, JsonlFormat)))
+}
+
+func TestParquetWriter_Write(t *testing.T) {
+	data := writeTestRows(t, ParquetFormat)
+
+	f, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), f.NumRows())
+
+	schema := f.Schema()
+	getColumnIndex := func(name string) int {
+		leaf, ok := schema.Lookup(name)
+		require.True(t, ok)
+		return leaf.ColumnIndex
+	}
+
+	rows := make([]parquet.Row, 3)
+	reader := parquet.NewReader(f)
+	n, err := reader.ReadRows(rows)
+	require.Equal(t, 3, n)
+	if err != nil {
+		require.ErrorContains(t, err, "EOF")
+	}
+
+	first := rows[0]
+	assert.Equal(t, int32(1), first[getColumnIndex("id")].Int32())
+	assert.Equal(t, `John "Doe", Jr`, first[getColumnIndex("name")].String())
+	assert.True(t, first[getColumnIndex("active")].Boolean())
+	expectedTime := time.Date(2023, 8, 27, 9, 30, 0, 500000000, time.UTC)
+	assert.Equal(t, expectedTime.UnixMicro(), first[getColumnIndex("created_at")].Int64())
+	assert.Equal(t, `{"a": 1}`, first[getColumnIndex("data")].String())
+
+	second := rows[1]
+	assert.True(t, second[getColumnIndex("name")].IsNull())
+	assert.True(t, second[getColumnIndex("created_at")].IsNull())
+	assert.Equal(t, 1.5, second[getColumnIndex("score")].Double())
+}
+
+func TestNewRowWriter_unknown_format(t *testing.T) {
+	_, err := NewRowWriter("xml", getTestColumns(), bytes.NewBuffer(nil))
+	require.Error(t, err)
+}
+
+func Test_convertParquetDate(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected int32
+	}{
+		{name: "epoch", data: "1970-01-01", expected: 0},
+		{name: "after epoch", data: "2023-08-27", expected: 19596},
+		{name: "before epoch", data: "1969-12-31", expected: -1},
+		{name: "min date", data: "0001-01-01", expected: -719162},
+		{name: "max date", data: "9999-12-31", expected: 2932896},
+		{name: "infinity", data: "infinity", expected: math.MaxInt32},
+		{name: "negative infinity", data: "-infinity", expected: math.MinInt32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := convertParquetDate([]byte(tt.data))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, res.Int32())
+		})
+	}
+}
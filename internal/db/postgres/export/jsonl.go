@@ -0,0 +1,118 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+type jsonValueKind int
+
+const (
+	jsonStringKind jsonValueKind = iota
+	jsonBoolKind
+	jsonNumberKind
+	jsonRawKind
+)
+
+// JsonlWriter - writes rows in JSON Lines format. Each row is an object where keys are the column names.
+// Boolean and numeric values are written as JSON booleans and numbers, json and jsonb as is and the rest of the
+// types as strings
+type JsonlWriter struct {
+	w     *bufio.Writer
+	keys  [][]byte
+	kinds []jsonValueKind
+	buf   []byte
+}
+
+func NewJsonlWriter(columns []*toolkit.Column, w io.Writer) *JsonlWriter {
+	keys := make([][]byte, len(columns))
+	kinds := make([]jsonValueKind, len(columns))
+	for idx, c := range columns {
+		// Marshalling a string cannot fail
+		keys[idx], _ = json.Marshal(c.Name)
+		kinds[idx] = getJsonValueKind(c.TypeOid)
+	}
+	return &JsonlWriter{
+		w:     bufio.NewWriter(w),
+		keys:  keys,
+		kinds: kinds,
+	}
+}
+
+func (jw *JsonlWriter) Write(values []*toolkit.RawValue) error {
+	res := append(jw.buf[:0], '{')
+	for idx, v := range values {
+		if idx > 0 {
+			res = append(res, ',')
+		}
+		res = append(res, jw.keys[idx]...)
+		res = append(res, ':')
+		res = appendJsonValue(res, jw.kinds[idx], v)
+	}
+	res = append(res, '}', '\n')
+	jw.buf = res
+	if _, err := jw.w.Write(res); err != nil {
+		return fmt.Errorf("error writing jsonl record: %w", err)
+	}
+	return nil
+}
+
+func (jw *JsonlWriter) Close() error {
+	return jw.w.Flush()
+}
+
+func getJsonValueKind(typeOid toolkit.Oid) jsonValueKind {
+	switch uint32(typeOid) {
+	case pgtype.BoolOID:
+		return jsonBoolKind
+	case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID, pgtype.Float4OID, pgtype.Float8OID, pgtype.NumericOID:
+		return jsonNumberKind
+	case pgtype.JSONOID, pgtype.JSONBOID:
+		return jsonRawKind
+	default:
+		return jsonStringKind
+	}
+}
+
+func appendJsonValue(res []byte, kind jsonValueKind, v *toolkit.RawValue) []byte {
+	if v.IsNull {
+		return append(res, "null"...)
+	}
+	switch kind {
+	case jsonBoolKind:
+		if string(v.Data) == "t" {
+			return append(res, "true"...)
+		}
+		return append(res, "false"...)
+	case jsonNumberKind:
+		// NaN and Infinity are not valid JSON numbers, so they are written as strings
+		if json.Valid(v.Data) {
+			return append(res, v.Data...)
+		}
+	case jsonRawKind:
+		return append(res, v.Data...)
+	}
+	// Marshalling a string cannot fail
+	data, _ := json.Marshal(string(v.Data))
+	return append(res, data...)
+}
@@ -0,0 +1,282 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const (
+	// parquetRowsBufferSize - the number of rows buffered before writing them into parquet writer
+	parquetRowsBufferSize = 1024
+	parquetSchemaName     = "greenmask"
+	secondsPerDay         = 24 * 60 * 60
+)
+
+var (
+	timestampLayouts = []string{
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02 15:04:05.999999999Z07",
+		"2006-01-02 15:04:05.999999999Z07:00",
+		"2006-01-02 15:04:05.999999999Z07:00:00",
+	}
+)
+
+// parquetValueConverter - converts value from the PostgreSQL text representation to the parquet value
+type parquetValueConverter func(data []byte) (parquet.Value, error)
+
+type parquetColumn struct {
+	name        string
+	columnIndex int
+	convert     parquetValueConverter
+}
+
+// ParquetWriter - writes rows in parquet format. The schema is derived from the column type OIDs. All the
+// columns are optional. The types that do not have the parquet equivalent are written as strings
+type ParquetWriter struct {
+	w       *parquet.Writer
+	columns []*parquetColumn
+	rows    []parquet.Row
+}
+
+func NewParquetWriter(columns []*toolkit.Column, w io.Writer) (*ParquetWriter, error) {
+	group := make(parquet.Group, len(columns))
+	converters := make([]parquetValueConverter, len(columns))
+	for idx, c := range columns {
+		if _, ok := group[c.Name]; ok {
+			return nil, fmt.Errorf("duplicate column name \"%s\"", c.Name)
+		}
+		node, convert := getParquetNodeAndConverter(c.TypeOid)
+		group[c.Name] = parquet.Optional(node)
+		converters[idx] = convert
+	}
+	schema := parquet.NewSchema(parquetSchemaName, group)
+
+	pColumns := make([]*parquetColumn, len(columns))
+	for idx, c := range columns {
+		leaf, ok := schema.Lookup(c.Name)
+		if !ok {
+			return nil, fmt.Errorf("column \"%s\" is not found in parquet schema", c.Name)
+		}
+		pColumns[idx] = &parquetColumn{
+			name:        c.Name,
+			columnIndex: leaf.ColumnIndex,
+			convert:     converters[idx],
+		}
+	}
+
+	config, err := parquet.NewWriterConfig(schema, parquet.Compression(&parquet.Snappy))
+	if err != nil {
+		return nil, fmt.Errorf("invalid parquet writer config: %w", err)
+	}
+
+	return &ParquetWriter{
+		w:       parquet.NewWriter(w, config),
+		columns: pColumns,
+		rows:    make([]parquet.Row, 0, parquetRowsBufferSize),
+	}, nil
+}
+
+// GetParquetSchema - returns the parquet schema string of the columns
+func GetParquetSchema(columns []*toolkit.Column) string {
+	group := make(parquet.Group, len(columns))
+	for _, c := range columns {
+		node, _ := getParquetNodeAndConverter(c.TypeOid)
+		group[c.Name] = parquet.Optional(node)
+	}
+	return parquet.NewSchema(parquetSchemaName, group).String()
+}
+
+func (pw *ParquetWriter) Write(values []*toolkit.RawValue) error {
+	row := make(parquet.Row, len(pw.columns))
+	for idx, c := range pw.columns {
+		v := values[idx]
+		if v.IsNull {
+			row[c.columnIndex] = parquet.NullValue().Level(0, 0, c.columnIndex)
+			continue
+		}
+		pv, err := c.convert(v.Data)
+		if err != nil {
+			return fmt.Errorf("error converting value of column \"%s\" to parquet: %w", c.name, err)
+		}
+		row[c.columnIndex] = pv.Level(0, 1, c.columnIndex)
+	}
+	pw.rows = append(pw.rows, row)
+	if len(pw.rows) == cap(pw.rows) {
+		return pw.flushRows()
+	}
+	return nil
+}
+
+func (pw *ParquetWriter) Close() error {
+	if err := pw.flushRows(); err != nil {
+		return err
+	}
+	if err := pw.w.Close(); err != nil {
+		return fmt.Errorf("error closing parquet writer: %w", err)
+	}
+	return nil
+}
+
+func (pw *ParquetWriter) flushRows() error {
+	if len(pw.rows) == 0 {
+		return nil
+	}
+	if _, err := pw.w.WriteRows(pw.rows); err != nil {
+		return fmt.Errorf("error writing parquet rows: %w", err)
+	}
+	pw.rows = pw.rows[:0]
+	return nil
+}
+
+func getParquetNodeAndConverter(typeOid toolkit.Oid) (parquet.Node, parquetValueConverter) {
+	switch uint32(typeOid) {
+	case pgtype.BoolOID:
+		return parquet.Leaf(parquet.BooleanType), convertParquetBool
+	case pgtype.Int2OID:
+		return parquet.Int(16), convertParquetInt32
+	case pgtype.Int4OID:
+		return parquet.Int(32), convertParquetInt32
+	case pgtype.Int8OID:
+		return parquet.Int(64), convertParquetInt64
+	case pgtype.Float4OID:
+		return parquet.Leaf(parquet.FloatType), convertParquetFloat
+	case pgtype.Float8OID:
+		return parquet.Leaf(parquet.DoubleType), convertParquetDouble
+	case pgtype.DateOID:
+		return parquet.Date(), convertParquetDate
+	case pgtype.TimestampOID, pgtype.TimestamptzOID:
+		return parquet.Timestamp(parquet.Microsecond), convertParquetTimestamp
+	case pgtype.ByteaOID:
+		return parquet.Leaf(parquet.ByteArrayType), convertParquetBytea
+	case pgtype.JSONOID, pgtype.JSONBOID:
+		return parquet.JSON(), convertParquetString
+	default:
+		return parquet.String(), convertParquetString
+	}
+}
+
+func convertParquetBool(data []byte) (parquet.Value, error) {
+	switch string(data) {
+	case "t":
+		return parquet.BooleanValue(true), nil
+	case "f":
+		return parquet.BooleanValue(false), nil
+	}
+	return parquet.Value{}, fmt.Errorf("invalid boolean value \"%s\"", string(data))
+}
+
+func convertParquetInt32(data []byte) (parquet.Value, error) {
+	v, err := strconv.ParseInt(string(data), 10, 32)
+	if err != nil {
+		return parquet.Value{}, err
+	}
+	return parquet.Int32Value(int32(v)), nil
+}
+
+func convertParquetInt64(data []byte) (parquet.Value, error) {
+	v, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return parquet.Value{}, err
+	}
+	return parquet.Int64Value(v), nil
+}
+
+func convertParquetFloat(data []byte) (parquet.Value, error) {
+	v, err := strconv.ParseFloat(string(data), 32)
+	if err != nil {
+		return parquet.Value{}, err
+	}
+	return parquet.FloatValue(float32(v)), nil
+}
+
+func convertParquetDouble(data []byte) (parquet.Value, error) {
+	v, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return parquet.Value{}, err
+	}
+	return parquet.DoubleValue(v), nil
+}
+
+func convertParquetDate(data []byte) (parquet.Value, error) {
+	switch string(data) {
+	case "infinity":
+		return parquet.Int32Value(math.MaxInt32), nil
+	case "-infinity":
+		return parquet.Int32Value(math.MinInt32), nil
+	}
+	t, err := time.Parse(time.DateOnly, string(data))
+	if err != nil {
+		return parquet.Value{}, err
+	}
+	// The days are computed from the Unix seconds because time.Duration overflows for the dates that are more than
+	// 292 years away from the epoch
+	return parquet.Int32Value(int32(floorDiv(t.Unix(), secondsPerDay))), nil
+}
+
+// floorDiv - returns the quotient rounded toward negative infinity
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// convertParquetTimestamp - converts timestamp and timestamptz values. The timestamp without time zone is
+// considered as UTC
+func convertParquetTimestamp(data []byte) (parquet.Value, error) {
+	switch string(data) {
+	case "infinity":
+		return parquet.Int64Value(math.MaxInt64), nil
+	case "-infinity":
+		return parquet.Int64Value(math.MinInt64), nil
+	}
+	var lastErr error
+	for _, layout := range timestampLayouts {
+		t, err := time.Parse(layout, string(data))
+		if err == nil {
+			return parquet.Int64Value(t.UnixMicro()), nil
+		}
+		lastErr = err
+	}
+	return parquet.Value{}, lastErr
+}
+
+func convertParquetBytea(data []byte) (parquet.Value, error) {
+	if len(data) < 2 || data[0] != '\\' || data[1] != 'x' {
+		return parquet.Value{}, fmt.Errorf("unsupported bytea output format: expected hex")
+	}
+	res := make([]byte, hex.DecodedLen(len(data)-2))
+	if _, err := hex.Decode(res, data[2:]); err != nil {
+		return parquet.Value{}, err
+	}
+	return parquet.ByteArrayValue(res), nil
+}
+
+func convertParquetString(data []byte) (parquet.Value, error) {
+	// The data buffer is reused by the row decoder, so it must be copied
+	return parquet.ByteArrayValue([]byte(string(data))), nil
+}
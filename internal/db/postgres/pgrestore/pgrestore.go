@@ -55,6 +55,8 @@ type DataSectionSettings struct {
 	DisableTriggers                  bool
 	SuperUser                        string
 	UseSessionReplicationRoleReplica bool
+	// DeadLetter - write the rows rejected by the database to the dead-letter files instead of failing the table
+	DeadLetter bool
 }
 
 type Options struct {
@@ -116,6 +118,14 @@ type Options struct {
 	Pgzip                            bool  `mapstructure:"pgzip"`
 	BatchSize                        int64 `mapstructure:"batch-size"`
 	UseSessionReplicationRoleReplica bool  `mapstructure:"use-session-replication-role-replica"`
+	// SchemaTolerant - restore only the columns that exist in both dump and target table
+	SchemaTolerant bool `mapstructure:"schema-tolerant"`
+	// DeadLetter - fall back from COPY to INSERTs when the COPY batch fails and write the rejected rows to the
+	// dead-letter files
+	DeadLetter bool `mapstructure:"dead-letter"`
+	// DeadLetterDir - the local directory of the dead-letter files. If empty, the files are written to the dump
+	// storage
+	DeadLetterDir string `mapstructure:"dead-letter-dir"`
 
 	// Connection options:
 	Host       string `mapstructure:"host"`
@@ -136,6 +146,7 @@ func (o *Options) ToDataSectionSettings() *DataSectionSettings {
 		DisableTriggers:                  o.DisableTriggers,
 		SuperUser:                        o.SuperUser,
 		UseSessionReplicationRoleReplica: o.UseSessionReplicationRoleReplica,
+		DeadLetter:                       o.DeadLetter,
 	}
 }
 
@@ -0,0 +1,622 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/dumpers"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// engineParameterName - the parameter of the transformers that generate the values by random or hash engine
+const engineParameterName = "engine"
+
+// Applier - transforms the decoded changes using the tables transformers and applies them to the target database.
+// Each source transaction is applied in a single target transaction together with the checkpoint
+type Applier struct {
+	conn       *pgx.Conn
+	checkpoint *Checkpoint
+	eg         *errgroup.Group
+	// tables - the dumped tables by the schema and name. The changes of other tables are skipped
+	tables    map[string]*entries.Table
+	pipelines map[toolkit.Oid]*dumpers.TransformationPipeline
+	relations map[uint32]*relation
+	tx        pgx.Tx
+	// skip - the current transaction is already applied before the restart
+	skip bool
+	// applied - the end position of the last applied transaction
+	applied LSN
+}
+
+// relation - the source relation mapped to the table. The table is nil if the relation is excluded
+type relation struct {
+	msg      *RelationMessage
+	table    *entries.Table
+	pipeline *dumpers.TransformationPipeline
+	// columns - the table column index for each relation column
+	columns []int
+	// transformers - the columns read and changed by the table transformers
+	transformers []*transformerColumns
+}
+
+// transformerColumns - the table column indexes the transformer depends on and changes. The nil inputs mean the
+// transformer might read any column
+type transformerColumns struct {
+	tc       *utils.TransformerContext
+	inputs   []int
+	affected []int
+}
+
+// changeQuery - the query with the parameters in the text format and their types
+type changeQuery struct {
+	sql    strings.Builder
+	params [][]byte
+	oids   []uint32
+}
+
+func NewApplier(
+	conn *pgx.Conn, checkpoint *Checkpoint, tables []*entries.Table, eg *errgroup.Group, applied LSN,
+) *Applier {
+	tablesMap := make(map[string]*entries.Table, len(tables))
+	for _, t := range tables {
+		tablesMap[tableKey(t.Schema, t.Name)] = t
+	}
+	return &Applier{
+		conn:       conn,
+		checkpoint: checkpoint,
+		eg:         eg,
+		tables:     tablesMap,
+		pipelines:  make(map[toolkit.Oid]*dumpers.TransformationPipeline),
+		relations:  make(map[uint32]*relation),
+		applied:    applied,
+	}
+}
+
+// Applied - returns the end position of the last applied transaction
+func (a *Applier) Applied() LSN {
+	return a.applied
+}
+
+// InTransaction - returns true if the source transaction is being received
+func (a *Applier) InTransaction() bool {
+	return a.tx != nil || a.skip
+}
+
+// Apply - applies the decoded pgoutput message
+func (a *Applier) Apply(ctx context.Context, msg any) (err error) {
+	switch m := msg.(type) {
+	case *BeginMessage:
+		return a.begin(ctx, m)
+	case *CommitMessage:
+		return a.commit(ctx, m)
+	case *RelationMessage:
+		return a.setRelation(ctx, m)
+	}
+	if a.skip {
+		return nil
+	}
+	if a.tx == nil {
+		return fmt.Errorf("received change %T outside of transaction", msg)
+	}
+	switch m := msg.(type) {
+	case *InsertMessage:
+		return a.insert(ctx, m)
+	case *UpdateMessage:
+		return a.update(ctx, m)
+	case *DeleteMessage:
+		return a.delete(ctx, m)
+	case *TruncateMessage:
+		return a.truncate(ctx, m)
+	}
+	return nil
+}
+
+// Close - rolls back the not committed transaction and terminates the transformers
+func (a *Applier) Close(ctx context.Context) error {
+	if a.tx != nil {
+		if err := a.tx.Rollback(ctx); err != nil {
+			log.Warn().Err(err).Msg("error rolling back transaction")
+		}
+		a.tx = nil
+	}
+	var lastErr error
+	for _, p := range a.pipelines {
+		if err := p.Done(ctx); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (a *Applier) begin(ctx context.Context, m *BeginMessage) (err error) {
+	if m.FinalLSN < a.applied {
+		// The transaction has been applied before the restart
+		log.Debug().
+			Str("Lsn", m.FinalLSN.String()).
+			Msg("skipping already applied transaction")
+		a.skip = true
+		return nil
+	}
+	a.tx, err = a.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot start transaction: %w", err)
+	}
+	return nil
+}
+
+func (a *Applier) commit(ctx context.Context, m *CommitMessage) error {
+	if a.skip {
+		a.skip = false
+		return nil
+	}
+	if a.tx == nil {
+		return fmt.Errorf("received commit outside of transaction")
+	}
+	tx := a.tx
+	a.tx = nil
+	if err := a.checkpoint.Save(ctx, tx, m.EndLSN); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("cannot commit transaction: %w", err)
+	}
+	a.applied = m.EndLSN
+	return nil
+}
+
+func (a *Applier) setRelation(ctx context.Context, m *RelationMessage) error {
+	rel := &relation{msg: m}
+	a.relations[m.RelationID] = rel
+	table, ok := a.tables[tableKey(m.Namespace, m.Name)]
+	if !ok {
+		log.Debug().
+			Str("Schema", m.Namespace).
+			Str("Table", m.Name).
+			Msg("table is not dumped: changes will be skipped")
+		return nil
+	}
+	rel.table = table
+	for _, c := range m.Columns {
+		idx := slices.IndexFunc(table.Columns, func(tc *toolkit.Column) bool {
+			return tc.Name == c.Name
+		})
+		if idx == -1 {
+			// The changes cannot be transformed without the column definition, so the column is not silently dropped
+			return fmt.Errorf(
+				"table %s.%s column \"%s\" is not found in the table definition: the column might be added after "+
+					"the replication start, restart the replication to reload the schema",
+				table.Schema, table.Name, c.Name,
+			)
+		}
+		rel.columns = append(rel.columns, idx)
+	}
+	rel.transformers = getTransformersColumns(table)
+	if err := rel.validateKeyTransformers(); err != nil {
+		return err
+	}
+
+	pipeline, ok := a.pipelines[table.Oid]
+	if !ok {
+		var err error
+		pipeline, err = dumpers.NewTransformationPipeline(ctx, a.eg, table, io.Discard)
+		if err != nil {
+			return fmt.Errorf("cannot create transformation pipeline: %w", err)
+		}
+		if err = pipeline.Init(ctx); err != nil {
+			return fmt.Errorf("cannot initialize transformation pipeline: %w", err)
+		}
+		a.pipelines[table.Oid] = pipeline
+	}
+	rel.pipeline = pipeline
+	return nil
+}
+
+func (a *Applier) getRelation(id uint32) (*relation, error) {
+	rel, ok := a.relations[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown relation %d", id)
+	}
+	return rel, nil
+}
+
+func (a *Applier) insert(ctx context.Context, m *InsertMessage) error {
+	rel, err := a.getRelation(m.RelationID)
+	if err != nil || rel.table == nil {
+		return err
+	}
+	values, present, err := rel.transform(ctx, m.New, nil)
+	if errors.Is(err, dumpers.ErrRowSkipped) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = a.exec(ctx, generateInsertQuery(rel.table, values, present))
+	return err
+}
+
+func (a *Applier) update(ctx context.Context, m *UpdateMessage) error {
+	rel, err := a.getRelation(m.RelationID)
+	if err != nil || rel.table == nil {
+		return err
+	}
+	keys, err := rel.keyColumns()
+	if err != nil {
+		return err
+	}
+	// The unchanged TOAST values are not received in the new tuple. They are taken from the old tuple if it is sent
+	// with REPLICA IDENTITY FULL
+	values, present, err := rel.transform(ctx, m.New, m.Old)
+	if errors.Is(err, dumpers.ErrRowSkipped) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	oldValues := values
+	if m.Old == nil {
+		for _, idx := range keys {
+			if !present[idx] {
+				return fmt.Errorf(
+					"table %s.%s replica identity column \"%s\" is not received: set REPLICA IDENTITY FULL on the "+
+						"source table", rel.table.Schema, rel.table.Name, rel.table.Columns[idx].Name,
+				)
+			}
+		}
+	} else {
+		// The key is searched by the transformed old values, so the key columns transformers must be deterministic.
+		// It is checked when the relation is received
+		oldValues, _, err = rel.transform(ctx, m.Old, nil)
+		if errors.Is(err, dumpers.ErrRowSkipped) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	affected, err := a.exec(ctx, generateUpdateQuery(rel.table, values, present, oldValues, keys))
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		log.Warn().
+			Str("Schema", rel.table.Schema).
+			Str("Table", rel.table.Name).
+			Msg("updated row is not found in the target database")
+	}
+	return nil
+}
+
+func (a *Applier) delete(ctx context.Context, m *DeleteMessage) error {
+	rel, err := a.getRelation(m.RelationID)
+	if err != nil || rel.table == nil {
+		return err
+	}
+	keys, err := rel.keyColumns()
+	if err != nil {
+		return err
+	}
+	oldValues, _, err := rel.transform(ctx, m.Old, nil)
+	if errors.Is(err, dumpers.ErrRowSkipped) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	affected, err := a.exec(ctx, generateDeleteQuery(rel.table, oldValues, keys))
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		log.Warn().
+			Str("Schema", rel.table.Schema).
+			Str("Table", rel.table.Name).
+			Msg("deleted row is not found in the target database")
+	}
+	return nil
+}
+
+func (a *Applier) truncate(ctx context.Context, m *TruncateMessage) error {
+	var tables []*entries.Table
+	for _, id := range m.RelationIDs {
+		rel, err := a.getRelation(id)
+		if err != nil {
+			return err
+		}
+		if rel.table != nil {
+			tables = append(tables, rel.table)
+		}
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	_, err := a.exec(ctx, generateTruncateQuery(tables, m.Cascade, m.RestartIdentity))
+	return err
+}
+
+func (a *Applier) exec(ctx context.Context, q *changeQuery) (int64, error) {
+	tag, err := a.tx.Conn().PgConn().ExecParams(ctx, q.sql.String(), q.params, q.oids, nil, nil).Close()
+	if err != nil {
+		return 0, fmt.Errorf("cannot apply change: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// transform - builds the table row from the tuple and applies the transformers. It returns the values in the table
+// columns order and the flags of the columns that are received. The unchanged TOAST values are not received, they are
+// taken from the fallback tuple if it contains them. The unchanged values are not returned as received since they must
+// not be written. It returns an error if the transformer that changes the received column depends on the value that
+// is neither received nor taken from the fallback tuple
+func (r *relation) transform(
+	ctx context.Context, tuple, fallback []*TupleColumn,
+) ([]*toolkit.RawValue, []bool, error) {
+	values, present, err := r.decode(tuple)
+	if err != nil {
+		return nil, nil, err
+	}
+	known := slices.Clone(present)
+	if fallback != nil && slices.Contains(known, false) {
+		fallbackValues, fallbackPresent, err := r.decode(fallback)
+		if err != nil {
+			return nil, nil, err
+		}
+		for idx := range values {
+			if !known[idx] && fallbackPresent[idx] {
+				values[idx] = fallbackValues[idx]
+				known[idx] = true
+			}
+		}
+	}
+	if err = r.checkUnknownColumns(present, known); err != nil {
+		return nil, nil, err
+	}
+	res, err := r.pipeline.TransformValues(ctx, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, present, nil
+}
+
+// decode - builds the table row from the tuple. It returns the values in the table columns order and the flags of the
+// columns that are received. The values of the columns that are not received are NULL
+func (r *relation) decode(tuple []*TupleColumn) ([]*toolkit.RawValue, []bool, error) {
+	if len(tuple) != len(r.columns) {
+		return nil, nil, fmt.Errorf(
+			"table %s.%s tuple columns count %d does not match relation columns count %d",
+			r.table.Schema, r.table.Name, len(tuple), len(r.columns),
+		)
+	}
+	values := make([]*toolkit.RawValue, len(r.table.Columns))
+	present := make([]bool, len(r.table.Columns))
+	for idx := range values {
+		values[idx] = toolkit.NewRawValue(nil, true)
+	}
+	for i, c := range tuple {
+		idx := r.columns[i]
+		switch c.Kind {
+		case TupleColumnText:
+			values[idx] = toolkit.NewRawValue(c.Data, false)
+			present[idx] = true
+		case TupleColumnNull:
+			present[idx] = true
+		case TupleColumnUnchanged:
+		default:
+			return nil, nil, fmt.Errorf("unsupported tuple column kind %c", c.Kind)
+		}
+	}
+	return values, present, nil
+}
+
+// checkUnknownColumns - returns an error if the transformer that changes the received column depends on the column
+// that is not known. Such transformer would receive NULL instead of the real value and write the wrong result
+func (r *relation) checkUnknownColumns(present, known []bool) error {
+	if !slices.Contains(known, false) {
+		return nil
+	}
+	for _, tc := range r.transformers {
+		if !slices.ContainsFunc(tc.affected, func(idx int) bool {
+			return present[idx]
+		}) {
+			continue
+		}
+		for idx, ok := range known {
+			if ok || (tc.inputs != nil && !slices.Contains(tc.inputs, idx)) {
+				continue
+			}
+			return fmt.Errorf(
+				"table %s.%s transformer %s depends on the unchanged TOAST value of the column \"%s\" that is not "+
+					"received: set REPLICA IDENTITY FULL on the source table",
+				r.table.Schema, r.table.Name, tc.tc.Name, r.table.Columns[idx].Name,
+			)
+		}
+	}
+	return nil
+}
+
+// validateKeyTransformers - checks that the replica identity columns are not transformed by the random engine. The
+// updated and deleted rows are searched by the transformed old key values, so the random values would match no rows
+// and the replica would silently drift
+func (r *relation) validateKeyTransformers() error {
+	for i, c := range r.msg.Columns {
+		if !c.IsKey {
+			continue
+		}
+		idx := r.columns[i]
+		for _, tc := range r.transformers {
+			if !slices.Contains(tc.affected, idx) || !isRandomEngineTransformer(tc.tc) {
+				continue
+			}
+			return fmt.Errorf(
+				"table %s.%s replica identity column \"%s\" is transformed by non-deterministic transformer %s: "+
+					"set %s parameter to \"%s\"",
+				r.table.Schema, r.table.Name, r.table.Columns[idx].Name, tc.tc.Name,
+				engineParameterName, transformers.HashEngineParameterName,
+			)
+		}
+	}
+	return nil
+}
+
+// isRandomEngineTransformer - returns true if the transformer generates the values by the random engine
+func isRandomEngineTransformer(tc *utils.TransformerContext) bool {
+	p, ok := tc.StaticParameters[engineParameterName]
+	if !ok {
+		return false
+	}
+	v, err := p.RawValue()
+	return err == nil && string(v) == transformers.RandomEngineParameterName
+}
+
+// getTransformersColumns - returns the columns the table transformers depend on and change. The transformers with
+// when condition might read any column
+func getTransformersColumns(t *entries.Table) []*transformerColumns {
+	res := make([]*transformerColumns, 0, len(t.TransformersContext))
+	for _, tc := range t.TransformersContext {
+		affected := tc.AffectedColumns(t.Columns)
+		item := &transformerColumns{
+			tc:       tc,
+			affected: affected,
+		}
+		if t.When.IsEmpty() && tc.When.IsEmpty() {
+			item.inputs = slices.Clone(affected)
+			for _, dp := range tc.DynamicParameters {
+				idx := slices.IndexFunc(t.Columns, func(c *toolkit.Column) bool {
+					return c.Name == dp.DynamicValue.Column
+				})
+				if idx != -1 && !slices.Contains(item.inputs, idx) {
+					item.inputs = append(item.inputs, idx)
+				}
+			}
+		}
+		res = append(res, item)
+	}
+	return res
+}
+
+// keyColumns - returns the table column indexes of the replica identity
+func (r *relation) keyColumns() ([]int, error) {
+	var res []int
+	for i, c := range r.msg.Columns {
+		if c.IsKey {
+			res = append(res, r.columns[i])
+		}
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf(
+			"table %s.%s has no replica identity: updates and deletes cannot be applied",
+			r.table.Schema, r.table.Name,
+		)
+	}
+	return res, nil
+}
+
+func (q *changeQuery) addParam(c *toolkit.Column, v *toolkit.RawValue) string {
+	if v.IsNull {
+		q.params = append(q.params, nil)
+	} else {
+		q.params = append(q.params, v.Data)
+	}
+	q.oids = append(q.oids, uint32(c.TypeOid))
+	return fmt.Sprintf("$%d", len(q.params))
+}
+
+func (q *changeQuery) writeWhere(t *entries.Table, values []*toolkit.RawValue, keys []int) {
+	q.sql.WriteString(" WHERE ")
+	for i, idx := range keys {
+		if i > 0 {
+			q.sql.WriteString(" AND ")
+		}
+		c := t.Columns[idx]
+		if values[idx].IsNull {
+			fmt.Fprintf(&q.sql, `"%s" IS NULL`, c.Name)
+			continue
+		}
+		fmt.Fprintf(&q.sql, `"%s" = %s`, c.Name, q.addParam(c, values[idx]))
+	}
+}
+
+func generateInsertQuery(t *entries.Table, values []*toolkit.RawValue, present []bool) *changeQuery {
+	q := &changeQuery{}
+	var columns, params []string
+	for idx, c := range t.Columns {
+		if !present[idx] {
+			continue
+		}
+		columns = append(columns, fmt.Sprintf(`"%s"`, c.Name))
+		params = append(params, q.addParam(c, values[idx]))
+	}
+	fmt.Fprintf(
+		&q.sql, `INSERT INTO "%s"."%s" (%s) VALUES (%s)`,
+		t.Schema, t.Name, strings.Join(columns, ", "), strings.Join(params, ", "),
+	)
+	return q
+}
+
+func generateUpdateQuery(
+	t *entries.Table, values []*toolkit.RawValue, present []bool, oldValues []*toolkit.RawValue, keys []int,
+) *changeQuery {
+	q := &changeQuery{}
+	var sets []string
+	for idx, c := range t.Columns {
+		if !present[idx] {
+			continue
+		}
+		sets = append(sets, fmt.Sprintf(`"%s" = %s`, c.Name, q.addParam(c, values[idx])))
+	}
+	fmt.Fprintf(&q.sql, `UPDATE "%s"."%s" SET %s`, t.Schema, t.Name, strings.Join(sets, ", "))
+	q.writeWhere(t, oldValues, keys)
+	return q
+}
+
+func generateDeleteQuery(t *entries.Table, oldValues []*toolkit.RawValue, keys []int) *changeQuery {
+	q := &changeQuery{}
+	fmt.Fprintf(&q.sql, `DELETE FROM "%s"."%s"`, t.Schema, t.Name)
+	q.writeWhere(t, oldValues, keys)
+	return q
+}
+
+func generateTruncateQuery(tables []*entries.Table, cascade, restartIdentity bool) *changeQuery {
+	q := &changeQuery{}
+	names := make([]string, 0, len(tables))
+	for _, t := range tables {
+		names = append(names, fmt.Sprintf(`"%s"."%s"`, t.Schema, t.Name))
+	}
+	fmt.Fprintf(&q.sql, `TRUNCATE %s`, strings.Join(names, ", "))
+	if restartIdentity {
+		q.sql.WriteString(" RESTART IDENTITY")
+	}
+	if cascade {
+		q.sql.WriteString(" CASCADE")
+	}
+	return q
+}
+
+func tableKey(schema, name string) string {
+	return fmt.Sprintf(`"%s"."%s"`, schema, name)
+}
@@ -0,0 +1,258 @@
+package replication
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+type applierTestTransformer struct {
+	affectedColumns map[int]string
+}
+
+func (tt *applierTestTransformer) Init(context.Context) error { return nil }
+
+func (tt *applierTestTransformer) Done(context.Context) error { return nil }
+
+func (tt *applierTestTransformer) Transform(_ context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	return r, nil
+}
+
+func (tt *applierTestTransformer) GetAffectedColumns() map[int]string {
+	return tt.affectedColumns
+}
+
+func newApplierTestTable(t *testing.T) *entries.Table {
+	table := &entries.Table{
+		Table: &toolkit.Table{
+			Schema: "public",
+			Name:   "users",
+			Columns: []*toolkit.Column{
+				{Name: "id", TypeName: "int4", TypeOid: 23, Num: 1, Length: -1},
+				{Name: "email", TypeName: "text", TypeOid: 25, Num: 2, Length: -1},
+				{Name: "bio", TypeName: "text", TypeOid: 25, Num: 3, Length: -1},
+			},
+			Constraints: []toolkit.Constraint{},
+		},
+	}
+	driver, _, err := toolkit.NewDriver(table.Table, nil)
+	require.NoError(t, err)
+	table.Driver = driver
+	return table
+}
+
+func newApplierTestTransformerContext(
+	t *testing.T, table *entries.Table, name, engine string, affected map[int]string,
+) *utils.TransformerContext {
+	tc := &utils.TransformerContext{
+		Name:             name,
+		Transformer:      &applierTestTransformer{affectedColumns: affected},
+		StaticParameters: map[string]*toolkit.StaticParameter{},
+	}
+	if engine != "" {
+		p := toolkit.NewStaticParameter(toolkit.MustNewParameterDefinition(engineParameterName, "engine"), table.Driver)
+		_, err := p.Init(nil, toolkit.ParamsValue(engine))
+		require.NoError(t, err)
+		tc.StaticParameters[engineParameterName] = p
+	}
+	return tc
+}
+
+func newApplierTestRelation(table *entries.Table) *relation {
+	return &relation{
+		msg: &RelationMessage{
+			Namespace: table.Schema,
+			Name:      table.Name,
+			Columns: []*RelationColumn{
+				{Name: "id", IsKey: true},
+				{Name: "email"},
+				{Name: "bio"},
+			},
+		},
+		table:        table,
+		columns:      []int{0, 1, 2},
+		transformers: getTransformersColumns(table),
+	}
+}
+
+func TestApplier_setRelation_unknownColumn(t *testing.T) {
+	table := newApplierTestTable(t)
+	a := NewApplier(nil, nil, []*entries.Table{table}, nil, 0)
+	err := a.Apply(context.Background(), &RelationMessage{
+		RelationID: 1,
+		Namespace:  "public",
+		Name:       "users",
+		Columns:    []*RelationColumn{{Name: "id", IsKey: true}, {Name: "phone"}},
+	})
+	require.ErrorContains(t, err, `column "phone" is not found in the table definition`)
+}
+
+func TestRelation_validateKeyTransformers(t *testing.T) {
+	tests := []struct {
+		name     string
+		engine   string
+		affected map[int]string
+		errMsg   string
+	}{
+		{
+			name:     "random engine on key column",
+			engine:   "random",
+			affected: map[int]string{0: "id"},
+			errMsg:   `replica identity column "id" is transformed by non-deterministic transformer RandomInt`,
+		},
+		{
+			name:     "hash engine on key column",
+			engine:   "hash",
+			affected: map[int]string{0: "id"},
+		},
+		{
+			name:     "random engine on non key column",
+			engine:   "random",
+			affected: map[int]string{1: "email"},
+		},
+		{
+			name:   "random engine on all columns",
+			engine: "random",
+			errMsg: `replica identity column "id" is transformed by non-deterministic transformer RandomInt`,
+		},
+		{
+			name:     "transformer without engine",
+			affected: map[int]string{0: "id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := newApplierTestTable(t)
+			table.TransformersContext = []*utils.TransformerContext{
+				newApplierTestTransformerContext(t, table, "RandomInt", tt.engine, tt.affected),
+			}
+			err := newApplierTestRelation(table).validateKeyTransformers()
+			if tt.errMsg != "" {
+				require.ErrorContains(t, err, tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRelation_decode(t *testing.T) {
+	rel := newApplierTestRelation(newApplierTestTable(t))
+	values, present, err := rel.decode([]*TupleColumn{
+		{Kind: TupleColumnText, Data: []byte("1")},
+		{Kind: TupleColumnNull},
+		{Kind: TupleColumnUnchanged},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, true, false}, present)
+	require.Equal(t, []*toolkit.RawValue{
+		toolkit.NewRawValue([]byte("1"), false),
+		toolkit.NewRawValue(nil, true),
+		toolkit.NewRawValue(nil, true),
+	}, values)
+
+	_, _, err = rel.decode([]*TupleColumn{{Kind: TupleColumnText, Data: []byte("1")}})
+	require.ErrorContains(t, err, "tuple columns count 1 does not match relation columns count 3")
+}
+
+func TestRelation_checkUnknownColumns(t *testing.T) {
+	table := newApplierTestTable(t)
+	emailTransformer := newApplierTestTransformerContext(t, table, "RandomEmail", "", map[int]string{1: "email"})
+	bioTransformer := newApplierTestTransformerContext(t, table, "ScrubText", "", map[int]string{2: "bio"})
+
+	t.Run("transformer of unchanged column", func(t *testing.T) {
+		table.TransformersContext = []*utils.TransformerContext{emailTransformer, bioTransformer}
+		rel := newApplierTestRelation(table)
+		require.NoError(t, rel.checkUnknownColumns([]bool{true, true, false}, []bool{true, true, false}))
+	})
+
+	t.Run("dynamic parameter refers to unchanged column", func(t *testing.T) {
+		emailTransformer.DynamicParameters = map[string]*toolkit.DynamicParameter{
+			"min": {DynamicValue: &toolkit.DynamicParamValue{Column: "bio"}},
+		}
+		defer func() {
+			emailTransformer.DynamicParameters = nil
+		}()
+		table.TransformersContext = []*utils.TransformerContext{emailTransformer}
+		rel := newApplierTestRelation(table)
+		err := rel.checkUnknownColumns([]bool{true, true, false}, []bool{true, true, false})
+		require.ErrorContains(t, err, `transformer RandomEmail depends on the unchanged TOAST value of the column "bio"`)
+
+		// The value is taken from the old tuple
+		require.NoError(t, rel.checkUnknownColumns([]bool{true, true, false}, []bool{true, true, true}))
+	})
+
+	t.Run("transformer of all columns", func(t *testing.T) {
+		table.TransformersContext = []*utils.TransformerContext{
+			newApplierTestTransformerContext(t, table, "TemplateRecord", "", nil),
+		}
+		rel := newApplierTestRelation(table)
+		err := rel.checkUnknownColumns([]bool{true, true, false}, []bool{true, true, false})
+		require.ErrorContains(t, err, `transformer TemplateRecord depends on the unchanged TOAST value`)
+	})
+
+	t.Run("transform fails before the transformation", func(t *testing.T) {
+		table.TransformersContext = []*utils.TransformerContext{
+			newApplierTestTransformerContext(t, table, "TemplateRecord", "", nil),
+		}
+		rel := newApplierTestRelation(table)
+		tuple := []*TupleColumn{
+			{Kind: TupleColumnText, Data: []byte("1")},
+			{Kind: TupleColumnText, Data: []byte("a@example.com")},
+			{Kind: TupleColumnUnchanged},
+		}
+		_, _, err := rel.transform(context.Background(), tuple, nil)
+		require.ErrorContains(t, err, `depends on the unchanged TOAST value of the column "bio"`)
+	})
+}
+
+func TestGenerateQueries(t *testing.T) {
+	table := &entries.Table{
+		Table: &toolkit.Table{
+			Schema: "public",
+			Name:   "users",
+			Columns: []*toolkit.Column{
+				{Name: "id", TypeOid: 23},
+				{Name: "email", TypeOid: 25},
+				{Name: "bio", TypeOid: 25},
+			},
+		},
+	}
+	values := []*toolkit.RawValue{
+		toolkit.NewRawValue([]byte("1"), false),
+		toolkit.NewRawValue([]byte("a@example.com"), false),
+		toolkit.NewRawValue(nil, true),
+	}
+
+	t.Run("insert", func(t *testing.T) {
+		q := generateInsertQuery(table, values, []bool{true, true, true})
+		require.Equal(t, `INSERT INTO "public"."users" ("id", "email", "bio") VALUES ($1, $2, $3)`, q.sql.String())
+		require.Equal(t, [][]byte{[]byte("1"), []byte("a@example.com"), nil}, q.params)
+		require.Equal(t, []uint32{23, 25, 25}, q.oids)
+	})
+
+	t.Run("update without unchanged value", func(t *testing.T) {
+		oldValues := []*toolkit.RawValue{toolkit.NewRawValue([]byte("2"), false), nil, nil}
+		q := generateUpdateQuery(table, values, []bool{true, true, false}, oldValues, []int{0})
+		require.Equal(t, `UPDATE "public"."users" SET "id" = $1, "email" = $2 WHERE "id" = $3`, q.sql.String())
+		require.Equal(t, [][]byte{[]byte("1"), []byte("a@example.com"), []byte("2")}, q.params)
+	})
+
+	t.Run("delete by full identity", func(t *testing.T) {
+		q := generateDeleteQuery(table, values, []int{0, 1, 2})
+		require.Equal(t,
+			`DELETE FROM "public"."users" WHERE "id" = $1 AND "email" = $2 AND "bio" IS NULL`, q.sql.String(),
+		)
+		require.Len(t, q.params, 2)
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		q := generateTruncateQuery([]*entries.Table{table}, true, true)
+		require.Equal(t, `TRUNCATE "public"."users" RESTART IDENTITY CASCADE`, q.sql.String())
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const DefaultCheckpointTable = "public.greenmask_replication_checkpoint"
+
+// Checkpoint - stores the position of the last applied transaction in the target database. The position is saved in
+// the same transaction as the applied changes, so the replication is restarted from the consistent position
+type Checkpoint struct {
+	table    string
+	slotName string
+}
+
+// NewCheckpoint - creates the checkpoint for the slot. The table is provided in the form schema.table
+func NewCheckpoint(table, slotName string) *Checkpoint {
+	if table == "" {
+		table = DefaultCheckpointTable
+	}
+	return &Checkpoint{
+		table:    pgx.Identifier(strings.SplitN(table, ".", 2)).Sanitize(),
+		slotName: slotName,
+	}
+}
+
+// Init - creates the checkpoint table if it does not exist
+func (c *Checkpoint) Init(ctx context.Context, conn *pgx.Conn) error {
+	_, err := conn.Exec(ctx, fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (slot_name TEXT PRIMARY KEY, lsn PG_LSN NOT NULL, updated_at TIMESTAMPTZ NOT NULL DEFAULT now())`,
+		c.table,
+	))
+	if err != nil {
+		return fmt.Errorf("cannot create checkpoint table: %w", err)
+	}
+	return nil
+}
+
+// Get - returns the saved position. It returns false if the position is not saved yet
+func (c *Checkpoint) Get(ctx context.Context, conn *pgx.Conn) (LSN, bool, error) {
+	var lsn string
+	row := conn.QueryRow(ctx, fmt.Sprintf(`SELECT lsn::TEXT FROM %s WHERE slot_name = $1`, c.table), c.slotName)
+	if err := row.Scan(&lsn); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("cannot get checkpoint: %w", err)
+	}
+	res, err := ParseLSN(lsn)
+	if err != nil {
+		return 0, false, err
+	}
+	return res, true, nil
+}
+
+// Save - saves the position within the provided transaction
+func (c *Checkpoint) Save(ctx context.Context, tx pgx.Tx, lsn LSN) error {
+	_, err := tx.Exec(ctx, fmt.Sprintf(
+		`INSERT INTO %s (slot_name, lsn) VALUES ($1, $2::TEXT::PG_LSN) `+
+			`ON CONFLICT (slot_name) DO UPDATE SET lsn = EXCLUDED.lsn, updated_at = now()`,
+		c.table,
+	), c.slotName, lsn.String())
+	if err != nil {
+		return fmt.Errorf("cannot save checkpoint: %w", err)
+	}
+	return nil
+}
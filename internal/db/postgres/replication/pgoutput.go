@@ -0,0 +1,286 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replication
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	beginMsgType    = 'B'
+	commitMsgType   = 'C'
+	relationMsgType = 'R'
+	insertMsgType   = 'I'
+	updateMsgType   = 'U'
+	deleteMsgType   = 'D'
+	truncateMsgType = 'T'
+)
+
+const (
+	TupleColumnNull      = 'n'
+	TupleColumnUnchanged = 'u'
+	TupleColumnText      = 't'
+	TupleColumnBinary    = 'b'
+)
+
+const (
+	tupleNew = 'N'
+	tupleKey = 'K'
+	tupleOld = 'O'
+)
+
+const (
+	truncateCascadeFlag         = 1
+	truncateRestartIdentityFlag = 2
+)
+
+const relationColumnKeyFlag = 1
+
+var errMessageTooShort = errors.New("message is too short")
+
+type BeginMessage struct {
+	// FinalLSN - the commit position of the transaction
+	FinalLSN   LSN
+	CommitTime time.Time
+	Xid        uint32
+}
+
+type CommitMessage struct {
+	CommitLSN LSN
+	// EndLSN - the end position of the transaction. The streaming is continued from this position
+	EndLSN     LSN
+	CommitTime time.Time
+}
+
+type RelationMessage struct {
+	RelationID      uint32
+	Namespace       string
+	Name            string
+	ReplicaIdentity byte
+	Columns         []*RelationColumn
+}
+
+type RelationColumn struct {
+	Name         string
+	IsKey        bool
+	TypeOid      uint32
+	TypeModifier int32
+}
+
+// TupleColumn - the column value. Data is set only for the text and binary kinds
+type TupleColumn struct {
+	Kind byte
+	Data []byte
+}
+
+type InsertMessage struct {
+	RelationID uint32
+	New        []*TupleColumn
+}
+
+// UpdateMessage - the updated row. Old is set only if the replica identity is changed or replica identity is full.
+// In the first case it contains only the key columns
+type UpdateMessage struct {
+	RelationID uint32
+	Old        []*TupleColumn
+	New        []*TupleColumn
+}
+
+type DeleteMessage struct {
+	RelationID uint32
+	Old        []*TupleColumn
+}
+
+type TruncateMessage struct {
+	RelationIDs     []uint32
+	Cascade         bool
+	RestartIdentity bool
+}
+
+// DecodeMessage - decodes the pgoutput message. It returns nil for the messages that are not used, such as origin
+// and type messages
+func DecodeMessage(data []byte) (any, error) {
+	if len(data) == 0 {
+		return nil, errMessageTooShort
+	}
+	d := &decoder{data: data[1:]}
+	var msg any
+	switch data[0] {
+	case beginMsgType:
+		msg = &BeginMessage{
+			FinalLSN:   LSN(d.uint64()),
+			CommitTime: d.time(),
+			Xid:        d.uint32(),
+		}
+	case commitMsgType:
+		d.uint8()
+		msg = &CommitMessage{
+			CommitLSN:  LSN(d.uint64()),
+			EndLSN:     LSN(d.uint64()),
+			CommitTime: d.time(),
+		}
+	case relationMsgType:
+		m := &RelationMessage{
+			RelationID:      d.uint32(),
+			Namespace:       d.string(),
+			Name:            d.string(),
+			ReplicaIdentity: d.uint8(),
+		}
+		count := int(d.uint16())
+		for i := 0; i < count && d.err == nil; i++ {
+			m.Columns = append(m.Columns, &RelationColumn{
+				IsKey:        d.uint8()&relationColumnKeyFlag != 0,
+				Name:         d.string(),
+				TypeOid:      d.uint32(),
+				TypeModifier: int32(d.uint32()),
+			})
+		}
+		msg = m
+	case insertMsgType:
+		m := &InsertMessage{RelationID: d.uint32()}
+		d.expect(tupleNew)
+		m.New = d.tuple()
+		msg = m
+	case updateMsgType:
+		m := &UpdateMessage{RelationID: d.uint32()}
+		if kind := d.uint8(); kind == tupleKey || kind == tupleOld {
+			m.Old = d.tuple()
+			d.expect(tupleNew)
+		} else if kind != tupleNew && d.err == nil {
+			d.err = fmt.Errorf("unexpected tuple type %c", kind)
+		}
+		m.New = d.tuple()
+		msg = m
+	case deleteMsgType:
+		m := &DeleteMessage{RelationID: d.uint32()}
+		if kind := d.uint8(); kind != tupleKey && kind != tupleOld && d.err == nil {
+			d.err = fmt.Errorf("unexpected tuple type %c", kind)
+		}
+		m.Old = d.tuple()
+		msg = m
+	case truncateMsgType:
+		count := int(d.uint32())
+		flags := d.uint8()
+		m := &TruncateMessage{
+			Cascade:         flags&truncateCascadeFlag != 0,
+			RestartIdentity: flags&truncateRestartIdentityFlag != 0,
+		}
+		for i := 0; i < count && d.err == nil; i++ {
+			m.RelationIDs = append(m.RelationIDs, d.uint32())
+		}
+		msg = m
+	default:
+		return nil, nil
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("cannot decode message %c: %w", data[0], d.err)
+	}
+	return msg, nil
+}
+
+// decoder - reads the message fields sequentially. The first error is kept and the next reads return zero values
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.data) < n {
+		d.err = errMessageTooShort
+		return nil
+	}
+	res := d.data[:n]
+	d.data = d.data[n:]
+	return res
+}
+
+func (d *decoder) uint8() byte {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint16() uint16 {
+	if b := d.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.next(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) time() time.Time {
+	return postgresEpoch.Add(time.Duration(int64(d.uint64())) * time.Microsecond)
+}
+
+func (d *decoder) string() string {
+	if d.err != nil {
+		return ""
+	}
+	idx := bytes.IndexByte(d.data, 0)
+	if idx == -1 {
+		d.err = errors.New("string is not terminated")
+		return ""
+	}
+	res := string(d.data[:idx])
+	d.data = d.data[idx+1:]
+	return res
+}
+
+func (d *decoder) expect(kind byte) {
+	if k := d.uint8(); k != kind && d.err == nil {
+		d.err = fmt.Errorf("unexpected tuple type %c: expected %c", k, kind)
+	}
+}
+
+func (d *decoder) tuple() []*TupleColumn {
+	count := int(d.uint16())
+	res := make([]*TupleColumn, 0, count)
+	for i := 0; i < count && d.err == nil; i++ {
+		c := &TupleColumn{Kind: d.uint8()}
+		switch c.Kind {
+		case TupleColumnNull, TupleColumnUnchanged:
+		case TupleColumnText, TupleColumnBinary:
+			// The data is copied because the message buffer is reused by the connection
+			c.Data = bytes.Clone(d.next(int(d.uint32())))
+		default:
+			if d.err == nil {
+				d.err = fmt.Errorf("unknown tuple column kind %c", c.Kind)
+			}
+		}
+		res = append(res, c)
+	}
+	return res
+}
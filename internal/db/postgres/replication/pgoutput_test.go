@@ -0,0 +1,114 @@
+package replication
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type messageBuilder struct {
+	data []byte
+}
+
+func (b *messageBuilder) byte(v byte) *messageBuilder {
+	b.data = append(b.data, v)
+	return b
+}
+
+func (b *messageBuilder) uint16(v uint16) *messageBuilder {
+	b.data = binary.BigEndian.AppendUint16(b.data, v)
+	return b
+}
+
+func (b *messageBuilder) uint32(v uint32) *messageBuilder {
+	b.data = binary.BigEndian.AppendUint32(b.data, v)
+	return b
+}
+
+func (b *messageBuilder) uint64(v uint64) *messageBuilder {
+	b.data = binary.BigEndian.AppendUint64(b.data, v)
+	return b
+}
+
+func (b *messageBuilder) string(v string) *messageBuilder {
+	b.data = append(append(b.data, v...), 0)
+	return b
+}
+
+func (b *messageBuilder) text(v string) *messageBuilder {
+	b.byte(TupleColumnText).uint32(uint32(len(v)))
+	b.data = append(b.data, v...)
+	return b
+}
+
+func TestParseLSN(t *testing.T) {
+	lsn, err := ParseLSN("16/B374D848")
+	require.NoError(t, err)
+	require.Equal(t, LSN(0x16B374D848), lsn)
+	require.Equal(t, "16/B374D848", lsn.String())
+
+	_, err = ParseLSN("wrong")
+	require.Error(t, err)
+}
+
+func TestDecodeMessage(t *testing.T) {
+	t.Run("relation", func(t *testing.T) {
+		data := (&messageBuilder{}).byte(relationMsgType).uint32(16384).string("public").string("users").byte('d').
+			uint16(2).
+			byte(1).string("id").uint32(23).uint32(0xFFFFFFFF).
+			byte(0).string("email").uint32(25).uint32(0xFFFFFFFF).data
+		msg, err := DecodeMessage(data)
+		require.NoError(t, err)
+		require.Equal(t, &RelationMessage{
+			RelationID:      16384,
+			Namespace:       "public",
+			Name:            "users",
+			ReplicaIdentity: 'd',
+			Columns: []*RelationColumn{
+				{Name: "id", IsKey: true, TypeOid: 23, TypeModifier: -1},
+				{Name: "email", TypeOid: 25, TypeModifier: -1},
+			},
+		}, msg)
+	})
+
+	t.Run("update with key", func(t *testing.T) {
+		data := (&messageBuilder{}).byte(updateMsgType).uint32(16384).
+			byte(tupleKey).uint16(2).text("1").byte(TupleColumnNull).
+			byte(tupleNew).uint16(2).text("2").byte(TupleColumnUnchanged).data
+		msg, err := DecodeMessage(data)
+		require.NoError(t, err)
+		require.Equal(t, &UpdateMessage{
+			RelationID: 16384,
+			Old:        []*TupleColumn{{Kind: TupleColumnText, Data: []byte("1")}, {Kind: TupleColumnNull}},
+			New:        []*TupleColumn{{Kind: TupleColumnText, Data: []byte("2")}, {Kind: TupleColumnUnchanged}},
+		}, msg)
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		data := (&messageBuilder{}).byte(commitMsgType).byte(0).uint64(10).uint64(20).uint64(0).data
+		msg, err := DecodeMessage(data)
+		require.NoError(t, err)
+		require.Equal(t, LSN(10), msg.(*CommitMessage).CommitLSN)
+		require.Equal(t, LSN(20), msg.(*CommitMessage).EndLSN)
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		data := (&messageBuilder{}).byte(truncateMsgType).uint32(2).byte(truncateCascadeFlag).
+			uint32(1).uint32(2).data
+		msg, err := DecodeMessage(data)
+		require.NoError(t, err)
+		require.Equal(t, &TruncateMessage{RelationIDs: []uint32{1, 2}, Cascade: true}, msg)
+	})
+
+	t.Run("skipped message", func(t *testing.T) {
+		msg, err := DecodeMessage([]byte{'Y'})
+		require.NoError(t, err)
+		require.Nil(t, msg)
+	})
+
+	t.Run("too short", func(t *testing.T) {
+		_, err := DecodeMessage((&messageBuilder{}).byte(insertMsgType).uint16(1).data)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,171 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replication
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+)
+
+const (
+	xLogDataMsgType            = 'w'
+	primaryKeepaliveMsgType    = 'k'
+	standbyStatusUpdateMsgType = 'r'
+)
+
+const pgOutputProtoVersion = 1
+
+// postgresEpoch - the timestamps in the replication protocol are the microseconds since 2000-01-01
+var postgresEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Slot - the created logical replication slot
+type Slot struct {
+	Name string
+	// ConsistentPoint - the position the changes are streamed from
+	ConsistentPoint LSN
+	// SnapshotName - the exported snapshot that contains the data up to the consistent point. It is valid until the
+	// next command on the replication connection
+	SnapshotName string
+}
+
+type xLogData struct {
+	walStart   LSN
+	walEnd     LSN
+	serverTime time.Time
+	data       []byte
+}
+
+type primaryKeepalive struct {
+	walEnd         LSN
+	serverTime     time.Time
+	replyRequested bool
+}
+
+// Connect - opens the replication connection in the database mode
+func Connect(ctx context.Context, dsn string) (*pgconn.PgConn, error) {
+	cfg, err := pgconn.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse connection string: %w", err)
+	}
+	cfg.RuntimeParams["replication"] = "database"
+	return pgconn.ConnectConfig(ctx, cfg)
+}
+
+// CreateSlot - creates the logical replication slot with the pgoutput plugin and exports the snapshot
+func CreateSlot(ctx context.Context, conn *pgconn.PgConn, name string) (*Slot, error) {
+	res, err := conn.Exec(
+		ctx, fmt.Sprintf(`CREATE_REPLICATION_SLOT "%s" LOGICAL pgoutput EXPORT_SNAPSHOT`, name),
+	).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != 1 || len(res[0].Rows) != 1 || len(res[0].Rows[0]) < 3 {
+		return nil, fmt.Errorf("unexpected create replication slot result")
+	}
+	row := res[0].Rows[0]
+	lsn, err := ParseLSN(string(row[1]))
+	if err != nil {
+		return nil, err
+	}
+	return &Slot{
+		Name:            string(row[0]),
+		ConsistentPoint: lsn,
+		SnapshotName:    string(row[2]),
+	}, nil
+}
+
+// DropSlot - drops the logical replication slot
+func DropSlot(ctx context.Context, conn *pgconn.PgConn, name string) error {
+	_, err := conn.Exec(ctx, fmt.Sprintf(`DROP_REPLICATION_SLOT "%s"`, name)).ReadAll()
+	return err
+}
+
+// StartReplication - starts streaming the changes of the publication from the provided position. After the call
+// the connection is in the copy both mode
+func StartReplication(ctx context.Context, conn *pgconn.PgConn, slot string, lsn LSN, publication string) error {
+	query := fmt.Sprintf(
+		`START_REPLICATION SLOT "%s" LOGICAL %s ("proto_version" '%d', "publication_names" '%s')`,
+		slot, lsn, pgOutputProtoVersion, strings.ReplaceAll(publication, "'", "''"),
+	)
+	conn.Frontend().Send(&pgproto3.Query{String: query})
+	if err := conn.Frontend().Flush(); err != nil {
+		return fmt.Errorf("cannot send start replication command: %w", err)
+	}
+	for {
+		msg, err := conn.ReceiveMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot receive message: %w", err)
+		}
+		switch m := msg.(type) {
+		case *pgproto3.CopyBothResponse:
+			return nil
+		case *pgproto3.ErrorResponse:
+			return pgconn.ErrorResponseToPgError(m)
+		case *pgproto3.NoticeResponse:
+			continue
+		default:
+			return fmt.Errorf("unexpected message %T", msg)
+		}
+	}
+}
+
+// SendStandbyStatus - reports the position that is applied to the target, so the source might release the WAL
+// before this position
+func SendStandbyStatus(ctx context.Context, conn *pgconn.PgConn, lsn LSN) error {
+	data := make([]byte, 34)
+	data[0] = standbyStatusUpdateMsgType
+	binary.BigEndian.PutUint64(data[1:], uint64(lsn))
+	binary.BigEndian.PutUint64(data[9:], uint64(lsn))
+	binary.BigEndian.PutUint64(data[17:], uint64(lsn))
+	binary.BigEndian.PutUint64(data[25:], uint64(time.Since(postgresEpoch).Microseconds()))
+	conn.Frontend().Send(&pgproto3.CopyData{Data: data})
+	if err := conn.Frontend().Flush(); err != nil {
+		return fmt.Errorf("cannot send standby status: %w", err)
+	}
+	return nil
+}
+
+func parseXLogData(data []byte) (*xLogData, error) {
+	if len(data) < 24 {
+		return nil, fmt.Errorf("xlog data message is too short: %d bytes", len(data))
+	}
+	return &xLogData{
+		walStart:   LSN(binary.BigEndian.Uint64(data)),
+		walEnd:     LSN(binary.BigEndian.Uint64(data[8:])),
+		serverTime: parseTime(data[16:]),
+		data:       data[24:],
+	}, nil
+}
+
+func parsePrimaryKeepalive(data []byte) (*primaryKeepalive, error) {
+	if len(data) < 17 {
+		return nil, fmt.Errorf("primary keepalive message is too short: %d bytes", len(data))
+	}
+	return &primaryKeepalive{
+		walEnd:         LSN(binary.BigEndian.Uint64(data)),
+		serverTime:     parseTime(data[8:]),
+		replyRequested: data[16] != 0,
+	}, nil
+}
+
+func parseTime(data []byte) time.Time {
+	return postgresEpoch.Add(time.Duration(int64(binary.BigEndian.Uint64(data))) * time.Microsecond)
+}
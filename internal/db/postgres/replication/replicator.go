@@ -0,0 +1,135 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replication
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/rs/zerolog/log"
+)
+
+const DefaultStatusInterval = 10 * time.Second
+
+// Replicator - receives the logical replication stream and passes the decoded messages to the applier. The source
+// is informed about the applied position periodically, so the slot does not retain the applied WAL
+type Replicator struct {
+	conn           *pgconn.PgConn
+	applier        *Applier
+	statusInterval time.Duration
+	// reported - the position that can be reported to the source
+	reported LSN
+}
+
+func NewReplicator(conn *pgconn.PgConn, applier *Applier, statusInterval time.Duration) *Replicator {
+	if statusInterval <= 0 {
+		statusInterval = DefaultStatusInterval
+	}
+	return &Replicator{
+		conn:           conn,
+		applier:        applier,
+		statusInterval: statusInterval,
+		reported:       applier.Applied(),
+	}
+}
+
+// Run - streams the changes until the context is cancelled or an error occurred
+func (r *Replicator) Run(ctx context.Context) error {
+	nextStatus := time.Now().Add(r.statusInterval)
+	for {
+		if time.Now().After(nextStatus) {
+			if err := r.sendStatus(ctx); err != nil {
+				return err
+			}
+			nextStatus = time.Now().Add(r.statusInterval)
+		}
+
+		receiveCtx, cancel := context.WithDeadline(ctx, nextStatus)
+		msg, err := r.conn.ReceiveMessage(receiveCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if pgconn.Timeout(err) {
+				continue
+			}
+			return fmt.Errorf("cannot receive message: %w", err)
+		}
+
+		switch m := msg.(type) {
+		case *pgproto3.CopyData:
+			replyRequested, err := r.handleCopyData(ctx, m.Data)
+			if err != nil {
+				return err
+			}
+			if replyRequested {
+				nextStatus = time.Now()
+			}
+		case *pgproto3.ErrorResponse:
+			return pgconn.ErrorResponseToPgError(m)
+		default:
+			log.Debug().Msgf("unexpected replication message %T", msg)
+		}
+	}
+}
+
+func (r *Replicator) handleCopyData(ctx context.Context, data []byte) (bool, error) {
+	if len(data) == 0 {
+		return false, fmt.Errorf("empty copy data message")
+	}
+	switch data[0] {
+	case primaryKeepaliveMsgType:
+		pk, err := parsePrimaryKeepalive(data[1:])
+		if err != nil {
+			return false, err
+		}
+		// The changes are sent at the transaction commit, so between the transactions all the WAL up to the server
+		// position is processed
+		if !r.applier.InTransaction() && pk.walEnd > r.reported {
+			r.reported = pk.walEnd
+		}
+		return pk.replyRequested, nil
+	case xLogDataMsgType:
+		xld, err := parseXLogData(data[1:])
+		if err != nil {
+			return false, err
+		}
+		msg, err := DecodeMessage(xld.data)
+		if err != nil {
+			return false, err
+		}
+		if err = r.applier.Apply(ctx, msg); err != nil {
+			return false, fmt.Errorf("cannot apply message at %s: %w", xld.walStart, err)
+		}
+		if applied := r.applier.Applied(); applied > r.reported {
+			r.reported = applied
+		}
+		return false, nil
+	default:
+		log.Debug().Msgf("unknown copy data message type %c", data[0])
+		return false, nil
+	}
+}
+
+func (r *Replicator) sendStatus(ctx context.Context) error {
+	log.Debug().
+		Str("Lsn", r.reported.String()).
+		Msg("sending standby status")
+	return SendStandbyStatus(ctx, r.conn, r.reported)
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restorers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+	"github.com/greenmaskio/greenmask/internal/storages"
+)
+
+// DeadLetterStorageDir - the directory of the dead-letter files in the dump storage
+const DeadLetterStorageDir = "dead_letter"
+
+// DeadLetterRecord - the row rejected by the database during the restoration
+type DeadLetterRecord struct {
+	// Line - the line number of the row in the table dump
+	Line       int64  `json:"line"`
+	Code       string `json:"code"`
+	Constraint string `json:"constraint,omitempty"`
+	Message    string `json:"message"`
+	// Data - the row in COPY format
+	Data string `json:"data"`
+}
+
+// DeadLetterSummary - the rejected rows of the table
+type DeadLetterSummary struct {
+	Schema string
+	Name   string
+	Rows   int64
+	// Errors - the number of the rejected rows by the error code and constraint name
+	Errors map[string]int64
+	// Path - the dead-letter file path in the local directory or in the storage
+	Path string
+}
+
+// DeadLetter - writes the rows rejected during the restoration to the per-table dead-letter files in the local
+// directory or in the dump storage if the directory is not set. It is safe for concurrent use by the restoration
+// workers
+type DeadLetter struct {
+	st      storages.Storager
+	dir     string
+	mx      sync.Mutex
+	summary []*DeadLetterSummary
+}
+
+func NewDeadLetter(st storages.Storager, dir string) *DeadLetter {
+	return &DeadLetter{
+		st:  st,
+		dir: dir,
+	}
+}
+
+// Summary - returns the tables with the rejected rows ordered by the schema and name
+func (dl *DeadLetter) Summary() []*DeadLetterSummary {
+	dl.mx.Lock()
+	defer dl.mx.Unlock()
+	res := slices.Clone(dl.summary)
+	slices.SortFunc(res, func(a, b *DeadLetterSummary) int {
+		if c := strings.Compare(a.Schema, b.Schema); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return res
+}
+
+func (dl *DeadLetter) newTable(ctx context.Context, entry *toc.Entry) *tableDeadLetter {
+	return &tableDeadLetter{
+		ctx:   ctx,
+		dl:    dl,
+		entry: entry,
+		summary: &DeadLetterSummary{
+			Schema: strings.Trim(*entry.Namespace, `"`),
+			Name:   strings.Trim(*entry.Tag, `"`),
+			Errors: make(map[string]int64),
+		},
+	}
+}
+
+// tableDeadLetter - writes the rejected rows of the single table as they are added. The dead-letter file is opened
+// on the first rejected row, so the tables without rejected rows do not produce the files
+type tableDeadLetter struct {
+	ctx     context.Context
+	dl      *DeadLetter
+	entry   *toc.Entry
+	summary *DeadLetterSummary
+	// w - the dead-letter file or the pipe to the storage object. It is nil until the first rejected row
+	w io.WriteCloser
+	// uploaded - receives the storage upload result if the records are written to the storage
+	uploaded chan error
+}
+
+// add - adds the row rejected with the error. It returns false if the error is not returned by the database, so the
+// row cannot be considered as rejected
+func (t *tableDeadLetter) add(line int64, data []byte, err error) (bool, error) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false, nil
+	}
+	rec := &DeadLetterRecord{
+		Line:       line,
+		Code:       pgErr.Code,
+		Constraint: pgErr.ConstraintName,
+		Message:    pgErr.Message,
+		Data:       string(data),
+	}
+	res, err := json.Marshal(rec)
+	if err != nil {
+		return false, fmt.Errorf("error encoding dead-letter record: %w", err)
+	}
+	if t.w == nil {
+		if err = t.open(); err != nil {
+			return false, err
+		}
+	}
+	if _, err = t.w.Write(append(res, '\n')); err != nil {
+		return false, fmt.Errorf("cannot write dead-letter record: %w", err)
+	}
+
+	t.summary.Rows++
+	key := pgErr.Code
+	if pgErr.ConstraintName != "" {
+		key = fmt.Sprintf("%s %s", pgErr.Code, pgErr.ConstraintName)
+	}
+	t.summary.Errors[key]++
+	return true, nil
+}
+
+// open - creates the dead-letter file in the local directory or starts the upload of the storage object that
+// receives the records through the pipe
+func (t *tableDeadLetter) open() error {
+	fileName := fmt.Sprintf("%d.%s.%s.jsonl", t.entry.DumpId, t.summary.Schema, t.summary.Name)
+	if t.dl.dir != "" {
+		if err := os.MkdirAll(t.dl.dir, 0750); err != nil {
+			return fmt.Errorf("cannot create dead-letter directory: %w", err)
+		}
+		t.summary.Path = filepath.Join(t.dl.dir, fileName)
+		f, err := os.OpenFile(t.summary.Path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+		if err != nil {
+			return fmt.Errorf("cannot create dead-letter file: %w", err)
+		}
+		t.w = f
+		return nil
+	}
+
+	t.summary.Path = path.Join(DeadLetterStorageDir, fileName)
+	r, w := io.Pipe()
+	t.w = w
+	t.uploaded = make(chan error, 1)
+	go func() {
+		err := t.dl.st.PutObject(t.ctx, t.summary.Path, r)
+		// Unblock the writer if the upload is finished before the pipe is closed
+		_ = r.CloseWithError(err)
+		t.uploaded <- err
+	}()
+	return nil
+}
+
+// close - completes the dead-letter file of the table and adds the table to the summary if there are rejected rows
+func (t *tableDeadLetter) close() error {
+	if t.w == nil {
+		return nil
+	}
+	if err := t.w.Close(); err != nil {
+		return fmt.Errorf("cannot close dead-letter file: %w", err)
+	}
+	if t.uploaded != nil {
+		if err := <-t.uploaded; err != nil {
+			return fmt.Errorf("cannot write dead-letter object: %w", err)
+		}
+	}
+
+	t.dl.mx.Lock()
+	t.dl.summary = append(t.dl.summary, t.summary)
+	t.dl.mx.Unlock()
+	return nil
+}
@@ -0,0 +1,240 @@
+package restorers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgrestore"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/utils"
+	"github.com/greenmaskio/greenmask/internal/utils/testutils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func newDeadLetterTestEntry(dumpId int32, schemaName, tableName string) *toc.Entry {
+	return &toc.Entry{
+		DumpId:    dumpId,
+		Namespace: &schemaName,
+		Tag:       &tableName,
+	}
+}
+
+func TestTableDeadLetter_add(t *testing.T) {
+	dl := NewDeadLetter(nil, t.TempDir())
+	tdl := dl.newTable(context.Background(), newDeadLetterTestEntry(1, `"public"`, `"orders"`))
+
+	rejected, err := tdl.add(1, []byte("1\t999"), errors.New("connection reset"))
+	require.NoError(t, err)
+	assert.False(t, rejected)
+
+	pgErr := &pgconn.PgError{Code: "23503", ConstraintName: "fk_user", Message: "fk violation"}
+	rejected, err = tdl.add(2, []byte("2\t999"), pgErr)
+	require.NoError(t, err)
+	assert.True(t, rejected)
+	rejected, err = tdl.add(3, []byte("3\t\\N"), &pgconn.PgError{Code: "23502", Message: "null violation"})
+	require.NoError(t, err)
+	assert.True(t, rejected)
+
+	assert.Equal(t, "public", tdl.summary.Schema)
+	assert.Equal(t, "orders", tdl.summary.Name)
+	assert.Equal(t, int64(2), tdl.summary.Rows)
+	assert.Equal(t, map[string]int64{"23503 fk_user": 1, "23502": 1}, tdl.summary.Errors)
+
+	// The records are written as they are added, before the dead-letter file is closed
+	data, err := os.ReadFile(tdl.summary.Path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(data, []byte{'\n'}))
+	require.NoError(t, tdl.close())
+}
+
+func TestTableDeadLetter_close(t *testing.T) {
+	t.Run("local directory", func(t *testing.T) {
+		ctx := context.Background()
+		dir := filepath.Join(t.TempDir(), "dead_letter")
+		dl := NewDeadLetter(nil, dir)
+
+		empty := dl.newTable(ctx, newDeadLetterTestEntry(2, "public", "users"))
+		require.NoError(t, empty.close())
+
+		tdl := dl.newTable(ctx, newDeadLetterTestEntry(1, "public", "orders"))
+		pgErr := &pgconn.PgError{Code: "23503", ConstraintName: "fk_user", Message: "fk violation"}
+		_, err := tdl.add(2, []byte("2\t999"), pgErr)
+		require.NoError(t, err)
+		require.NoError(t, tdl.close())
+
+		summary := dl.Summary()
+		require.Len(t, summary, 1)
+		assert.Equal(t, filepath.Join(dir, "1.public.orders.jsonl"), summary[0].Path)
+
+		data, err := os.ReadFile(summary[0].Path)
+		require.NoError(t, err)
+		var rec DeadLetterRecord
+		require.NoError(t, json.Unmarshal(bytes.TrimSpace(data), &rec))
+		assert.Equal(t, DeadLetterRecord{
+			Line:       2,
+			Code:       "23503",
+			Constraint: "fk_user",
+			Message:    "fk violation",
+			Data:       "2\t999",
+		}, rec)
+	})
+
+	t.Run("storage", func(t *testing.T) {
+		ctx := context.Background()
+		st := new(testutils.StorageMock)
+		var uploaded []byte
+		st.On("PutObject", ctx, "dead_letter/1.public.orders.jsonl", mock.Anything).
+			Run(func(args mock.Arguments) {
+				var err error
+				uploaded, err = io.ReadAll(args.Get(2).(io.Reader))
+				require.NoError(t, err)
+			}).
+			Return(nil)
+		dl := NewDeadLetter(st, "")
+
+		tdl := dl.newTable(ctx, newDeadLetterTestEntry(1, "public", "orders"))
+		_, err := tdl.add(2, []byte("2\t999"), &pgconn.PgError{Code: "23503"})
+		require.NoError(t, err)
+		_, err = tdl.add(3, []byte("3\t999"), &pgconn.PgError{Code: "23503"})
+		require.NoError(t, err)
+		require.NoError(t, tdl.close())
+
+		st.AssertExpectations(t)
+		assert.Equal(t, 2, bytes.Count(uploaded, []byte{'\n'}))
+		assert.Equal(t, "dead_letter/1.public.orders.jsonl", dl.Summary()[0].Path)
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		ctx := context.Background()
+		st := new(testutils.StorageMock)
+		st.On("PutObject", ctx, "dead_letter/1.public.orders.jsonl", mock.Anything).
+			Return(errors.New("access denied"))
+		dl := NewDeadLetter(st, "")
+
+		tdl := dl.newTable(ctx, newDeadLetterTestEntry(1, "public", "orders"))
+		// The pipe is closed with the upload error, so the record write is not blocked
+		_, err := tdl.add(2, []byte("2\t999"), &pgconn.PgError{Code: "23503"})
+		require.ErrorContains(t, err, "access denied")
+		require.ErrorContains(t, tdl.close(), "access denied")
+		assert.Empty(t, dl.Summary())
+	})
+}
+
+func TestDeadLetter_Summary(t *testing.T) {
+	ctx := context.Background()
+	dl := NewDeadLetter(nil, t.TempDir())
+	for idx, name := range [][2]string{{"public", "users"}, {"billing", "orders"}, {"public", "orders"}} {
+		tdl := dl.newTable(ctx, newDeadLetterTestEntry(int32(idx), name[0], name[1]))
+		_, err := tdl.add(1, []byte("1"), &pgconn.PgError{Code: "23505"})
+		require.NoError(t, err)
+		require.NoError(t, tdl.close())
+	}
+
+	var names []string
+	for _, s := range dl.Summary() {
+		names = append(names, s.Schema+"."+s.Name)
+	}
+	assert.Equal(t, []string{"billing.orders", "public.orders", "public.users"}, names)
+}
+
+func (s *restoresSuite) Test_TableRestorerCopyFallback() {
+	ctx := context.Background()
+	schemaName := "public"
+	tableName := "orders"
+	fileName := "test_table"
+	copyStmt := "COPY orders (id, user_id, order_amount, raise_error) FROM stdin;"
+	entry := &toc.Entry{
+		DumpId:    1,
+		Namespace: &schemaName,
+		Tag:       &tableName,
+		FileName:  &fileName,
+		CopyStmt:  &copyStmt,
+	}
+	data := strings.Join([]string{
+		"100\t1\t10.00\t\\N",
+		"101\t999\t20.00\t\\N",
+		"102\t1\t30.00\t\\N",
+		"103\t998\t40.00\t\\N",
+		"104\t2\t50.00\t\\N",
+	}, "\n") + "\n\\.\n"
+	buf := new(bytes.Buffer)
+	gzData := gzip.NewWriter(buf)
+	_, err := gzData.Write([]byte(data))
+	s.Require().NoError(err)
+	s.Require().NoError(gzData.Close())
+	objSrc := &readCloserMock{Buffer: buf}
+
+	st := new(testutils.StorageMock)
+	st.On("GetObject", ctx, mock.Anything).Return(objSrc, nil)
+	opt := &pgrestore.DataSectionSettings{
+		ExitOnError: true,
+		BatchSize:   2,
+	}
+	t := &toolkit.Table{
+		Schema: schemaName,
+		Name:   tableName,
+		Columns: []*toolkit.Column{
+			{Name: "id", TypeName: "int4"},
+			{Name: "user_id", TypeName: "int4"},
+			{Name: "order_amount", TypeName: "numeric"},
+			{Name: "raise_error", TypeName: "text"},
+		},
+	}
+	dir := s.T().TempDir()
+	dl := NewDeadLetter(st, dir)
+
+	tr := NewTableRestorerCopyFallback(entry, t, st, opt, dl)
+	conn, err := s.GetConnectionWithUser(ctx, s.nonSuperUser, s.nonSuperUserPassword)
+	s.Require().NoError(err)
+	defer conn.Close(ctx) // nolint: errcheck
+	s.Require().NoError(tr.Execute(ctx, utils.NewPGConn(conn)))
+
+	var ids []int
+	rows, err := conn.Query(ctx, "SELECT id FROM orders WHERE id >= 100 ORDER BY id")
+	s.Require().NoError(err)
+	for rows.Next() {
+		var id int
+		s.Require().NoError(rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	s.Require().NoError(rows.Err())
+	s.Equal([]int{100, 102, 104}, ids)
+
+	summary := dl.Summary()
+	s.Require().Len(summary, 1)
+	s.Equal(int64(2), summary[0].Rows)
+	s.Equal(map[string]int64{"23503 fk_user": 2}, summary[0].Errors)
+	res, err := os.ReadFile(summary[0].Path)
+	s.Require().NoError(err)
+	// The rejected rows are in the first and second batches, so the line numbers are counted across the batches
+	var records []DeadLetterRecord
+	for _, line := range bytes.Split(bytes.TrimSpace(res), []byte{'\n'}) {
+		var rec DeadLetterRecord
+		s.Require().NoError(json.Unmarshal(line, &rec))
+		records = append(records, rec)
+	}
+	s.Require().Len(records, 2)
+	s.Equal(int64(2), records[0].Line)
+	s.Equal("101\t999\t20.00\t\\N", records[0].Data)
+	s.Equal(int64(4), records[1].Line)
+	s.Equal("103\t998\t40.00\t\\N", records[1].Data)
+
+	suConn, err := s.GetConnection(ctx)
+	s.Require().NoError(err)
+	defer suConn.Close(ctx) // nolint: errcheck
+	_, err = suConn.Exec(ctx, "DELETE FROM orders WHERE id >= 100")
+	s.Require().NoError(err)
+}
@@ -0,0 +1,304 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restorers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgcopy"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const targetColumnsQuery = `
+	SELECT a.attname,
+	       pg_catalog.format_type(a.atttypid, a.atttypmod),
+	       a.attnotnull,
+	       a.atthasdef,
+	       a.attgenerated <> '',
+	       a.attidentity <> '',
+	       coalesce(pg_catalog.pg_get_expr(d.adbin, d.adrelid), '')
+	FROM pg_catalog.pg_attribute a
+	         JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+	         JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+	         LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+	WHERE n.nspname = $1
+	  AND c.relname = $2
+	  AND a.attnum > 0
+	  AND NOT a.attisdropped
+	ORDER BY a.attnum
+`
+
+// TargetColumn - column of the table in the target database
+type TargetColumn struct {
+	Name        string
+	TypeName    string
+	NotNull     bool
+	HasDefault  bool
+	IsGenerated bool
+	IsIdentity  bool
+	// Default - default expression of the column. It is empty for the generated columns
+	Default string
+}
+
+// ColumnFill - column that is not present in the dump and is filled by the configured expression
+type ColumnFill struct {
+	Name       string
+	Expression string
+	// OriginalDefault - default expression of the column that is set back after the restoration
+	OriginalDefault string
+}
+
+// ColumnsMapping - mapping of the dumped table columns to the target table columns. It is used for restoration into
+// the table that has different structure
+type ColumnsMapping struct {
+	Schema string
+	Name   string
+	// Positions - positions of the dumped columns in the COPY data that are restored
+	Positions []int
+	// ColumnsCount - the number of the columns in the COPY data
+	ColumnsCount int
+	// Columns - the restored columns names in the same order as Positions
+	Columns []string
+	Fills   []*ColumnFill
+}
+
+// CopyStmt - returns COPY statement with the restored columns only
+func (cm *ColumnsMapping) CopyStmt() string {
+	columns := make([]string, 0, len(cm.Columns))
+	for _, c := range cm.Columns {
+		columns = append(columns, pgx.Identifier{c}.Sanitize())
+	}
+	return fmt.Sprintf(
+		"COPY %s (%s) FROM stdin;\n", pgx.Identifier{cm.Schema, cm.Name}.Sanitize(), strings.Join(columns, ", "),
+	)
+}
+
+// GetTargetColumns - returns the columns of the table in the target database. The result is empty if the table
+// does not exist
+func GetTargetColumns(ctx context.Context, conn *pgx.Conn, schema, name string) ([]*TargetColumn, error) {
+	rows, err := conn.Query(ctx, targetColumnsQuery, schema, name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot query target table columns: %w", err)
+	}
+	defer rows.Close()
+	var res []*TargetColumn
+	for rows.Next() {
+		c := &TargetColumn{}
+		if err = rows.Scan(
+			&c.Name, &c.TypeName, &c.NotNull, &c.HasDefault, &c.IsGenerated, &c.IsIdentity, &c.Default,
+		); err != nil {
+			return nil, fmt.Errorf("cannot scan target table column: %w", err)
+		}
+		res = append(res, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read target table columns: %w", err)
+	}
+	return res, nil
+}
+
+// BuildColumnsMapping - compares the dumped table columns with the target table columns by name and returns the
+// mapping for intersecting columns. Each column-level mismatch is returned as a warning. The target columns that
+// are not present in the dump are filled by their defaults or by the configured expressions, if such column is
+// NOT NULL and has neither default nor expression, then the error warning is returned
+func BuildColumnsMapping(
+	dumpTable *toolkit.Table, targetColumns []*TargetColumn, cfg *domains.SchemaToleranceTable,
+) (*ColumnsMapping, toolkit.ValidationWarnings) {
+	var warnings toolkit.ValidationWarnings
+	cm := &ColumnsMapping{
+		Schema: dumpTable.Schema,
+		Name:   dumpTable.Name,
+	}
+
+	// Generated columns are not dumped, so the positions are calculated by the non-generated columns only
+	var dumpColumns []*toolkit.Column
+	for _, c := range dumpTable.Columns {
+		if !c.IsGenerated {
+			dumpColumns = append(dumpColumns, c)
+		}
+	}
+	cm.ColumnsCount = len(dumpColumns)
+
+	for pos, dc := range dumpColumns {
+		idx := slices.IndexFunc(targetColumns, func(tc *TargetColumn) bool {
+			return tc.Name == dc.Name
+		})
+		if idx == -1 {
+			warnings = append(warnings, newColumnWarning(dc.Name, toolkit.WarningValidationSeverity,
+				"column is not found in the target table: column data will be skipped"))
+			continue
+		}
+		tc := targetColumns[idx]
+		if tc.IsGenerated {
+			warnings = append(warnings, newColumnWarning(dc.Name, toolkit.WarningValidationSeverity,
+				"column is generated in the target table: column data will be skipped"))
+			continue
+		}
+		if tc.TypeName != dc.TypeName {
+			warnings = append(warnings, newColumnWarning(dc.Name, toolkit.WarningValidationSeverity,
+				"column type differs from the dumped column type: values will be converted by the target type input").
+				AddMeta("DumpTypeName", dc.TypeName).
+				AddMeta("TargetTypeName", tc.TypeName),
+			)
+		}
+		if tc.NotNull && !dc.NotNull {
+			warnings = append(warnings, newColumnWarning(dc.Name, toolkit.WarningValidationSeverity,
+				"column is NOT NULL in the target table but nullable in the dump: NULL values will fail the restoration"))
+		}
+		cm.Positions = append(cm.Positions, pos)
+		cm.Columns = append(cm.Columns, dc.Name)
+	}
+
+	var expressions []*domains.SchemaToleranceColumn
+	if cfg != nil {
+		expressions = cfg.Columns
+	}
+	for _, e := range expressions {
+		if !slices.ContainsFunc(targetColumns, func(tc *TargetColumn) bool {
+			return tc.Name == e.Name
+		}) {
+			warnings = append(warnings, newColumnWarning(e.Name, toolkit.ErrorValidationSeverity,
+				"column from schema_tolerance config is not found in the target table"))
+		} else if slices.Contains(cm.Columns, e.Name) {
+			warnings = append(warnings, newColumnWarning(e.Name, toolkit.WarningValidationSeverity,
+				"column from schema_tolerance config is restored from the dump: expression will be ignored"))
+		}
+	}
+
+	for _, tc := range targetColumns {
+		if slices.ContainsFunc(dumpColumns, func(dc *toolkit.Column) bool {
+			return dc.Name == tc.Name
+		}) {
+			continue
+		}
+		exprIdx := slices.IndexFunc(expressions, func(e *domains.SchemaToleranceColumn) bool {
+			return e.Name == tc.Name
+		})
+		switch {
+		case tc.IsGenerated:
+			warnings = append(warnings, newColumnWarning(tc.Name, toolkit.InfoValidationSeverity,
+				"generated column is not found in the dump: column will be computed"))
+		case exprIdx != -1:
+			warnings = append(warnings, newColumnWarning(tc.Name, toolkit.InfoValidationSeverity,
+				"column is not found in the dump: column will be filled by the configured expression").
+				AddMeta("Expression", expressions[exprIdx].Expression),
+			)
+			cm.Fills = append(cm.Fills, &ColumnFill{
+				Name:            tc.Name,
+				Expression:      expressions[exprIdx].Expression,
+				OriginalDefault: tc.Default,
+			})
+		case tc.HasDefault || tc.IsIdentity:
+			warnings = append(warnings, newColumnWarning(tc.Name, toolkit.InfoValidationSeverity,
+				"column is not found in the dump: column will be filled by its default value"))
+		case !tc.NotNull:
+			warnings = append(warnings, newColumnWarning(tc.Name, toolkit.InfoValidationSeverity,
+				"column is not found in the dump: column will be filled by NULL"))
+		default:
+			warnings = append(warnings, newColumnWarning(tc.Name, toolkit.ErrorValidationSeverity,
+				"NOT NULL column without default is not found in the dump: set the column expression in "+
+					"restore.schema_tolerance config"))
+		}
+	}
+
+	for _, w := range warnings {
+		w.AddMeta("SchemaName", dumpTable.Schema).
+			AddMeta("TableName", dumpTable.Name)
+	}
+	return cm, warnings
+}
+
+func newColumnWarning(name string, severity, msg string) *toolkit.ValidationWarning {
+	return toolkit.NewValidationWarning().
+		SetMsg(msg).
+		SetSeverity(severity).
+		AddMeta("ColumnName", name)
+}
+
+// columnsProjectionReader - reads COPY data in text format and keeps only the columns by the positions. The
+// end-of-data marker and the lines after it are passed as is
+type columnsProjectionReader struct {
+	r            *bufio.Reader
+	positions    []int
+	columnsCount int
+	buf          []byte
+	done         bool
+}
+
+func newColumnsProjectionReader(r io.Reader, positions []int, columnsCount int) *columnsProjectionReader {
+	return &columnsProjectionReader{
+		r:            bufio.NewReaderSize(r, defaultBufferSize),
+		positions:    positions,
+		columnsCount: columnsCount,
+	}
+}
+
+func (cpr *columnsProjectionReader) Read(p []byte) (int, error) {
+	for len(cpr.buf) == 0 {
+		if cpr.done {
+			return 0, io.EOF
+		}
+		if err := cpr.readLine(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, cpr.buf)
+	cpr.buf = cpr.buf[n:]
+	return n, nil
+}
+
+func (cpr *columnsProjectionReader) readLine() error {
+	line, err := cpr.r.ReadBytes('\n')
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			return err
+		}
+		cpr.done = true
+		if len(line) == 0 {
+			return nil
+		}
+	}
+	data := bytes.TrimSuffix(line, []byte{'\n'})
+	if len(data) == 0 || bytes.Equal(data, pgcopy.DefaultCopyTerminationSeq) {
+		cpr.buf = line
+		return nil
+	}
+	// The tab characters in the values are always escaped in COPY text format, so the line might be split by tab
+	values := bytes.Split(data, []byte{'\t'})
+	if len(values) != cpr.columnsCount {
+		return fmt.Errorf(
+			"unexpected number of columns in COPY data: expected %d got %d", cpr.columnsCount, len(values),
+		)
+	}
+	res := make([]byte, 0, len(line))
+	for i, pos := range cpr.positions {
+		if i > 0 {
+			res = append(res, '\t')
+		}
+		res = append(res, values[pos]...)
+	}
+	cpr.buf = append(res, '\n')
+	return nil
+}
@@ -0,0 +1,107 @@
+package restorers
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestBuildColumnsMapping(t *testing.T) {
+	dumpTable := &toolkit.Table{
+		Schema: "public",
+		Name:   "users",
+		Columns: []*toolkit.Column{
+			{Name: "id", TypeName: "integer", NotNull: true},
+			{Name: "full_name", TypeName: "text", IsGenerated: true},
+			{Name: "legacy", TypeName: "text"},
+			{Name: "email", TypeName: "character varying(100)"},
+			{Name: "age", TypeName: "integer"},
+		},
+	}
+	targetColumns := []*TargetColumn{
+		{Name: "id", TypeName: "integer", NotNull: true},
+		{Name: "email", TypeName: "text", NotNull: true},
+		{Name: "age", TypeName: "integer"},
+		{Name: "tenant_id", TypeName: "integer", NotNull: true},
+		{Name: "created_at", TypeName: "timestamp", NotNull: true, HasDefault: true, Default: "now()"},
+		{Name: "nickname", TypeName: "text"},
+		{Name: "status", TypeName: "text", NotNull: true, HasDefault: true, Default: "'new'::text"},
+	}
+
+	t.Run("with expressions", func(t *testing.T) {
+		cfg := &domains.SchemaToleranceTable{
+			Schema: "public",
+			Name:   "users",
+			Columns: []*domains.SchemaToleranceColumn{
+				{Name: "tenant_id", Expression: "1"},
+				{Name: "status", Expression: "'imported'"},
+			},
+		}
+		cm, warnings := BuildColumnsMapping(dumpTable, targetColumns, cfg)
+		assert.False(t, warnings.IsFatal())
+		assert.Equal(t, []int{0, 2, 3}, cm.Positions)
+		assert.Equal(t, []string{"id", "email", "age"}, cm.Columns)
+		assert.Equal(t, 4, cm.ColumnsCount)
+		assert.Equal(t, []*ColumnFill{
+			{Name: "tenant_id", Expression: "1"},
+			{Name: "status", Expression: "'imported'", OriginalDefault: "'new'::text"},
+		}, cm.Fills)
+		assert.Equal(t, `COPY "public"."users" ("id", "email", "age") FROM stdin;`+"\n", cm.CopyStmt())
+
+		var msgs []string
+		for _, w := range warnings {
+			msgs = append(msgs, w.Meta["ColumnName"].(string)+": "+w.Severity)
+		}
+		assert.Equal(t, []string{
+			"legacy: warning",
+			"email: warning",
+			"email: warning",
+			"tenant_id: info",
+			"created_at: info",
+			"nickname: info",
+			"status: info",
+		}, msgs)
+	})
+
+	t.Run("not null column without default", func(t *testing.T) {
+		_, warnings := BuildColumnsMapping(dumpTable, targetColumns, nil)
+		require.True(t, warnings.IsFatal())
+		idx := -1
+		for i, w := range warnings {
+			if w.Severity == toolkit.ErrorValidationSeverity {
+				idx = i
+			}
+		}
+		require.NotEqual(t, -1, idx)
+		assert.Equal(t, "tenant_id", warnings[idx].Meta["ColumnName"])
+	})
+
+	t.Run("unknown column in config", func(t *testing.T) {
+		cfg := &domains.SchemaToleranceTable{
+			Columns: []*domains.SchemaToleranceColumn{
+				{Name: "tenant_id", Expression: "1"},
+				{Name: "unknown", Expression: "1"},
+			},
+		}
+		_, warnings := BuildColumnsMapping(dumpTable, targetColumns, cfg)
+		require.True(t, warnings.IsFatal())
+	})
+}
+
+func TestColumnsProjectionReader(t *testing.T) {
+	data := "1\tJohn\t\\N\tjohn@example.com\n2\tJane\\tDoe\t30\t\\N\n\\.\n\n"
+	r := newColumnsProjectionReader(strings.NewReader(data), []int{0, 1, 3}, 4)
+	res, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "1\tJohn\tjohn@example.com\n2\tJane\\tDoe\t\\N\n\\.\n\n", string(res))
+
+	r = newColumnsProjectionReader(strings.NewReader("1\tJohn\n"), []int{0}, 3)
+	_, err = io.ReadAll(r)
+	require.Error(t, err)
+}
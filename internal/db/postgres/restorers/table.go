@@ -37,6 +37,9 @@ const defaultBufferSize = 1024 * 1024
 
 type TableRestorer struct {
 	*restoreBase
+	// columnsMapping - mapping of the dumped columns to the target table columns. If it is set then only the
+	// mapped columns are restored
+	columnsMapping *ColumnsMapping
 }
 
 func NewTableRestorer(
@@ -47,6 +50,20 @@ func NewTableRestorer(
 	}
 }
 
+// NewSchemaTolerantTableRestorer - creates TableRestorer that restores only the columns from the mapping and fills
+// the other columns by the configured expressions
+func NewSchemaTolerantTableRestorer(
+	entry *toc.Entry, st storages.Storager, opt *pgrestore.DataSectionSettings, mapping *ColumnsMapping,
+) *TableRestorer {
+	entry = entry.Copy()
+	copyStmt := mapping.CopyStmt()
+	entry.CopyStmt = &copyStmt
+	return &TableRestorer{
+		restoreBase:    newRestoreBase(entry, st, opt),
+		columnsMapping: mapping,
+	}
+}
+
 func (td *TableRestorer) GetEntry() *toc.Entry {
 	return td.entry
 }
@@ -79,13 +96,22 @@ func (td *TableRestorer) Execute(ctx context.Context, conn utils.PGConnector) er
 	if err := td.setupTx(ctx, tx); err != nil {
 		return fmt.Errorf("cannot setup transaction: %w", err)
 	}
+	if err := td.setColumnsFills(ctx, tx); err != nil {
+		rollbackTransaction(ctx, tx, td.entry)
+		return fmt.Errorf("cannot set columns fill expressions: %w", err)
+	}
+
+	var data io.Reader = r
+	if td.columnsMapping != nil {
+		data = newColumnsProjectionReader(r, td.columnsMapping.Positions, td.columnsMapping.ColumnsCount)
+	}
 
 	log.Debug().
 		Str("copyStmt", *td.entry.CopyStmt).
 		Msgf("performing pgcopy statement")
 	f := tx.Conn().PgConn().Frontend()
 
-	if err = td.restoreCopy(ctx, f, r); err != nil {
+	if err = td.restoreCopy(ctx, f, data); err != nil {
 		rollbackTransaction(ctx, tx, td.entry)
 		if td.opt.ExitOnError {
 			return fmt.Errorf("unable to restore table: %w", err)
@@ -104,6 +130,11 @@ func (td *TableRestorer) Execute(ctx context.Context, conn utils.PGConnector) er
 		}
 	}
 
+	if err := td.resetColumnsFills(ctx, tx); err != nil {
+		rollbackTransaction(ctx, tx, td.entry)
+		return fmt.Errorf("cannot reset columns fill expressions: %w", err)
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return fmt.Errorf("cannot commit transaction (restoring %s): %w", td.DebugInfo(), err)
 	}
@@ -111,6 +142,56 @@ func (td *TableRestorer) Execute(ctx context.Context, conn utils.PGConnector) er
 	return nil
 }
 
+// setColumnsFills - sets the configured expressions as the columns defaults, so the columns that are not present
+// in COPY data are filled by them. The original defaults are set back in resetColumnsFills in the same transaction
+func (td *TableRestorer) setColumnsFills(ctx context.Context, tx pgx.Tx) error {
+	if td.columnsMapping == nil || len(td.columnsMapping.Fills) == 0 {
+		return nil
+	}
+	for _, fill := range td.columnsMapping.Fills {
+		if err := td.alterColumnDefault(ctx, tx, fill.Name, fill.Expression); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (td *TableRestorer) resetColumnsFills(ctx context.Context, tx pgx.Tx) error {
+	if td.columnsMapping == nil || len(td.columnsMapping.Fills) == 0 {
+		return nil
+	}
+	for _, fill := range td.columnsMapping.Fills {
+		if err := td.alterColumnDefault(ctx, tx, fill.Name, fill.OriginalDefault); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// alterColumnDefault - sets the column default expression. If the expression is empty then the default is dropped
+func (td *TableRestorer) alterColumnDefault(ctx context.Context, tx pgx.Tx, column, expression string) error {
+	action := "DROP DEFAULT"
+	if expression != "" {
+		action = fmt.Sprintf("SET DEFAULT %s", expression)
+	}
+	query := fmt.Sprintf(
+		"ALTER TABLE %s ALTER COLUMN %s %s",
+		pgx.Identifier{td.columnsMapping.Schema, td.columnsMapping.Name}.Sanitize(),
+		pgx.Identifier{column}.Sanitize(),
+		action,
+	)
+	if err := td.setSuperUser(ctx, tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, query); err != nil {
+		return fmt.Errorf("cannot alter default of column %s: %w", column, err)
+	}
+	if err := td.resetSuperUser(ctx, tx); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (td *TableRestorer) restoreCopy(ctx context.Context, f *pgproto3.Frontend, r io.Reader) error {
 	if err := td.initCopy(ctx, f); err != nil {
 		return fmt.Errorf("error initializing pgcopy: %w", err)
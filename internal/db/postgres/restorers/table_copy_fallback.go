@@ -0,0 +1,243 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restorers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgcopy"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgrestore"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/utils"
+	"github.com/greenmaskio/greenmask/internal/storages"
+	"github.com/greenmaskio/greenmask/internal/utils/reader"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// defaultCopyFallbackBatchSize - the number of rows in the COPY batch if the batch size is not set
+const defaultCopyFallbackBatchSize = 1000
+
+const (
+	copyFallbackBatchSavepoint = "greenmask_copy_batch"
+	copyFallbackRowSavepoint   = "greenmask_copy_row"
+)
+
+// TableRestorerCopyFallback - restores the table data by COPY batches in a single transaction. If the batch fails,
+// its rows are inserted one by one and the rows rejected by the database are written to the dead-letter file
+type TableRestorerCopyFallback struct {
+	*TableRestorerInsertFormat
+	batchSize int64
+}
+
+func NewTableRestorerCopyFallback(
+	entry *toc.Entry, t *toolkit.Table, st storages.Storager, opt *pgrestore.DataSectionSettings, dl *DeadLetter,
+) *TableRestorerCopyFallback {
+	td := NewTableRestorerInsertFormat(entry, t, st, opt, nil)
+	td.SetDeadLetter(dl)
+	batchSize := opt.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultCopyFallbackBatchSize
+	}
+	return &TableRestorerCopyFallback{
+		TableRestorerInsertFormat: td,
+		batchSize:                 batchSize,
+	}
+}
+
+func (td *TableRestorerCopyFallback) Execute(ctx context.Context, conn utils.PGConnector) error {
+	if td.entry.CopyStmt == nil {
+		return fmt.Errorf("cannot get COPY statement from toc Entry")
+	}
+	r, err := td.getObject(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get storage object: %w", err)
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			log.Warn().
+				Err(err).
+				Str("objectName", td.DebugInfo()).
+				Msg("cannot close storage object")
+		}
+	}()
+
+	tdl := td.deadLetter.newTable(ctx, td.entry)
+	err = td.restore(ctx, conn.GetConn(), r, tdl)
+	if closeErr := tdl.close(); closeErr != nil {
+		err = errors.Join(err, closeErr)
+	}
+	if err != nil {
+		if td.opt.ExitOnError {
+			return fmt.Errorf("unable to restore table: %w", err)
+		}
+		log.Warn().
+			Err(err).
+			Str("objectName", td.DebugInfo()).
+			Msg("unable to restore table")
+	}
+	return nil
+}
+
+func (td *TableRestorerCopyFallback) restore(
+	ctx context.Context, conn *pgx.Conn, r io.Reader, tdl *tableDeadLetter,
+) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot start transaction (restoring %s): %w", td.DebugInfo(), err)
+	}
+	if err = td.setupTx(ctx, tx); err != nil {
+		rollbackTransaction(ctx, tx, td.entry)
+		return fmt.Errorf("cannot setup transaction: %w", err)
+	}
+	err = td.streamBatches(ctx, r, func(batch [][]byte, firstLine int64) error {
+		return td.restoreBatch(ctx, tx, batch, firstLine, tdl)
+	})
+	if err != nil {
+		rollbackTransaction(ctx, tx, td.entry)
+		return err
+	}
+	if err = td.resetTx(ctx, tx); err != nil {
+		rollbackTransaction(ctx, tx, td.entry)
+		return fmt.Errorf("cannot reset transaction: %w", err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("cannot commit transaction (restoring %s): %w", td.DebugInfo(), err)
+	}
+	return nil
+}
+
+// streamBatches - reads the table dump and passes it to restoreBatch by batches of batchSize rows. firstLine is the
+// line number of the first batch row in the table dump
+func (td *TableRestorerCopyFallback) streamBatches(
+	ctx context.Context, r io.Reader, restoreBatch func(batch [][]byte, firstLine int64) error,
+) error {
+	bi := bufio.NewReader(r)
+	batch := make([][]byte, 0, td.batchSize)
+	var lineNum int64
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		line, err := reader.ReadLine(bi, nil)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("error readimg from table dump: %w", err)
+		}
+		if isTerminationSeq(line) {
+			break
+		}
+		lineNum++
+		// The line is allocated on each read, so it is kept in the batch without copying
+		batch = append(batch, line)
+		if int64(len(batch)) == td.batchSize {
+			if err = restoreBatch(batch, lineNum-int64(len(batch))+1); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		return restoreBatch(batch, lineNum-int64(len(batch))+1)
+	}
+	return nil
+}
+
+// restoreBatch - restores the batch by COPY. If COPY fails, the batch is rolled back to the savepoint and its rows
+// are inserted one by one. firstLine is the line number of the first batch row in the table dump
+func (td *TableRestorerCopyFallback) restoreBatch(
+	ctx context.Context, tx pgx.Tx, batch [][]byte, firstLine int64, tdl *tableDeadLetter,
+) error {
+	if _, err := tx.Exec(ctx, "SAVEPOINT "+copyFallbackBatchSavepoint); err != nil {
+		return fmt.Errorf("cannot create batch savepoint: %w", err)
+	}
+	data := bytes.Join(batch, []byte{'\n'})
+	data = append(data, '\n')
+	_, copyErr := tx.Conn().PgConn().CopyFrom(ctx, bytes.NewReader(data), *td.entry.CopyStmt)
+	if copyErr == nil {
+		if _, err := tx.Exec(ctx, "RELEASE SAVEPOINT "+copyFallbackBatchSavepoint); err != nil {
+			return fmt.Errorf("cannot release batch savepoint: %w", err)
+		}
+		return nil
+	}
+	if _, err := tx.Exec(ctx, "ROLLBACK TO SAVEPOINT "+copyFallbackBatchSavepoint); err != nil {
+		return errors.Join(
+			fmt.Errorf("error restoring batch: %w", copyErr),
+			fmt.Errorf("cannot rollback to batch savepoint: %w", err),
+		)
+	}
+	log.Debug().
+		Err(copyErr).
+		Str("objectName", td.DebugInfo()).
+		Int64("FirstLine", firstLine).
+		Msg("COPY batch failed: falling back to INSERTs")
+
+	if td.query == "" {
+		td.query = td.generateInsertStmt(td.opt.OnConflictDoNothing)
+	}
+	row := pgcopy.NewRow(pgcopy.UseDynamicSize)
+	for idx, line := range batch {
+		if err := row.Decode(line); err != nil {
+			return fmt.Errorf("error decoding line: %w", err)
+		}
+		if err := td.insertRow(ctx, tx, row, firstLine+int64(idx), line, tdl); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, "RELEASE SAVEPOINT "+copyFallbackBatchSavepoint); err != nil {
+		return fmt.Errorf("cannot release batch savepoint: %w", err)
+	}
+	return nil
+}
+
+// insertRow - inserts the row in the savepoint. If the row is rejected by the database, it is written to the
+// dead-letter file
+func (td *TableRestorerCopyFallback) insertRow(
+	ctx context.Context, tx pgx.Tx, row *pgcopy.Row, lineNum int64, line []byte, tdl *tableDeadLetter,
+) error {
+	if _, err := tx.Exec(ctx, "SAVEPOINT "+copyFallbackRowSavepoint); err != nil {
+		return fmt.Errorf("cannot create row savepoint: %w", err)
+	}
+	_, insertErr := tx.Exec(ctx, td.query, getAllArguments(row)...)
+	if insertErr == nil {
+		if _, err := tx.Exec(ctx, "RELEASE SAVEPOINT "+copyFallbackRowSavepoint); err != nil {
+			return fmt.Errorf("cannot release row savepoint: %w", err)
+		}
+		return nil
+	}
+	rejected, err := tdl.add(lineNum, line, insertErr)
+	if err != nil {
+		return err
+	}
+	if !rejected {
+		return fmt.Errorf("error inserting data: %w", insertErr)
+	}
+	if _, err = tx.Exec(ctx, "ROLLBACK TO SAVEPOINT "+copyFallbackRowSavepoint); err != nil {
+		return fmt.Errorf("cannot rollback to row savepoint: %w", err)
+	}
+	return nil
+}
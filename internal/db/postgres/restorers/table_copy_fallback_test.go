@@ -0,0 +1,131 @@
+package restorers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgcopy"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgrestore"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+type txMock struct {
+	pgx.Tx
+	mock.Mock
+}
+
+func (m *txMock) Exec(ctx context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	args := m.Called(ctx, sql)
+	return pgconn.CommandTag{}, args.Error(0)
+}
+
+func newCopyFallbackTestRestorer(batchSize int64, dl *DeadLetter) *TableRestorerCopyFallback {
+	copyStmt := "COPY orders (id, user_id) FROM stdin;"
+	entry := newDeadLetterTestEntry(1, "public", "orders")
+	entry.CopyStmt = &copyStmt
+	t := &toolkit.Table{
+		Schema: "public",
+		Name:   "orders",
+		Columns: []*toolkit.Column{
+			{Name: "id", TypeName: "int4"},
+			{Name: "user_id", TypeName: "int4"},
+		},
+	}
+	return NewTableRestorerCopyFallback(entry, t, nil, &pgrestore.DataSectionSettings{BatchSize: batchSize}, dl)
+}
+
+func TestTableRestorerCopyFallback_streamBatches(t *testing.T) {
+	td := newCopyFallbackTestRestorer(2, NewDeadLetter(nil, t.TempDir()))
+	data := "1\t1\n2\t1\n3\t1\n4\t1\n5\t1\n\\.\n"
+
+	var firstLines []int64
+	var batches [][]string
+	err := td.streamBatches(context.Background(), strings.NewReader(data), func(batch [][]byte, firstLine int64) error {
+		var lines []string
+		for _, line := range batch {
+			lines = append(lines, string(line))
+		}
+		firstLines = append(firstLines, firstLine)
+		batches = append(batches, lines)
+		return nil
+	})
+	require.NoError(t, err)
+	// The line numbers are counted across the batches, so the last incomplete batch starts with the 5th line
+	assert.Equal(t, []int64{1, 3, 5}, firstLines)
+	assert.Equal(t, [][]string{{"1\t1", "2\t1"}, {"3\t1", "4\t1"}, {"5\t1"}}, batches)
+
+	t.Run("batch error", func(t *testing.T) {
+		calls := 0
+		err := td.streamBatches(context.Background(), strings.NewReader(data), func([][]byte, int64) error {
+			calls++
+			return errors.New("test error")
+		})
+		require.ErrorContains(t, err, "test error")
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestTableRestorerCopyFallback_insertRow(t *testing.T) {
+	ctx := context.Background()
+	line := []byte("2\t999")
+	row := pgcopy.NewRow(pgcopy.UseDynamicSize)
+	require.NoError(t, row.Decode(line))
+
+	t.Run("rejected row", func(t *testing.T) {
+		dl := NewDeadLetter(nil, t.TempDir())
+		td := newCopyFallbackTestRestorer(2, dl)
+		td.query = td.generateInsertStmt(false)
+		tdl := dl.newTable(ctx, td.entry)
+
+		tx := new(txMock)
+		tx.On("Exec", mock.Anything, "SAVEPOINT "+copyFallbackRowSavepoint).Return(nil)
+		tx.On("Exec", mock.Anything, td.query).
+			Return(&pgconn.PgError{Code: "23503", ConstraintName: "fk_user", Message: "fk violation"})
+		tx.On("Exec", mock.Anything, "ROLLBACK TO SAVEPOINT "+copyFallbackRowSavepoint).Return(nil)
+
+		require.NoError(t, td.insertRow(ctx, tx, row, 12, line, tdl))
+		require.NoError(t, tdl.close())
+		tx.AssertExpectations(t)
+
+		summary := dl.Summary()
+		require.Len(t, summary, 1)
+		assert.Equal(t, int64(1), summary[0].Rows)
+		assert.Equal(t, map[string]int64{"23503 fk_user": 1}, summary[0].Errors)
+		data, err := os.ReadFile(summary[0].Path)
+		require.NoError(t, err)
+		var rec DeadLetterRecord
+		require.NoError(t, json.Unmarshal(data, &rec))
+		assert.Equal(t, int64(12), rec.Line)
+		assert.Equal(t, "2\t999", rec.Data)
+	})
+
+	t.Run("not a database error", func(t *testing.T) {
+		dl := NewDeadLetter(nil, t.TempDir())
+		td := newCopyFallbackTestRestorer(2, dl)
+		td.query = td.generateInsertStmt(false)
+		tdl := dl.newTable(ctx, td.entry)
+
+		tx := new(txMock)
+		tx.On("Exec", mock.Anything, "SAVEPOINT "+copyFallbackRowSavepoint).Return(nil)
+		tx.On("Exec", mock.Anything, td.query).Return(errors.New("conn closed"))
+
+		// The row is not written to the dead-letter file and the restoration is aborted without rolling back to the
+		// row savepoint
+		err := td.insertRow(ctx, tx, row, 12, line, tdl)
+		require.ErrorContains(t, err, "error inserting data: conn closed")
+		require.NoError(t, tdl.close())
+		tx.AssertExpectations(t)
+		tx.AssertNotCalled(t, "Exec", mock.Anything, "ROLLBACK TO SAVEPOINT "+copyFallbackRowSavepoint)
+		assert.Empty(t, dl.Summary())
+	})
+}
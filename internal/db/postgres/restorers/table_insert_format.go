@@ -43,6 +43,8 @@ type TableRestorerInsertFormat struct {
 	query            string
 	globalExclusions *domains.GlobalDataRestorationErrorExclusions
 	tableExclusion   *domains.TablesDataRestorationErrorExclusions
+	// deadLetter - the writer of the rejected rows. If it is set, the rejected rows do not fail the restoration
+	deadLetter *DeadLetter
 }
 
 func NewTableRestorerInsertFormat(
@@ -85,6 +87,12 @@ func (td *TableRestorerInsertFormat) GetEntry() *toc.Entry {
 	return td.entry
 }
 
+// SetDeadLetter - sets the writer of the rows rejected by the database. The rejected rows, including the ones
+// allowed by insert_error_exclusions, are written to it instead of failing the restoration
+func (td *TableRestorerInsertFormat) SetDeadLetter(dl *DeadLetter) {
+	td.deadLetter = dl
+}
+
 func (td *TableRestorerInsertFormat) Execute(ctx context.Context, conn utils.PGConnector) error {
 	r, err := td.getObject(ctx)
 	if err != nil {
@@ -99,7 +107,17 @@ func (td *TableRestorerInsertFormat) Execute(ctx context.Context, conn utils.PGC
 		}
 	}()
 
-	if err = td.streamInsertData(ctx, conn.GetConn(), r); err != nil {
+	var tdl *tableDeadLetter
+	if td.deadLetter != nil {
+		tdl = td.deadLetter.newTable(ctx, td.entry)
+	}
+	err = td.streamInsertData(ctx, conn.GetConn(), r, tdl)
+	if tdl != nil {
+		if closeErr := tdl.close(); closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}
+	if err != nil {
 		if td.opt.ExitOnError {
 			return fmt.Errorf("error streaming pgcopy data: %w", err)
 		}
@@ -109,9 +127,12 @@ func (td *TableRestorerInsertFormat) Execute(ctx context.Context, conn utils.PGC
 	return nil
 }
 
-func (td *TableRestorerInsertFormat) streamInsertData(ctx context.Context, conn *pgx.Conn, r io.Reader) error {
+func (td *TableRestorerInsertFormat) streamInsertData(
+	ctx context.Context, conn *pgx.Conn, r io.Reader, tdl *tableDeadLetter,
+) error {
 	// Streaming pgcopy data from table dump
 	buf := bufio.NewReader(r)
+	var lineNum int64
 
 	row := pgcopy.NewRow(pgcopy.UseDynamicSize)
 	for {
@@ -132,11 +153,21 @@ func (td *TableRestorerInsertFormat) streamInsertData(ctx context.Context, conn
 		if isTerminationSeq(line) {
 			break
 		}
+		lineNum++
 		if err = row.Decode(line); err != nil {
 			return fmt.Errorf("error decoding line: %w", err)
 		}
 
 		if err = td.insertData(ctx, conn, row); err != nil {
+			if tdl != nil {
+				rejected, addErr := tdl.add(lineNum, line, err)
+				if addErr != nil {
+					return addErr
+				}
+				if rejected {
+					continue
+				}
+			}
 			if !td.isErrorAllowed(err) {
 				return fmt.Errorf("error inserting data: %w", err)
 			} else {
@@ -0,0 +1,313 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schemarewrite
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+	"github.com/greenmaskio/greenmask/internal/domains"
+)
+
+const (
+	CommentsKeep   = "keep"
+	CommentsDrop   = "drop"
+	CommentsRedact = "redact"
+)
+
+const (
+	defaultCommentPlaceholder = "redacted"
+	functionStub              = "\nBEGIN\n    RAISE EXCEPTION 'the function body is redacted';\nEND;\n"
+)
+
+const (
+	commentDesc   = "COMMENT"
+	functionDesc  = "FUNCTION"
+	procedureDesc = "PROCEDURE"
+)
+
+var grantDescs = []string{"ACL", "DEFAULT ACL"}
+
+var (
+	commentRe          = regexp.MustCompile(`(?s)^(COMMENT ON .*) IS (?:E?'(?:[^']|'')*'|NULL);\s*$`)
+	functionLanguageRe = regexp.MustCompile(`\n    LANGUAGE ("(?:[^"]|"")+"|[A-Za-z0-9_]+)`)
+	functionBodyRe     = regexp.MustCompile(`\n    AS (\$[A-Za-z0-9_]*\$)`)
+	functionSqlBodyRe  = regexp.MustCompile(`\n    (?:BEGIN ATOMIC|RETURN )`)
+)
+
+type objectMatcher struct {
+	schema *regexp.Regexp
+	name   *regexp.Regexp
+}
+
+func (om *objectMatcher) match(schema, name string) bool {
+	return (om.schema == nil || om.schema.MatchString(schema)) && (om.name == nil || om.name.MatchString(name))
+}
+
+type rule struct {
+	types       []string
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// Rewriter - rewrites the TOC entries definitions according to the schema rewrite rules. It changes only the
+// statements, names and owners of the entries, so the archive stays restorable by pg_restore
+type Rewriter struct {
+	cfg                *domains.SchemaRewrite
+	commentPlaceholder string
+	functions          []*objectMatcher
+	rules              []*rule
+}
+
+func NewRewriter(cfg *domains.SchemaRewrite) (*Rewriter, error) {
+	switch cfg.Comments {
+	case "", CommentsKeep, CommentsDrop, CommentsRedact:
+	default:
+		return nil, fmt.Errorf(
+			"unknown comments action \"%s\": expected one of %s, %s or %s",
+			cfg.Comments, CommentsKeep, CommentsDrop, CommentsRedact,
+		)
+	}
+	r := &Rewriter{
+		cfg:                cfg,
+		commentPlaceholder: cfg.CommentPlaceholder,
+	}
+	if r.commentPlaceholder == "" {
+		r.commentPlaceholder = defaultCommentPlaceholder
+	}
+
+	for idx, f := range cfg.FunctionBodies {
+		om := &objectMatcher{}
+		var err error
+		if f.Schema != "" {
+			if om.schema, err = regexp.Compile(f.Schema); err != nil {
+				return nil, fmt.Errorf("function_bodies[%d]: cannot compile schema regexp: %w", idx, err)
+			}
+		}
+		if f.Name != "" {
+			if om.name, err = regexp.Compile(f.Name); err != nil {
+				return nil, fmt.Errorf("function_bodies[%d]: cannot compile name regexp: %w", idx, err)
+			}
+		}
+		r.functions = append(r.functions, om)
+	}
+
+	for idx, rr := range cfg.Rules {
+		if rr.Pattern == "" {
+			return nil, fmt.Errorf("rules[%d]: pattern cannot be empty", idx)
+		}
+		pattern, err := regexp.Compile(rr.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d]: cannot compile pattern: %w", idx, err)
+		}
+		var types []string
+		for _, t := range rr.Types {
+			types = append(types, strings.ToUpper(t))
+		}
+		r.rules = append(r.rules, &rule{types: types, pattern: pattern, replacement: rr.Replacement})
+	}
+	return r, nil
+}
+
+// Rewrite - rewrites the entries and returns the entries that must be stored. The dropped entries are removed from
+// the dependencies of the rest
+func (r *Rewriter) Rewrite(entries []*toc.Entry) []*toc.Entry {
+	res := make([]*toc.Entry, 0, len(entries))
+	dropped := make(map[int32]struct{})
+	for _, e := range entries {
+		if r.isDropped(e) {
+			log.Debug().
+				Int32("DumpId", e.DumpId).
+				Str("Desc", getStr(e.Desc)).
+				Str("Tag", getStr(e.Tag)).
+				Msg("schema rewrite: dropping entry")
+			dropped[e.DumpId] = struct{}{}
+			continue
+		}
+		r.rewriteOwner(e)
+		r.rewriteComment(e)
+		r.rewriteFunctionBody(e)
+		r.applyRules(e)
+		res = append(res, e)
+	}
+
+	if len(dropped) == 0 {
+		return res
+	}
+	for _, e := range res {
+		e.Dependencies = slices.DeleteFunc(e.Dependencies, func(id int32) bool {
+			_, ok := dropped[id]
+			return ok
+		})
+		e.NDeps = int32(len(e.Dependencies))
+	}
+	return res
+}
+
+func (r *Rewriter) isDropped(e *toc.Entry) bool {
+	desc := getStr(e.Desc)
+	if r.cfg.DropGrants && slices.Contains(grantDescs, desc) {
+		return true
+	}
+	return r.cfg.Comments == CommentsDrop && desc == commentDesc
+}
+
+// rewriteOwner - pg_restore skips ALTER ... OWNER TO when the owner is empty
+func (r *Rewriter) rewriteOwner(e *toc.Entry) {
+	if r.cfg.Owners == nil || e.Owner == nil || *e.Owner == "" {
+		return
+	}
+	if r.cfg.Owners.Strip {
+		e.Owner = toc.NewObj("")
+		return
+	}
+	if owner, ok := r.cfg.Owners.Remap[*e.Owner]; ok {
+		e.Owner = toc.NewObj(owner)
+	} else if r.cfg.Owners.Default != "" {
+		e.Owner = toc.NewObj(r.cfg.Owners.Default)
+	}
+}
+
+func (r *Rewriter) rewriteComment(e *toc.Entry) {
+	if r.cfg.Comments != CommentsRedact || getStr(e.Desc) != commentDesc || e.Defn == nil {
+		return
+	}
+	defn, ok := redactComment(*e.Defn, r.commentPlaceholder)
+	if !ok {
+		log.Warn().
+			Int32("DumpId", e.DumpId).
+			Str("Tag", getStr(e.Tag)).
+			Msg("schema rewrite: unable to redact comment")
+		return
+	}
+	e.Defn = &defn
+}
+
+func (r *Rewriter) rewriteFunctionBody(e *toc.Entry) {
+	desc := getStr(e.Desc)
+	if len(r.functions) == 0 || (desc != functionDesc && desc != procedureDesc) || e.Defn == nil {
+		return
+	}
+	name, _, _ := strings.Cut(getStr(e.Tag), "(")
+	if !slices.ContainsFunc(r.functions, func(om *objectMatcher) bool {
+		return om.match(getStr(e.Namespace), name)
+	}) {
+		return
+	}
+	defn, ok := stubFunctionBody(*e.Defn)
+	if !ok {
+		log.Warn().
+			Int32("DumpId", e.DumpId).
+			Str("Schema", getStr(e.Namespace)).
+			Str("Tag", getStr(e.Tag)).
+			Msg("schema rewrite: unable to replace function body")
+		return
+	}
+	e.Defn = &defn
+}
+
+func (r *Rewriter) applyRules(e *toc.Entry) {
+	desc := getStr(e.Desc)
+	for _, rr := range r.rules {
+		if len(rr.types) > 0 && !slices.Contains(rr.types, desc) {
+			continue
+		}
+		if e.Defn != nil {
+			e.Defn = toc.NewObj(rr.pattern.ReplaceAllString(*e.Defn, rr.replacement))
+		}
+		if e.DropStmt != nil {
+			e.DropStmt = toc.NewObj(rr.pattern.ReplaceAllString(*e.DropStmt, rr.replacement))
+		}
+		if e.CopyStmt != nil {
+			e.CopyStmt = toc.NewObj(rr.pattern.ReplaceAllString(*e.CopyStmt, rr.replacement))
+		}
+		rr.applyToName(e)
+	}
+}
+
+// applyToName - applies the rule to the entry name, so the renamed objects are filtered and restored by the same name
+// as in the definition. The rule is matched against the schema-qualified name as it is written in the statements
+func (rr *rule) applyToName(e *toc.Entry) {
+	if e.Tag == nil {
+		return
+	}
+	if e.Namespace == nil || *e.Namespace == "" {
+		e.Tag = toc.NewObj(rr.pattern.ReplaceAllString(*e.Tag, rr.replacement))
+		return
+	}
+	name := rr.pattern.ReplaceAllString(*e.Namespace+"."+*e.Tag, rr.replacement)
+	if schema, tag, ok := strings.Cut(name, "."); ok {
+		e.Namespace = toc.NewObj(schema)
+		e.Tag = toc.NewObj(tag)
+		return
+	}
+	e.Tag = toc.NewObj(name)
+}
+
+// redactComment - replaces the comment text in the COMMENT ON ... IS '...'; statement produced by pg_dump
+func redactComment(defn, placeholder string) (string, bool) {
+	m := commentRe.FindStringSubmatch(defn)
+	if m == nil {
+		return defn, false
+	}
+	return fmt.Sprintf("%s IS '%s';\n", m[1], strings.ReplaceAll(placeholder, "'", "''")), true
+}
+
+// stubFunctionBody - replaces the function body produced by pg_dump with the plpgsql stub that raises an exception.
+// The C and internal functions have no body to replace
+func stubFunctionBody(defn string) (string, bool) {
+	lm := functionLanguageRe.FindStringSubmatchIndex(defn)
+	if lm == nil {
+		return defn, false
+	}
+	lang := strings.ToLower(strings.Trim(defn[lm[2]:lm[3]], `"`))
+	if lang == "c" || lang == "internal" {
+		return defn, false
+	}
+	rest := defn[lm[1]:]
+
+	var options, tail string
+	if bm := functionBodyRe.FindStringSubmatchIndex(rest); bm != nil {
+		tag := rest[bm[2]:bm[3]]
+		end := strings.Index(rest[bm[1]:], tag)
+		if end == -1 {
+			return defn, false
+		}
+		options = rest[:bm[0]]
+		tail = rest[bm[1]+end+len(tag):]
+	} else if bm := functionSqlBodyRe.FindStringIndex(rest); bm != nil {
+		// SQL-standard body lasts until the end of the statement
+		options = rest[:bm[0]]
+		tail = ";\n"
+	} else {
+		return defn, false
+	}
+
+	return fmt.Sprintf(
+		"%s\n    LANGUAGE plpgsql%s\n    AS $$%s$$%s", defn[:lm[0]], options, functionStub, tail,
+	), true
+}
+
+func getStr(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
@@ -0,0 +1,271 @@
+package schemarewrite
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
+	"github.com/greenmaskio/greenmask/internal/domains"
+)
+
+func newEntry(dumpId int32, desc, namespace, tag, owner, defn string, deps ...int32) *toc.Entry {
+	return &toc.Entry{
+		DumpId:       dumpId,
+		Desc:         toc.NewObj(desc),
+		Namespace:    toc.NewObj(namespace),
+		Tag:          toc.NewObj(tag),
+		Owner:        toc.NewObj(owner),
+		Defn:         toc.NewObj(defn),
+		DropStmt:     toc.NewObj(""),
+		Dependencies: deps,
+		NDeps:        int32(len(deps)),
+	}
+}
+
+func TestNewRewriter(t *testing.T) {
+	_, err := NewRewriter(&domains.SchemaRewrite{Comments: "hide"})
+	require.ErrorContains(t, err, "unknown comments action")
+
+	_, err = NewRewriter(&domains.SchemaRewrite{Rules: []*domains.SchemaRewriteRule{{Pattern: "("}}})
+	require.ErrorContains(t, err, "rules[0]: cannot compile pattern")
+
+	_, err = NewRewriter(&domains.SchemaRewrite{FunctionBodies: []*domains.SchemaRewriteObject{{Name: "["}}})
+	require.ErrorContains(t, err, "function_bodies[0]: cannot compile name regexp")
+}
+
+func TestRewriter_Owners(t *testing.T) {
+	type test struct {
+		name   string
+		owners *domains.SchemaRewriteOwners
+		owner  string
+		want   string
+	}
+	tests := []test{
+		{name: "strip", owners: &domains.SchemaRewriteOwners{Strip: true}, owner: "app_admin", want: ""},
+		{
+			name:   "remap",
+			owners: &domains.SchemaRewriteOwners{Remap: map[string]string{"app_admin": "owner"}, Default: "other"},
+			owner:  "app_admin",
+			want:   "owner",
+		},
+		{
+			name:   "default",
+			owners: &domains.SchemaRewriteOwners{Remap: map[string]string{"app_admin": "owner"}, Default: "other"},
+			owner:  "billing_admin",
+			want:   "other",
+		},
+		{
+			name:   "keep",
+			owners: &domains.SchemaRewriteOwners{Remap: map[string]string{"app_admin": "owner"}},
+			owner:  "billing_admin",
+			want:   "billing_admin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRewriter(&domains.SchemaRewrite{Owners: tt.owners})
+			require.NoError(t, err)
+			res := r.Rewrite([]*toc.Entry{newEntry(1, "TABLE", "public", "users", tt.owner, "CREATE TABLE ...")})
+			require.Len(t, res, 1)
+			assert.Equal(t, tt.want, *res[0].Owner)
+		})
+	}
+}
+
+func TestRewriter_DropGrantsAndComments(t *testing.T) {
+	r, err := NewRewriter(&domains.SchemaRewrite{DropGrants: true, Comments: CommentsDrop})
+	require.NoError(t, err)
+	entries := []*toc.Entry{
+		newEntry(1, "TABLE", "public", "users", "app", "CREATE TABLE public.users (id integer);\n"),
+		newEntry(2, "COMMENT", "public", "COLUMN users.id", "app", "COMMENT ON COLUMN public.users.id IS 'secret';\n", 1),
+		newEntry(3, "ACL", "public", "TABLE users", "app", "GRANT SELECT ON TABLE public.users TO reporting;\n", 1),
+		newEntry(4, "INDEX", "public", "users_idx", "app", "CREATE INDEX users_idx ON public.users (id);\n", 1, 3),
+	}
+	res := r.Rewrite(entries)
+	require.Len(t, res, 2)
+	assert.Equal(t, int32(1), res[0].DumpId)
+	assert.Equal(t, int32(4), res[1].DumpId)
+	assert.Equal(t, []int32{1}, res[1].Dependencies)
+	assert.Equal(t, int32(1), res[1].NDeps)
+}
+
+func TestRewriter_RedactComments(t *testing.T) {
+	r, err := NewRewriter(&domains.SchemaRewrite{Comments: CommentsRedact, CommentPlaceholder: "it's hidden"})
+	require.NoError(t, err)
+	entries := []*toc.Entry{
+		newEntry(1, "COMMENT", "public", "COLUMN users.id", "app",
+			"COMMENT ON COLUMN public.users.id IS 'customer''s IS ''internal'' id';\n"),
+		newEntry(2, "COMMENT", "public", "TABLE \"IS 'a'\"", "app",
+			"COMMENT ON TABLE public.\"IS 'a'\" IS E'multi\\\\nline';\n"),
+	}
+	res := r.Rewrite(entries)
+	require.Len(t, res, 2)
+	assert.Equal(t, "COMMENT ON COLUMN public.users.id IS 'it''s hidden';\n", *res[0].Defn)
+	assert.Equal(t, "COMMENT ON TABLE public.\"IS 'a'\" IS 'it''s hidden';\n", *res[1].Defn)
+}
+
+func TestRewriter_FunctionBodies(t *testing.T) {
+	type test struct {
+		name     string
+		schema   string
+		tag      string
+		defn     string
+		expected string
+	}
+	tests := []test{
+		{
+			name:   "plpgsql",
+			schema: "public",
+			tag:    "get_key()",
+			defn: "CREATE FUNCTION public.get_key() RETURNS text\n    LANGUAGE plpgsql SECURITY DEFINER\n" +
+				"    AS $$\nBEGIN\n    RETURN 'sk_live_123';\nEND;\n$$;\n",
+			expected: "CREATE FUNCTION public.get_key() RETURNS text\n    LANGUAGE plpgsql SECURITY DEFINER\n" +
+				"    AS $$" + functionStub + "$$;\n",
+		},
+		{
+			name:   "sql with custom dollar quote tag",
+			schema: "public",
+			tag:    "get_key(integer)",
+			defn: "CREATE FUNCTION public.get_key(a integer) RETURNS text\n    LANGUAGE sql IMMUTABLE\n" +
+				"    SET search_path TO 'public'\n    AS $_$SELECT 'key' || $1 || '$$'$_$;\n",
+			expected: "CREATE FUNCTION public.get_key(a integer) RETURNS text\n    LANGUAGE plpgsql IMMUTABLE\n" +
+				"    SET search_path TO 'public'\n    AS $$" + functionStub + "$$;\n",
+		},
+		{
+			name:   "sql standard body",
+			schema: "public",
+			tag:    "get_key()",
+			defn: "CREATE FUNCTION public.get_key() RETURNS text\n    LANGUAGE sql\n" +
+				"    BEGIN ATOMIC\n SELECT 'sk_live_123'::text;\nEND;\n",
+			expected: "CREATE FUNCTION public.get_key() RETURNS text\n    LANGUAGE plpgsql\n" +
+				"    AS $$" + functionStub + "$$;\n",
+		},
+		{
+			name:   "c function is kept",
+			schema: "public",
+			tag:    "get_key()",
+			defn: "CREATE FUNCTION public.get_key() RETURNS text\n    LANGUAGE c\n" +
+				"    AS '$libdir/keys', 'get_key';\n",
+			expected: "CREATE FUNCTION public.get_key() RETURNS text\n    LANGUAGE c\n" +
+				"    AS '$libdir/keys', 'get_key';\n",
+		},
+		{
+			name:   "not matched schema",
+			schema: "audit",
+			tag:    "get_key()",
+			defn: "CREATE FUNCTION audit.get_key() RETURNS text\n    LANGUAGE sql\n" +
+				"    AS $$SELECT 'sk_live_123'$$;\n",
+			expected: "CREATE FUNCTION audit.get_key() RETURNS text\n    LANGUAGE sql\n" +
+				"    AS $$SELECT 'sk_live_123'$$;\n",
+		},
+	}
+	r, err := NewRewriter(&domains.SchemaRewrite{
+		FunctionBodies: []*domains.SchemaRewriteObject{{Schema: "^public$", Name: "^get_"}},
+	})
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := r.Rewrite([]*toc.Entry{newEntry(1, "FUNCTION", tt.schema, tt.tag, "app", tt.defn)})
+			require.Len(t, res, 1)
+			assert.Equal(t, tt.expected, *res[0].Defn)
+		})
+	}
+}
+
+func TestRewriter_Rules(t *testing.T) {
+	r, err := NewRewriter(&domains.SchemaRewrite{
+		Rules: []*domains.SchemaRewriteRule{
+			{Types: []string{"view"}, Pattern: `sk_live_[A-Za-z0-9]+`, Replacement: "sk_live_redacted"},
+			{Pattern: `internal_(\w+)`, Replacement: "ext_$1"},
+		},
+	})
+	require.NoError(t, err)
+	view := newEntry(1, "VIEW", "public", "keys", "app", "CREATE VIEW public.keys AS SELECT 'sk_live_abc' AS key;\n")
+	view.DropStmt = toc.NewObj("DROP VIEW public.keys;\n")
+	table := newEntry(2, "TABLE", "public", "internal_users", "app", "CREATE TABLE public.internal_users (key text DEFAULT 'sk_live_abc');\n")
+	table.DropStmt = toc.NewObj("DROP TABLE public.internal_users;\n")
+
+	data := newEntry(3, "TABLE DATA", "public", "internal_users", "app", "", 2)
+	data.CopyStmt = toc.NewObj("COPY public.internal_users (key) FROM stdin;\n")
+
+	res := r.Rewrite([]*toc.Entry{view, table, data})
+	require.Len(t, res, 3)
+	assert.Equal(t, "CREATE VIEW public.keys AS SELECT 'sk_live_redacted' AS key;\n", *res[0].Defn)
+	assert.Equal(t, "keys", *res[0].Tag)
+	assert.Equal(t, "CREATE TABLE public.ext_users (key text DEFAULT 'sk_live_abc');\n", *res[1].Defn)
+	assert.Equal(t, "DROP TABLE public.ext_users;\n", *res[1].DropStmt)
+	assert.Equal(t, "public", *res[1].Namespace)
+	assert.Equal(t, "ext_users", *res[1].Tag)
+	// The table data entry is restored into the renamed table
+	assert.Equal(t, "COPY public.ext_users (key) FROM stdin;\n", *res[2].CopyStmt)
+	assert.Equal(t, "public", *res[2].Namespace)
+	assert.Equal(t, "ext_users", *res[2].Tag)
+}
+
+func TestRewriter_RulesSchemaRename(t *testing.T) {
+	r, err := NewRewriter(&domains.SchemaRewrite{
+		Rules: []*domains.SchemaRewriteRule{{Pattern: `\bbilling\b`, Replacement: "accounts"}},
+	})
+	require.NoError(t, err)
+	schema := newEntry(1, "SCHEMA", "", "billing", "app", "CREATE SCHEMA billing;\n")
+	table := newEntry(2, "TABLE", "billing", "invoices", "app", "CREATE TABLE billing.invoices (id integer);\n", 1)
+	data := newEntry(3, "TABLE DATA", "billing", "invoices", "app", "", 2)
+	data.CopyStmt = toc.NewObj("COPY billing.invoices (id) FROM stdin;\n")
+
+	res := r.Rewrite([]*toc.Entry{schema, table, data})
+	require.Len(t, res, 3)
+	assert.Equal(t, "CREATE SCHEMA accounts;\n", *res[0].Defn)
+	assert.Equal(t, "accounts", *res[0].Tag)
+	for _, e := range res[1:] {
+		assert.Equal(t, "accounts", *e.Namespace)
+		assert.Equal(t, "invoices", *e.Tag)
+	}
+	assert.Equal(t, "COPY accounts.invoices (id) FROM stdin;\n", *res[2].CopyStmt)
+}
+
+func TestRewriter_RulesTocRoundTrip(t *testing.T) {
+	r, err := NewRewriter(&domains.SchemaRewrite{
+		Rules: []*domains.SchemaRewriteRule{{Pattern: `internal_(\w+)`, Replacement: "ext_$1"}},
+	})
+	require.NoError(t, err)
+	table := newEntry(1, "TABLE", "public", "internal_users", "app", "CREATE TABLE public.internal_users (id integer);\n")
+	table.Section = toc.SectionPreData
+	data := newEntry(2, "TABLE DATA", "public", "internal_users", "app", "", 1)
+	data.Section = toc.SectionData
+	data.CopyStmt = toc.NewObj("COPY public.internal_users (id) FROM stdin;\n")
+	data.FileName = toc.NewObj("2.dat.gz")
+
+	dbName := "test"
+	version := "16.2"
+	src := &toc.Toc{
+		Header: &toc.Header{
+			VersionMajor:         1,
+			VersionMinor:         15,
+			Version:              toc.BackupVersions["1.15"],
+			IntSize:              4,
+			OffSize:              8,
+			Format:               toc.ArchTar,
+			ArchDbName:           &dbName,
+			ArchiveRemoteVersion: &version,
+			ArchiveDumpVersion:   &version,
+		},
+		Entries: r.Rewrite([]*toc.Entry{table, data}),
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, toc.NewWriter(buf).Write(src))
+	res, err := toc.NewReader(buf).Read()
+	require.NoError(t, err)
+
+	// The restore filters and the INSERT statements use the entry names, so they must match the rewritten
+	// definition and COPY statement
+	require.Len(t, res.Entries, 2)
+	for _, e := range res.Entries {
+		assert.Equal(t, "public", *e.Namespace)
+		assert.Equal(t, "ext_users", *e.Tag)
+	}
+	assert.Equal(t, "CREATE TABLE public.ext_users (id integer);\n", *res.Entries[0].Defn)
+	assert.Equal(t, "COPY public.ext_users (id) FROM stdin;\n", *res.Entries[1].CopyStmt)
+}
@@ -0,0 +1,49 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import "time"
+
+// ClusterDatabase - the database properties that are required to recreate it before the restoration
+type ClusterDatabase struct {
+	Name     string `yaml:"name" json:"name"`
+	Owner    string `yaml:"owner" json:"owner"`
+	Encoding string `yaml:"encoding" json:"encoding"`
+	Collate  string `yaml:"collate" json:"collate"`
+	Ctype    string `yaml:"ctype" json:"ctype"`
+	// LocaleProvider - libc, icu or builtin. It is empty in the dumps made before the locale provider was collected
+	LocaleProvider string `yaml:"localeProvider,omitempty" json:"localeProvider,omitempty"`
+	// Locale - the locale of the icu or builtin locale provider
+	Locale     string `yaml:"locale,omitempty" json:"locale,omitempty"`
+	Tablespace string `yaml:"tablespace" json:"tablespace"`
+}
+
+// ClusterMetadata - metadata of the cluster dump. Each database is dumped into the sub directory with its name
+type ClusterMetadata struct {
+	StartedAt     time.Time          `yaml:"startedAt" json:"startedAt"`
+	CompletedAt   time.Time          `yaml:"completedAt" json:"completedAt"`
+	DumpedFrom    string             `yaml:"dumpedFrom" json:"dumpedFrom"`
+	RolePasswords bool               `yaml:"rolePasswords" json:"rolePasswords"`
+	Databases     []*ClusterDatabase `yaml:"databases" json:"databases"`
+}
+
+func (cm *ClusterMetadata) GetDatabase(name string) *ClusterDatabase {
+	for _, db := range cm.Databases {
+		if db.Name == name {
+			return db
+		}
+	}
+	return nil
+}
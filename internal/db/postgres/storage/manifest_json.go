@@ -0,0 +1,84 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"time"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/export"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+type ManifestColumn struct {
+	Name     string      `json:"name" yaml:"name"`
+	TypeName string      `json:"typeName" yaml:"typeName"`
+	TypeOid  toolkit.Oid `json:"typeOid" yaml:"typeOid"`
+	NotNull  bool        `json:"notNull" yaml:"notNull"`
+}
+
+type ManifestFile struct {
+	Name           string `json:"name" yaml:"name"`
+	Rows           uint64 `json:"rows" yaml:"rows"`
+	OriginalSize   int64  `json:"originalSize" yaml:"originalSize"`
+	CompressedSize int64  `json:"compressedSize" yaml:"compressedSize"`
+}
+
+type ManifestTable struct {
+	Schema  string            `json:"schema" yaml:"schema"`
+	Name    string            `json:"name" yaml:"name"`
+	Oid     toolkit.Oid       `json:"oid" yaml:"oid"`
+	Rows    uint64            `json:"rows" yaml:"rows"`
+	Columns []*ManifestColumn `json:"columns" yaml:"columns"`
+	// ParquetSchema - the parquet schema of the files. It is set only for parquet format
+	ParquetSchema string          `json:"parquetSchema,omitempty" yaml:"parquetSchema,omitempty"`
+	Files         []*ManifestFile `json:"files" yaml:"files"`
+}
+
+// Manifest - the list of exported files and their schemas. It is stored instead of toc.dat for the export
+type Manifest struct {
+	StartedAt    time.Time        `json:"startedAt" yaml:"startedAt"`
+	CompletedAt  time.Time        `json:"completedAt" yaml:"completedAt"`
+	Format       string           `json:"format" yaml:"format"`
+	Compression  string           `json:"compression" yaml:"compression"`
+	Transformers []*domains.Table `json:"transformers" yaml:"transformers"`
+	Tables       []*ManifestTable `json:"tables" yaml:"tables"`
+}
+
+func NewManifestTable(table *entries.Table, format string, files []*ManifestFile) *ManifestTable {
+	mt := &ManifestTable{
+		Schema:  table.Schema,
+		Name:    table.Name,
+		Oid:     table.Oid,
+		Columns: make([]*ManifestColumn, 0, len(table.Columns)),
+		Files:   files,
+	}
+	for _, c := range table.Columns {
+		mt.Columns = append(mt.Columns, &ManifestColumn{
+			Name:     c.Name,
+			TypeName: c.TypeName,
+			TypeOid:  c.TypeOid,
+			NotNull:  c.NotNull,
+		})
+	}
+	for _, f := range files {
+		mt.Rows += f.Rows
+	}
+	if format == export.ParquetFormat {
+		mt.ParquetSchema = export.GetParquetSchema(table.Columns)
+	}
+	return mt
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/rs/zerolog/log"
 
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
 	"github.com/greenmaskio/greenmask/internal/db/postgres/toc"
 	"github.com/greenmaskio/greenmask/internal/domains"
 	"github.com/greenmaskio/greenmask/pkg/toolkit"
@@ -31,6 +32,8 @@ type ObjectSizeStat struct {
 }
 
 type Header struct {
+	// Engine - the database engine of the dump. It is empty for PostgreSQL dumps
+	Engine          string    `json:"engine,omitempty" yaml:"engine,omitempty"`
 	CreationDate    time.Time `json:"creationDate" yaml:"creationDate"`
 	DbName          string    `json:"dbName" yaml:"dbName"`
 	TocEntriesCount int       `json:"tocEntriesCount" yaml:"tocEntriesCount"`
@@ -73,6 +76,42 @@ type Metadata struct {
 	Cycles            [][]string             `yaml:"cycles" json:"cycles"`
 	TableOidToDumpId  map[toolkit.Oid]int32  `yaml:"table_dump_id" json:"table_dump_id"`
 	DumpIdsToTableOid map[int32]toolkit.Oid  `yaml:"dump_id_table" json:"dump_id_table"`
+	// TransformationErrors - the tables with the transformer errors handled by the on_error policies
+	TransformationErrors []*TransformationErrors `yaml:"transformation_errors,omitempty" json:"transformation_errors,omitempty"`
+}
+
+// TransformationErrors - the transformer errors of the table handled by the on_error policies
+type TransformationErrors struct {
+	Schema      string `json:"schema" yaml:"schema"`
+	Name        string `json:"name" yaml:"name"`
+	DumpId      int32  `json:"dumpId" yaml:"dumpId"`
+	RowsFailed  uint64 `json:"rowsFailed" yaml:"rowsFailed"`
+	RowsSkipped uint64 `json:"rowsSkipped" yaml:"rowsSkipped"`
+	// Transformers - the number of errors by the transformer name
+	Transformers map[string]uint64 `json:"transformers" yaml:"transformers"`
+	// QuarantineObject - the storage object with the failed rows. The original values of the transformed columns
+	// are redacted
+	QuarantineObject string `json:"quarantineObject,omitempty" yaml:"quarantineObject,omitempty"`
+}
+
+// NewTransformationErrors - collects the transformation errors of the tables. The tables without errors are skipped
+func NewTransformationErrors(tables []*entries.Table) []*TransformationErrors {
+	var res []*TransformationErrors
+	for _, t := range tables {
+		if t.Stats == nil || t.Stats.RowsFailed == 0 {
+			continue
+		}
+		res = append(res, &TransformationErrors{
+			Schema:           t.Schema,
+			Name:             t.Name,
+			DumpId:           t.DumpId,
+			RowsFailed:       t.Stats.RowsFailed,
+			RowsSkipped:      t.Stats.RowsSkipped,
+			Transformers:     t.Stats.Errors,
+			QuarantineObject: t.QuarantineObject,
+		})
+	}
+	return res
 }
 
 func NewMetadata(
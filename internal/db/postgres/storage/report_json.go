@@ -0,0 +1,125 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+)
+
+const ReportDigestAlgorithm = "sha256"
+
+var ErrReportDigestMismatch = errors.New("report digest mismatch")
+
+type ColumnReport struct {
+	Name            string `json:"name" yaml:"name"`
+	RowsTransformed uint64 `json:"rowsTransformed" yaml:"rowsTransformed"`
+	NullsKept       uint64 `json:"nullsKept" yaml:"nullsKept"`
+	Unchanged       uint64 `json:"unchanged" yaml:"unchanged"`
+}
+
+type TableReport struct {
+	Schema          string          `json:"schema" yaml:"schema"`
+	Name            string          `json:"name" yaml:"name"`
+	DumpId          int32           `json:"dumpId" yaml:"dumpId"`
+	RowsSeen        uint64          `json:"rowsSeen" yaml:"rowsSeen"`
+	RowsTransformed uint64          `json:"rowsTransformed" yaml:"rowsTransformed"`
+	Columns         []*ColumnReport `json:"columns" yaml:"columns"`
+}
+
+type ReportData struct {
+	StartedAt   time.Time      `json:"startedAt" yaml:"startedAt"`
+	CompletedAt time.Time      `json:"completedAt" yaml:"completedAt"`
+	Tables      []*TableReport `json:"tables" yaml:"tables"`
+}
+
+// Report - masking audit report of the dump. The digest is an unkeyed hash of the JSON encoded Data. It detects the
+// accidental report corruption, but it does not prove the report was not tampered with since anyone who edits the
+// report can recalculate it
+type Report struct {
+	Data            ReportData `json:"data" yaml:"data"`
+	DigestAlgorithm string     `json:"digestAlgorithm" yaml:"digestAlgorithm"`
+	Digest          string     `json:"digest" yaml:"digest"`
+}
+
+func NewReport(startedAt, completedAt time.Time, tables []*entries.Table) (*Report, error) {
+	data := ReportData{
+		StartedAt:   startedAt,
+		CompletedAt: completedAt,
+		Tables:      make([]*TableReport, 0, len(tables)),
+	}
+	for _, t := range tables {
+		if t.Stats == nil {
+			continue
+		}
+		tr := &TableReport{
+			Schema:          t.Schema,
+			Name:            t.Name,
+			DumpId:          t.DumpId,
+			RowsSeen:        t.Stats.RowsSeen,
+			RowsTransformed: t.Stats.RowsTransformed,
+			Columns:         make([]*ColumnReport, 0, len(t.Stats.Columns)),
+		}
+		for _, c := range t.Stats.Columns {
+			tr.Columns = append(tr.Columns, &ColumnReport{
+				Name:            c.Name,
+				RowsTransformed: c.RowsTransformed,
+				NullsKept:       c.NullsKept,
+				Unchanged:       c.Unchanged,
+			})
+		}
+		data.Tables = append(data.Tables, tr)
+	}
+
+	digest, err := data.digest()
+	if err != nil {
+		return nil, err
+	}
+	return &Report{
+		Data:            data,
+		DigestAlgorithm: ReportDigestAlgorithm,
+		Digest:          digest,
+	}, nil
+}
+
+// Verify - recalculates the digest of the report data and compares it with the stored one
+func (r *Report) Verify() error {
+	if r.DigestAlgorithm != ReportDigestAlgorithm {
+		return fmt.Errorf("unsupported report digest algorithm \"%s\"", r.DigestAlgorithm)
+	}
+	digest, err := r.Data.digest()
+	if err != nil {
+		return err
+	}
+	if digest != r.Digest {
+		return ErrReportDigestMismatch
+	}
+	return nil
+}
+
+func (rd *ReportData) digest() (string, error) {
+	data, err := json.Marshal(rd)
+	if err != nil {
+		return "", fmt.Errorf("error encoding report data: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
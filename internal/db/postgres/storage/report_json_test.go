@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestReport_Verify(t *testing.T) {
+	tables := []*entries.Table{
+		{
+			Table:  &toolkit.Table{Schema: "public", Name: "users"},
+			DumpId: 10,
+			Stats: &entries.TransformationStats{
+				RowsSeen:        10,
+				RowsTransformed: 8,
+				Columns: []*entries.ColumnTransformationStats{
+					{Name: "email", RowsTransformed: 8, NullsKept: 2, Unchanged: 1},
+				},
+			},
+		},
+		{
+			// Table dumped without transformation is not included into the report
+			Table:  &toolkit.Table{Schema: "public", Name: "orders"},
+			DumpId: 11,
+		},
+	}
+	report, err := NewReport(time.Now(), time.Now(), tables)
+	require.NoError(t, err)
+	require.Len(t, report.Data.Tables, 1)
+	require.NoError(t, report.Verify())
+
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	decoded := &Report{}
+	require.NoError(t, json.Unmarshal(data, decoded))
+	require.NoError(t, decoded.Verify())
+
+	decoded.Data.Tables[0].Columns[0].Unchanged = 0
+	require.ErrorIs(t, decoded.Verify(), ErrReportDigestMismatch)
+}
@@ -59,17 +59,36 @@ type Graph struct {
 	componentsToOriginalVertexes map[int][]int
 	// paths - the subset paths for the tables. The key is the vertex index in the graph and the value is the path for
 	// creating the subset query
-	paths    map[int]*Path
-	edges    []*Edge
-	visited  []int
-	order    []int
-	sscCount int
+	paths map[int]*Path
+	// parentMinimalVertexes - the condensed graph vertexes that are restricted by the parent-minimal closure
+	parentMinimalVertexes []int
+	edges                 []*Edge
+	visited               []int
+	order                 []int
+	sscCount              int
 }
 
 // NewGraph creates a new graph based on the provided tables by finding the references in DB between them
 func NewGraph(
 	ctx context.Context, tx pgx.Tx, tables []*entries.Table, vr []*domains.VirtualReference,
 ) (*Graph, error) {
+	refs := make(map[toolkit.Oid][]*toolkit.Reference, len(tables))
+	for _, table := range tables {
+		tableRefs, err := getReferences(ctx, tx, table.Oid)
+		if err != nil {
+			return nil, fmt.Errorf("error getting references: %w", err)
+		}
+		refs[table.Oid] = tableRefs
+	}
+	return NewGraphFromReferences(tables, refs, vr), nil
+}
+
+// NewGraphFromReferences creates a new graph based on the provided tables and the references between them that
+// were fetched in advance. The key of refs is the oid of the table that owns the foreign key. The references must
+// point to the primary key of the referenced table
+func NewGraphFromReferences(
+	tables []*entries.Table, refs map[toolkit.Oid][]*toolkit.Reference, vr []*domains.VirtualReference,
+) *Graph {
 	graph := make([][]*Edge, len(tables))
 	reversedGraph := make([][]*Edge, len(tables))
 	reversedSimpleGraph := make([][]int, len(tables))
@@ -77,11 +96,7 @@ func NewGraph(
 
 	var edgeIdSequence int
 	for idx, table := range tables {
-		refs, err := getReferences(ctx, tx, table.Oid)
-		if err != nil {
-			return nil, fmt.Errorf("error getting references: %w", err)
-		}
-		for _, ref := range refs {
+		for _, ref := range refs[table.Oid] {
 			referenceTableIdx := slices.IndexFunc(tables, func(t *entries.Table) bool {
 				return t.Name == ref.Name && t.Schema == ref.Schema
 			})
@@ -174,7 +189,7 @@ func NewGraph(
 		reversedGraph:       reversedGraph,
 	}
 	g.buildCondensedGraph()
-	return g, nil
+	return g
 }
 
 func (g *Graph) Tables() []*entries.Table {
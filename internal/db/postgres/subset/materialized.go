@@ -0,0 +1,163 @@
+package subset
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const materializedSubqueryAlias = "__subset_src"
+
+// KeySet - the temporary table with the primary keys of the subsetted table
+type KeySet struct {
+	// Name - the temporary table name
+	Name string
+	// Statements - the statements that create and analyze the temporary table. The creation query may refer to the
+	// key sets created before
+	Statements []string
+}
+
+// SetMaterializedSubsetQueries - sets the subset queries that use the materialised key sets of the subsetted tables
+// instead of joining the whole path to the root tables. It returns the key sets of the subsetted tables in the
+// topological order. The key sets required by the table query must be created in the session before the query
+// execution. The cycles are resolved by the recursive queries and then materialised as well
+func SetMaterializedSubsetQueries(graph *Graph) ([]*KeySet, error) {
+	graph.findSubsetVertexes()
+	keySets := make(map[toolkit.Oid]string)
+	var res []*KeySet
+	// The parents are visited before the children, so each table refers only to already materialised key sets
+	for _, v := range sortCondensedEdges(graph.condensedGraph) {
+		p, ok := graph.paths[v]
+		if !ok {
+			continue
+		}
+		c := graph.scc[v]
+		if c.hasCycle() {
+			graph.generateAndSetQueryForScc(p)
+		} else {
+			t := c.getOneTable()
+			t.Query = graph.generateMaterializedQueryForTable(v, t, keySets)
+		}
+
+		tables := make([]*entries.Table, 0, len(c.tables))
+		for _, t := range c.tables {
+			tables = append(tables, t)
+		}
+		slices.SortFunc(tables, func(a, b *entries.Table) int {
+			return cmp.Compare(a.Oid, b.Oid)
+		})
+		for _, t := range tables {
+			if len(t.PrimaryKey) == 0 {
+				continue
+			}
+			name := getKeySetName(t)
+			res = append(res, &KeySet{
+				Name:       name,
+				Statements: generateKeySetStatements(t, name),
+			})
+			keySets[t.Oid] = name
+		}
+	}
+	graph.setParentMinimalQueries()
+	return res, nil
+}
+
+// RequiredKeySets - returns the key sets the query refers to directly or through the other key sets in the
+// creation order. The key sets must be ordered topologically as returned by SetMaterializedSubsetQueries
+func RequiredKeySets(query string, keySets []*KeySet) []*KeySet {
+	required := make([]bool, len(keySets))
+	// The key set refers only to the key sets created before, so the dependencies are resolved in the reverse order
+	for idx := len(keySets) - 1; idx >= 0; idx-- {
+		ks := keySets[idx]
+		if strings.Contains(query, ks.Name) {
+			required[idx] = true
+			continue
+		}
+		for depIdx := idx + 1; depIdx < len(keySets); depIdx++ {
+			if required[depIdx] && strings.Contains(keySets[depIdx].Statements[0], ks.Name) {
+				required[idx] = true
+				break
+			}
+		}
+	}
+	var res []*KeySet
+	for idx, ks := range keySets {
+		if required[idx] {
+			res = append(res, ks)
+		}
+	}
+	return res
+}
+
+// generateMaterializedQueryForTable - generates the query that checks only the direct references of the table. The
+// referenced subsetted tables are checked by their key sets that already contain the result of the whole path
+func (g *Graph) generateMaterializedQueryForTable(v int, t *entries.Table, keySets map[toolkit.Oid]string) string {
+	conds := slices.Clone(t.SubsetConds)
+	for _, ce := range g.condensedGraph[v] {
+		e := ce.originalEdge
+		parent := e.to.table
+
+		var parentKeys []string
+		for _, k := range e.to.keys {
+			parentKeys = append(parentKeys, fmt.Sprintf(`"%s"`, k.Name))
+		}
+		var source string
+		switch {
+		case keySets[parent.Oid] != "":
+			source = fmt.Sprintf(`SELECT %s FROM %s`, strings.Join(parentKeys, ", "), keySets[parent.Oid])
+		case parent.Query != "":
+			source = fmt.Sprintf(
+				`SELECT %s FROM (%s) AS "%s"`, strings.Join(parentKeys, ", "), parent.Query, materializedSubqueryAlias,
+			)
+		case len(e.from.polymorphicExprs) > 0:
+			// The polymorphic reference is not checked by the foreign key, so the referenced row existence is checked
+			source = fmt.Sprintf(
+				`SELECT %s FROM "%s"."%s"`, strings.Join(parentKeys, ", "), parent.Schema, parent.Name,
+			)
+		default:
+			// The parent is not subsetted so all the rows are present
+			continue
+		}
+
+		var childKeys, nullChecks []string
+		for _, k := range e.from.keys {
+			childKeys = append(childKeys, k.GetKeyReference(t))
+			nullChecks = append(nullChecks, fmt.Sprintf(`%s IS NULL`, k.GetKeyReference(t)))
+		}
+		cond := fmt.Sprintf(`(%s) IN (%s)`, strings.Join(childKeys, ", "), source)
+		if len(e.from.polymorphicExprs) > 0 {
+			cond = fmt.Sprintf(`NOT (%s) OR %s`, strings.Join(e.from.polymorphicExprs, " AND "), cond)
+		}
+		if e.isNullable {
+			cond = fmt.Sprintf(`(%s) OR %s`, strings.Join(nullChecks, " AND "), cond)
+		}
+		conds = append(conds, cond)
+	}
+
+	return fmt.Sprintf(
+		`SELECT "%s"."%s".* FROM "%s"."%s" %s`,
+		t.Schema, t.Name, t.Schema, t.Name, generateWhereClause(conds),
+	)
+}
+
+func getKeySetName(t *entries.Table) string {
+	return fmt.Sprintf(`pg_temp."__subset_keys_%d"`, t.Oid)
+}
+
+func generateKeySetStatements(t *entries.Table, name string) []string {
+	var keys []string
+	for _, k := range t.PrimaryKey {
+		keys = append(keys, fmt.Sprintf(`"%s"`, k))
+	}
+	return []string{
+		fmt.Sprintf(
+			`CREATE TEMP TABLE %s AS SELECT %s FROM (%s) AS "%s"`,
+			name, strings.Join(keys, ", "), t.Query, materializedSubqueryAlias,
+		),
+		fmt.Sprintf(`ANALYZE %s`, name),
+	}
+}
@@ -0,0 +1,108 @@
+package subset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+)
+
+func TestSetMaterializedSubsetQueries(t *testing.T) {
+	customers := newTestTable(1, "customers", false)
+	customers.SubsetConds = []string{`"public"."customers"."id" < 10`}
+	orders := newTestTable(2, "orders", false)
+	items := newTestTable(3, "items", false)
+	products := newTestTable(4, "products", false)
+	g := newTestGraph([]*entries.Table{customers, orders, items, products}, []*testReference{
+		{from: 1, to: 0, keys: NewKeysByColumn([]string{"customer_id"}), isNullable: true},
+		{from: 2, to: 1, keys: NewKeysByColumn([]string{"order_id"})},
+		{from: 2, to: 3, keys: NewKeysByColumn([]string{"product_id"})},
+	})
+
+	keySets, err := SetMaterializedSubsetQueries(g)
+	require.NoError(t, err)
+
+	require.Empty(t, products.Query)
+	require.Equal(t,
+		`SELECT "public"."customers".* FROM "public"."customers" WHERE ( "public"."customers"."id" < 10 )`,
+		customers.Query,
+	)
+	require.Equal(t,
+		`SELECT "public"."orders".* FROM "public"."orders" WHERE ( ("public"."orders"."customer_id" IS NULL) OR `+
+			`("public"."orders"."customer_id") IN (SELECT "id" FROM pg_temp."__subset_keys_1") )`,
+		orders.Query,
+	)
+	require.Equal(t,
+		`SELECT "public"."items".* FROM "public"."items" WHERE `+
+			`( ("public"."items"."order_id") IN (SELECT "id" FROM pg_temp."__subset_keys_2") )`,
+		items.Query,
+	)
+	require.Equal(t, []*KeySet{
+		{
+			Name: `pg_temp."__subset_keys_1"`,
+			Statements: []string{
+				`CREATE TEMP TABLE pg_temp."__subset_keys_1" AS SELECT "id" FROM (` + customers.Query + `) AS "__subset_src"`,
+				`ANALYZE pg_temp."__subset_keys_1"`,
+			},
+		},
+		{
+			Name: `pg_temp."__subset_keys_2"`,
+			Statements: []string{
+				`CREATE TEMP TABLE pg_temp."__subset_keys_2" AS SELECT "id" FROM (` + orders.Query + `) AS "__subset_src"`,
+				`ANALYZE pg_temp."__subset_keys_2"`,
+			},
+		},
+		{
+			Name: `pg_temp."__subset_keys_3"`,
+			Statements: []string{
+				`CREATE TEMP TABLE pg_temp."__subset_keys_3" AS SELECT "id" FROM (` + items.Query + `) AS "__subset_src"`,
+				`ANALYZE pg_temp."__subset_keys_3"`,
+			},
+		},
+	}, keySets)
+
+	require.Empty(t, RequiredKeySets(customers.Query, keySets))
+	require.Empty(t, RequiredKeySets(products.Query, keySets))
+	require.Equal(t, keySets[:1], RequiredKeySets(orders.Query, keySets))
+	// The orders key set refers to the customers key set
+	require.Equal(t, keySets[:2], RequiredKeySets(items.Query, keySets))
+}
+
+func TestRequiredKeySets(t *testing.T) {
+	keySets := []*KeySet{
+		{Name: `pg_temp."__subset_keys_1"`, Statements: []string{`CREATE TEMP TABLE pg_temp."__subset_keys_1" AS SELECT 1`}},
+		{Name: `pg_temp."__subset_keys_12"`, Statements: []string{`CREATE TEMP TABLE pg_temp."__subset_keys_12" AS SELECT 1`}},
+		{
+			Name: `pg_temp."__subset_keys_123"`,
+			Statements: []string{
+				`CREATE TEMP TABLE pg_temp."__subset_keys_123" AS SELECT * FROM pg_temp."__subset_keys_12"`,
+			},
+		},
+	}
+	tests := []struct {
+		name     string
+		query    string
+		expected []*KeySet
+	}{
+		{
+			name:  "not referenced",
+			query: `SELECT * FROM "public"."users"`,
+		},
+		{
+			name:     "name prefix is not matched",
+			query:    `SELECT * FROM pg_temp."__subset_keys_12"`,
+			expected: keySets[1:2],
+		},
+		{
+			name:     "transitive dependency",
+			query:    `SELECT * FROM pg_temp."__subset_keys_123"`,
+			expected: keySets[1:],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, RequiredKeySets(tt.query, keySets))
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package subset
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const (
+	parentMinimalCteName       = "__parent_minimal"
+	parentMinimalNextAlias     = "__parent_minimal_next"
+	parentMinimalSubqueryAlias = "__parent_minimal_src"
+)
+
+// referencedKeysSelection - the selection of the keys that are referenced by the child table rows
+type referencedKeysSelection struct {
+	keys  []string
+	from  string
+	conds []string
+}
+
+// newReferencedKeysSelection - builds the selection of the parent keys referenced by the edge. If the child table
+// has a query the keys are taken from the query result. It returns false if the selection cannot be built, this
+// happens when the child table has a query, the reference contains expressions and the child has no primary key
+func newReferencedKeysSelection(e *Edge) (*referencedKeysSelection, bool) {
+	child := e.from.table
+	hasExpressions := len(e.from.polymorphicExprs) > 0 || slices.ContainsFunc(e.from.keys, func(k *Key) bool {
+		return k.Expression != ""
+	})
+
+	s := &referencedKeysSelection{}
+	switch {
+	case child.Query == "":
+		// The child is dumped in full so all the referenced keys are required
+		s.from = fmt.Sprintf(`FROM "%s"."%s"`, child.Schema, child.Name)
+		for _, k := range e.from.keys {
+			s.keys = append(s.keys, k.GetKeyReference(child))
+		}
+		s.conds = append(s.conds, e.from.polymorphicExprs...)
+	case !hasExpressions:
+		// The child query returns the table columns so the keys might be taken from the query result directly
+		s.from = fmt.Sprintf(`FROM (%s) AS "%s"`, child.Query, parentMinimalSubqueryAlias)
+		for _, k := range e.from.keys {
+			s.keys = append(s.keys, fmt.Sprintf(`"%s"."%s"`, parentMinimalSubqueryAlias, k.Name))
+		}
+	case len(child.PrimaryKey) > 0:
+		// The expressions refer to the child table, so the child rows are matched with the query result by the
+		// primary key
+		s.from = fmt.Sprintf(`FROM "%s"."%s"`, child.Schema, child.Name)
+		for _, k := range e.from.keys {
+			s.keys = append(s.keys, k.GetKeyReference(child))
+		}
+		s.conds = append(s.conds, e.from.polymorphicExprs...)
+		var childKeys, queryKeys []string
+		for _, k := range child.PrimaryKey {
+			childKeys = append(childKeys, fmt.Sprintf(`"%s"."%s"."%s"`, child.Schema, child.Name, k))
+			queryKeys = append(queryKeys, fmt.Sprintf(`"%s"."%s"`, parentMinimalSubqueryAlias, k))
+		}
+		s.conds = append(s.conds, fmt.Sprintf(
+			`(%s) IN (SELECT %s FROM (%s) AS "%s")`,
+			strings.Join(childKeys, ", "),
+			strings.Join(queryKeys, ", "),
+			child.Query,
+			parentMinimalSubqueryAlias,
+		))
+	default:
+		return nil, false
+	}
+
+	if e.isNullable {
+		// The rows with NULL foreign key do not require any parent row
+		for _, k := range s.keys {
+			s.conds = append(s.conds, fmt.Sprintf(`%s IS NOT NULL`, k))
+		}
+	}
+	return s, true
+}
+
+func (s *referencedKeysSelection) generateQuery(selection []string) string {
+	return fmt.Sprintf(
+		`SELECT %s %s %s`,
+		strings.Join(selection, ", "),
+		s.from,
+		generateWhereClause(s.conds),
+	)
+}
+
+// setParentMinimalQueries - restricts the referenced (parent) tables to the rows that are referenced by the
+// subsetted tables. The components are visited in the reversed topological order, so the children queries are
+// already set when the parent query is generated and the restriction is propagated up to the root tables
+func (g *Graph) setParentMinimalQueries() {
+	order := sortCondensedEdges(g.condensedGraph)
+	slices.Reverse(order)
+	for _, v := range order {
+		c := g.scc[v]
+		children := g.reversedCondensedGraph[v]
+		if !isParentMinimalComponent(c) || len(children) == 0 {
+			continue
+		}
+		// The parent is restricted only if at least one child is restricted, otherwise the whole table is required
+		if !slices.ContainsFunc(children, func(e *CondensedEdge) bool {
+			return e.originalEdge.from.table.Query != ""
+		}) {
+			continue
+		}
+
+		var queries map[toolkit.Oid]string
+		var ok bool
+		if c.hasCycle() {
+			queries, ok = generateParentMinimalQueriesForScc(c, children)
+		} else {
+			table := c.getOneTable()
+			var query string
+			query, ok = generateParentMinimalQuery(table, children)
+			queries = map[toolkit.Oid]string{table.Oid: query}
+		}
+		if !ok {
+			for _, t := range c.tables {
+				log.Debug().
+					Str("Schema", t.Schema).
+					Str("Table", t.Name).
+					Msg("unable to restrict parent table by the referenced keys: table will be dumped in full")
+			}
+			continue
+		}
+		for _, t := range c.tables {
+			t.Query = queries[t.Oid]
+		}
+		g.parentMinimalVertexes = append(g.parentMinimalVertexes, v)
+	}
+}
+
+// isParentMinimalComponent - checks that all the tables in the component might be restricted by the referenced
+// keys. The tables that already have a query are left as is
+func isParentMinimalComponent(c *Component) bool {
+	for _, t := range c.tables {
+		if !t.SubsetParentMinimal || t.Query != "" || len(t.PrimaryKey) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// generateParentMinimalQuery - generates query that selects the parent table rows referenced by any child
+func generateParentMinimalQuery(table *entries.Table, children []*CondensedEdge) (string, bool) {
+	var conds []string
+	for _, ce := range children {
+		e := ce.originalEdge
+		s, ok := newReferencedKeysSelection(e)
+		if !ok {
+			return "", false
+		}
+		var parentKeys []string
+		for _, k := range e.to.keys {
+			parentKeys = append(parentKeys, k.GetKeyReference(table))
+		}
+		conds = append(conds, fmt.Sprintf(`(%s) IN (%s)`, strings.Join(parentKeys, ", "), s.generateQuery(s.keys)))
+	}
+	return fmt.Sprintf(
+		`SELECT * FROM "%s"."%s" WHERE %s`,
+		table.Schema, table.Name, strings.Join(conds, " OR "),
+	), true
+}
+
+// generateParentMinimalQueriesForScc - generates the queries for the tables in the cycle. The referenced keys are
+// collected by the recursive CTE: it starts from the keys referenced by the children outside the component and
+// follows the edges inside the component. The keys of the different tables are stored as text arrays with the
+// table oid
+func generateParentMinimalQueriesForScc(c *Component, children []*CondensedEdge) (map[toolkit.Oid]string, bool) {
+	var initialQueries []string
+	for _, ce := range children {
+		e := ce.originalEdge
+		s, ok := newReferencedKeysSelection(e)
+		if !ok {
+			return nil, false
+		}
+		initialQueries = append(initialQueries, s.generateQuery(
+			[]string{fmt.Sprintf(`%d::OID`, e.to.table.Oid), generateTextArray(s.keys)},
+		))
+	}
+
+	var vertexes []int
+	for v := range c.componentGraph {
+		vertexes = append(vertexes, v)
+	}
+	sort.Ints(vertexes)
+	var recursiveQueries []string
+	for _, v := range vertexes {
+		for _, e := range c.componentGraph[v] {
+			// The component tables have no query, so the keys are selected from the table itself
+			from := e.from.table
+			s, ok := newReferencedKeysSelection(e)
+			if !ok {
+				return nil, false
+			}
+			var fromKeys []string
+			for _, k := range from.PrimaryKey {
+				fromKeys = append(fromKeys, fmt.Sprintf(`"%s"."%s"."%s"`, from.Schema, from.Name, k))
+			}
+			s.conds = append(
+				s.conds,
+				fmt.Sprintf(`"%s"."table" = %d::OID`, parentMinimalCteName, from.Oid),
+				fmt.Sprintf(`%s = "%s"."keys"`, generateTextArray(fromKeys), parentMinimalCteName),
+			)
+			recursiveQueries = append(recursiveQueries, s.generateQuery([]string{
+				fmt.Sprintf(`%d::OID AS "table"`, e.to.table.Oid),
+				fmt.Sprintf(`%s AS "keys"`, generateTextArray(s.keys)),
+			}))
+		}
+	}
+
+	cte := fmt.Sprintf(
+		`WITH RECURSIVE "%s"("table", "keys") AS (%s UNION SELECT "%s"."table", "%s"."keys" FROM "%s" CROSS JOIN LATERAL (%s) AS "%s")`,
+		parentMinimalCteName,
+		strings.Join(initialQueries, " UNION "),
+		parentMinimalNextAlias,
+		parentMinimalNextAlias,
+		parentMinimalCteName,
+		strings.Join(recursiveQueries, " UNION ALL "),
+		parentMinimalNextAlias,
+	)
+
+	res := make(map[toolkit.Oid]string, len(c.tables))
+	for _, t := range c.tables {
+		var keys []string
+		for _, k := range t.PrimaryKey {
+			keys = append(keys, fmt.Sprintf(`"%s"."%s"."%s"`, t.Schema, t.Name, k))
+		}
+		res[t.Oid] = fmt.Sprintf(
+			`%s SELECT * FROM "%s"."%s" WHERE %s IN (SELECT "keys" FROM "%s" WHERE "table" = %d::OID)`,
+			cte, t.Schema, t.Name, generateTextArray(keys), parentMinimalCteName, t.Oid,
+		)
+	}
+	return res, true
+}
+
+func generateTextArray(keys []string) string {
+	textKeys := make([]string, 0, len(keys))
+	for _, k := range keys {
+		textKeys = append(textKeys, fmt.Sprintf(`(%s)::TEXT`, k))
+	}
+	return fmt.Sprintf(`ARRAY[%s]`, strings.Join(textKeys, ", "))
+}
@@ -0,0 +1,189 @@
+package subset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+type testReference struct {
+	from, to   int
+	keys       []*Key
+	isNullable bool
+	exprs      []string
+}
+
+func newTestTable(oid toolkit.Oid, name string, parentMinimal bool) *entries.Table {
+	return &entries.Table{
+		Table: &toolkit.Table{
+			Oid:        oid,
+			Schema:     "public",
+			Name:       name,
+			PrimaryKey: []string{"id"},
+		},
+		SubsetParentMinimal: parentMinimal,
+	}
+}
+
+func newTestGraph(tables []*entries.Table, refs []*testReference) *Graph {
+	g := &Graph{
+		tables:              tables,
+		graph:               make([][]*Edge, len(tables)),
+		reversedGraph:       make([][]*Edge, len(tables)),
+		reversedSimpleGraph: make([][]int, len(tables)),
+		paths:               make(map[int]*Path),
+		visited:             make([]int, len(tables)),
+	}
+	for id, r := range refs {
+		e := NewEdge(
+			id, r.to, r.isNullable,
+			NewTableLink(r.from, tables[r.from], r.keys, r.exprs),
+			NewTableLink(r.to, tables[r.to], NewKeysByColumn(tables[r.to].PrimaryKey), nil),
+		)
+		g.graph[r.from] = append(g.graph[r.from], e)
+		g.reversedSimpleGraph[r.to] = append(g.reversedSimpleGraph[r.to], r.from)
+		g.edges = append(g.edges, e)
+	}
+	g.buildCondensedGraph()
+	return g
+}
+
+func TestGraph_setParentMinimalQueries(t *testing.T) {
+	t.Run("chain", func(t *testing.T) {
+		customers := newTestTable(1, "customers", true)
+		orders := newTestTable(2, "orders", true)
+		orders.Query = `SELECT * FROM "public"."orders" WHERE id < 10`
+		items := newTestTable(3, "items", true)
+		g := newTestGraph([]*entries.Table{customers, orders, items}, []*testReference{
+			{from: 1, to: 0, keys: NewKeysByColumn([]string{"customer_id"})},
+			{from: 2, to: 1, keys: NewKeysByColumn([]string{"order_id"}), isNullable: true},
+		})
+
+		g.setParentMinimalQueries()
+
+		require.Empty(t, items.Query)
+		require.Equal(t, `SELECT * FROM "public"."orders" WHERE id < 10`, orders.Query)
+		require.Equal(t,
+			`SELECT * FROM "public"."customers" WHERE ("public"."customers"."id") IN `+
+				`(SELECT "__parent_minimal_src"."customer_id" FROM `+
+				`(SELECT * FROM "public"."orders" WHERE id < 10) AS "__parent_minimal_src" WHERE TRUE)`,
+			customers.Query,
+		)
+	})
+
+	t.Run("propagated to the root", func(t *testing.T) {
+		regions := newTestTable(1, "regions", true)
+		customers := newTestTable(2, "customers", true)
+		orders := newTestTable(3, "orders", true)
+		orders.Query = `SELECT * FROM "public"."orders" WHERE id < 10`
+		g := newTestGraph([]*entries.Table{regions, customers, orders}, []*testReference{
+			{from: 1, to: 0, keys: NewKeysByColumn([]string{"region_id"}), isNullable: true},
+			{from: 2, to: 1, keys: NewKeysByColumn([]string{"customer_id"})},
+		})
+
+		g.setParentMinimalQueries()
+
+		require.NotEmpty(t, customers.Query)
+		require.Equal(t,
+			`SELECT * FROM "public"."regions" WHERE ("public"."regions"."id") IN `+
+				`(SELECT "__parent_minimal_src"."region_id" FROM (`+customers.Query+`) AS "__parent_minimal_src" `+
+				`WHERE ( "__parent_minimal_src"."region_id" IS NOT NULL ))`,
+			regions.Query,
+		)
+	})
+
+	t.Run("full child and virtual reference", func(t *testing.T) {
+		products := newTestTable(1, "products", true)
+		orders := newTestTable(2, "orders", true)
+		orders.Query = `SELECT * FROM "public"."orders" WHERE id < 10`
+		reviews := newTestTable(3, "reviews", true)
+		g := newTestGraph([]*entries.Table{products, orders, reviews}, []*testReference{
+			{from: 1, to: 0, keys: NewKeysByColumn([]string{"product_id"})},
+			{
+				from: 2, to: 0, isNullable: true,
+				keys:  []*Key{{Name: "object_id"}},
+				exprs: []string{`"public"."reviews"."object_type" = 'product'`},
+			},
+		})
+
+		g.setParentMinimalQueries()
+
+		require.Empty(t, reviews.Query)
+		require.Equal(t,
+			`SELECT * FROM "public"."products" WHERE ("public"."products"."id") IN `+
+				`(SELECT "__parent_minimal_src"."product_id" FROM `+
+				`(SELECT * FROM "public"."orders" WHERE id < 10) AS "__parent_minimal_src" WHERE TRUE) OR `+
+				`("public"."products"."id") IN (SELECT "public"."reviews"."object_id" FROM "public"."reviews" `+
+				`WHERE ( "public"."reviews"."object_type" = 'product' ) AND `+
+				`( "public"."reviews"."object_id" IS NOT NULL ))`,
+			products.Query,
+		)
+	})
+
+	t.Run("disabled for table", func(t *testing.T) {
+		customers := newTestTable(1, "customers", false)
+		orders := newTestTable(2, "orders", true)
+		orders.Query = `SELECT * FROM "public"."orders" WHERE id < 10`
+		g := newTestGraph([]*entries.Table{customers, orders}, []*testReference{
+			{from: 1, to: 0, keys: NewKeysByColumn([]string{"customer_id"})},
+		})
+
+		g.setParentMinimalQueries()
+
+		require.Empty(t, customers.Query)
+	})
+
+	t.Run("no restricted children", func(t *testing.T) {
+		customers := newTestTable(1, "customers", true)
+		orders := newTestTable(2, "orders", true)
+		g := newTestGraph([]*entries.Table{customers, orders}, []*testReference{
+			{from: 1, to: 0, keys: NewKeysByColumn([]string{"customer_id"})},
+		})
+
+		g.setParentMinimalQueries()
+
+		require.Empty(t, customers.Query)
+		require.Empty(t, orders.Query)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		employees := newTestTable(1, "employees", true)
+		departments := newTestTable(2, "departments", true)
+		tasks := newTestTable(3, "tasks", true)
+		tasks.Query = `SELECT * FROM "public"."tasks" WHERE id < 10`
+		g := newTestGraph([]*entries.Table{employees, departments, tasks}, []*testReference{
+			{from: 0, to: 1, keys: NewKeysByColumn([]string{"department_id"})},
+			{from: 1, to: 0, keys: NewKeysByColumn([]string{"manager_id"}), isNullable: true},
+			{from: 2, to: 0, keys: NewKeysByColumn([]string{"employee_id"})},
+		})
+
+		g.setParentMinimalQueries()
+
+		cte := `WITH RECURSIVE "__parent_minimal"("table", "keys") AS (` +
+			`SELECT 1::OID, ARRAY[("__parent_minimal_src"."employee_id")::TEXT] FROM ` +
+			`(SELECT * FROM "public"."tasks" WHERE id < 10) AS "__parent_minimal_src" WHERE TRUE UNION ` +
+			`SELECT "__parent_minimal_next"."table", "__parent_minimal_next"."keys" FROM "__parent_minimal" ` +
+			`CROSS JOIN LATERAL (` +
+			`SELECT 2::OID AS "table", ARRAY[("public"."employees"."department_id")::TEXT] AS "keys" ` +
+			`FROM "public"."employees" WHERE ( "__parent_minimal"."table" = 1::OID ) AND ` +
+			`( ARRAY[("public"."employees"."id")::TEXT] = "__parent_minimal"."keys" ) UNION ALL ` +
+			`SELECT 1::OID AS "table", ARRAY[("public"."departments"."manager_id")::TEXT] AS "keys" ` +
+			`FROM "public"."departments" WHERE ( "public"."departments"."manager_id" IS NOT NULL ) AND ` +
+			`( "__parent_minimal"."table" = 2::OID ) AND ` +
+			`( ARRAY[("public"."departments"."id")::TEXT] = "__parent_minimal"."keys" )` +
+			`) AS "__parent_minimal_next")`
+		require.Equal(t,
+			cte+` SELECT * FROM "public"."employees" WHERE ARRAY[("public"."employees"."id")::TEXT] IN `+
+				`(SELECT "keys" FROM "__parent_minimal" WHERE "table" = 1::OID)`,
+			employees.Query,
+		)
+		require.Equal(t,
+			cte+` SELECT * FROM "public"."departments" WHERE ARRAY[("public"."departments"."id")::TEXT] IN `+
+				`(SELECT "keys" FROM "__parent_minimal" WHERE "table" = 2::OID)`,
+			departments.Query,
+		)
+	})
+}
@@ -0,0 +1,150 @@
+package subset
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	// PlanTableModeFull - the table is dumped in full
+	PlanTableModeFull = "full"
+	// PlanTableModeSubsetConds - the table is filtered by its own subset conditions
+	PlanTableModeSubsetConds = "subset_conds"
+	// PlanTableModePath - the table is filtered through the references to the subsetted tables
+	PlanTableModePath = "path"
+	// PlanTableModeParentMinimal - the table is restricted to the rows referenced by the subsetted tables
+	PlanTableModeParentMinimal = "parent_minimal"
+	// PlanTableModeQuery - the table is dumped by the custom query
+	PlanTableModeQuery = "query"
+)
+
+// Plan - description of the subset plan built by the graph. It is used for the subset plan review
+type Plan struct {
+	Tables     []*PlanTable     `json:"tables"`
+	References []*PlanReference `json:"references"`
+	// Components - strongly connected components that contain cycles
+	Components []*PlanComponent `json:"components"`
+}
+
+type PlanTable struct {
+	Schema      string   `json:"schema"`
+	Name        string   `json:"name"`
+	Component   int      `json:"component"`
+	Mode        string   `json:"mode"`
+	SubsetConds []string `json:"subset_conds,omitempty"`
+	// Path - the references through which the subset conditions are applied to the table
+	Path  []*PlanReference `json:"path,omitempty"`
+	Query string           `json:"query,omitempty"`
+	// Before - estimation of the table rows without subset. It is set by the caller
+	Before *PlanEstimation `json:"before,omitempty"`
+	// After - estimation of the table rows with subset. It is set by the caller
+	After *PlanEstimation `json:"after,omitempty"`
+}
+
+// FullName - returns the table name with schema
+func (pt *PlanTable) FullName() string {
+	return fmt.Sprintf("%s.%s", pt.Schema, pt.Name)
+}
+
+type PlanEstimation struct {
+	Rows int64 `json:"rows"`
+	// Cost - total cost of the query plan. It is empty for the exact rows count
+	Cost  float64 `json:"cost,omitempty"`
+	Exact bool    `json:"exact"`
+}
+
+type PlanReference struct {
+	From             string   `json:"from"`
+	To               string   `json:"to"`
+	Keys             []string `json:"keys"`
+	IsNullable       bool     `json:"is_nullable"`
+	PolymorphicExprs []string `json:"polymorphic_exprs,omitempty"`
+}
+
+type PlanComponent struct {
+	Id     int      `json:"id"`
+	Tables []string `json:"tables"`
+	// Cycles - the tables in the cycle order. The first table is repeated at the end
+	Cycles [][]string `json:"cycles"`
+}
+
+// Plan - describes the graph and the subset queries. It must be called after SetSubsetQueries
+func (g *Graph) Plan() *Plan {
+	vertexesToComponents := make(map[int]int, len(g.tables))
+	for c, vertexes := range g.componentsToOriginalVertexes {
+		for _, v := range vertexes {
+			vertexesToComponents[v] = c
+		}
+	}
+
+	plan := &Plan{}
+	for _, e := range g.edges {
+		plan.References = append(plan.References, newPlanReference(e))
+	}
+
+	for v, t := range g.tables {
+		c := vertexesToComponents[v]
+		pt := &PlanTable{
+			Schema:      t.Schema,
+			Name:        t.Name,
+			Component:   c,
+			Mode:        PlanTableModeFull,
+			SubsetConds: t.SubsetConds,
+			Query:       t.Query,
+		}
+		if p, ok := g.paths[c]; ok {
+			for _, e := range p.edges {
+				pt.Path = append(pt.Path, newPlanReference(e.originalEdge))
+			}
+		}
+		switch {
+		case len(t.SubsetConds) > 0:
+			pt.Mode = PlanTableModeSubsetConds
+		case len(pt.Path) > 0:
+			pt.Mode = PlanTableModePath
+		case slices.Contains(g.parentMinimalVertexes, c):
+			pt.Mode = PlanTableModeParentMinimal
+		case t.Query != "":
+			pt.Mode = PlanTableModeQuery
+		}
+		plan.Tables = append(plan.Tables, pt)
+	}
+
+	for _, c := range g.scc {
+		if !c.hasCycle() {
+			continue
+		}
+		pc := &PlanComponent{Id: c.id}
+		for _, v := range g.componentsToOriginalVertexes[c.id] {
+			pc.Tables = append(pc.Tables, fmt.Sprintf("%s.%s", g.tables[v].Schema, g.tables[v].Name))
+		}
+		for _, cycle := range c.cycles {
+			var tables []string
+			for _, e := range cycle {
+				tables = append(tables, fmt.Sprintf("%s.%s", e.from.table.Schema, e.from.table.Name))
+			}
+			last := cycle[len(cycle)-1].to.table
+			tables = append(tables, fmt.Sprintf("%s.%s", last.Schema, last.Name))
+			pc.Cycles = append(pc.Cycles, tables)
+		}
+		plan.Components = append(plan.Components, pc)
+	}
+	return plan
+}
+
+func newPlanReference(e *Edge) *PlanReference {
+	r := &PlanReference{
+		From:             fmt.Sprintf("%s.%s", e.from.table.Schema, e.from.table.Name),
+		To:               fmt.Sprintf("%s.%s", e.to.table.Schema, e.to.table.Name),
+		IsNullable:       e.isNullable,
+		PolymorphicExprs: e.from.polymorphicExprs,
+	}
+	for _, k := range e.from.keys {
+		if k.Expression != "" {
+			r.Keys = append(r.Keys, k.Expression)
+			continue
+		}
+		r.Keys = append(r.Keys, k.Name)
+	}
+	return r
+}
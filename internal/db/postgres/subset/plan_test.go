@@ -0,0 +1,51 @@
+package subset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/entries"
+)
+
+func TestGraph_Plan(t *testing.T) {
+	customers := newTestTable(1, "customers", true)
+	orders := newTestTable(2, "orders", true)
+	orders.SubsetConds = []string{`"public"."orders"."id" < 10`}
+	items := newTestTable(3, "items", true)
+	employees := newTestTable(4, "employees", false)
+	departments := newTestTable(5, "departments", false)
+	g := newTestGraph([]*entries.Table{customers, orders, items, employees, departments}, []*testReference{
+		{from: 1, to: 0, keys: NewKeysByColumn([]string{"customer_id"})},
+		{from: 2, to: 1, keys: NewKeysByColumn([]string{"order_id"}), isNullable: true},
+		{from: 3, to: 4, keys: NewKeysByColumn([]string{"department_id"})},
+		{from: 4, to: 3, keys: NewKeysByColumn([]string{"manager_id"}), isNullable: true},
+	})
+	require.NoError(t, SetSubsetQueries(g))
+
+	plan := g.Plan()
+
+	require.Len(t, plan.Tables, 5)
+	modes := make(map[string]string)
+	for _, pt := range plan.Tables {
+		modes[pt.FullName()] = pt.Mode
+	}
+	require.Equal(t, map[string]string{
+		"public.customers":   PlanTableModeParentMinimal,
+		"public.orders":      PlanTableModeSubsetConds,
+		"public.items":       PlanTableModePath,
+		"public.employees":   PlanTableModeFull,
+		"public.departments": PlanTableModeFull,
+	}, modes)
+
+	require.Equal(t, []*PlanReference{
+		{From: "public.items", To: "public.orders", Keys: []string{"order_id"}, IsNullable: true},
+	}, plan.Tables[2].Path)
+	require.Equal(t, items.Query, plan.Tables[2].Query)
+	require.Len(t, plan.References, 4)
+
+	require.Len(t, plan.Components, 1)
+	require.ElementsMatch(t, []string{"public.employees", "public.departments"}, plan.Components[0].Tables)
+	require.Len(t, plan.Components[0].Cycles, 1)
+	require.Len(t, plan.Components[0].Cycles[0], 3)
+}
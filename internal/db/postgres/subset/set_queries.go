@@ -9,5 +9,6 @@ func SetSubsetQueries(graph *Graph) error {
 			graph.generateAndSetQueryForTable(p)
 		}
 	}
+	graph.setParentMinimalQueries()
 	return nil
 }
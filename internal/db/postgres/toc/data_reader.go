@@ -0,0 +1,150 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DataReader - reads the data blocks of the custom format archive. The blocks follow the TOC and each of them
+// consists of the block type, the dump id of the entry and the data chunks. The data chunks are returned as is,
+// so they must be decompressed by the caller if the archive is compressed
+type DataReader struct {
+	r         *Reader
+	blockType byte
+	// remaining - remaining bytes of the current chunk
+	remaining int32
+	// done - the current block data is read completely
+	done bool
+}
+
+func NewDataReader(r io.Reader, header *Header) *DataReader {
+	return &DataReader{
+		r: &Reader{
+			r:       r,
+			intSize: header.IntSize,
+			offSize: header.OffSize,
+			version: header.Version,
+			format:  header.Format,
+		},
+		done: true,
+	}
+}
+
+// Next - reads the header of the next data block and returns its type and dump id. The data of the previous block
+// is skipped if it was not read completely. It returns io.EOF when there are no more blocks
+func (dr *DataReader) Next() (byte, int32, error) {
+	if err := dr.skip(); err != nil {
+		return 0, 0, fmt.Errorf("cannot skip block data: %w", err)
+	}
+	blockType, err := dr.r.readByte()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, 0, io.EOF
+		}
+		return 0, 0, fmt.Errorf("cannot read block type: %w", err)
+	}
+	if blockType != BlkData && blockType != BlkBlobs {
+		return 0, 0, fmt.Errorf("unsupported data block type %d", blockType)
+	}
+	dumpId, err := dr.r.readInt()
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot read block dump id: %w", err)
+	}
+	dr.blockType = blockType
+	dr.remaining = 0
+	dr.done = false
+	return blockType, dumpId, nil
+}
+
+// Read - reads the data of the current block. It returns io.EOF at the end of the block
+func (dr *DataReader) Read(p []byte) (int, error) {
+	if dr.done {
+		return 0, io.EOF
+	}
+	if dr.blockType != BlkData {
+		return 0, errors.New("large objects block cannot be read as table data")
+	}
+	if dr.remaining == 0 {
+		l, err := dr.r.readInt()
+		if err != nil {
+			return 0, fmt.Errorf("cannot read chunk length: %w", err)
+		}
+		if l == 0 {
+			dr.done = true
+			return 0, io.EOF
+		}
+		if l < 0 {
+			return 0, fmt.Errorf("invalid chunk length %d", l)
+		}
+		dr.remaining = l
+	}
+	if int32(len(p)) > dr.remaining {
+		p = p[:dr.remaining]
+	}
+	n, err := dr.r.r.Read(p)
+	dr.remaining -= int32(n)
+	if errors.Is(err, io.EOF) {
+		if dr.remaining > 0 {
+			return n, io.ErrUnexpectedEOF
+		}
+		err = nil
+	}
+	return n, err
+}
+
+// skip - skips the rest of the current block
+func (dr *DataReader) skip() error {
+	if dr.done {
+		return nil
+	}
+	if dr.blockType == BlkData {
+		_, err := io.Copy(io.Discard, dr)
+		return err
+	}
+	// The large objects block contains the list of large objects. Each of them is the object oid followed by
+	// the data chunks. The list is terminated by zero oid
+	for {
+		oid, err := dr.r.readInt()
+		if err != nil {
+			return fmt.Errorf("cannot read large object oid: %w", err)
+		}
+		if oid == 0 {
+			dr.done = true
+			return nil
+		}
+		if err = dr.skipChunks(); err != nil {
+			return err
+		}
+	}
+}
+
+// skipChunks - skips the data chunks until the terminating zero length chunk
+func (dr *DataReader) skipChunks() error {
+	for {
+		l, err := dr.r.readInt()
+		if err != nil {
+			return fmt.Errorf("cannot read chunk length: %w", err)
+		}
+		if l == 0 {
+			return nil
+		}
+		if _, err = io.CopyN(io.Discard, dr.r.r, int64(l)); err != nil {
+			return err
+		}
+	}
+}
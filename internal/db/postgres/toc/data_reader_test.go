@@ -0,0 +1,144 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestCustomToc() *Toc {
+	dbName := "test"
+	version := "16.2"
+	tableDataTag := "users"
+	blobsTag := "BLOBS"
+	ns := "public"
+	owner := "postgres"
+	copyStmt := "COPY public.users (id, name) FROM stdin;\n"
+	return &Toc{
+		Header: &Header{
+			VersionMajor:         1,
+			VersionMinor:         15,
+			Version:              BackupVersions["1.15"],
+			IntSize:              4,
+			OffSize:              8,
+			Format:               ArchCustom,
+			ArchDbName:           &dbName,
+			ArchiveRemoteVersion: &version,
+			ArchiveDumpVersion:   &version,
+		},
+		Entries: []*Entry{
+			{
+				DumpId:    10,
+				HadDumper: 1,
+				Section:   SectionData,
+				Tag:       &tableDataTag,
+				Desc:      &TableDataDesc,
+				Namespace: &ns,
+				Owner:     &owner,
+				CopyStmt:  &copyStmt,
+				DataState: OffsetPosSet,
+				DataPos:   1234,
+			},
+			{
+				DumpId:    11,
+				HadDumper: 1,
+				Section:   SectionData,
+				Tag:       &blobsTag,
+				Desc:      &BlobsDesc,
+				Owner:     &owner,
+				DataState: OffsetPosNotSet,
+			},
+		},
+	}
+}
+
+func writeTestInt(t *testing.T, buf *bytes.Buffer, v int32) {
+	w := NewWriter(buf)
+	w.intSize = 4
+	require.NoError(t, w.writeInt(v))
+}
+
+func writeTestChunks(t *testing.T, buf *bytes.Buffer, chunks ...string) {
+	for _, c := range chunks {
+		writeTestInt(t, buf, int32(len(c)))
+		buf.WriteString(c)
+	}
+	writeTestInt(t, buf, 0)
+}
+
+func TestReader_Read_custom(t *testing.T) {
+	expected := getTestCustomToc()
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, NewWriter(buf).Write(expected))
+
+	actual, err := NewReader(buf).Read()
+	require.NoError(t, err)
+	require.Len(t, actual.Entries, 2)
+	assert.Equal(t, ArchCustom, actual.Header.Format)
+	assert.Equal(t, OffsetPosSet, actual.Entries[0].DataState)
+	assert.Equal(t, int64(1234), actual.Entries[0].DataPos)
+	assert.Nil(t, actual.Entries[0].FileName)
+	assert.Equal(t, OffsetPosNotSet, actual.Entries[1].DataState)
+	assert.Equal(t, *expected.Entries[0].CopyStmt, *actual.Entries[0].CopyStmt)
+}
+
+func TestDataReader(t *testing.T) {
+	header := getTestCustomToc().Header
+	buf := bytes.NewBuffer(nil)
+
+	// Large objects block with two objects
+	buf.WriteByte(BlkBlobs)
+	writeTestInt(t, buf, 11)
+	writeTestInt(t, buf, 16001)
+	writeTestChunks(t, buf, "blob1")
+	writeTestInt(t, buf, 16002)
+	writeTestChunks(t, buf, "blob2", "blob2")
+	writeTestInt(t, buf, 0)
+	// Table data block
+	buf.WriteByte(BlkData)
+	writeTestInt(t, buf, 10)
+	writeTestChunks(t, buf, "1\tJohn\n", "2\tJane\n\\.\n\n")
+	// Table data block that is not read by the caller
+	buf.WriteByte(BlkData)
+	writeTestInt(t, buf, 12)
+	writeTestChunks(t, buf, "skipped")
+
+	dr := NewDataReader(buf, header)
+
+	blockType, dumpId, err := dr.Next()
+	require.NoError(t, err)
+	assert.Equal(t, BlkBlobs, blockType)
+	assert.Equal(t, int32(11), dumpId)
+
+	blockType, dumpId, err = dr.Next()
+	require.NoError(t, err)
+	assert.Equal(t, BlkData, blockType)
+	assert.Equal(t, int32(10), dumpId)
+	data, err := io.ReadAll(dr)
+	require.NoError(t, err)
+	assert.Equal(t, "1\tJohn\n2\tJane\n\\.\n\n", string(data))
+
+	_, dumpId, err = dr.Next()
+	require.NoError(t, err)
+	assert.Equal(t, int32(12), dumpId)
+
+	_, _, err = dr.Next()
+	require.ErrorIs(t, err, io.EOF)
+}
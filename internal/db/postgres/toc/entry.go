@@ -53,6 +53,10 @@ type Entry struct {
 	NDeps        int32   /* number of Dependencies */
 	FileName     *string
 	Relkind      byte /* relation kind, only for TABLE tags */
+	// DataState - state of the data block offset (OffsetPosNotSet, OffsetNoData or OffsetPosSet). Custom format only
+	DataState byte
+	// DataPos - data block offset in the archive file. Custom format only
+	DataPos int64
 
 	DataDumper int32 /* Routine to dump data for object */
 
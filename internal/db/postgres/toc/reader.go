@@ -28,7 +28,9 @@ type Reader struct {
 	r         io.Reader
 	buf       []byte
 	intSize   uint32
+	offSize   uint32
 	version   int
+	format    byte
 	position  int
 	maxDumpId int32
 }
@@ -42,7 +44,9 @@ func NewReader(r io.Reader) *Reader {
 func (r *Reader) prune() {
 	r.buf = r.buf[:]
 	r.intSize = 0
+	r.offSize = 0
 	r.version = 0
+	r.format = 0
 	r.position = 0
 	r.maxDumpId = 0
 }
@@ -77,7 +81,7 @@ func (r *Reader) readStr() (*string, error) {
 
 	buf := make([]byte, l)
 
-	n, err := r.r.Read(buf)
+	n, err := io.ReadFull(r.r, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +111,7 @@ func (r *Reader) readInt() (int32, error) {
 	}
 
 	intBytes := make([]byte, r.intSize)
-	n, err := r.r.Read(intBytes)
+	n, err := io.ReadFull(r.r, intBytes)
 	if err != nil {
 		return 0, err
 	}
@@ -127,6 +131,24 @@ func (r *Reader) readInt() (int32, error) {
 	return res, nil
 }
 
+// readOffset - reads the data block offset of the custom format archive. It returns the offset state
+// (OffsetPosNotSet, OffsetNoData or OffsetPosSet) and the offset
+func (r *Reader) readOffset() (byte, int64, error) {
+	state, err := r.readByte()
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot read offset state: %w", err)
+	}
+	offBytes, err := r.readBytes(int(r.offSize))
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot read offset: %w", err)
+	}
+	var res int64
+	for idx, b := range offBytes {
+		res |= int64(b) << (8 * idx)
+	}
+	return state, res, nil
+}
+
 func (r *Reader) readByte() (byte, error) {
 	res, err := r.readBytes(1)
 	if err != nil {
@@ -137,7 +159,7 @@ func (r *Reader) readByte() (byte, error) {
 
 func (r *Reader) readBytes(length int) ([]byte, error) {
 	bytes := make([]byte, length)
-	n, err := r.r.Read(bytes)
+	n, err := io.ReadFull(r.r, bytes)
 	if err != nil {
 		return nil, err
 	}
@@ -215,6 +237,7 @@ func (r *Reader) readHeader() (*Header, error) {
 	} else {
 		header.OffSize = header.IntSize
 	}
+	r.offSize = header.OffSize
 
 	if err := r.scanBytes(&header.Format); err != nil {
 		return nil, fmt.Errorf("unable to scan bytes from TOC srcFile: %w", err)
@@ -225,9 +248,17 @@ func (r *Reader) readHeader() (*Header, error) {
 	 * is compatible with 'tar', so there's no point having a different
 	 * format code for it.
 	 */
-	if ArchTar != header.Format {
-		return nil, fmt.Errorf("unsupported format \"%s\": suports only directory", BackupFormats[header.Format])
+	if ArchTar != header.Format && ArchCustom != header.Format {
+		return nil, fmt.Errorf(
+			"unsupported format \"%s\": suports only directory and custom", BackupFormats[header.Format],
+		)
 	}
+	if ArchCustom == header.Format && header.Version < BackupVersions["1.7"] {
+		return nil, fmt.Errorf(
+			"unsupported custom format archive version %d.%d", header.VersionMajor, header.VersionMinor,
+		)
+	}
+	r.format = header.Format
 
 	if header.Version >= BackupVersions["1.15"] {
 		algorithm, err := r.readByte()
@@ -490,11 +521,19 @@ func (r *Reader) readEntries() ([]*Entry, error) {
 		}
 		entry.DataLength = 0
 
-		fileName, err := r.readStr()
-		if err != nil {
-			return nil, fmt.Errorf("cannot read an additional FileName data: %w", err)
+		if r.format == ArchCustom {
+			// The custom format stores the data block offset instead of the file name
+			entry.DataState, entry.DataPos, err = r.readOffset()
+			if err != nil {
+				return nil, fmt.Errorf("cannot read an additional data offset: %w", err)
+			}
+		} else {
+			fileName, err := r.readStr()
+			if err != nil {
+				return nil, fmt.Errorf("cannot read an additional FileName data: %w", err)
+			}
+			entry.FileName = fileName
 		}
-		entry.FileName = fileName
 		entries = append(entries, &entry)
 
 	}
@@ -24,6 +24,19 @@ const (
 
 const InvalidOid = 0
 
+// Data block offset states of the custom format archive
+const (
+	OffsetPosNotSet byte = 1
+	OffsetNoData    byte = 2
+	OffsetPosSet    byte = 3
+)
+
+// Data block types of the custom format archive
+const (
+	BlkData  byte = 1
+	BlkBlobs byte = 3
+)
+
 const MaxVersion = "1.16"
 
 const (
@@ -25,7 +25,9 @@ type Writer struct {
 	w        io.Writer
 	buf      []byte
 	intSize  uint32
+	offSize  uint32
 	version  int
+	format   byte
 	position int
 }
 
@@ -38,7 +40,9 @@ func NewWriter(w io.Writer) *Writer {
 func (w *Writer) prune() {
 	w.buf = w.buf[:]
 	w.intSize = 0
+	w.offSize = 0
 	w.version = 0
+	w.format = 0
 	w.position = 0
 }
 
@@ -51,7 +55,9 @@ func (w *Writer) Write(toc *Toc) error {
 	}
 	defer w.prune()
 	w.intSize = toc.Header.IntSize
+	w.offSize = toc.Header.OffSize
 	w.version = toc.Header.Version
+	w.format = toc.Header.Format
 	if err := w.writeHeader(toc.Header); err != nil {
 		return fmt.Errorf("error writing header: %w", err)
 	}
@@ -261,6 +267,14 @@ func (w *Writer) writeEntries(entries []*Entry) error {
 			}
 		}
 
+		if w.format == ArchCustom {
+			// The custom format stores the data block offset instead of the file name
+			if err := w.writeOffset(entry.DataState, entry.DataPos); err != nil {
+				return fmt.Errorf("unable to write data offset: %w", err)
+			}
+			continue
+		}
+
 		// TODO: Ensure entry.FileName is required for all versions
 		// WriteExtraTocPtr - write filename here
 		if err := w.writeStr(entry.FileName); err != nil {
@@ -312,6 +326,19 @@ func (w *Writer) writeInt(i int32) error {
 	return nil
 }
 
+func (w *Writer) writeOffset(state byte, pos int64) error {
+	if err := w.writeByte(state); err != nil {
+		return fmt.Errorf("unable to write offset state: %w", err)
+	}
+	for b := uint32(0); b < w.offSize; b++ {
+		if err := w.writeByte(byte(pos) & 0xFF); err != nil {
+			return fmt.Errorf("unable to write offset byte: %w", err)
+		}
+		pos >>= 8
+	}
+	return nil
+}
+
 func (w *Writer) writeStr(data *string) error {
 
 	if data != nil {
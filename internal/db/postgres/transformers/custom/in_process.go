@@ -0,0 +1,112 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package custom
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// inProcessTransformer - adapts toolkit.Transformer implemented in the Go code of the application that embeds
+// greenmask. The record is transformed in place, so there is no interaction with an external process
+type inProcessTransformer struct {
+	t               toolkit.Transformer
+	affectedColumns map[int]string
+}
+
+func (ipt *inProcessTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (ipt *inProcessTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (ipt *inProcessTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	if err := ipt.t.Transform(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (ipt *inProcessTransformer) GetAffectedColumns() map[int]string {
+	return ipt.affectedColumns
+}
+
+// NewInProcessTransformerDefinition - builds the transformer definition from toolkit.TransformerDefinition that
+// creates the transformer in the current process. The column parameters without properties are affected, the same
+// as for the custom cmd transformers. Transformer.Validate is called on the creation if the definition requires
+// validation
+func NewInProcessTransformerDefinition(def *toolkit.TransformerDefinition) (*utils.TransformerDefinition, error) {
+	if def.Name == "" {
+		return nil, fmt.Errorf("transformer name is required")
+	}
+	if def.New == nil {
+		return nil, fmt.Errorf("transformer %s: New function is required", def.Name)
+	}
+	for _, p := range def.Parameters {
+		if p.IsColumn && p.ColumnProperties == nil {
+			p.SetIsColumn(toolkit.NewColumnProperties().
+				SetAffected(true),
+			)
+		}
+	}
+
+	newFunc := func(
+		ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer,
+	) (utils.Transformer, toolkit.ValidationWarnings, error) {
+		affectedColumns, _, err := toolkit.GetAffectedAndTransferringColumns(parameters, driver)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot get affected columns: %w", err)
+		}
+		t, warnings, err := def.New(ctx, driver, parameters)
+		if err != nil {
+			return nil, nil, err
+		}
+		if warnings.IsFatal() {
+			return nil, warnings, nil
+		}
+		if def.Validate {
+			validateWarnings, err := t.Validate(ctx)
+			if err != nil {
+				return nil, nil, fmt.Errorf("transformer validation error: %w", err)
+			}
+			warnings = append(warnings, validateWarnings...)
+		}
+
+		ipt := &inProcessTransformer{
+			t:               t,
+			affectedColumns: make(map[int]string, len(affectedColumns)),
+		}
+		for _, c := range affectedColumns {
+			idx, _, _ := driver.GetColumnByName(c.Name)
+			ipt.affectedColumns[idx] = c.Name
+		}
+		return ipt, warnings, nil
+	}
+
+	return utils.NewTransformerDefinition(
+		&utils.TransformerProperties{
+			Name:        def.Name,
+			Description: def.Description,
+			IsCustom:    true,
+		},
+		newFunc,
+		def.Parameters...,
+	), nil
+}
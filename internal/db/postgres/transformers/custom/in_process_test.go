@@ -0,0 +1,105 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package custom
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+type upperTransformer struct {
+	column    string
+	validated bool
+}
+
+func (ut *upperTransformer) Validate(ctx context.Context) (toolkit.ValidationWarnings, error) {
+	ut.validated = true
+	return toolkit.ValidationWarnings{
+		toolkit.NewValidationWarning().
+			SetMsg("validated").
+			SetSeverity(toolkit.InfoValidationSeverity),
+	}, nil
+}
+
+func (ut *upperTransformer) Transform(ctx context.Context, r *toolkit.Record) error {
+	v, err := r.GetRawColumnValueByName(ut.column)
+	if err != nil {
+		return err
+	}
+	return r.SetRawColumnValueByName(ut.column, toolkit.NewRawValue([]byte(strings.ToUpper(string(v.Data))), false))
+}
+
+func newUpperTransformerDefinition() *toolkit.TransformerDefinition {
+	return toolkit.NewTransformerDefinition(
+		"Upper",
+		func(ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer) (
+			toolkit.Transformer, toolkit.ValidationWarnings, error,
+		) {
+			var column string
+			if err := parameters["column"].Scan(&column); err != nil {
+				return nil, nil, err
+			}
+			return &upperTransformer{column: column}, nil, nil
+		},
+	).SetDescription("makes the value upper case").
+		SetValidate(true).
+		AddParameter(
+			toolkit.MustNewParameterDefinition("column", "column name").
+				SetIsColumn(nil).
+				SetRequired(true),
+		)
+}
+
+func TestNewInProcessTransformerDefinition(t *testing.T) {
+	def, err := NewInProcessTransformerDefinition(newUpperTransformerDefinition())
+	require.NoError(t, err)
+	assert.Equal(t, "Upper", def.Properties.Name)
+	assert.True(t, def.Properties.IsCustom)
+
+	driver, record := toolkit.GetDriverAndRecord(map[string]*toolkit.RawValue{
+		"id4":  toolkit.NewRawValue([]byte("1"), false),
+		"data": toolkit.NewRawValue([]byte("john"), false),
+	})
+	tc, warnings, err := def.Instance(
+		context.Background(), driver, map[string]toolkit.ParamsValue{"column": toolkit.ParamsValue("data")}, nil, "",
+	)
+	require.NoError(t, err)
+	require.False(t, warnings.IsFatal())
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "validated", warnings[0].Msg)
+
+	idx, _, ok := driver.GetColumnByName("data")
+	require.True(t, ok)
+	assert.Equal(t, map[int]string{idx: "data"}, tc.Transformer.GetAffectedColumns())
+
+	r, err := tc.Transformer.Transform(context.Background(), record)
+	require.NoError(t, err)
+	v, err := r.GetRawColumnValueByName("data")
+	require.NoError(t, err)
+	assert.Equal(t, "JOHN", string(v.Data))
+}
+
+func TestNewInProcessTransformerDefinition_invalid(t *testing.T) {
+	_, err := NewInProcessTransformerDefinition(toolkit.NewTransformerDefinition("", nil))
+	require.Error(t, err)
+	_, err = NewInProcessTransformerDefinition(toolkit.NewTransformerDefinition("Test", nil))
+	require.Error(t, err)
+}
@@ -0,0 +1,268 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const DateShiftTransformerName = "DateShift"
+
+var dateShiftColumnTypes = []string{"date", "timestamp", "timestamptz"}
+
+var DateShiftTransformerDefinition = utils.NewTransformerDefinition(
+	utils.NewTransformerProperties(
+		DateShiftTransformerName,
+		"Shift all the dates of the entity by the same number of days derived from the entity key",
+	).AddMeta(AllowApplyForReferenced, true).
+		AddMeta(RequireHashEngineParameter, true).
+		AddMeta(ResetParamsForReferences, []string{"columns"}),
+
+	NewDateShiftTransformer,
+
+	toolkit.MustNewParameterDefinition(
+		"column",
+		"entity key column name. The dates of the rows with the same key are shifted by the same offset",
+	).SetIsColumn(toolkit.NewColumnProperties().
+		SetAffected(false),
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"columns",
+		"list of date, timestamp and timestamptz columns to shift. By default all of them except the key column",
+	).SetDefaultValue(toolkit.ParamsValue("[]")),
+
+	toolkit.MustNewParameterDefinition(
+		"min_shift",
+		"min offset rounded down to days",
+	).SetCastDbType("interval").
+		SetDefaultValue(toolkit.ParamsValue("0 days")),
+
+	toolkit.MustNewParameterDefinition(
+		"max_shift",
+		"max offset rounded down to days",
+	).SetRequired(true).
+		SetCastDbType("interval"),
+
+	toolkit.MustNewParameterDefinition(
+		"engine",
+		"The engine used for generating the offset [random, hash]. The hash engine makes the offset "+
+			"deterministic for the key",
+	).SetDefaultValue([]byte(HashEngineParameterName)).
+		SetRawValueValidator(engineValidator),
+)
+
+type DateShiftTransformer struct {
+	t               *transformers.DateShift
+	keyColumnIdx    int
+	columnsIdx      []int
+	affectedColumns map[int]string
+}
+
+func NewDateShiftTransformer(
+	ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer,
+) (utils.Transformer, toolkit.ValidationWarnings, error) {
+	var keyColumn, engine string
+	var columns []string
+	var minShift, maxShift pgtype.Interval
+
+	if err := parameters["column"].Scan(&keyColumn); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "column" param: %w`, err)
+	}
+	keyColumnIdx, _, ok := driver.GetColumnByName(keyColumn)
+	if !ok {
+		return nil, nil, fmt.Errorf("column with name %s is not found", keyColumn)
+	}
+	if err := parameters["columns"].Scan(&columns); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "columns" param: %w`, err)
+	}
+	if err := parameters["min_shift"].Scan(&minShift); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "min_shift" param: %w`, err)
+	}
+	if err := parameters["max_shift"].Scan(&maxShift); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "max_shift" param: %w`, err)
+	}
+	if err := parameters["engine"].Scan(&engine); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "engine" param: %w`, err)
+	}
+
+	var warns toolkit.ValidationWarnings
+	minDays, maxDays := intervalToDays(minShift), intervalToDays(maxShift)
+	if maxDays <= 0 {
+		warns = append(warns, toolkit.NewValidationWarning().
+			SetMsg("max_shift must be at least 1 day").
+			AddMeta("ParameterName", "max_shift").
+			SetSeverity(toolkit.ErrorValidationSeverity),
+		)
+	}
+	if minDays < 0 || minDays > maxDays {
+		warns = append(warns, toolkit.NewValidationWarning().
+			SetMsg("min_shift must be positive and less than or equal to max_shift").
+			AddMeta("ParameterName", "min_shift").
+			SetSeverity(toolkit.ErrorValidationSeverity),
+		)
+	}
+
+	columnsIdx, affectedColumns, columnsWarns := getDateShiftColumns(driver, keyColumn, columns)
+	warns = append(warns, columnsWarns...)
+	if warns.IsFatal() {
+		return nil, warns, nil
+	}
+
+	t, err := transformers.NewDateShift(minDays, maxDays)
+	if err != nil {
+		return nil, nil, err
+	}
+	g, err := getGenerateEngine(ctx, engine, t.GetRequiredGeneratorByteLength())
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get generator: %w", err)
+	}
+	if err = t.SetGenerator(g); err != nil {
+		return nil, nil, fmt.Errorf("unable to set generator: %w", err)
+	}
+
+	return &DateShiftTransformer{
+		t:               t,
+		keyColumnIdx:    keyColumnIdx,
+		columnsIdx:      columnsIdx,
+		affectedColumns: affectedColumns,
+	}, warns, nil
+}
+
+// getDateShiftColumns - returns the columns to shift. If the columns are not provided, all the date and timestamp
+// columns except the key column are shifted
+func getDateShiftColumns(
+	driver *toolkit.Driver, keyColumn string, columns []string,
+) ([]int, map[int]string, toolkit.ValidationWarnings) {
+	var warns toolkit.ValidationWarnings
+	var columnsIdx []int
+	affectedColumns := make(map[int]string)
+
+	if len(columns) == 0 {
+		for idx, c := range driver.Table.Columns {
+			typeName, _ := c.GetType()
+			if c.Name == keyColumn || !slices.Contains(dateShiftColumnTypes, typeName) {
+				continue
+			}
+			columnsIdx = append(columnsIdx, idx)
+			affectedColumns[idx] = c.Name
+		}
+		if len(columnsIdx) == 0 {
+			warns = append(warns, toolkit.NewValidationWarning().
+				SetMsg("table does not have date or timestamp columns to shift").
+				SetSeverity(toolkit.WarningValidationSeverity),
+			)
+		}
+		return columnsIdx, affectedColumns, warns
+	}
+
+	for listIdx, name := range columns {
+		idx, c, ok := driver.GetColumnByName(name)
+		if !ok {
+			warns = append(warns, toolkit.NewValidationWarning().
+				SetMsg("column is not found").
+				AddMeta("ParameterName", "columns").
+				AddMeta("ParameterValue", name).
+				AddMeta("ListIdx", listIdx).
+				SetSeverity(toolkit.ErrorValidationSeverity),
+			)
+			continue
+		}
+		typeName, _ := c.GetType()
+		if !slices.Contains(dateShiftColumnTypes, typeName) {
+			warns = append(warns, toolkit.NewValidationWarning().
+				SetMsg("unsupported column type: expected date, timestamp or timestamptz").
+				AddMeta("ParameterName", "columns").
+				AddMeta("ParameterValue", name).
+				AddMeta("ColumnType", typeName).
+				SetSeverity(toolkit.ErrorValidationSeverity),
+			)
+			continue
+		}
+		if name == keyColumn {
+			warns = append(warns, toolkit.NewValidationWarning().
+				SetMsg("key column cannot be shifted").
+				AddMeta("ParameterName", "columns").
+				AddMeta("ParameterValue", name).
+				SetSeverity(toolkit.ErrorValidationSeverity),
+			)
+			continue
+		}
+		columnsIdx = append(columnsIdx, idx)
+		affectedColumns[idx] = name
+	}
+	return columnsIdx, affectedColumns, warns
+}
+
+func (dst *DateShiftTransformer) GetAffectedColumns() map[int]string {
+	return dst.affectedColumns
+}
+
+func (dst *DateShiftTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (dst *DateShiftTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (dst *DateShiftTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	key, err := r.GetRawColumnValueByIdx(dst.keyColumnIdx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get key value: %w", err)
+	}
+	// The rows with NULL key get the same offset as the rows with the empty key
+	var keyData []byte
+	if !key.IsNull {
+		keyData = key.Data
+	}
+	days, err := dst.t.GetOffset(keyData)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, idx := range dst.columnsIdx {
+		var v time.Time
+		isNull, err := r.ScanColumnValueByIdx(idx, &v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan attribute value: %w", err)
+		}
+		if isNull {
+			continue
+		}
+		if err = r.SetColumnValueByIdx(idx, dst.t.Shift(v, days)); err != nil {
+			return nil, fmt.Errorf("unable to set new value: %w", err)
+		}
+	}
+	return r, nil
+}
+
+// intervalToDays - converts the interval to days. A month is 30 days and the time part is rounded down
+func intervalToDays(v pgtype.Interval) int64 {
+	return int64(v.Months)*30 + int64(v.Days) + v.Microseconds/int64(24*time.Hour/time.Microsecond)
+}
+
+func init() {
+	utils.DefaultTransformerRegistry.MustRegister(DateShiftTransformerDefinition)
+}
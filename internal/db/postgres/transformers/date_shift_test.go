@@ -0,0 +1,125 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func getDateShiftDriverAndRecord(key, date, ts string) (*toolkit.Driver, *toolkit.Record) {
+	return toolkit.GetDriverAndRecord(map[string]*toolkit.RawValue{
+		"id4":       toolkit.NewRawValue([]byte(key), false),
+		"date_date": toolkit.NewRawValue([]byte(date), false),
+		"date_ts":   toolkit.NewRawValue([]byte(ts), false),
+	})
+}
+
+func TestDateShiftTransformer_Transform(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "salt", []byte("12345678"))
+	params := map[string]toolkit.ParamsValue{
+		"column":    toolkit.ParamsValue("id4"),
+		"min_shift": toolkit.ParamsValue("10 days"),
+		"max_shift": toolkit.ParamsValue("1 mon"),
+	}
+
+	shift := func(key, date, ts string) (time.Time, time.Time) {
+		driver, record := getDateShiftDriverAndRecord(key, date, ts)
+		tc, warnings, err := DateShiftTransformerDefinition.Instance(ctx, driver, params, nil, "")
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+		assert.Len(t, tc.Transformer.GetAffectedColumns(), 2)
+
+		r, err := tc.Transformer.Transform(ctx, record)
+		require.NoError(t, err)
+		var resDate, resTs time.Time
+		_, err = r.ScanColumnValueByName("date_date", &resDate)
+		require.NoError(t, err)
+		_, err = r.ScanColumnValueByName("date_ts", &resTs)
+		require.NoError(t, err)
+		return resDate, resTs
+	}
+
+	originalDate := time.Date(2023, 6, 25, 0, 0, 0, 0, time.UTC)
+	originalTs := time.Date(2023, 6, 28, 10, 30, 0, 0, time.UTC)
+	resDate, resTs := shift("42", "2023-06-25", "2023-06-28 10:30:00")
+
+	offset := resDate.Sub(originalDate)
+	days := offset.Hours() / 24
+	if days < 0 {
+		days = -days
+	}
+	assert.GreaterOrEqual(t, days, float64(10))
+	assert.LessOrEqual(t, days, float64(30))
+	assert.Equal(t, originalTs.Sub(originalDate), resTs.Sub(resDate))
+
+	// The other row of the same entity is shifted by the same offset
+	otherDate, _ := shift("42", "2023-07-01", "2023-07-02 00:00:00")
+	assert.Equal(t, offset, otherDate.Sub(time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestDateShiftTransformer_validation(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		params map[string]toolkit.ParamsValue
+	}{
+		{
+			name: "column is not a date",
+			params: map[string]toolkit.ParamsValue{
+				"column":    toolkit.ParamsValue("id4"),
+				"columns":   toolkit.ParamsValue(`["id4"]`),
+				"max_shift": toolkit.ParamsValue("30 days"),
+			},
+		},
+		{
+			name: "unknown column",
+			params: map[string]toolkit.ParamsValue{
+				"column":    toolkit.ParamsValue("id4"),
+				"columns":   toolkit.ParamsValue(`["unknown"]`),
+				"max_shift": toolkit.ParamsValue("30 days"),
+			},
+		},
+		{
+			name: "max_shift less than a day",
+			params: map[string]toolkit.ParamsValue{
+				"column":    toolkit.ParamsValue("id4"),
+				"max_shift": toolkit.ParamsValue("10 hours"),
+			},
+		},
+		{
+			name: "min_shift greater than max_shift",
+			params: map[string]toolkit.ParamsValue{
+				"column":    toolkit.ParamsValue("id4"),
+				"min_shift": toolkit.ParamsValue("40 days"),
+				"max_shift": toolkit.ParamsValue("30 days"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver, _ := getDateShiftDriverAndRecord("1", "2023-06-25", "2023-06-28 10:30:00")
+			_, warnings, err := DateShiftTransformerDefinition.Instance(ctx, driver, tt.params, nil, "")
+			require.NoError(t, err)
+			assert.True(t, warnings.IsFatal())
+		})
+	}
+}
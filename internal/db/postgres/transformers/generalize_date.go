@@ -0,0 +1,152 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const GeneralizeDateTransformerName = "GeneralizeDate"
+
+const (
+	generalizeDateQuarterPart = "quarter"
+	generalizeDateWeekPart    = "week"
+)
+
+var GeneralizeDateTransformerDefinition = utils.NewTransformerDefinition(
+	utils.NewTransformerProperties(
+		GeneralizeDateTransformerName,
+		"Truncate the date to the beginning of the year, quarter, month, week, day, hour or minute",
+	),
+
+	NewGeneralizeDateTransformer,
+
+	toolkit.MustNewParameterDefinition(
+		"column",
+		"column name",
+	).SetIsColumn(toolkit.NewColumnProperties().
+		SetAffected(true).
+		SetAllowedColumnTypes("date", "timestamp", "timestamptz"),
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"part",
+		"the part the date is truncated to (year, quarter, month, week, day, hour, minute)",
+	).SetAllowedValues(
+		toolkit.ParamsValue(transformers.YearTruncateName),
+		toolkit.ParamsValue(generalizeDateQuarterPart),
+		toolkit.ParamsValue(transformers.MonthTruncateName),
+		toolkit.ParamsValue(generalizeDateWeekPart),
+		toolkit.ParamsValue(transformers.DayTruncateName),
+		toolkit.ParamsValue(transformers.HourTruncateName),
+		toolkit.ParamsValue(transformers.MinuteTruncateName),
+	).SetRequired(true),
+)
+
+type GeneralizeDateTransformer struct {
+	columnName      string
+	columnIdx       int
+	part            string
+	truncater       *transformers.DateTruncater
+	affectedColumns map[int]string
+}
+
+func NewGeneralizeDateTransformer(
+	ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer,
+) (utils.Transformer, toolkit.ValidationWarnings, error) {
+	var columnName, part string
+
+	if err := parameters["column"].Scan(&columnName); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "column" param: %w`, err)
+	}
+	idx, _, ok := driver.GetColumnByName(columnName)
+	if !ok {
+		return nil, nil, fmt.Errorf("column with name %s is not found", columnName)
+	}
+	affectedColumns := make(map[int]string)
+	affectedColumns[idx] = columnName
+
+	if err := parameters["part"].Scan(&part); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "part" param: %w`, err)
+	}
+
+	// The quarter and the week are truncated to the day first and then moved to the beginning of the period
+	truncatePart := part
+	switch part {
+	case generalizeDateQuarterPart:
+		truncatePart = transformers.MonthTruncateName
+	case generalizeDateWeekPart:
+		truncatePart = transformers.DayTruncateName
+	}
+	truncater, err := transformers.NewDateTruncater(truncatePart)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create date truncater: %w", err)
+	}
+
+	return &GeneralizeDateTransformer{
+		columnName:      columnName,
+		columnIdx:       idx,
+		part:            part,
+		truncater:       truncater,
+		affectedColumns: affectedColumns,
+	}, nil, nil
+}
+
+func (gdt *GeneralizeDateTransformer) GetAffectedColumns() map[int]string {
+	return gdt.affectedColumns
+}
+
+func (gdt *GeneralizeDateTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (gdt *GeneralizeDateTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (gdt *GeneralizeDateTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	var v time.Time
+	isNull, err := r.ScanColumnValueByIdx(gdt.columnIdx, &v)
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan attribute value: %w", err)
+	}
+	if isNull {
+		return r, nil
+	}
+
+	res := gdt.truncater.Truncate(v)
+	switch gdt.part {
+	case generalizeDateQuarterPart:
+		res = res.AddDate(0, -(int(res.Month())-1)%3, 0)
+	case generalizeDateWeekPart:
+		// The week starts on Monday as in PostgreSQL date_trunc
+		res = res.AddDate(0, 0, -(int(res.Weekday())+6)%7)
+	}
+
+	if err = r.SetColumnValueByIdx(gdt.columnIdx, res); err != nil {
+		return nil, fmt.Errorf("unable to set new value: %w", err)
+	}
+	return r, nil
+}
+
+func init() {
+	utils.DefaultTransformerRegistry.MustRegister(GeneralizeDateTransformerDefinition)
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestGeneralizeDateTransformer_Transform(t *testing.T) {
+	tests := []struct {
+		name         string
+		columnName   string
+		part         string
+		original     string
+		expected     string
+		expectedNull bool
+	}{
+		{
+			name:       "year",
+			columnName: "date_date",
+			part:       "year",
+			original:   "2023-11-10",
+			expected:   "2023-01-01",
+		},
+		{
+			name:       "quarter",
+			columnName: "date_date",
+			part:       "quarter",
+			original:   "2023-11-10",
+			expected:   "2023-10-01",
+		},
+		{
+			name:       "quarter first month",
+			columnName: "date_date",
+			part:       "quarter",
+			original:   "2023-04-30",
+			expected:   "2023-04-01",
+		},
+		{
+			name:       "month",
+			columnName: "date_date",
+			part:       "month",
+			original:   "2023-11-10",
+			expected:   "2023-11-01",
+		},
+		{
+			name:       "week",
+			columnName: "date_date",
+			part:       "week",
+			original:   "2023-11-12",
+			expected:   "2023-11-06",
+		},
+		{
+			name:       "week on Monday",
+			columnName: "date_date",
+			part:       "week",
+			original:   "2023-11-06",
+			expected:   "2023-11-06",
+		},
+		{
+			name:       "hour",
+			columnName: "date_ts",
+			part:       "hour",
+			original:   "2023-11-10 12:34:56.123456",
+			expected:   "2023-11-10 12:00:00",
+		},
+		{
+			name:         "keep NULL",
+			columnName:   "date_date",
+			part:         "month",
+			original:     "\\N",
+			expectedNull: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]toolkit.ParamsValue{
+				"column": toolkit.ParamsValue(tt.columnName),
+				"part":   toolkit.ParamsValue(tt.part),
+			}
+			driver, record := getDriverAndRecord(tt.columnName, tt.original)
+			transformer, warnings, err := GeneralizeDateTransformerDefinition.Instance(
+				context.Background(),
+				driver,
+				params,
+				nil,
+				"",
+			)
+			require.NoError(t, err)
+			require.Empty(t, warnings)
+
+			r, err := transformer.Transformer.Transform(context.Background(), record)
+			require.NoError(t, err)
+			res, err := r.GetRawColumnValueByName(tt.columnName)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedNull, res.IsNull)
+			if !tt.expectedNull {
+				assert.Equal(t, tt.expected, string(res.Data))
+			}
+		})
+	}
+}
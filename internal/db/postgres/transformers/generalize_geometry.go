@@ -0,0 +1,140 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const (
+	GeneralizeGeometryTransformerName = "GeneralizeGeometry"
+
+	generalizeGeometryModeGrid     = "grid"
+	generalizeGeometryModeCentroid = "centroid"
+)
+
+var GeneralizeGeometryTransformerDefinition = utils.NewTransformerDefinition(
+	utils.NewTransformerProperties(
+		GeneralizeGeometryTransformerName,
+		"Snap the PostGIS geometry to the grid or replace it with its centroid keeping its SRID",
+	),
+
+	NewGeneralizeGeometryTransformer,
+
+	toolkit.MustNewParameterDefinition(
+		"column",
+		"column name",
+	).SetIsColumn(toolkit.NewColumnProperties().
+		SetAffected(true).
+		SetAllowedColumnTypes(toolkit.PostgisTypeNames...),
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"mode",
+		"grid - snap the coordinates to the grid, centroid - replace the geometry with its centroid point",
+	).SetAllowedValues(
+		toolkit.ParamsValue(generalizeGeometryModeGrid),
+		toolkit.ParamsValue(generalizeGeometryModeCentroid),
+	).SetDefaultValue(toolkit.ParamsValue(generalizeGeometryModeGrid)),
+
+	toolkit.MustNewParameterDefinition(
+		"grid_size",
+		"the size of the grid cell in the units of the geometry SRID",
+	).SetDefaultValue(toolkit.ParamsValue("0.01")),
+)
+
+type GeneralizeGeometryTransformer struct {
+	columnName      string
+	columnIdx       int
+	mode            string
+	gridSize        float64
+	affectedColumns map[int]string
+}
+
+func NewGeneralizeGeometryTransformer(
+	ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer,
+) (utils.Transformer, toolkit.ValidationWarnings, error) {
+	var columnName, mode string
+	var gridSize float64
+
+	if err := parameters["column"].Scan(&columnName); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "column" param: %w`, err)
+	}
+	idx, _, ok := driver.GetColumnByName(columnName)
+	if !ok {
+		return nil, nil, fmt.Errorf("column with name %s is not found", columnName)
+	}
+	affectedColumns := make(map[int]string)
+	affectedColumns[idx] = columnName
+
+	if err := parameters["mode"].Scan(&mode); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "mode" param: %w`, err)
+	}
+	if err := parameters["grid_size"].Scan(&gridSize); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "grid_size" param: %w`, err)
+	}
+
+	if mode == generalizeGeometryModeGrid && gridSize <= 0 {
+		return nil, toolkit.ValidationWarnings{
+			toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ParameterName", "grid_size").
+				AddMeta("ParameterValue", gridSize).
+				SetMsg("grid_size must be greater than 0"),
+		}, nil
+	}
+
+	return &GeneralizeGeometryTransformer{
+		columnName:      columnName,
+		columnIdx:       idx,
+		mode:            mode,
+		gridSize:        gridSize,
+		affectedColumns: affectedColumns,
+	}, nil, nil
+}
+
+func (ggt *GeneralizeGeometryTransformer) GetAffectedColumns() map[int]string {
+	return ggt.affectedColumns
+}
+
+func (ggt *GeneralizeGeometryTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (ggt *GeneralizeGeometryTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (ggt *GeneralizeGeometryTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	return transformGeometry(r, ggt.columnIdx, func(_ []byte, g *transformers.Geometry) (*transformers.Geometry, error) {
+		if g.IsEmpty() {
+			return g, nil
+		}
+		if ggt.mode == generalizeGeometryModeCentroid {
+			return g.Centroid(), nil
+		}
+		g.SnapToGrid(ggt.gridSize)
+		return g, nil
+	})
+}
+
+func init() {
+	utils.DefaultTransformerRegistry.MustRegister(GeneralizeGeometryTransformerDefinition)
+}
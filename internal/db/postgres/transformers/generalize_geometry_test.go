@@ -0,0 +1,122 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestGeneralizeGeometryTransformer_Transform(t *testing.T) {
+	square := &transformers.Geometry{
+		Type:    transformers.GeometryPolygon,
+		HasSRID: true,
+		SRID:    3857,
+		Rings:   [][][]float64{{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}}},
+	}
+
+	tests := []struct {
+		name     string
+		original string
+		params   map[string]toolkit.ParamsValue
+		expected *transformers.Geometry
+	}{
+		{
+			name:     "grid",
+			original: testGeometryPoint,
+			params: map[string]toolkit.ParamsValue{
+				"grid_size": toolkit.ParamsValue("0.1"),
+			},
+			expected: &transformers.Geometry{
+				Type:    transformers.GeometryPoint,
+				HasSRID: true,
+				SRID:    4326,
+				Points:  [][]float64{{13.4, 52.5}},
+			},
+		},
+		{
+			name:     "centroid",
+			original: string(square.HexEWKB()),
+			params: map[string]toolkit.ParamsValue{
+				"mode": toolkit.ParamsValue("centroid"),
+			},
+			expected: &transformers.Geometry{
+				Type:    transformers.GeometryPoint,
+				HasSRID: true,
+				SRID:    3857,
+				Points:  [][]float64{{2, 2}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params["column"] = toolkit.ParamsValue("col_geometry")
+			driver, record := getDriverAndRecord("col_geometry", tt.original)
+			transformer, warnings, err := GeneralizeGeometryTransformerDefinition.Instance(
+				context.Background(),
+				driver,
+				tt.params,
+				nil,
+				"",
+			)
+			require.NoError(t, err)
+			require.Empty(t, warnings)
+
+			r, err := transformer.Transformer.Transform(context.Background(), record)
+			require.NoError(t, err)
+			res, err := r.GetRawColumnValueByName("col_geometry")
+			require.NoError(t, err)
+			g, err := transformers.ParseHexEWKB(res.Data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected.Type, g.Type)
+			assert.Equal(t, tt.expected.SRID, g.SRID)
+			assert.InDeltaSlice(t, tt.expected.Points[0], g.Points[0], 1e-9)
+		})
+	}
+}
+
+func TestGeneralizeGeometryTransformer_validation(t *testing.T) {
+	driver, _ := getDriverAndRecord("col_geometry", testGeometryPoint)
+	_, warnings, err := GeneralizeGeometryTransformerDefinition.Instance(
+		context.Background(),
+		driver,
+		map[string]toolkit.ParamsValue{
+			"column":    toolkit.ParamsValue("col_geometry"),
+			"grid_size": toolkit.ParamsValue("-1"),
+		},
+		nil,
+		"",
+	)
+	require.NoError(t, err)
+	assert.True(t, warnings.IsFatal())
+
+	driver, _ = getDriverAndRecord("data", "test")
+	_, warnings, err = GeneralizeGeometryTransformerDefinition.Instance(
+		context.Background(),
+		driver,
+		map[string]toolkit.ParamsValue{"column": toolkit.ParamsValue("data")},
+		nil,
+		"",
+	)
+	require.NoError(t, err)
+	assert.True(t, warnings.IsFatal())
+}
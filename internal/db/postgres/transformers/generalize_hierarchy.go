@@ -0,0 +1,265 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const GeneralizeHierarchyTransformerName = "GeneralizeHierarchy"
+
+var GeneralizeHierarchyTransformerDefinition = utils.NewTransformerDefinition(
+	utils.NewTransformerProperties(
+		GeneralizeHierarchyTransformerName,
+		"Replace the value with its ancestor from the hierarchy dictionary",
+	),
+
+	NewGeneralizeHierarchyTransformer,
+
+	toolkit.MustNewParameterDefinition(
+		"column",
+		"column name",
+	).SetIsColumn(toolkit.NewColumnProperties().
+		SetAffected(true),
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"hierarchy",
+		`map of value to its parent in format: {"child": "parent"}`,
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"level",
+		"number of the levels to climb up. The value is replaced with the root if the hierarchy is shorter",
+	).SetDefaultValue(toolkit.ParamsValue("1")),
+
+	toolkit.MustNewParameterDefinition(
+		"default",
+		`default value if the value is not found in the hierarchy. The string with value "\N" supposed to be NULL value. Default is empty`,
+	).SetRequired(false),
+
+	toolkit.MustNewParameterDefinition(
+		"fail_not_matched",
+		`fail if value is not found in the hierarchy otherwise keep value`,
+	).SetRequired(false).
+		SetDefaultValue(toolkit.ParamsValue("true")),
+
+	toolkit.MustNewParameterDefinition(
+		"validate",
+		`perform encode-decode procedure using column type, ensuring that value has correct type`,
+	).SetRequired(false).
+		SetDefaultValue(toolkit.ParamsValue("true")),
+)
+
+type GeneralizeHierarchyTransformer struct {
+	columnName string
+	columnIdx  int
+	// resolved - the ancestor on the requested level for each value of the hierarchy
+	resolved        map[string]*toolkit.RawValue
+	defaultValue    *toolkit.RawValue
+	failNotMatched  bool
+	affectedColumns map[int]string
+}
+
+func NewGeneralizeHierarchyTransformer(
+	ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer,
+) (utils.Transformer, toolkit.ValidationWarnings, error) {
+	var columnName string
+	var level int
+	var validate, failNotMatched bool
+
+	if err := parameters["column"].Scan(&columnName); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "column" param: %w`, err)
+	}
+	idx, _, ok := driver.GetColumnByName(columnName)
+	if !ok {
+		return nil, nil, fmt.Errorf("column with name %s is not found", columnName)
+	}
+	affectedColumns := make(map[int]string)
+	affectedColumns[idx] = columnName
+
+	hierarchy := make(map[string]string)
+	if err := parameters["hierarchy"].Scan(&hierarchy); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "hierarchy" param: %w`, err)
+	}
+	if err := parameters["level"].Scan(&level); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "level" param: %w`, err)
+	}
+	if err := parameters["validate"].Scan(&validate); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "validate" param: %w`, err)
+	}
+	if err := parameters["fail_not_matched"].Scan(&failNotMatched); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "fail_not_matched" param: %w`, err)
+	}
+
+	var warnings toolkit.ValidationWarnings
+	if level < 1 {
+		warnings = append(warnings, toolkit.NewValidationWarning().
+			SetSeverity(toolkit.ErrorValidationSeverity).
+			AddMeta("ParameterName", "level").
+			AddMeta("ParameterValue", level).
+			SetMsg("level must be greater than 0"),
+		)
+	}
+
+	for child, parent := range hierarchy {
+		if validate {
+			for _, v := range []string{child, parent} {
+				if err := dictValidateValue([]byte(v), driver, idx); err != nil {
+					warnings = append(warnings, toolkit.NewValidationWarning().
+						SetSeverity(toolkit.ErrorValidationSeverity).
+						AddMeta("ParameterName", "hierarchy").
+						AddMeta("ParameterValue", v).
+						AddMeta("Error", err.Error()).
+						SetMsg("error validating hierarchy value"),
+					)
+				}
+			}
+		}
+		if hierarchyHasCycle(hierarchy, child) {
+			warnings = append(warnings, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ParameterName", "hierarchy").
+				AddMeta("ParameterValue", child).
+				SetMsg("hierarchy contains a cycle"),
+			)
+		}
+	}
+
+	var defaultValue *toolkit.RawValue
+	p := parameters["default"]
+	isEmpty, err := p.IsEmpty()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error checking is parameter \"default\" empty: %w", err)
+	}
+	if !isEmpty {
+		rawDefaultValue, err := p.RawValue()
+		if err != nil {
+			return nil, nil, fmt.Errorf(`unable to scan "default" param: %w`, err)
+		}
+		if string(rawDefaultValue) == defaultNullSeq {
+			defaultValue = toolkit.NewRawValue(nil, true)
+		} else {
+			defaultValue = toolkit.NewRawValue(rawDefaultValue, false)
+			if validate {
+				if err := dictValidateValue(defaultValue.Data, driver, idx); err != nil {
+					warnings = append(warnings, toolkit.NewValidationWarning().
+						SetSeverity(toolkit.ErrorValidationSeverity).
+						AddMeta("ParameterValue", string(defaultValue.Data)).
+						AddMeta("ParameterName", "default").
+						AddMeta("Error", err.Error()).
+						SetMsg("error validating \"default\""),
+					)
+				}
+			}
+		}
+	}
+
+	if warnings.IsFatal() {
+		return nil, warnings, nil
+	}
+
+	resolved := make(map[string]*toolkit.RawValue, len(hierarchy)*2)
+	for child, parent := range hierarchy {
+		resolved[child] = toolkit.NewRawValue([]byte(hierarchyAncestor(hierarchy, child, level)), false)
+		if _, ok := hierarchy[parent]; !ok {
+			// The root is generalised to itself
+			resolved[parent] = toolkit.NewRawValue([]byte(parent), false)
+		}
+	}
+
+	return &GeneralizeHierarchyTransformer{
+		columnName:      columnName,
+		columnIdx:       idx,
+		resolved:        resolved,
+		defaultValue:    defaultValue,
+		failNotMatched:  failNotMatched,
+		affectedColumns: affectedColumns,
+	}, warnings, nil
+}
+
+func (ght *GeneralizeHierarchyTransformer) GetAffectedColumns() map[int]string {
+	return ght.affectedColumns
+}
+
+func (ght *GeneralizeHierarchyTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (ght *GeneralizeHierarchyTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (ght *GeneralizeHierarchyTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	val, err := r.GetRawColumnValueByIdx(ght.columnIdx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan attribute value: %w", err)
+	}
+	if val.IsNull {
+		return r, nil
+	}
+
+	newVal, ok := ght.resolved[string(val.Data)]
+	if !ok {
+		if ght.defaultValue != nil {
+			newVal = ght.defaultValue
+		} else if ght.failNotMatched {
+			return nil, fmt.Errorf(`unable to find value "%s" in the hierarchy`, string(val.Data))
+		} else {
+			return r, nil
+		}
+	}
+
+	if err = r.SetRawColumnValueByIdx(ght.columnIdx, newVal); err != nil {
+		return nil, fmt.Errorf("unable to set new value: %w", err)
+	}
+	return r, nil
+}
+
+// hierarchyAncestor - climb up the hierarchy from the value up to level steps, stopping at the root
+func hierarchyAncestor(hierarchy map[string]string, value string, level int) string {
+	for i := 0; i < level; i++ {
+		parent, ok := hierarchy[value]
+		if !ok {
+			break
+		}
+		value = parent
+	}
+	return value
+}
+
+// hierarchyHasCycle - check that the path from the value to the root does not visit the same value twice
+func hierarchyHasCycle(hierarchy map[string]string, value string) bool {
+	visited := map[string]struct{}{value: {}}
+	for {
+		parent, ok := hierarchy[value]
+		if !ok {
+			return false
+		}
+		if _, ok = visited[parent]; ok {
+			return true
+		}
+		visited[parent] = struct{}{}
+		value = parent
+	}
+}
+
+func init() {
+	utils.DefaultTransformerRegistry.MustRegister(GeneralizeHierarchyTransformerDefinition)
+}
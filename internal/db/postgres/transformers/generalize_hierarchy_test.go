@@ -0,0 +1,148 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const testHierarchy = `{"Berlin": "Germany", "Munich": "Germany", "Germany": "Europe", "Paris": "France", "France": "Europe"}`
+
+func TestGeneralizeHierarchyTransformer_Transform(t *testing.T) {
+	tests := []struct {
+		name         string
+		original     string
+		params       map[string]toolkit.ParamsValue
+		expected     string
+		expectedNull bool
+	}{
+		{
+			name:     "one level",
+			original: "Berlin",
+			params:   map[string]toolkit.ParamsValue{},
+			expected: "Germany",
+		},
+		{
+			name:     "two levels",
+			original: "Paris",
+			params:   map[string]toolkit.ParamsValue{"level": toolkit.ParamsValue("2")},
+			expected: "Europe",
+		},
+		{
+			name:     "stop at root",
+			original: "Munich",
+			params:   map[string]toolkit.ParamsValue{"level": toolkit.ParamsValue("5")},
+			expected: "Europe",
+		},
+		{
+			name:     "root",
+			original: "Europe",
+			params:   map[string]toolkit.ParamsValue{},
+			expected: "Europe",
+		},
+		{
+			name:     "not matched default",
+			original: "Tokyo",
+			params:   map[string]toolkit.ParamsValue{"default": toolkit.ParamsValue("Other")},
+			expected: "Other",
+		},
+		{
+			name:         "not matched default NULL",
+			original:     "Tokyo",
+			params:       map[string]toolkit.ParamsValue{"default": toolkit.ParamsValue(`\N`)},
+			expectedNull: true,
+		},
+		{
+			name:     "not matched keep",
+			original: "Tokyo",
+			params:   map[string]toolkit.ParamsValue{"fail_not_matched": toolkit.ParamsValue("false")},
+			expected: "Tokyo",
+		},
+		{
+			name:         "keep NULL",
+			original:     "\\N",
+			params:       map[string]toolkit.ParamsValue{},
+			expectedNull: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params["column"] = toolkit.ParamsValue("data")
+			tt.params["hierarchy"] = toolkit.ParamsValue(testHierarchy)
+			driver, record := getDriverAndRecord("data", tt.original)
+			transformer, warnings, err := GeneralizeHierarchyTransformerDefinition.Instance(
+				context.Background(),
+				driver,
+				tt.params,
+				nil,
+				"",
+			)
+			require.NoError(t, err)
+			require.Empty(t, warnings)
+
+			r, err := transformer.Transformer.Transform(context.Background(), record)
+			require.NoError(t, err)
+			res, err := r.GetRawColumnValueByName("data")
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedNull, res.IsNull)
+			if !tt.expectedNull {
+				assert.Equal(t, tt.expected, string(res.Data))
+			}
+		})
+	}
+}
+
+func TestGeneralizeHierarchyTransformer_Transform_error_not_matched(t *testing.T) {
+	params := map[string]toolkit.ParamsValue{
+		"column":    toolkit.ParamsValue("data"),
+		"hierarchy": toolkit.ParamsValue(testHierarchy),
+	}
+	driver, record := getDriverAndRecord("data", "Tokyo")
+	transformer, warnings, err := GeneralizeHierarchyTransformerDefinition.Instance(
+		context.Background(),
+		driver,
+		params,
+		nil,
+		"",
+	)
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+	_, err = transformer.Transformer.Transform(context.Background(), record)
+	require.ErrorContains(t, err, "unable to find value")
+}
+
+func TestGeneralizeHierarchyTransformer_cycle(t *testing.T) {
+	params := map[string]toolkit.ParamsValue{
+		"column":    toolkit.ParamsValue("data"),
+		"hierarchy": toolkit.ParamsValue(`{"a": "b", "b": "c", "c": "a"}`),
+	}
+	driver, _ := getDriverAndRecord("data", "a")
+	_, warnings, err := GeneralizeHierarchyTransformerDefinition.Instance(
+		context.Background(),
+		driver,
+		params,
+		nil,
+		"",
+	)
+	require.NoError(t, err)
+	require.True(t, warnings.IsFatal())
+}
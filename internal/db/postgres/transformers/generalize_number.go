@@ -0,0 +1,204 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const GeneralizeNumberTransformerName = "GeneralizeNumber"
+
+const (
+	generalizeNumberOutputLower = "lower"
+	generalizeNumberOutputRange = "range"
+)
+
+var (
+	generalizeNumberIntegerTypes = []string{"int2", "int4", "int8"}
+	generalizeNumberTextTypes    = []string{"text", "varchar", "char", "bpchar", "citext"}
+)
+
+var GeneralizeNumberTransformerDefinition = utils.NewTransformerDefinition(
+	utils.NewTransformerProperties(
+		GeneralizeNumberTransformerName,
+		"Replace the number with the bin it belongs to",
+	),
+
+	NewGeneralizeNumberTransformer,
+
+	toolkit.MustNewParameterDefinition(
+		"column",
+		"column name",
+	).SetIsColumn(toolkit.NewColumnProperties().
+		SetAffected(true).
+		SetAllowedColumnTypes(
+			"int2", "int4", "int8", "float4", "float8", "numeric", "text", "varchar", "char", "bpchar", "citext",
+		),
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"width",
+		"bin width",
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"offset",
+		"the lower bound of the first bin. The bins are [offset + n * width; offset + (n + 1) * width)",
+	).SetDefaultValue(toolkit.ParamsValue("0")),
+
+	toolkit.MustNewParameterDefinition(
+		"output",
+		"the value of the bin: lower - the lower bound, range - the text label [lower, upper) for text columns",
+	).SetAllowedValues(
+		toolkit.ParamsValue(generalizeNumberOutputLower),
+		toolkit.ParamsValue(generalizeNumberOutputRange),
+	).SetDefaultValue(toolkit.ParamsValue(generalizeNumberOutputLower)),
+)
+
+type GeneralizeNumberTransformer struct {
+	columnName      string
+	columnIdx       int
+	width           decimal.Decimal
+	offset          decimal.Decimal
+	output          string
+	affectedColumns map[int]string
+}
+
+func NewGeneralizeNumberTransformer(
+	ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer,
+) (utils.Transformer, toolkit.ValidationWarnings, error) {
+	var columnName, widthStr, offsetStr, output string
+
+	if err := parameters["column"].Scan(&columnName); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "column" param: %w`, err)
+	}
+	idx, c, ok := driver.GetColumnByName(columnName)
+	if !ok {
+		return nil, nil, fmt.Errorf("column with name %s is not found", columnName)
+	}
+	affectedColumns := make(map[int]string)
+	affectedColumns[idx] = columnName
+
+	if err := parameters["width"].Scan(&widthStr); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "width" param: %w`, err)
+	}
+	if err := parameters["offset"].Scan(&offsetStr); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "offset" param: %w`, err)
+	}
+	if err := parameters["output"].Scan(&output); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "output" param: %w`, err)
+	}
+
+	var warns toolkit.ValidationWarnings
+	width, err := decimal.NewFromString(widthStr)
+	if err != nil || !width.IsPositive() {
+		warns = append(warns, toolkit.NewValidationWarning().
+			SetSeverity(toolkit.ErrorValidationSeverity).
+			AddMeta("ParameterName", "width").
+			AddMeta("ParameterValue", widthStr).
+			SetMsg("width must be a positive number"),
+		)
+	}
+	offset, err := decimal.NewFromString(offsetStr)
+	if err != nil {
+		warns = append(warns, toolkit.NewValidationWarning().
+			SetSeverity(toolkit.ErrorValidationSeverity).
+			AddMeta("ParameterName", "offset").
+			AddMeta("ParameterValue", offsetStr).
+			SetMsg("offset must be a number"),
+		)
+	}
+	if warns.IsFatal() {
+		return nil, warns, nil
+	}
+
+	typeName, _ := c.GetType()
+	if output == generalizeNumberOutputRange && !slices.Contains(generalizeNumberTextTypes, typeName) {
+		warns = append(warns, toolkit.NewValidationWarning().
+			SetSeverity(toolkit.ErrorValidationSeverity).
+			AddMeta("ParameterName", "output").
+			AddMeta("ColumnType", typeName).
+			SetMsg("range output is supported only for text columns"),
+		)
+	}
+	if slices.Contains(generalizeNumberIntegerTypes, typeName) && (!width.IsInteger() || !offset.IsInteger()) {
+		warns = append(warns, toolkit.NewValidationWarning().
+			SetSeverity(toolkit.ErrorValidationSeverity).
+			AddMeta("ParameterName", "width").
+			AddMeta("ColumnType", typeName).
+			SetMsg("width and offset must be integers for integer column"),
+		)
+	}
+	if warns.IsFatal() {
+		return nil, warns, nil
+	}
+
+	return &GeneralizeNumberTransformer{
+		columnName:      columnName,
+		columnIdx:       idx,
+		width:           width,
+		offset:          offset,
+		output:          output,
+		affectedColumns: affectedColumns,
+	}, warns, nil
+}
+
+func (gnt *GeneralizeNumberTransformer) GetAffectedColumns() map[int]string {
+	return gnt.affectedColumns
+}
+
+func (gnt *GeneralizeNumberTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (gnt *GeneralizeNumberTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (gnt *GeneralizeNumberTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	val, err := r.GetRawColumnValueByIdx(gnt.columnIdx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan value: %w", err)
+	}
+	if val.IsNull {
+		return r, nil
+	}
+
+	v, err := decimal.NewFromString(string(val.Data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse number: %w", err)
+	}
+	lower := v.Sub(gnt.offset).Div(gnt.width).Floor().Mul(gnt.width).Add(gnt.offset)
+	res := lower.String()
+	if gnt.output == generalizeNumberOutputRange {
+		res = fmt.Sprintf("[%s, %s)", res, lower.Add(gnt.width).String())
+	}
+
+	if err = r.SetRawColumnValueByIdx(gnt.columnIdx, toolkit.NewRawValue([]byte(res), false)); err != nil {
+		return nil, fmt.Errorf("unable to set new value: %w", err)
+	}
+	return r, nil
+}
+
+func init() {
+	utils.DefaultTransformerRegistry.MustRegister(GeneralizeNumberTransformerDefinition)
+}
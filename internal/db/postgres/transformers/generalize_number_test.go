@@ -0,0 +1,153 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestGeneralizeNumberTransformer_Transform(t *testing.T) {
+	tests := []struct {
+		name         string
+		columnName   string
+		original     string
+		params       map[string]toolkit.ParamsValue
+		expected     string
+		expectedNull bool
+	}{
+		{
+			name:       "int4",
+			columnName: "id4",
+			original:   "37",
+			params:     map[string]toolkit.ParamsValue{"width": toolkit.ParamsValue("10")},
+			expected:   "30",
+		},
+		{
+			name:       "int4 negative",
+			columnName: "id4",
+			original:   "-3",
+			params:     map[string]toolkit.ParamsValue{"width": toolkit.ParamsValue("10")},
+			expected:   "-10",
+		},
+		{
+			name:       "int8 with offset",
+			columnName: "id8",
+			original:   "37",
+			params: map[string]toolkit.ParamsValue{
+				"width":  toolkit.ParamsValue("10"),
+				"offset": toolkit.ParamsValue("5"),
+			},
+			expected: "35",
+		},
+		{
+			name:       "numeric",
+			columnName: "val_numeric",
+			original:   "12.345",
+			params:     map[string]toolkit.ParamsValue{"width": toolkit.ParamsValue("0.5")},
+			expected:   "12",
+		},
+		{
+			name:       "text range",
+			columnName: "data",
+			original:   "42",
+			params: map[string]toolkit.ParamsValue{
+				"width":  toolkit.ParamsValue("20"),
+				"output": toolkit.ParamsValue("range"),
+			},
+			expected: "[40, 60)",
+		},
+		{
+			name:         "keep NULL",
+			columnName:   "id4",
+			original:     "\\N",
+			params:       map[string]toolkit.ParamsValue{"width": toolkit.ParamsValue("10")},
+			expectedNull: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params["column"] = toolkit.ParamsValue(tt.columnName)
+			driver, record := getDriverAndRecord(tt.columnName, tt.original)
+			transformer, warnings, err := GeneralizeNumberTransformerDefinition.Instance(
+				context.Background(),
+				driver,
+				tt.params,
+				nil,
+				"",
+			)
+			require.NoError(t, err)
+			require.Empty(t, warnings)
+
+			r, err := transformer.Transformer.Transform(context.Background(), record)
+			require.NoError(t, err)
+			res, err := r.GetRawColumnValueByName(tt.columnName)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedNull, res.IsNull)
+			if !tt.expectedNull {
+				assert.Equal(t, tt.expected, string(res.Data))
+			}
+		})
+	}
+}
+
+func TestGeneralizeNumberTransformer_validation(t *testing.T) {
+	tests := []struct {
+		name       string
+		columnName string
+		params     map[string]toolkit.ParamsValue
+	}{
+		{
+			name:       "zero width",
+			columnName: "id4",
+			params:     map[string]toolkit.ParamsValue{"width": toolkit.ParamsValue("0")},
+		},
+		{
+			name:       "fractional width for integer column",
+			columnName: "id4",
+			params:     map[string]toolkit.ParamsValue{"width": toolkit.ParamsValue("2.5")},
+		},
+		{
+			name:       "range for numeric column",
+			columnName: "val_numeric",
+			params: map[string]toolkit.ParamsValue{
+				"width":  toolkit.ParamsValue("10"),
+				"output": toolkit.ParamsValue("range"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params["column"] = toolkit.ParamsValue(tt.columnName)
+			driver, _ := getDriverAndRecord(tt.columnName, "1")
+			_, warnings, err := GeneralizeNumberTransformerDefinition.Instance(
+				context.Background(),
+				driver,
+				tt.params,
+				nil,
+				"",
+			)
+			require.NoError(t, err)
+			require.True(t, warnings.IsFatal())
+		})
+	}
+}
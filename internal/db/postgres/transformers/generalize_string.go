@@ -0,0 +1,152 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const GeneralizeStringTransformerName = "GeneralizeString"
+
+var GeneralizeStringTransformerDefinition = utils.NewTransformerDefinition(
+	utils.NewTransformerProperties(
+		GeneralizeStringTransformerName,
+		"Keep the prefix of the string and drop or fill the rest",
+	),
+
+	NewGeneralizeStringTransformer,
+
+	toolkit.MustNewParameterDefinition(
+		"column",
+		"column name",
+	).SetIsColumn(toolkit.NewColumnProperties().
+		SetAffected(true).
+		SetAllowedColumnTypes("text", "varchar", "char", "bpchar", "citext"),
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"keep",
+		"number of the leading characters to keep",
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"fill",
+		"the character the dropped characters are replaced with. By default they are removed",
+	).SetDefaultValue(toolkit.ParamsValue("")),
+)
+
+type GeneralizeStringTransformer struct {
+	columnName      string
+	columnIdx       int
+	keep            int
+	fill            string
+	affectedColumns map[int]string
+}
+
+func NewGeneralizeStringTransformer(
+	ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer,
+) (utils.Transformer, toolkit.ValidationWarnings, error) {
+	var columnName, fill string
+	var keep int
+
+	if err := parameters["column"].Scan(&columnName); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "column" param: %w`, err)
+	}
+	idx, _, ok := driver.GetColumnByName(columnName)
+	if !ok {
+		return nil, nil, fmt.Errorf("column with name %s is not found", columnName)
+	}
+	affectedColumns := make(map[int]string)
+	affectedColumns[idx] = columnName
+
+	if err := parameters["keep"].Scan(&keep); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "keep" param: %w`, err)
+	}
+	if err := parameters["fill"].Scan(&fill); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "fill" param: %w`, err)
+	}
+
+	var warns toolkit.ValidationWarnings
+	if keep < 0 {
+		warns = append(warns, toolkit.NewValidationWarning().
+			SetSeverity(toolkit.ErrorValidationSeverity).
+			AddMeta("ParameterName", "keep").
+			AddMeta("ParameterValue", keep).
+			SetMsg("keep must be positive or zero"),
+		)
+	}
+	if utf8.RuneCountInString(fill) > 1 {
+		warns = append(warns, toolkit.NewValidationWarning().
+			SetSeverity(toolkit.ErrorValidationSeverity).
+			AddMeta("ParameterName", "fill").
+			AddMeta("ParameterValue", fill).
+			SetMsg("fill must be a single character"),
+		)
+	}
+	if warns.IsFatal() {
+		return nil, warns, nil
+	}
+
+	return &GeneralizeStringTransformer{
+		columnName:      columnName,
+		columnIdx:       idx,
+		keep:            keep,
+		fill:            fill,
+		affectedColumns: affectedColumns,
+	}, nil, nil
+}
+
+func (gst *GeneralizeStringTransformer) GetAffectedColumns() map[int]string {
+	return gst.affectedColumns
+}
+
+func (gst *GeneralizeStringTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (gst *GeneralizeStringTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (gst *GeneralizeStringTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	val, err := r.GetRawColumnValueByIdx(gst.columnIdx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan value: %w", err)
+	}
+	if val.IsNull {
+		return r, nil
+	}
+
+	runes := []rune(string(val.Data))
+	if len(runes) <= gst.keep {
+		return r, nil
+	}
+	res := string(runes[:gst.keep]) + strings.Repeat(gst.fill, len(runes)-gst.keep)
+
+	if err = r.SetRawColumnValueByIdx(gst.columnIdx, toolkit.NewRawValue([]byte(res), false)); err != nil {
+		return nil, fmt.Errorf("unable to set new value: %w", err)
+	}
+	return r, nil
+}
+
+func init() {
+	utils.DefaultTransformerRegistry.MustRegister(GeneralizeStringTransformerDefinition)
+}
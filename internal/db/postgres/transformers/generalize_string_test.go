@@ -0,0 +1,94 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestGeneralizeStringTransformer_Transform(t *testing.T) {
+	tests := []struct {
+		name         string
+		original     string
+		params       map[string]toolkit.ParamsValue
+		expected     string
+		expectedNull bool
+	}{
+		{
+			name:     "drop suffix",
+			original: "10115",
+			params:   map[string]toolkit.ParamsValue{"keep": toolkit.ParamsValue("3")},
+			expected: "101",
+		},
+		{
+			name:     "fill suffix",
+			original: "10115",
+			params: map[string]toolkit.ParamsValue{
+				"keep": toolkit.ParamsValue("3"),
+				"fill": toolkit.ParamsValue("*"),
+			},
+			expected: "101**",
+		},
+		{
+			name:     "multibyte",
+			original: "Zürich",
+			params:   map[string]toolkit.ParamsValue{"keep": toolkit.ParamsValue("2")},
+			expected: "Zü",
+		},
+		{
+			name:     "shorter than keep",
+			original: "ab",
+			params:   map[string]toolkit.ParamsValue{"keep": toolkit.ParamsValue("3")},
+			expected: "ab",
+		},
+		{
+			name:         "keep NULL",
+			original:     "\\N",
+			params:       map[string]toolkit.ParamsValue{"keep": toolkit.ParamsValue("3")},
+			expectedNull: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params["column"] = toolkit.ParamsValue("data")
+			driver, record := getDriverAndRecord("data", tt.original)
+			transformer, warnings, err := GeneralizeStringTransformerDefinition.Instance(
+				context.Background(),
+				driver,
+				tt.params,
+				nil,
+				"",
+			)
+			require.NoError(t, err)
+			require.Empty(t, warnings)
+
+			r, err := transformer.Transformer.Transform(context.Background(), record)
+			require.NoError(t, err)
+			res, err := r.GetRawColumnValueByName("data")
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedNull, res.IsNull)
+			if !tt.expectedNull {
+				assert.Equal(t, tt.expected, string(res.Data))
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const (
+	MaskBinaryTransformerName = "MaskBinary"
+	maskBinaryModeNull        = "null"
+)
+
+var MaskBinaryTransformerDefinition = utils.NewTransformerDefinition(
+	utils.NewTransformerProperties(
+		MaskBinaryTransformerName,
+		"Replace the binary content with the placeholder of the same MIME type, strip the image metadata or set NULL",
+	),
+
+	NewMaskBinaryTransformer,
+
+	toolkit.MustNewParameterDefinition(
+		"column",
+		"column name",
+	).SetIsColumn(toolkit.NewColumnProperties().
+		SetAffected(true).
+		SetAllowedColumnTypes("bytea"),
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"mode",
+		"placeholder - replace with the minimal file of the same MIME type, strip_metadata - remove EXIF, XMP and "+
+			"text metadata from JPEG and PNG images, empty - replace with the empty value, null - set NULL",
+	).SetAllowedValues(
+		toolkit.ParamsValue(transformers.BinaryContentModePlaceholder),
+		toolkit.ParamsValue(transformers.BinaryContentModeStripMetadata),
+		toolkit.ParamsValue(transformers.BinaryContentModeEmpty),
+		toolkit.ParamsValue(maskBinaryModeNull),
+	).SetDefaultValue(toolkit.ParamsValue(transformers.BinaryContentModePlaceholder)),
+
+	toolkit.MustNewParameterDefinition(
+		"keep_size",
+		"pad the result up to the size of the original value",
+	).SetDefaultValue(toolkit.ParamsValue("false")),
+)
+
+type MaskBinaryTransformer struct {
+	t               *transformers.BinaryContent
+	columnName      string
+	columnIdx       int
+	setNull         bool
+	affectedColumns map[int]string
+}
+
+func NewMaskBinaryTransformer(
+	ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer,
+) (utils.Transformer, toolkit.ValidationWarnings, error) {
+	var columnName, mode string
+	var keepSize bool
+
+	if err := parameters["column"].Scan(&columnName); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "column" param: %w`, err)
+	}
+	idx, _, ok := driver.GetColumnByName(columnName)
+	if !ok {
+		return nil, nil, fmt.Errorf("column with name %s is not found", columnName)
+	}
+	affectedColumns := make(map[int]string)
+	affectedColumns[idx] = columnName
+
+	if err := parameters["mode"].Scan(&mode); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "mode" param: %w`, err)
+	}
+	if err := parameters["keep_size"].Scan(&keepSize); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "keep_size" param: %w`, err)
+	}
+
+	res := &MaskBinaryTransformer{
+		columnName:      columnName,
+		columnIdx:       idx,
+		setNull:         mode == maskBinaryModeNull,
+		affectedColumns: affectedColumns,
+	}
+	if res.setNull {
+		return res, nil, nil
+	}
+
+	t, err := transformers.NewBinaryContent(mode, keepSize)
+	if err != nil {
+		return nil, toolkit.ValidationWarnings{
+			toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ParameterName", "mode").
+				AddMeta("ParameterValue", mode).
+				AddMeta("Error", err.Error()).
+				SetMsg("unable to create binary content transformer"),
+		}, nil
+	}
+	res.t = t
+	return res, nil, nil
+}
+
+func (mbt *MaskBinaryTransformer) GetAffectedColumns() map[int]string {
+	return mbt.affectedColumns
+}
+
+func (mbt *MaskBinaryTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (mbt *MaskBinaryTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (mbt *MaskBinaryTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	if mbt.setNull {
+		if err := r.SetRawColumnValueByIdx(mbt.columnIdx, toolkit.NewRawValue(nil, true)); err != nil {
+			return nil, fmt.Errorf("unable to set new value: %w", err)
+		}
+		return r, nil
+	}
+
+	var data []byte
+	isNull, err := r.ScanColumnValueByIdx(mbt.columnIdx, &data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan value: %w", err)
+	}
+	if isNull {
+		return r, nil
+	}
+
+	if err = r.SetColumnValueByIdx(mbt.columnIdx, mbt.t.Transform(data)); err != nil {
+		return nil, fmt.Errorf("unable to set new value: %w", err)
+	}
+	return r, nil
+}
+
+func init() {
+	utils.DefaultTransformerRegistry.MustRegister(MaskBinaryTransformerDefinition)
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestMaskBinaryTransformer_Transform(t *testing.T) {
+	pdf := []byte("%PDF-1.7\n/Author (John Doe) signed contract")
+	text := []byte("John Doe, passport 123456")
+
+	tests := []struct {
+		name         string
+		original     string
+		params       map[string]toolkit.ParamsValue
+		expected     []byte
+		expectedNull bool
+	}{
+		{
+			name:     "placeholder",
+			original: "\\\\x" + hex.EncodeToString(pdf),
+			params:   map[string]toolkit.ParamsValue{},
+			expected: transformers.BinaryPlaceholder("application/pdf", 0),
+		},
+		{
+			name:     "keep size",
+			original: "\\\\x" + hex.EncodeToString(text),
+			params: map[string]toolkit.ParamsValue{
+				"keep_size": toolkit.ParamsValue("true"),
+			},
+			expected: []byte(strings.Repeat(" ", len(text))),
+		},
+		{
+			name:     "empty",
+			original: "\\\\x" + hex.EncodeToString(pdf),
+			params: map[string]toolkit.ParamsValue{
+				"mode": toolkit.ParamsValue("empty"),
+			},
+			expected: []byte{},
+		},
+		{
+			name:     "null",
+			original: "\\\\x" + hex.EncodeToString(pdf),
+			params: map[string]toolkit.ParamsValue{
+				"mode": toolkit.ParamsValue("null"),
+			},
+			expectedNull: true,
+		},
+		{
+			name:         "keep NULL",
+			original:     "\\N",
+			params:       map[string]toolkit.ParamsValue{},
+			expectedNull: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params["column"] = toolkit.ParamsValue("col_bytea")
+			driver, record := getDriverAndRecord("col_bytea", tt.original)
+			transformer, warnings, err := MaskBinaryTransformerDefinition.Instance(
+				context.Background(),
+				driver,
+				tt.params,
+				nil,
+				"",
+			)
+			require.NoError(t, err)
+			require.Empty(t, warnings)
+
+			r, err := transformer.Transformer.Transform(context.Background(), record)
+			require.NoError(t, err)
+			res, err := r.GetRawColumnValueByName("col_bytea")
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedNull, res.IsNull)
+			if !tt.expectedNull {
+				assert.Equal(t, "\\x"+hex.EncodeToString(tt.expected), string(res.Data))
+			}
+		})
+	}
+}
+
+func TestMaskBinaryTransformer_unsupported_column_type(t *testing.T) {
+	driver, _ := getDriverAndRecord("data", "test")
+	_, warnings, err := MaskBinaryTransformerDefinition.Instance(
+		context.Background(),
+		driver,
+		map[string]toolkit.ParamsValue{"column": toolkit.ParamsValue("data")},
+		nil,
+		"",
+	)
+	require.NoError(t, err)
+	assert.True(t, warnings.IsFatal())
+}
@@ -0,0 +1,177 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const (
+	NoiseGeometryTransformerName = "NoiseGeometry"
+
+	geometryUnitMeters      = "meters"
+	geometryUnitCoordinates = "coordinates"
+)
+
+var NoiseGeometryTransformerDefinition = utils.NewTransformerDefinition(
+	utils.NewTransformerProperties(
+		NoiseGeometryTransformerName,
+		"Move the PostGIS geometry by the random offset within the radius keeping its SRID and type",
+	),
+
+	NewNoiseGeometryTransformer,
+
+	toolkit.MustNewParameterDefinition(
+		"column",
+		"column name",
+	).SetIsColumn(toolkit.NewColumnProperties().
+		SetAffected(true).
+		SetAllowedColumnTypes(toolkit.PostgisTypeNames...),
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"radius",
+		"the maximal distance the geometry is moved by",
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"unit",
+		"the unit of the radius: meters - the coordinates are longitude and latitude in degrees, "+
+			"coordinates - the units of the geometry SRID",
+	).SetAllowedValues(
+		toolkit.ParamsValue(geometryUnitMeters),
+		toolkit.ParamsValue(geometryUnitCoordinates),
+	).SetDefaultValue(toolkit.ParamsValue(geometryUnitMeters)),
+
+	engineParameterDefinition,
+)
+
+type NoiseGeometryTransformer struct {
+	t               *transformers.GeometryNoise
+	columnName      string
+	columnIdx       int
+	affectedColumns map[int]string
+}
+
+func NewNoiseGeometryTransformer(
+	ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer,
+) (utils.Transformer, toolkit.ValidationWarnings, error) {
+	var columnName, unit, engine string
+	var radius float64
+
+	if err := parameters["column"].Scan(&columnName); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "column" param: %w`, err)
+	}
+	idx, _, ok := driver.GetColumnByName(columnName)
+	if !ok {
+		return nil, nil, fmt.Errorf("column with name %s is not found", columnName)
+	}
+	affectedColumns := make(map[int]string)
+	affectedColumns[idx] = columnName
+
+	if err := parameters["radius"].Scan(&radius); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "radius" param: %w`, err)
+	}
+	if err := parameters["unit"].Scan(&unit); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "unit" param: %w`, err)
+	}
+	if err := parameters["engine"].Scan(&engine); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "engine" param: %w`, err)
+	}
+
+	t, err := transformers.NewGeometryNoise(radius, unit == geometryUnitMeters)
+	if err != nil {
+		return nil, toolkit.ValidationWarnings{
+			toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ParameterName", "radius").
+				AddMeta("ParameterValue", radius).
+				AddMeta("Error", err.Error()).
+				SetMsg("invalid radius"),
+		}, nil
+	}
+	g, err := getGenerateEngine(ctx, engine, t.GetRequiredGeneratorByteLength())
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get generator: %w", err)
+	}
+	if err = t.SetGenerator(g); err != nil {
+		return nil, nil, fmt.Errorf("unable to set generator: %w", err)
+	}
+
+	return &NoiseGeometryTransformer{
+		t:               t,
+		columnName:      columnName,
+		columnIdx:       idx,
+		affectedColumns: affectedColumns,
+	}, nil, nil
+}
+
+func (ngt *NoiseGeometryTransformer) GetAffectedColumns() map[int]string {
+	return ngt.affectedColumns
+}
+
+func (ngt *NoiseGeometryTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (ngt *NoiseGeometryTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (ngt *NoiseGeometryTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	return transformGeometry(r, ngt.columnIdx, func(original []byte, g *transformers.Geometry) (*transformers.Geometry, error) {
+		if err := ngt.t.Transform(original, g); err != nil {
+			return nil, err
+		}
+		return g, nil
+	})
+}
+
+// transformGeometry - decodes the hex EWKB value of the column, transforms it and sets the result. NULL values are
+// kept as is
+func transformGeometry(
+	r *toolkit.Record, columnIdx int,
+	transform func(original []byte, g *transformers.Geometry) (*transformers.Geometry, error),
+) (*toolkit.Record, error) {
+	val, err := r.GetRawColumnValueByIdx(columnIdx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan value: %w", err)
+	}
+	if val.IsNull {
+		return r, nil
+	}
+
+	g, err := transformers.ParseHexEWKB(val.Data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse geometry: %w", err)
+	}
+	res, err := transform(val.Data, g)
+	if err != nil {
+		return nil, fmt.Errorf("unable to transform geometry: %w", err)
+	}
+	if err = r.SetRawColumnValueByIdx(columnIdx, toolkit.NewRawValue(res.HexEWKB(), false)); err != nil {
+		return nil, fmt.Errorf("unable to set new value: %w", err)
+	}
+	return r, nil
+}
+
+func init() {
+	utils.DefaultTransformerRegistry.MustRegister(NoiseGeometryTransformerDefinition)
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// SRID=4326;POINT(13.4049 52.5208)
+const testGeometryPoint = "0101000020E61000006B2BF697DDCF2A40E9B7AF03E7424A40"
+
+func TestNoiseGeometryTransformer_Transform(t *testing.T) {
+	params := map[string]toolkit.ParamsValue{
+		"column": toolkit.ParamsValue("col_geometry"),
+		"radius": toolkit.ParamsValue("1000"),
+		"engine": toolkit.ParamsValue("hash"),
+	}
+
+	var results []string
+	for i := 0; i < 2; i++ {
+		driver, record := getDriverAndRecord("col_geometry", testGeometryPoint)
+		transformer, warnings, err := NoiseGeometryTransformerDefinition.Instance(
+			context.Background(),
+			driver,
+			params,
+			nil,
+			"",
+		)
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+
+		r, err := transformer.Transformer.Transform(context.Background(), record)
+		require.NoError(t, err)
+		res, err := r.GetRawColumnValueByName("col_geometry")
+		require.NoError(t, err)
+		require.False(t, res.IsNull)
+
+		g, err := transformers.ParseHexEWKB(res.Data)
+		require.NoError(t, err)
+		assert.Equal(t, transformers.GeometryPoint, g.Type)
+		assert.Equal(t, uint32(4326), g.SRID)
+		dLon, dLat := transformers.GeometryMetersToDegrees(1, 52.5208)
+		distance := math.Hypot((g.Points[0][0]-13.4049)/dLon, (g.Points[0][1]-52.5208)/dLat)
+		assert.LessOrEqual(t, distance, 1000.0+1e-6)
+		assert.Greater(t, distance, 0.0)
+		results = append(results, string(res.Data))
+	}
+	assert.Equal(t, results[0], results[1])
+}
+
+func TestNoiseGeometryTransformer_Transform_null_and_invalid(t *testing.T) {
+	params := map[string]toolkit.ParamsValue{
+		"column": toolkit.ParamsValue("col_geometry"),
+		"radius": toolkit.ParamsValue("10"),
+	}
+	driver, record := getDriverAndRecord("col_geometry", "\\N")
+	transformer, warnings, err := NoiseGeometryTransformerDefinition.Instance(
+		context.Background(), driver, params, nil, "",
+	)
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+	r, err := transformer.Transformer.Transform(context.Background(), record)
+	require.NoError(t, err)
+	res, err := r.GetRawColumnValueByName("col_geometry")
+	require.NoError(t, err)
+	assert.True(t, res.IsNull)
+
+	_, record = getDriverAndRecord("col_geometry", "POINT(1 2)")
+	_, err = transformer.Transformer.Transform(context.Background(), record)
+	require.Error(t, err)
+
+	params["radius"] = toolkit.ParamsValue("0")
+	_, warnings, err = NoiseGeometryTransformerDefinition.Instance(
+		context.Background(), driver, params, nil, "",
+	)
+	require.NoError(t, err)
+	assert.True(t, warnings.IsFatal())
+}
@@ -0,0 +1,231 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const (
+	RandomIBANTransformerName       = "RandomIBAN"
+	RandomCardNumberTransformerName = "RandomCardNumber"
+	RandomSSNTransformerName        = "RandomSSN"
+	RandomISBNTransformerName       = "RandomISBN"
+	RandomNationalIdTransformerName = "RandomNationalId"
+	RandomVATIdTransformerName      = "RandomVATId"
+)
+
+type ChecksumIdTransformerDef struct {
+	Kind        string
+	Description string
+	// KeepPrefixDescription - describes what is kept by keep_prefix. The parameter is not defined if it is empty
+	KeepPrefixDescription string
+	// DefaultCountry - the default value of the country parameter. The parameter is not defined if the kind does
+	// not depend on the country
+	DefaultCountry string
+}
+
+var ChecksumIdTransformersDefs = map[string]*ChecksumIdTransformerDef{
+	RandomIBANTransformerName: {
+		Kind:                  transformers.ChecksumIdIBAN,
+		Description:           "Generate IBAN with valid mod-97 check digits",
+		KeepPrefixDescription: "keep the country and the bank code of the original IBAN",
+		DefaultCountry:        "DE",
+	},
+	RandomCardNumberTransformerName: {
+		Kind:                  transformers.ChecksumIdCardNumber,
+		Description:           "Generate card number with valid Luhn check digit",
+		KeepPrefixDescription: "keep the BIN (6 first digits) and the length of the original card number",
+	},
+	RandomSSNTransformerName: {
+		Kind:                  transformers.ChecksumIdSSN,
+		Description:           "Generate US social security number in AAA-GG-SSSS format",
+		KeepPrefixDescription: "keep the area number of the original SSN",
+	},
+	RandomISBNTransformerName: {
+		Kind:                  transformers.ChecksumIdISBN,
+		Description:           "Generate ISBN-13 with valid check digit",
+		KeepPrefixDescription: "keep the EAN prefix and the first digit of the registration group of the original ISBN",
+	},
+	RandomNationalIdTransformerName: {
+		Kind:           transformers.ChecksumIdNationalId,
+		Description:    "Generate national identifier with valid check digit (IN - Aadhaar, NL - BSN)",
+		DefaultCountry: "NL",
+	},
+	RandomVATIdTransformerName: {
+		Kind:           transformers.ChecksumIdVATId,
+		Description:    "Generate VAT identification number with valid check digits (DE, FR)",
+		DefaultCountry: "DE",
+	},
+}
+
+func generateChecksumIdTransformers(registry *utils.TransformerRegistry) {
+	for name, def := range ChecksumIdTransformersDefs {
+		params := []*toolkit.ParameterDefinition{
+			toolkit.MustNewParameterDefinition(
+				"column",
+				"column name",
+			).SetIsColumn(toolkit.NewColumnProperties().
+				SetAffected(true).
+				SetAllowedColumnTypes("text", "varchar", "char", "bpchar", "citext"),
+			).SetRequired(true),
+
+			toolkit.MustNewParameterDefinition(
+				"keep_null",
+				"indicates that NULL values must not be replaced with transformed values",
+			).SetDefaultValue(toolkit.ParamsValue("true")),
+
+			engineParameterDefinition,
+		}
+		if def.KeepPrefixDescription != "" {
+			params = append(params, toolkit.MustNewParameterDefinition(
+				"keep_prefix",
+				def.KeepPrefixDescription,
+			).SetDefaultValue(toolkit.ParamsValue("false")))
+		}
+		if def.DefaultCountry != "" {
+			countries := transformers.ChecksumIdCountries(def.Kind)
+			allowedValues := make([]toolkit.ParamsValue, 0, len(countries))
+			for _, c := range countries {
+				allowedValues = append(allowedValues, toolkit.ParamsValue(c))
+			}
+			params = append(params, toolkit.MustNewParameterDefinition(
+				"country",
+				fmt.Sprintf("country code %v", countries),
+			).SetAllowedValues(allowedValues...).
+				SetDefaultValue(toolkit.ParamsValue(def.DefaultCountry)))
+		}
+
+		td := utils.NewTransformerDefinition(
+			utils.NewTransformerProperties(
+				name,
+				def.Description,
+			).AddMeta(AllowApplyForReferenced, true).
+				AddMeta(RequireHashEngineParameter, true),
+			MakeNewChecksumIdTransformerFunction(def.Kind),
+			params...,
+		)
+
+		registry.MustRegister(td)
+	}
+}
+
+type ChecksumIdTransformer struct {
+	t               *transformers.ChecksumId
+	columnName      string
+	columnIdx       int
+	keepNull        bool
+	affectedColumns map[int]string
+}
+
+func MakeNewChecksumIdTransformerFunction(kind string) utils.NewTransformerFunc {
+	return func(ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer) (utils.Transformer, toolkit.ValidationWarnings, error) {
+		return NewChecksumIdTransformer(ctx, driver, parameters, kind)
+	}
+}
+
+func NewChecksumIdTransformer(
+	ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer, kind string,
+) (utils.Transformer, toolkit.ValidationWarnings, error) {
+	var columnName, engine, country string
+	var keepNull, keepPrefix bool
+
+	if err := parameters["column"].Scan(&columnName); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "column" param: %w`, err)
+	}
+	idx, _, ok := driver.GetColumnByName(columnName)
+	if !ok {
+		return nil, nil, fmt.Errorf("column with name %s is not found", columnName)
+	}
+	affectedColumns := make(map[int]string)
+	affectedColumns[idx] = columnName
+
+	if err := parameters["keep_null"].Scan(&keepNull); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "keep_null" param: %w`, err)
+	}
+	if err := parameters["engine"].Scan(&engine); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "engine" param: %w`, err)
+	}
+	if p, ok := parameters["keep_prefix"]; ok {
+		if err := p.Scan(&keepPrefix); err != nil {
+			return nil, nil, fmt.Errorf(`unable to scan "keep_prefix" param: %w`, err)
+		}
+	}
+	if p, ok := parameters["country"]; ok {
+		if err := p.Scan(&country); err != nil {
+			return nil, nil, fmt.Errorf(`unable to scan "country" param: %w`, err)
+		}
+	}
+
+	t, err := transformers.NewChecksumId(kind, country, keepPrefix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create identifier generator: %w", err)
+	}
+	g, err := getGenerateEngine(ctx, engine, t.GetRequiredGeneratorByteLength())
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get generator: %w", err)
+	}
+	if err = t.SetGenerator(g); err != nil {
+		return nil, nil, fmt.Errorf("unable to set generator: %w", err)
+	}
+
+	return &ChecksumIdTransformer{
+		t:               t,
+		columnName:      columnName,
+		columnIdx:       idx,
+		keepNull:        keepNull,
+		affectedColumns: affectedColumns,
+	}, nil, nil
+}
+
+func (cit *ChecksumIdTransformer) GetAffectedColumns() map[int]string {
+	return cit.affectedColumns
+}
+
+func (cit *ChecksumIdTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (cit *ChecksumIdTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (cit *ChecksumIdTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	val, err := r.GetRawColumnValueByIdx(cit.columnIdx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan value: %w", err)
+	}
+	if val.IsNull && cit.keepNull {
+		return r, nil
+	}
+
+	res, err := cit.t.Generate(val.Data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to transform value: %w", err)
+	}
+	if err = r.SetRawColumnValueByIdx(cit.columnIdx, toolkit.NewRawValue(res, false)); err != nil {
+		return nil, fmt.Errorf("unable to set new value: %w", err)
+	}
+	return r, nil
+}
+
+func init() {
+	generateChecksumIdTransformers(utils.DefaultTransformerRegistry)
+}
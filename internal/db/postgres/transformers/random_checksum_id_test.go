@@ -0,0 +1,114 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestChecksumIdTransformer_Transform(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "salt", []byte("12345678"))
+	tests := []struct {
+		name     string
+		params   map[string]toolkit.ParamsValue
+		original *toolkit.RawValue
+		pattern  string
+	}{
+		{
+			name: RandomIBANTransformerName,
+			params: map[string]toolkit.ParamsValue{
+				"country": toolkit.ParamsValue("NL"),
+				"engine":  toolkit.ParamsValue("hash"),
+			},
+			original: toolkit.NewRawValue([]byte("GB29NWBK60161331926819"), false),
+			pattern:  `^NL\d{2}[A-Z]{4}\d{10}$`,
+		},
+		{
+			name: RandomIBANTransformerName,
+			params: map[string]toolkit.ParamsValue{
+				"keep_prefix": toolkit.ParamsValue("true"),
+			},
+			original: toolkit.NewRawValue([]byte("GB29NWBK60161331926819"), false),
+			pattern:  `^GB\d{2}NWBK601613\d{8}$`,
+		},
+		{
+			name: RandomCardNumberTransformerName,
+			params: map[string]toolkit.ParamsValue{
+				"keep_prefix": toolkit.ParamsValue("true"),
+			},
+			original: toolkit.NewRawValue([]byte("4111 1111 1111 1111"), false),
+			pattern:  `^411111\d{10}$`,
+		},
+		{
+			name:     RandomSSNTransformerName,
+			params:   map[string]toolkit.ParamsValue{},
+			original: toolkit.NewRawValue([]byte("123-45-6789"), false),
+			pattern:  `^\d{3}-\d{2}-\d{4}$`,
+		},
+		{
+			name:     RandomVATIdTransformerName,
+			params:   map[string]toolkit.ParamsValue{"country": toolkit.ParamsValue("FR")},
+			original: toolkit.NewRawValue([]byte("FR40303265045"), false),
+			pattern:  `^FR\d{11}$`,
+		},
+		{
+			name:     RandomSSNTransformerName,
+			params:   map[string]toolkit.ParamsValue{},
+			original: toolkit.NewRawValue(nil, true),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def, ok := utils.DefaultTransformerRegistry.Get(tt.name)
+			require.True(t, ok)
+			driver, record := toolkit.GetDriverAndRecord(map[string]*toolkit.RawValue{"data": tt.original})
+			tt.params["column"] = toolkit.ParamsValue("data")
+
+			transformer, warnings, err := def.Instance(ctx, driver, tt.params, nil, "")
+			require.NoError(t, err)
+			require.Empty(t, warnings)
+			r, err := transformer.Transformer.Transform(ctx, record)
+			require.NoError(t, err)
+			res, err := r.GetRawColumnValueByName("data")
+			require.NoError(t, err)
+			if tt.original.IsNull {
+				assert.True(t, res.IsNull)
+				return
+			}
+			assert.Regexp(t, tt.pattern, string(res.Data))
+		})
+	}
+}
+
+func TestChecksumIdTransformer_unsupportedCountry(t *testing.T) {
+	driver, _ := toolkit.GetDriverAndRecord(map[string]*toolkit.RawValue{
+		"data": toolkit.NewRawValue([]byte("123"), false),
+	})
+	def, ok := utils.DefaultTransformerRegistry.Get(RandomNationalIdTransformerName)
+	require.True(t, ok)
+	_, warnings, err := def.Instance(context.Background(), driver, map[string]toolkit.ParamsValue{
+		"column":  toolkit.ParamsValue("data"),
+		"country": toolkit.ParamsValue("US"),
+	}, nil, "")
+	require.NoError(t, err)
+	require.True(t, warnings.IsFatal())
+}
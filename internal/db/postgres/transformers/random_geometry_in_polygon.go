@@ -0,0 +1,131 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const RandomGeometryInPolygonTransformerName = "RandomGeometryInPolygon"
+
+var RandomGeometryInPolygonTransformerDefinition = utils.NewTransformerDefinition(
+	utils.NewTransformerProperties(
+		RandomGeometryInPolygonTransformerName,
+		"Move the PostGIS geometry to the random location within the polygon keeping its SRID and type",
+	),
+
+	NewRandomGeometryInPolygonTransformer,
+
+	toolkit.MustNewParameterDefinition(
+		"column",
+		"column name",
+	).SetIsColumn(toolkit.NewColumnProperties().
+		SetAffected(true).
+		SetAllowedColumnTypes(toolkit.PostgisTypeNames...),
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"polygon",
+		`the polygon in the units of the geometry SRID in format: [[x1, y1], [x2, y2], ...]`,
+	).SetRequired(true),
+
+	engineParameterDefinition,
+)
+
+type RandomGeometryInPolygonTransformer struct {
+	t               *transformers.GeometryInPolygon
+	columnName      string
+	columnIdx       int
+	affectedColumns map[int]string
+}
+
+func NewRandomGeometryInPolygonTransformer(
+	ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer,
+) (utils.Transformer, toolkit.ValidationWarnings, error) {
+	var columnName, engine string
+	var polygon [][]float64
+
+	if err := parameters["column"].Scan(&columnName); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "column" param: %w`, err)
+	}
+	idx, _, ok := driver.GetColumnByName(columnName)
+	if !ok {
+		return nil, nil, fmt.Errorf("column with name %s is not found", columnName)
+	}
+	affectedColumns := make(map[int]string)
+	affectedColumns[idx] = columnName
+
+	if err := parameters["polygon"].Scan(&polygon); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "polygon" param: %w`, err)
+	}
+	if err := parameters["engine"].Scan(&engine); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "engine" param: %w`, err)
+	}
+
+	t, err := transformers.NewGeometryInPolygon(polygon)
+	if err != nil {
+		return nil, toolkit.ValidationWarnings{
+			toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ParameterName", "polygon").
+				AddMeta("Error", err.Error()).
+				SetMsg("invalid polygon"),
+		}, nil
+	}
+	g, err := getGenerateEngine(ctx, engine, t.GetRequiredGeneratorByteLength())
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get generator: %w", err)
+	}
+	if err = t.SetGenerator(g); err != nil {
+		return nil, nil, fmt.Errorf("unable to set generator: %w", err)
+	}
+
+	return &RandomGeometryInPolygonTransformer{
+		t:               t,
+		columnName:      columnName,
+		columnIdx:       idx,
+		affectedColumns: affectedColumns,
+	}, nil, nil
+}
+
+func (rgt *RandomGeometryInPolygonTransformer) GetAffectedColumns() map[int]string {
+	return rgt.affectedColumns
+}
+
+func (rgt *RandomGeometryInPolygonTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (rgt *RandomGeometryInPolygonTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (rgt *RandomGeometryInPolygonTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	return transformGeometry(r, rgt.columnIdx, func(original []byte, g *transformers.Geometry) (*transformers.Geometry, error) {
+		if err := rgt.t.Transform(original, g); err != nil {
+			return nil, err
+		}
+		return g, nil
+	})
+}
+
+func init() {
+	utils.DefaultTransformerRegistry.MustRegister(RandomGeometryInPolygonTransformerDefinition)
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestRandomGeometryInPolygonTransformer_Transform(t *testing.T) {
+	polygon := [][]float64{{13.0, 52.3}, {13.8, 52.3}, {13.8, 52.7}, {13.0, 52.7}}
+	driver, record := getDriverAndRecord("col_geometry", testGeometryPoint)
+	transformer, warnings, err := RandomGeometryInPolygonTransformerDefinition.Instance(
+		context.Background(),
+		driver,
+		map[string]toolkit.ParamsValue{
+			"column":  toolkit.ParamsValue("col_geometry"),
+			"polygon": toolkit.ParamsValue("[[13.0, 52.3], [13.8, 52.3], [13.8, 52.7], [13.0, 52.7]]"),
+		},
+		nil,
+		"",
+	)
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+
+	r, err := transformer.Transformer.Transform(context.Background(), record)
+	require.NoError(t, err)
+	res, err := r.GetRawColumnValueByName("col_geometry")
+	require.NoError(t, err)
+	g, err := transformers.ParseHexEWKB(res.Data)
+	require.NoError(t, err)
+	assert.Equal(t, transformers.GeometryPoint, g.Type)
+	assert.Equal(t, uint32(4326), g.SRID)
+	assert.True(t, transformers.GeometryPolygonContains(polygon, g.Points[0][0], g.Points[0][1]))
+}
+
+func TestRandomGeometryInPolygonTransformer_invalid_polygon(t *testing.T) {
+	driver, _ := getDriverAndRecord("col_geometry", testGeometryPoint)
+	_, warnings, err := RandomGeometryInPolygonTransformerDefinition.Instance(
+		context.Background(),
+		driver,
+		map[string]toolkit.ParamsValue{
+			"column":  toolkit.ParamsValue("col_geometry"),
+			"polygon": toolkit.ParamsValue("[[13.0, 52.3], [13.8, 52.3]]"),
+		},
+		nil,
+		"",
+	)
+	require.NoError(t, err)
+	assert.True(t, warnings.IsFatal())
+}
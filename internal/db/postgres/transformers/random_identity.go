@@ -0,0 +1,346 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"text/template"
+	"time"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const RandomIdentityTransformerName = "RandomIdentity"
+
+var RandomIdentityTransformerDefinition = utils.NewTransformerDefinition(
+	utils.NewTransformerProperties(
+		RandomIdentityTransformerName,
+		"Generate the coherent fake persona (name, gender, email, username, phone, birthdate and address) "+
+			"by the key column",
+	),
+
+	NewRandomIdentityTransformer,
+
+	toolkit.MustNewParameterDefinition(
+		"column",
+		"persona key column name. The rows with the same key get the same persona with the hash engine",
+	).SetIsColumn(toolkit.NewColumnProperties().
+		SetAffected(false),
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"columns",
+		"affected column names."+
+			"The structure:"+
+			`{`+
+			`"name": "type:string, required:true, description: column Name",`+
+			`"attribute": "type:string, required:false, description: persona attribute to set",`+
+			`"template": "type:string, required:false, description: gotemplate with persona attributes injections",`+
+			`"keep_null": "type:bool, required:false, description: keep null values",`+
+			`}`,
+	).SetRequired(true).
+		SetIsColumnContainer(true),
+
+	toolkit.MustNewParameterDefinition(
+		"gender",
+		"set specific gender (possible values: Male, Female, Any)",
+	).SetDefaultValue(toolkit.ParamsValue(transformers.AnyGenderName)),
+
+	toolkit.MustNewParameterDefinition(
+		"min_age",
+		"min age of the persona in years",
+	).SetDefaultValue(toolkit.ParamsValue("18")),
+
+	toolkit.MustNewParameterDefinition(
+		"max_age",
+		"max age of the persona in years",
+	).SetDefaultValue(toolkit.ParamsValue("90")),
+
+	toolkit.MustNewParameterDefinition(
+		"reference_date",
+		"the date the age is calculated from. By default it is the current date",
+	).SetCastDbType("date"),
+
+	toolkit.MustNewParameterDefinition(
+		"email_domains",
+		"list of the email domains",
+	).SetDefaultValue(toolkit.ParamsValue(`["example.com", "example.net", "example.org"]`)),
+
+	toolkit.MustNewParameterDefinition(
+		"phone_format",
+		"phone number format. Each # is replaced by a digit",
+	).SetDefaultValue(toolkit.ParamsValue(transformers.DefaultIdentityPhoneFormat)),
+
+	toolkit.MustNewParameterDefinition(
+		"engine",
+		"The engine used for generating the persona [random, hash]. The hash engine makes the persona "+
+			"deterministic for the key",
+	).SetDefaultValue([]byte(HashEngineParameterName)).
+		SetRawValueValidator(engineValidator),
+)
+
+type randomIdentityColumn struct {
+	Name      string `json:"name"`
+	Attribute string `json:"attribute"`
+	Template  string `json:"template"`
+	KeepNull  *bool  `json:"keep_null"`
+	tmpl      *template.Template
+	columnIdx int
+}
+
+type RandomIdentityTransformer struct {
+	t               *transformers.RandomIdentityTransformer
+	keyColumnIdx    int
+	columns         []*randomIdentityColumn
+	affectedColumns map[int]string
+	buf             *bytes.Buffer
+}
+
+func NewRandomIdentityTransformer(
+	ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer,
+) (utils.Transformer, toolkit.ValidationWarnings, error) {
+	var keyColumn, gender, phoneFormat, engine string
+	var minAge, maxAge int
+	var emailDomains []string
+	var columns []*randomIdentityColumn
+
+	if err := parameters["column"].Scan(&keyColumn); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "column" param: %w`, err)
+	}
+	keyColumnIdx, _, ok := driver.GetColumnByName(keyColumn)
+	if !ok {
+		return nil, nil, fmt.Errorf("column with name %s is not found", keyColumn)
+	}
+	if err := parameters["columns"].Scan(&columns); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "columns" param: %w`, err)
+	}
+	if err := parameters["gender"].Scan(&gender); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "gender" param: %w`, err)
+	}
+	if err := parameters["min_age"].Scan(&minAge); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "min_age" param: %w`, err)
+	}
+	if err := parameters["max_age"].Scan(&maxAge); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "max_age" param: %w`, err)
+	}
+	if err := parameters["email_domains"].Scan(&emailDomains); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "email_domains" param: %w`, err)
+	}
+	if err := parameters["phone_format"].Scan(&phoneFormat); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "phone_format" param: %w`, err)
+	}
+	if err := parameters["engine"].Scan(&engine); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "engine" param: %w`, err)
+	}
+
+	referenceDate := time.Now()
+	empty, err := parameters["reference_date"].IsEmpty()
+	if err != nil {
+		return nil, nil, fmt.Errorf(`unable to check "reference_date" param: %w`, err)
+	}
+	if !empty {
+		if err := parameters["reference_date"].Scan(&referenceDate); err != nil {
+			return nil, nil, fmt.Errorf(`unable to scan "reference_date" param: %w`, err)
+		}
+	}
+
+	warns := randomNameTransformerValidateGender(gender, transformers.DefaultDb.Genders)
+	if minAge < 0 || minAge > maxAge {
+		warns = append(warns, toolkit.NewValidationWarning().
+			SetMsg("min_age must be positive and less than or equal to max_age").
+			AddMeta("ParameterName", "min_age").
+			SetSeverity(toolkit.ErrorValidationSeverity),
+		)
+	}
+	affectedColumns, columnsWarns := validateRandomIdentityColumns(driver, keyColumn, columns)
+	warns = append(warns, columnsWarns...)
+	if warns.IsFatal() {
+		return nil, warns, nil
+	}
+
+	t, err := transformers.NewRandomIdentityTransformer(gender, minAge, maxAge, referenceDate, emailDomains, phoneFormat)
+	if err != nil {
+		return nil, toolkit.ValidationWarnings{
+			toolkit.NewValidationWarning().
+				SetMsg(err.Error()).
+				AddMeta("ParameterName", "phone_format").
+				SetSeverity(toolkit.ErrorValidationSeverity),
+		}, nil
+	}
+	g, err := getGenerateEngine(ctx, engine, t.GetRequiredGeneratorByteLength())
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get generator: %w", err)
+	}
+	if err = t.SetGenerator(g); err != nil {
+		return nil, nil, fmt.Errorf("unable to set generator: %w", err)
+	}
+
+	return &RandomIdentityTransformer{
+		t:               t,
+		keyColumnIdx:    keyColumnIdx,
+		columns:         columns,
+		affectedColumns: affectedColumns,
+		buf:             bytes.NewBuffer(nil),
+	}, warns, nil
+}
+
+// validateRandomIdentityColumns - checks that every column is set either by the persona attribute or by the
+// template and compiles the templates
+func validateRandomIdentityColumns(
+	driver *toolkit.Driver, keyColumn string, columns []*randomIdentityColumn,
+) (map[int]string, toolkit.ValidationWarnings) {
+	affectedColumns := make(map[int]string)
+	var warns toolkit.ValidationWarnings
+
+	for idx, c := range columns {
+		if c.Name == "" {
+			warns = append(warns, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ParameterName", "columns").
+				AddMeta("ListIdx", idx).
+				SetMsg("name is required"),
+			)
+			continue
+		}
+		columnIdx, _, ok := driver.GetColumnByName(c.Name)
+		if !ok {
+			warns = append(warns, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ParameterName", "columns").
+				AddMeta("ParameterValue", c.Name).
+				AddMeta("ListIdx", idx).
+				SetMsg("column is not found"),
+			)
+			continue
+		}
+		if c.Name == keyColumn {
+			warns = append(warns, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ParameterName", "columns").
+				AddMeta("ParameterValue", c.Name).
+				SetMsg("key column cannot be transformed"),
+			)
+			continue
+		}
+		c.columnIdx = columnIdx
+		affectedColumns[columnIdx] = c.Name
+
+		if c.KeepNull == nil {
+			defaultKeepNullValue := true
+			c.KeepNull = &defaultKeepNullValue
+		}
+
+		if (c.Attribute == "") == (c.Template == "") {
+			warns = append(warns, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ParameterName", "columns").
+				AddMeta("ListIdx", idx).
+				SetMsg(`either "attribute" or "template" must be set`),
+			)
+			continue
+		}
+		if c.Attribute != "" {
+			if !slices.Contains(transformers.IdentityAttributes, c.Attribute) {
+				warns = append(warns, toolkit.NewValidationWarning().
+					SetSeverity(toolkit.ErrorValidationSeverity).
+					AddMeta("ParameterName", "columns").
+					AddMeta("ParameterValue", c.Attribute).
+					AddMeta("AllowedValues", transformers.IdentityAttributes).
+					AddMeta("ListIdx", idx).
+					SetMsg("unknown persona attribute"),
+				)
+			}
+			continue
+		}
+		tmpl, err := template.New(c.Name).
+			Funcs(toolkit.FuncMap()).
+			Parse(c.Template)
+		if err != nil {
+			warns = append(warns, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("Error", err.Error()).
+				AddMeta("ParameterName", "columns").
+				AddMeta("ListIdx", idx).
+				SetMsg("error parsing template"),
+			)
+			continue
+		}
+		c.tmpl = tmpl
+	}
+	return affectedColumns, warns
+}
+
+func (rit *RandomIdentityTransformer) GetAffectedColumns() map[int]string {
+	return rit.affectedColumns
+}
+
+func (rit *RandomIdentityTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (rit *RandomIdentityTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (rit *RandomIdentityTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	key, err := r.GetRawColumnValueByIdx(rit.keyColumnIdx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get key value: %w", err)
+	}
+	// The rows with NULL key get the same persona as the rows with the empty key
+	var keyData []byte
+	if !key.IsNull {
+		keyData = key.Data
+	}
+	identity, err := rit.t.GetIdentity(keyData)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range rit.columns {
+		if *c.KeepNull {
+			rawVal, err := r.GetRawColumnValueByIdx(c.columnIdx)
+			if err != nil {
+				return nil, fmt.Errorf("unable to get raw value by idx %d: %w", c.columnIdx, err)
+			}
+			if rawVal.IsNull {
+				continue
+			}
+		}
+		newRawVal := toolkit.NewRawValue(nil, false)
+		if c.Attribute != "" {
+			newRawVal.Data = []byte(identity[c.Attribute])
+		} else {
+			rit.buf.Reset()
+			if err = c.tmpl.Execute(rit.buf, identity); err != nil {
+				return nil, fmt.Errorf("error executing template for column %s: %w", c.Name, err)
+			}
+			newRawVal.Data = slices.Clone(rit.buf.Bytes())
+		}
+		if err = r.SetRawColumnValueByIdx(c.columnIdx, newRawVal); err != nil {
+			return nil, fmt.Errorf("unable to set new value for column \"%s\": %w", c.Name, err)
+		}
+	}
+	return r, nil
+}
+
+func init() {
+	utils.DefaultTransformerRegistry.MustRegister(RandomIdentityTransformerDefinition)
+}
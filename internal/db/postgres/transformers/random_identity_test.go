@@ -0,0 +1,139 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestRandomIdentityTransformer_Transform(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "salt", []byte("12345678"))
+	params := map[string]toolkit.ParamsValue{
+		"column": toolkit.ParamsValue("id4"),
+		"columns": toolkit.ParamsValue(`[
+			{"name": "data", "template": "{{ .FirstName }} {{ .LastName }} <{{ .Email }}>"},
+			{"name": "data2", "attribute": "Username"},
+			{"name": "date_date", "attribute": "BirthDate"}
+		]`),
+		"min_age":        toolkit.ParamsValue("20"),
+		"max_age":        toolkit.ParamsValue("30"),
+		"reference_date": toolkit.ParamsValue("2024-01-01"),
+		"email_domains":  toolkit.ParamsValue(`["corp.test"]`),
+	}
+
+	transform := func(key string) (string, string, time.Time) {
+		driver, record := toolkit.GetDriverAndRecord(map[string]*toolkit.RawValue{
+			"id4":       toolkit.NewRawValue([]byte(key), false),
+			"data":      toolkit.NewRawValue([]byte("John Doe"), false),
+			"data2":     toolkit.NewRawValue([]byte("jdoe"), false),
+			"date_date": toolkit.NewRawValue([]byte("2000-01-01"), false),
+		})
+		tc, warnings, err := RandomIdentityTransformerDefinition.Instance(ctx, driver, params, nil, "")
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+		assert.Len(t, tc.Transformer.GetAffectedColumns(), 3)
+
+		r, err := tc.Transformer.Transform(ctx, record)
+		require.NoError(t, err)
+		data, err := r.GetRawColumnValueByName("data")
+		require.NoError(t, err)
+		data2, err := r.GetRawColumnValueByName("data2")
+		require.NoError(t, err)
+		var birthDate time.Time
+		_, err = r.ScanColumnValueByName("date_date", &birthDate)
+		require.NoError(t, err)
+		return string(data.Data), string(data2.Data), birthDate
+	}
+
+	name, username, birthDate := transform("42")
+	assert.True(t, strings.HasSuffix(name, "@corp.test>"))
+	lastName := strings.Fields(name)[1]
+	assert.Contains(t, username, strings.ToLower(lastName[:1]))
+	assert.True(t, birthDate.After(time.Date(1993, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, birthDate.After(time.Date(2004, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	// The same key gets the same persona
+	otherName, otherUsername, otherBirthDate := transform("42")
+	assert.Equal(t, name, otherName)
+	assert.Equal(t, username, otherUsername)
+	assert.Equal(t, birthDate, otherBirthDate)
+}
+
+func TestRandomIdentityTransformer_validation(t *testing.T) {
+	ctx := context.Background()
+	driver, _ := toolkit.GetDriverAndRecord(map[string]*toolkit.RawValue{
+		"id4":  toolkit.NewRawValue([]byte("1"), false),
+		"data": toolkit.NewRawValue([]byte("John"), false),
+	})
+
+	tests := []struct {
+		name    string
+		columns string
+		params  map[string]toolkit.ParamsValue
+		msg     string
+	}{
+		{
+			name:    "unknown attribute",
+			columns: `[{"name": "data", "attribute": "Nickname"}]`,
+			msg:     "unknown persona attribute",
+		},
+		{
+			name:    "both attribute and template",
+			columns: `[{"name": "data", "attribute": "Email", "template": "{{ .Email }}"}]`,
+			msg:     `either "attribute" or "template" must be set`,
+		},
+		{
+			name:    "key column",
+			columns: `[{"name": "id4", "attribute": "Email"}]`,
+			msg:     "key column cannot be transformed",
+		},
+		{
+			name:    "wrong age",
+			columns: `[{"name": "data", "attribute": "Email"}]`,
+			params:  map[string]toolkit.ParamsValue{"min_age": toolkit.ParamsValue("50")},
+			msg:     "min_age must be positive and less than or equal to max_age",
+		},
+		{
+			name:    "wrong gender",
+			columns: `[{"name": "data", "attribute": "Email"}]`,
+			params:  map[string]toolkit.ParamsValue{"gender": toolkit.ParamsValue("Unknown")},
+			msg:     "wrong gender name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]toolkit.ParamsValue{
+				"column":  toolkit.ParamsValue("id4"),
+				"columns": toolkit.ParamsValue(tt.columns),
+				"max_age": toolkit.ParamsValue("40"),
+			}
+			for k, v := range tt.params {
+				params[k] = v
+			}
+			_, warnings, err := RandomIdentityTransformerDefinition.Instance(ctx, driver, params, nil, "")
+			require.NoError(t, err)
+			require.True(t, warnings.IsFatal())
+			assert.Equal(t, tt.msg, warnings[0].Msg)
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/generators/transformers"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+const ScrubTextTransformerName = "ScrubText"
+
+var ScrubTextTransformerDefinition = utils.NewTransformerDefinition(
+	utils.NewTransformerProperties(
+		ScrubTextTransformerName,
+		"Replace emails, phone numbers, card numbers, IBANs, SSNs and custom patterns found in the text",
+	),
+
+	NewScrubTextTransformer,
+
+	toolkit.MustNewParameterDefinition(
+		"column",
+		"column name",
+	).SetIsColumn(toolkit.NewColumnProperties().
+		SetAffected(true).
+		SetAllowedColumnTypes("text", "varchar", "char", "bpchar", "citext"),
+	).SetRequired(true),
+
+	toolkit.MustNewParameterDefinition(
+		"detectors",
+		fmt.Sprintf("list of the built-in detectors to use %v", transformers.TextDetectors),
+	).SetDefaultValue(toolkit.ParamsValue(
+		`["`+strings.Join(transformers.TextDetectors, `", "`)+`"]`,
+	)),
+
+	toolkit.MustNewParameterDefinition(
+		"custom_detectors",
+		`list of the user-defined detectors in format: [{"name": "string", "regexp": "string"}]`,
+	).SetDefaultValue(toolkit.ParamsValue("[]")),
+
+	toolkit.MustNewParameterDefinition(
+		"mode",
+		"replacement mode: fake - replace with the fake value of the same type, tag - replace with the tag like [EMAIL]",
+	).SetAllowedValues(
+		toolkit.ParamsValue(transformers.TextScrubberModeFake),
+		toolkit.ParamsValue(transformers.TextScrubberModeTag),
+	).SetDefaultValue(toolkit.ParamsValue(transformers.TextScrubberModeFake)),
+
+	engineParameterDefinition,
+)
+
+type scrubTextCustomDetector struct {
+	Name   string `json:"name"`
+	Regexp string `json:"regexp"`
+}
+
+type ScrubTextTransformer struct {
+	t               *transformers.TextScrubber
+	columnName      string
+	columnIdx       int
+	affectedColumns map[int]string
+}
+
+func NewScrubTextTransformer(
+	ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer,
+) (utils.Transformer, toolkit.ValidationWarnings, error) {
+	var columnName, mode, engine string
+	var detectorNames []string
+	var customDetectors []*scrubTextCustomDetector
+
+	if err := parameters["column"].Scan(&columnName); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "column" param: %w`, err)
+	}
+	idx, _, ok := driver.GetColumnByName(columnName)
+	if !ok {
+		return nil, nil, fmt.Errorf("column with name %s is not found", columnName)
+	}
+	affectedColumns := make(map[int]string)
+	affectedColumns[idx] = columnName
+
+	if err := parameters["detectors"].Scan(&detectorNames); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "detectors" param: %w`, err)
+	}
+	if err := parameters["custom_detectors"].Scan(&customDetectors); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "custom_detectors" param: %w`, err)
+	}
+	if err := parameters["mode"].Scan(&mode); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "mode" param: %w`, err)
+	}
+	if err := parameters["engine"].Scan(&engine); err != nil {
+		return nil, nil, fmt.Errorf(`unable to scan "engine" param: %w`, err)
+	}
+
+	var warns toolkit.ValidationWarnings
+	for _, name := range detectorNames {
+		if !slices.Contains(transformers.TextDetectors, name) {
+			warns = append(warns, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ParameterName", "detectors").
+				AddMeta("ParameterValue", name).
+				AddMeta("AllowedValues", transformers.TextDetectors).
+				SetMsg("unknown detector"),
+			)
+		}
+	}
+
+	// The built-in detectors keep their priority order and go before the custom ones
+	var detectors []*transformers.TextDetector
+	for _, name := range transformers.TextDetectors {
+		if !slices.Contains(detectorNames, name) {
+			continue
+		}
+		d, err := transformers.NewBuiltInTextDetector(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to create detector: %w", err)
+		}
+		detectors = append(detectors, d)
+	}
+	for _, cd := range customDetectors {
+		d, err := transformers.NewCustomTextDetector(cd.Name, cd.Regexp)
+		if err != nil {
+			warns = append(warns, toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ParameterName", "custom_detectors").
+				AddMeta("DetectorName", cd.Name).
+				AddMeta("Error", err.Error()).
+				SetMsg("invalid custom detector"),
+			)
+			continue
+		}
+		detectors = append(detectors, d)
+	}
+	if warns.IsFatal() {
+		return nil, warns, nil
+	}
+
+	t, err := transformers.NewTextScrubber(detectors, mode)
+	if err != nil {
+		return nil, toolkit.ValidationWarnings{
+			toolkit.NewValidationWarning().
+				SetSeverity(toolkit.ErrorValidationSeverity).
+				AddMeta("ParameterName", "detectors").
+				AddMeta("Error", err.Error()).
+				SetMsg("unable to create text scrubber"),
+		}, nil
+	}
+	g, err := getGenerateEngine(ctx, engine, t.GetRequiredGeneratorByteLength())
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get generator: %w", err)
+	}
+	if err = t.SetGenerator(g); err != nil {
+		return nil, nil, fmt.Errorf("unable to set generator: %w", err)
+	}
+
+	return &ScrubTextTransformer{
+		t:               t,
+		columnName:      columnName,
+		columnIdx:       idx,
+		affectedColumns: affectedColumns,
+	}, nil, nil
+}
+
+func (stt *ScrubTextTransformer) GetAffectedColumns() map[int]string {
+	return stt.affectedColumns
+}
+
+func (stt *ScrubTextTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (stt *ScrubTextTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+// GetStats - returns the number of the replaced findings per detector
+func (stt *ScrubTextTransformer) GetStats() map[string]uint64 {
+	return stt.t.GetHits()
+}
+
+func (stt *ScrubTextTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	val, err := r.GetRawColumnValueByIdx(stt.columnIdx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan value: %w", err)
+	}
+	if val.IsNull {
+		return r, nil
+	}
+
+	res, err := stt.t.Scrub(val.Data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to scrub text: %w", err)
+	}
+	if err = r.SetRawColumnValueByIdx(stt.columnIdx, toolkit.NewRawValue(res, false)); err != nil {
+		return nil, fmt.Errorf("unable to set new value: %w", err)
+	}
+	return r, nil
+}
+
+func init() {
+	utils.DefaultTransformerRegistry.MustRegister(ScrubTextTransformerDefinition)
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func TestScrubTextTransformer_Transform(t *testing.T) {
+	tests := []struct {
+		name         string
+		original     string
+		params       map[string]toolkit.ParamsValue
+		expected     string
+		expectedNull bool
+		stats        map[string]uint64
+	}{
+		{
+			name:     "tag",
+			original: "Contact john.doe@example.com or +1-202-555-0143, ticket TCK-004211",
+			params: map[string]toolkit.ParamsValue{
+				"mode":             toolkit.ParamsValue("tag"),
+				"custom_detectors": toolkit.ParamsValue(`[{"name": "ticket", "regexp": "TCK-\\d{6}"}]`),
+			},
+			expected: "Contact [EMAIL] or [PHONE], ticket [TICKET]",
+			stats: map[string]uint64{
+				"email": 1, "phone": 1, "iban": 0, "card_number": 0, "ssn": 0, "ticket": 1,
+			},
+		},
+		{
+			name:     "only selected detectors",
+			original: "Contact john.doe@example.com or +1-202-555-0143",
+			params: map[string]toolkit.ParamsValue{
+				"mode":      toolkit.ParamsValue("tag"),
+				"detectors": toolkit.ParamsValue(`["phone"]`),
+			},
+			expected: "Contact john.doe@example.com or [PHONE]",
+			stats:    map[string]uint64{"phone": 1},
+		},
+		{
+			name:     "fake deterministic",
+			original: "SSN 123-45-6789",
+			params: map[string]toolkit.ParamsValue{
+				"engine": toolkit.ParamsValue("hash"),
+			},
+			expected: "SSN 323-54-0968",
+			stats: map[string]uint64{
+				"email": 0, "phone": 0, "iban": 0, "card_number": 0, "ssn": 1,
+			},
+		},
+		{
+			name:         "keep NULL",
+			original:     "\\N",
+			params:       map[string]toolkit.ParamsValue{},
+			expectedNull: true,
+			stats: map[string]uint64{
+				"email": 0, "phone": 0, "iban": 0, "card_number": 0, "ssn": 0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params["column"] = toolkit.ParamsValue("data")
+			driver, record := getDriverAndRecord("data", tt.original)
+			transformer, warnings, err := ScrubTextTransformerDefinition.Instance(
+				context.Background(),
+				driver,
+				tt.params,
+				nil,
+				"",
+			)
+			require.NoError(t, err)
+			require.Empty(t, warnings)
+
+			r, err := transformer.Transformer.Transform(context.Background(), record)
+			require.NoError(t, err)
+			res, err := r.GetRawColumnValueByName("data")
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedNull, res.IsNull)
+			if !tt.expectedNull {
+				assert.Equal(t, tt.expected, string(res.Data))
+			}
+
+			sr, ok := transformer.Transformer.(utils.StatsReporter)
+			require.True(t, ok)
+			assert.Equal(t, tt.stats, sr.GetStats())
+		})
+	}
+}
+
+func TestScrubTextTransformer_validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]toolkit.ParamsValue
+	}{
+		{
+			name:   "unknown detector",
+			params: map[string]toolkit.ParamsValue{"detectors": toolkit.ParamsValue(`["passport"]`)},
+		},
+		{
+			name: "invalid regexp",
+			params: map[string]toolkit.ParamsValue{
+				"custom_detectors": toolkit.ParamsValue(`[{"name": "test", "regexp": "("}]`),
+			},
+		},
+		{
+			name:   "no detectors",
+			params: map[string]toolkit.ParamsValue{"detectors": toolkit.ParamsValue(`[]`)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params["column"] = toolkit.ParamsValue("data")
+			driver, _ := getDriverAndRecord("data", "test")
+			_, warnings, err := ScrubTextTransformerDefinition.Instance(
+				context.Background(),
+				driver,
+				tt.params,
+				nil,
+				"",
+			)
+			require.NoError(t, err)
+			require.True(t, warnings.IsFatal())
+		})
+	}
+}
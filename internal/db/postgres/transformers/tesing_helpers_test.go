@@ -172,6 +172,22 @@ var columnList = []*toolkit.Column{
 		NotNull:  false,
 		Length:   -1,
 	},
+	{
+		Name:     "col_bytea",
+		TypeName: "bytea",
+		TypeOid:  pgtype.ByteaOID,
+		Num:      18,
+		NotNull:  false,
+		Length:   -1,
+	},
+	{
+		Name:     "col_geometry",
+		TypeName: "geometry",
+		TypeOid:  100001,
+		Num:      19,
+		NotNull:  false,
+		Length:   -1,
+	},
 }
 
 // getDriverAndRecord - return adhoc table for testing
@@ -13,6 +13,9 @@ import (
 const (
 	AllowApplyForReferenced    utils.MetaKey = "AllowApplyForReferenced"
 	RequireHashEngineParameter utils.MetaKey = "RequireHashEngineParameter"
+	// ResetParamsForReferences - the parameters ([]string) that are related to the root table only, so they are
+	// removed from the transformer config that is applied for the references
+	ResetParamsForReferences utils.MetaKey = "ResetParamsForReferences"
 )
 
 func getGenerateEngine(ctx context.Context, engineName string, size int) (generators.Generator, error) {
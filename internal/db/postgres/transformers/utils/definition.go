@@ -108,6 +108,7 @@ func (d *TransformerDefinition) Instance(
 	res = append(res, condWarns...)
 
 	return &TransformerContext{
+		Name:              d.Properties.Name,
 		Transformer:       t,
 		StaticParameters:  staticParams,
 		DynamicParameters: dynamicParams,
@@ -116,12 +117,28 @@ func (d *TransformerDefinition) Instance(
 }
 
 type TransformerContext struct {
+	Name              string
 	Transformer       Transformer
 	StaticParameters  map[string]*toolkit.StaticParameter
 	DynamicParameters map[string]*toolkit.DynamicParameter
 	When              *toolkit.WhenCond
+	// OnError - the behaviour of the pipeline when the transformer returns an error
+	OnError *OnErrorPolicy
 }
 
 func (tc *TransformerContext) EvaluateWhen(r *toolkit.Record) (bool, error) {
 	return tc.When.Evaluate(r)
 }
+
+// AffectedColumns - returns the sorted indexes of the table columns affected by the transformer. All the columns are
+// returned if the transformer does not declare them
+func (tc *TransformerContext) AffectedColumns(columns []*toolkit.Column) []int {
+	affected := tc.Transformer.GetAffectedColumns()
+	res := make([]int, 0, len(columns))
+	for idx := range columns {
+		if _, ok := affected[idx]; ok || len(affected) == 0 {
+			res = append(res, idx)
+		}
+	}
+	return res
+}
@@ -104,3 +104,14 @@ func TestDefinition(t *testing.T) {
 	require.NoError(t, err)
 	assert.Empty(t, warnings)
 }
+
+func TestTransformerContext_AffectedColumns(t *testing.T) {
+	columns := []*toolkit.Column{{Name: "name"}, {Name: "email"}, {Name: "age"}}
+
+	tc := &TransformerContext{Transformer: &counterTransformer{}}
+	assert.Equal(t, []int{0}, tc.AffectedColumns(columns))
+
+	// All the columns are affected if the transformer does not declare them
+	tc = &TransformerContext{Transformer: &TestTransformer{}}
+	assert.Equal(t, []int{0, 1, 2}, tc.AffectedColumns(columns))
+}
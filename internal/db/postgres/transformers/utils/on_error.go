@@ -0,0 +1,43 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import "github.com/greenmaskio/greenmask/pkg/toolkit"
+
+const (
+	OnErrorFail         = "fail"
+	OnErrorSkip         = "skip"
+	OnErrorSetNull      = "set_null"
+	OnErrorSetDefault   = "set_default"
+	OnErrorKeepOriginal = "keep_original"
+)
+
+var OnErrorPolicies = []string{
+	OnErrorFail, OnErrorSkip, OnErrorSetNull, OnErrorSetDefault, OnErrorKeepOriginal,
+}
+
+// OnErrorPolicy - the behaviour of the transformation pipeline when the transformer returns an error. The nil policy
+// means fail
+type OnErrorPolicy struct {
+	Policy string
+	// Defaults - the values of the affected columns by the column index for set_default policy. The columns without
+	// the value are set to NULL
+	Defaults map[int]*toolkit.RawValue
+}
+
+// IsFail - returns true if the transformation error must fail the dump
+func (p *OnErrorPolicy) IsFail() bool {
+	return p == nil || p.Policy == "" || p.Policy == OnErrorFail
+}
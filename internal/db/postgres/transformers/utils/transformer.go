@@ -26,3 +26,9 @@ type Transformer interface {
 	Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error)
 	GetAffectedColumns() map[int]string
 }
+
+// StatsReporter - optional interface of the transformer that collects its own counters during the transformation,
+// e.g. the number of findings per detector. The counters are printed by the validate command
+type StatsReporter interface {
+	GetStats() map[string]uint64
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// Vault - the storage of the original to fake values mappings
+type Vault interface {
+	Lookup(ctx context.Context, namespace string, original []byte) ([]byte, bool, error)
+	Store(ctx context.Context, namespace string, original, fake []byte) ([]byte, error)
+}
+
+// VaultTransformer - wraps the transformer of a single column and keeps the original to fake values mappings in the
+// vault. If the original value is mapped already the stored fake value is set without calling the transformer,
+// otherwise the transformer result is stored. NULL values are passed to the transformer as is
+type VaultTransformer struct {
+	Transformer
+	vault     Vault
+	namespace string
+	columnIdx int
+}
+
+func NewVaultTransformer(t Transformer, v Vault, namespace string) (*VaultTransformer, error) {
+	affected := t.GetAffectedColumns()
+	if len(affected) != 1 {
+		return nil, fmt.Errorf("vault requires the transformer of a single column: got %d affected columns", len(affected))
+	}
+	vt := &VaultTransformer{
+		Transformer: t,
+		vault:       v,
+		namespace:   namespace,
+	}
+	for idx := range affected {
+		vt.columnIdx = idx
+	}
+	return vt, nil
+}
+
+func (vt *VaultTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	rawValue, err := r.GetRawColumnValueByIdx(vt.columnIdx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get raw value: %w", err)
+	}
+	if rawValue.IsNull {
+		return vt.Transformer.Transform(ctx, r)
+	}
+	// The raw data might be reused by the record, so it must be copied before the transformation
+	original := bytes.Clone(rawValue.Data)
+
+	fake, ok, err := vt.vault.Lookup(ctx, vt.namespace, original)
+	if err != nil {
+		return nil, fmt.Errorf("vault lookup error: %w", err)
+	}
+	if ok {
+		if err = r.SetRawColumnValueByIdx(vt.columnIdx, toolkit.NewRawValue(fake, false)); err != nil {
+			return nil, fmt.Errorf("unable to set raw value: %w", err)
+		}
+		return r, nil
+	}
+
+	r, err = vt.Transformer.Transform(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	rawValue, err = r.GetRawColumnValueByIdx(vt.columnIdx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get raw value: %w", err)
+	}
+	if rawValue.IsNull {
+		return r, nil
+	}
+	stored, err := vt.vault.Store(ctx, vt.namespace, original, rawValue.Data)
+	if err != nil {
+		return nil, fmt.Errorf("vault store error: %w", err)
+	}
+	if !bytes.Equal(stored, rawValue.Data) {
+		// The value was mapped by the concurrent dumper
+		if err = r.SetRawColumnValueByIdx(vt.columnIdx, toolkit.NewRawValue(stored, false)); err != nil {
+			return nil, fmt.Errorf("unable to set raw value: %w", err)
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgcopy"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+type testVault struct {
+	m map[string]string
+	// overrides - the fake values that are stored "concurrently" before the Store call
+	overrides map[string]string
+}
+
+func newTestVault() *testVault {
+	return &testVault{
+		m:         make(map[string]string),
+		overrides: make(map[string]string),
+	}
+}
+
+func (tv *testVault) Lookup(_ context.Context, namespace string, original []byte) ([]byte, bool, error) {
+	fake, ok := tv.m[namespace+"."+string(original)]
+	return []byte(fake), ok, nil
+}
+
+func (tv *testVault) Store(_ context.Context, namespace string, original, fake []byte) ([]byte, error) {
+	key := namespace + "." + string(original)
+	if o, ok := tv.overrides[key]; ok {
+		tv.m[key] = o
+	}
+	if stored, ok := tv.m[key]; ok {
+		return []byte(stored), nil
+	}
+	tv.m[key] = string(fake)
+	return fake, nil
+}
+
+type counterTransformer struct {
+	calls int
+}
+
+func (ct *counterTransformer) Init(ctx context.Context) error {
+	return nil
+}
+
+func (ct *counterTransformer) Done(ctx context.Context) error {
+	return nil
+}
+
+func (ct *counterTransformer) Transform(ctx context.Context, r *toolkit.Record) (*toolkit.Record, error) {
+	ct.calls++
+	raw, err := r.GetRawColumnValueByIdx(0)
+	if err != nil {
+		return nil, err
+	}
+	if raw.IsNull {
+		return r, nil
+	}
+	return r, r.SetRawColumnValueByIdx(0, toolkit.NewRawValue([]byte(fmt.Sprintf("fake%d", ct.calls)), false))
+}
+
+func (ct *counterTransformer) GetAffectedColumns() map[int]string {
+	return map[int]string{0: "name"}
+}
+
+func newVaultTestRecord(t *testing.T, value string) *toolkit.Record {
+	table := &toolkit.Table{
+		Schema: "public",
+		Name:   "test",
+		Oid:    1224,
+		Columns: []*toolkit.Column{
+			{
+				Name:     "name",
+				TypeName: "text",
+				TypeOid:  pgtype.TextOID,
+				Num:      1,
+				Length:   -1,
+			},
+		},
+		Constraints: []toolkit.Constraint{},
+	}
+	driver, _, err := toolkit.NewDriver(table, nil)
+	require.NoError(t, err)
+	row := pgcopy.NewRow(1)
+	require.NoError(t, row.Decode([]byte(value)))
+	r := toolkit.NewRecord(driver)
+	r.SetRow(row)
+	return r
+}
+
+func transformVaultTestRecord(t *testing.T, vt *VaultTransformer, value string) *toolkit.RawValue {
+	r, err := vt.Transform(context.Background(), newVaultTestRecord(t, value))
+	require.NoError(t, err)
+	raw, err := r.GetRawColumnValueByIdx(0)
+	require.NoError(t, err)
+	return raw
+}
+
+func TestVaultTransformer_Transform(t *testing.T) {
+	v := newTestVault()
+	ct := &counterTransformer{}
+	vt, err := NewVaultTransformer(ct, v, "users.name")
+	require.NoError(t, err)
+
+	raw := transformVaultTestRecord(t, vt, "John")
+	assert.Equal(t, "fake1", string(raw.Data))
+	raw = transformVaultTestRecord(t, vt, "Jane")
+	assert.Equal(t, "fake2", string(raw.Data))
+
+	// The stored mapping is reused without the transformation
+	raw = transformVaultTestRecord(t, vt, "John")
+	assert.Equal(t, "fake1", string(raw.Data))
+	assert.Equal(t, 2, ct.calls)
+
+	// NULL values are not stored
+	raw = transformVaultTestRecord(t, vt, `\N`)
+	assert.True(t, raw.IsNull)
+	assert.Equal(t, 3, ct.calls)
+	assert.Len(t, v.m, 2)
+}
+
+func TestVaultTransformer_Transform_concurrent_store(t *testing.T) {
+	v := newTestVault()
+	v.overrides["users.name.John"] = "stored"
+	vt, err := NewVaultTransformer(&counterTransformer{}, v, "users.name")
+	require.NoError(t, err)
+
+	raw := transformVaultTestRecord(t, vt, "John")
+	assert.Equal(t, "stored", string(raw.Data))
+}
+
+func TestNewVaultTransformer_multiple_columns(t *testing.T) {
+	_, err := NewVaultTransformer(&TestTransformer{}, newTestVault(), "users.name")
+	require.Error(t, err)
+}
@@ -17,7 +17,9 @@ package domains
 import (
 	"maps"
 	"sync"
+	"time"
 
+	"github.com/greenmaskio/greenmask/internal/db/mysql/client"
 	"github.com/greenmaskio/greenmask/internal/db/postgres/pgdump"
 	"github.com/greenmaskio/greenmask/internal/db/postgres/pgrestore"
 	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/custom"
@@ -61,20 +63,63 @@ type Config struct {
 	Dump               Dump                            `mapstructure:"dump" yaml:"dump" json:"dump"`
 	Validate           Validate                        `mapstructure:"validate" yaml:"validate" json:"validate"`
 	Restore            Restore                         `mapstructure:"restore" yaml:"restore" json:"restore"`
+	Export             Export                          `mapstructure:"export" yaml:"export" json:"export"`
+	SubsetExplain      SubsetExplain                   `mapstructure:"subset_explain" yaml:"subset_explain" json:"subset_explain"`
+	Replicate          Replicate                       `mapstructure:"replicate" yaml:"replicate" json:"replicate"`
 	CustomTransformers []*custom.TransformerDefinition `mapstructure:"custom_transformers" yaml:"custom_transformers" json:"custom_transformers,omitempty"`
 }
 
 type Validate struct {
-	Tables           []string `mapstructure:"tables" yaml:"tables" json:"tables,omitempty"`
-	Data             bool     `mapstructure:"data" yaml:"data" json:"data,omitempty"`
-	Diff             bool     `mapstructure:"diff" yaml:"diff" json:"diff,omitempty"`
-	Schema           bool     `mapstructure:"schema" yaml:"schema" json:"schema,omitempty"`
-	RowsLimit        uint64   `mapstructure:"rows_limit" yaml:"rows_limit" json:"rows_limit,omitempty"`
-	ResolvedWarnings []string `mapstructure:"resolved_warnings" yaml:"resolved_warnings" json:"resolved_warnings,omitempty"`
-	TableFormat      string   `mapstructure:"table_format" yaml:"table_format" json:"table_format,omitempty"`
-	Format           string   `mapstructure:"format" yaml:"format" json:"format,omitempty"`
-	OnlyTransformed  bool     `mapstructure:"transformed_only" yaml:"transformed_only" json:"transformed_only,omitempty"`
-	Warnings         bool     `mapstructure:"warnings" yaml:"warnings" json:"warnings,omitempty"`
+	Tables           []string   `mapstructure:"tables" yaml:"tables" json:"tables,omitempty"`
+	Data             bool       `mapstructure:"data" yaml:"data" json:"data,omitempty"`
+	Diff             bool       `mapstructure:"diff" yaml:"diff" json:"diff,omitempty"`
+	Schema           bool       `mapstructure:"schema" yaml:"schema" json:"schema,omitempty"`
+	RowsLimit        uint64     `mapstructure:"rows_limit" yaml:"rows_limit" json:"rows_limit,omitempty"`
+	ResolvedWarnings []string   `mapstructure:"resolved_warnings" yaml:"resolved_warnings" json:"resolved_warnings,omitempty"`
+	TableFormat      string     `mapstructure:"table_format" yaml:"table_format" json:"table_format,omitempty"`
+	Format           string     `mapstructure:"format" yaml:"format" json:"format,omitempty"`
+	OnlyTransformed  bool       `mapstructure:"transformed_only" yaml:"transformed_only" json:"transformed_only,omitempty"`
+	Warnings         bool       `mapstructure:"warnings" yaml:"warnings" json:"warnings,omitempty"`
+	KAnonymity       KAnonymity `mapstructure:"k_anonymity" yaml:"k_anonymity" json:"k_anonymity,omitempty"`
+}
+
+// KAnonymity - k-anonymity and l-diversity check of the transformed sample. The check is disabled when K is 0
+type KAnonymity struct {
+	K      int                `mapstructure:"k" yaml:"k" json:"k,omitempty"`
+	L      int                `mapstructure:"l" yaml:"l" json:"l,omitempty"`
+	Tables []*KAnonymityTable `mapstructure:"tables" yaml:"tables" json:"tables,omitempty"`
+}
+
+type KAnonymityTable struct {
+	Schema           string   `mapstructure:"schema" yaml:"schema" json:"schema,omitempty"`
+	Name             string   `mapstructure:"name" yaml:"name" json:"name,omitempty"`
+	QuasiIdentifiers []string `mapstructure:"quasi_identifiers" yaml:"quasi_identifiers" json:"quasi_identifiers,omitempty"`
+	SensitiveColumns []string `mapstructure:"sensitive_columns" yaml:"sensitive_columns" json:"sensitive_columns,omitempty"`
+}
+
+type Export struct {
+	Format         string `mapstructure:"format" yaml:"format" json:"format,omitempty"`
+	Compress       bool   `mapstructure:"compress" yaml:"compress" json:"compress,omitempty"`
+	Pgzip          bool   `mapstructure:"pgzip" yaml:"pgzip" json:"pgzip,omitempty"`
+	MaxRowsPerFile uint64 `mapstructure:"max_rows_per_file" yaml:"max_rows_per_file" json:"max_rows_per_file,omitempty"`
+}
+
+type SubsetExplain struct {
+	Format string `mapstructure:"format" yaml:"format" json:"format,omitempty"`
+	// Counts - the way of the rows counting: none, estimated (by EXPLAIN) or exact (by count)
+	Counts string `mapstructure:"counts" yaml:"counts" json:"counts,omitempty"`
+}
+
+// Replicate - settings of the masked replica that is kept up to date by the logical replication
+type Replicate struct {
+	// SlotName - name of the logical replication slot on the source database
+	SlotName string `mapstructure:"slot_name" yaml:"slot_name" json:"slot_name,omitempty"`
+	// Publication - name of the publication on the source database
+	Publication string `mapstructure:"publication" yaml:"publication" json:"publication,omitempty"`
+	// CheckpointTable - table in the target database that stores the position of the last applied transaction
+	CheckpointTable string `mapstructure:"checkpoint_table" yaml:"checkpoint_table" json:"checkpoint_table,omitempty"`
+	// StatusInterval - interval of the status updates sent to the source database
+	StatusInterval time.Duration `mapstructure:"status_interval" yaml:"status_interval" json:"status_interval,omitempty"`
 }
 
 type Common struct {
@@ -93,16 +138,159 @@ type LogConfig struct {
 	Level  string `mapstructure:"level" yaml:"level" json:"level,omitempty"`
 }
 
+const (
+	PostgresEngine = "postgres"
+	MysqlEngine    = "mysql"
+)
+
 type Dump struct {
+	// Engine - the database engine: postgres (default) or mysql
+	Engine            string              `mapstructure:"engine" yaml:"engine" json:"engine,omitempty"`
 	PgDumpOptions     pgdump.Options      `mapstructure:"pg_dump_options" yaml:"pg_dump_options" json:"pg_dump_options"`
+	MysqlOptions      client.Options      `mapstructure:"mysql_options" yaml:"mysql_options" json:"mysql_options,omitempty"`
 	Transformation    []*Table            `mapstructure:"transformation" yaml:"transformation" json:"transformation,omitempty"`
 	VirtualReferences []*VirtualReference `mapstructure:"virtual_references" yaml:"virtual_references" json:"virtual_references,omitempty"`
+	Policy            *Policy             `mapstructure:"policy" yaml:"policy" json:"policy,omitempty"`
+	Subset            *Subset             `mapstructure:"subset" yaml:"subset" json:"subset,omitempty"`
+	Cluster           *Cluster            `mapstructure:"cluster" yaml:"cluster" json:"cluster,omitempty"`
+	SchemaRewrite     *SchemaRewrite      `mapstructure:"schema_rewrite" yaml:"schema_rewrite" json:"schema_rewrite,omitempty"`
+	LargeObjects      []*LargeObjects     `mapstructure:"large_objects" yaml:"large_objects" json:"large_objects,omitempty"`
+	Vault             *Vault              `mapstructure:"vault" yaml:"vault" json:"vault,omitempty"`
+	// AllowKeepOriginalOnError - allow keep_original on_error policy. The original values of the failed rows are
+	// dumped with it, so it must be enabled explicitly
+	AllowKeepOriginalOnError bool `mapstructure:"allow_keep_original_on_error" yaml:"allow_keep_original_on_error" json:"allow_keep_original_on_error,omitempty"`
+}
+
+// Vault - the storage of the original to fake values mappings. The transformers with the vault namespace reuse the
+// stored fake values on the next dumps. The entries are encrypted with the key from GREENMASK_VAULT_KEY environment
+// variable
+type Vault struct {
+	// Type - file (default), sqlite or postgresql
+	Type string `mapstructure:"type" yaml:"type" json:"type,omitempty"`
+	// Path - the path of the vault file or SQLite database
+	Path string `mapstructure:"path" yaml:"path" json:"path,omitempty"`
+	// ConnectionString - the connection string of the PostgreSQL database
+	ConnectionString string `mapstructure:"connection_string" yaml:"connection_string" json:"connection_string,omitempty"`
+	// Table - the name of the PostgreSQL table, greenmask_vault by default
+	Table string `mapstructure:"table" yaml:"table" json:"table,omitempty"`
+}
+
+// LargeObjects - transformation of the large objects content. The large objects are selected by the table column
+// that stores their OIDs or by the query that returns the OIDs. If several rules select the same large object, the
+// first one is applied
+type LargeObjects struct {
+	Schema string `mapstructure:"schema" yaml:"schema" json:"schema,omitempty"`
+	Name   string `mapstructure:"name" yaml:"name" json:"name,omitempty"`
+	Column string `mapstructure:"column" yaml:"column" json:"column,omitempty"`
+	// Query - the query that returns the OIDs of the large objects. It is used instead of the table column
+	Query string `mapstructure:"query" yaml:"query" json:"query,omitempty"`
+	// Mode - placeholder (default), strip_metadata or empty
+	Mode string `mapstructure:"mode" yaml:"mode" json:"mode,omitempty"`
+	// KeepSize - pad the transformed content up to the size of the original one
+	KeepSize bool `mapstructure:"keep_size" yaml:"keep_size" json:"keep_size,omitempty"`
+}
+
+// SchemaRewrite - rules of the schema definitions rewriting that are applied to the TOC entries before the TOC is
+// stored. It is used to remove the sensitive information from the pre-data and post-data sections
+type SchemaRewrite struct {
+	Owners *SchemaRewriteOwners `mapstructure:"owners" yaml:"owners" json:"owners,omitempty"`
+	// DropGrants - drop the ACL and DEFAULT ACL entries
+	DropGrants bool `mapstructure:"drop_grants" yaml:"drop_grants" json:"drop_grants,omitempty"`
+	// Comments - what to do with the comments: keep (default), drop or redact
+	Comments string `mapstructure:"comments" yaml:"comments" json:"comments,omitempty"`
+	// CommentPlaceholder - the text of the redacted comments
+	CommentPlaceholder string `mapstructure:"comment_placeholder" yaml:"comment_placeholder" json:"comment_placeholder,omitempty"`
+	// FunctionBodies - functions and procedures which bodies are replaced with the stubs
+	FunctionBodies []*SchemaRewriteObject `mapstructure:"function_bodies" yaml:"function_bodies" json:"function_bodies,omitempty"`
+	// Rules - regexp rewrites of the entries definitions
+	Rules []*SchemaRewriteRule `mapstructure:"rules" yaml:"rules" json:"rules,omitempty"`
+}
+
+type SchemaRewriteOwners struct {
+	// Strip - remove the owners, so the objects are owned by the user that restores the dump
+	Strip bool `mapstructure:"strip" yaml:"strip" json:"strip,omitempty"`
+	// Remap - map of the original owner to the new one
+	Remap map[string]string `mapstructure:"remap" yaml:"remap" json:"remap,omitempty"`
+	// Default - owner that is used for the owners that are not found in the remap
+	Default string `mapstructure:"default" yaml:"default" json:"default,omitempty"`
+}
+
+// SchemaRewriteObject - regexps of the object schema and name. The empty value matches any
+type SchemaRewriteObject struct {
+	Schema string `mapstructure:"schema" yaml:"schema" json:"schema,omitempty"`
+	Name   string `mapstructure:"name" yaml:"name" json:"name,omitempty"`
+}
+
+type SchemaRewriteRule struct {
+	// Types - TOC entry types (FUNCTION, VIEW, COMMENT, etc.) the rule is applied to. The empty list matches any
+	Types       []string `mapstructure:"types" yaml:"types" json:"types,omitempty"`
+	Pattern     string   `mapstructure:"pattern" yaml:"pattern" json:"pattern"`
+	Replacement string   `mapstructure:"replacement" yaml:"replacement" json:"replacement"`
+}
+
+// Cluster - settings of the cluster dump that dumps the globals (roles, memberships and tablespaces) and several
+// databases under one dump ID
+type Cluster struct {
+	// Databases - databases to dump with their transformation settings. If empty all the connectable non-template
+	// databases are dumped
+	Databases []*ClusterDatabase `mapstructure:"databases" yaml:"databases" json:"databases,omitempty"`
+	// ExcludeDatabases - databases that are not dumped
+	ExcludeDatabases []string `mapstructure:"exclude_databases" yaml:"exclude_databases" json:"exclude_databases,omitempty"`
+	// RolePasswords - dump the roles password hashes. They are not dumped by default
+	RolePasswords bool `mapstructure:"role_passwords" yaml:"role_passwords" json:"role_passwords,omitempty"`
+}
+
+// ClusterDatabase - transformation settings of the database in the cluster dump
+type ClusterDatabase struct {
+	Name              string              `mapstructure:"name" yaml:"name" json:"name"`
+	Transformation    []*Table            `mapstructure:"transformation" yaml:"transformation" json:"transformation,omitempty"`
+	VirtualReferences []*VirtualReference `mapstructure:"virtual_references" yaml:"virtual_references" json:"virtual_references,omitempty"`
+}
+
+// Subset - global subset settings
+type Subset struct {
+	// ParentMinimal - restrict the referenced (parent) tables to the rows that are referenced by the subsetted
+	// tables instead of dumping them in full. It might be overridden by subset_parent_minimal table setting
+	ParentMinimal bool `mapstructure:"parent_minimal" yaml:"parent_minimal" json:"parent_minimal,omitempty"`
+	// Materialize - materialise the primary keys of the subsetted tables into the temporary tables in the topological
+	// order, so the tables are joined with the referenced key sets instead of the whole path to the root tables
+	Materialize bool `mapstructure:"materialize" yaml:"materialize" json:"materialize,omitempty"`
 }
 
 type Restore struct {
 	PgRestoreOptions pgrestore.Options               `mapstructure:"pg_restore_options" yaml:"pg_restore_options" json:"pg_restore_options"`
+	MysqlOptions     client.Options                  `mapstructure:"mysql_options" yaml:"mysql_options" json:"mysql_options,omitempty"`
 	Scripts          map[string][]pgrestore.Script   `mapstructure:"scripts" yaml:"scripts" json:"scripts,omitempty"`
 	ErrorExclusions  *DataRestorationErrorExclusions `mapstructure:"insert_error_exclusions" yaml:"insert_error_exclusions" json:"insert_error_exclusions,omitempty"`
+	SchemaTolerance  *SchemaTolerance                `mapstructure:"schema_tolerance" yaml:"schema_tolerance" json:"schema_tolerance,omitempty"`
+	Cluster          ClusterRestore                  `mapstructure:"cluster" yaml:"cluster" json:"cluster,omitempty"`
+}
+
+// ClusterRestore - settings of the cluster dump restoration
+type ClusterRestore struct {
+	// Databases - databases to restore from the cluster dump. If empty all the dumped databases are restored
+	Databases []string `mapstructure:"databases" yaml:"databases" json:"databases,omitempty"`
+	// NoGlobals - do not restore the globals (roles, memberships and tablespaces)
+	NoGlobals bool `mapstructure:"no_globals" yaml:"no_globals" json:"no_globals,omitempty"`
+}
+
+// SchemaTolerance - settings of the schema-tolerant data restoration into the target database that has different
+// tables structure
+type SchemaTolerance struct {
+	Tables []*SchemaToleranceTable `mapstructure:"tables" yaml:"tables" json:"tables,omitempty"`
+}
+
+type SchemaToleranceTable struct {
+	Schema string `mapstructure:"schema" yaml:"schema" json:"schema,omitempty"`
+	Name   string `mapstructure:"name" yaml:"name" json:"name,omitempty"`
+	// Columns - expressions for the target table columns that are not present in the dump
+	Columns []*SchemaToleranceColumn `mapstructure:"columns" yaml:"columns" json:"columns,omitempty"`
+}
+
+type SchemaToleranceColumn struct {
+	Name string `mapstructure:"name" yaml:"name" json:"name,omitempty"`
+	// Expression - SQL expression that is used as the column default value during the restoration
+	Expression string `mapstructure:"expression" yaml:"expression" json:"expression,omitempty"`
 }
 
 type TablesDataRestorationErrorExclusions struct {
@@ -141,6 +329,11 @@ type TransformerConfig struct {
 	MetadataParams map[string]any            `mapstructure:"-" yaml:"params,omitempty" json:"params,omitempty"`
 	DynamicParams  toolkit.DynamicParameters `mapstructure:"dynamic_params" yaml:"dynamic_params" json:"dynamic_params,omitempty"`
 	When           string                    `mapstructure:"when" yaml:"when" json:"when,omitempty"`
+	// Vault - the namespace of the mappings in the vault. If it is set the fake values of the original values are
+	// stored in the vault and reused on the next dumps
+	Vault string `mapstructure:"vault" yaml:"vault" json:"vault,omitempty"`
+	// OnError - the behaviour of the dump when the transformer returns an error. It overrides the table policy
+	OnError *OnError `mapstructure:"on_error" yaml:"on_error" json:"on_error,omitempty"`
 }
 
 func (tc *TransformerConfig) Clone() *TransformerConfig {
@@ -150,6 +343,8 @@ func (tc *TransformerConfig) Clone() *TransformerConfig {
 		Params:             maps.Clone(tc.Params),
 		DynamicParams:      maps.Clone(tc.DynamicParams),
 		When:               tc.When,
+		Vault:              tc.Vault,
+		OnError:            tc.OnError,
 	}
 
 }
@@ -163,6 +358,49 @@ type Table struct {
 	ColumnsTypeOverride map[string]string    `mapstructure:"columns_type_override" yaml:"columns_type_override" json:"columns_type_override,omitempty"`
 	SubsetConds         []string             `mapstructure:"subset_conds" yaml:"subset_conds" json:"subset_conds,omitempty"`
 	When                string               `mapstructure:"when" yaml:"when" json:"when,omitempty"`
+	// SubsetParentMinimal - overrides the global subset.parent_minimal setting for the table
+	SubsetParentMinimal *bool `mapstructure:"subset_parent_minimal" yaml:"subset_parent_minimal" json:"subset_parent_minimal,omitempty"`
+	// SubsetSample - sample of the table rows that is used as subset condition
+	SubsetSample *SubsetSample `mapstructure:"subset_sample" yaml:"subset_sample" json:"subset_sample,omitempty"`
+	// SubsetSeeds - external list of the key values the table subset is seeded from
+	SubsetSeeds *SubsetSeeds `mapstructure:"subset_seeds" yaml:"subset_seeds" json:"subset_seeds,omitempty"`
+	// OnError - the behaviour of the dump when the table transformers return an error
+	OnError *OnError `mapstructure:"on_error" yaml:"on_error" json:"on_error,omitempty"`
+}
+
+// OnError - the behaviour of the dump when the transformer returns an error
+type OnError struct {
+	// Policy - fail (default), skip, set_null, set_default or keep_original
+	Policy string `mapstructure:"policy" yaml:"policy" json:"policy,omitempty"`
+	// Defaults - the values of the affected columns in the PostgreSQL text format for set_default policy. The
+	// columns without the value are set to NULL
+	Defaults map[string]string `mapstructure:"defaults" yaml:"defaults" json:"defaults,omitempty"`
+}
+
+// SubsetSeeds - settings of the external list of the key values that is used as subset condition
+type SubsetSeeds struct {
+	// Source - path to the local file or to the storage object with storage:// prefix
+	Source string `mapstructure:"source" yaml:"source" json:"source,omitempty"`
+	// Format - csv or list. By default, it is detected by the source extension
+	Format string `mapstructure:"format" yaml:"format" json:"format,omitempty"`
+	// Columns - columns the values are matched with. By default, the primary key columns are used
+	Columns []string `mapstructure:"columns" yaml:"columns" json:"columns,omitempty"`
+	// Header - the first CSV line contains the column names
+	Header bool `mapstructure:"header" yaml:"header" json:"header,omitempty"`
+	// Values - values loaded from the source. They are set before the runtime context building
+	Values [][]string `mapstructure:"-" yaml:"-" json:"-"`
+}
+
+// SubsetSample - settings of the table rows sampling. Either Percent or Rows must be set
+type SubsetSample struct {
+	// Percent - percent of the table rows in range (0, 100]
+	Percent float64 `mapstructure:"percent" yaml:"percent" json:"percent,omitempty"`
+	// Rows - maximal number of the table rows
+	Rows int64 `mapstructure:"rows" yaml:"rows" json:"rows,omitempty"`
+	// Method - sampling method: random (default), tablesample or hash(column)
+	Method string `mapstructure:"method" yaml:"method" json:"method,omitempty"`
+	// Seed - the same seed gives the same sample on the same data
+	Seed int64 `mapstructure:"seed" yaml:"seed" json:"seed,omitempty"`
 }
 
 // DummyConfig - This is a dummy config to the viper workaround
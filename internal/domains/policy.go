@@ -0,0 +1,57 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domains
+
+// Policy - data protection policy that is enforced during dump and validate
+type Policy struct {
+	SensitiveColumns *SensitiveColumnsPolicy `mapstructure:"sensitive_columns" yaml:"sensitive_columns" json:"sensitive_columns,omitempty"`
+}
+
+// SensitiveColumnsPolicy - describes which columns are considered sensitive. Each sensitive column must be
+// covered by at least one transformer, otherwise a fatal validation warning is produced
+type SensitiveColumnsPolicy struct {
+	// Rules - pattern based rules. The column is sensitive if it matches at least one rule
+	Rules []*SensitiveColumnRule `mapstructure:"rules" yaml:"rules" json:"rules,omitempty"`
+	// Columns - explicit list of sensitive columns
+	Columns []*SensitiveColumn `mapstructure:"columns" yaml:"columns" json:"columns,omitempty"`
+	// Exemptions - sensitive columns that are intentionally left as is. Each exemption must have a reason
+	Exemptions []*SensitiveColumnExemption `mapstructure:"exemptions" yaml:"exemptions" json:"exemptions,omitempty"`
+	// Tables - limits the policy check to the listed tables (schema.table). Empty means all dumped tables
+	Tables []string `mapstructure:"tables" yaml:"tables" json:"tables,omitempty"`
+}
+
+// SensitiveColumnRule - the set of regular expressions. The column matches the rule if all the provided
+// expressions match. At least one expression must be provided
+type SensitiveColumnRule struct {
+	Schema        string `mapstructure:"schema" yaml:"schema" json:"schema,omitempty"`
+	Table         string `mapstructure:"table" yaml:"table" json:"table,omitempty"`
+	Column        string `mapstructure:"column" yaml:"column" json:"column,omitempty"`
+	Type          string `mapstructure:"type" yaml:"type" json:"type,omitempty"`
+	Comment       string `mapstructure:"comment" yaml:"comment" json:"comment,omitempty"`
+	SecurityLabel string `mapstructure:"security_label" yaml:"security_label" json:"security_label,omitempty"`
+}
+
+type SensitiveColumn struct {
+	Schema string `mapstructure:"schema" yaml:"schema" json:"schema"`
+	Name   string `mapstructure:"name" yaml:"name" json:"name"`
+	Column string `mapstructure:"column" yaml:"column" json:"column"`
+}
+
+type SensitiveColumnExemption struct {
+	Schema string `mapstructure:"schema" yaml:"schema" json:"schema"`
+	Name   string `mapstructure:"name" yaml:"name" json:"name"`
+	Column string `mapstructure:"column" yaml:"column" json:"column"`
+	Reason string `mapstructure:"reason" yaml:"reason" json:"reason"`
+}
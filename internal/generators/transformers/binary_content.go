@@ -0,0 +1,223 @@
+package transformers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strings"
+)
+
+const (
+	BinaryContentModePlaceholder   = "placeholder"
+	BinaryContentModeStripMetadata = "strip_metadata"
+	BinaryContentModeEmpty         = "empty"
+)
+
+const (
+	binaryContentTypeZip       = "application/zip"
+	binaryContentMaxZipComment = 1<<16 - 1
+)
+
+var BinaryContentModes = []string{
+	BinaryContentModePlaceholder, BinaryContentModeStripMetadata, BinaryContentModeEmpty,
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadataChunks - the PNG chunks that may contain the textual metadata, EXIF or the modification time
+var pngMetadataChunks = map[string]struct{}{
+	"tEXt": {}, "zTXt": {}, "iTXt": {}, "eXIf": {}, "tIME": {},
+}
+
+// binaryPlaceholders - the minimal valid files of the supported content types
+var binaryPlaceholders = map[string][]byte{
+	"image/png":       mustEncodePlaceholderImage(func(w *bytes.Buffer, img image.Image) error { return png.Encode(w, img) }),
+	"image/jpeg":      mustEncodePlaceholderImage(func(w *bytes.Buffer, img image.Image) error { return jpeg.Encode(w, img, nil) }),
+	"image/gif":       mustEncodePlaceholderImage(func(w *bytes.Buffer, img image.Image) error { return gif.Encode(w, img, nil) }),
+	"application/pdf": []byte(placeholderPdf),
+}
+
+const placeholderPdf = "%PDF-1.4\n" +
+	"1 0 obj <</Type /Catalog /Pages 2 0 R>> endobj\n" +
+	"2 0 obj <</Type /Pages /Kids [3 0 R] /Count 1>> endobj\n" +
+	"3 0 obj <</Type /Page /Parent 2 0 R /MediaBox [0 0 1 1]>> endobj\n" +
+	"trailer <</Root 1 0 R>>\n" +
+	"%%EOF\n"
+
+// BinaryContent - replaces the binary content (documents, images, etc.) with the placeholder of the same content type,
+// strips the metadata of the images or empties the content. The content type is detected by the magic bytes
+type BinaryContent struct {
+	mode     string
+	keepSize bool
+}
+
+func NewBinaryContent(mode string, keepSize bool) (*BinaryContent, error) {
+	switch mode {
+	case BinaryContentModePlaceholder, BinaryContentModeStripMetadata, BinaryContentModeEmpty:
+	default:
+		return nil, fmt.Errorf("unknown mode \"%s\"", mode)
+	}
+	return &BinaryContent{
+		mode:     mode,
+		keepSize: keepSize,
+	}, nil
+}
+
+// Transform - returns the transformed content. If the metadata cannot be stripped because the content type is not
+// supported or the image is malformed, the content is replaced with the placeholder
+func (bc *BinaryContent) Transform(data []byte) []byte {
+	contentType := DetectBinaryContentType(data)
+	var res []byte
+	switch bc.mode {
+	case BinaryContentModeEmpty:
+		res = []byte{}
+	case BinaryContentModeStripMetadata:
+		var ok bool
+		res, ok = StripImageMetadata(contentType, data)
+		if !ok {
+			res = BinaryPlaceholder(contentType, 0)
+		}
+	default:
+		res = BinaryPlaceholder(contentType, 0)
+	}
+	if !bc.keepSize || len(res) >= len(data) {
+		return res
+	}
+	if contentType == binaryContentTypeZip && bc.mode != BinaryContentModeEmpty {
+		// The trailing bytes break the zip archive, so the archive comment is used as the padding
+		return BinaryPlaceholder(contentType, len(data))
+	}
+	return padBinaryContent(res, len(data), contentType)
+}
+
+// DetectBinaryContentType - detects the MIME type of the content by the magic bytes. The parameters like charset are
+// dropped
+func DetectBinaryContentType(data []byte) string {
+	contentType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	return contentType
+}
+
+// BinaryPlaceholder - returns the minimal valid file of the content type. The size is used only for zip archives,
+// which are padded up to it by the archive comment. The empty content is returned for the unsupported types
+func BinaryPlaceholder(contentType string, size int) []byte {
+	if contentType == binaryContentTypeZip {
+		buf := bytes.NewBuffer(nil)
+		w := zip.NewWriter(buf)
+		// The empty archive is 22 bytes long
+		if padding := size - 22; padding > 0 {
+			_ = w.SetComment(strings.Repeat(" ", min(padding, binaryContentMaxZipComment)))
+		}
+		_ = w.Close()
+		return buf.Bytes()
+	}
+	if p, ok := binaryPlaceholders[contentType]; ok {
+		return bytes.Clone(p)
+	}
+	return []byte{}
+}
+
+// StripImageMetadata - removes EXIF, XMP, IPTC, comments and textual chunks from JPEG and PNG images. It returns false
+// if the content type is not supported or the image cannot be parsed
+func StripImageMetadata(contentType string, data []byte) ([]byte, bool) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJpegMetadata(data)
+	case "image/png":
+		return stripPngMetadata(data)
+	}
+	return nil, false
+}
+
+func stripJpegMetadata(data []byte) ([]byte, bool) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, false
+	}
+	res := make([]byte, 0, len(data))
+	res = append(res, data[:2]...)
+	pos := 2
+	for {
+		if pos+4 > len(data) || data[pos] != 0xFF {
+			return nil, false
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			// Fill byte
+			pos++
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil, false
+		}
+		if marker == 0xDA {
+			// Start of scan - the rest is the entropy-coded data that is copied as is
+			return append(res, data[pos:]...), true
+		}
+		// APP0 (JFIF), APP2 (ICC profile) and APP14 (Adobe colour transform) are needed to render the image
+		isMetadata := marker == 0xFE || (marker >= 0xE1 && marker <= 0xEF && marker != 0xE2 && marker != 0xEE)
+		if !isMetadata {
+			res = append(res, data[pos:pos+2+length]...)
+		}
+		pos += 2 + length
+	}
+}
+
+func stripPngMetadata(data []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, false
+	}
+	res := make([]byte, 0, len(data))
+	res = append(res, pngSignature...)
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, false
+		}
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		// length, type, data and CRC
+		end := pos + 12 + length
+		if end > len(data) {
+			return nil, false
+		}
+		chunkType := string(data[pos+4 : pos+8])
+		if _, ok := pngMetadataChunks[chunkType]; !ok {
+			res = append(res, data[pos:end]...)
+		}
+		pos = end
+		if chunkType == "IEND" {
+			break
+		}
+	}
+	return res, true
+}
+
+// padBinaryContent - pads the content up to the size. The readers of the supported formats ignore the trailing bytes.
+// The text is padded with spaces and the rest with zero bytes
+func padBinaryContent(data []byte, size int, contentType string) []byte {
+	pad := byte(0)
+	if strings.HasPrefix(contentType, "text/") {
+		pad = ' '
+	}
+	res := make([]byte, size)
+	copy(res, data)
+	for idx := len(data); idx < size; idx++ {
+		res[idx] = pad
+	}
+	return res
+}
+
+func mustEncodePlaceholderImage(encode func(w *bytes.Buffer, img image.Image) error) []byte {
+	img := image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.White})
+	buf := bytes.NewBuffer(nil)
+	if err := encode(buf, img); err != nil {
+		panic(fmt.Sprintf("cannot encode placeholder image: %s", err))
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,155 @@
+package transformers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pngChunk(chunkType string, data []byte) []byte {
+	res := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	res = append(res, chunkType...)
+	res = append(res, data...)
+	return binary.BigEndian.AppendUint32(res, crc32.ChecksumIEEE(append([]byte(chunkType), data...)))
+}
+
+func jpegSegment(marker byte, data []byte) []byte {
+	res := []byte{0xFF, marker}
+	res = binary.BigEndian.AppendUint16(res, uint16(len(data)+2))
+	return append(res, data...)
+}
+
+func newTestZip(t *testing.T) []byte {
+	buf := bytes.NewBuffer(nil)
+	w := zip.NewWriter(buf)
+	f, err := w.Create("passport.txt")
+	require.NoError(t, err)
+	_, err = f.Write(bytes.Repeat([]byte("John Doe "), 50))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestDetectBinaryContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{name: "png", data: binaryPlaceholders["image/png"], expected: "image/png"},
+		{name: "jpeg", data: binaryPlaceholders["image/jpeg"], expected: "image/jpeg"},
+		{name: "pdf", data: []byte("%PDF-1.7\nsigned contract"), expected: "application/pdf"},
+		{name: "zip", data: newTestZip(t), expected: "application/zip"},
+		{name: "text", data: []byte("passport number 123456"), expected: "text/plain"},
+		{name: "unknown", data: []byte{0x00, 0x01, 0x02, 0x03}, expected: "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectBinaryContentType(tt.data))
+		})
+	}
+}
+
+func TestBinaryContent_Transform_placeholder(t *testing.T) {
+	bc, err := NewBinaryContent(BinaryContentModePlaceholder, false)
+	require.NoError(t, err)
+
+	original := []byte("%PDF-1.7\n" + string(bytes.Repeat([]byte("secret "), 100)))
+	res := bc.Transform(original)
+	assert.Equal(t, placeholderPdf, string(res))
+
+	res = bc.Transform(append(bytes.Clone(binaryPlaceholders["image/png"]), bytes.Repeat([]byte{1}, 100)...))
+	_, err = png.Decode(bytes.NewReader(res))
+	require.NoError(t, err)
+
+	res = bc.Transform([]byte{0x00, 0x01, 0x02, 0x03})
+	assert.Empty(t, res)
+}
+
+func TestBinaryContent_Transform_keep_size(t *testing.T) {
+	bc, err := NewBinaryContent(BinaryContentModePlaceholder, true)
+	require.NoError(t, err)
+
+	original := append(bytes.Clone(binaryPlaceholders["image/jpeg"]), bytes.Repeat([]byte{1}, 1000)...)
+	res := bc.Transform(original)
+	require.Len(t, res, len(original))
+	assert.Equal(t, "image/jpeg", DetectBinaryContentType(res))
+	_, err = jpeg.Decode(bytes.NewReader(res))
+	require.NoError(t, err)
+
+	original = []byte("John Doe, passport 123456")
+	res = bc.Transform(original)
+	assert.Equal(t, string(bytes.Repeat([]byte(" "), len(original))), string(res))
+
+	original = newTestZip(t)
+	res = bc.Transform(original)
+	require.Len(t, res, len(original))
+	zr, err := zip.NewReader(bytes.NewReader(res), int64(len(res)))
+	require.NoError(t, err)
+	assert.Empty(t, zr.File)
+}
+
+func TestBinaryContent_Transform_strip_metadata(t *testing.T) {
+	bc, err := NewBinaryContent(BinaryContentModeStripMetadata, false)
+	require.NoError(t, err)
+
+	t.Run("png", func(t *testing.T) {
+		img := binaryPlaceholders["image/png"]
+		// Insert the textual chunk right after IHDR
+		ihdrEnd := len(pngSignature) + 12 + 13
+		original := bytes.Clone(img[:ihdrEnd])
+		original = append(original, pngChunk("tEXt", []byte("Author\x00John Doe"))...)
+		original = append(original, pngChunk("eXIf", []byte("GPS 51.5N 0.12W"))...)
+		original = append(original, img[ihdrEnd:]...)
+
+		res := bc.Transform(original)
+		assert.Equal(t, img, res)
+		assert.NotContains(t, string(res), "John Doe")
+	})
+
+	t.Run("jpeg", func(t *testing.T) {
+		img := binaryPlaceholders["image/jpeg"]
+		original := bytes.Clone(img[:2])
+		original = append(original, jpegSegment(0xE1, []byte("Exif\x00\x00GPS 51.5N 0.12W"))...)
+		original = append(original, jpegSegment(0xFE, []byte("John Doe"))...)
+		original = append(original, img[2:]...)
+
+		res := bc.Transform(original)
+		assert.Equal(t, img, res)
+		_, err = jpeg.Decode(bytes.NewReader(res))
+		require.NoError(t, err)
+	})
+
+	t.Run("unsupported type is replaced with placeholder", func(t *testing.T) {
+		res := bc.Transform([]byte("%PDF-1.7\n/Author (John Doe)"))
+		assert.Equal(t, placeholderPdf, string(res))
+	})
+
+	t.Run("malformed image is replaced with placeholder", func(t *testing.T) {
+		original := append(bytes.Clone(pngSignature), 0x00, 0x00, 0xFF, 0xFF, 'I', 'D', 'A', 'T')
+		res := bc.Transform(original)
+		assert.Equal(t, binaryPlaceholders["image/png"], res)
+	})
+}
+
+func TestBinaryContent_Transform_empty(t *testing.T) {
+	bc, err := NewBinaryContent(BinaryContentModeEmpty, false)
+	require.NoError(t, err)
+	assert.Empty(t, bc.Transform([]byte("%PDF-1.7\nsecret")))
+
+	bc, err = NewBinaryContent(BinaryContentModeEmpty, true)
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, 4), bc.Transform([]byte{0x00, 0x01, 0x02, 0x03}))
+}
+
+func TestNewBinaryContent_unknown_mode(t *testing.T) {
+	_, err := NewBinaryContent("unknown", false)
+	require.Error(t, err)
+}
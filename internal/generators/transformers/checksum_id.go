@@ -0,0 +1,380 @@
+package transformers
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/greenmaskio/greenmask/internal/generators"
+)
+
+const (
+	ChecksumIdIBAN       = "iban"
+	ChecksumIdCardNumber = "card_number"
+	ChecksumIdSSN        = "ssn"
+	ChecksumIdISBN       = "isbn"
+	ChecksumIdNationalId = "national_id"
+	ChecksumIdVATId      = "vat_id"
+)
+
+const (
+	checksumIdByteLength     = 32
+	checksumIdCardLength     = 16
+	checksumIdCardBinLength  = 6
+	checksumIdAlphanumerics  = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	checksumIdCardMinLength  = 12
+	checksumIdCardMaxLength  = 19
+	checksumIdISBNLength     = 13
+	checksumIdISBN10Length   = 10
+	checksumIdSSNLength      = 9
+	checksumIdSSNMaxArea     = 899
+	checksumIdSSNInvalidArea = 666
+)
+
+var ErrUnsupportedCountry = errors.New("unsupported country")
+
+// ibanCountry - the BBAN structure of the country. Each character of the pattern is the character class:
+// n - digit, a - upper case letter, c - digit or upper case letter
+type ibanCountry struct {
+	pattern string
+	// bankCodeLength - number of the leading BBAN characters that identify the bank
+	bankCodeLength int
+}
+
+// ibanCountries - the countries without the national check digits in BBAN
+var ibanCountries = map[string]ibanCountry{
+	"AT": {pattern: "nnnnnnnnnnnnnnnn", bankCodeLength: 5},
+	"CH": {pattern: "nnnnncccccccccccc", bankCodeLength: 5},
+	"DE": {pattern: "nnnnnnnnnnnnnnnnnn", bankCodeLength: 8},
+	"DK": {pattern: "nnnnnnnnnnnnnn", bankCodeLength: 4},
+	"GB": {pattern: "aaaannnnnnnnnnnnnn", bankCodeLength: 10},
+	"IE": {pattern: "aaaannnnnnnnnnnnnn", bankCodeLength: 10},
+	"LU": {pattern: "nnnccccccccccccc", bankCodeLength: 3},
+	"NL": {pattern: "aaaannnnnnnnnn", bankCodeLength: 4},
+}
+
+// checksumIdCountries - the supported countries of the identifier kinds that depend on the country
+var checksumIdCountries = map[string][]string{
+	ChecksumIdIBAN:       {"AT", "CH", "DE", "DK", "GB", "IE", "LU", "NL"},
+	ChecksumIdNationalId: {"IN", "NL"},
+	ChecksumIdVATId:      {"DE", "FR"},
+}
+
+// ChecksumIdCountries - returns the supported countries of the identifier kind or nil if the kind does not depend on
+// the country
+func ChecksumIdCountries(kind string) []string {
+	return checksumIdCountries[kind]
+}
+
+// ChecksumId - generates structurally valid identifiers with the correct check digits. If keepPrefix is set, the
+// prefix of the original value (BIN, country and bank code, SSN area or ISBN prefix) is kept when the original value
+// has the expected structure
+type ChecksumId struct {
+	kind       string
+	country    string
+	keepPrefix bool
+	generator  generators.Generator
+	byteLength int
+}
+
+func NewChecksumId(kind, country string, keepPrefix bool) (*ChecksumId, error) {
+	switch kind {
+	case ChecksumIdCardNumber, ChecksumIdSSN, ChecksumIdISBN:
+	case ChecksumIdIBAN, ChecksumIdNationalId, ChecksumIdVATId:
+		if !slices.Contains(checksumIdCountries[kind], country) {
+			return nil, fmt.Errorf("%w \"%s\" for %s", ErrUnsupportedCountry, country, kind)
+		}
+	default:
+		return nil, fmt.Errorf("unknown identifier kind \"%s\"", kind)
+	}
+	return &ChecksumId{
+		kind:       kind,
+		country:    country,
+		keepPrefix: keepPrefix,
+		byteLength: checksumIdByteLength,
+	}, nil
+}
+
+func (ci *ChecksumId) GetRequiredGeneratorByteLength() int {
+	return ci.byteLength
+}
+
+func (ci *ChecksumId) SetGenerator(g generators.Generator) error {
+	if g.Size() < ci.byteLength {
+		return fmt.Errorf("requested byte length (%d) higher than generator can produce (%d)", ci.byteLength, g.Size())
+	}
+	ci.generator = g
+	return nil
+}
+
+// Generate - generates the identifier by the original value
+func (ci *ChecksumId) Generate(original []byte) ([]byte, error) {
+	rnd, err := ci.generator.Generate(original)
+	if err != nil {
+		return nil, fmt.Errorf("error generating identifier: %w", err)
+	}
+	var normalized string
+	if ci.keepPrefix {
+		normalized = normalizeChecksumId(original)
+	}
+
+	var res string
+	switch ci.kind {
+	case ChecksumIdIBAN:
+		res = generateIBAN(ci.country, normalized, rnd)
+	case ChecksumIdCardNumber:
+		res = generateCardNumber(normalized, rnd)
+	case ChecksumIdSSN:
+		res = generateSSN(normalized, rnd)
+	case ChecksumIdISBN:
+		res = generateISBN(normalized, rnd)
+	case ChecksumIdNationalId:
+		res = generateNationalId(ci.country, rnd)
+	case ChecksumIdVATId:
+		res = generateVATId(ci.country, rnd)
+	}
+	return []byte(res), nil
+}
+
+// generateIBAN - generates IBAN with mod-97 check digits. The country and the bank code of the original IBAN are
+// kept if it is provided and the country is supported
+func generateIBAN(country, original string, rnd []byte) string {
+	var bankCode string
+	if len(original) > 4 {
+		if c, ok := ibanCountries[original[:2]]; ok && len(original) == 4+len(c.pattern) {
+			country = original[:2]
+			bankCode = original[4 : 4+c.bankCodeLength]
+		}
+	}
+	spec := ibanCountries[country]
+	bban := bankCode + fillChecksumIdPattern(spec.pattern[len(bankCode):], rnd)
+	return country + ibanCheckDigits(country, bban) + bban
+}
+
+// generateCardNumber - generates the card number with Luhn check digit. The BIN and the length of the original
+// number are kept if it is provided. By default, 16 digits Visa number is generated
+func generateCardNumber(original string, rnd []byte) string {
+	prefix, length := "4", checksumIdCardLength
+	if len(original) >= checksumIdCardMinLength && len(original) <= checksumIdCardMaxLength && isDigits(original) {
+		prefix, length = original[:checksumIdCardBinLength], len(original)
+	}
+	body := prefix + fillChecksumIdPattern(strings.Repeat("n", length-len(prefix)-1), rnd)
+	return body + string(luhnCheckDigit(body))
+}
+
+// generateSSN - generates US social security number in the AAA-GG-SSSS format. The area number of the original
+// SSN is kept if it is provided and valid. The area 000, 666 and 900-999, the group 00 and the serial 0000 are
+// never assigned
+func generateSSN(original string, rnd []byte) string {
+	area := int(binary.LittleEndian.Uint16(rnd[0:2]))%checksumIdSSNMaxArea + 1
+	if area == checksumIdSSNInvalidArea {
+		area++
+	}
+	if len(original) == checksumIdSSNLength && isDigits(original) {
+		var originalArea int
+		_, _ = fmt.Sscanf(original[:3], "%d", &originalArea)
+		if originalArea > 0 && originalArea <= checksumIdSSNMaxArea && originalArea != checksumIdSSNInvalidArea {
+			area = originalArea
+		}
+	}
+	group := int(rnd[2])%99 + 1
+	serial := int(binary.LittleEndian.Uint16(rnd[3:5]))%9999 + 1
+	return fmt.Sprintf("%03d-%02d-%04d", area, group, serial)
+}
+
+// generateISBN - generates ISBN-13 with the check digit. The EAN prefix and the first digit of the registration
+// group of the original ISBN-13 or ISBN-10 are kept if it is provided
+func generateISBN(original string, rnd []byte) string {
+	prefix := "978"
+	switch {
+	case len(original) == checksumIdISBNLength && isDigits(original) &&
+		(strings.HasPrefix(original, "978") || strings.HasPrefix(original, "979")):
+		prefix = original[:4]
+	case len(original) == checksumIdISBN10Length && isDigits(original[:9]):
+		prefix = "978" + original[:1]
+	}
+	body := prefix + fillChecksumIdPattern(strings.Repeat("n", checksumIdISBNLength-len(prefix)-1), rnd)
+	return body + string(isbnCheckDigit(body))
+}
+
+// generateNationalId - generates the national identifier of the country: Aadhaar number with Verhoeff check digit
+// for IN and BSN with 11-test check digit for NL
+func generateNationalId(country string, rnd []byte) string {
+	switch country {
+	case "IN":
+		body := string('2'+rnd[0]%8) + fillChecksumIdPattern("nnnnnnnnnn", rnd[1:])
+		return body + string(verhoeffCheckDigit(body))
+	default:
+		body := []byte(fillChecksumIdPattern("nnnnnnnn", rnd))
+		// The check digit 10 is not allowed. The weight of the last body digit is 2, so changing it always gives
+		// the valid number
+		for {
+			check := bsnCheckDigit(string(body))
+			if check < 10 {
+				return string(body) + string('0'+check)
+			}
+			body[7] = '0' + (body[7]-'0'+1)%10
+		}
+	}
+}
+
+// generateVATId - generates the VAT identification number of the country: DE with ISO 7064 MOD 11,10 check digit
+// and FR with the key calculated from SIREN
+func generateVATId(country string, rnd []byte) string {
+	switch country {
+	case "DE":
+		body := string('1'+rnd[0]%9) + fillChecksumIdPattern("nnnnnnn", rnd[1:])
+		return "DE" + body + string(mod1110CheckDigit(body))
+	default:
+		sirenBody := fillChecksumIdPattern("nnnnnnnn", rnd)
+		siren := sirenBody + string(luhnCheckDigit(sirenBody))
+		var sirenValue int64
+		_, _ = fmt.Sscanf(siren, "%d", &sirenValue)
+		return fmt.Sprintf("FR%02d%s", (12+3*(sirenValue%97))%97, siren)
+	}
+}
+
+// fillChecksumIdPattern - builds the string by the pattern using one random byte per character
+func fillChecksumIdPattern(pattern string, rnd []byte) string {
+	res := make([]byte, len(pattern))
+	for idx := range pattern {
+		b := rnd[idx%len(rnd)]
+		switch pattern[idx] {
+		case 'n':
+			res[idx] = '0' + b%10
+		case 'a':
+			res[idx] = 'A' + b%26
+		default:
+			res[idx] = checksumIdAlphanumerics[int(b)%len(checksumIdAlphanumerics)]
+		}
+	}
+	return string(res)
+}
+
+// normalizeChecksumId - removes the separators and converts letters to the upper case
+func normalizeChecksumId(v []byte) string {
+	var sb strings.Builder
+	for _, r := range strings.ToUpper(string(v)) {
+		if (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z') {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func isDigits(v string) bool {
+	for _, r := range v {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func luhnCheckDigit(body string) byte {
+	sum := 0
+	double := true
+	for idx := len(body) - 1; idx >= 0; idx-- {
+		d := int(body[idx] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// ibanCheckDigits - calculates the check digits according to ISO 7064 MOD 97-10
+func ibanCheckDigits(country, bban string) string {
+	var sb strings.Builder
+	for _, r := range bban + country + "00" {
+		if r >= 'A' && r <= 'Z' {
+			sb.WriteString(fmt.Sprintf("%d", r-'A'+10))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	n, _ := new(big.Int).SetString(sb.String(), 10)
+	mod := new(big.Int).Mod(n, big.NewInt(97)).Int64()
+	return fmt.Sprintf("%02d", 98-mod)
+}
+
+func isbnCheckDigit(body string) byte {
+	sum := 0
+	for idx := range body {
+		d := int(body[idx] - '0')
+		if idx%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+var (
+	verhoeffMultiplication = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+		{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+		{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+		{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+		{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffPermutation = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+		{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0},
+		{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+		{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+	verhoeffInverse = [10]int{0, 4, 3, 2, 1, 5, 6, 7, 8, 9}
+)
+
+func verhoeffCheckDigit(body string) byte {
+	c := 0
+	for idx := 0; idx < len(body); idx++ {
+		d := int(body[len(body)-1-idx] - '0')
+		c = verhoeffMultiplication[c][verhoeffPermutation[(idx+1)%8][d]]
+	}
+	return byte('0' + verhoeffInverse[c])
+}
+
+// bsnCheckDigit - returns the last digit of BSN by the 11-test. The value 10 means that the body cannot be completed
+func bsnCheckDigit(body string) byte {
+	sum := 0
+	for idx := range body {
+		sum += int(body[idx]-'0') * (9 - idx)
+	}
+	return byte(sum % 11)
+}
+
+// mod1110CheckDigit - calculates the check digit according to ISO 7064 MOD 11,10
+func mod1110CheckDigit(body string) byte {
+	product := 10
+	for idx := range body {
+		sum := (int(body[idx]-'0') + product) % 10
+		if sum == 0 {
+			sum = 10
+		}
+		product = (2 * sum) % 11
+	}
+	check := 11 - product
+	if check == 10 {
+		check = 0
+	}
+	return byte('0' + check)
+}
@@ -0,0 +1,166 @@
+package transformers
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/generators"
+)
+
+func isValidIBAN(iban string) bool {
+	var sb strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' && r <= 'Z' {
+			sb.WriteString(fmt.Sprintf("%d", r-'A'+10))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	n, _ := new(big.Int).SetString(sb.String(), 10)
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+func Test_checkDigits(t *testing.T) {
+	assert.Equal(t, byte('3'), luhnCheckDigit("7992739871"))
+	assert.Equal(t, "89", ibanCheckDigits("DE", "370400440532013000"))
+	assert.Equal(t, byte('7'), isbnCheckDigit("978030640615"))
+	assert.Equal(t, byte('3'), verhoeffCheckDigit("236"))
+	assert.Equal(t, byte(3), bsnCheckDigit("11122233"))
+	assert.Equal(t, byte('6'), mod1110CheckDigit("13669597"))
+}
+
+func TestChecksumId_Generate(t *testing.T) {
+	tests := []struct {
+		name       string
+		kind       string
+		country    string
+		keepPrefix bool
+		original   string
+		check      func(t *testing.T, res string)
+	}{
+		{
+			name:    "iban",
+			kind:    ChecksumIdIBAN,
+			country: "GB",
+			check: func(t *testing.T, res string) {
+				assert.Regexp(t, `^GB\d{2}[A-Z]{4}\d{14}$`, res)
+				assert.True(t, isValidIBAN(res))
+			},
+		},
+		{
+			name:       "iban keep prefix",
+			kind:       ChecksumIdIBAN,
+			country:    "GB",
+			keepPrefix: true,
+			original:   "DE89 3704 0044 0532 0130 00",
+			check: func(t *testing.T, res string) {
+				assert.True(t, strings.HasPrefix(res, "DE"))
+				assert.Equal(t, "37040044", res[4:12])
+				assert.Len(t, res, 22)
+				assert.True(t, isValidIBAN(res))
+			},
+		},
+		{
+			name: "card number",
+			kind: ChecksumIdCardNumber,
+			check: func(t *testing.T, res string) {
+				assert.Regexp(t, `^4\d{15}$`, res)
+				assert.Equal(t, res[15], luhnCheckDigit(res[:15]))
+			},
+		},
+		{
+			name:       "card number keep prefix",
+			kind:       ChecksumIdCardNumber,
+			keepPrefix: true,
+			original:   "5500-0000-0000-0004",
+			check: func(t *testing.T, res string) {
+				assert.Regexp(t, `^550000\d{10}$`, res)
+				assert.Equal(t, res[15], luhnCheckDigit(res[:15]))
+			},
+		},
+		{
+			name:       "ssn keep prefix",
+			kind:       ChecksumIdSSN,
+			keepPrefix: true,
+			original:   "123-45-6789",
+			check: func(t *testing.T, res string) {
+				assert.Regexp(t, `^123-(0[1-9]|[1-9]\d)-\d{4}$`, res)
+				assert.NotEqual(t, "0000", res[7:])
+			},
+		},
+		{
+			name:       "isbn keep prefix",
+			kind:       ChecksumIdISBN,
+			keepPrefix: true,
+			original:   "0-306-40615-2",
+			check: func(t *testing.T, res string) {
+				assert.Regexp(t, `^9780\d{9}$`, res)
+				assert.Equal(t, res[12], isbnCheckDigit(res[:12]))
+			},
+		},
+		{
+			name:    "aadhaar",
+			kind:    ChecksumIdNationalId,
+			country: "IN",
+			check: func(t *testing.T, res string) {
+				assert.Regexp(t, `^[2-9]\d{11}$`, res)
+				assert.Equal(t, res[11], verhoeffCheckDigit(res[:11]))
+			},
+		},
+		{
+			name:    "bsn",
+			kind:    ChecksumIdNationalId,
+			country: "NL",
+			check: func(t *testing.T, res string) {
+				assert.Regexp(t, `^\d{9}$`, res)
+				assert.Equal(t, res[8]-'0', bsnCheckDigit(res[:8]))
+			},
+		},
+		{
+			name:    "de vat",
+			kind:    ChecksumIdVATId,
+			country: "DE",
+			check: func(t *testing.T, res string) {
+				assert.Regexp(t, `^DE[1-9]\d{8}$`, res)
+				assert.Equal(t, res[10], mod1110CheckDigit(res[2:10]))
+			},
+		},
+		{
+			name:    "fr vat",
+			kind:    ChecksumIdVATId,
+			country: "FR",
+			check: func(t *testing.T, res string) {
+				assert.Regexp(t, `^FR\d{11}$`, res)
+				assert.Equal(t, res[12], luhnCheckDigit(res[4:12]))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ci, err := NewChecksumId(tt.kind, tt.country, tt.keepPrefix)
+			require.NoError(t, err)
+			g, err := generators.GetHashBytesGen([]byte("salt"), ci.GetRequiredGeneratorByteLength())
+			require.NoError(t, err)
+			require.NoError(t, ci.SetGenerator(g))
+			keys := []string{"1", "2", "3", "4", "5"}
+			if tt.keepPrefix {
+				keys = []string{tt.original}
+			}
+			for _, key := range keys {
+				res, err := ci.Generate([]byte(key))
+				require.NoError(t, err)
+				tt.check(t, string(res))
+			}
+		})
+	}
+}
+
+func TestNewChecksumId_unsupportedCountry(t *testing.T) {
+	_, err := NewChecksumId(ChecksumIdIBAN, "XX", false)
+	require.ErrorIs(t, err, ErrUnsupportedCountry)
+}
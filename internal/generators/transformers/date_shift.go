@@ -0,0 +1,60 @@
+package transformers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/greenmaskio/greenmask/internal/generators"
+)
+
+// DateShift - generates the offset in days by the entity key. The same key always gets the same offset with the
+// hash generator, so all the dates of the entity are shifted equally and their order and intervals are preserved
+type DateShift struct {
+	byteLength int
+	generator  generators.Generator
+	minDays    int64
+	// distance - number of the possible offsets in one direction
+	distance int64
+}
+
+func NewDateShift(minDays, maxDays int64) (*DateShift, error) {
+	if minDays < 0 || minDays > maxDays {
+		return nil, ErrWrongLimits
+	}
+	return &DateShift{
+		minDays:    minDays,
+		distance:   maxDays - minDays + 1,
+		byteLength: 9, // 8 bytes for the offset, 1 byte for sign
+	}, nil
+}
+
+// GetOffset - returns the offset in days in the range [-maxDays; -minDays] or [minDays; maxDays]
+func (ds *DateShift) GetOffset(key []byte) (int64, error) {
+	genBytes, err := ds.generator.Generate(key)
+	if err != nil {
+		return 0, fmt.Errorf("error generating date shift: %w", err)
+	}
+	days := ds.minDays + int64(binary.LittleEndian.Uint64(genBytes[1:9])%uint64(ds.distance))
+	if genBytes[0]%2 == 0 {
+		return -days, nil
+	}
+	return days, nil
+}
+
+// Shift - shifts the value by the offset in days. The time of the day is kept
+func (ds *DateShift) Shift(v time.Time, days int64) time.Time {
+	return v.AddDate(0, 0, int(days))
+}
+
+func (ds *DateShift) GetRequiredGeneratorByteLength() int {
+	return ds.byteLength
+}
+
+func (ds *DateShift) SetGenerator(g generators.Generator) error {
+	if g.Size() < ds.byteLength {
+		return fmt.Errorf("requested byte length (%d) higher than generator can produce (%d)", ds.byteLength, g.Size())
+	}
+	ds.generator = g
+	return nil
+}
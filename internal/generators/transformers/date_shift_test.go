@@ -0,0 +1,53 @@
+package transformers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/generators"
+)
+
+func TestDateShift_GetOffset(t *testing.T) {
+	ds, err := NewDateShift(10, 30)
+	require.NoError(t, err)
+	g, err := generators.GetHashBytesGen([]byte("salt"), ds.GetRequiredGeneratorByteLength())
+	require.NoError(t, err)
+	require.NoError(t, ds.SetGenerator(g))
+
+	for _, key := range []string{"1", "2", "3", "100", "patient-42"} {
+		offset, err := ds.GetOffset([]byte(key))
+		require.NoError(t, err)
+		abs := offset
+		if abs < 0 {
+			abs = -abs
+		}
+		assert.GreaterOrEqual(t, abs, int64(10))
+		assert.LessOrEqual(t, abs, int64(30))
+
+		again, err := ds.GetOffset([]byte(key))
+		require.NoError(t, err)
+		assert.Equal(t, offset, again)
+	}
+}
+
+func TestDateShift_Shift(t *testing.T) {
+	ds, err := NewDateShift(0, 10)
+	require.NoError(t, err)
+	admission := time.Date(2023, 1, 30, 10, 15, 0, 0, time.UTC)
+	discharge := time.Date(2023, 2, 3, 8, 0, 0, 0, time.UTC)
+
+	shiftedAdmission := ds.Shift(admission, -7)
+	shiftedDischarge := ds.Shift(discharge, -7)
+	assert.Equal(t, time.Date(2023, 1, 23, 10, 15, 0, 0, time.UTC), shiftedAdmission)
+	assert.Equal(t, discharge.Sub(admission), shiftedDischarge.Sub(shiftedAdmission))
+}
+
+func TestNewDateShift_wrongLimits(t *testing.T) {
+	_, err := NewDateShift(10, 5)
+	require.ErrorIs(t, err, ErrWrongLimits)
+	_, err = NewDateShift(-1, 5)
+	require.ErrorIs(t, err, ErrWrongLimits)
+}
@@ -0,0 +1,592 @@
+package transformers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/greenmaskio/greenmask/internal/generators"
+)
+
+const (
+	GeometryPoint uint32 = iota + 1
+	GeometryLineString
+	GeometryPolygon
+	GeometryMultiPoint
+	GeometryMultiLineString
+	GeometryMultiPolygon
+	GeometryCollection
+)
+
+const (
+	ewkbZFlag    uint32 = 0x80000000
+	ewkbMFlag    uint32 = 0x40000000
+	ewkbSRIDFlag uint32 = 0x20000000
+	ewkbFlags           = ewkbZFlag | ewkbMFlag | ewkbSRIDFlag
+)
+
+const (
+	geometryNoiseByteLength     = 16
+	geometryInPolygonByteLength = 16
+	geometryInPolygonAttempts   = 1000
+)
+
+const (
+	// geometryMetersPerDegree - the length of the latitude degree and the longitude degree on the equator in meters
+	geometryMetersPerDegree = 111320.0
+	geometryMaxNestingLevel = 32
+)
+
+// Geometry - the geometry decoded from the (E)WKB. Both PostGIS EWKB with the dimension and SRID flags and ISO WKB
+// with the dimension encoded in the type code are supported. The geometry is encoded back in the same flavour
+type Geometry struct {
+	Type    uint32
+	HasZ    bool
+	HasM    bool
+	HasSRID bool
+	SRID    uint32
+	// Points - the coordinates of Point (single item) and LineString. The empty point has no coordinates
+	Points [][]float64
+	// Rings - the rings of Polygon
+	Rings [][][]float64
+	// Geometries - the members of the Multi* geometries and GeometryCollection
+	Geometries []*Geometry
+
+	byteOrder binary.ByteOrder
+	iso       bool
+}
+
+// ParseHexEWKB - parses the hex encoded (E)WKB that is the text representation of PostGIS geometry and geography
+func ParseHexEWKB(data []byte) (*Geometry, error) {
+	raw := make([]byte, hex.DecodedLen(len(data)))
+	if _, err := hex.Decode(raw, data); err != nil {
+		return nil, fmt.Errorf("cannot decode hex: %w", err)
+	}
+	r := &wkbReader{data: raw}
+	g, err := r.readGeometry(0)
+	if err != nil {
+		return nil, err
+	}
+	if r.pos != len(raw) {
+		return nil, fmt.Errorf("unexpected %d trailing bytes", len(raw)-r.pos)
+	}
+	return g, nil
+}
+
+// HexEWKB - encodes the geometry into the upper-case hex (E)WKB as PostGIS does
+func (g *Geometry) HexEWKB() []byte {
+	buf := bytes.NewBuffer(nil)
+	g.write(buf, true)
+	res := make([]byte, hex.EncodedLen(buf.Len()))
+	hex.Encode(res, buf.Bytes())
+	return bytes.ToUpper(res)
+}
+
+// IsEmpty - returns true if the geometry has no coordinates
+func (g *Geometry) IsEmpty() bool {
+	empty := true
+	g.forEachCoord(func(_ []float64) {
+		empty = false
+	})
+	return empty
+}
+
+// Translate - moves all the coordinates of the geometry by dx and dy
+func (g *Geometry) Translate(dx, dy float64) {
+	g.forEachCoord(func(c []float64) {
+		c[0] += dx
+		c[1] += dy
+	})
+}
+
+// SnapToGrid - rounds X and Y of all the coordinates to the nearest multiple of the grid size
+func (g *Geometry) SnapToGrid(size float64) {
+	g.forEachCoord(func(c []float64) {
+		c[0] = math.Round(c[0]/size) * size
+		c[1] = math.Round(c[1]/size) * size
+	})
+}
+
+// Centroid - returns the centroid of the geometry as a 2D point with the same SRID. The centroid of the polygons is
+// weighted by the area, the centroid of the other geometries is the mean of the vertices
+func (g *Geometry) Centroid() *Geometry {
+	var area, cx, cy float64
+	g.forEachPolygon(func(rings [][][]float64) {
+		for idx, ring := range rings {
+			a, x, y := ringCentroid(ring)
+			if idx > 0 {
+				// Holes are subtracted from the exterior ring
+				a = -a
+			}
+			area += a
+			cx += x * a
+			cy += y * a
+		}
+	})
+	if area != 0 {
+		cx, cy = cx/area, cy/area
+	} else {
+		var count float64
+		cx, cy = 0, 0
+		g.forEachCoord(func(c []float64) {
+			cx += c[0]
+			cy += c[1]
+			count++
+		})
+		cx, cy = cx/count, cy/count
+	}
+	return &Geometry{
+		Type:      GeometryPoint,
+		HasSRID:   g.HasSRID,
+		SRID:      g.SRID,
+		Points:    [][]float64{{cx, cy}},
+		byteOrder: g.byteOrder,
+		iso:       g.iso,
+	}
+}
+
+// Bounds - returns the bounding box of the geometry
+func (g *Geometry) Bounds() (minX, minY, maxX, maxY float64) {
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	g.forEachCoord(func(c []float64) {
+		minX, maxX = min(minX, c[0]), max(maxX, c[0])
+		minY, maxY = min(minY, c[1]), max(maxY, c[1])
+	})
+	return minX, minY, maxX, maxY
+}
+
+func (g *Geometry) forEachCoord(f func(c []float64)) {
+	for _, p := range g.Points {
+		f(p)
+	}
+	for _, ring := range g.Rings {
+		for _, p := range ring {
+			f(p)
+		}
+	}
+	for _, child := range g.Geometries {
+		child.forEachCoord(f)
+	}
+}
+
+func (g *Geometry) forEachPolygon(f func(rings [][][]float64)) {
+	if g.Type == GeometryPolygon && len(g.Rings) > 0 {
+		f(g.Rings)
+	}
+	for _, child := range g.Geometries {
+		child.forEachPolygon(f)
+	}
+}
+
+func (g *Geometry) dims() int {
+	d := 2
+	if g.HasZ {
+		d++
+	}
+	if g.HasM {
+		d++
+	}
+	return d
+}
+
+func (g *Geometry) typeCode(withSRID bool) uint32 {
+	code := g.Type
+	if g.iso {
+		switch {
+		case g.HasZ && g.HasM:
+			code += 3000
+		case g.HasM:
+			code += 2000
+		case g.HasZ:
+			code += 1000
+		}
+		return code
+	}
+	if g.HasZ {
+		code |= ewkbZFlag
+	}
+	if g.HasM {
+		code |= ewkbMFlag
+	}
+	if withSRID && g.HasSRID {
+		code |= ewkbSRIDFlag
+	}
+	return code
+}
+
+func (g *Geometry) write(buf *bytes.Buffer, root bool) {
+	order := g.byteOrder
+	if order == nil {
+		order = binary.LittleEndian
+	}
+	if order == binary.LittleEndian {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+	b := make([]byte, 8)
+	writeUint32 := func(v uint32) {
+		order.PutUint32(b, v)
+		buf.Write(b[:4])
+	}
+	writeCoords := func(coords [][]float64) {
+		for _, c := range coords {
+			for _, v := range c {
+				order.PutUint64(b, math.Float64bits(v))
+				buf.Write(b)
+			}
+		}
+	}
+
+	writeUint32(g.typeCode(root))
+	if root && g.HasSRID && !g.iso {
+		writeUint32(g.SRID)
+	}
+	switch g.Type {
+	case GeometryPoint:
+		if len(g.Points) == 0 {
+			// The empty point is encoded with NaN coordinates
+			nan := make([]float64, g.dims())
+			for idx := range nan {
+				nan[idx] = math.NaN()
+			}
+			writeCoords([][]float64{nan})
+		} else {
+			writeCoords(g.Points[:1])
+		}
+	case GeometryLineString:
+		writeUint32(uint32(len(g.Points)))
+		writeCoords(g.Points)
+	case GeometryPolygon:
+		writeUint32(uint32(len(g.Rings)))
+		for _, ring := range g.Rings {
+			writeUint32(uint32(len(ring)))
+			writeCoords(ring)
+		}
+	default:
+		writeUint32(uint32(len(g.Geometries)))
+		for _, child := range g.Geometries {
+			child.write(buf, false)
+		}
+	}
+}
+
+type wkbReader struct {
+	data  []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+func (r *wkbReader) readUint32() (uint32, error) {
+	if r.pos+4 > len(r.data) {
+		return 0, fmt.Errorf("unexpected end of data")
+	}
+	v := r.order.Uint32(r.data[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+func (r *wkbReader) readCoords(n, dims int) ([][]float64, error) {
+	if n < 0 || r.pos+n*dims*8 > len(r.data) {
+		return nil, fmt.Errorf("unexpected end of data")
+	}
+	res := make([][]float64, n)
+	for i := range res {
+		res[i] = make([]float64, dims)
+		for j := range res[i] {
+			res[i][j] = math.Float64frombits(r.order.Uint64(r.data[r.pos:]))
+			r.pos += 8
+		}
+	}
+	return res, nil
+}
+
+func (r *wkbReader) readCount() (int, error) {
+	n, err := r.readUint32()
+	if err != nil {
+		return 0, err
+	}
+	// Each item takes at least 4 bytes
+	if int(n) > (len(r.data)-r.pos)/4 {
+		return 0, fmt.Errorf("invalid number of items %d", n)
+	}
+	return int(n), nil
+}
+
+func (r *wkbReader) readGeometry(level int) (*Geometry, error) {
+	if level > geometryMaxNestingLevel {
+		return nil, fmt.Errorf("geometry nesting is too deep")
+	}
+	if r.pos >= len(r.data) {
+		return nil, fmt.Errorf("unexpected end of data")
+	}
+	g := &Geometry{}
+	switch r.data[r.pos] {
+	case 0:
+		g.byteOrder = binary.BigEndian
+	case 1:
+		g.byteOrder = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("unknown byte order %d", r.data[r.pos])
+	}
+	r.order = g.byteOrder
+	r.pos++
+
+	code, err := r.readUint32()
+	if err != nil {
+		return nil, err
+	}
+	if code&ewkbFlags != 0 {
+		g.HasZ = code&ewkbZFlag != 0
+		g.HasM = code&ewkbMFlag != 0
+		g.HasSRID = code&ewkbSRIDFlag != 0
+		code &^= ewkbFlags
+	} else if code > 1000 {
+		g.iso = true
+		switch code / 1000 {
+		case 1:
+			g.HasZ = true
+		case 2:
+			g.HasM = true
+		case 3:
+			g.HasZ, g.HasM = true, true
+		default:
+			return nil, fmt.Errorf("unknown geometry type %d", code)
+		}
+		code %= 1000
+	}
+	g.Type = code
+	if g.HasSRID {
+		if g.SRID, err = r.readUint32(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch g.Type {
+	case GeometryPoint:
+		coords, err := r.readCoords(1, g.dims())
+		if err != nil {
+			return nil, err
+		}
+		if !math.IsNaN(coords[0][0]) {
+			g.Points = coords
+		}
+	case GeometryLineString:
+		n, err := r.readCount()
+		if err != nil {
+			return nil, err
+		}
+		if g.Points, err = r.readCoords(n, g.dims()); err != nil {
+			return nil, err
+		}
+	case GeometryPolygon:
+		n, err := r.readCount()
+		if err != nil {
+			return nil, err
+		}
+		g.Rings = make([][][]float64, n)
+		for idx := range g.Rings {
+			size, err := r.readCount()
+			if err != nil {
+				return nil, err
+			}
+			if g.Rings[idx], err = r.readCoords(size, g.dims()); err != nil {
+				return nil, err
+			}
+		}
+	case GeometryMultiPoint, GeometryMultiLineString, GeometryMultiPolygon, GeometryCollection:
+		n, err := r.readCount()
+		if err != nil {
+			return nil, err
+		}
+		g.Geometries = make([]*Geometry, n)
+		for idx := range g.Geometries {
+			if g.Geometries[idx], err = r.readGeometry(level + 1); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %d", g.Type)
+	}
+	return g, nil
+}
+
+// ringCentroid - returns the absolute area of the ring and its centroid
+func ringCentroid(ring [][]float64) (area, cx, cy float64) {
+	for idx := 0; idx+1 < len(ring); idx++ {
+		x0, y0 := ring[idx][0], ring[idx][1]
+		x1, y1 := ring[idx+1][0], ring[idx+1][1]
+		cross := x0*y1 - x1*y0
+		area += cross
+		cx += (x0 + x1) * cross
+		cy += (y0 + y1) * cross
+	}
+	if area == 0 {
+		return 0, 0, 0
+	}
+	cx /= 3 * area
+	cy /= 3 * area
+	return math.Abs(area / 2), cx, cy
+}
+
+// GeometryPolygonContains - checks that the point is inside the polygon ring using the ray casting
+func GeometryPolygonContains(polygon [][]float64, x, y float64) bool {
+	var inside bool
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		xi, yi := polygon[i][0], polygon[i][1]
+		xj, yj := polygon[j][0], polygon[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// GeometryMetersToDegrees - converts the distance in meters into the longitude and latitude degrees at the latitude
+func GeometryMetersToDegrees(meters, latitude float64) (dLon, dLat float64) {
+	dLat = meters / geometryMetersPerDegree
+	cos := math.Cos(latitude * math.Pi / 180)
+	if cos < 1e-6 {
+		cos = 1e-6
+	}
+	return meters / (geometryMetersPerDegree * cos), dLat
+}
+
+// geometryUnitFloat - converts 8 random bytes into the float in range [0, 1)
+func geometryUnitFloat(b []byte) float64 {
+	return float64(binary.BigEndian.Uint64(b)>>11) / (1 << 53)
+}
+
+// GeometryNoise - moves the geometry by the random offset that is uniformly distributed within the circle of the
+// radius. The whole geometry is moved by the same offset, so its shape is kept
+type GeometryNoise struct {
+	radius     float64
+	meters     bool
+	generator  generators.Generator
+	byteLength int
+}
+
+// NewGeometryNoise - creates the noise transformer. If meters is true, the radius is in meters and the coordinates
+// are supposed to be longitude and latitude in degrees
+func NewGeometryNoise(radius float64, meters bool) (*GeometryNoise, error) {
+	if radius <= 0 {
+		return nil, fmt.Errorf("radius must be greater than 0")
+	}
+	return &GeometryNoise{
+		radius:     radius,
+		meters:     meters,
+		byteLength: geometryNoiseByteLength,
+	}, nil
+}
+
+func (gn *GeometryNoise) GetRequiredGeneratorByteLength() int {
+	return gn.byteLength
+}
+
+func (gn *GeometryNoise) SetGenerator(g generators.Generator) error {
+	if g.Size() < gn.byteLength {
+		return fmt.Errorf("requested byte length (%d) higher than generator can produce (%d)", gn.byteLength, g.Size())
+	}
+	gn.generator = g
+	return nil
+}
+
+// Transform - moves the geometry. The original value is used as the hash engine input
+func (gn *GeometryNoise) Transform(original []byte, g *Geometry) error {
+	if g.IsEmpty() {
+		return nil
+	}
+	rnd, err := gn.generator.Generate(original)
+	if err != nil {
+		return fmt.Errorf("error generating random bytes: %w", err)
+	}
+	angle := 2 * math.Pi * geometryUnitFloat(rnd[:8])
+	distance := gn.radius * math.Sqrt(geometryUnitFloat(rnd[8:16]))
+	dx, dy := distance*math.Cos(angle), distance*math.Sin(angle)
+	if gn.meters {
+		_, minY, _, maxY := g.Bounds()
+		dLon, dLat := GeometryMetersToDegrees(1, (minY+maxY)/2)
+		dx, dy = dx*dLon, dy*dLat
+	}
+	g.Translate(dx, dy)
+	return nil
+}
+
+// GeometryInPolygon - moves the point to the random location within the polygon. The other geometries are moved so
+// their centroid gets into the random location and their shape is kept
+type GeometryInPolygon struct {
+	polygon                [][]float64
+	minX, minY, maxX, maxY float64
+	generator              generators.Generator
+	byteLength             int
+}
+
+// NewGeometryInPolygon - creates the transformer by the polygon ring that is the list of [x, y] pairs
+func NewGeometryInPolygon(polygon [][]float64) (*GeometryInPolygon, error) {
+	if len(polygon) < 3 {
+		return nil, fmt.Errorf("polygon must have at least 3 points")
+	}
+	for _, p := range polygon {
+		if len(p) != 2 {
+			return nil, fmt.Errorf("polygon point must have 2 coordinates")
+		}
+	}
+	ring := polygon
+	if first, last := polygon[0], polygon[len(polygon)-1]; first[0] != last[0] || first[1] != last[1] {
+		ring = append(slices.Clone(polygon), first)
+	}
+	if area, _, _ := ringCentroid(ring); area == 0 {
+		return nil, fmt.Errorf("polygon has zero area")
+	}
+	g := &Geometry{Type: GeometryPolygon, Rings: [][][]float64{ring}}
+	minX, minY, maxX, maxY := g.Bounds()
+	return &GeometryInPolygon{
+		polygon:    polygon,
+		minX:       minX,
+		minY:       minY,
+		maxX:       maxX,
+		maxY:       maxY,
+		byteLength: geometryInPolygonByteLength,
+	}, nil
+}
+
+func (gip *GeometryInPolygon) GetRequiredGeneratorByteLength() int {
+	return gip.byteLength
+}
+
+func (gip *GeometryInPolygon) SetGenerator(g generators.Generator) error {
+	if g.Size() < gip.byteLength {
+		return fmt.Errorf("requested byte length (%d) higher than generator can produce (%d)", gip.byteLength, g.Size())
+	}
+	gip.generator = g
+	return nil
+}
+
+// Transform - moves the geometry into the polygon. The random location is sampled within the polygon bounding box
+// until it gets into the polygon. The original value is used as the hash engine input of the first attempt and the
+// random bytes of the previous attempt are used for the next one
+func (gip *GeometryInPolygon) Transform(original []byte, g *Geometry) error {
+	if g.IsEmpty() {
+		return nil
+	}
+	seed := original
+	for attempt := 0; attempt < geometryInPolygonAttempts; attempt++ {
+		rnd, err := gip.generator.Generate(seed)
+		if err != nil {
+			return fmt.Errorf("error generating random bytes: %w", err)
+		}
+		x := gip.minX + (gip.maxX-gip.minX)*geometryUnitFloat(rnd[:8])
+		y := gip.minY + (gip.maxY-gip.minY)*geometryUnitFloat(rnd[8:16])
+		if GeometryPolygonContains(gip.polygon, x, y) {
+			c := g.Centroid()
+			g.Translate(x-c.Points[0][0], y-c.Points[0][1])
+			return nil
+		}
+		seed = rnd
+	}
+	return fmt.Errorf("cannot find the location within the polygon in %d attempts", geometryInPolygonAttempts)
+}
@@ -0,0 +1,144 @@
+package transformers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/generators"
+)
+
+// SRID=4326;POINT(1 2)
+const testPointEWKB = "0101000020E6100000000000000000F03F0000000000000040"
+
+func newTestSquare(size float64) *Geometry {
+	return &Geometry{
+		Type:    GeometryPolygon,
+		HasSRID: true,
+		SRID:    4326,
+		Rings: [][][]float64{
+			{{0, 0}, {size, 0}, {size, size}, {0, size}, {0, 0}},
+		},
+	}
+}
+
+func TestParseHexEWKB(t *testing.T) {
+	g, err := ParseHexEWKB([]byte(testPointEWKB))
+	require.NoError(t, err)
+	assert.Equal(t, GeometryPoint, g.Type)
+	assert.True(t, g.HasSRID)
+	assert.Equal(t, uint32(4326), g.SRID)
+	assert.Equal(t, [][]float64{{1, 2}}, g.Points)
+	assert.Equal(t, testPointEWKB, string(g.HexEWKB()))
+
+	// ISO WKB POINT Z (1 2 3) in big endian
+	iso := "00000003E93FF000000000000040000000000000004008000000000000"
+	g, err = ParseHexEWKB([]byte(iso))
+	require.NoError(t, err)
+	assert.True(t, g.HasZ)
+	assert.False(t, g.HasSRID)
+	assert.Equal(t, [][]float64{{1, 2, 3}}, g.Points)
+	assert.Equal(t, iso, string(g.HexEWKB()))
+}
+
+func TestParseHexEWKB_roundtrip(t *testing.T) {
+	multi := &Geometry{
+		Type:    GeometryCollection,
+		HasSRID: true,
+		SRID:    3857,
+		HasZ:    true,
+		Geometries: []*Geometry{
+			{Type: GeometryPoint, HasZ: true, Points: [][]float64{{1, 2, 3}}},
+			{Type: GeometryLineString, HasZ: true, Points: [][]float64{{1, 2, 3}, {4, 5, 6}}},
+			{Type: GeometryPoint, HasZ: true},
+		},
+	}
+	g, err := ParseHexEWKB(multi.HexEWKB())
+	require.NoError(t, err)
+	assert.Equal(t, string(multi.HexEWKB()), string(g.HexEWKB()))
+	assert.Len(t, g.Geometries, 3)
+	assert.Empty(t, g.Geometries[2].Points)
+	assert.Equal(t, uint32(3857), g.SRID)
+}
+
+func TestParseHexEWKB_invalid(t *testing.T) {
+	for _, v := range []string{"zz", "02", "0101000020E6100000", testPointEWKB + "00", "0108000000"} {
+		_, err := ParseHexEWKB([]byte(v))
+		assert.Error(t, err, v)
+	}
+}
+
+func TestGeometry_Centroid(t *testing.T) {
+	square := newTestSquare(4)
+	c := square.Centroid()
+	assert.Equal(t, GeometryPoint, c.Type)
+	assert.Equal(t, uint32(4326), c.SRID)
+	assert.InDeltaSlice(t, []float64{2, 2}, c.Points[0], 1e-9)
+
+	// The hole in the right half moves the centroid to the left
+	square.Rings = append(square.Rings, [][]float64{{2, 0}, {4, 0}, {4, 4}, {2, 4}, {2, 0}})
+	c = square.Centroid()
+	assert.InDeltaSlice(t, []float64{1, 2}, c.Points[0], 1e-9)
+
+	line := &Geometry{Type: GeometryLineString, Points: [][]float64{{0, 0}, {2, 4}}}
+	assert.InDeltaSlice(t, []float64{1, 2}, line.Centroid().Points[0], 1e-9)
+}
+
+func TestGeometry_SnapToGrid(t *testing.T) {
+	g, err := ParseHexEWKB([]byte(testPointEWKB))
+	require.NoError(t, err)
+	g.Points[0] = []float64{13.4049, 52.5208}
+	g.SnapToGrid(0.01)
+	assert.InDeltaSlice(t, []float64{13.40, 52.52}, g.Points[0], 1e-9)
+}
+
+func TestGeometryNoise_Transform(t *testing.T) {
+	gn, err := NewGeometryNoise(500, true)
+	require.NoError(t, err)
+	gen, err := generators.GetHashBytesGen([]byte("salt"), gn.GetRequiredGeneratorByteLength())
+	require.NoError(t, err)
+	require.NoError(t, gn.SetGenerator(gen))
+
+	var results []string
+	for i := 0; i < 2; i++ {
+		g := &Geometry{Type: GeometryPoint, HasSRID: true, SRID: 4326, Points: [][]float64{{13.4049, 52.5208}}}
+		require.NoError(t, gn.Transform([]byte(testPointEWKB), g))
+		dLon, dLat := GeometryMetersToDegrees(1, 52.5208)
+		dx, dy := (g.Points[0][0]-13.4049)/dLon, (g.Points[0][1]-52.5208)/dLat
+		assert.LessOrEqual(t, math.Hypot(dx, dy), 500.0+1e-6)
+		assert.Equal(t, uint32(4326), g.SRID)
+		results = append(results, string(g.HexEWKB()))
+	}
+	// The hash engine gives the same result for the same value
+	assert.Equal(t, results[0], results[1])
+}
+
+func TestGeometryInPolygon_Transform(t *testing.T) {
+	triangle := [][]float64{{0, 0}, {10, 0}, {0, 10}, {0, 0}}
+	gip, err := NewGeometryInPolygon(triangle)
+	require.NoError(t, err)
+	gen, err := generators.GetHashBytesGen([]byte("salt"), gip.GetRequiredGeneratorByteLength())
+	require.NoError(t, err)
+	require.NoError(t, gip.SetGenerator(gen))
+
+	for _, v := range []string{"a", "b", "c", "d", "e"} {
+		g := &Geometry{Type: GeometryPoint, Points: [][]float64{{100, 100}}}
+		require.NoError(t, gip.Transform([]byte(v), g))
+		assert.True(t, GeometryPolygonContains(triangle, g.Points[0][0], g.Points[0][1]), g.Points[0])
+	}
+
+	// The polygon is moved keeping its shape
+	square := newTestSquare(1)
+	require.NoError(t, gip.Transform([]byte("a"), square))
+	c := square.Centroid()
+	assert.True(t, GeometryPolygonContains(triangle, c.Points[0][0], c.Points[0][1]))
+	ring := square.Rings[0]
+	assert.InDelta(t, 1, ring[1][0]-ring[0][0], 1e-9)
+
+	_, err = NewGeometryInPolygon([][]float64{{0, 0}, {1, 1}, {2, 2}})
+	require.Error(t, err)
+	_, err = NewGeometryInPolygon([][]float64{{0, 0}, {1, 1}})
+	require.Error(t, err)
+}
@@ -0,0 +1,251 @@
+package transformers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/greenmaskio/greenmask/internal/generators"
+)
+
+const (
+	IdentityTitleAttr      = "Title"
+	IdentityFirstNameAttr  = "FirstName"
+	IdentityLastNameAttr   = "LastName"
+	IdentityGenderAttr     = "Gender"
+	IdentityEmailAttr      = "Email"
+	IdentityUsernameAttr   = "Username"
+	IdentityPhoneAttr      = "Phone"
+	IdentityBirthDateAttr  = "BirthDate"
+	IdentityStreetAttr     = "Street"
+	IdentityCityAttr       = "City"
+	IdentityStateAttr      = "State"
+	IdentityPostalCodeAttr = "PostalCode"
+	IdentityAddressAttr    = "Address"
+)
+
+// IdentityAttributes - the persona attributes that can be mapped to the columns
+var IdentityAttributes = []string{
+	IdentityTitleAttr, IdentityFirstNameAttr, IdentityLastNameAttr, IdentityGenderAttr, IdentityEmailAttr,
+	IdentityUsernameAttr, IdentityPhoneAttr, IdentityBirthDateAttr, IdentityStreetAttr, IdentityCityAttr,
+	IdentityStateAttr, IdentityPostalCodeAttr, IdentityAddressAttr,
+}
+
+const (
+	identityPhoneDigitPlaceholder = '#'
+	// identityMaxPhoneDigits - keeps the required byte length in the limits of the hash generator
+	identityMaxPhoneDigits = 20
+)
+
+var DefaultIdentityEmailDomains = []string{"example.com", "example.net", "example.org"}
+
+const DefaultIdentityPhoneFormat = "+1-###-###-####"
+
+var DefaultIdentityStreetNames = []string{
+	"Maple", "Oak", "Pine", "Cedar", "Elm", "Washington", "Lake", "Hill", "Park", "Main", "Church", "High",
+	"Sunset", "River", "Meadow", "Forest", "Spring", "Lincoln", "Jefferson", "Madison", "Franklin", "Walnut",
+	"Chestnut", "Willow", "Highland", "Ridge", "Valley", "Mill", "Bridge", "Center", "Union", "Grove",
+}
+
+var DefaultIdentityStreetSuffixes = []string{
+	"Street", "Avenue", "Road", "Lane", "Drive", "Court", "Boulevard", "Way", "Place", "Terrace",
+}
+
+// IdentityCity - the city with its state and the first 3 digits of the postal code
+type IdentityCity struct {
+	City         string
+	State        string
+	PostalPrefix string
+}
+
+var DefaultIdentityCities = []IdentityCity{
+	{"New York", "NY", "100"},
+	{"Buffalo", "NY", "142"},
+	{"Los Angeles", "CA", "900"},
+	{"San Francisco", "CA", "941"},
+	{"San Diego", "CA", "921"},
+	{"Chicago", "IL", "606"},
+	{"Houston", "TX", "770"},
+	{"Austin", "TX", "787"},
+	{"Dallas", "TX", "752"},
+	{"Phoenix", "AZ", "850"},
+	{"Philadelphia", "PA", "191"},
+	{"Pittsburgh", "PA", "152"},
+	{"Seattle", "WA", "981"},
+	{"Portland", "OR", "972"},
+	{"Denver", "CO", "802"},
+	{"Boston", "MA", "021"},
+	{"Atlanta", "GA", "303"},
+	{"Miami", "FL", "331"},
+	{"Orlando", "FL", "328"},
+	{"Detroit", "MI", "482"},
+	{"Minneapolis", "MN", "554"},
+	{"Columbus", "OH", "432"},
+	{"Nashville", "TN", "372"},
+	{"Charlotte", "NC", "282"},
+	{"Las Vegas", "NV", "891"},
+	{"Salt Lake City", "UT", "841"},
+	{"Kansas City", "MO", "641"},
+	{"Baltimore", "MD", "212"},
+}
+
+// RandomIdentityTransformer - generates the complete fake persona. All the attributes are derived from the single
+// generated sequence, so they are coherent with each other (the email and the username are built from the name,
+// the title matches the gender) and the same key gets the same persona with the hash generator
+type RandomIdentityTransformer struct {
+	person       *RandomPersonTransformer
+	gender       string
+	emailDomains []string
+	phoneFormat  string
+	// latestBirthDate - the birthdate of the youngest possible persona
+	latestBirthDate time.Time
+	// birthDateRange - number of the possible birthdates
+	birthDateRange uint32
+	byteLength     int
+	generator      generators.Generator
+	result         map[string]string
+}
+
+func NewRandomIdentityTransformer(
+	gender string, minAge, maxAge int, referenceDate time.Time, emailDomains []string, phoneFormat string,
+) (*RandomIdentityTransformer, error) {
+	if minAge < 0 || minAge > maxAge {
+		return nil, ErrWrongLimits
+	}
+	if len(emailDomains) == 0 {
+		emailDomains = DefaultIdentityEmailDomains
+	}
+	if phoneFormat == "" {
+		phoneFormat = DefaultIdentityPhoneFormat
+	}
+
+	referenceDate = time.Date(referenceDate.Year(), referenceDate.Month(), referenceDate.Day(), 0, 0, 0, 0, time.UTC)
+	latestBirthDate := referenceDate.AddDate(-minAge, 0, 0)
+	// The oldest persona is maxAge years and 364 days old
+	earliestBirthDate := referenceDate.AddDate(-maxAge-1, 0, 1)
+	birthDateRange := uint32(latestBirthDate.Sub(earliestBirthDate).Hours()/24) + 1
+
+	person := NewRandomPersonTransformer(gender, nil)
+	// 1 byte for gender and 4 bytes per name attribute
+	personByteLength := 1 + len(person.GetDb().Attributes)*4
+	phoneDigits := strings.Count(phoneFormat, string(identityPhoneDigitPlaceholder))
+	if phoneDigits > identityMaxPhoneDigits {
+		return nil, fmt.Errorf("phone format must contain at most %d digits", identityMaxPhoneDigits)
+	}
+
+	return &RandomIdentityTransformer{
+		person:          person,
+		gender:          gender,
+		emailDomains:    emailDomains,
+		phoneFormat:     phoneFormat,
+		latestBirthDate: latestBirthDate,
+		birthDateRange:  birthDateRange,
+		// person bytes + 2 bytes for the username and email styles + 2 bytes for the login number +
+		// 1 byte for the email domain + 4 bytes for the birthdate + 2 bytes for the house number +
+		// 2 bytes for the street name + 1 byte for the street suffix + 2 bytes for the city +
+		// 2 bytes for the postal code + 1 byte per phone digit
+		byteLength: personByteLength + 19 + phoneDigits,
+		result:     make(map[string]string, len(IdentityAttributes)),
+	}, nil
+}
+
+// GetIdentity - returns the persona attributes by the key. The map is reused by the next call
+func (rit *RandomIdentityTransformer) GetIdentity(key []byte) (map[string]string, error) {
+	resBytes, err := rit.generator.Generate(key)
+	if err != nil {
+		return nil, fmt.Errorf("error generating identity: %w", err)
+	}
+
+	gender, err := rit.person.getGender(rit.gender, resBytes[0])
+	if err != nil {
+		return nil, err
+	}
+	rit.result[IdentityGenderAttr] = gender
+	offset := 1
+	for _, attr := range rit.person.GetDb().Attributes {
+		attrIdx := binary.LittleEndian.Uint32(resBytes[offset : offset+4])
+		rit.result[attr] = rit.person.GetDb().GetRandomAttribute(gender, attr, attrIdx)
+		offset += 4
+	}
+	first := identityLoginPart(rit.result[IdentityFirstNameAttr])
+	last := identityLoginPart(rit.result[IdentityLastNameAttr])
+
+	usernameStyle, emailStyle := resBytes[offset], resBytes[offset+1]
+	number := binary.LittleEndian.Uint16(resBytes[offset+2:offset+4]) % 100
+	domain := rit.emailDomains[int(resBytes[offset+4])%len(rit.emailDomains)]
+	offset += 5
+	rit.result[IdentityUsernameAttr] = identityLogin(first, last, number, usernameStyle)
+	rit.result[IdentityEmailAttr] = identityLogin(first, last, number, emailStyle) + "@" + domain
+
+	birthDateOffset := binary.LittleEndian.Uint32(resBytes[offset:offset+4]) % rit.birthDateRange
+	offset += 4
+	rit.result[IdentityBirthDateAttr] = rit.latestBirthDate.AddDate(0, 0, -int(birthDateOffset)).Format(time.DateOnly)
+
+	houseNumber := binary.LittleEndian.Uint16(resBytes[offset:offset+2])%9899 + 1
+	streetName := DefaultIdentityStreetNames[int(binary.LittleEndian.Uint16(resBytes[offset+2:offset+4]))%len(DefaultIdentityStreetNames)]
+	streetSuffix := DefaultIdentityStreetSuffixes[int(resBytes[offset+4])%len(DefaultIdentityStreetSuffixes)]
+	city := DefaultIdentityCities[int(binary.LittleEndian.Uint16(resBytes[offset+5:offset+7]))%len(DefaultIdentityCities)]
+	postalSuffix := binary.LittleEndian.Uint16(resBytes[offset+7:offset+9]) % 100
+	offset += 9
+	rit.result[IdentityStreetAttr] = fmt.Sprintf("%d %s %s", houseNumber, streetName, streetSuffix)
+	rit.result[IdentityCityAttr] = city.City
+	rit.result[IdentityStateAttr] = city.State
+	rit.result[IdentityPostalCodeAttr] = fmt.Sprintf("%s%02d", city.PostalPrefix, postalSuffix)
+	rit.result[IdentityAddressAttr] = fmt.Sprintf(
+		"%s, %s, %s %s", rit.result[IdentityStreetAttr], city.City, city.State, rit.result[IdentityPostalCodeAttr],
+	)
+
+	phone := []byte(rit.phoneFormat)
+	for idx := range phone {
+		if phone[idx] != identityPhoneDigitPlaceholder {
+			continue
+		}
+		phone[idx] = '0' + resBytes[offset]%10
+		offset++
+	}
+	rit.result[IdentityPhoneAttr] = string(phone)
+
+	return rit.result, nil
+}
+
+func (rit *RandomIdentityTransformer) GetRequiredGeneratorByteLength() int {
+	return rit.byteLength
+}
+
+func (rit *RandomIdentityTransformer) SetGenerator(g generators.Generator) error {
+	if g.Size() < rit.byteLength {
+		return fmt.Errorf("requested byte length (%d) higher than generator can produce (%d)", rit.byteLength, g.Size())
+	}
+	rit.generator = g
+	return nil
+}
+
+// identityLogin - builds the login from the name in one of the styles: anna.schmidt, anna_schmidt, aschmidt,
+// anna.schmidt42
+func identityLogin(first, last string, number uint16, style byte) string {
+	switch style % 4 {
+	case 0:
+		return first + "." + last
+	case 1:
+		return first + "_" + last
+	case 2:
+		return first[:1] + last
+	default:
+		return fmt.Sprintf("%s.%s%d", first, last, number)
+	}
+}
+
+// identityLoginPart - lowers the name and removes the characters that are not allowed in the login
+func identityLoginPart(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() == 0 {
+		return "user"
+	}
+	return sb.String()
+}
@@ -0,0 +1,67 @@
+package transformers
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/generators"
+)
+
+func TestRandomIdentityTransformer_GetIdentity(t *testing.T) {
+	referenceDate := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	rit, err := NewRandomIdentityTransformer(AnyGenderName, 18, 30, referenceDate, []string{"corp.test"}, "")
+	require.NoError(t, err)
+	g, err := generators.GetHashBytesGen([]byte("salt"), rit.GetRequiredGeneratorByteLength())
+	require.NoError(t, err)
+	require.NoError(t, rit.SetGenerator(g))
+
+	res, err := rit.GetIdentity([]byte("42"))
+	require.NoError(t, err)
+	for _, attr := range IdentityAttributes {
+		assert.NotEmpty(t, res[attr], attr)
+	}
+
+	gender := res[IdentityGenderAttr]
+	if gender == MaleGenderName {
+		assert.True(t, slices.Contains(DefaultFirstNamesMale, res[IdentityFirstNameAttr]))
+		assert.True(t, slices.Contains(DefaultTitlesMale, res[IdentityTitleAttr]))
+	} else {
+		assert.True(t, slices.Contains(DefaultFirstNamesFemale, res[IdentityFirstNameAttr]))
+		assert.True(t, slices.Contains(DefaultTitlesFemale, res[IdentityTitleAttr]))
+	}
+	assert.True(t, strings.HasSuffix(res[IdentityEmailAttr], "@corp.test"))
+	assert.Contains(t, res[IdentityEmailAttr], identityLoginPart(res[IdentityLastNameAttr]))
+	assert.Contains(t, res[IdentityUsernameAttr], identityLoginPart(res[IdentityLastNameAttr]))
+	assert.Regexp(t, `^\+1-\d{3}-\d{3}-\d{4}$`, res[IdentityPhoneAttr])
+	assert.Contains(t, res[IdentityAddressAttr], res[IdentityCityAttr])
+
+	birthDate, err := time.Parse(time.DateOnly, res[IdentityBirthDateAttr])
+	require.NoError(t, err)
+	assert.False(t, birthDate.After(referenceDate.AddDate(-18, 0, 0)))
+	assert.True(t, birthDate.After(referenceDate.AddDate(-31, 0, 0)))
+
+	expected := make(map[string]string)
+	for k, v := range res {
+		expected[k] = v
+	}
+	res, err = rit.GetIdentity([]byte("42"))
+	require.NoError(t, err)
+	assert.Equal(t, expected, res)
+}
+
+func TestRandomIdentityTransformer_wrongParams(t *testing.T) {
+	_, err := NewRandomIdentityTransformer(AnyGenderName, 30, 18, time.Now(), nil, "")
+	require.ErrorIs(t, err, ErrWrongLimits)
+	_, err = NewRandomIdentityTransformer(AnyGenderName, 18, 30, time.Now(), nil, strings.Repeat("#", 21))
+	require.Error(t, err)
+}
+
+func Test_identityLoginPart(t *testing.T) {
+	assert.Equal(t, "oconnor", identityLoginPart("O'Connor"))
+	assert.Equal(t, "user", identityLoginPart("---"))
+}
@@ -149,6 +149,8 @@ func NewPersonalDatabase(data Database) *PersonDatabase {
 	}
 
 	slices.Sort(attributes)
+	// The map iteration order is random, so genders are sorted to get the same gender by the same hash
+	slices.Sort(genders)
 
 	return &PersonDatabase{
 		Db:              data,
@@ -0,0 +1,325 @@
+package transformers
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/greenmaskio/greenmask/internal/generators"
+)
+
+const (
+	TextDetectorEmail      = "email"
+	TextDetectorPhone      = "phone"
+	TextDetectorCardNumber = "card_number"
+	TextDetectorIBAN       = "iban"
+	TextDetectorSSN        = "ssn"
+)
+
+const (
+	TextScrubberModeFake = "fake"
+	TextScrubberModeTag  = "tag"
+)
+
+const (
+	textScrubberByteLength     = checksumIdByteLength
+	textScrubberEmailLocalSize = 8
+	textScrubberPhoneMinDigits = 7
+	textScrubberPhoneMaxDigits = 15
+)
+
+// TextDetectors - the built-in detectors in the order of their priority. If the findings of several detectors
+// overlap, the finding of the detector that goes first is replaced
+var TextDetectors = []string{
+	TextDetectorEmail, TextDetectorIBAN, TextDetectorPhone, TextDetectorCardNumber, TextDetectorSSN,
+}
+
+// TextDetector - finds the sensitive values of a single type in the text and generates the replacements
+type TextDetector struct {
+	Name string
+	re   *regexp.Regexp
+	// check - additional check of the finding, e.g. Luhn check digit of the card number
+	check func(finding string) bool
+	// fake - generates the replacement of the finding using the random bytes
+	fake func(finding string, rnd []byte) string
+}
+
+// NewCustomTextDetector - creates the detector by the user-supplied regular expression. The fake replacement keeps
+// the format of the finding: the digits are replaced with digits and the letters with letters of the same case
+func NewCustomTextDetector(name, expr string) (*TextDetector, error) {
+	if name == "" {
+		return nil, fmt.Errorf("detector name is required")
+	}
+	if slices.Contains(TextDetectors, name) {
+		return nil, fmt.Errorf("detector name \"%s\" is reserved by the built-in detector", name)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot compile regular expression: %w", err)
+	}
+	return &TextDetector{
+		Name: name,
+		re:   re,
+		fake: fakeKeepingFormat,
+	}, nil
+}
+
+// NewBuiltInTextDetector - creates the built-in detector by its name
+func NewBuiltInTextDetector(name string) (*TextDetector, error) {
+	switch name {
+	case TextDetectorEmail:
+		return &TextDetector{
+			Name: name,
+			re:   regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`),
+			fake: fakeEmail,
+		}, nil
+	case TextDetectorIBAN:
+		return &TextDetector{
+			Name:  name,
+			re:    regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`),
+			check: checkTextIBAN,
+			fake: func(finding string, rnd []byte) string {
+				// The country of the original IBAN is kept if it is supported
+				return applyTextLayout(finding, generateIBAN("DE", normalizeChecksumId([]byte(finding)), rnd))
+			},
+		}, nil
+	case TextDetectorCardNumber:
+		return &TextDetector{
+			Name:  name,
+			re:    regexp.MustCompile(`\b(?:\d[ -]?){11,18}\d\b`),
+			check: checkTextCardNumber,
+			fake: func(finding string, rnd []byte) string {
+				return applyTextLayout(finding, generateCardNumber("", rnd))
+			},
+		}, nil
+	case TextDetectorSSN:
+		return &TextDetector{
+			Name:  name,
+			re:    regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+			check: checkTextSSN,
+			fake: func(finding string, rnd []byte) string {
+				return generateSSN("", rnd)
+			},
+		}, nil
+	case TextDetectorPhone:
+		return &TextDetector{
+			Name: name,
+			re: regexp.MustCompile(
+				`\+\d{1,3}(?:[ .-]?\(?\d{1,4}\)?){2,5}|\(?\b\d{3}\)?[ .-]?\d{3}[ .-]\d{4}\b`,
+			),
+			check: checkTextPhone,
+			fake:  fakeKeepingFormat,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown detector \"%s\"", name)
+}
+
+type textFinding struct {
+	start, end int
+	detector   int
+}
+
+// TextScrubber - replaces the findings of the detectors in the text with the fake values or with the tags like
+// [EMAIL]. The fake value depends only on the finding, so the same value is replaced with the same fake value
+// under the hash engine
+type TextScrubber struct {
+	detectors  []*TextDetector
+	mode       string
+	generator  generators.Generator
+	byteLength int
+	hits       map[string]uint64
+	findings   []textFinding
+}
+
+func NewTextScrubber(detectors []*TextDetector, mode string) (*TextScrubber, error) {
+	if len(detectors) == 0 {
+		return nil, fmt.Errorf("at least one detector is required")
+	}
+	if mode != TextScrubberModeFake && mode != TextScrubberModeTag {
+		return nil, fmt.Errorf("unknown mode \"%s\"", mode)
+	}
+	hits := make(map[string]uint64, len(detectors))
+	for _, d := range detectors {
+		if _, ok := hits[d.Name]; ok {
+			return nil, fmt.Errorf("detector \"%s\" is defined twice", d.Name)
+		}
+		hits[d.Name] = 0
+	}
+	return &TextScrubber{
+		detectors:  detectors,
+		mode:       mode,
+		byteLength: textScrubberByteLength,
+		hits:       hits,
+	}, nil
+}
+
+func (ts *TextScrubber) GetRequiredGeneratorByteLength() int {
+	return ts.byteLength
+}
+
+func (ts *TextScrubber) SetGenerator(g generators.Generator) error {
+	if g.Size() < ts.byteLength {
+		return fmt.Errorf("requested byte length (%d) higher than generator can produce (%d)", ts.byteLength, g.Size())
+	}
+	ts.generator = g
+	return nil
+}
+
+// GetHits - returns the number of the replaced findings per detector
+func (ts *TextScrubber) GetHits() map[string]uint64 {
+	return ts.hits
+}
+
+// Scrub - replaces the findings in the text. The original text is returned if nothing is found
+func (ts *TextScrubber) Scrub(text []byte) ([]byte, error) {
+	ts.findings = ts.findings[:0]
+	for dIdx, d := range ts.detectors {
+		for _, loc := range d.re.FindAllIndex(text, -1) {
+			if d.check != nil && !d.check(string(text[loc[0]:loc[1]])) {
+				continue
+			}
+			ts.findings = append(ts.findings, textFinding{start: loc[0], end: loc[1], detector: dIdx})
+		}
+	}
+	if len(ts.findings) == 0 {
+		return text, nil
+	}
+
+	// Keep the non-overlapping findings preferring the detectors with the higher priority
+	slices.SortStableFunc(ts.findings, func(a, b textFinding) int {
+		if a.detector != b.detector {
+			return a.detector - b.detector
+		}
+		return a.start - b.start
+	})
+	selected := ts.findings[:0]
+	for _, f := range ts.findings {
+		overlaps := slices.ContainsFunc(selected, func(s textFinding) bool {
+			return f.start < s.end && s.start < f.end
+		})
+		if !overlaps {
+			selected = append(selected, f)
+		}
+	}
+	ts.findings = selected
+	slices.SortFunc(ts.findings, func(a, b textFinding) int {
+		return a.start - b.start
+	})
+
+	var sb strings.Builder
+	sb.Grow(len(text))
+	var pos int
+	for _, f := range ts.findings {
+		d := ts.detectors[f.detector]
+		replacement, err := ts.replace(d, string(text[f.start:f.end]))
+		if err != nil {
+			return nil, err
+		}
+		sb.Write(text[pos:f.start])
+		sb.WriteString(replacement)
+		pos = f.end
+		ts.hits[d.Name]++
+	}
+	sb.Write(text[pos:])
+	return []byte(sb.String()), nil
+}
+
+func (ts *TextScrubber) replace(d *TextDetector, finding string) (string, error) {
+	if ts.mode == TextScrubberModeTag {
+		return "[" + strings.ToUpper(d.Name) + "]", nil
+	}
+	rnd, err := ts.generator.Generate([]byte(finding))
+	if err != nil {
+		return "", fmt.Errorf("error generating replacement: %w", err)
+	}
+	return d.fake(finding, rnd), nil
+}
+
+func fakeEmail(_ string, rnd []byte) string {
+	domain := DefaultIdentityEmailDomains[int(rnd[textScrubberEmailLocalSize])%len(DefaultIdentityEmailDomains)]
+	return hex.EncodeToString(rnd[:textScrubberEmailLocalSize]) + "@" + domain
+}
+
+// fakeKeepingFormat - replaces the digits with random digits and the letters with random letters of the same case
+func fakeKeepingFormat(finding string, rnd []byte) string {
+	res := []rune(finding)
+	for idx, r := range res {
+		b := rnd[idx%len(rnd)]
+		switch {
+		case r >= '0' && r <= '9':
+			res[idx] = '0' + rune(b%10)
+		case r >= 'a' && r <= 'z':
+			res[idx] = 'a' + rune(b%26)
+		case r >= 'A' && r <= 'Z':
+			res[idx] = 'A' + rune(b%26)
+		}
+	}
+	return string(res)
+}
+
+// applyTextLayout - puts the characters of the generated value into the places of the letters and digits of the
+// original value, so the separators of the original value are kept. The generated value is returned as is if the
+// number of the characters differs
+func applyTextLayout(original, generated string) string {
+	var count int
+	for _, r := range original {
+		if isAlphanumeric(r) {
+			count++
+		}
+	}
+	if count != len(generated) {
+		return generated
+	}
+	res := []byte(original)
+	var pos int
+	for idx := range res {
+		if isAlphanumeric(rune(res[idx])) {
+			res[idx] = generated[pos]
+			pos++
+		}
+	}
+	return string(res)
+}
+
+func isAlphanumeric(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func onlyDigits(v string) string {
+	var sb strings.Builder
+	for _, r := range v {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func checkTextCardNumber(finding string) bool {
+	digits := onlyDigits(finding)
+	if len(digits) < checksumIdCardMinLength || len(digits) > checksumIdCardMaxLength {
+		return false
+	}
+	return luhnCheckDigit(digits[:len(digits)-1]) == digits[len(digits)-1]
+}
+
+func checkTextIBAN(finding string) bool {
+	normalized := normalizeChecksumId([]byte(finding))
+	if len(normalized) < 15 {
+		return false
+	}
+	return ibanCheckDigits(normalized[:2], normalized[4:]) == normalized[2:4]
+}
+
+func checkTextSSN(finding string) bool {
+	digits := onlyDigits(finding)
+	area, group, serial := digits[:3], digits[3:5], digits[5:]
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
+
+func checkTextPhone(finding string) bool {
+	digits := len(onlyDigits(finding))
+	return digits >= textScrubberPhoneMinDigits && digits <= textScrubberPhoneMaxDigits
+}
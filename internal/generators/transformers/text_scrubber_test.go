@@ -0,0 +1,110 @@
+package transformers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/generators"
+)
+
+func newTestTextScrubber(t *testing.T, mode string, custom ...*TextDetector) *TextScrubber {
+	var detectors []*TextDetector
+	for _, name := range TextDetectors {
+		d, err := NewBuiltInTextDetector(name)
+		require.NoError(t, err)
+		detectors = append(detectors, d)
+	}
+	detectors = append(detectors, custom...)
+	ts, err := NewTextScrubber(detectors, mode)
+	require.NoError(t, err)
+	g, err := generators.GetHashBytesGen([]byte("salt"), ts.GetRequiredGeneratorByteLength())
+	require.NoError(t, err)
+	require.NoError(t, ts.SetGenerator(g))
+	return ts
+}
+
+func TestTextScrubber_Scrub_tag(t *testing.T) {
+	custom, err := NewCustomTextDetector("ticket", `TCK-\d{6}`)
+	require.NoError(t, err)
+	ts := newTestTextScrubber(t, TextScrubberModeTag, custom)
+
+	text := "Hi, I am john.doe@example.com, call me at +1-202-555-0143 or (202) 555-0178. " +
+		"Card 4111 1111 1111 1111, IBAN DE89 3704 0044 0532 0130 00, SSN 123-45-6789. " +
+		"See TCK-004211. Order 1234567890123 placed on 2023-11-10."
+	res, err := ts.Scrub([]byte(text))
+	require.NoError(t, err)
+	assert.Equal(t,
+		"Hi, I am [EMAIL], call me at [PHONE] or [PHONE]. "+
+			"Card [CARD_NUMBER], IBAN [IBAN], SSN [SSN]. "+
+			"See [TICKET]. Order 1234567890123 placed on 2023-11-10.",
+		string(res),
+	)
+	assert.Equal(t, map[string]uint64{
+		TextDetectorEmail:      1,
+		TextDetectorPhone:      2,
+		TextDetectorCardNumber: 1,
+		TextDetectorIBAN:       1,
+		TextDetectorSSN:        1,
+		"ticket":               1,
+	}, ts.GetHits())
+}
+
+func TestTextScrubber_Scrub_fake(t *testing.T) {
+	ts := newTestTextScrubber(t, TextScrubberModeFake)
+
+	text := "Mail john.doe@example.com twice: john.doe@example.com. Card 4111-1111-1111-1111. " +
+		"IBAN DE89 3704 0044 0532 0130 00. SSN 123-45-6789. Phone +44 20 7946 0958."
+	res, err := ts.Scrub([]byte(text))
+	require.NoError(t, err)
+	assert.NotContains(t, string(res), "john.doe@example.com")
+	assert.NotContains(t, string(res), "4111-1111-1111-1111")
+	assert.NotContains(t, string(res), "DE89 3704 0044 0532 0130 00")
+	assert.NotContains(t, string(res), "123-45-6789")
+	assert.NotContains(t, string(res), "+44 20 7946 0958")
+	// The same value is replaced with the same fake value and the format is kept
+	assert.Regexp(t,
+		`^Mail ([0-9a-f]{16}@[a-z.]+) twice: ([0-9a-f]{16}@[a-z.]+)\. Card \d{4}-\d{4}-\d{4}-\d{4}\. `+
+			`IBAN DE\d{2} \d{4} \d{4} \d{4} \d{4} \d{2}\. SSN \d{3}-\d{2}-\d{4}\. Phone \+\d{2} \d{2} \d{4} \d{4}\.$`,
+		string(res),
+	)
+	emails := emailRegexp.FindAllString(string(res), -1)
+	require.Len(t, emails, 2)
+	assert.Equal(t, emails[0], emails[1])
+
+	// The replacements are valid values of their type, so they are detected again
+	again, err := ts.Scrub(res)
+	require.NoError(t, err)
+	assert.NotEqual(t, string(res), string(again))
+	assert.Equal(t, uint64(4), ts.GetHits()[TextDetectorEmail])
+	assert.Equal(t, uint64(2), ts.GetHits()[TextDetectorCardNumber])
+	assert.Equal(t, uint64(2), ts.GetHits()[TextDetectorIBAN])
+}
+
+var emailRegexp = mustBuiltInTextDetector(TextDetectorEmail).re
+
+func mustBuiltInTextDetector(name string) *TextDetector {
+	d, err := NewBuiltInTextDetector(name)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestTextScrubber_Scrub_not_found(t *testing.T) {
+	ts := newTestTextScrubber(t, TextScrubberModeFake)
+	text := "Nothing sensitive here, invoice 4111 1111 1111 1112 is not a valid card"
+	res, err := ts.Scrub([]byte(text))
+	require.NoError(t, err)
+	assert.Equal(t, text, string(res))
+}
+
+func TestNewCustomTextDetector(t *testing.T) {
+	_, err := NewCustomTextDetector("email", `.+`)
+	require.ErrorContains(t, err, "reserved")
+	_, err = NewCustomTextDetector("test", `(`)
+	require.ErrorContains(t, err, "cannot compile")
+	_, err = NewTextScrubber([]*TextDetector{mustBuiltInTextDetector(TextDetectorSSN)}, "unknown")
+	require.Error(t, err)
+}
@@ -48,33 +48,67 @@ func GetDumpStatusAndMetadata(ctx context.Context, st storages.Storager) (string
 		return heartBeatDoneContent, md, nil
 	}
 
+	status, err := getHeartBeatStatus(ctx, st, objectInfo.LastModified)
+	if err != nil {
+		return "", nil, err
+	}
+	if status != DoneStatusName {
+		return status, nil, nil
+	}
+	// if done - read and parse metadata
+	md, err := getMetadata(ctx, st)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get metadata: %w", err)
+	}
+	return heartBeatDoneContent, md, nil
+}
+
+// GetClusterDumpStatusAndMetadata - returns the status of the cluster dump and its metadata. The metadata is returned
+// for the in progress dumps too, so the databases might be listed
+func GetClusterDumpStatusAndMetadata(
+	ctx context.Context, st storages.Storager,
+) (string, *storage.ClusterMetadata, error) {
+	md, err := cmd.ReadClusterMetadata(ctx, st)
+	if err != nil {
+		return "", nil, err
+	}
+	objectInfo, err := st.Stat(cmd.HeartBeatFileName)
+	if err != nil {
+		return "", nil, err
+	}
+	if !objectInfo.Exist {
+		return UnknownOrFailedStatusName, md, nil
+	}
+	status, err := getHeartBeatStatus(ctx, st, objectInfo.LastModified)
+	if err != nil {
+		return "", nil, err
+	}
+	return status, md, nil
+}
+
+func getHeartBeatStatus(ctx context.Context, st storages.Storager, lastModified time.Time) (string, error) {
 	f, err := st.GetObject(ctx, cmd.HeartBeatFileName)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to get heart beat file: %w", err)
+		return "", fmt.Errorf("failed to get heart beat file: %w", err)
 	}
 	defer f.Close()
 	data, err := io.ReadAll(f)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to read heart beat file: %w", err)
+		return "", fmt.Errorf("failed to read heart beat file: %w", err)
 	}
 	if len(data) == 0 {
-		return FailedStatusName, nil, nil
+		return FailedStatusName, nil
 	}
 	switch string(data) {
 	case cmd.HeartBeatDoneContent:
-		// if done - read and parse metadata
-		md, err := getMetadata(ctx, st)
-		if err != nil {
-			return "", nil, fmt.Errorf("failed to get metadata: %w", err)
-		}
-		return heartBeatDoneContent, md, nil
+		return DoneStatusName, nil
 	case cmd.HeartBeatInProgressContent:
-		if time.Now().After(objectInfo.LastModified.Add(cmd.HeartBeatWriteInterval * failedTimeoutMultiplayer)) {
-			return FailedStatusName, nil, nil
+		if time.Now().After(lastModified.Add(cmd.HeartBeatWriteInterval * failedTimeoutMultiplayer)) {
+			return FailedStatusName, nil
 		}
-		return InProgressStatusName, nil, nil
+		return InProgressStatusName, nil
 	}
-	return UnknownOrFailedStatusName, nil, nil
+	return UnknownOrFailedStatusName, nil
 }
 
 func isMetadataExist(st storages.Storager) (bool, error) {
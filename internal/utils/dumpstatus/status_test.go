@@ -0,0 +1,121 @@
+package dumpstatus
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
+	"github.com/greenmaskio/greenmask/internal/storages/domains"
+	"github.com/greenmaskio/greenmask/internal/utils/testutils"
+)
+
+func newObject(data string) io.ReadCloser {
+	return io.NopCloser(strings.NewReader(data))
+}
+
+func TestGetDumpStatusAndMetadata(t *testing.T) {
+	ctx := context.Background()
+	staleHeartBeat := time.Now().Add(-cmd.HeartBeatWriteInterval * (failedTimeoutMultiplayer + 1))
+	tests := []struct {
+		name           string
+		heartBeat      *string
+		lastModified   time.Time
+		metadataExists bool
+		expectedStatus string
+		expectedMd     bool
+	}{
+		{name: "done", heartBeat: ptr(cmd.HeartBeatDoneContent), expectedStatus: DoneStatusName, expectedMd: true},
+		{
+			name:           "in progress",
+			heartBeat:      ptr(cmd.HeartBeatInProgressContent),
+			lastModified:   time.Now(),
+			expectedStatus: InProgressStatusName,
+		},
+		{
+			name:           "stale in progress is failed",
+			heartBeat:      ptr(cmd.HeartBeatInProgressContent),
+			lastModified:   staleHeartBeat,
+			expectedStatus: FailedStatusName,
+		},
+		{name: "empty heart beat is failed", heartBeat: ptr(""), expectedStatus: FailedStatusName},
+		{name: "unknown heart beat", heartBeat: ptr("unknown"), expectedStatus: UnknownOrFailedStatusName},
+		{name: "legacy dump without heart beat", metadataExists: true, expectedStatus: DoneStatusName, expectedMd: true},
+		{name: "no heart beat and metadata", expectedStatus: UnknownOrFailedStatusName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := new(testutils.StorageMock)
+			st.On("Stat", cmd.HeartBeatFileName).
+				Return(&domains.ObjectStat{Exist: tt.heartBeat != nil, LastModified: tt.lastModified}, nil)
+			st.On("Stat", cmd.MetadataJsonFileName).
+				Return(&domains.ObjectStat{Exist: tt.metadataExists}, nil)
+			if tt.heartBeat != nil {
+				st.On("GetObject", ctx, cmd.HeartBeatFileName).Return(newObject(*tt.heartBeat), nil)
+			}
+			st.On("GetObject", ctx, cmd.MetadataJsonFileName).Return(newObject(`{"originalSize": 10}`), nil)
+
+			status, md, err := GetDumpStatusAndMetadata(ctx, st)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, status)
+			if !tt.expectedMd {
+				assert.Nil(t, md)
+				return
+			}
+			require.NotNil(t, md)
+			assert.Equal(t, int64(10), md.OriginalSize)
+		})
+	}
+}
+
+func TestGetClusterDumpStatusAndMetadata(t *testing.T) {
+	ctx := context.Background()
+	clusterMetadata := `{"databases": [{"name": "app"}, {"name": "billing"}]}`
+	tests := []struct {
+		name           string
+		heartBeat      *string
+		expectedStatus string
+	}{
+		{name: "done", heartBeat: ptr(cmd.HeartBeatDoneContent), expectedStatus: DoneStatusName},
+		{name: "in progress", heartBeat: ptr(cmd.HeartBeatInProgressContent), expectedStatus: InProgressStatusName},
+		{name: "failed", heartBeat: ptr(""), expectedStatus: FailedStatusName},
+		{name: "no heart beat", expectedStatus: UnknownOrFailedStatusName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := new(testutils.StorageMock)
+			st.On("GetObject", ctx, cmd.ClusterMetadataJsonFileName).Return(newObject(clusterMetadata), nil)
+			st.On("Stat", cmd.HeartBeatFileName).
+				Return(&domains.ObjectStat{Exist: tt.heartBeat != nil, LastModified: time.Now()}, nil)
+			if tt.heartBeat != nil {
+				st.On("GetObject", ctx, cmd.HeartBeatFileName).Return(newObject(*tt.heartBeat), nil)
+			}
+
+			status, md, err := GetClusterDumpStatusAndMetadata(ctx, st)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, status)
+			// The metadata is returned regardless of the status, so the databases are listed
+			require.NotNil(t, md)
+			require.Len(t, md.Databases, 2)
+			assert.Equal(t, "app", md.Databases[0].Name)
+			assert.Equal(t, "billing", md.Databases[1].Name)
+		})
+	}
+
+	t.Run("metadata error", func(t *testing.T) {
+		st := new(testutils.StorageMock)
+		st.On("GetObject", ctx, cmd.ClusterMetadataJsonFileName).Return(nil, errors.New("not found"))
+		_, _, err := GetClusterDumpStatusAndMetadata(ctx, st)
+		require.ErrorContains(t, err, "cannot open cluster metadata file")
+	})
+}
+
+func ptr(v string) *string {
+	return &v
+}
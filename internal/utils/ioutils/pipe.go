@@ -25,3 +25,9 @@ func NewGzipPipe(usePgzip bool) (CountWriteCloser, CountReadCloser) {
 	// into count reader
 	return NewWriter(NewGzipWriter(pw, usePgzip)), NewReader(pr)
 }
+
+// NewPipe - returns wrapped PipeWriter into Writer and PipeReader into Reader without compression
+func NewPipe() (CountWriteCloser, CountReadCloser) {
+	pr, pw := io.Pipe()
+	return NewWriter(pw), NewReader(pr)
+}
@@ -0,0 +1,162 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const fileStorageVersion = 1
+
+type fileEntry struct {
+	Namespace  string `json:"namespace"`
+	Key        []byte `json:"key"`
+	ReverseKey []byte `json:"reverse_key,omitempty"`
+	Value      []byte `json:"value"`
+}
+
+type fileContent struct {
+	Version int          `json:"version"`
+	Entries []*fileEntry `json:"entries"`
+}
+
+// FileStorage - the storage that keeps the entries in memory and writes them into the local file on close. The file
+// is replaced atomically, so the previous state is kept if the dump is interrupted
+type FileStorage struct {
+	path    string
+	mx      sync.RWMutex
+	entries map[string]*fileEntry
+	reverse map[string][]*fileEntry
+	order   []*fileEntry
+	changed bool
+}
+
+func NewFileStorage(path string) (*FileStorage, error) {
+	fs := &FileStorage{
+		path:    path,
+		entries: make(map[string]*fileEntry),
+		reverse: make(map[string][]*fileEntry),
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fs, nil
+		}
+		return nil, fmt.Errorf("cannot read vault file: %w", err)
+	}
+	var content fileContent
+	if err = json.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("cannot parse vault file: %w", err)
+	}
+	if content.Version != fileStorageVersion {
+		return nil, fmt.Errorf("unsupported vault file version %d", content.Version)
+	}
+	for _, e := range content.Entries {
+		fs.add(e)
+	}
+	return fs, nil
+}
+
+func (fs *FileStorage) Get(_ context.Context, namespace string, key []byte) ([]byte, bool, error) {
+	fs.mx.RLock()
+	defer fs.mx.RUnlock()
+	e, ok := fs.entries[sessionKey(namespace, key)]
+	if !ok {
+		return nil, false, nil
+	}
+	return e.Value, true, nil
+}
+
+func (fs *FileStorage) PutIfAbsent(_ context.Context, namespace string, key, reverseKey, entry []byte) ([]byte, error) {
+	fs.mx.Lock()
+	defer fs.mx.Unlock()
+	if e, ok := fs.entries[sessionKey(namespace, key)]; ok {
+		return e.Value, nil
+	}
+	fs.add(&fileEntry{
+		Namespace:  namespace,
+		Key:        key,
+		ReverseKey: reverseKey,
+		Value:      entry,
+	})
+	fs.changed = true
+	return entry, nil
+}
+
+func (fs *FileStorage) GetByReverseKey(_ context.Context, namespace string, reverseKey []byte) ([]*Entry, error) {
+	fs.mx.RLock()
+	defer fs.mx.RUnlock()
+	var res []*Entry
+	for _, e := range fs.reverse[sessionKey(namespace, reverseKey)] {
+		res = append(res, &Entry{Key: e.Key, Value: e.Value})
+	}
+	return res, nil
+}
+
+// Close - writes the entries into the file if there are new ones
+func (fs *FileStorage) Close() error {
+	fs.mx.Lock()
+	defer fs.mx.Unlock()
+	if !fs.changed {
+		return nil
+	}
+	data, err := json.Marshal(&fileContent{
+		Version: fileStorageVersion,
+		Entries: fs.order,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot encode vault file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fs.path), filepath.Base(fs.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot create temporary vault file: %w", err)
+	}
+	defer func() {
+		// It does nothing if the file was renamed
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("cannot write vault file: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("cannot sync vault file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("cannot close vault file: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), fs.path); err != nil {
+		return fmt.Errorf("cannot replace vault file: %w", err)
+	}
+	fs.changed = false
+	return nil
+}
+
+func (fs *FileStorage) add(e *fileEntry) {
+	fs.entries[sessionKey(e.Namespace, e.Key)] = e
+	fs.order = append(fs.order, e)
+	if e.ReverseKey != nil {
+		rk := sessionKey(e.Namespace, e.ReverseKey)
+		fs.reverse[rk] = append(fs.reverse[rk], e)
+	}
+}
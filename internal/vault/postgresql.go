@@ -0,0 +1,128 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresqlStorage - the storage in the PostgreSQL table. The table might be shared by several greenmask instances,
+// the concurrent insertions of the same key are resolved by the primary key
+type PostgresqlStorage struct {
+	pool  *pgxpool.Pool
+	table string
+}
+
+// NewPostgresqlStorage - connects to the database and creates the table if it does not exist. The table name might
+// be qualified with the schema name
+func NewPostgresqlStorage(ctx context.Context, connString, table string) (*PostgresqlStorage, error) {
+	pool, err := pgxpool.New(ctx, connString)
+	if err != nil {
+		return nil, err
+	}
+	ps := &PostgresqlStorage{
+		pool:  pool,
+		table: pgx.Identifier(strings.Split(table, ".")).Sanitize(),
+	}
+	indexName := pgx.Identifier{strings.ReplaceAll(table, ".", "_") + "_reverse_key_idx"}.Sanitize()
+	_, err = pool.Exec(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			namespace   TEXT  NOT NULL,
+			key         BYTEA NOT NULL,
+			reverse_key BYTEA,
+			value       BYTEA NOT NULL,
+			PRIMARY KEY (namespace, key)
+		)`, ps.table,
+	))
+	if err == nil {
+		_, err = pool.Exec(ctx, fmt.Sprintf(
+			"CREATE INDEX IF NOT EXISTS %s ON %s (namespace, reverse_key)", indexName, ps.table,
+		))
+	}
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("cannot create vault table: %w", err)
+	}
+	return ps, nil
+}
+
+func (ps *PostgresqlStorage) Get(ctx context.Context, namespace string, key []byte) ([]byte, bool, error) {
+	var value []byte
+	err := ps.pool.QueryRow(
+		ctx, fmt.Sprintf("SELECT value FROM %s WHERE namespace = $1 AND key = $2", ps.table), namespace, key,
+	).Scan(&value)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// PutIfAbsent - inserts the entry and selects the stored one in the separate statement. The select statement takes
+// a new snapshot, so it sees the entry inserted by the concurrent transaction that caused the conflict
+func (ps *PostgresqlStorage) PutIfAbsent(ctx context.Context, namespace string, key, reverseKey, entry []byte) ([]byte, error) {
+	_, err := ps.pool.Exec(
+		ctx,
+		fmt.Sprintf(
+			"INSERT INTO %s (namespace, key, reverse_key, value) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING",
+			ps.table,
+		),
+		namespace, key, reverseKey, entry,
+	)
+	if err != nil {
+		return nil, err
+	}
+	value, ok, err := ps.Get(ctx, namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("vault entry is not found after insertion")
+	}
+	return value, nil
+}
+
+func (ps *PostgresqlStorage) GetByReverseKey(ctx context.Context, namespace string, reverseKey []byte) ([]*Entry, error) {
+	rows, err := ps.pool.Query(
+		ctx, fmt.Sprintf("SELECT key, value FROM %s WHERE namespace = $1 AND reverse_key = $2", ps.table),
+		namespace, reverseKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*Entry
+	for rows.Next() {
+		e := &Entry{}
+		if err = rows.Scan(&e.Key, &e.Value); err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	return res, rows.Err()
+}
+
+func (ps *PostgresqlStorage) Close() error {
+	ps.pool.Close()
+	return nil
+}
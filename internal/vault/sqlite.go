@@ -0,0 +1,115 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+	CREATE TABLE IF NOT EXISTS greenmask_vault (
+		namespace   TEXT NOT NULL,
+		key         BLOB NOT NULL,
+		reverse_key BLOB,
+		value       BLOB NOT NULL,
+		PRIMARY KEY (namespace, key)
+	);
+	CREATE INDEX IF NOT EXISTS greenmask_vault_reverse_key_idx ON greenmask_vault (namespace, reverse_key);
+`
+
+// SqliteStorage - the storage in the SQLite database. The connections are limited to one, so the concurrent
+// dumpers are serialised and the database is not locked by the process itself
+type SqliteStorage struct {
+	db *sql.DB
+}
+
+func NewSqliteStorage(ctx context.Context, path string) (*SqliteStorage, error) {
+	dsn := (&url.URL{
+		Scheme:   "file",
+		Opaque:   path,
+		RawQuery: "_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)",
+	}).String()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if _, err = db.ExecContext(ctx, sqliteSchema); err != nil {
+		return nil, errors.Join(fmt.Errorf("cannot create vault table: %w", err), db.Close())
+	}
+	return &SqliteStorage{db: db}, nil
+}
+
+func (ss *SqliteStorage) Get(ctx context.Context, namespace string, key []byte) ([]byte, bool, error) {
+	var value []byte
+	err := ss.db.QueryRowContext(
+		ctx, "SELECT value FROM greenmask_vault WHERE namespace = ? AND key = ?", namespace, key,
+	).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (ss *SqliteStorage) PutIfAbsent(ctx context.Context, namespace string, key, reverseKey, entry []byte) ([]byte, error) {
+	_, err := ss.db.ExecContext(
+		ctx,
+		"INSERT INTO greenmask_vault (namespace, key, reverse_key, value) VALUES (?, ?, ?, ?) ON CONFLICT DO NOTHING",
+		namespace, key, reverseKey, entry,
+	)
+	if err != nil {
+		return nil, err
+	}
+	value, ok, err := ss.Get(ctx, namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("vault entry is not found after insertion")
+	}
+	return value, nil
+}
+
+func (ss *SqliteStorage) GetByReverseKey(ctx context.Context, namespace string, reverseKey []byte) ([]*Entry, error) {
+	rows, err := ss.db.QueryContext(
+		ctx, "SELECT key, value FROM greenmask_vault WHERE namespace = ? AND reverse_key = ?", namespace, reverseKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*Entry
+	for rows.Next() {
+		e := &Entry{}
+		if err = rows.Scan(&e.Key, &e.Value); err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	return res, rows.Err()
+}
+
+func (ss *SqliteStorage) Close() error {
+	return ss.db.Close()
+}
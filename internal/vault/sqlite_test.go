@@ -0,0 +1,38 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVault_sqlite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.db")
+	testVaultStorage(t, func() Storage {
+		s, err := NewSqliteStorage(context.Background(), path)
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestVault_sqlite_concurrent_store(t *testing.T) {
+	s, err := NewSqliteStorage(context.Background(), filepath.Join(t.TempDir(), "vault.db"))
+	require.NoError(t, err)
+	testVaultConcurrentStore(t, s)
+}
@@ -0,0 +1,332 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/greenmaskio/greenmask/internal/domains"
+)
+
+const (
+	FileType       = "file"
+	SqliteType     = "sqlite"
+	PostgresqlType = "postgresql"
+
+	// KeyEnvName - the environment variable with the hex encoded vault key
+	KeyEnvName = "GREENMASK_VAULT_KEY"
+
+	defaultTable = "greenmask_vault"
+	minKeyLength = 16
+	// checkNamespace - the reserved namespace of the entry that is used for the key verification
+	checkNamespace = ""
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid vault key")
+
+	checkValue = []byte("greenmask vault")
+)
+
+// Storage - the storage of the vault entries. The entries are looked up by the HMAC of the original value and by the
+// HMAC of the fake value. The entries are encrypted, so the storage never keeps the original and fake values in
+// plain text. The implementations must be safe for concurrent use
+type Storage interface {
+	// Get - returns the entry by the key
+	Get(ctx context.Context, namespace string, key []byte) ([]byte, bool, error)
+	// PutIfAbsent - stores the entry if there is no entry with the same key and returns the stored entry. If the
+	// entry was stored concurrently the existing entry is returned
+	PutIfAbsent(ctx context.Context, namespace string, key, reverseKey, entry []byte) ([]byte, error)
+	// GetByReverseKey - returns the entries by the reverse key
+	GetByReverseKey(ctx context.Context, namespace string, reverseKey []byte) ([]*Entry, error)
+	Close() error
+}
+
+// Entry - the stored entry and its key
+type Entry struct {
+	Key   []byte
+	Value []byte
+}
+
+// Vault - the encrypted storage of the original to fake values mappings. The mappings are grouped by the namespaces,
+// so the same original value might be mapped to the different fakes in the different columns. In the read only mode
+// the new mappings are kept in memory until the vault is closed
+type Vault struct {
+	storage  Storage
+	aead     cipher.AEAD
+	macKey   []byte
+	readOnly bool
+	mx       sync.Mutex
+	session  map[string][]byte
+}
+
+// Open - opens the vault storage by the config. The key is read from GREENMASK_VAULT_KEY environment variable
+func Open(ctx context.Context, cfg *domains.Vault, readOnly bool) (*Vault, error) {
+	keyHex := os.Getenv(KeyEnvName)
+	if keyHex == "" {
+		return nil, fmt.Errorf("%s environment variable must be set", KeyEnvName)
+	}
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding vault key from hex: %w", err)
+	}
+
+	var s Storage
+	switch cfg.Type {
+	case "", FileType:
+		if cfg.Path == "" {
+			return nil, errors.New("vault path must be set for file vault")
+		}
+		s, err = NewFileStorage(cfg.Path)
+	case SqliteType:
+		if cfg.Path == "" {
+			return nil, errors.New("vault path must be set for sqlite vault")
+		}
+		s, err = NewSqliteStorage(ctx, cfg.Path)
+	case PostgresqlType:
+		if cfg.ConnectionString == "" {
+			return nil, errors.New("vault connection_string must be set for postgresql vault")
+		}
+		table := cfg.Table
+		if table == "" {
+			table = defaultTable
+		}
+		s, err = NewPostgresqlStorage(ctx, cfg.ConnectionString, table)
+	default:
+		return nil, fmt.Errorf("unknown vault type \"%s\": expected file, sqlite or postgresql", cfg.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s vault: %w", cfg.Type, err)
+	}
+
+	v, err := New(ctx, s, key, readOnly)
+	if err != nil {
+		if closeErr := s.Close(); closeErr != nil {
+			return nil, errors.Join(err, closeErr)
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+// New - creates the vault over the storage. The encryption and HMAC keys are derived from the key. The key is
+// verified against the check entry of the storage, the check entry is created in the empty storage
+func New(ctx context.Context, s Storage, key []byte, readOnly bool) (*Vault, error) {
+	if len(key) < minKeyLength {
+		return nil, fmt.Errorf("vault key must be at least %d bytes long", minKeyLength)
+	}
+	encKey, err := deriveKey(key, "greenmask vault encryption")
+	if err != nil {
+		return nil, err
+	}
+	macKey, err := deriveKey(key, "greenmask vault mac")
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create cipher: %w", err)
+	}
+	v := &Vault{
+		storage:  s,
+		aead:     aead,
+		macKey:   macKey,
+		readOnly: readOnly,
+		session:  make(map[string][]byte),
+	}
+	if err = v.checkKey(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Lookup - returns the fake value of the original one if it is stored in the vault
+func (v *Vault) Lookup(ctx context.Context, namespace string, original []byte) ([]byte, bool, error) {
+	key := v.mac("o", namespace, original)
+	if v.readOnly {
+		v.mx.Lock()
+		fake, ok := v.session[sessionKey(namespace, key)]
+		v.mx.Unlock()
+		if ok {
+			return fake, true, nil
+		}
+	}
+	entry, ok, err := v.storage.Get(ctx, namespace, key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	_, fake, err := v.decrypt(namespace, key, entry)
+	if err != nil {
+		return nil, false, err
+	}
+	return fake, true, nil
+}
+
+// Store - stores the mapping if the original value is not mapped yet and returns the stored fake value. If the
+// original value was mapped concurrently, the existing fake value is returned and must be used instead
+func (v *Vault) Store(ctx context.Context, namespace string, original, fake []byte) ([]byte, error) {
+	key := v.mac("o", namespace, original)
+	if v.readOnly {
+		v.mx.Lock()
+		defer v.mx.Unlock()
+		sk := sessionKey(namespace, key)
+		if stored, ok := v.session[sk]; ok {
+			return stored, nil
+		}
+		v.session[sk] = bytes.Clone(fake)
+		return fake, nil
+	}
+	entry, err := v.encrypt(namespace, key, original, fake)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := v.storage.PutIfAbsent(ctx, namespace, key, v.mac("f", namespace, fake), entry)
+	if err != nil {
+		return nil, err
+	}
+	_, storedFake, err := v.decrypt(namespace, key, stored)
+	if err != nil {
+		return nil, err
+	}
+	return storedFake, nil
+}
+
+// Reverse - returns the original values that are mapped to the fake value. There might be several original values
+// if the transformer generated the same fake value for them
+func (v *Vault) Reverse(ctx context.Context, namespace string, fake []byte) ([][]byte, error) {
+	entries, err := v.storage.GetByReverseKey(ctx, namespace, v.mac("f", namespace, fake))
+	if err != nil {
+		return nil, err
+	}
+	var res [][]byte
+	for _, entry := range entries {
+		original, storedFake, err := v.decrypt(namespace, entry.Key, entry.Value)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(storedFake, fake) {
+			continue
+		}
+		res = append(res, original)
+	}
+	return res, nil
+}
+
+// Close - closes the storage. The file storage is written on close
+func (v *Vault) Close() error {
+	return v.storage.Close()
+}
+
+// checkKey - verifies the key by decrypting the check entry. The check entry is stored with the constant key, so it
+// is found with any vault key
+func (v *Vault) checkKey(ctx context.Context) error {
+	key := checkValue
+	entry, ok, err := v.storage.Get(ctx, checkNamespace, key)
+	if err != nil {
+		return fmt.Errorf("cannot get check entry: %w", err)
+	}
+	if !ok {
+		if v.readOnly {
+			return nil
+		}
+		entry, err = v.encrypt(checkNamespace, key, checkValue, checkValue)
+		if err != nil {
+			return err
+		}
+		entry, err = v.storage.PutIfAbsent(ctx, checkNamespace, key, nil, entry)
+		if err != nil {
+			return fmt.Errorf("cannot store check entry: %w", err)
+		}
+	}
+	if _, _, err = v.decrypt(checkNamespace, key, entry); err != nil {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+func (v *Vault) mac(kind, namespace string, value []byte) []byte {
+	h := hmac.New(sha256.New, v.macKey)
+	h.Write([]byte(kind))
+	h.Write(binary.AppendUvarint(nil, uint64(len(namespace))))
+	h.Write([]byte(namespace))
+	h.Write(value)
+	return h.Sum(nil)
+}
+
+// encrypt - encrypts the original and fake values. The namespace and key are authenticated as well, so the entry
+// cannot be moved to another key
+func (v *Vault) encrypt(namespace string, key, original, fake []byte) ([]byte, error) {
+	plain := binary.AppendUvarint(nil, uint64(len(original)))
+	plain = append(plain, original...)
+	plain = append(plain, fake...)
+	nonce := make([]byte, v.aead.NonceSize(), v.aead.NonceSize()+len(plain)+v.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("cannot generate nonce: %w", err)
+	}
+	return v.aead.Seal(nonce, nonce, plain, additionalData(namespace, key)), nil
+}
+
+func (v *Vault) decrypt(namespace string, key, entry []byte) ([]byte, []byte, error) {
+	if len(entry) < v.aead.NonceSize() {
+		return nil, nil, errors.New("vault entry is too short")
+	}
+	nonce, data := entry[:v.aead.NonceSize()], entry[v.aead.NonceSize():]
+	plain, err := v.aead.Open(nil, nonce, data, additionalData(namespace, key))
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot decrypt vault entry: %w", err)
+	}
+	l, n := binary.Uvarint(plain)
+	if n <= 0 || uint64(len(plain)-n) < l {
+		return nil, nil, errors.New("malformed vault entry")
+	}
+	return plain[n : n+int(l)], plain[n+int(l):], nil
+}
+
+func additionalData(namespace string, key []byte) []byte {
+	res := binary.AppendUvarint(nil, uint64(len(namespace)))
+	res = append(res, namespace...)
+	return append(res, key...)
+}
+
+func sessionKey(namespace string, key []byte) string {
+	return namespace + "\x00" + string(key)
+}
+
+func deriveKey(key []byte, info string) ([]byte, error) {
+	res := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(info)), res); err != nil {
+		return nil, fmt.Errorf("cannot derive key: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/greenmaskio/greenmask/internal/domains"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func testVaultStorage(t *testing.T, open func() Storage) {
+	ctx := context.Background()
+
+	v, err := New(ctx, open(), testKey, false)
+	require.NoError(t, err)
+
+	_, ok, err := v.Lookup(ctx, "users.name", []byte("John"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	stored, err := v.Store(ctx, "users.name", []byte("John"), []byte("Bob"))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", string(stored))
+	stored, err = v.Store(ctx, "users.name", []byte("Jane"), []byte("Bob"))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", string(stored))
+
+	// The first stored mapping wins
+	stored, err = v.Store(ctx, "users.name", []byte("John"), []byte("Alice"))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", string(stored))
+
+	// The namespaces are independent
+	_, ok, err = v.Lookup(ctx, "orders.name", []byte("John"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, v.Close())
+
+	// The mappings are kept after reopening
+	v, err = New(ctx, open(), testKey, false)
+	require.NoError(t, err)
+	fake, ok, err := v.Lookup(ctx, "users.name", []byte("John"))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "Bob", string(fake))
+
+	originals, err := v.Reverse(ctx, "users.name", []byte("Bob"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"John", "Jane"}, toStrings(originals))
+	originals, err = v.Reverse(ctx, "users.name", []byte("Alice"))
+	require.NoError(t, err)
+	assert.Empty(t, originals)
+	require.NoError(t, v.Close())
+
+	// The wrong key is detected
+	s := open()
+	_, err = New(ctx, s, []byte("fedcba9876543210fedcba9876543210"), false)
+	require.ErrorIs(t, err, ErrInvalidKey)
+	require.NoError(t, s.Close())
+}
+
+func testVaultConcurrentStore(t *testing.T, s Storage) {
+	ctx := context.Background()
+	v, err := New(ctx, s, testKey, false)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, v.Close())
+	}()
+
+	var wg sync.WaitGroup
+	res := make([]string, 8)
+	for i := range res {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stored, err := v.Store(ctx, "users.name", []byte("John"), []byte(fmt.Sprintf("fake%d", i)))
+			assert.NoError(t, err)
+			res[i] = string(stored)
+		}()
+	}
+	wg.Wait()
+	for _, r := range res {
+		assert.Equal(t, res[0], r)
+	}
+}
+
+func TestVault_file(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+	testVaultStorage(t, func() Storage {
+		s, err := NewFileStorage(path)
+		require.NoError(t, err)
+		return s
+	})
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "John")
+	assert.NotContains(t, string(data), "Bob")
+}
+
+func TestVault_file_concurrent_store(t *testing.T) {
+	s, err := NewFileStorage(filepath.Join(t.TempDir(), "vault.json"))
+	require.NoError(t, err)
+	testVaultConcurrentStore(t, s)
+}
+
+func TestVault_read_only(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "vault.json")
+	s, err := NewFileStorage(path)
+	require.NoError(t, err)
+	v, err := New(ctx, s, testKey, true)
+	require.NoError(t, err)
+
+	stored, err := v.Store(ctx, "users.name", []byte("John"), []byte("Bob"))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", string(stored))
+	fake, ok, err := v.Lookup(ctx, "users.name", []byte("John"))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "Bob", string(fake))
+	require.NoError(t, v.Close())
+
+	// Nothing is written in the read only mode
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	cfg := &domains.Vault{
+		Path: filepath.Join(t.TempDir(), "vault.json"),
+	}
+
+	t.Setenv(KeyEnvName, "")
+	_, err := Open(ctx, cfg, false)
+	require.Error(t, err)
+
+	t.Setenv(KeyEnvName, "0123")
+	_, err = Open(ctx, cfg, false)
+	require.Error(t, err)
+
+	t.Setenv(KeyEnvName, fmt.Sprintf("%x", testKey))
+	v, err := Open(ctx, cfg, false)
+	require.NoError(t, err)
+	require.NoError(t, v.Close())
+
+	_, err = Open(ctx, &domains.Vault{Type: "unknown"}, false)
+	require.Error(t, err)
+}
+
+func toStrings(values [][]byte) []string {
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		res = append(res, string(v))
+	}
+	return res
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greenmask
+
+import (
+	"github.com/greenmaskio/greenmask/internal/db/mysql/client"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgdump"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgrestore"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/custom"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/storages/directory"
+	"github.com/greenmaskio/greenmask/internal/storages/s3"
+)
+
+// The nested types of Config. They are the aliases of the internal types, so the config can be built outside the
+// module. The transformer parameters are set with toolkit.StaticParameters and toolkit.DynamicParameters
+
+// Common, log and storage sections
+type (
+	CommonConfig           = domains.Common
+	LogConfig              = domains.LogConfig
+	StorageConfig          = domains.StorageConfig
+	S3StorageConfig        = s3.Config
+	DirectoryStorageConfig = directory.Config
+)
+
+// Dump section
+type (
+	DumpConfig                  = domains.Dump
+	PgDumpOptions               = pgdump.Options
+	MysqlOptions                = client.Options
+	Table                       = domains.Table
+	TransformerConfig           = domains.TransformerConfig
+	OnError                     = domains.OnError
+	SubsetSample                = domains.SubsetSample
+	SubsetSeeds                 = domains.SubsetSeeds
+	Subset                      = domains.Subset
+	VirtualReference            = domains.VirtualReference
+	Reference                   = domains.Reference
+	ReferencedColumn            = domains.ReferencedColumn
+	Policy                      = domains.Policy
+	SensitiveColumnsPolicy      = domains.SensitiveColumnsPolicy
+	SensitiveColumnRule         = domains.SensitiveColumnRule
+	SensitiveColumn             = domains.SensitiveColumn
+	SensitiveColumnExemption    = domains.SensitiveColumnExemption
+	Cluster                     = domains.Cluster
+	ClusterDatabase             = domains.ClusterDatabase
+	SchemaRewrite               = domains.SchemaRewrite
+	SchemaRewriteOwners         = domains.SchemaRewriteOwners
+	SchemaRewriteObject         = domains.SchemaRewriteObject
+	SchemaRewriteRule           = domains.SchemaRewriteRule
+	LargeObjects                = domains.LargeObjects
+	Vault                       = domains.Vault
+	CustomTransformerDefinition = custom.TransformerDefinition
+)
+
+// Restore section
+type (
+	RestoreConfig                        = domains.Restore
+	PgRestoreOptions                     = pgrestore.Options
+	RestoreScript                        = pgrestore.Script
+	ClusterRestore                       = domains.ClusterRestore
+	SchemaTolerance                      = domains.SchemaTolerance
+	SchemaToleranceTable                 = domains.SchemaToleranceTable
+	SchemaToleranceColumn                = domains.SchemaToleranceColumn
+	DataRestorationErrorExclusions       = domains.DataRestorationErrorExclusions
+	TablesDataRestorationErrorExclusions = domains.TablesDataRestorationErrorExclusions
+	GlobalDataRestorationErrorExclusions = domains.GlobalDataRestorationErrorExclusions
+)
+
+// Validate, export, subset explain and replicate sections
+type (
+	ValidateConfig      = domains.Validate
+	KAnonymity          = domains.KAnonymity
+	KAnonymityTable     = domains.KAnonymityTable
+	ExportConfig        = domains.Export
+	SubsetExplainConfig = domains.SubsetExplain
+	ReplicateConfig     = domains.Replicate
+)
@@ -0,0 +1,78 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greenmask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	mysqlCmd "github.com/greenmaskio/greenmask/internal/db/mysql/cmd"
+	cmdInternals "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/storages/builder"
+)
+
+// Dump - makes the dump of the database configured in cfg.Dump and stores it in the storage. It returns the ID of
+// the created dump. The cluster dump is made if cfg.Dump.Cluster is set. The dump is interrupted when ctx is
+// cancelled
+func Dump(ctx context.Context, cfg *Config, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	if cfg.Common.TempDirectory == "" {
+		return "", errors.New("common.tmp_dir cannot be empty")
+	}
+
+	st, err := builder.GetStorage(ctx, &cfg.Storage, &cfg.Log)
+	if err != nil {
+		return "", fmt.Errorf("error building storage: %w", err)
+	}
+	dumpId := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	st = st.SubStorage(dumpId, true)
+
+	switch cfg.Dump.Engine {
+	case "", domains.PostgresEngine:
+	case domains.MysqlEngine:
+		if cfg.Dump.Cluster != nil {
+			return "", errors.New("cluster mode is not supported for MySQL")
+		}
+		d := mysqlCmd.NewDump(cfg, st, utils.DefaultTransformerRegistry)
+		d.SetProgressFunc(o.progress)
+		if err := d.Run(ctx); err != nil {
+			return "", fmt.Errorf("cannot make a backup: %w", err)
+		}
+		return dumpId, nil
+	default:
+		return "", fmt.Errorf("unknown engine %s: expected postgres or mysql", cfg.Dump.Engine)
+	}
+
+	if cfg.Dump.Cluster != nil {
+		d := cmdInternals.NewClusterDump(cfg, st, utils.DefaultTransformerRegistry)
+		d.SetProgressFunc(o.progress)
+		if err := d.Run(ctx); err != nil {
+			return "", fmt.Errorf("cannot make a cluster backup: %w", err)
+		}
+		return dumpId, nil
+	}
+
+	d := cmdInternals.NewDump(cfg, st, utils.DefaultTransformerRegistry)
+	d.SetProgressFunc(o.progress)
+	if err := d.Run(ctx); err != nil {
+		return "", fmt.Errorf("cannot make a backup: %w", err)
+	}
+	return dumpId, nil
+}
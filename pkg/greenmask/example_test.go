@@ -0,0 +1,73 @@
+package greenmask_test
+
+import (
+	"fmt"
+
+	"github.com/greenmaskio/greenmask/pkg/greenmask"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// The config is built with the types of the greenmask and toolkit packages only
+func ExampleNewConfig() {
+	cfg := greenmask.NewConfig()
+	cfg.Common.PgBinPath = "/usr/lib/postgresql/16/bin"
+	cfg.Storage.Directory.Path = "/var/lib/greenmask/dumps"
+	cfg.Dump.PgDumpOptions.DbName = "host=localhost user=postgres dbname=demo"
+	cfg.Dump.Transformation = []*greenmask.Table{
+		{
+			Schema:      "public",
+			Name:        "users",
+			SubsetConds: []string{"public.users.id < 1000"},
+			OnError:     &greenmask.OnError{Policy: "set_null"},
+			Transformers: []*greenmask.TransformerConfig{
+				{
+					Name: "RandomEmail",
+					Params: toolkit.StaticParameters{
+						"column": toolkit.ParamsValue("email"),
+					},
+				},
+				{
+					Name: "RandomDate",
+					Params: toolkit.StaticParameters{
+						"column": toolkit.ParamsValue("birth_date"),
+					},
+					DynamicParams: toolkit.DynamicParameters{
+						"max": &toolkit.DynamicParamValue{Column: "created_at"},
+					},
+				},
+			},
+		},
+	}
+	cfg.Dump.VirtualReferences = []*greenmask.VirtualReference{
+		{
+			Schema: "public",
+			Name:   "orders",
+			References: []*greenmask.Reference{
+				{
+					Schema:  "public",
+					Name:    "users",
+					Columns: []*greenmask.ReferencedColumn{{Name: "user_id"}},
+				},
+			},
+		},
+	}
+	cfg.Dump.Subset = &greenmask.Subset{Materialize: true}
+	cfg.Restore.SchemaTolerance = &greenmask.SchemaTolerance{
+		Tables: []*greenmask.SchemaToleranceTable{
+			{
+				Schema:  "public",
+				Name:    "users",
+				Columns: []*greenmask.SchemaToleranceColumn{{Name: "tenant_id", Expression: "1"}},
+			},
+		},
+	}
+
+	for _, t := range cfg.Dump.Transformation {
+		for _, tr := range t.Transformers {
+			fmt.Printf("%s.%s: %s(%s)\n", t.Schema, t.Name, tr.Name, tr.Params["column"])
+		}
+	}
+	// Output:
+	// public.users: RandomEmail(email)
+	// public.users: RandomDate(birth_date)
+}
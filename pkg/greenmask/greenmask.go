@@ -0,0 +1,125 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package greenmask - the API for embedding greenmask into the Go applications. It runs the dump, restore,
+// validation and listing of the dumps the same way as the greenmask utility does, and allows registering the
+// transformers implemented in Go before running them
+package greenmask
+
+import (
+	cmdInternals "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgdump"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/pgrestore"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/storage"
+	"github.com/greenmaskio/greenmask/internal/domains"
+	"github.com/greenmaskio/greenmask/internal/storages/directory"
+	"github.com/greenmaskio/greenmask/internal/storages/s3"
+)
+
+const (
+	PostgresEngine = domains.PostgresEngine
+	MysqlEngine    = domains.MysqlEngine
+)
+
+const (
+	SchemaProgressStage    = cmdInternals.SchemaProgressStage
+	PreDataProgressStage   = cmdInternals.PreDataProgressStage
+	DataProgressStage      = cmdInternals.DataProgressStage
+	PostDataProgressStage  = cmdInternals.PostDataProgressStage
+	CompletedProgressStage = cmdInternals.CompletedProgressStage
+)
+
+// Config - the greenmask configuration. It has the same structure as the config file
+type Config = domains.Config
+
+type (
+	Progress      = cmdInternals.Progress
+	ProgressStage = cmdInternals.ProgressStage
+	ProgressFunc  = cmdInternals.ProgressFunc
+)
+
+type (
+	Metadata        = storage.Metadata
+	ClusterMetadata = storage.ClusterMetadata
+)
+
+// NewConfig - creates the config with the same default values as the greenmask utility flags have. Unlike the
+// config of the utility, each call returns the new config, so several configs can be used concurrently
+func NewConfig() *Config {
+	return &Config{
+		Common: domains.Common{
+			TempDirectory: "/tmp",
+		},
+		Log: domains.LogConfig{
+			Format: "text",
+			Level:  "info",
+		},
+		Storage: domains.StorageConfig{
+			Type:      "directory",
+			S3:        s3.NewConfig(),
+			Directory: directory.NewConfig(),
+		},
+		Dump: domains.Dump{
+			Engine: domains.PostgresEngine,
+			PgDumpOptions: pgdump.Options{
+				Jobs:            1,
+				Compression:     -1,
+				LockWaitTimeout: -1,
+				Blobs:           true,
+				DbName:          "postgres",
+				Host:            "/var/run/postgres",
+				Port:            5432,
+				UserName:        "postgres",
+			},
+		},
+		Restore: domains.Restore{
+			PgRestoreOptions: pgrestore.Options{
+				Jobs:       1,
+				ListFormat: "text",
+				DbName:     "postgres",
+				Host:       "/var/run/postgres",
+				Port:       5432,
+				UserName:   "postgres",
+			},
+		},
+		Validate: domains.Validate{
+			RowsLimit:   10,
+			Format:      cmdInternals.TextFormat,
+			TableFormat: cmdInternals.VerticalTableFormat,
+		},
+	}
+}
+
+// Option - the option of Dump and Restore
+type Option func(o *options)
+
+type options struct {
+	progress ProgressFunc
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithProgress - sets the callback that is called on each stage and each dumped or restored data object. The
+// callback may be called concurrently from several workers
+func WithProgress(f ProgressFunc) Option {
+	return func(o *options) {
+		o.progress = f
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greenmask
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cmdInternals "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
+	storageDto "github.com/greenmaskio/greenmask/internal/db/postgres/storage"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+func writeTestDump(t *testing.T, dir, dumpId, heartBeat string, md *Metadata) {
+	dumpDir := path.Join(dir, dumpId)
+	require.NoError(t, os.MkdirAll(dumpDir, 0700))
+	require.NoError(t, os.WriteFile(path.Join(dumpDir, cmdInternals.HeartBeatFileName), []byte(heartBeat), 0600))
+	if md == nil {
+		return
+	}
+	data, err := json.Marshal(md)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path.Join(dumpDir, cmdInternals.MetadataJsonFileName), data, 0600))
+}
+
+func writeTestClusterDump(t *testing.T, dir, dumpId, heartBeat string, md *ClusterMetadata) {
+	dumpDir := path.Join(dir, dumpId)
+	require.NoError(t, os.MkdirAll(dumpDir, 0700))
+	require.NoError(t, os.WriteFile(path.Join(dumpDir, cmdInternals.HeartBeatFileName), []byte(heartBeat), 0600))
+	data, err := json.Marshal(md)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path.Join(dumpDir, cmdInternals.ClusterMetadataJsonFileName), data, 0600))
+}
+
+func newTestConfig(t *testing.T) *Config {
+	cfg := NewConfig()
+	cfg.Storage.Directory.Path = t.TempDir()
+	return cfg
+}
+
+func TestNewConfig(t *testing.T) {
+	a := NewConfig()
+	b := NewConfig()
+	assert.NotSame(t, a, b)
+	assert.Equal(t, 1, a.Dump.PgDumpOptions.Jobs)
+	assert.Equal(t, -1, a.Dump.PgDumpOptions.Compression)
+	assert.Equal(t, 1, a.Restore.PgRestoreOptions.Jobs)
+	assert.Equal(t, PostgresEngine, a.Dump.Engine)
+}
+
+func TestListDumps(t *testing.T) {
+	cfg := newTestConfig(t)
+	writeTestDump(t, cfg.Storage.Directory.Path, "100", cmdInternals.HeartBeatDoneContent, &Metadata{
+		OriginalSize: 10,
+	})
+	writeTestDump(t, cfg.Storage.Directory.Path, "200", cmdInternals.HeartBeatInProgressContent, nil)
+	writeTestClusterDump(t, cfg.Storage.Directory.Path, "150", cmdInternals.HeartBeatDoneContent, &ClusterMetadata{
+		Databases: []*storageDto.ClusterDatabase{{Name: "billing"}, {Name: "app"}},
+	})
+	writeTestDump(t, cfg.Storage.Directory.Path, "150/billing", cmdInternals.HeartBeatDoneContent, &Metadata{
+		OriginalSize: 20,
+	})
+	writeTestDump(t, cfg.Storage.Directory.Path, "150/app", cmdInternals.HeartBeatDoneContent, &Metadata{
+		OriginalSize: 30,
+	})
+
+	dumps, err := ListDumps(context.Background(), cfg)
+	require.NoError(t, err)
+	require.Len(t, dumps, 3)
+
+	assert.Equal(t, "200", dumps[0].Id)
+	assert.Equal(t, InProgressStatus, dumps[0].Status)
+	assert.Nil(t, dumps[0].Metadata)
+	assert.False(t, dumps[0].IsCluster())
+
+	// The databases of the cluster dump keep the order of the cluster metadata
+	assert.Equal(t, "150", dumps[1].Id)
+	assert.Equal(t, DoneStatus, dumps[1].Status)
+	assert.True(t, dumps[1].IsCluster())
+	require.Len(t, dumps[1].Databases, 2)
+	assert.Equal(t, "150/billing", dumps[1].Databases[0].Id)
+	assert.Equal(t, int64(20), dumps[1].Databases[0].Metadata.OriginalSize)
+	assert.Equal(t, "150/app", dumps[1].Databases[1].Id)
+	assert.Equal(t, int64(30), dumps[1].Databases[1].Metadata.OriginalSize)
+
+	assert.Equal(t, "100", dumps[2].Id)
+	assert.Equal(t, DoneStatus, dumps[2].Status)
+	require.NotNil(t, dumps[2].Metadata)
+	assert.Equal(t, int64(10), dumps[2].Metadata.OriginalSize)
+}
+
+func TestRestore_dumpIsNotFound(t *testing.T) {
+	cfg := newTestConfig(t)
+	err := Restore(context.Background(), cfg, "100")
+	require.ErrorContains(t, err, "dump with id 100 is not found")
+
+	err = Restore(context.Background(), cfg, LatestDumpId)
+	require.ErrorContains(t, err, "no dumps found")
+}
+
+func TestRestore_clusterOptionsOnDatabaseDump(t *testing.T) {
+	cfg := newTestConfig(t)
+	writeTestDump(t, cfg.Storage.Directory.Path, "100", cmdInternals.HeartBeatDoneContent, &Metadata{})
+
+	cfg.Restore.Cluster.NoGlobals = true
+	err := Restore(context.Background(), cfg, "100")
+	require.ErrorContains(t, err, "dump 100 is not a cluster dump")
+
+	cfg.Restore.Cluster.NoGlobals = false
+	cfg.Restore.Cluster.Databases = []string{"app"}
+	err = Restore(context.Background(), cfg, LatestDumpId)
+	require.ErrorContains(t, err, "dump 100 is not a cluster dump")
+}
+
+func TestRegisterTransformer(t *testing.T) {
+	def := toolkit.NewTransformerDefinition(
+		"TestRegisterTransformer",
+		func(ctx context.Context, driver *toolkit.Driver, parameters map[string]toolkit.Parameterizer) (
+			toolkit.Transformer, toolkit.ValidationWarnings, error,
+		) {
+			return nil, nil, nil
+		},
+	)
+	require.NoError(t, RegisterTransformer(def))
+	registered, ok := utils.DefaultTransformerRegistry.Get("TestRegisterTransformer")
+	require.True(t, ok)
+	assert.True(t, registered.Properties.IsCustom)
+
+	require.Error(t, RegisterTransformer(def))
+	require.Error(t, RegisterTransformer(toolkit.NewTransformerDefinition("", nil)))
+}
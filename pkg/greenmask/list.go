@@ -0,0 +1,172 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greenmask
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	cmdInternals "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
+	"github.com/greenmaskio/greenmask/internal/storages"
+	"github.com/greenmaskio/greenmask/internal/storages/builder"
+	"github.com/greenmaskio/greenmask/internal/utils/dumpstatus"
+)
+
+// LatestDumpId - the dump ID that is resolved to the latest dump in the storage
+const LatestDumpId = "latest"
+
+const (
+	DoneStatus            = dumpstatus.DoneStatusName
+	InProgressStatus      = dumpstatus.InProgressStatusName
+	FailedStatus          = dumpstatus.FailedStatusName
+	UnknownOrFailedStatus = dumpstatus.UnknownOrFailedStatusName
+)
+
+// DumpInfo - the dump in the storage
+type DumpInfo struct {
+	// Id - the dump ID. The databases of the cluster dump have ID in the form dumpId/database
+	Id string
+	// Status - one of DoneStatus, InProgressStatus, FailedStatus and UnknownOrFailedStatus
+	Status string
+	// Metadata - the metadata of the database dump. It is nil if the dump is not done
+	Metadata *Metadata
+	// ClusterMetadata - the metadata of the cluster dump. It is nil for the database dump
+	ClusterMetadata *ClusterMetadata
+	// Databases - the database dumps of the cluster dump
+	Databases []*DumpInfo
+}
+
+// IsCluster - checks that the dump is the cluster dump
+func (di *DumpInfo) IsCluster() bool {
+	return di.ClusterMetadata != nil
+}
+
+// ListDumps - lists the dumps in the storage sorted by ID from the latest to the oldest. The dumps which status
+// cannot be received are skipped with the warning
+func ListDumps(ctx context.Context, cfg *Config) ([]*DumpInfo, error) {
+	st, err := builder.GetStorage(ctx, &cfg.Storage, &cfg.Log)
+	if err != nil {
+		return nil, fmt.Errorf("error building storage: %w", err)
+	}
+
+	_, dirs, err := st.ListDir(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot walk through directory: %w", err)
+	}
+
+	var res []*DumpInfo
+	for _, dir := range dirs {
+		info, err := getDumpInfo(ctx, dir, dir.Dirname())
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("DumpId", dir.Dirname()).
+				Msg("unable to get dump info")
+			continue
+		}
+		res = append(res, info)
+	}
+
+	slices.SortFunc(res, func(a, b *DumpInfo) int {
+		return strings.Compare(b.Id, a.Id)
+	})
+	return res, nil
+}
+
+func getDumpInfo(ctx context.Context, st storages.Storager, dumpId string) (*DumpInfo, error) {
+	isCluster, err := cmdInternals.IsClusterDump(ctx, st)
+	if err != nil {
+		return nil, fmt.Errorf("unable to check cluster dump: %w", err)
+	}
+	if !isCluster {
+		status, metadata, err := dumpstatus.GetDumpStatusAndMetadata(ctx, st)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status and metadata: %w", err)
+		}
+		return &DumpInfo{
+			Id:       dumpId,
+			Status:   status,
+			Metadata: metadata,
+		}, nil
+	}
+
+	status, clusterMetadata, err := dumpstatus.GetClusterDumpStatusAndMetadata(ctx, st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster status and metadata: %w", err)
+	}
+	info := &DumpInfo{
+		Id:              dumpId,
+		Status:          status,
+		ClusterMetadata: clusterMetadata,
+	}
+	for _, db := range clusterMetadata.Databases {
+		status, metadata, err := dumpstatus.GetDumpStatusAndMetadata(ctx, st.SubStorage(db.Name, true))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status and metadata of database %s: %w", db.Name, err)
+		}
+		info.Databases = append(info.Databases, &DumpInfo{
+			Id:       dumpId + "/" + db.Name,
+			Status:   status,
+			Metadata: metadata,
+		})
+	}
+	return info, nil
+}
+
+// resolveDumpId - returns the latest dump ID for LatestDumpId or checks that the dump with the ID exists
+func resolveDumpId(ctx context.Context, st storages.Storager, dumpId string) (string, error) {
+	if dumpId != LatestDumpId {
+		exists, err := st.Exists(ctx, path.Join(dumpId, cmdInternals.MetadataJsonFileName))
+		if err != nil {
+			return "", fmt.Errorf("cannot check file existence: %w", err)
+		}
+		clusterExists, err := st.Exists(ctx, path.Join(dumpId, cmdInternals.ClusterMetadataJsonFileName))
+		if err != nil {
+			return "", fmt.Errorf("cannot check file existence: %w", err)
+		}
+		if !exists && !clusterExists {
+			return "", fmt.Errorf("dump with id %s is not found", dumpId)
+		}
+		return dumpId, nil
+	}
+
+	_, dirs, err := st.ListDir(ctx)
+	if err != nil {
+		return "", fmt.Errorf("cannot walk through directory: %w", err)
+	}
+	var latest string
+	for _, dir := range dirs {
+		exists, err := dir.Exists(ctx, cmdInternals.MetadataJsonFileName)
+		if err != nil {
+			return "", fmt.Errorf("cannot check file existence: %w", err)
+		}
+		isCluster, err := cmdInternals.IsClusterDump(ctx, dir)
+		if err != nil {
+			return "", fmt.Errorf("cannot check file existence: %w", err)
+		}
+		if (exists || isCluster) && dir.Dirname() > latest {
+			latest = dir.Dirname()
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no dumps found in the storage")
+	}
+	return latest, nil
+}
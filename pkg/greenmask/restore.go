@@ -0,0 +1,83 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greenmask
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	mysqlCmd "github.com/greenmaskio/greenmask/internal/db/mysql/cmd"
+	cmdInternals "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
+	"github.com/greenmaskio/greenmask/internal/storages/builder"
+)
+
+// Restore - restores the dump with the ID or the latest dump if dumpId is LatestDumpId to the database configured
+// in cfg.Restore. The kind of the dump (cluster, MySQL or PostgreSQL) is detected by its metadata
+func Restore(ctx context.Context, cfg *Config, dumpId string, opts ...Option) error {
+	o := newOptions(opts)
+	st, err := builder.GetStorage(ctx, &cfg.Storage, &cfg.Log)
+	if err != nil {
+		return fmt.Errorf("error building storage: %w", err)
+	}
+
+	dumpId, err = resolveDumpId(ctx, st, dumpId)
+	if err != nil {
+		return err
+	}
+	st = st.SubStorage(dumpId, true)
+
+	isCluster, err := cmdInternals.IsClusterDump(ctx, st)
+	if err != nil {
+		return fmt.Errorf("cannot check file existence: %w", err)
+	}
+	if isCluster {
+		r := cmdInternals.NewClusterRestore(cfg.Common.PgBinPath, st, &cfg.Restore, cfg.Common.TempDirectory)
+		r.SetProgressFunc(o.progress)
+		log.Info().
+			Str("dumpId", dumpId).
+			Msgf("restoring cluster dump")
+		return r.Run(ctx)
+	}
+	if len(cfg.Restore.Cluster.Databases) > 0 || cfg.Restore.Cluster.NoGlobals {
+		return fmt.Errorf(
+			"dump %s is not a cluster dump: --cluster-database and --no-globals are applicable only to the cluster dump",
+			dumpId,
+		)
+	}
+
+	isMysql, err := mysqlCmd.IsMysqlDump(ctx, st)
+	if err != nil {
+		return fmt.Errorf("cannot read dump metadata: %w", err)
+	}
+	if isMysql {
+		r := mysqlCmd.NewRestore(st, &cfg.Restore)
+		r.SetProgressFunc(o.progress)
+		log.Info().
+			Str("dumpId", dumpId).
+			Msgf("restoring MySQL dump")
+		return r.Run(ctx)
+	}
+
+	r := cmdInternals.NewRestore(
+		cfg.Common.PgBinPath, st, &cfg.Restore, cfg.Restore.Scripts, cfg.Common.TempDirectory,
+	)
+	r.SetProgressFunc(o.progress)
+	log.Info().
+		Str("dumpId", dumpId).
+		Msgf("restoring dump")
+	return r.Run(ctx)
+}
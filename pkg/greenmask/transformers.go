@@ -0,0 +1,41 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greenmask
+
+import (
+	"fmt"
+
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/custom"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/pkg/toolkit"
+)
+
+// RegisterTransformer - registers the transformer implemented in Go. The transformer is created in the current
+// process and can be used in the transformation config by its name like the built-in transformers. It must be
+// called before Dump or Validate
+func RegisterTransformer(def *toolkit.TransformerDefinition) error {
+	td, err := custom.NewInProcessTransformerDefinition(def)
+	if err != nil {
+		return fmt.Errorf("invalid transformer definition: %w", err)
+	}
+	return utils.DefaultTransformerRegistry.Register(td)
+}
+
+// MustRegisterTransformer - the same as RegisterTransformer, but panics on error
+func MustRegisterTransformer(def *toolkit.TransformerDefinition) {
+	if err := RegisterTransformer(def); err != nil {
+		panic(err.Error())
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greenmask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	cmdInternals "github.com/greenmaskio/greenmask/internal/db/postgres/cmd"
+	"github.com/greenmaskio/greenmask/internal/db/postgres/transformers/utils"
+	"github.com/greenmaskio/greenmask/internal/storages/builder"
+)
+
+// Validate - runs the validation configured in cfg.Validate and prints the result to stdout. It returns the non
+// zero exit code if the validation has found the unresolved warnings
+func Validate(ctx context.Context, cfg *Config) (int, error) {
+	if cfg.Common.TempDirectory == "" {
+		return 0, errors.New("common.tmp_dir cannot be empty")
+	}
+	if cfg.Validate.RowsLimit <= 0 {
+		return 0, fmt.Errorf("rows limit must be greater than 0 got %d", cfg.Validate.RowsLimit)
+	}
+	if cfg.Validate.Format != cmdInternals.JsonFormat &&
+		cfg.Validate.Format != cmdInternals.TextFormat {
+		return 0, fmt.Errorf("unknown format value %s", cfg.Validate.Format)
+	}
+	if cfg.Validate.TableFormat != cmdInternals.VerticalTableFormat &&
+		cfg.Validate.TableFormat != cmdInternals.HorizontalTableFormat {
+		return 0, fmt.Errorf("unknown table format value %s", cfg.Validate.TableFormat)
+	}
+
+	st, err := builder.GetStorage(ctx, &cfg.Storage, &cfg.Log)
+	if err != nil {
+		return 0, fmt.Errorf("error building storage: %w", err)
+	}
+
+	v, err := cmdInternals.NewValidate(cfg, utils.DefaultTransformerRegistry, st)
+	if err != nil {
+		return 0, err
+	}
+	return v.Run(ctx)
+}
@@ -51,6 +51,11 @@ func NewWhenCond(when string, driver *Driver, meta map[string]any) (*WhenCond, V
 	}, nil
 }
 
+// IsEmpty - returns true if the when condition is not set, so it does not depend on the record values
+func (wc *WhenCond) IsEmpty() bool {
+	return wc == nil || wc.whenCond == nil
+}
+
 // Evaluate - evaluates when condition. If when condition is empty, it will always return true.
 func (wc *WhenCond) Evaluate(r *Record) (bool, error) {
 	if wc.whenCond == nil {
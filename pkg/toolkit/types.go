@@ -22,6 +22,10 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// PostgisTypeNames - PostGIS base types which text representation is the hex encoded EWKB. They are registered with
+// the text codec, so the driver decodes and encodes them as strings
+var PostgisTypeNames = []string{"geometry", "geography"}
+
 var (
 	KindOfType = map[rune]string{
 		'b': "Base",
@@ -121,6 +125,29 @@ func (t *Type) IsAffected(p *StaticParameter) (w ValidationWarnings) {
 }
 
 func TryRegisterCustomTypes(typeMap *pgtype.Map, types []*Type, silent bool) {
+	// PostGIS types are registered first because the domains might be based on them
+	for _, t := range types {
+		if t.Kind != 'b' || !slices.Contains(PostgisTypeNames, t.Name) {
+			continue
+		}
+		if _, ok := typeMap.TypeForOID(uint32(t.Oid)); ok {
+			continue
+		}
+		pgType := &pgtype.Type{
+			Name:  t.Name,
+			OID:   uint32(t.Oid),
+			Codec: &pgtype.TextCodec{},
+		}
+		typeMap.RegisterType(pgType)
+		if t.ArrayType != 0 {
+			typeMap.RegisterType(&pgtype.Type{
+				Name:  fmt.Sprintf("_%s", t.Name),
+				OID:   uint32(t.ArrayType),
+				Codec: &pgtype.ArrayCodec{ElementType: pgType},
+			})
+		}
+	}
+
 	for _, t := range types {
 		// Test is this type already registered
 		_, ok := typeMap.TypeForOID(uint32(t.Oid))
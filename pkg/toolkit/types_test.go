@@ -0,0 +1,59 @@
+// Copyright 2023 Greenmask
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolkit
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTryRegisterCustomTypes_postgis(t *testing.T) {
+	types := []*Type{
+		// The domain goes before its base type to check the registration order
+		{
+			Oid:        100003,
+			Name:       "location",
+			Kind:       'd',
+			BaseType:   100001,
+			ArrayType:  100004,
+			ChainOids:  []Oid{100001},
+			ChainNames: []string{"geometry"},
+		},
+		{Oid: 100001, Name: "geometry", Kind: 'b', ArrayType: 100002},
+		{Oid: 100005, Name: "geography", Kind: 'b'},
+		{Oid: 100006, Name: "box2d", Kind: 'b'},
+	}
+	tm := pgtype.NewMap()
+	TryRegisterCustomTypes(tm, types, true)
+
+	for _, name := range []string{"geometry", "_geometry", "geography", "location", "_location"} {
+		_, ok := tm.TypeForName(name)
+		assert.True(t, ok, name)
+	}
+	_, ok := tm.TypeForName("box2d")
+	assert.False(t, ok)
+
+	// The hex EWKB is decoded and encoded as is
+	const point = "0101000020E6100000000000000000F03F0000000000000040"
+	var res string
+	require.NoError(t, tm.Scan(100001, pgtype.TextFormatCode, []byte(point), &res))
+	assert.Equal(t, point, res)
+	data, err := tm.Encode(100003, pgtype.TextFormatCode, res, nil)
+	require.NoError(t, err)
+	assert.Equal(t, point, string(data))
+}